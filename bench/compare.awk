@@ -0,0 +1,47 @@
+# compare.awk reports the change in ns/op between a baseline and a current run of
+# `go test -bench` and exits non-zero when any benchmark slowed down by more than
+# threshold percent. Each benchmark's fastest run is compared, since repeated runs
+# (-count) only add noise above it.
+#
+# Usage: awk -v threshold=20 -f bench/compare.awk baseline.txt current.txt
+
+FNR == 1 { file++ }
+
+/^pkg: / { pkg = $2; sub(/.*\//, "", pkg) }
+
+/^Benchmark/ {
+	for (i = 3; i < NF; i++) {
+		if ($(i + 1) != "ns/op") continue
+		name = pkg "." $1
+		sub(/-[0-9]+$/, "", name)
+		if (file == 1) {
+			if (!(name in base) || $i < base[name]) base[name] = $i
+		} else {
+			if (!(name in cur)) order[++count] = name
+			if (!(name in cur) || $i < cur[name]) cur[name] = $i
+		}
+	}
+}
+
+END {
+	printf "%-60s %14s %14s %9s\n", "benchmark", "baseline ns/op", "current ns/op", "delta"
+	failed = 0
+	for (i = 1; i <= count; i++) {
+		name = order[i]
+		if (!(name in base)) {
+			printf "%-60s %14s %14.0f %9s\n", name, "-", cur[name], "new"
+			continue
+		}
+		delta = (cur[name] - base[name]) * 100 / base[name]
+		flag = ""
+		if (delta > threshold) {
+			flag = "  REGRESSION"
+			failed = 1
+		}
+		printf "%-60s %14.0f %14.0f %+8.1f%%%s\n", name, base[name], cur[name], delta, flag
+	}
+	if (failed) {
+		printf "\nbenchmarks slowed down by more than %d%% against the baseline\n", threshold
+		exit 1
+	}
+}
@@ -1,18 +1,42 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
 	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gomcpgo/mcp/pkg/handler"
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/gomcpgo/mcp/pkg/server"
+	"github.com/prasanthmj/perplexity/pkg/cache"
 	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/credentials"
+	"github.com/prasanthmj/perplexity/pkg/daemon"
+	"github.com/prasanthmj/perplexity/pkg/eval"
+	"github.com/prasanthmj/perplexity/pkg/grpcserver"
 	mcpHandler "github.com/prasanthmj/perplexity/pkg/handler"
+	"github.com/prasanthmj/perplexity/pkg/httpserver"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/notify"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
 	"github.com/prasanthmj/perplexity/pkg/search"
+	"github.com/prasanthmj/perplexity/pkg/stats"
+	"github.com/prasanthmj/perplexity/pkg/vault"
 	"github.com/prasanthmj/perplexity/test"
 )
 
@@ -27,14 +51,150 @@ func main() {
 		listPrevious    = flag.Bool("list", false, "List previous cached queries")
 		getResult       = flag.String("get", "", "Get cached result by ID: ./perplexity -get 'ABC123XYZ0'")
 		model           = flag.String("model", "", "Model to use (sonar, sonar-pro)")
-		debugMode       = flag.Bool("debug", false, "Enable debug mode")
+		verifyAudit     = flag.Bool("verify-audit", false, "Verify the audit hash chain of cached results")
+		migrateCache    = flag.Bool("cache-migrate", false, "Upgrade cached results in place to the current metadata schema")
+		exportCache     = flag.String("cache-export", "", "Bundle cached results into a tar.gz: ./perplexity -cache-export research.tar.gz [-ids A1B2C3D4E5,...] [-tags nvidia,...]")
+		importCache     = flag.String("cache-import", "", "Unpack a bundle made by -cache-export into the cache: ./perplexity -cache-import research.tar.gz")
+		exportIDs       = flag.String("ids", "", "Comma-separated result IDs to include with -cache-export")
+		exportTags      = flag.String("tags", "", "Comma-separated keywords or entities to include with -cache-export")
+		sendDigest      = flag.String("send-digest", "", "Email a digest of the daily or weekly watches in PERPLEXITY_WATCHES_FILE: ./perplexity -send-digest daily|weekly")
+		releaseWatch    = flag.String("release-watch", "", "Check projects for new releases, e.g. from cron: ./perplexity -release-watch 'kubernetes,golang/go'")
+		exportVault     = flag.String("export-vault", "", "Export cached results as an Obsidian/Logseq vault: ./perplexity -export-vault ~/notes/perplexity")
+		preflight       = flag.Bool("preflight", false, "Verify the API key and cache folder before serving, and print a readiness summary to stderr")
+		serveUI         = flag.Bool("ui", false, "Serve a web UI for browsing cached results at /ui/ on PERPLEXITY_HTTP_ADDR")
+		serveREST       = flag.Bool("rest", false, "Serve the tools as a REST API at /v1/ on PERPLEXITY_HTTP_ADDR")
+		evalSpec        = flag.String("eval", "", "Run the fixture queries of an eval spec through two prompt or argument variants and print a diff report: ./perplexity -eval prompts.json")
+		daemonMode      = flag.Bool("daemon", false, "Serve the HTTP endpoints without stdio until stopped, for running under systemd or as a Windows service")
+		pidfile         = flag.String("pidfile", "", "Write the process ID to this file in -daemon mode")
+		serviceAction   = flag.String("service", "", "Install or uninstall the Windows service running -daemon with the other flags given: ./perplexity -service install -rest")
+		usageStats      = flag.Bool("stats", false, "Print a summary of the past week's usage from PERPLEXITY_USAGE_STATS_FILE")
+		exportStats     = flag.String("stats-export", "", "Write the recorded usage stats to a JSON file: ./perplexity -stats-export usage.json")
+		envFile         = flag.String("env-file", "", "Read settings from a file of KEY=VALUE lines; variables set in the environment take precedence")
+		debugMode       = flag.Bool("debug", false, "Enable debug mode (debug-level logging)")
 	)
 	flag.Parse()
 
+	// The credential store is managed before the configuration is loaded, since it may be
+	// where the API key comes from
+	if flag.Arg(0) == "credentials" {
+		if err := runCredentials(flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Load configuration
+	config.UseEnvFile(*envFile)
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Logs go to stderr, or PERPLEXITY_LOG_FILE; stdout carries the MCP stdio protocol
+	if *debugMode {
+		cfg.LogLevel = config.LogLevelDebug
+	}
+	var logFile *daemon.LogFile
+	if cfg.LogFile != "" {
+		if logFile, err = daemon.OpenLogFile(cfg.LogFile); err != nil {
+			slog.Error("failed to open log file", "error", err)
+			os.Exit(1)
+		}
+	}
+	slog.SetDefault(logging.New(logFile, cfg.LogLevel, cfg.LogFormat))
+
+	// Results roots become key prefixes in the bucket when the cache lives in object storage
+	if cfg.CacheBackend == config.CacheBackendS3 {
+		cache.UseBackend(cache.S3(cache.S3Options{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			SessionToken:    cfg.S3.SessionToken,
+		}))
+	}
+
+	// Audit chain verification
+	if *verifyAudit {
+		if err := runVerifyAudit(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Cache schema migration
+	if *migrateCache {
+		if err := runCacheMigrate(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Cache bundles
+	if *exportCache != "" {
+		selection := cache.BundleSelection{IDs: splitList(*exportIDs), Tags: splitList(*exportTags)}
+		if err := runCacheExport(cfg, *exportCache, selection); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *importCache != "" {
+		if err := runCacheImport(cfg, *importCache); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Digest email
+	if *sendDigest != "" {
+		if err := runSendDigest(cfg, *sendDigest); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Release watch
+	if *releaseWatch != "" {
+		if err := runReleaseWatch(cfg, *releaseWatch); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Knowledge-base export
+	if *exportVault != "" {
+		if err := runExportVault(cfg, *exportVault); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Prompt A/B evaluation
+	if *evalSpec != "" {
+		if err := runEval(cfg, *evalSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Local usage statistics
+	if *usageStats || *exportStats != "" {
+		if err := runUsageStats(cfg, *exportStats); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	// Terminal mode operations for testing
@@ -53,10 +213,56 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Startup checks, reported on stderr because stdout carries the MCP protocol
+	if *preflight {
+		if err := runPreflight(cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Windows service registration
+	if *serviceAction != "" {
+		if err := runServiceAction(*serviceAction); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if (*serveUI || *serveREST || *daemonMode) && cfg.HTTPAddr == "" {
+		fmt.Fprintf(os.Stderr, "Error: -ui, -rest, and -daemon require PERPLEXITY_HTTP_ADDR\n")
+		os.Exit(1)
+	}
+	// An open REST API runs every tool for anyone who can reach it
+	if *serveREST && len(cfg.Clients) == 0 && !httpserver.IsLoopbackAddr(cfg.HTTPAddr) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", httpserver.ErrOpenREST)
+		os.Exit(1)
+	}
+	// An open UI shows every cached result to anyone who can reach it
+	if *serveUI && len(cfg.Clients) == 0 && !httpserver.IsLoopbackAddr(cfg.HTTPAddr) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", httpserver.ErrOpenUI)
+		os.Exit(1)
+	}
+	if cfg.GRPCAddr != "" && len(cfg.Clients) == 0 && !httpserver.IsLoopbackAddr(cfg.GRPCAddr) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", grpcserver.ErrOpenGRPC)
+		os.Exit(1)
+	}
+
+	// Daemon mode, serving HTTP only
+	if *daemonMode {
+		if err := runDaemon(cfg, logFile, *serveUI, *serveREST, *pidfile); err != nil {
+			slog.Error("daemon stopped", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// MCP Server mode (default)
-	err = runMCPServer(cfg)
+	err = runMCPServer(cfg, logFile, *serveUI, *serveREST)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("server stopped", "error", err)
+		os.Exit(1)
 	}
 }
 
@@ -69,10 +275,11 @@ func runTerminalMode(cfg *config.Config, searchQuery, academicQuery, financialQu
 	if err != nil {
 		return fmt.Errorf("failed to create searcher: %w", err)
 	}
+	defer searcher.Wait()
 
 	// Handle list previous queries
 	if listPrevious {
-		result, err := searcher.ListPrevious(ctx)
+		result, err := searcher.ListPrevious(ctx, "", cache.QueryFilter{})
 		if err != nil {
 			return fmt.Errorf("failed to list previous queries: %w", err)
 		}
@@ -149,17 +356,311 @@ func runTerminalMode(cfg *config.Config, searchQuery, academicQuery, financialQu
 	return nil
 }
 
-// runMCPServer starts the MCP server
-func runMCPServer(cfg *config.Config) error {
-	// Create handler
-	h, err := mcpHandler.NewHandler(cfg, false)
+// runVerifyAudit checks the audit hash chain and fails if any entry has been tampered with
+func runVerifyAudit(cfg *config.Config) error {
+	if !cache.IsCachingEnabled(cfg.ResultsRootFolder) {
+		return fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable")
+	}
+
+	report, err := cache.VerifyAuditChain(cfg.ResultsRootFolder)
 	if err != nil {
-		return fmt.Errorf("failed to create handler: %w", err)
+		return fmt.Errorf("failed to verify audit chain: %w", err)
 	}
 
+	fmt.Printf("Audit chain entries checked: %d\n", report.Entries)
+	if len(report.Problems) > 0 {
+		for _, problem := range report.Problems {
+			fmt.Printf("- %s\n", problem)
+		}
+		return fmt.Errorf("audit chain verification found %d problem(s)", len(report.Problems))
+	}
+
+	fmt.Println("Audit chain intact")
+	return nil
+}
+
+// runCacheMigrate upgrades old result folders to the current metadata schema
+func runCacheMigrate(cfg *config.Config) error {
+	if !cache.IsCachingEnabled(cfg.ResultsRootFolder) {
+		return fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable")
+	}
+
+	report, err := search.MigrateCache(cfg.ResultsRootFolder)
+	if err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+
+	fmt.Printf("Results checked: %d, migrated to schema version %d: %d\n", report.Scanned, cache.SchemaVersion, report.Migrated)
+	if len(report.Problems) > 0 {
+		for _, problem := range report.Problems {
+			fmt.Printf("- %s\n", problem)
+		}
+		return fmt.Errorf("cache migration failed for %d result(s)", len(report.Problems))
+	}
+	return nil
+}
+
+// runCacheExport writes the selected cached results to a tar.gz bundle at file
+func runCacheExport(cfg *config.Config, file string, selection cache.BundleSelection) error {
+	if !cache.IsCachingEnabled(cfg.ResultsRootFolder) {
+		return fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable")
+	}
+
+	// The bundle is written next to its destination and renamed, so a failed export leaves nothing behind
+	tmp := file + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	manifest, err := cache.Export(cfg.ResultsRootFolder, out, selection)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to export cache: %w", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	fmt.Printf("Exported %d result(s) to %s\n", len(manifest.Results), file)
+	return nil
+}
+
+// runCacheImport unpacks a bundle into the cache, chaining the new results when the audit chain is on
+func runCacheImport(cfg *config.Config, file string) error {
+	if !cache.IsCachingEnabled(cfg.ResultsRootFolder) {
+		return fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable")
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer in.Close()
+
+	report, err := cache.Import(cfg.ResultsRootFolder, in)
+	if err != nil {
+		return fmt.Errorf("failed to import cache: %w", err)
+	}
+	if cfg.AuditChain {
+		for _, id := range report.Imported {
+			if err := cache.AppendAuditChain(cfg.ResultsRootFolder, id); err != nil {
+				return fmt.Errorf("failed to append result %s to audit chain: %w", id, err)
+			}
+		}
+	}
+
+	fmt.Printf("Imported %d result(s) from %s\n", len(report.Imported), file)
+	if len(report.Skipped) > 0 {
+		fmt.Printf("Skipped %d result(s) already in the cache: %s\n", len(report.Skipped), strings.Join(report.Skipped, ", "))
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runSendDigest emails a digest of the period's watches to their recipients, printing the
+// digest of watches without any
+func runSendDigest(cfg *config.Config, period string) error {
+	now := time.Now()
+	since, err := search.DigestSince(period, now)
+	if err != nil {
+		return err
+	}
+
+	// Each watch goes to its own recipients, or to PERPLEXITY_DIGEST_TO, so recipients that
+	// share watches get one email with all of them
+	groups := map[string]map[string]config.WatchConfig{}
+	for name, watch := range cfg.Watches {
+		if watch.Period != period {
+			continue
+		}
+		recipients := watch.Recipients
+		if len(recipients) == 0 {
+			recipients = cfg.DigestTo
+		}
+		key := strings.Join(recipients, ",")
+		if groups[key] == nil {
+			groups[key] = map[string]config.WatchConfig{}
+		}
+		groups[key][name] = watch
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("no %s watches in PERPLEXITY_WATCHES_FILE", period)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entries, err := search.BuildWatchDigest(cfg.ResultsRootFolder, groups[key], since)
+		if err != nil {
+			return fmt.Errorf("failed to build digest: %w", err)
+		}
+		subject, body := search.FormatDigest(entries, period, since, now)
+
+		if key == "" {
+			fmt.Printf("Subject: %s\n\n%s\n", subject, body)
+			continue
+		}
+		recipients := strings.Split(key, ",")
+		mailer := notify.NewMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From, recipients)
+		if err := mailer.Send(subject, body); err != nil {
+			return err
+		}
+		fmt.Printf("Sent %s digest of %d watch(es) to %s\n", period, len(entries), strings.Join(recipients, ", "))
+	}
+	return nil
+}
+
+// runReleaseWatch checks each comma-separated project or owner/name repository for new releases.
+// Results are cached like any other search, so the digest reports which projects changed since the last run.
+func runReleaseWatch(cfg *config.Config, projects string) error {
+	searcher, err := search.NewSearcher(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create searcher: %w", err)
+	}
+	defer searcher.Wait()
+	ctx := ratelimit.WithPriority(context.Background(), ratelimit.PriorityWatch)
+
+	failed := 0
+	for _, entry := range strings.Split(projects, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		params := &search.SearchParams{SearchType: "release", Project: entry}
+		if strings.Contains(entry, "/") {
+			params.Project, params.Repo = "", entry
+		}
+		params.Query = search.ReleaseWatchQuery(params.Project, params.Repo)
+
+		result, err := searcher.ReleaseWatch(ctx, params)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error checking %s: %v\n", entry, err)
+			failed++
+			continue
+		}
+		fmt.Printf("=== %s ===\n%s\n\n", entry, result)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("release watch failed for %d project(s)", failed)
+	}
+	return nil
+}
+
+// runExportVault writes the cached results to dir as interlinked markdown notes
+func runExportVault(cfg *config.Config, dir string) error {
+	report, err := vault.Export(cfg.ResultsRootFolder, dir)
+	if err != nil {
+		return fmt.Errorf("failed to export vault: %w", err)
+	}
+
+	fmt.Printf("Exported %d result note(s), %d entity note(s) and %d keyword note(s) to %s\n", report.Results, report.Entities, report.Keywords, dir)
+	return nil
+}
+
+// runEval runs an eval spec's cases through one handler per variant and prints the report.
+// Caching and duplicate detection are off so every call reaches the API.
+func runEval(cfg *config.Config, path string) error {
+	spec, err := eval.LoadSpec(path)
+	if err != nil {
+		return err
+	}
+
+	variantCfg := *cfg
+	variantCfg.ResultsRootFolder = ""
+	variantCfg.DuplicateWindow = 0
+	variantCfg.SimilarityThreshold = 0
+	variantCfg.RedisURL = ""
+
+	var callers []eval.Caller
+	for _, variant := range []eval.Variant{spec.A, spec.B} {
+		h, err := mcpHandler.NewHandler(&variantCfg, false)
+		if err != nil {
+			return fmt.Errorf("failed to create handler for %s: %w", variant.Name, err)
+		}
+		h.SetPromptScaffold(variant.Prompt)
+		callers = append(callers, h)
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %d case(s) with %s and %s\n", len(spec.Cases), spec.A.Name, spec.B.Name)
+	results := eval.Run(context.Background(), spec, callers[0], callers[1])
+	fmt.Print(eval.Report(spec, results))
+	return nil
+}
+
+// runPreflight checks the configuration before the server starts and fails if any check does
+func runPreflight(cfg *config.Config) error {
+	searcher, err := search.NewSearcher(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create searcher: %w", err)
+	}
+
+	report := searcher.Preflight(context.Background())
+	fmt.Fprint(os.Stderr, report)
+	if !report.Ready() {
+		return fmt.Errorf("preflight failed")
+	}
+	return nil
+}
+
+// runMCPServer starts the MCP server, with the web UI and the REST API on the HTTP address
+// when ui and rest are set
+func runMCPServer(cfg *config.Config, logFile *daemon.LogFile, ui, rest bool) error {
+	stopStats, err := startStats(cfg)
+	if err != nil {
+		return err
+	}
+	defer stopStats()
+
+	reloader := newReloader(cfg)
+	watchReloads(reloader, logFile)
+
+	h, clients, err := newHandler(reloader, cfg)
+	if err != nil {
+		return err
+	}
+	// Citations still being archived when stdin closes are finished before exiting
+	defer h.Searcher().Wait()
+
 	// Create MCP server
 	registry := handler.NewHandlerRegistry()
 	registry.RegisterToolHandler(h)
+	registry.RegisterResourceHandler(h)
+
+	// Start HTTP endpoints alongside stdio if configured
+	if cfg.HTTPAddr != "" {
+		httpSrv := newHTTPServer(reloader, h, clients, ui, rest)
+		go func() {
+			if err := httpSrv.ListenAndServe(); err != nil {
+				slog.Error("HTTP server stopped", "addr", cfg.HTTPAddr, "error", err)
+			}
+		}()
+	}
+	if cfg.GRPCAddr != "" {
+		grpcSrv := grpcserver.NewServer(cfg, h.Searcher(), clients)
+		go func() {
+			if err := grpcSrv.ListenAndServe(); err != nil {
+				slog.Error("gRPC server stopped", "addr", cfg.GRPCAddr, "error", err)
+			}
+		}()
+	}
 
 	srv := server.New(server.Options{
 		Name:     "perplexity",
@@ -167,9 +668,283 @@ func runMCPServer(cfg *config.Config) error {
 		Registry: registry,
 	})
 
+	slog.Info("MCP server starting", "transport", "stdio", "http_addr", cfg.HTTPAddr, "grpc_addr", cfg.GRPCAddr)
 	return srv.Run()
 }
 
+// runDaemon serves the HTTP endpoints without stdio until it is stopped by SIGTERM, an
+// interrupt, or the Windows service manager. Readiness is reported to systemd once the
+// address is bound.
+func runDaemon(cfg *config.Config, logFile *daemon.LogFile, ui, rest bool, pidfile string) error {
+	if pidfile != "" {
+		remove, err := daemon.WritePidfile(pidfile)
+		if err != nil {
+			return err
+		}
+		defer remove()
+	}
+	stopStats, err := startStats(cfg)
+	if err != nil {
+		return err
+	}
+	defer stopStats()
+
+	run := func(stop <-chan struct{}) error {
+		reloader := newReloader(cfg)
+		watchReloads(reloader, logFile)
+		h, clients, err := newHandler(reloader, cfg)
+		if err != nil {
+			return err
+		}
+		// Runs after the servers shut down, finishing citations still being archived
+		defer h.Searcher().Wait()
+		httpSrv := newHTTPServer(reloader, h, clients, ui, rest)
+		ln, err := httpSrv.Listen()
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", cfg.HTTPAddr, err)
+		}
+		var grpcSrv *grpcserver.Server
+		var grpcLn net.Listener
+		if cfg.GRPCAddr != "" {
+			grpcSrv = grpcserver.NewServer(cfg, h.Searcher(), clients)
+			if grpcLn, err = grpcSrv.Listen(); err != nil {
+				ln.Close()
+				return fmt.Errorf("failed to listen on %s: %w", cfg.GRPCAddr, err)
+			}
+		}
+		served := make(chan error, 2)
+		go func() { served <- httpSrv.Serve(ln) }()
+		if grpcSrv != nil {
+			go func() { served <- grpcSrv.Serve(grpcLn) }()
+		}
+
+		if err := daemon.Notify(daemon.Ready); err != nil {
+			slog.Warn("failed to report readiness", "error", err)
+		}
+		slog.Info("daemon started", "http_addr", cfg.HTTPAddr, "grpc_addr", cfg.GRPCAddr, "pid", os.Getpid())
+
+		select {
+		case err := <-served:
+			return err
+		case <-stop:
+		}
+		daemon.Notify(daemon.Stopping)
+		slog.Info("daemon stopping")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if grpcSrv != nil {
+			grpcSrv.Shutdown(ctx)
+		}
+		return httpSrv.Shutdown(ctx)
+	}
+
+	if handled, err := daemon.RunService(run); handled || err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	return run(stop)
+}
+
+// startStats records usage in PERPLEXITY_USAGE_STATS_FILE, when it is set, while the server
+// runs. The returned function writes the counts not yet flushed.
+func startStats(cfg *config.Config) (func(), error) {
+	if cfg.UsageStatsFile == "" {
+		return func() {}, nil
+	}
+	recorder, err := stats.Open(cfg.UsageStatsFile, cfg.Location())
+	if err != nil {
+		return nil, err
+	}
+	stats.Default = recorder
+	return func() {
+		if err := recorder.Close(); err != nil {
+			slog.Error("failed to write usage stats", "error", err)
+		}
+	}, nil
+}
+
+// runUsageStats prints a summary of the past week's usage, or writes the recorded stats to
+// the export file when one is given
+func runUsageStats(cfg *config.Config, export string) error {
+	if cfg.UsageStatsFile == "" {
+		return fmt.Errorf("usage stats are not recorded; set PERPLEXITY_USAGE_STATS_FILE to turn them on")
+	}
+	file, err := stats.Load(cfg.UsageStatsFile)
+	if err != nil {
+		return err
+	}
+
+	if export != "" {
+		data, err := json.MarshalIndent(file, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(export, append(data, '\n'), 0600); err != nil {
+			return fmt.Errorf("failed to export usage stats: %w", err)
+		}
+		fmt.Printf("Exported %d day(s) of usage stats to %s\n", len(file.Days), export)
+		return nil
+	}
+
+	now := time.Now()
+	if loc := cfg.Location(); loc != nil {
+		now = now.In(loc)
+	}
+	stats.WriteSummary(os.Stdout, file, now, 7)
+	return nil
+}
+
+// runServiceAction installs or uninstalls the Windows service. The service runs -daemon with
+// the flags given along with -service.
+func runServiceAction(action string) error {
+	switch action {
+	case "install":
+		var args []string
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name != "service" && f.Name != "daemon" {
+				args = append(args, "-"+f.Name+"="+f.Value.String())
+			}
+		})
+		if err := daemon.InstallService(args); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Installed the %s service; start it with: sc.exe start %s\n", daemon.ServiceName, daemon.ServiceName)
+		return nil
+	case "uninstall":
+		return daemon.UninstallService()
+	default:
+		return fmt.Errorf("-service must be install or uninstall")
+	}
+}
+
+// runCredentials stores the API key in the OS credential store, read from stdin so it stays
+// out of the shell history, or deletes it
+func runCredentials(args []string) error {
+	action := ""
+	if len(args) == 1 {
+		action = args[0]
+	}
+	switch action {
+	case "set":
+		key, err := readSecret("Perplexity API key: ")
+		if err != nil {
+			return fmt.Errorf("failed to read the API key: %w", err)
+		}
+		if err := credentials.Set(key); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Stored the API key in the credential store; set PERPLEXITY_CREDENTIAL_STORE=true to use it")
+		return nil
+	case "delete":
+		if err := credentials.Delete(); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Deleted the API key from the credential store")
+		return nil
+	default:
+		return fmt.Errorf("usage: perplexity credentials set|delete")
+	}
+}
+
+// readSecret reads a line from stdin. At a terminal it prompts on stderr and, where stty is
+// available, hides what is typed.
+func readSecret(prompt string) (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, prompt)
+		if stty("-echo") == nil {
+			defer func() {
+				stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// stty changes the terminal settings of stdin
+func stty(setting string) error {
+	cmd := exec.Command("stty", setting)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// newReloader returns the configuration source of a long-running server, reloaded by
+// watchReloads. Logging was set up at startup, so its settings, including -debug, carry over.
+func newReloader(cfg *config.Config) *config.Reloader {
+	reloader := config.NewReloader(cfg, func() (*config.Config, error) {
+		next, err := config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		next.LogLevel, next.LogFormat, next.LogFile = cfg.LogLevel, cfg.LogFormat, cfg.LogFile
+		return next, nil
+	})
+	reloader.OnChange(func(prev, next *config.Config) {
+		if next.APIKey != prev.APIKey || next.RateLimit != prev.RateLimit || next.HTTPAddr != prev.HTTPAddr || next.GRPCAddr != prev.GRPCAddr || !maps.Equal(next.Clients, prev.Clients) ||
+			next.TLSCert != prev.TLSCert || next.TLSKey != prev.TLSKey || next.TLSClientCA != prev.TLSClientCA || next.UsageStatsFile != prev.UsageStatsFile {
+			slog.Warn("API key, rate limit, HTTP and gRPC address, client, TLS file, and usage stats file changes take effect after a restart")
+		}
+	})
+	return reloader
+}
+
+// watchReloads reopens the log file, so rotated logs go to a new file, and reloads the
+// configuration from the environment on each SIGHUP
+func watchReloads(reloader *config.Reloader, logFile *daemon.LogFile) {
+	reloads := make(chan os.Signal, 1)
+	signal.Notify(reloads, syscall.SIGHUP)
+	go func() {
+		for range reloads {
+			daemon.Notify(daemon.Reloading)
+			if err := logFile.Reopen(); err != nil {
+				slog.Error("failed to reopen log file", "error", err)
+			}
+			if err := reloader.Reload(); err != nil {
+				slog.Error("failed to reload configuration", "error", err)
+			} else {
+				slog.Info("configuration reloaded")
+			}
+			daemon.Notify(daemon.Ready)
+		}
+	}()
+}
+
+// newHandler creates the tool handler and the tracker of REST API clients, which it shares
+// with the usage report
+func newHandler(reloader *config.Reloader, cfg *config.Config) (*mcpHandler.Handler, *quota.Tracker, error) {
+	h, err := mcpHandler.NewHandler(reloader, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create handler: %w", err)
+	}
+	clients := quota.NewTracker(cfg.Clients, cfg.Location())
+	h.SetClients(clients)
+	return h, clients, nil
+}
+
+// newHTTPServer creates the HTTP endpoints, with the web UI and the REST API when ui and
+// rest are set
+func newHTTPServer(reloader *config.Reloader, h *mcpHandler.Handler, clients *quota.Tracker, ui, rest bool) *httpserver.Server {
+	httpSrv := httpserver.NewServer(reloader)
+	if ui {
+		httpSrv.EnableUI()
+	}
+	if rest {
+		httpSrv.EnableREST(h)
+	}
+	httpSrv.RequireClients(clients)
+	return httpSrv
+}
+
 // PerplexityMCPServer wraps the handler to implement the required interfaces
 type PerplexityMCPServer struct {
 	handler *mcpHandler.Handler
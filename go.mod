@@ -4,4 +4,15 @@ go 1.23.4
 
 require github.com/gomcpgo/mcp v0.1.1
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require (
+	google.golang.org/grpc v1.75.1
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+)
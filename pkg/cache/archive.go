@@ -0,0 +1,14 @@
+package cache
+
+// RecordArchivedURLs stores the Wayback Machine copies of a saved result's citations in its
+// metadata.yaml, keyed by the original URL
+func RecordArchivedURLs(rootFolder, uniqueID string, archived map[string]string) error {
+	store := storageFor(rootFolder)
+	metadata, err := readMetadata(store, uniqueID)
+	if err != nil {
+		return err
+	}
+
+	metadata.ArchivedURLs = archived
+	return writeMetadata(store, uniqueID, metadata)
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditHeadFile records the ID and hash of the most recent entry in the audit chain
+const auditHeadFile = "audit_head"
+
+// auditMu serializes chain appends so each entry links to the true previous one
+var auditMu sync.Mutex
+
+// AuditReport summarizes an audit chain verification
+type AuditReport struct {
+	Entries  int
+	Problems []string
+}
+
+// AppendAuditChain hashes a saved result together with the previous chain hash and
+// records both in its metadata.yaml, then advances the chain head
+func AppendAuditChain(rootFolder, uniqueID string) error {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	store := storageFor(rootFolder)
+	_, prevHash, err := readAuditHead(store)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := readMetadata(store, uniqueID)
+	if err != nil {
+		return err
+	}
+
+	metadata.PreviousHash = prevHash
+	metadata.AuditHash, err = auditHash(store, uniqueID, metadata)
+	if err != nil {
+		return err
+	}
+	if err := writeMetadata(store, uniqueID, metadata); err != nil {
+		return err
+	}
+
+	head := uniqueID + " " + metadata.AuditHash + "\n"
+	if err := store.WriteFile(auditHeadFile, []byte(head)); err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+	return nil
+}
+
+// VerifyAuditChain walks the chain back from its head, recomputing every hash, and
+// reports altered entries, missing links, and audited entries that are not on the chain
+func VerifyAuditChain(rootFolder string) (*AuditReport, error) {
+	store := storageFor(rootFolder)
+	headID, headHash, err := readAuditHead(store)
+	if err != nil {
+		return nil, err
+	}
+	report := &AuditReport{}
+	if headID == "" {
+		return report, nil
+	}
+
+	// Index every audited entry by its recorded hash
+	folders, err := store.Folders("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results directory: %w", err)
+	}
+	byHash := make(map[string]string)
+	for _, folder := range folders {
+		metadata, err := readMetadata(store, folder)
+		if err != nil || metadata.AuditHash == "" {
+			continue
+		}
+		byHash[metadata.AuditHash] = folder
+	}
+
+	visited := make(map[string]bool)
+	id, want := headID, headHash
+	for id != "" {
+		if visited[id] {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: chain loops back on itself", id))
+			break
+		}
+		visited[id] = true
+		report.Entries++
+
+		metadata, err := readMetadata(store, id)
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: %v", id, err))
+			break
+		}
+		got, err := auditHash(store, id, metadata)
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: %v", id, err))
+			break
+		}
+		if got != want || metadata.AuditHash != want {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: content does not match its recorded hash", id))
+		}
+
+		want = metadata.PreviousHash
+		if want == "" {
+			break
+		}
+		prevID, ok := byHash[want]
+		if !ok {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: previous entry with hash %s is missing", id, want))
+			break
+		}
+		id = prevID
+	}
+
+	for hash, entryID := range byHash {
+		if !visited[entryID] {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: audited entry (hash %s) is not on the chain", entryID, hash))
+		}
+	}
+
+	return report, nil
+}
+
+// auditHash computes the chain hash of an entry from its previous hash, metadata, and result
+func auditHash(store Storage, uniqueID string, metadata *QueryMetadata) (string, error) {
+	result, err := store.ReadFile(uniqueID + "/" + resultFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read result file: %w", err)
+	}
+	parameters, err := json.Marshal(metadata.Parameters)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal parameters: %w", err)
+	}
+	resultSum := sha256.Sum256(result)
+
+	fields := []string{
+		metadata.PreviousHash,
+		uniqueID,
+		metadata.Timestamp.UTC().Format(time.RFC3339Nano),
+		metadata.Query,
+		metadata.SearchType,
+		metadata.Model,
+		string(parameters),
+		hex.EncodeToString(resultSum[:]),
+	}
+	// Appended only when set, so entries chained before request IDs were recorded still verify
+	if metadata.RequestID != "" {
+		fields = append(fields, metadata.RequestID)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readAuditHead returns the ID and hash at the head of the chain, or empty strings if none
+func readAuditHead(store Storage) (string, string, error) {
+	data, err := store.ReadFile(auditHeadFile)
+	if isNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read audit head: %w", err)
+	}
+
+	parts := strings.Fields(string(data))
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("audit head file is malformed")
+	}
+	return parts[0], parts[1], nil
+}
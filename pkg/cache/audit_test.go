@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditChain(t *testing.T) {
+	root := t.TempDir()
+	params := map[string]interface{}{"max_tokens": 512, "search_domain_filter": []string{"example.com"}, "temperature": 0.2}
+
+	var ids []string
+	for _, query := range []string{"first query", "second query", "third query"} {
+		id, err := SaveResult(root, query, "general", "sonar", "Answer to "+query, params)
+		if err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+		if err := AppendAuditChain(root, id); err != nil {
+			t.Fatalf("AppendAuditChain failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	report, err := VerifyAuditChain(root)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed: %v", err)
+	}
+	if report.Entries != 3 || len(report.Problems) != 0 {
+		t.Fatalf("Report mismatch: got %d entries, problems %v", report.Entries, report.Problems)
+	}
+
+	first, err := readMetadata(Filesystem(root), ids[0])
+	if err != nil {
+		t.Fatalf("readMetadata failed: %v", err)
+	}
+	second, err := readMetadata(Filesystem(root), ids[1])
+	if err != nil {
+		t.Fatalf("readMetadata failed: %v", err)
+	}
+	if first.PreviousHash != "" || second.PreviousHash != first.AuditHash {
+		t.Errorf("Chain links mismatch: first previous %q, second previous %q, first hash %q",
+			first.PreviousHash, second.PreviousHash, first.AuditHash)
+	}
+
+	// Editing an answer after the fact must be detected
+	if err := os.WriteFile(filepath.Join(root, ids[1], resultFile), []byte("Rewritten answer"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	report, err = VerifyAuditChain(root)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed: %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.HasPrefix(report.Problems[0], ids[1]) {
+		t.Errorf("Expected one problem for %s, got %v", ids[1], report.Problems)
+	}
+}
+
+func TestAuditChainMissingEntry(t *testing.T) {
+	root := t.TempDir()
+
+	var ids []string
+	for _, query := range []string{"first query", "second query"} {
+		id, err := SaveResult(root, query, "general", "sonar", "Answer", nil)
+		if err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+		if err := AppendAuditChain(root, id); err != nil {
+			t.Fatalf("AppendAuditChain failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := os.RemoveAll(filepath.Join(root, ids[0])); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	report, err := VerifyAuditChain(root)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed: %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "is missing") {
+		t.Errorf("Expected missing entry problem, got %v", report.Problems)
+	}
+}
+
+func TestVerifyAuditChainEmpty(t *testing.T) {
+	report, err := VerifyAuditChain(t.TempDir())
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed: %v", err)
+	}
+	if report.Entries != 0 || len(report.Problems) != 0 {
+		t.Errorf("Expected empty report, got %+v", report)
+	}
+}
+
+func TestAuditChainCoversRequestID(t *testing.T) {
+	root := t.TempDir()
+	id, err := SaveResult(root, "query", "general", "sonar", "Answer", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	if err := RecordRequestID(root, id, "0123456789abcdef"); err != nil {
+		t.Fatalf("RecordRequestID failed: %v", err)
+	}
+	if err := AppendAuditChain(root, id); err != nil {
+		t.Fatalf("AppendAuditChain failed: %v", err)
+	}
+	if report, err := VerifyAuditChain(root); err != nil || len(report.Problems) != 0 {
+		t.Fatalf("Expected an intact chain, got %v, %v", report, err)
+	}
+
+	// Pointing the result at a different request must be detected
+	if err := RecordRequestID(root, id, "fedcba9876543210"); err != nil {
+		t.Fatalf("RecordRequestID failed: %v", err)
+	}
+	report, err := VerifyAuditChain(root)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain failed: %v", err)
+	}
+	if len(report.Problems) != 1 {
+		t.Errorf("Expected one problem, got %v", report.Problems)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// benchEntries is the size of the cache the list benchmarks scan
+const benchEntries = 10000
+
+var (
+	benchRootOnce sync.Once
+	benchRoot     string
+	benchRootErr  error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchRoot != "" {
+		os.RemoveAll(benchRoot)
+	}
+	os.Exit(code)
+}
+
+// populatedCache returns a cache root holding benchEntries tagged results. It is built once
+// and shared, since writing ten thousand results takes far longer than scanning them.
+func populatedCache(b *testing.B) string {
+	b.Helper()
+	benchRootOnce.Do(func() {
+		if benchRoot, benchRootErr = os.MkdirTemp("", "perplexity-cache-bench"); benchRootErr != nil {
+			return
+		}
+		for i := 0; i < benchEntries; i++ {
+			id, err := SaveResult(benchRoot, fmt.Sprintf("query number %d", i), "general", "sonar", "result", map[string]interface{}{"model": "sonar"})
+			if err != nil {
+				benchRootErr = err
+				return
+			}
+			if err := TagResult(benchRoot, id, []string{"battery", fmt.Sprintf("topic%d", i%50)}, []string{"Tesla"}); err != nil {
+				benchRootErr = err
+				return
+			}
+		}
+	})
+	if benchRootErr != nil {
+		b.Fatalf("failed to populate cache: %v", benchRootErr)
+	}
+	return benchRoot
+}
+
+func BenchmarkListPreviousQueries(b *testing.B) {
+	root := populatedCache(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		items, err := ListPreviousQueries(root)
+		if err != nil || len(items) != benchEntries {
+			b.Fatalf("ListPreviousQueries returned %d items, err %v", len(items), err)
+		}
+	}
+}
+
+func BenchmarkQueryFilterApply(b *testing.B) {
+	root := populatedCache(b)
+	items, err := ListPreviousQueries(root)
+	if err != nil {
+		b.Fatalf("ListPreviousQueries failed: %v", err)
+	}
+	filter := QueryFilter{Entity: "tesla", Keyword: "topic7"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filter.Apply(items)
+	}
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// bundleManifestFile is the first entry of every bundle
+	bundleManifestFile = "manifest.json"
+	// BundleFormat is the bundle layout written by Export
+	BundleFormat = 1
+	// maxBundleFileBytes bounds each file read from a bundle
+	maxBundleFileBytes = 64 << 20
+)
+
+// maxBundleBytes bounds the files of a whole bundle together, so an import cannot fill the
+// disk; a variable so that tests can lower it
+var maxBundleBytes int64 = 1 << 30
+
+// BundleManifest describes the results in a bundle
+type BundleManifest struct {
+	Format        int             `json:"format"`
+	CreatedAt     time.Time       `json:"created_at"`
+	SchemaVersion int             `json:"schema_version"`
+	Results       []QueryListItem `json:"results"`
+}
+
+// BundleSelection picks the results to export: those with one of the IDs, or tagged with
+// one of the tags as a keyword or entity. An empty selection exports every result.
+type BundleSelection struct {
+	IDs  []string
+	Tags []string
+}
+
+// matches reports whether the selection includes item
+func (s BundleSelection) matches(item QueryListItem) bool {
+	if len(s.IDs) == 0 && len(s.Tags) == 0 {
+		return true
+	}
+	for _, id := range s.IDs {
+		if strings.EqualFold(id, item.UniqueID) {
+			return true
+		}
+	}
+	for _, tag := range s.Tags {
+		if containsFold(item.Keywords, tag) || containsFold(item.Entities, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportReport lists what an import did with each result in a bundle
+type ImportReport struct {
+	Imported []string
+	Skipped  []string
+}
+
+// Export writes the selected results, with their images, to w as a tar.gz bundle whose first
+// entry is a manifest listing them
+func Export(rootFolder string, w io.Writer, selection BundleSelection) (*BundleManifest, error) {
+	items, err := ListPreviousQueries(rootFolder)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &BundleManifest{Format: BundleFormat, CreatedAt: time.Now().UTC(), SchemaVersion: SchemaVersion, Results: []QueryListItem{}}
+	for _, item := range items {
+		if selection.matches(item) {
+			manifest.Results = append(manifest.Results, item)
+		}
+	}
+	if len(manifest.Results) == 0 {
+		return nil, fmt.Errorf("no cached results match the selection")
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := writeBundleFile(tw, bundleManifestFile, manifestBytes, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	store := storageFor(rootFolder)
+	for _, item := range manifest.Results {
+		files, err := store.Files(item.UniqueID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add result %s: %w", item.UniqueID, err)
+		}
+		for _, name := range files {
+			data, err := store.ReadFile(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add result %s: %w", item.UniqueID, err)
+			}
+			if err := writeBundleFile(tw, name, data, item.DateTime); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// writeBundleFile adds one file to a bundle
+func writeBundleFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Import unpacks a bundle written by Export into rootFolder. Results whose ID already exists
+// are skipped, so importing the same bundle twice is harmless. Only results listed in the
+// manifest are imported, and entries that would land outside their result folder are
+// rejected. Audit chain fields are cleared, since they belong to the exporting cache's chain.
+func Import(rootFolder string, r io.Reader) (*ImportReport, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a results bundle: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	manifest, err := readBundleManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool, len(manifest.Results))
+	for _, item := range manifest.Results {
+		if !IsValidID(item.UniqueID) {
+			return nil, fmt.Errorf("manifest lists invalid result ID %q", item.UniqueID)
+		}
+		listed[item.UniqueID] = true
+	}
+
+	// Results are unpacked into a local staging folder and copied into place once the whole
+	// bundle has been checked, so a rejected bundle never touches the cache
+	stagingDir, err := os.MkdirTemp("", "perplexity-import-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging folder: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+	staging := Filesystem(stagingDir)
+
+	var total int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		id, _, _ := strings.Cut(name, "/")
+		if !listed[id] || name == id || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return nil, fmt.Errorf("bundle entry %q is not part of a listed result", header.Name)
+		}
+		if header.Size > maxBundleFileBytes {
+			return nil, fmt.Errorf("bundle entry %q is too large", header.Name)
+		}
+		if total += header.Size; total > maxBundleBytes {
+			return nil, fmt.Errorf("bundle is larger than %d MB unpacked", maxBundleBytes>>20)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxBundleFileBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %w", name, err)
+		}
+		if err := staging.WriteFile(name, data); err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %w", name, err)
+		}
+	}
+
+	store := storageFor(rootFolder)
+	report := &ImportReport{}
+	for _, item := range manifest.Results {
+		id := item.UniqueID
+		if idExists(store, id) {
+			report.Skipped = append(report.Skipped, id)
+			continue
+		}
+		metadata, err := readMetadata(staging, id)
+		if err != nil {
+			return report, fmt.Errorf("result %s in bundle: %w", id, err)
+		}
+		if metadata.PreviousHash != "" || metadata.AuditHash != "" {
+			metadata.PreviousHash, metadata.AuditHash = "", ""
+			if err := writeMetadata(staging, id, metadata); err != nil {
+				return report, err
+			}
+		}
+		if err := copyFolder(staging, store, id); err != nil {
+			return report, fmt.Errorf("failed to copy result %s into place: %w", id, err)
+		}
+		report.Imported = append(report.Imported, id)
+	}
+	return report, nil
+}
+
+// readBundleManifest reads the manifest, which must be the bundle's first entry
+func readBundleManifest(tr *tar.Reader) (*BundleManifest, error) {
+	header, err := tr.Next()
+	if err != nil || header.Name != bundleManifestFile {
+		return nil, fmt.Errorf("not a results bundle: %s must be its first entry", bundleManifestFile)
+	}
+	var manifest BundleManifest
+	if err := json.NewDecoder(io.LimitReader(tr, maxBundleFileBytes)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+	if manifest.Format != BundleFormat {
+		return nil, fmt.Errorf("unsupported bundle format %d", manifest.Format)
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	src := t.TempDir()
+	nvidia, err := SaveResult(src, "nvidia earnings", "financial", "sonar", "Nvidia reported record revenue.", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	if err := TagResult(src, nvidia, []string{"revenue"}, []string{"Nvidia"}); err != nil {
+		t.Fatalf("TagResult failed: %v", err)
+	}
+	if _, err := SaveImage(src, nvidia, "1.png", []byte("png data")); err != nil {
+		t.Fatalf("SaveImage failed: %v", err)
+	}
+	if err := AppendAuditChain(src, nvidia); err != nil {
+		t.Fatalf("AppendAuditChain failed: %v", err)
+	}
+	if _, err := SaveResult(src, "paris facts", "general", "sonar", "Paris is the capital.", nil); err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	var bundle bytes.Buffer
+	manifest, err := Export(src, &bundle, BundleSelection{Tags: []string{"nvidia"}})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(manifest.Results) != 1 || manifest.Results[0].UniqueID != nvidia {
+		t.Fatalf("Expected only the tagged result in the manifest, got %+v", manifest.Results)
+	}
+
+	dst := t.TempDir()
+	report, err := Import(dst, bytes.NewReader(bundle.Bytes()))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if len(report.Imported) != 1 || report.Imported[0] != nvidia {
+		t.Fatalf("Unexpected import report: %+v", report)
+	}
+
+	result, err := GetPreviousResult(dst, nvidia)
+	if err != nil || result != "Nvidia reported record revenue." {
+		t.Errorf("Expected the imported result, got %q, %v", result, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, nvidia, "images", "1.png")); err != nil || string(data) != "png data" {
+		t.Errorf("Expected the imported image, got %q, %v", data, err)
+	}
+	metadata, err := readMetadata(Filesystem(dst), nvidia)
+	if err != nil {
+		t.Fatalf("readMetadata failed: %v", err)
+	}
+	if metadata.AuditHash != "" || metadata.PreviousHash != "" || metadata.Entities[0] != "Nvidia" {
+		t.Errorf("Expected tags kept and audit fields cleared, got %+v", metadata)
+	}
+
+	// Importing again skips what is already there
+	report, err = Import(dst, bytes.NewReader(bundle.Bytes()))
+	if err != nil || len(report.Imported) != 0 || len(report.Skipped) != 1 {
+		t.Errorf("Expected the second import to skip the result, got %+v, %v", report, err)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 1 {
+		t.Errorf("Expected no staging folders left behind, got %d entries", len(entries))
+	}
+}
+
+func TestExportNoMatches(t *testing.T) {
+	root := t.TempDir()
+	if _, err := SaveResult(root, "q", "general", "sonar", "Answer", nil); err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	var bundle bytes.Buffer
+	if _, err := Export(root, &bundle, BundleSelection{IDs: []string{"ZZZZZZZZZZ"}}); err == nil {
+		t.Error("Expected an error when nothing matches")
+	}
+}
+
+// craftBundle builds a bundle by hand from a manifest and named files
+func craftBundle(t *testing.T, ids []string, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	manifest := BundleManifest{Format: BundleFormat}
+	for _, id := range ids {
+		manifest.Results = append(manifest.Results, QueryListItem{UniqueID: id})
+	}
+	data, _ := json.Marshal(manifest)
+	tw.WriteHeader(&tar.Header{Name: bundleManifestFile, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+	tw.Write(data)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestImportRejectsUnsafeEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		ids   []string
+		files map[string]string
+	}{
+		{"path traversal", []string{"AAAAAAAAAA"}, map[string]string{"AAAAAAAAAA/../../escape.txt": "x"}},
+		{"unlisted result", []string{"AAAAAAAAAA"}, map[string]string{"BBBBBBBBBB/result.md": "x"}},
+		{"invalid ID", []string{"../etc"}, nil},
+		{"empty ID", []string{""}, nil},
+		{"short ID", []string{"AAAA"}, map[string]string{"AAAA/result.md": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			_, err := Import(root, bytes.NewReader(craftBundle(t, tt.ids, tt.files)))
+			if err == nil {
+				t.Fatal("Expected the bundle to be rejected")
+			}
+			if entries, _ := os.ReadDir(root); len(entries) != 0 {
+				t.Errorf("Expected nothing written, got %d entries", len(entries))
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(root), "escape.txt")); err == nil {
+				t.Error("Expected no file outside the results folder")
+			}
+		})
+	}
+
+	defer func(limit int64) { maxBundleBytes = limit }(maxBundleBytes)
+	maxBundleBytes = 8
+	bundle := craftBundle(t, []string{"AAAAAAAAAA"}, map[string]string{"AAAAAAAAAA/result.md": "12345", "AAAAAAAAAA/metadata.json": "12345"})
+	if _, err := Import(t.TempDir(), bytes.NewReader(bundle)); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected a bundle over the size limit rejected, got %v", err)
+	}
+
+	if _, err := Import(t.TempDir(), strings.NewReader("not a bundle")); err == nil {
+		t.Error("Expected an error for a file that is not a bundle")
+	}
+}
@@ -3,10 +3,7 @@ package cache
 import (
 	"crypto/rand"
 	"fmt"
-	"io/ioutil"
 	"math/big"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -21,6 +18,17 @@ type QueryMetadata struct {
 	Timestamp  time.Time              `yaml:"timestamp"`
 	Model      string                 `yaml:"model"`
 	Parameters map[string]interface{} `yaml:"parameters,omitempty"`
+	Keywords   []string               `yaml:"keywords,omitempty"`
+	Entities   []string               `yaml:"entities,omitempty"`
+	// ID of the tool call that produced the result, also found in its log records
+	RequestID string `yaml:"request_id,omitempty"`
+	// Wayback Machine copies of cited URLs, set only when citation archiving is enabled
+	ArchivedURLs map[string]string `yaml:"archived_urls,omitempty"`
+	// Audit chain fields, set only when the audit chain is enabled
+	PreviousHash string `yaml:"previous_hash,omitempty"`
+	AuditHash    string `yaml:"audit_hash,omitempty"`
+	// Layout version of this file (see SchemaVersion); results saved before versioning have none
+	SchemaVersion int `yaml:"schema_version,omitempty"`
 }
 
 // QueryListItem represents an item in the previous queries list
@@ -29,6 +37,9 @@ type QueryListItem struct {
 	UniqueID   string    `json:"unique_id"`
 	DateTime   time.Time `json:"datetime"`
 	SearchType string    `json:"search_type"`
+	Keywords   []string  `json:"keywords,omitempty"`
+	Entities   []string  `json:"entities,omitempty"`
+	Shared     bool      `json:"shared,omitempty"` // Listed from the read-only shared folder
 }
 
 const (
@@ -40,10 +51,11 @@ const (
 
 // GenerateUniqueID generates a 10-character alphanumeric unique ID
 func GenerateUniqueID(rootFolder string) (string, error) {
+	store := storageFor(rootFolder)
 	maxAttempts := 100
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		id := generateRandomID()
-		if !idExists(rootFolder, id) {
+		if !idExists(store, id) {
 			return id, nil
 		}
 	}
@@ -61,13 +73,24 @@ func generateRandomID() string {
 }
 
 // idExists checks if a folder with the given ID already exists
-func idExists(rootFolder, id string) bool {
-	if rootFolder == "" {
-		return false
-	}
-	folderPath := filepath.Join(rootFolder, id)
-	_, err := os.Stat(folderPath)
-	return err == nil
+func idExists(store Storage, id string) bool {
+	return store.Exists(id)
+}
+
+// HasResult reports whether rootFolder holds a cached result with the given ID
+func HasResult(rootFolder, uniqueID string) bool {
+	return rootFolder != "" && IsValidID(uniqueID) && idExists(storageFor(rootFolder), uniqueID)
+}
+
+// Location returns where a file of a cached result can be found outside the server,
+// e.g. "result.md": a local path, or a URL when results are kept in object storage
+func Location(rootFolder, uniqueID, name string) string {
+	return storageFor(rootFolder).Location(uniqueID + "/" + name)
+}
+
+// ListFolders lists the names of the folders directly inside rootFolder
+func ListFolders(rootFolder string) ([]string, error) {
+	return storageFor(rootFolder).Folders("")
 }
 
 // SaveResult saves query result and metadata to the cache
@@ -82,34 +105,23 @@ func SaveResult(rootFolder, query, searchType, model, result string, parameters
 		return "", fmt.Errorf("failed to generate unique ID: %w", err)
 	}
 
-	// Create folder for this result
-	resultFolder := filepath.Join(rootFolder, uniqueID)
-	if err := os.MkdirAll(resultFolder, 0755); err != nil {
-		return "", fmt.Errorf("failed to create result folder: %w", err)
-	}
-
 	// Save metadata
-	metadata := QueryMetadata{
+	store := storageFor(rootFolder)
+	metadata := &QueryMetadata{
 		Query:      query,
 		SearchType: searchType,
 		Timestamp:  time.Now(),
 		Model:      model,
 		Parameters: parameters,
 	}
+	metadata.SchemaVersion = SchemaVersion
 
-	metadataPath := filepath.Join(resultFolder, metadataFile)
-	metadataBytes, err := yaml.Marshal(metadata)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal metadata: %w", err)
-	}
-
-	if err := ioutil.WriteFile(metadataPath, metadataBytes, 0644); err != nil {
-		return "", fmt.Errorf("failed to write metadata file: %w", err)
+	if err := writeMetadata(store, uniqueID, metadata); err != nil {
+		return "", err
 	}
 
 	// Save result
-	resultPath := filepath.Join(resultFolder, resultFile)
-	if err := ioutil.WriteFile(resultPath, []byte(result), 0644); err != nil {
+	if err := store.WriteFile(uniqueID+"/"+resultFile, []byte(result)); err != nil {
 		return "", fmt.Errorf("failed to write result file: %w", err)
 	}
 
@@ -122,36 +134,23 @@ func ListPreviousQueries(rootFolder string) ([]QueryListItem, error) {
 		return []QueryListItem{}, nil // Return empty list if no root folder set
 	}
 
-	// Check if root folder exists
-	if _, err := os.Stat(rootFolder); os.IsNotExist(err) {
+	// Read all subdirectories
+	store := storageFor(rootFolder)
+	folders, err := store.Folders("")
+	if isNotExist(err) {
 		return []QueryListItem{}, nil // Return empty list if folder doesn't exist
 	}
-
-	// Read all subdirectories
-	entries, err := ioutil.ReadDir(rootFolder)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read results directory: %w", err)
 	}
 
 	var queryItems []QueryListItem
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-
-		uniqueID := entry.Name()
-		metadataPath := filepath.Join(rootFolder, uniqueID, metadataFile)
-
-		// Read metadata
-		metadataBytes, err := ioutil.ReadFile(metadataPath)
+	for _, uniqueID := range folders {
+		// Read metadata; skip folders whose metadata is missing or can't be parsed
+		metadata, err := readMetadata(store, uniqueID)
 		if err != nil {
-			continue // Skip if metadata file doesn't exist or can't be read
-		}
-
-		var metadata QueryMetadata
-		if err := yaml.Unmarshal(metadataBytes, &metadata); err != nil {
-			continue // Skip if metadata can't be parsed
+			continue
 		}
 
 		queryItems = append(queryItems, QueryListItem{
@@ -159,6 +158,8 @@ func ListPreviousQueries(rootFolder string) ([]QueryListItem, error) {
 			UniqueID:   uniqueID,
 			DateTime:   metadata.Timestamp,
 			SearchType: metadata.SearchType,
+			Keywords:   metadata.Keywords,
+			Entities:   metadata.Entities,
 		})
 	}
 
@@ -181,15 +182,11 @@ func GetPreviousResult(rootFolder, uniqueID string) (string, error) {
 		return "", fmt.Errorf("invalid unique ID format: must be %d alphanumeric characters", idLength)
 	}
 
-	resultPath := filepath.Join(rootFolder, uniqueID, resultFile)
-
-	// Check if result file exists
-	if _, err := os.Stat(resultPath); os.IsNotExist(err) {
+	// Read result file
+	resultBytes, err := storageFor(rootFolder).ReadFile(uniqueID + "/" + resultFile)
+	if isNotExist(err) {
 		return "", fmt.Errorf("result with ID '%s' not found", uniqueID)
 	}
-
-	// Read result file
-	resultBytes, err := ioutil.ReadFile(resultPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read result file: %w", err)
 	}
@@ -197,6 +194,11 @@ func GetPreviousResult(rootFolder, uniqueID string) (string, error) {
 	return string(resultBytes), nil
 }
 
+// IsValidID reports whether id has the format of a cache unique ID
+func IsValidID(id string) bool {
+	return len(id) == idLength && isValidID(id)
+}
+
 // isValidID checks if the ID contains only valid characters
 func isValidID(id string) bool {
 	for _, char := range id {
@@ -210,4 +212,69 @@ func isValidID(id string) bool {
 // IsCachingEnabled returns true if caching is enabled (root folder is set)
 func IsCachingEnabled(rootFolder string) bool {
 	return rootFolder != ""
-}
\ No newline at end of file
+}
+
+// CheckWritable creates the root folder if needed and verifies that results can be written to it
+func CheckWritable(rootFolder string) error {
+	store := storageFor(rootFolder)
+	// The probe's name is not a valid result ID, so it never shows up in listings
+	probe := ".preflight-" + generateRandomID()
+	if err := store.WriteFile(probe, nil); err != nil {
+		return fmt.Errorf("results folder is not writable: %w", err)
+	}
+	return store.RemoveAll(probe)
+}
+
+// CheckReadable verifies that the root folder exists and its results can be listed
+func CheckReadable(rootFolder string) error {
+	if _, err := storageFor(rootFolder).Folders(""); err != nil {
+		return fmt.Errorf("results folder is not readable: %w", err)
+	}
+	return nil
+}
+
+// RecordRequestID stores the ID of the tool call that produced a saved result in its metadata.yaml
+func RecordRequestID(rootFolder, uniqueID, requestID string) error {
+	store := storageFor(rootFolder)
+	metadata, err := readMetadata(store, uniqueID)
+	if err != nil {
+		return err
+	}
+
+	metadata.RequestID = requestID
+	return writeMetadata(store, uniqueID, metadata)
+}
+
+// GetMetadata retrieves the metadata of a cached result by unique ID
+func GetMetadata(rootFolder, uniqueID string) (*QueryMetadata, error) {
+	if !IsValidID(uniqueID) {
+		return nil, fmt.Errorf("invalid unique ID format: must be %d alphanumeric characters", idLength)
+	}
+	return readMetadata(storageFor(rootFolder), uniqueID)
+}
+
+// readMetadata loads and parses an entry's metadata.yaml
+func readMetadata(store Storage, uniqueID string) (*QueryMetadata, error) {
+	metadataBytes, err := store.ReadFile(uniqueID + "/" + metadataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+
+	var metadata QueryMetadata
+	if err := yaml.Unmarshal(metadataBytes, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata file: %w", err)
+	}
+	return &metadata, nil
+}
+
+// writeMetadata replaces an entry's metadata.yaml
+func writeMetadata(store Storage, uniqueID string, metadata *QueryMetadata) error {
+	metadataBytes, err := yaml.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := store.WriteFile(uniqueID+"/"+metadataFile, metadataBytes); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+)
+
+// imagesFolder holds downloaded images inside a result's folder
+const imagesFolder = "images"
+
+// SaveImage writes an image into the result's images folder and returns its path relative to
+// the result file, suitable for markdown links
+func SaveImage(rootFolder, uniqueID, name string, data []byte) (string, error) {
+	if !IsValidID(uniqueID) {
+		return "", fmt.Errorf("invalid unique ID format: must be %d alphanumeric characters", idLength)
+	}
+	if name == "" || name != path.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid image name '%s'", name)
+	}
+
+	if err := storageFor(rootFolder).WriteFile(uniqueID+"/"+imagesFolder+"/"+name, data); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+	return imagesFolder + "/" + name, nil
+}
+
+// ReadImage returns an image saved by SaveImage
+func ReadImage(rootFolder, uniqueID, name string) ([]byte, error) {
+	if !IsValidID(uniqueID) {
+		return nil, fmt.Errorf("invalid unique ID format: must be %d alphanumeric characters", idLength)
+	}
+	if name == "" || name != path.Base(name) || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid image name '%s'", name)
+	}
+	data, err := storageFor(rootFolder).ReadFile(uniqueID + "/" + imagesFolder + "/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return data, nil
+}
+
+// UpdateResult replaces the content of an existing cached result
+func UpdateResult(rootFolder, uniqueID, result string) error {
+	if !IsValidID(uniqueID) {
+		return fmt.Errorf("invalid unique ID format: must be %d alphanumeric characters", idLength)
+	}
+	store := storageFor(rootFolder)
+	resultPath := uniqueID + "/" + resultFile
+	if !store.Exists(resultPath) {
+		return fmt.Errorf("result with ID '%s' not found", uniqueID)
+	}
+	if err := store.WriteFile(resultPath, []byte(result)); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	return nil
+}
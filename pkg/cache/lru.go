@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRU keeps the most recently used results in memory so repeated reads skip the disk.
+// It is safe for concurrent use; a nil LRU caches nothing.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+// lruEntry is a cached result and its unique ID
+type lruEntry struct {
+	id     string
+	result string
+}
+
+// LRUStats is a snapshot of an LRU's size and lookups
+type LRUStats struct {
+	Size     int
+	Capacity int
+	Hits     uint64
+	Misses   uint64
+}
+
+// HitRate returns the fraction of lookups served from memory, or 0 before any lookup
+func (s LRUStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewLRU returns an LRU holding up to capacity results, or nil when capacity is not positive
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		return nil
+	}
+	return &LRU{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the result cached for id and marks it as most recently used
+func (c *LRU) Get(id string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		c.misses++
+		return "", false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).result, true
+}
+
+// Add caches result for id, evicting the least recently used result when full
+func (c *LRU) Add(id, result string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[id]; ok {
+		elem.Value.(*lruEntry).result = result
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[id] = c.order.PushFront(&lruEntry{id: id, result: result})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).id)
+	}
+}
+
+// Stats returns the LRU's current size and lookup counts
+func (c *LRU) Stats() LRUStats {
+	if c == nil {
+		return LRUStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return LRUStats{Size: c.order.Len(), Capacity: c.capacity, Hits: c.hits, Misses: c.misses}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import "testing"
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU(2)
+	c.Add("AAAAAAAAAA", "first")
+	c.Add("BBBBBBBBBB", "second")
+
+	// Reading the first result makes the second the least recently used
+	if result, ok := c.Get("AAAAAAAAAA"); !ok || result != "first" {
+		t.Fatalf("Expected first result, got %q, %v", result, ok)
+	}
+	c.Add("CCCCCCCCCC", "third")
+
+	if _, ok := c.Get("BBBBBBBBBB"); ok {
+		t.Error("Expected the least recently used result to be evicted")
+	}
+	if result, ok := c.Get("AAAAAAAAAA"); !ok || result != "first" {
+		t.Errorf("Expected first result to stay cached, got %q, %v", result, ok)
+	}
+
+	c.Add("CCCCCCCCCC", "third, updated")
+	if result, _ := c.Get("CCCCCCCCCC"); result != "third, updated" {
+		t.Errorf("Expected the updated result, got %q", result)
+	}
+
+	stats := c.Stats()
+	if stats.Size != 2 || stats.Capacity != 2 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.HitRate() != 0.75 {
+		t.Errorf("HitRate mismatch: got %v, want 0.75", stats.HitRate())
+	}
+}
+
+func TestLRUDisabled(t *testing.T) {
+	c := NewLRU(0)
+	if c != nil {
+		t.Fatal("Expected no LRU for a zero capacity")
+	}
+	c.Add("AAAAAAAAAA", "result")
+	if _, ok := c.Get("AAAAAAAAAA"); ok {
+		t.Error("Expected a disabled LRU to cache nothing")
+	}
+	if c.Stats() != (LRUStats{}) {
+		t.Errorf("Expected empty stats, got %+v", c.Stats())
+	}
+}
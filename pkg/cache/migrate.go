@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"fmt"
+)
+
+// SchemaVersion is the metadata.yaml layout written by this version of the server.
+// Version 1 is the original, unversioned layout; version 2 records keywords and entities.
+const SchemaVersion = 2
+
+// Tagger extracts keywords and entities from a result's markdown
+type Tagger func(result string) (keywords, entities []string)
+
+// MigrationReport summarizes a cache migration
+type MigrationReport struct {
+	Scanned  int
+	Migrated int
+	Problems []string
+}
+
+// migrations upgrade metadata one version at a time; entry i moves a result from version i+1
+// to version i+2. They only touch fields outside the audit hash, so audited results still verify.
+var migrations = []func(store Storage, uniqueID string, metadata *QueryMetadata, tag Tagger) error{
+	// 1 -> 2: results saved before tagging get keywords and entities
+	func(store Storage, uniqueID string, metadata *QueryMetadata, tag Tagger) error {
+		if len(metadata.Keywords) > 0 || len(metadata.Entities) > 0 {
+			return nil
+		}
+		result, err := store.ReadFile(uniqueID + "/" + resultFile)
+		if err != nil {
+			return fmt.Errorf("failed to read result file: %w", err)
+		}
+		metadata.Keywords, metadata.Entities = tag(string(result))
+		return nil
+	},
+}
+
+// Migrate upgrades every cached result in place to the current schema version. Results already
+// current are left untouched, so it is safe to run repeatedly; results written by a newer
+// server are reported rather than downgraded.
+func Migrate(rootFolder string, tag Tagger) (*MigrationReport, error) {
+	store := storageFor(rootFolder)
+	folders, err := store.Folders("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results directory: %w", err)
+	}
+
+	report := &MigrationReport{}
+	for _, folder := range folders {
+		if !isValidID(folder) {
+			continue
+		}
+		report.Scanned++
+
+		migrated, err := migrateResult(store, folder, tag)
+		if err != nil {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: %v", folder, err))
+			continue
+		}
+		if migrated {
+			report.Migrated++
+		}
+	}
+	return report, nil
+}
+
+// migrateResult upgrades one result and reports whether it changed
+func migrateResult(store Storage, uniqueID string, tag Tagger) (bool, error) {
+	metadata, err := readMetadata(store, uniqueID)
+	if err != nil {
+		return false, err
+	}
+
+	version := metadata.SchemaVersion
+	if version == 0 {
+		version = 1
+	}
+	if version > SchemaVersion {
+		return false, fmt.Errorf("schema version %d is newer than this server supports (%d)", version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return false, nil
+	}
+
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version-1](store, uniqueID, metadata, tag); err != nil {
+			return false, fmt.Errorf("migrating to version %d: %w", version+1, err)
+		}
+	}
+	metadata.SchemaVersion = SchemaVersion
+
+	// Storage writes replace the file in one step, so an interrupted migration never
+	// leaves a half-written metadata.yaml behind
+	if err := writeMetadata(store, uniqueID, metadata); err != nil {
+		return false, err
+	}
+	return true, nil
+}
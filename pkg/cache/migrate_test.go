@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// legacyMetadata is a metadata.yaml written before the schema was versioned or tagged
+const legacyMetadata = `query: nvidia earnings
+search_type: financial
+timestamp: 2024-05-01T10:00:00Z
+model: sonar
+parameters:
+    max_tokens: 512
+`
+
+func writeLegacyResult(t *testing.T, root, id string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, id), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, id, metadataFile), []byte(legacyMetadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, id, resultFile), []byte("Nvidia reported record revenue."), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fakeTagger(result string) ([]string, []string) {
+	return []string{"revenue"}, []string{"Nvidia"}
+}
+
+func TestMigrate(t *testing.T) {
+	root := t.TempDir()
+	writeLegacyResult(t, root, "LEGACY0001")
+	current, err := SaveResult(root, "current", "general", "sonar", "Answer", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	report, err := Migrate(root, fakeTagger)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if report.Scanned != 2 || report.Migrated != 1 || len(report.Problems) != 0 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	metadata, err := readMetadata(Filesystem(root), "LEGACY0001")
+	if err != nil {
+		t.Fatalf("readMetadata failed: %v", err)
+	}
+	if metadata.SchemaVersion != SchemaVersion || metadata.Keywords[0] != "revenue" || metadata.Entities[0] != "Nvidia" {
+		t.Errorf("Expected a tagged, current result, got %+v", metadata)
+	}
+	if metadata.Query != "nvidia earnings" || metadata.Parameters["max_tokens"] != 512 {
+		t.Errorf("Expected existing fields to be preserved, got %+v", metadata)
+	}
+	if metadata, _ := readMetadata(Filesystem(root), current); len(metadata.Keywords) != 0 {
+		t.Error("Expected a current result to be left alone")
+	}
+
+	// Running again changes nothing
+	if report, err := Migrate(root, fakeTagger); err != nil || report.Migrated != 0 {
+		t.Errorf("Expected a second run to migrate nothing, got %+v, %v", report, err)
+	}
+}
+
+func TestMigrateKeepsAuditChain(t *testing.T) {
+	root := t.TempDir()
+	writeLegacyResult(t, root, "LEGACY0001")
+	if err := AppendAuditChain(root, "LEGACY0001"); err != nil {
+		t.Fatalf("AppendAuditChain failed: %v", err)
+	}
+
+	if _, err := Migrate(root, fakeTagger); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	report, err := VerifyAuditChain(root)
+	if err != nil || len(report.Problems) != 0 {
+		t.Errorf("Expected the chain to verify after migration, got %+v, %v", report, err)
+	}
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	root := t.TempDir()
+	writeLegacyResult(t, root, "NEWER00001")
+	newer := "schema_version: 99\n" + legacyMetadata
+	if err := os.WriteFile(filepath.Join(root, "NEWER00001", metadataFile), []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Migrate(root, fakeTagger)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "newer than this server supports") {
+		t.Errorf("Expected the newer result to be reported, got %v", report.Problems)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "NEWER00001", metadataFile)); string(data) != newer {
+		t.Error("Expected the newer result to be left untouched")
+	}
+}
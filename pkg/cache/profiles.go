@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// profilesFile holds the search profiles saved with save_profile in a results root
+const profilesFile = "profiles.json"
+
+// ReadProfiles returns the profiles file of a results root, or nil when it has none
+func ReadProfiles(rootFolder string) ([]byte, error) {
+	data, err := storageFor(rootFolder).ReadFile(profilesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	return data, nil
+}
+
+// WriteProfiles replaces the profiles file of a results root
+func WriteProfiles(rootFolder string, data []byte) error {
+	if err := storageFor(rootFolder).WriteFile(profilesFile, data); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// s3Timeout bounds each object storage request
+	s3Timeout = 30 * time.Second
+	// maxS3ObjectBytes bounds each object read from the bucket
+	maxS3ObjectBytes = 64 << 20
+)
+
+// S3Options configures object storage reached through the S3 API: Amazon S3, MinIO, or
+// Google Cloud Storage with HMAC keys
+type S3Options struct {
+	Endpoint        string // e.g. https://s3.us-east-1.amazonaws.com or https://storage.googleapis.com
+	Region          string // Signing region; "auto" for Cloud Storage
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set only for temporary credentials
+}
+
+// S3 stores each results root as a key prefix in a bucket, so a stateless deployment keeps
+// its cache. Requests use path-style addressing and Signature Version 4.
+func S3(opts S3Options) Backend {
+	client := &s3Client{opts: opts, http: &http.Client{Timeout: s3Timeout}, now: time.Now}
+	return func(rootFolder string) Storage {
+		return &s3Storage{client: client, prefix: strings.Trim(path.Clean("/"+rootFolder), "/")}
+	}
+}
+
+// s3Storage is a results root kept under a key prefix in a bucket
+type s3Storage struct {
+	client *s3Client
+	prefix string
+}
+
+// key returns the object key of a file in the root
+func (s *s3Storage) key(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	if name == "" {
+		return s.prefix
+	}
+	return s.prefix + "/" + name
+}
+
+// dirKey returns the key prefix of the objects inside a folder of the root
+func (s *s3Storage) dirKey(dir string) string {
+	if key := s.key(dir); key != "" {
+		return key + "/"
+	}
+	return ""
+}
+
+func (s *s3Storage) ReadFile(name string) ([]byte, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	return s.client.get(s.key(name))
+}
+
+func (s *s3Storage) WriteFile(name string, data []byte) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	return s.client.put(s.key(name), data)
+}
+
+// Folders lists the common prefixes under dir. Buckets have no empty folders, so a root
+// holding nothing is reported missing, as a local folder that was never created would be.
+func (s *s3Storage) Folders(dir string) ([]string, error) {
+	if err := checkName(dir); err != nil {
+		return nil, err
+	}
+	prefix := s.dirKey(dir)
+	folders, files, err := s.client.list(prefix, "/", 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(folders) == 0 && len(files) == 0 {
+		return nil, fmt.Errorf("%s: %w", s.Location(dir), fs.ErrNotExist)
+	}
+	names := make([]string, len(folders))
+	for i, folder := range folders {
+		names[i] = strings.TrimSuffix(strings.TrimPrefix(folder, prefix), "/")
+	}
+	return names, nil
+}
+
+func (s *s3Storage) Files(dir string) ([]string, error) {
+	if err := checkName(dir); err != nil {
+		return nil, err
+	}
+	_, keys, err := s.client.list(s.dirKey(dir), "", 0)
+	if err != nil {
+		return nil, err
+	}
+	root := s.dirKey("")
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = strings.TrimPrefix(key, root)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *s3Storage) Exists(name string) bool {
+	if checkName(name) != nil {
+		return false
+	}
+	if _, keys, err := s.client.list(s.dirKey(name), "", 1); err == nil && len(keys) > 0 {
+		return true
+	}
+	return s.client.head(s.key(name)) == nil
+}
+
+func (s *s3Storage) RemoveAll(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	_, keys, err := s.client.list(s.dirKey(name), "", 0)
+	if err != nil {
+		return err
+	}
+	for _, key := range append(keys, s.key(name)) {
+		if err := s.client.delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *s3Storage) Location(name string) string {
+	return "s3://" + s.client.opts.Bucket + "/" + s.key(name)
+}
+
+// s3Client makes signed requests against one bucket
+type s3Client struct {
+	opts S3Options
+	http *http.Client
+	now  func() time.Time
+}
+
+func (c *s3Client) get(key string) ([]byte, error) {
+	resp, err := c.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, maxS3ObjectBytes))
+}
+
+func (c *s3Client) head(key string) error {
+	resp, err := c.do(http.MethodHead, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *s3Client) put(key string, data []byte) error {
+	resp, err := c.do(http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *s3Client) delete(key string) error {
+	resp, err := c.do(http.MethodDelete, key, nil, nil)
+	if isNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3ListResult is the response of ListObjectsV2
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// list returns the common prefixes and object keys under prefix, following continuation
+// tokens; a positive limit stops after that many keys
+func (c *s3Client) list(prefix, delimiter string, limit int) (folders, keys []string, err error) {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if limit > 0 {
+		query.Set("max-keys", fmt.Sprint(limit))
+	}
+	for {
+		resp, err := c.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(io.LimitReader(resp.Body, maxS3ObjectBytes)).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse bucket listing: %w", err)
+		}
+
+		for _, p := range result.CommonPrefixes {
+			folders = append(folders, p.Prefix)
+		}
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" || (limit > 0 && len(keys) >= limit) {
+			return folders, keys, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// do sends a signed request for an object, or for the bucket when key is empty. A missing
+// object gives an error matching fs.ErrNotExist.
+func (c *s3Client) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	endpoint := strings.TrimSuffix(c.opts.Endpoint, "/") + "/" + s3Escape(c.opts.Bucket)
+	if key != "" {
+		endpoint += "/" + s3Escape(key)
+	}
+	if len(query) > 0 {
+		endpoint += "?" + s3Query(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if body == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+	c.sign(req, body)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("object storage request failed: %w", err)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", key, fs.ErrNotExist)
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("object storage returned %s for %s %s: %s", resp.Status, method, key, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// sign adds Signature Version 4 headers to req, covering the host and every header already set
+func (c *s3Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadSum := sha256.Sum256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadSum[:]))
+	if c.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.opts.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3Query(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	requestSum := sha256.Sum256([]byte(canonicalRequest))
+	scope := day + "/" + c.opts.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestSum[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+c.opts.SecretAccessKey), day)
+	for _, part := range []string{c.opts.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes a key as Signature Version 4 expects, leaving slashes alone
+func s3Escape(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.QueryEscape(segment), "+", "%20")
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Query encodes query parameters sorted by name, with spaces as %20
+func s3Query(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), "+", "%20")
+}
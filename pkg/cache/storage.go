@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage holds the files of one results root. Names are slash-separated and relative to
+// the root, e.g. "A1B2C3D4E5/metadata.yaml"; the empty name is the root itself. Names that
+// would leave the root are rejected with an error matching ErrOutsideRoot.
+type Storage interface {
+	// ReadFile returns a file's content; a missing file gives an error matching fs.ErrNotExist
+	ReadFile(name string) ([]byte, error)
+	// WriteFile creates or replaces a file in one step, creating the folders above it
+	WriteFile(name string, data []byte) error
+	// Folders lists the names of the folders directly inside dir; a missing root gives an
+	// error matching fs.ErrNotExist
+	Folders(dir string) ([]string, error)
+	// Files lists the files in dir and its subfolders, as names relative to the root
+	Files(dir string) ([]string, error)
+	// Exists reports whether name is a file or a folder holding files
+	Exists(name string) bool
+	// RemoveAll removes a file, or a folder and everything in it
+	RemoveAll(name string) error
+	// Location returns where a file can be found outside the server: a path or a URL
+	Location(name string) string
+}
+
+// ErrOutsideRoot is returned for names that would reach outside the results root: absolute
+// paths, ".." elements, or local paths through a symlink that leads out of the root
+var ErrOutsideRoot = errors.New("path is outside the results root")
+
+// checkName rejects names that are not plain paths inside the root
+func checkName(name string) error {
+	if name != "" && (!filepath.IsLocal(filepath.FromSlash(name)) || strings.Contains(name, "\\")) {
+		return fmt.Errorf("%w: %q", ErrOutsideRoot, name)
+	}
+	return nil
+}
+
+// Backend opens the storage of a results root
+type Backend func(rootFolder string) Storage
+
+var (
+	backendMu sync.RWMutex
+	backend   Backend = Filesystem
+)
+
+// UseBackend makes every results root use b; it is meant to be called once at startup
+func UseBackend(b Backend) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backend = b
+}
+
+// storageFor opens the storage of a results root with the current backend
+func storageFor(rootFolder string) Storage {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return backend(rootFolder)
+}
+
+// Filesystem stores each results root as a local folder
+func Filesystem(rootFolder string) Storage {
+	return dirStorage(rootFolder)
+}
+
+// dirStorage is a results root on the local filesystem
+type dirStorage string
+
+// path returns the local path of a name after checking that it stays inside the root
+func (d dirStorage) path(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	target := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := d.checkInside(target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// checkInside resolves the symlinks along target, up to the deepest part that exists, and
+// rejects a target they lead outside the root
+func (d dirStorage) checkInside(target string) error {
+	root, err := filepath.EvalSymlinks(string(d))
+	if isNotExist(err) {
+		// Without a root nothing below it exists, so no link can lead out
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for existing := target; ; existing = filepath.Dir(existing) {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if isNotExist(err) && existing != filepath.Dir(existing) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(root, resolved); err != nil || (rel != "." && !filepath.IsLocal(rel)) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrOutsideRoot, target, resolved)
+		}
+		return nil
+	}
+}
+
+func (d dirStorage) ReadFile(name string) ([]byte, error) {
+	target, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(target)
+}
+
+// WriteFile writes through a temporary file so readers never see a half-written file. The
+// temporary file is created exclusively, so a planted symlink cannot redirect the write.
+func (d dirStorage) WriteFile(name string, data []byte) error {
+	target, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (d dirStorage) Folders(dir string) ([]string, error) {
+	target, err := d.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			folders = append(folders, entry.Name())
+		}
+	}
+	return folders, nil
+}
+
+// Files does not follow symlinks, so linked files and folders are not listed
+func (d dirStorage) Files(dir string) ([]string, error) {
+	target, err := d.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = filepath.WalkDir(target, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(string(d), file)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func (d dirStorage) Exists(name string) bool {
+	target, err := d.path(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(target)
+	return err == nil
+}
+
+func (d dirStorage) RemoveAll(name string) error {
+	target, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(target)
+}
+
+func (d dirStorage) Location(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(name))
+}
+
+// isNotExist reports whether err means a file or folder is missing
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// copyFolder copies the files of folder dir from one storage to another, writing
+// metadata.yaml last so a partly copied result is never listed
+func copyFolder(from, to Storage, dir string) error {
+	files, err := from.Files(dir)
+	if err != nil {
+		return err
+	}
+	var metadata []string
+	for _, name := range files {
+		if path.Base(name) == metadataFile {
+			metadata = append(metadata, name)
+			continue
+		}
+		if err := copyFile(from, to, name); err != nil {
+			return err
+		}
+	}
+	for _, name := range metadata {
+		if err := copyFile(from, to, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(from, to Storage, name string) error {
+	data, err := from.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := to.WriteFile(name, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirStorageRejectsEscapes(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := Filesystem(root)
+
+	for _, name := range []string{"../secret.txt", "A1B2C3D4E5/../../secret.txt", "/etc/passwd", `A1B2C3D4E5\..\..\secret.txt`} {
+		if _, err := store.ReadFile(name); !errors.Is(err, ErrOutsideRoot) {
+			t.Errorf("ReadFile(%q) error = %v, want ErrOutsideRoot", name, err)
+		}
+		if err := store.WriteFile(name, []byte("x")); !errors.Is(err, ErrOutsideRoot) {
+			t.Errorf("WriteFile(%q) error = %v, want ErrOutsideRoot", name, err)
+		}
+		if err := store.RemoveAll(name); !errors.Is(err, ErrOutsideRoot) {
+			t.Errorf("RemoveAll(%q) error = %v, want ErrOutsideRoot", name, err)
+		}
+	}
+
+	// A result folder or file linked to somewhere outside the root is not followed
+	if err := os.Symlink(outside, filepath.Join(root, "LINKEDDIR1")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "A1B2C3D4E5"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "A1B2C3D4E5", "result.md")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.ReadFile("LINKEDDIR1/secret.txt"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected a read through a linked folder rejected, got %v", err)
+	}
+	if _, err := store.ReadFile("A1B2C3D4E5/result.md"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected a read of a linked file rejected, got %v", err)
+	}
+	if err := store.WriteFile("LINKEDDIR1/new.txt", []byte("x")); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected a write through a linked folder rejected, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); err == nil {
+		t.Error("Write through a linked folder reached outside the root")
+	}
+	if files, err := store.Files(""); err != nil || len(files) != 0 {
+		t.Errorf("Expected linked files left out of listings, got %v, %v", files, err)
+	}
+}
+
+func TestDirStorageWriteIgnoresPlantedTempLink(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "target.txt")
+	if err := os.MkdirAll(filepath.Join(root, "A1B2C3D4E5"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The temporary name used by earlier versions, pointing outside the root
+	if err := os.Symlink(outside, filepath.Join(root, "A1B2C3D4E5", "result.md.tmp")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	store := Filesystem(root)
+	if err := store.WriteFile("A1B2C3D4E5/result.md", []byte("answer")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := os.Stat(outside); err == nil {
+		t.Error("Write followed a planted temporary file link out of the root")
+	}
+	if data, err := store.ReadFile("A1B2C3D4E5/result.md"); err != nil || string(data) != "answer" {
+		t.Errorf("ReadFile = %q, %v; want the written answer", data, err)
+	}
+}
+
+func TestDirStorageRootBehindSymlink(t *testing.T) {
+	// A root that is itself a link, as with a results folder on another volume, still works
+	target := t.TempDir()
+	root := filepath.Join(t.TempDir(), "results")
+	if err := os.Symlink(target, root); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	store := Filesystem(root)
+	if err := store.WriteFile("A1B2C3D4E5/result.md", []byte("answer")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, err := store.ReadFile("A1B2C3D4E5/result.md"); err != nil || string(data) != "answer" {
+		t.Errorf("ReadFile = %q, %v; want the written answer", data, err)
+	}
+}
+
+func TestS3StorageRejectsEscapes(t *testing.T) {
+	store := S3(S3Options{Bucket: "results"})("root")
+	if _, err := store.ReadFile("../other-root/A1B2C3D4E5/result.md"); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("Expected a key outside the prefix rejected, got %v", err)
+	}
+	if store.Exists("../other-root") {
+		t.Error("Expected Exists to be false for a name outside the root")
+	}
+}
@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+)
+
+// tablesFolder holds tables extracted from the answer inside a result's folder
+const tablesFolder = "tables"
+
+// SaveTable writes a table file (CSV or JSON) into the result's tables folder and returns
+// where it can be found outside the server
+func SaveTable(rootFolder, uniqueID, name string, data []byte) (string, error) {
+	if !IsValidID(uniqueID) {
+		return "", fmt.Errorf("invalid unique ID format: must be %d alphanumeric characters", idLength)
+	}
+	if name == "" || name != path.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid table name '%s'", name)
+	}
+
+	if err := storageFor(rootFolder).WriteFile(uniqueID+"/"+tablesFolder+"/"+name, data); err != nil {
+		return "", fmt.Errorf("failed to write table: %w", err)
+	}
+	return Location(rootFolder, uniqueID, tablesFolder+"/"+name), nil
+}
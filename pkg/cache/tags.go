@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"strings"
+)
+
+// QueryFilter narrows a query list to results tagged with an entity and/or keyword
+type QueryFilter struct {
+	Entity  string
+	Keyword string
+}
+
+// Matches reports whether an item carries the filter's entity and keyword (case-insensitive)
+func (f QueryFilter) Matches(item QueryListItem) bool {
+	if f.Entity != "" && !containsFold(item.Entities, f.Entity) {
+		return false
+	}
+	if f.Keyword != "" && !containsFold(item.Keywords, f.Keyword) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the items matching the filter, preserving order
+func (f QueryFilter) Apply(items []QueryListItem) []QueryListItem {
+	if f.Entity == "" && f.Keyword == "" {
+		return items
+	}
+
+	matched := []QueryListItem{}
+	for _, item := range items {
+		if f.Matches(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// containsFold reports whether values contains target, ignoring case
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// TagResult records extracted keywords and entities in a saved result's metadata.yaml
+func TagResult(rootFolder, uniqueID string, keywords, entities []string) error {
+	store := storageFor(rootFolder)
+	metadata, err := readMetadata(store, uniqueID)
+	if err != nil {
+		return err
+	}
+
+	metadata.Keywords = keywords
+	metadata.Entities = entities
+	return writeMetadata(store, uniqueID, metadata)
+}
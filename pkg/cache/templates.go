@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// templatesFile holds query templates saved in a results root, in the format of
+// PERPLEXITY_TEMPLATES_FILE
+const templatesFile = "templates.json"
+
+// ReadTemplates returns the query templates file of a results root, or nil when it has none
+func ReadTemplates(rootFolder string) ([]byte, error) {
+	if rootFolder == "" {
+		return nil, nil
+	}
+	data, err := storageFor(rootFolder).ReadFile(templatesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+	return data, nil
+}
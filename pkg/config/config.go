@@ -1,11 +1,23 @@
 package config
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/textproto"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata" // Time zones resolve in containers without a zoneinfo database
 
+	"github.com/prasanthmj/perplexity/pkg/credentials"
+	"github.com/prasanthmj/perplexity/pkg/models"
 	"github.com/prasanthmj/perplexity/pkg/types"
 )
 
@@ -18,41 +30,377 @@ type Config struct {
 	TopP                float64
 	TopK                int
 	Timeout             time.Duration
+	Stream              bool
 	ReturnImages        bool
 	ReturnRelated       bool
 	ResultsRootFolder   string
+	HTTPAddr            string
+	RateLimit           int
+	RetryOnEmpty        bool
+	BlockedDomains      []string
+	AllowedDomains      []string
+	BlockedRequeryRatio float64
+	MaxDocumentBytes    int
+	ContextTokenBudget  int
+	OutlineThreshold    int
+	UserAgent           string
+	ExtraHeaders        map[string]string
+	ProxyURL            string
+	CABundle            string
+	TLSMinVersion       uint16
+	AuditChain          bool
+	AnonymizeRules      []AnonymizeRule
+	Examples            ExamplesConfig
+	Transforms          TransformsConfig
+	TranslationModel    string
+	Notion              NotionConfig
+	Confluence          ConfluenceConfig
+	NotifyWebhook       string
+	NotifySearchTypes   []string
+	SMTP                SMTPConfig
+	DigestTo            []string
+	CalendarURL         string
+	CalendarToken       string
+	Tickers             map[string][]string
+	TickerLookup        bool
+	PaperMetadata       bool
+	DownloadImages      bool
+	ImageMaxBytes       int
+	ArchiveCitations    bool
+	DeepSourcesCount    int
+	LanguageMismatch    string
+	ContentFilter       string
+	ContentFilterAction string
+	ContentFilterTerms  []string
+	DuplicateWindow     time.Duration
+	SimilarityThreshold float64
+	SimilarityTTL       time.Duration
+	LogLevel            string
+	LogFormat           string
+	LogFile             string
+	ResultCacheSize     int
+	// Results folders for named projects; other projects use a subfolder of ResultsRootFolder
+	ProjectRoots map[string]string
+	// Read-only results folder shared by a team, looked up after ResultsRootFolder
+	SharedResultsFolder string
+	CacheBackend        string
+	S3                  S3Config
+	// Redis server shared by instances behind a load balancer; empty keeps all state local
+	RedisURL    string
+	RedisPrefix string
+	// Token required to read the Atom feed of new results; empty disables the feed
+	FeedToken string
+	// File or URL of a model manifest replacing the bundled model list
+	ModelsManifest string
+	// Model retried once when the API rejects a request's model or lacks capacity for it
+	FallbackModel string
+	// What the formatter does with <think> traces of reasoning models
+	ReasoningTraces string
+	// Named query templates run by the run_template tool
+	Templates map[string]QueryTemplate
+	// Whether tool calls with arguments a tool does not accept are rejected or run anyway
+	ArgumentMode string
+	// How answers are annotated with confidence when a call doesn't choose
+	Confidence string
+	// Split answers into established facts and analysis when a call doesn't choose
+	SeparateOpinions bool
+	// Currency money figures in financial answers are annotated in; empty disables it
+	BaseCurrency string
+	// JSON file or URL of the exchange rates used to convert to BaseCurrency
+	FXRates string
+	// IANA time zone dates are computed and shown in when a call doesn't set one; empty
+	// keeps the server's zone
+	Timezone string
+	// REST API clients by name, each with its own bearer token and quotas; empty leaves the
+	// API open
+	Clients map[string]ClientConfig
+	// Certificate and key the HTTP server serves TLS with; empty serves plain HTTP
+	TLSCert string
+	TLSKey  string
+	// CA certificates that sign client certificates; when set, the HTTP server requires a
+	// client certificate signed by one of them
+	TLSClientCA string
+	// File the server keeps daily counts of tool calls, models, and error types in; empty
+	// records nothing
+	UsageStatsFile string
+	// Folder local grounding documents may be read from; empty refuses to read any
+	DocumentRoot string
+	// Address the gRPC search service listens on; empty serves none
+	GRPCAddr string
+	// Queries the digest reports on, by name; empty sends no digest
+	Watches map[string]WatchConfig
+}
+
+// Modes for PERPLEXITY_LANGUAGE_MISMATCH, applied when an answer's language differs from the
+// query's or the requested answer_language
+const (
+	LanguageMismatchWarn  = "warn"
+	LanguageMismatchRetry = "retry"
+	LanguageMismatchOff   = "off"
+)
+
+// Levels for PERPLEXITY_CONTENT_FILTER, naming the least severe terms filtered, and actions
+// for PERPLEXITY_CONTENT_FILTER_ACTION
+const (
+	ContentFilterOff    = "off"
+	ContentFilterMild   = "mild"
+	ContentFilterStrong = "strong"
+	ContentFilterSevere = "severe"
+
+	ContentFilterMask = "mask"
+	ContentFilterDrop = "drop"
+)
+
+// Modes for PERPLEXITY_REASONING_TRACES, applied to the <think> traces of reasoning models
+const (
+	ReasoningTracesStrip    = "strip"
+	ReasoningTracesKeep     = "keep"
+	ReasoningTracesAppendix = "appendix"
+)
+
+// Modes for PERPLEXITY_CONFIDENCE and the confidence tool argument: rate the sections of an
+// answer by how densely they cite sources, or ask the model to rate its key claims
+const (
+	ConfidenceOff       = "off"
+	ConfidenceCitations = "citations"
+	ConfidenceModel     = "model"
+)
+
+// Modes for PERPLEXITY_ARGUMENT_MODE, applied to tool call arguments a tool does not accept
+const (
+	ArgumentModeLenient = "lenient"
+	ArgumentModeStrict  = "strict"
+)
+
+// Levels for PERPLEXITY_LOG_LEVEL and formats for PERPLEXITY_LOG_FORMAT
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Backends for PERPLEXITY_CACHE_BACKEND
+const (
+	CacheBackendFilesystem = "filesystem"
+	CacheBackendS3         = "s3"
+)
+
+// S3Config holds the bucket results are cached in when PERPLEXITY_CACHE_BACKEND is s3
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SMTPConfig holds the mail server used to send digests
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NotionConfig holds the Notion database that publish_result writes pages to
+type NotionConfig struct {
+	Token         string
+	DatabaseID    string
+	TitleProperty string
+	TagsProperty  string
+}
+
+// Enabled reports whether Notion publishing is configured
+func (n NotionConfig) Enabled() bool {
+	return n.Token != ""
+}
+
+// ConfluenceConfig holds the Confluence space that publish_result writes pages to
+type ConfluenceConfig struct {
+	URL   string
+	User  string
+	Token string
+	Space string
+}
+
+// Enabled reports whether Confluence publishing is configured
+func (c ConfluenceConfig) Enabled() bool {
+	return c.Token != ""
+}
+
+// ExamplesConfig holds few-shot examples applied to every search tool or to specific tools
+type ExamplesConfig struct {
+	Default []types.Example            `json:"default"`
+	Tools   map[string][]types.Example `json:"tools"`
+}
+
+// Location returns the configured time zone, or nil when none is set
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// ExamplesFor returns the examples configured for a tool, falling back to the defaults
+func (c *Config) ExamplesFor(tool string) []types.Example {
+	if examples, ok := c.Examples.Tools[tool]; ok {
+		return examples
+	}
+	return c.Examples.Default
+}
+
+// QueryTemplate is a tool call with {{name}} placeholders in its string arguments, filled in
+// when the template is run
+type QueryTemplate struct {
+	Description string                 `json:"description"`
+	Tool        string                 `json:"tool"`
+	Query       string                 `json:"query"`     // Shorthand for a query argument
+	Arguments   map[string]interface{} `json:"arguments"` // Other arguments of the tool
+	Defaults    map[string]string      `json:"defaults"`  // Values of placeholders the caller may omit
+}
+
+// WatchConfig is a query whose changes the digest reports. Runs of the query are matched by
+// search type and query text, ignoring case and spacing.
+type WatchConfig struct {
+	Query      string   `json:"query"`
+	SearchType string   `json:"search_type"` // Search type of the runs, e.g. general or financial; empty is general
+	Period     string   `json:"period"`      // daily or weekly
+	Recipients []string `json:"recipients"`  // Empty sends the watch to PERPLEXITY_DIGEST_TO
+}
+
+// ClientConfig is a REST API client's bearer token and quotas; a zero quota is unlimited
+type ClientConfig struct {
+	Token         string `json:"token"`
+	RateLimit     int    `json:"rate_limit"`     // Tool calls per minute
+	DailyRequests int    `json:"daily_requests"` // Tool calls per day, counted from midnight in the configured time zone
+}
+
+// TransformsConfig names the answer transforms applied to every search tool or to specific tools
+type TransformsConfig struct {
+	Default []string
+	Tools   map[string][]string
+}
+
+// TransformsFor returns the transforms configured for a tool, falling back to the defaults
+func (c *Config) TransformsFor(tool string) []string {
+	if transforms, ok := c.Transforms.Tools[tool]; ok {
+		return transforms
+	}
+	return c.Transforms.Default
+}
+
+// AnonymizeRule replaces a sensitive term in outgoing queries; an empty Replacement gets a generated placeholder
+type AnonymizeRule struct {
+	Term        string
+	Replacement string
+}
+
+// Default returns the configuration used when no environment variables are set, apart from
+// the API key, which it leaves empty
+func Default() *Config {
+	return &Config{
+		DefaultModel:       types.DefaultModel,
+		MaxTokens:          types.DefaultMaxTokens,
+		Temperature:        types.DefaultTemperature,
+		TopP:               types.DefaultTopP,
+		TopK:               types.DefaultTopK,
+		Timeout:            30 * time.Second,
+		ReturnImages:       types.DefaultReturnImages,
+		ReturnRelated:      types.DefaultReturnRelated,
+		ResultsRootFolder:  "", // Empty by default - no caching if not set
+		RetryOnEmpty:       true,
+		MaxDocumentBytes:   100000,
+		ContextTokenBudget: 8000,
+		OutlineThreshold:   12000,
+		TranslationModel:   types.ModelSonar,
+		Notion:             NotionConfig{TitleProperty: "Name"},
+		NotifySearchTypes:  []string{"verification"},
+		SMTP:               SMTPConfig{Port: 587},
+		TickerLookup:       true,
+		PaperMetadata:      true,
+		ImageMaxBytes:      5 << 20,
+		DeepSourcesCount:   3,
+		LanguageMismatch:   LanguageMismatchWarn,
+		DuplicateWindow:    30 * time.Second,
+		SimilarityTTL:      time.Hour,
+		LogLevel:           LogLevelInfo,
+		LogFormat:          LogFormatText,
+		ResultCacheSize:    128,
+		CacheBackend:       CacheBackendFilesystem,
+		S3:                 S3Config{Region: "us-east-1"},
+		RedisPrefix:        "perplexity:",
+		ReasoningTraces:    ReasoningTracesStrip,
+		ArgumentMode:       ArgumentModeLenient,
+		Confidence:         ConfidenceOff,
+	}
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() (*Config, error) {
-	cfg := &Config{
-		// Set defaults
-		DefaultModel:      types.DefaultModel,
-		MaxTokens:         types.DefaultMaxTokens,
-		Temperature:       types.DefaultTemperature,
-		TopP:             types.DefaultTopP,
-		TopK:             types.DefaultTopK,
-		Timeout:          30 * time.Second,
-		ReturnImages:      types.DefaultReturnImages,
-		ReturnRelated:     types.DefaultReturnRelated,
-		ResultsRootFolder: "", // Empty by default - no caching if not set
-	}
-
-	// API Key is required
-	cfg.APIKey = os.Getenv("PERPLEXITY_API_KEY")
+	cfg := Default()
+	env, err := loadEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	// API Key is required; without one in the environment it may come from the OS credential store
+	cfg.APIKey = env.get("PERPLEXITY_API_KEY")
+	if store := env.get("PERPLEXITY_CREDENTIAL_STORE"); cfg.APIKey == "" && store != "" {
+		useStore, err := strconv.ParseBool(store)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_CREDENTIAL_STORE: %w", err)
+		}
+		if useStore {
+			if cfg.APIKey, err = credentials.Get(); err != nil {
+				return nil, fmt.Errorf("failed to read the API key from the credential store (store it with: perplexity credentials set): %w", err)
+			}
+		}
+	}
 	if cfg.APIKey == "" {
 		return nil, fmt.Errorf("PERPLEXITY_API_KEY environment variable is required")
 	}
 
+	// The model list comes first, since model settings are validated against it. A manifest
+	// URL that cannot be fetched leaves the bundled list in place rather than failing startup.
+	if manifest := env.get("PERPLEXITY_MODELS_MANIFEST"); manifest != "" {
+		err := models.Default.LoadManifest(context.Background(), manifest)
+		isURL := strings.HasPrefix(manifest, "http://") || strings.HasPrefix(manifest, "https://")
+		if err != nil && !isURL {
+			return nil, fmt.Errorf("invalid PERPLEXITY_MODELS_MANIFEST: %w", err)
+		}
+		if err != nil {
+			slog.Warn("using the bundled model list", "manifest", manifest, "error", err)
+		}
+		cfg.ModelsManifest = manifest
+	}
+
 	// Override defaults with environment variables if set
-	if model := os.Getenv("PERPLEXITY_DEFAULT_MODEL"); model != "" {
-		if err := validateModel(model); err != nil {
+	if model := env.get("PERPLEXITY_DEFAULT_MODEL"); model != "" {
+		if err := ValidateModel(model); err != nil {
 			return nil, fmt.Errorf("invalid model: %w", err)
 		}
 		cfg.DefaultModel = model
 	}
 
-	if maxTokens := os.Getenv("PERPLEXITY_MAX_TOKENS"); maxTokens != "" {
+	if fallback := env.get("PERPLEXITY_FALLBACK_MODEL"); fallback != "" {
+		if err := ValidateModel(fallback); err != nil || fallback == types.ModelAuto {
+			return nil, fmt.Errorf("invalid PERPLEXITY_FALLBACK_MODEL: must be a model other than auto")
+		}
+		cfg.FallbackModel = fallback
+	}
+
+	if maxTokens := env.get("PERPLEXITY_MAX_TOKENS"); maxTokens != "" {
 		val, err := strconv.Atoi(maxTokens)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_MAX_TOKENS: %w", err)
@@ -63,7 +411,7 @@ func LoadConfig() (*Config, error) {
 		cfg.MaxTokens = val
 	}
 
-	if temp := os.Getenv("PERPLEXITY_TEMPERATURE"); temp != "" {
+	if temp := env.get("PERPLEXITY_TEMPERATURE"); temp != "" {
 		val, err := strconv.ParseFloat(temp, 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_TEMPERATURE: %w", err)
@@ -74,7 +422,7 @@ func LoadConfig() (*Config, error) {
 		cfg.Temperature = val
 	}
 
-	if topP := os.Getenv("PERPLEXITY_TOP_P"); topP != "" {
+	if topP := env.get("PERPLEXITY_TOP_P"); topP != "" {
 		val, err := strconv.ParseFloat(topP, 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_TOP_P: %w", err)
@@ -85,7 +433,7 @@ func LoadConfig() (*Config, error) {
 		cfg.TopP = val
 	}
 
-	if topK := os.Getenv("PERPLEXITY_TOP_K"); topK != "" {
+	if topK := env.get("PERPLEXITY_TOP_K"); topK != "" {
 		val, err := strconv.Atoi(topK)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_TOP_K: %w", err)
@@ -96,7 +444,7 @@ func LoadConfig() (*Config, error) {
 		cfg.TopK = val
 	}
 
-	if timeout := os.Getenv("PERPLEXITY_TIMEOUT"); timeout != "" {
+	if timeout := env.get("PERPLEXITY_TIMEOUT"); timeout != "" {
 		val, err := time.ParseDuration(timeout)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_TIMEOUT: %w", err)
@@ -107,7 +455,15 @@ func LoadConfig() (*Config, error) {
 		cfg.Timeout = val
 	}
 
-	if returnImages := os.Getenv("PERPLEXITY_RETURN_IMAGES"); returnImages != "" {
+	if stream := env.get("PERPLEXITY_STREAM"); stream != "" {
+		val, err := strconv.ParseBool(stream)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_STREAM: %w", err)
+		}
+		cfg.Stream = val
+	}
+
+	if returnImages := env.get("PERPLEXITY_RETURN_IMAGES"); returnImages != "" {
 		val, err := strconv.ParseBool(returnImages)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_RETURN_IMAGES: %w", err)
@@ -115,7 +471,7 @@ func LoadConfig() (*Config, error) {
 		cfg.ReturnImages = val
 	}
 
-	if returnRelated := os.Getenv("PERPLEXITY_RETURN_RELATED"); returnRelated != "" {
+	if returnRelated := env.get("PERPLEXITY_RETURN_RELATED"); returnRelated != "" {
 		val, err := strconv.ParseBool(returnRelated)
 		if err != nil {
 			return nil, fmt.Errorf("invalid PERPLEXITY_RETURN_RELATED: %w", err)
@@ -123,26 +479,898 @@ func LoadConfig() (*Config, error) {
 		cfg.ReturnRelated = val
 	}
 
+	if retryOnEmpty := env.get("PERPLEXITY_RETRY_ON_EMPTY"); retryOnEmpty != "" {
+		val, err := strconv.ParseBool(retryOnEmpty)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_RETRY_ON_EMPTY: %w", err)
+		}
+		cfg.RetryOnEmpty = val
+	}
+
+	if maxDocBytes := env.get("PERPLEXITY_MAX_DOCUMENT_BYTES"); maxDocBytes != "" {
+		val, err := strconv.Atoi(maxDocBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_MAX_DOCUMENT_BYTES: %w", err)
+		}
+		if val <= 0 {
+			return nil, fmt.Errorf("PERPLEXITY_MAX_DOCUMENT_BYTES must be positive")
+		}
+		cfg.MaxDocumentBytes = val
+	}
+
+	// Tool calls name the documents to read, so only files inside this folder are allowed
+	if root := env.get("PERPLEXITY_DOCUMENT_ROOT"); root != "" {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_DOCUMENT_ROOT: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("PERPLEXITY_DOCUMENT_ROOT must be a directory")
+		}
+		cfg.DocumentRoot = root
+	}
+
+	if budget := env.get("PERPLEXITY_CONTEXT_TOKEN_BUDGET"); budget != "" {
+		val, err := strconv.Atoi(budget)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_CONTEXT_TOKEN_BUDGET: %w", err)
+		}
+		if val <= 0 {
+			return nil, fmt.Errorf("PERPLEXITY_CONTEXT_TOKEN_BUDGET must be positive")
+		}
+		cfg.ContextTokenBudget = val
+	}
+
+	if threshold := env.get("PERPLEXITY_OUTLINE_THRESHOLD"); threshold != "" {
+		val, err := strconv.Atoi(threshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_OUTLINE_THRESHOLD: %w", err)
+		}
+		if val < 0 {
+			return nil, fmt.Errorf("PERPLEXITY_OUTLINE_THRESHOLD must be non-negative")
+		}
+		cfg.OutlineThreshold = val
+	}
+
+	cfg.BlockedDomains = parseList(env.get("PERPLEXITY_BLOCKED_DOMAINS"))
+	cfg.AllowedDomains = parseList(env.get("PERPLEXITY_ALLOWED_DOMAINS"))
+
+	if ratio := env.get("PERPLEXITY_BLOCKED_REQUERY_RATIO"); ratio != "" {
+		val, err := strconv.ParseFloat(ratio, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_BLOCKED_REQUERY_RATIO: %w", err)
+		}
+		if val < 0 || val > 1 {
+			return nil, fmt.Errorf("PERPLEXITY_BLOCKED_REQUERY_RATIO must be between 0 and 1")
+		}
+		cfg.BlockedRequeryRatio = val
+	}
+
+	if rateLimit := env.get("PERPLEXITY_RATE_LIMIT"); rateLimit != "" {
+		val, err := strconv.Atoi(rateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_RATE_LIMIT: %w", err)
+		}
+		if val < 0 {
+			return nil, fmt.Errorf("PERPLEXITY_RATE_LIMIT must be non-negative")
+		}
+		cfg.RateLimit = val
+	}
+
+	cfg.UserAgent = env.get("PERPLEXITY_USER_AGENT")
+
+	if headers := env.get("PERPLEXITY_EXTRA_HEADERS"); headers != "" {
+		val, err := parseHeaders(headers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_EXTRA_HEADERS: %w", err)
+		}
+		cfg.ExtraHeaders = val
+	}
+
+	if proxyURL := env.get("PERPLEXITY_PROXY_URL"); proxyURL != "" {
+		if err := validateProxyURL(proxyURL); err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_PROXY_URL: %w", err)
+		}
+		cfg.ProxyURL = proxyURL
+	}
+
+	cfg.CABundle = env.get("PERPLEXITY_CA_BUNDLE")
+
+	if minVersion := env.get("PERPLEXITY_TLS_MIN_VERSION"); minVersion != "" {
+		val, ok := tlsVersions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("PERPLEXITY_TLS_MIN_VERSION must be one of 1.0, 1.1, 1.2, 1.3")
+		}
+		cfg.TLSMinVersion = val
+	}
+
+	if auditChain := env.get("PERPLEXITY_AUDIT_CHAIN"); auditChain != "" {
+		val, err := strconv.ParseBool(auditChain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_AUDIT_CHAIN: %w", err)
+		}
+		cfg.AuditChain = val
+	}
+
+	for _, term := range parseList(env.get("PERPLEXITY_ANONYMIZE_TERMS")) {
+		cfg.AnonymizeRules = append(cfg.AnonymizeRules, AnonymizeRule{Term: term})
+	}
+
+	if dictionary := env.get("PERPLEXITY_ANONYMIZE_FILE"); dictionary != "" {
+		rules, err := loadAnonymizeFile(dictionary)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_ANONYMIZE_FILE: %w", err)
+		}
+		cfg.AnonymizeRules = append(cfg.AnonymizeRules, rules...)
+	}
+
+	if translationModel := env.get("PERPLEXITY_TRANSLATION_MODEL"); translationModel != "" {
+		if err := ValidateModel(translationModel); err != nil || translationModel == types.ModelAuto {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TRANSLATION_MODEL: must be a model other than auto")
+		}
+		cfg.TranslationModel = translationModel
+	}
+
+	if transforms := env.get("PERPLEXITY_TRANSFORMS"); transforms != "" {
+		val, err := parseTransforms(transforms)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TRANSFORMS: %w", err)
+		}
+		cfg.Transforms = val
+	}
+
+	if examplesFile := env.get("PERPLEXITY_EXAMPLES_FILE"); examplesFile != "" {
+		examples, err := loadExamplesFile(examplesFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_EXAMPLES_FILE: %w", err)
+		}
+		cfg.Examples = *examples
+	}
+
+	if templatesFile := env.get("PERPLEXITY_TEMPLATES_FILE"); templatesFile != "" {
+		data, err := os.ReadFile(templatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TEMPLATES_FILE: %w", err)
+		}
+		templates, err := ParseTemplates(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TEMPLATES_FILE: %w", err)
+		}
+		cfg.Templates = templates
+	}
+
+	if clientsFile := env.get("PERPLEXITY_CLIENTS_FILE"); clientsFile != "" {
+		data, err := os.ReadFile(clientsFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_CLIENTS_FILE: %w", err)
+		}
+		clients, err := ParseClients(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_CLIENTS_FILE: %w", err)
+		}
+		cfg.Clients = clients
+	}
+
+	// Publishing targets are optional; each is enabled by setting its API token
+	cfg.Notion.Token = env.get("PERPLEXITY_NOTION_TOKEN")
+	cfg.Notion.DatabaseID = env.get("PERPLEXITY_NOTION_DATABASE_ID")
+	if property := env.get("PERPLEXITY_NOTION_TITLE_PROPERTY"); property != "" {
+		cfg.Notion.TitleProperty = property
+	}
+	cfg.Notion.TagsProperty = env.get("PERPLEXITY_NOTION_TAGS_PROPERTY")
+	if cfg.Notion.Enabled() && cfg.Notion.DatabaseID == "" {
+		return nil, fmt.Errorf("PERPLEXITY_NOTION_DATABASE_ID is required when PERPLEXITY_NOTION_TOKEN is set")
+	}
+
+	cfg.Confluence.URL = strings.TrimRight(env.get("PERPLEXITY_CONFLUENCE_URL"), "/")
+	cfg.Confluence.User = env.get("PERPLEXITY_CONFLUENCE_USER")
+	cfg.Confluence.Token = env.get("PERPLEXITY_CONFLUENCE_TOKEN")
+	cfg.Confluence.Space = env.get("PERPLEXITY_CONFLUENCE_SPACE")
+	if cfg.Confluence.Enabled() {
+		if cfg.Confluence.URL == "" || cfg.Confluence.Space == "" {
+			return nil, fmt.Errorf("PERPLEXITY_CONFLUENCE_URL and PERPLEXITY_CONFLUENCE_SPACE are required when PERPLEXITY_CONFLUENCE_TOKEN is set")
+		}
+		if parsed, err := url.Parse(cfg.Confluence.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("PERPLEXITY_CONFLUENCE_URL must be an http or https URL")
+		}
+	}
+
+	if webhook := env.get("PERPLEXITY_NOTIFY_WEBHOOK"); webhook != "" {
+		if parsed, err := url.Parse(webhook); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("PERPLEXITY_NOTIFY_WEBHOOK must be an http or https URL")
+		}
+		cfg.NotifyWebhook = webhook
+	}
+
+	if searchTypes := env.get("PERPLEXITY_NOTIFY_SEARCH_TYPES"); searchTypes != "" {
+		cfg.NotifySearchTypes = parseList(searchTypes)
+	}
+
+	if calendarURL := env.get("PERPLEXITY_CALENDAR_URL"); calendarURL != "" {
+		if !strings.Contains(calendarURL, "{ticker}") {
+			return nil, fmt.Errorf("PERPLEXITY_CALENDAR_URL must contain a {ticker} placeholder")
+		}
+		if parsed, err := url.Parse(strings.NewReplacer("{ticker}", "X", "{event}", "X").Replace(calendarURL)); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("PERPLEXITY_CALENDAR_URL must be an http or https URL")
+		}
+		cfg.CalendarURL = calendarURL
+	}
+	cfg.CalendarToken = env.get("PERPLEXITY_CALENDAR_TOKEN")
+
+	if tickersFile := env.get("PERPLEXITY_TICKERS_FILE"); tickersFile != "" {
+		tickers, err := loadTickersFile(tickersFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TICKERS_FILE: %w", err)
+		}
+		cfg.Tickers = tickers
+	}
+
+	if tickerLookup := env.get("PERPLEXITY_TICKER_LOOKUP"); tickerLookup != "" {
+		val, err := strconv.ParseBool(tickerLookup)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TICKER_LOOKUP: %w", err)
+		}
+		cfg.TickerLookup = val
+	}
+
+	if paperMetadata := env.get("PERPLEXITY_PAPER_METADATA"); paperMetadata != "" {
+		val, err := strconv.ParseBool(paperMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_PAPER_METADATA: %w", err)
+		}
+		cfg.PaperMetadata = val
+	}
+
+	if downloadImages := env.get("PERPLEXITY_DOWNLOAD_IMAGES"); downloadImages != "" {
+		val, err := strconv.ParseBool(downloadImages)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_DOWNLOAD_IMAGES: %w", err)
+		}
+		cfg.DownloadImages = val
+	}
+
+	if maxBytes := env.get("PERPLEXITY_IMAGE_MAX_BYTES"); maxBytes != "" {
+		val, err := strconv.Atoi(maxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_IMAGE_MAX_BYTES: %w", err)
+		}
+		if val <= 0 {
+			return nil, fmt.Errorf("PERPLEXITY_IMAGE_MAX_BYTES must be positive")
+		}
+		cfg.ImageMaxBytes = val
+	}
+
+	if archive := env.get("PERPLEXITY_ARCHIVE_CITATIONS"); archive != "" {
+		val, err := strconv.ParseBool(archive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_ARCHIVE_CITATIONS: %w", err)
+		}
+		cfg.ArchiveCitations = val
+	}
+
+	if deepSources := env.get("PERPLEXITY_DEEP_SOURCES_COUNT"); deepSources != "" {
+		val, err := strconv.Atoi(deepSources)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_DEEP_SOURCES_COUNT: %w", err)
+		}
+		if val <= 0 {
+			return nil, fmt.Errorf("PERPLEXITY_DEEP_SOURCES_COUNT must be positive")
+		}
+		cfg.DeepSourcesCount = val
+	}
+
+	if mismatch := env.get("PERPLEXITY_LANGUAGE_MISMATCH"); mismatch != "" {
+		switch mismatch {
+		case LanguageMismatchWarn, LanguageMismatchRetry, LanguageMismatchOff:
+			cfg.LanguageMismatch = mismatch
+		default:
+			return nil, fmt.Errorf("PERPLEXITY_LANGUAGE_MISMATCH must be one of warn, retry, off")
+		}
+	}
+
+	if level := env.get("PERPLEXITY_CONTENT_FILTER"); level != "" {
+		switch level {
+		case ContentFilterOff, ContentFilterMild, ContentFilterStrong, ContentFilterSevere:
+			cfg.ContentFilter = level
+		default:
+			return nil, fmt.Errorf("PERPLEXITY_CONTENT_FILTER must be one of off, mild, strong, severe")
+		}
+	}
+
+	if action := env.get("PERPLEXITY_CONTENT_FILTER_ACTION"); action != "" {
+		if action != ContentFilterMask && action != ContentFilterDrop {
+			return nil, fmt.Errorf("PERPLEXITY_CONTENT_FILTER_ACTION must be mask or drop")
+		}
+		cfg.ContentFilterAction = action
+	}
+
+	cfg.ContentFilterTerms = parseList(env.get("PERPLEXITY_CONTENT_FILTER_TERMS"))
+
+	if window := env.get("PERPLEXITY_DUPLICATE_WINDOW"); window != "" {
+		val, err := time.ParseDuration(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_DUPLICATE_WINDOW: %w", err)
+		}
+		if val < 0 {
+			return nil, fmt.Errorf("PERPLEXITY_DUPLICATE_WINDOW must not be negative")
+		}
+		cfg.DuplicateWindow = val
+	}
+
+	if threshold := env.get("PERPLEXITY_SIMILARITY_THRESHOLD"); threshold != "" {
+		val, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_SIMILARITY_THRESHOLD: %w", err)
+		}
+		if val < 0 || val > 1 {
+			return nil, fmt.Errorf("PERPLEXITY_SIMILARITY_THRESHOLD must be between 0 and 1")
+		}
+		cfg.SimilarityThreshold = val
+	}
+
+	if ttl := env.get("PERPLEXITY_SIMILARITY_TTL"); ttl != "" {
+		val, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_SIMILARITY_TTL: %w", err)
+		}
+		if val <= 0 {
+			return nil, fmt.Errorf("PERPLEXITY_SIMILARITY_TTL must be positive")
+		}
+		cfg.SimilarityTTL = val
+	}
+
+	if level := env.get("PERPLEXITY_LOG_LEVEL"); level != "" {
+		switch level {
+		case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+			cfg.LogLevel = level
+		default:
+			return nil, fmt.Errorf("PERPLEXITY_LOG_LEVEL must be one of debug, info, warn, error")
+		}
+	}
+
+	if format := env.get("PERPLEXITY_LOG_FORMAT"); format != "" {
+		if format != LogFormatText && format != LogFormatJSON {
+			return nil, fmt.Errorf("PERPLEXITY_LOG_FORMAT must be text or json")
+		}
+		cfg.LogFormat = format
+	}
+	cfg.LogFile = env.get("PERPLEXITY_LOG_FILE")
+
+	if size := env.get("PERPLEXITY_RESULT_CACHE_SIZE"); size != "" {
+		val, err := strconv.Atoi(size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_RESULT_CACHE_SIZE: %w", err)
+		}
+		if val < 0 {
+			return nil, fmt.Errorf("PERPLEXITY_RESULT_CACHE_SIZE must not be negative")
+		}
+		cfg.ResultCacheSize = val
+	}
+
+	if roots := env.get("PERPLEXITY_PROJECT_ROOTS"); roots != "" {
+		val, err := parseProjectRoots(roots)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_PROJECT_ROOTS: %w", err)
+		}
+		cfg.ProjectRoots = val
+	}
+
+	if backend := env.get("PERPLEXITY_CACHE_BACKEND"); backend != "" {
+		if backend != CacheBackendFilesystem && backend != CacheBackendS3 {
+			return nil, fmt.Errorf("PERPLEXITY_CACHE_BACKEND must be filesystem or s3")
+		}
+		cfg.CacheBackend = backend
+	}
+	if cfg.CacheBackend == CacheBackendS3 {
+		if err := loadS3Config(&cfg.S3, env); err != nil {
+			return nil, err
+		}
+	}
+
+	if redisURL := env.get("PERPLEXITY_REDIS_URL"); redisURL != "" {
+		if !strings.HasPrefix(redisURL, "redis://") && !strings.HasPrefix(redisURL, "rediss://") {
+			return nil, fmt.Errorf("PERPLEXITY_REDIS_URL must start with redis:// or rediss://")
+		}
+		cfg.RedisURL = redisURL
+	}
+	if prefix := env.get("PERPLEXITY_REDIS_PREFIX"); prefix != "" {
+		cfg.RedisPrefix = prefix
+	}
+
+	if traces := env.get("PERPLEXITY_REASONING_TRACES"); traces != "" {
+		switch traces {
+		case ReasoningTracesStrip, ReasoningTracesKeep, ReasoningTracesAppendix:
+			cfg.ReasoningTraces = traces
+		default:
+			return nil, fmt.Errorf("PERPLEXITY_REASONING_TRACES must be one of strip, keep, appendix")
+		}
+	}
+
+	if mode := env.get("PERPLEXITY_ARGUMENT_MODE"); mode != "" {
+		switch mode {
+		case ArgumentModeLenient, ArgumentModeStrict:
+			cfg.ArgumentMode = mode
+		default:
+			return nil, fmt.Errorf("PERPLEXITY_ARGUMENT_MODE must be one of lenient, strict")
+		}
+	}
+
+	if timezone := env.get("PERPLEXITY_TIMEZONE"); timezone != "" {
+		if err := ValidateTimezone(timezone); err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_TIMEZONE: %w", err)
+		}
+		cfg.Timezone = timezone
+	}
+
+	if base := env.get("PERPLEXITY_BASE_CURRENCY"); base != "" {
+		base = strings.ToUpper(strings.TrimSpace(base))
+		if !currencyCodePattern.MatchString(base) {
+			return nil, fmt.Errorf("PERPLEXITY_BASE_CURRENCY must be a three-letter currency code such as USD")
+		}
+		cfg.BaseCurrency = base
+	}
+	cfg.FXRates = env.get("PERPLEXITY_FX_RATES")
+	if cfg.FXRates != "" && cfg.BaseCurrency == "" {
+		return nil, fmt.Errorf("PERPLEXITY_FX_RATES requires PERPLEXITY_BASE_CURRENCY")
+	}
+
+	if separate := env.get("PERPLEXITY_SEPARATE_OPINIONS"); separate != "" {
+		val, err := strconv.ParseBool(separate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_SEPARATE_OPINIONS: %w", err)
+		}
+		cfg.SeparateOpinions = val
+	}
+
+	if confidence := env.get("PERPLEXITY_CONFIDENCE"); confidence != "" {
+		if err := ValidateConfidence(confidence); err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_CONFIDENCE: %w", err)
+		}
+		cfg.Confidence = confidence
+	}
+
+	cfg.SMTP.Host = env.get("PERPLEXITY_SMTP_HOST")
+	cfg.SMTP.Username = env.get("PERPLEXITY_SMTP_USERNAME")
+	cfg.SMTP.Password = env.get("PERPLEXITY_SMTP_PASSWORD")
+	cfg.SMTP.From = env.get("PERPLEXITY_SMTP_FROM")
+	if port := env.get("PERPLEXITY_SMTP_PORT"); port != "" {
+		val, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_SMTP_PORT: %w", err)
+		}
+		if val <= 0 || val > 65535 {
+			return nil, fmt.Errorf("PERPLEXITY_SMTP_PORT must be between 1 and 65535")
+		}
+		cfg.SMTP.Port = val
+	}
+
+	if digestTo := env.get("PERPLEXITY_DIGEST_TO"); digestTo != "" {
+		cfg.DigestTo = parseList(digestTo)
+		if cfg.SMTP.Host == "" || cfg.SMTP.From == "" {
+			return nil, fmt.Errorf("PERPLEXITY_SMTP_HOST and PERPLEXITY_SMTP_FROM are required when PERPLEXITY_DIGEST_TO is set")
+		}
+	}
+
+	if watchesFile := env.get("PERPLEXITY_WATCHES_FILE"); watchesFile != "" {
+		data, err := os.ReadFile(watchesFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_WATCHES_FILE: %w", err)
+		}
+		watches, err := ParseWatches(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PERPLEXITY_WATCHES_FILE: %w", err)
+		}
+		for _, watch := range watches {
+			if len(watch.Recipients) > 0 && (cfg.SMTP.Host == "" || cfg.SMTP.From == "") {
+				return nil, fmt.Errorf("PERPLEXITY_SMTP_HOST and PERPLEXITY_SMTP_FROM are required when a watch has recipients")
+			}
+		}
+		cfg.Watches = watches
+	}
+
 	// Results folder is optional - empty string means no caching
-	cfg.ResultsRootFolder = os.Getenv("PERPLEXITY_RESULTS_ROOT_FOLDER")
+	cfg.ResultsRootFolder = env.get("PERPLEXITY_RESULTS_ROOT_FOLDER")
+	cfg.SharedResultsFolder = env.get("PERPLEXITY_SHARED_RESULTS_FOLDER")
+
+	// HTTP listen address is optional - empty string means stdio only
+	cfg.HTTPAddr = env.get("PERPLEXITY_HTTP_ADDR")
+	cfg.FeedToken = env.get("PERPLEXITY_FEED_TOKEN")
+
+	// gRPC listen address is optional - empty string means no gRPC service
+	cfg.GRPCAddr = env.get("PERPLEXITY_GRPC_ADDR")
+
+	cfg.TLSCert = env.get("PERPLEXITY_TLS_CERT")
+	cfg.TLSKey = env.get("PERPLEXITY_TLS_KEY")
+	cfg.TLSClientCA = env.get("PERPLEXITY_TLS_CLIENT_CA")
+	if err := validateServerTLS(cfg); err != nil {
+		return nil, err
+	}
+
+	// Usage statistics are opt-in and stay on this machine
+	cfg.UsageStatsFile = env.get("PERPLEXITY_USAGE_STATS_FILE")
 
 	return cfg, nil
 }
 
-// validateModel checks if the model is valid
-func validateModel(model string) error {
-	validModels := map[string]bool{
-		types.ModelSonar:    true,
-		types.ModelSonarPro: true,
+// validateServerTLS checks that the HTTP server's certificate, key, and client CA are given
+// together as needed and can be loaded
+func validateServerTLS(cfg *Config) error {
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return fmt.Errorf("PERPLEXITY_TLS_CERT and PERPLEXITY_TLS_KEY must be set together")
+	}
+	if cfg.TLSClientCA != "" && cfg.TLSCert == "" {
+		return fmt.Errorf("PERPLEXITY_TLS_CLIENT_CA requires PERPLEXITY_TLS_CERT and PERPLEXITY_TLS_KEY")
+	}
+	if cfg.TLSCert != "" {
+		if _, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey); err != nil {
+			return fmt.Errorf("invalid PERPLEXITY_TLS_CERT or PERPLEXITY_TLS_KEY: %w", err)
+		}
+	}
+	if cfg.TLSClientCA != "" {
+		if _, err := LoadCertPool(cfg.TLSClientCA); err != nil {
+			return fmt.Errorf("invalid PERPLEXITY_TLS_CLIENT_CA: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadCertPool reads a pool of the PEM certificates in path
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("'%s' contains no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// parseList splits a comma-separated value into trimmed, non-empty items
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadS3Config reads the bucket settings, falling back to the standard AWS credential variables
+func loadS3Config(s3 *S3Config, env environment) error {
+	s3.Bucket = env.get("PERPLEXITY_S3_BUCKET")
+	if s3.Bucket == "" {
+		return fmt.Errorf("PERPLEXITY_S3_BUCKET is required when PERPLEXITY_CACHE_BACKEND is s3")
+	}
+	if region := env.get("PERPLEXITY_S3_REGION"); region != "" {
+		s3.Region = region
+	}
+
+	s3.Endpoint = env.get("PERPLEXITY_S3_ENDPOINT")
+	if s3.Endpoint == "" {
+		s3.Endpoint = "https://s3." + s3.Region + ".amazonaws.com"
+	}
+	if u, err := url.Parse(s3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("PERPLEXITY_S3_ENDPOINT must be an http or https URL")
+	}
+
+	s3.AccessKeyID = env.first("PERPLEXITY_S3_ACCESS_KEY_ID", "AWS_ACCESS_KEY_ID")
+	s3.SecretAccessKey = env.first("PERPLEXITY_S3_SECRET_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY")
+	s3.SessionToken = env.first("PERPLEXITY_S3_SESSION_TOKEN", "AWS_SESSION_TOKEN")
+	if s3.AccessKeyID == "" || s3.SecretAccessKey == "" {
+		return fmt.Errorf("PERPLEXITY_S3_ACCESS_KEY_ID and PERPLEXITY_S3_SECRET_ACCESS_KEY (or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY) are required when PERPLEXITY_CACHE_BACKEND is s3")
+	}
+	return nil
+}
+
+// currencyCodePattern matches ISO 4217 currency codes
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// projectNamePattern matches project names, which double as folder names
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// ValidateProjectName checks that a project name is safe to use as a folder name
+func ValidateProjectName(name string) error {
+	if !projectNamePattern.MatchString(name) {
+		return fmt.Errorf("project '%s' must be 1-64 letters, digits, hyphens or underscores, starting with a letter or digit", name)
 	}
+	return nil
+}
 
-	if !validModels[model] {
-		return fmt.Errorf("model '%s' is not valid. Available models: 'sonar' (fast, basic search) or 'sonar-pro' (comprehensive search with better depth)", model)
+// parseProjectRoots parses semicolon-separated "project=/path" pairs
+func parseProjectRoots(value string) (map[string]string, error) {
+	roots := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, root, ok := strings.Cut(pair, "=")
+		name, root = strings.TrimSpace(name), strings.TrimSpace(root)
+		if !ok || root == "" {
+			return nil, fmt.Errorf("entry '%s' must be in 'project=/path' form", strings.TrimSpace(pair))
+		}
+		if err := ValidateProjectName(name); err != nil {
+			return nil, err
+		}
+		roots[name] = root
+	}
+	return roots, nil
+}
+
+// reservedHeaders are set by the client itself and cannot be overridden
+var reservedHeaders = map[string]bool{
+	"Authorization":  true,
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+}
+
+// parseHeaders parses semicolon-separated "Name: value" pairs
+func parseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(pair, ":")
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("header '%s' must be in 'Name: value' form", strings.TrimSpace(pair))
+		}
+		if reservedHeaders[name] {
+			return nil, fmt.Errorf("header '%s' is set by the server and cannot be overridden", name)
+		}
+		headers[name] = strings.TrimSpace(val)
+	}
+	return headers, nil
+}
+
+// tlsVersions maps PERPLEXITY_TLS_MIN_VERSION values to crypto/tls constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// validateProxyURL checks that a proxy URL has a supported scheme and a host
+func validateProxyURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme '%s'. Use http, https, socks5, or socks5h", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("proxy URL must include a host")
 	}
 	return nil
 }
 
+// loadAnonymizeFile reads one "term" or "term = replacement" entry per line, ignoring blanks and # comments
+func loadAnonymizeFile(path string) ([]AnonymizeRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []AnonymizeRule
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		term, replacement, _ := strings.Cut(line, "=")
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("line %d has no term", i+1)
+		}
+		rules = append(rules, AnonymizeRule{Term: term, Replacement: strings.TrimSpace(replacement)})
+	}
+	return rules, nil
+}
+
+// loadTickersFile reads "TICKER, Company name[, alias...]" lines, ignoring blanks and # comments
+func loadTickersFile(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tickers := make(map[string][]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := parseList(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d must be 'TICKER, Company name[, alias...]'", i+1)
+		}
+		tickers[strings.ToUpper(fields[0])] = fields[1:]
+	}
+	return tickers, nil
+}
+
+// parseTransforms parses semicolon-separated sections, each either a default
+// comma-separated transform list or "tool=list" for a single tool
+func parseTransforms(value string) (TransformsConfig, error) {
+	transforms := TransformsConfig{Tools: make(map[string][]string)}
+	for _, section := range strings.Split(value, ";") {
+		if strings.TrimSpace(section) == "" {
+			continue
+		}
+		tool, list, found := strings.Cut(section, "=")
+		if !found {
+			transforms.Default = parseList(section)
+			continue
+		}
+		tool = strings.TrimSpace(tool)
+		if tool == "" {
+			return TransformsConfig{}, fmt.Errorf("section '%s' has no tool name", strings.TrimSpace(section))
+		}
+		transforms.Tools[tool] = parseList(list)
+	}
+	return transforms, nil
+}
+
+// loadExamplesFile reads few-shot examples from a JSON file
+func loadExamplesFile(path string) (*ExamplesConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var examples ExamplesConfig
+	if err := json.Unmarshal(data, &examples); err != nil {
+		return nil, fmt.Errorf("failed to parse examples: %w", err)
+	}
+
+	if err := ValidateExamples(examples.Default); err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	for tool, toolExamples := range examples.Tools {
+		if err := ValidateExamples(toolExamples); err != nil {
+			return nil, fmt.Errorf("%s: %w", tool, err)
+		}
+	}
+	return &examples, nil
+}
+
+// ValidateExamples checks that every example has both a question and an answer
+func ValidateExamples(examples []types.Example) error {
+	for i, example := range examples {
+		if strings.TrimSpace(example.Question) == "" || strings.TrimSpace(example.Answer) == "" {
+			return fmt.Errorf("example %d must have a question and an answer", i+1)
+		}
+	}
+	return nil
+}
+
+// templateNamePattern restricts template names to what fits in a tool argument and a file
+var templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ParseTemplates reads query templates from JSON, an object mapping each template's name to
+// its definition. Templates without a tool run perplexity_search.
+func ParseTemplates(data []byte) (map[string]QueryTemplate, error) {
+	var templates map[string]QueryTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+	for name, tmpl := range templates {
+		if !templateNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("template name '%s' may only contain letters, digits, - and _", name)
+		}
+		if tmpl.Tool == "" {
+			tmpl.Tool = "perplexity_search"
+		}
+		if tmpl.Tool == "run_template" {
+			return nil, fmt.Errorf("template '%s' cannot run another template", name)
+		}
+		if strings.TrimSpace(tmpl.Query) == "" && len(tmpl.Arguments) == 0 {
+			return nil, fmt.Errorf("template '%s' must have a query or arguments", name)
+		}
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
+
+// ParseWatches reads digest watches from JSON, an object mapping each watch's name to its
+// query, search type, period, and recipients
+func ParseWatches(data []byte) (map[string]WatchConfig, error) {
+	var watches map[string]WatchConfig
+	if err := json.Unmarshal(data, &watches); err != nil {
+		return nil, fmt.Errorf("failed to parse watches: %w", err)
+	}
+	for name, watch := range watches {
+		if !templateNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("watch name '%s' may only contain letters, digits, - and _", name)
+		}
+		if strings.TrimSpace(watch.Query) == "" {
+			return nil, fmt.Errorf("watch '%s' must have a query", name)
+		}
+		if watch.Period != "daily" && watch.Period != "weekly" {
+			return nil, fmt.Errorf("watch '%s' must have a period of daily or weekly", name)
+		}
+		if watch.SearchType == "" {
+			watch.SearchType = "general"
+		}
+		for _, recipient := range watch.Recipients {
+			if !strings.Contains(recipient, "@") {
+				return nil, fmt.Errorf("watch '%s' has an invalid recipient '%s'", name, recipient)
+			}
+		}
+		watches[name] = watch
+	}
+	return watches, nil
+}
+
+// ParseClients reads REST API clients from JSON, an object mapping each client's name to its
+// token and quotas. Every client needs a token of its own.
+func ParseClients(data []byte) (map[string]ClientConfig, error) {
+	var clients map[string]ClientConfig
+	if err := json.Unmarshal(data, &clients); err != nil {
+		return nil, fmt.Errorf("failed to parse clients: %w", err)
+	}
+	owners := make(map[string]string, len(clients))
+	for name, client := range clients {
+		if !templateNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("client name '%s' may only contain letters, digits, - and _", name)
+		}
+		if strings.TrimSpace(client.Token) == "" {
+			return nil, fmt.Errorf("client '%s' must have a token", name)
+		}
+		if owner, ok := owners[client.Token]; ok {
+			return nil, fmt.Errorf("clients '%s' and '%s' have the same token", min(owner, name), max(owner, name))
+		}
+		owners[client.Token] = name
+		if client.RateLimit < 0 || client.DailyRequests < 0 {
+			return nil, fmt.Errorf("client '%s' must have non-negative rate_limit and daily_requests", name)
+		}
+	}
+	return clients, nil
+}
+
+// ValidateModel checks that model is auto or one of the models in the model registry
+func ValidateModel(model string) error {
+	if model == types.ModelAuto || models.Default.Valid(model) {
+		return nil
+	}
+
+	var available []string
+	for _, m := range models.Default.List() {
+		if m.Description != "" {
+			available = append(available, fmt.Sprintf("'%s' (%s)", m.ID, m.Description))
+		} else {
+			available = append(available, fmt.Sprintf("'%s'", m.ID))
+		}
+	}
+	return fmt.Errorf("model '%s' is not valid. Available models: %s or 'auto' (picked per query)", model, strings.Join(available, ", "))
+}
+
+// ValidateTimezone checks that a time zone is an IANA name such as Europe/Berlin
+func ValidateTimezone(timezone string) error {
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return fmt.Errorf("unknown time zone '%s': use an IANA name such as America/New_York or UTC", timezone)
+	}
+	return nil
+}
+
+// ValidateConfidence checks that a confidence mode is one of the supported ones
+func ValidateConfidence(mode string) error {
+	switch mode {
+	case ConfidenceOff, ConfidenceCitations, ConfidenceModel:
+		return nil
+	}
+	return fmt.Errorf("invalid confidence mode '%s': must be one of off, citations, model", mode)
+}
+
 // GetAPIKey returns the API key (for testing purposes)
 func (c *Config) GetAPIKey() string {
 	return c.APIKey
-}
\ No newline at end of file
+}
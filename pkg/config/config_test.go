@@ -2,9 +2,12 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prasanthmj/perplexity/pkg/models"
 	"github.com/prasanthmj/perplexity/pkg/types"
 )
 
@@ -36,6 +39,44 @@ func TestLoadConfigWithDefaults(t *testing.T) {
 	if cfg.ReturnImages != types.DefaultReturnImages {
 		t.Errorf("ReturnImages mismatch: got %v, want %v", cfg.ReturnImages, types.DefaultReturnImages)
 	}
+	if cfg.DuplicateWindow != 30*time.Second {
+		t.Errorf("DuplicateWindow mismatch: got %v, want %v", cfg.DuplicateWindow, 30*time.Second)
+	}
+	if cfg.SimilarityThreshold != 0 || cfg.SimilarityTTL != time.Hour {
+		t.Errorf("Similarity defaults mismatch: got %v, %v", cfg.SimilarityThreshold, cfg.SimilarityTTL)
+	}
+	if cfg.LogLevel != LogLevelInfo || cfg.LogFormat != LogFormatText {
+		t.Errorf("Log defaults mismatch: got %q, %q", cfg.LogLevel, cfg.LogFormat)
+	}
+	if cfg.ResultCacheSize != 128 {
+		t.Errorf("ResultCacheSize mismatch: got %d, want 128", cfg.ResultCacheSize)
+	}
+	if cfg.CacheBackend != CacheBackendFilesystem {
+		t.Errorf("CacheBackend mismatch: got %q, want %q", cfg.CacheBackend, CacheBackendFilesystem)
+	}
+}
+
+func TestLoadConfigS3Backend(t *testing.T) {
+	for k, v := range map[string]string{
+		"PERPLEXITY_API_KEY":       "test-key",
+		"PERPLEXITY_CACHE_BACKEND": "s3",
+		"PERPLEXITY_S3_BUCKET":     "research",
+		"PERPLEXITY_S3_REGION":     "eu-west-1",
+		"AWS_ACCESS_KEY_ID":        "AKID",
+		"AWS_SECRET_ACCESS_KEY":    "secret",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	want := S3Config{Endpoint: "https://s3.eu-west-1.amazonaws.com", Region: "eu-west-1", Bucket: "research", AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	if cfg.S3 != want {
+		t.Errorf("S3 mismatch: got %+v, want %+v", cfg.S3, want)
+	}
 }
 
 func TestLoadConfigMissingAPIKey(t *testing.T) {
@@ -51,6 +92,21 @@ func TestLoadConfigMissingAPIKey(t *testing.T) {
 	}
 }
 
+func TestLoadConfigCredentialStore(t *testing.T) {
+	t.Setenv("PERPLEXITY_API_KEY", "")
+	t.Setenv("PERPLEXITY_CREDENTIAL_STORE", "sometimes")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "invalid PERPLEXITY_CREDENTIAL_STORE") {
+		t.Errorf("Expected an error for an invalid PERPLEXITY_CREDENTIAL_STORE, got %v", err)
+	}
+
+	// A key in the environment is used without consulting the store
+	t.Setenv("PERPLEXITY_API_KEY", "env-key")
+	t.Setenv("PERPLEXITY_CREDENTIAL_STORE", "true")
+	if cfg, err := LoadConfig(); err != nil || cfg.APIKey != "env-key" {
+		t.Errorf("Expected the environment's key, got %v", err)
+	}
+}
+
 func TestLoadConfigWithCustomValues(t *testing.T) {
 	// Set all environment variables
 	envVars := map[string]string{
@@ -61,6 +117,7 @@ func TestLoadConfigWithCustomValues(t *testing.T) {
 		"PERPLEXITY_TOP_P":            "0.95",
 		"PERPLEXITY_TOP_K":            "10",
 		"PERPLEXITY_TIMEOUT":          "60s",
+		"PERPLEXITY_STREAM":           "true",
 		"PERPLEXITY_RETURN_IMAGES":    "true",
 		"PERPLEXITY_RETURN_RELATED":   "true",
 	}
@@ -96,6 +153,9 @@ func TestLoadConfigWithCustomValues(t *testing.T) {
 	if cfg.Timeout != 60*time.Second {
 		t.Errorf("Timeout mismatch: got %v, want %v", cfg.Timeout, 60*time.Second)
 	}
+	if !cfg.Stream {
+		t.Error("Stream mismatch: got false, want true")
+	}
 	if cfg.ReturnImages != true {
 		t.Errorf("ReturnImages mismatch: got %v, want true", cfg.ReturnImages)
 	}
@@ -166,6 +226,371 @@ func TestLoadConfigInvalidValues(t *testing.T) {
 			},
 			wantErr: "invalid PERPLEXITY_RETURN_IMAGES:",
 		},
+		{
+			name: "requery ratio out of range",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":               "test-key",
+				"PERPLEXITY_BLOCKED_REQUERY_RATIO": "1.5",
+			},
+			wantErr: "PERPLEXITY_BLOCKED_REQUERY_RATIO must be between 0 and 1",
+		},
+		{
+			name: "negative rate limit",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":    "test-key",
+				"PERPLEXITY_RATE_LIMIT": "-1",
+			},
+			wantErr: "PERPLEXITY_RATE_LIMIT must be non-negative",
+		},
+		{
+			name: "negative outline threshold",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":           "test-key",
+				"PERPLEXITY_OUTLINE_THRESHOLD": "-5",
+			},
+			wantErr: "PERPLEXITY_OUTLINE_THRESHOLD must be non-negative",
+		},
+		{
+			name: "unsupported proxy scheme",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":   "test-key",
+				"PERPLEXITY_PROXY_URL": "ftp://proxy.internal:21",
+			},
+			wantErr: "invalid PERPLEXITY_PROXY_URL:",
+		},
+		{
+			name: "invalid TLS min version",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":         "test-key",
+				"PERPLEXITY_TLS_MIN_VERSION": "1.4",
+			},
+			wantErr: "PERPLEXITY_TLS_MIN_VERSION must be one of",
+		},
+		{
+			name: "notion token without database",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":      "test-key",
+				"PERPLEXITY_NOTION_TOKEN": "secret",
+			},
+			wantErr: "PERPLEXITY_NOTION_DATABASE_ID is required",
+		},
+		{
+			name: "confluence token without space",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":          "test-key",
+				"PERPLEXITY_CONFLUENCE_TOKEN": "secret",
+				"PERPLEXITY_CONFLUENCE_URL":   "https://example.atlassian.net/wiki",
+			},
+			wantErr: "PERPLEXITY_CONFLUENCE_URL and PERPLEXITY_CONFLUENCE_SPACE are required",
+		},
+		{
+			name: "invalid confluence URL",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":          "test-key",
+				"PERPLEXITY_CONFLUENCE_TOKEN": "secret",
+				"PERPLEXITY_CONFLUENCE_URL":   "example.atlassian.net",
+				"PERPLEXITY_CONFLUENCE_SPACE": "RES",
+			},
+			wantErr: "PERPLEXITY_CONFLUENCE_URL must be an http or https URL",
+		},
+		{
+			name: "invalid notify webhook",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":        "test-key",
+				"PERPLEXITY_NOTIFY_WEBHOOK": "hooks.slack.com/services/T/B/X",
+			},
+			wantErr: "PERPLEXITY_NOTIFY_WEBHOOK must be an http or https URL",
+		},
+		{
+			name: "invalid SMTP port",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":   "test-key",
+				"PERPLEXITY_SMTP_PORT": "70000",
+			},
+			wantErr: "PERPLEXITY_SMTP_PORT must be between 1 and 65535",
+		},
+		{
+			name: "digest recipients without SMTP host",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":   "test-key",
+				"PERPLEXITY_DIGEST_TO": "team@example.com",
+			},
+			wantErr: "PERPLEXITY_SMTP_HOST and PERPLEXITY_SMTP_FROM are required",
+		},
+		{
+			name: "missing watches file",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":      "test-key",
+				"PERPLEXITY_WATCHES_FILE": "/nonexistent/watches.json",
+			},
+			wantErr: "invalid PERPLEXITY_WATCHES_FILE",
+		},
+		{
+			name: "calendar URL without ticker placeholder",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":      "test-key",
+				"PERPLEXITY_CALENDAR_URL": "https://calendar.example.com/events",
+			},
+			wantErr: "PERPLEXITY_CALENDAR_URL must contain a {ticker} placeholder",
+		},
+		{
+			name: "invalid ticker lookup",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_TICKER_LOOKUP": "sometimes",
+			},
+			wantErr: "invalid PERPLEXITY_TICKER_LOOKUP",
+		},
+		{
+			name: "invalid paper metadata",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":        "test-key",
+				"PERPLEXITY_PAPER_METADATA": "maybe",
+			},
+			wantErr: "invalid PERPLEXITY_PAPER_METADATA",
+		},
+		{
+			name: "invalid download images",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":         "test-key",
+				"PERPLEXITY_DOWNLOAD_IMAGES": "sometimes",
+			},
+			wantErr: "invalid PERPLEXITY_DOWNLOAD_IMAGES",
+		},
+		{
+			name: "zero image max bytes",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":         "test-key",
+				"PERPLEXITY_IMAGE_MAX_BYTES": "0",
+			},
+			wantErr: "PERPLEXITY_IMAGE_MAX_BYTES must be positive",
+		},
+		{
+			name: "invalid archive citations",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":           "test-key",
+				"PERPLEXITY_ARCHIVE_CITATIONS": "often",
+			},
+			wantErr: "invalid PERPLEXITY_ARCHIVE_CITATIONS",
+		},
+		{
+			name: "zero deep sources count",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":           "test-key",
+				"PERPLEXITY_DEEP_SOURCES_COUNT": "0",
+			},
+			wantErr: "PERPLEXITY_DEEP_SOURCES_COUNT must be positive",
+		},
+		{
+			name: "invalid language mismatch mode",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":           "test-key",
+				"PERPLEXITY_LANGUAGE_MISMATCH": "fix",
+			},
+			wantErr: "PERPLEXITY_LANGUAGE_MISMATCH must be one of warn, retry, off",
+		},
+		{
+			name: "invalid content filter level",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":        "test-key",
+				"PERPLEXITY_CONTENT_FILTER": "high",
+			},
+			wantErr: "PERPLEXITY_CONTENT_FILTER must be one of off, mild, strong, severe",
+		},
+		{
+			name: "invalid content filter action",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":               "test-key",
+				"PERPLEXITY_CONTENT_FILTER_ACTION": "blur",
+			},
+			wantErr: "PERPLEXITY_CONTENT_FILTER_ACTION must be mask or drop",
+		},
+		{
+			name: "zero context token budget",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":              "test-key",
+				"PERPLEXITY_CONTEXT_TOKEN_BUDGET": "0",
+			},
+			wantErr: "PERPLEXITY_CONTEXT_TOKEN_BUDGET must be positive",
+		},
+		{
+			name: "negative duplicate window",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":          "test-key",
+				"PERPLEXITY_DUPLICATE_WINDOW": "-5s",
+			},
+			wantErr: "PERPLEXITY_DUPLICATE_WINDOW must not be negative",
+		},
+		{
+			name: "similarity threshold above one",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":              "test-key",
+				"PERPLEXITY_SIMILARITY_THRESHOLD": "1.5",
+			},
+			wantErr: "PERPLEXITY_SIMILARITY_THRESHOLD must be between 0 and 1",
+		},
+		{
+			name: "invalid log level",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":   "test-key",
+				"PERPLEXITY_LOG_LEVEL": "verbose",
+			},
+			wantErr: "PERPLEXITY_LOG_LEVEL must be one of debug, info, warn, error",
+		},
+		{
+			name: "invalid log format",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":    "test-key",
+				"PERPLEXITY_LOG_FORMAT": "xml",
+			},
+			wantErr: "PERPLEXITY_LOG_FORMAT must be text or json",
+		},
+		{
+			name: "negative result cache size",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":           "test-key",
+				"PERPLEXITY_RESULT_CACHE_SIZE": "-1",
+			},
+			wantErr: "PERPLEXITY_RESULT_CACHE_SIZE must not be negative",
+		},
+		{
+			name: "invalid project name",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_PROJECT_ROOTS": "thesis=/data/thesis;../other=/tmp/other",
+			},
+			wantErr: "invalid PERPLEXITY_PROJECT_ROOTS: project '../other' must be",
+		},
+		{
+			name: "invalid cache backend",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_CACHE_BACKEND": "redis",
+			},
+			wantErr: "PERPLEXITY_CACHE_BACKEND must be filesystem or s3",
+		},
+		{
+			name: "s3 backend without bucket",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_CACHE_BACKEND": "s3",
+			},
+			wantErr: "PERPLEXITY_S3_BUCKET is required",
+		},
+		{
+			name: "redis url without redis scheme",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":   "test-key",
+				"PERPLEXITY_REDIS_URL": "localhost:6379",
+			},
+			wantErr: "PERPLEXITY_REDIS_URL must start with redis:// or rediss://",
+		},
+		{
+			name: "auto fallback model",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":        "test-key",
+				"PERPLEXITY_FALLBACK_MODEL": "auto",
+			},
+			wantErr: "invalid PERPLEXITY_FALLBACK_MODEL",
+		},
+		{
+			name: "unknown reasoning traces mode",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":          "test-key",
+				"PERPLEXITY_REASONING_TRACES": "hide",
+			},
+			wantErr: "PERPLEXITY_REASONING_TRACES must be one of strip, keep, appendix",
+		},
+		{
+			name: "unknown argument mode",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_ARGUMENT_MODE": "picky",
+			},
+			wantErr: "PERPLEXITY_ARGUMENT_MODE must be one of lenient, strict",
+		},
+		{
+			name: "unknown confidence mode",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":    "test-key",
+				"PERPLEXITY_CONFIDENCE": "always",
+			},
+			wantErr: "invalid PERPLEXITY_CONFIDENCE",
+		},
+		{
+			name: "invalid separate opinions",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":           "test-key",
+				"PERPLEXITY_SEPARATE_OPINIONS": "sometimes",
+			},
+			wantErr: "invalid PERPLEXITY_SEPARATE_OPINIONS",
+		},
+		{
+			name: "unknown time zone",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":  "test-key",
+				"PERPLEXITY_TIMEZONE": "Mars/Olympus_Mons",
+			},
+			wantErr: "invalid PERPLEXITY_TIMEZONE",
+		},
+		{
+			name: "invalid base currency",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_BASE_CURRENCY": "dollars",
+			},
+			wantErr: "PERPLEXITY_BASE_CURRENCY must be a three-letter currency code",
+		},
+		{
+			name: "FX rates without base currency",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_BASE_CURRENCY": "",
+				"PERPLEXITY_FX_RATES":      "rates.json",
+			},
+			wantErr: "PERPLEXITY_FX_RATES requires PERPLEXITY_BASE_CURRENCY",
+		},
+		{
+			name: "missing clients file",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":      "test-key",
+				"PERPLEXITY_CLIENTS_FILE": "/nonexistent/clients.json",
+			},
+			wantErr: "invalid PERPLEXITY_CLIENTS_FILE",
+		},
+		{
+			name: "TLS certificate without key",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":  "test-key",
+				"PERPLEXITY_TLS_CERT": "server.pem",
+			},
+			wantErr: "PERPLEXITY_TLS_CERT and PERPLEXITY_TLS_KEY must be set together",
+		},
+		{
+			name: "client CA without certificate",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_TLS_CLIENT_CA": "ca.pem",
+			},
+			wantErr: "PERPLEXITY_TLS_CLIENT_CA requires PERPLEXITY_TLS_CERT",
+		},
+		{
+			name: "missing TLS certificate",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":  "test-key",
+				"PERPLEXITY_TLS_CERT": "/nonexistent/server.pem",
+				"PERPLEXITY_TLS_KEY":  "/nonexistent/server-key.pem",
+			},
+			wantErr: "invalid PERPLEXITY_TLS_CERT or PERPLEXITY_TLS_KEY",
+		},
+		{
+			name: "missing document root",
+			envVars: map[string]string{
+				"PERPLEXITY_API_KEY":       "test-key",
+				"PERPLEXITY_DOCUMENT_ROOT": "/nonexistent/perplexity-documents",
+			},
+			wantErr: "invalid PERPLEXITY_DOCUMENT_ROOT:",
+		},
 	}
 
 	for _, tt := range tests {
@@ -196,22 +621,255 @@ func TestValidateModel(t *testing.T) {
 	validModels := []string{
 		types.ModelSonar,
 		types.ModelSonarPro,
+		types.ModelSonarDeepResearch,
+		types.ModelAuto,
 	}
 
 	for _, model := range validModels {
-		if err := validateModel(model); err != nil {
-			t.Errorf("validateModel(%s) failed: %v", model, err)
+		if err := ValidateModel(model); err != nil {
+			t.Errorf("ValidateModel(%s) failed: %v", model, err)
 		}
 	}
 
 	invalidModels := []string{"gpt-4", "claude", "invalid", ""}
 	for _, model := range invalidModels {
-		if err := validateModel(model); err == nil {
-			t.Errorf("validateModel(%s) should have failed", model)
+		if err := ValidateModel(model); err == nil {
+			t.Errorf("ValidateModel(%s) should have failed", model)
 		}
 	}
 }
 
+func TestModelsManifest(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "models.json")
+	if err := os.WriteFile(manifest, []byte(`{"models": [{"id": "sonar"}, {"id": "sonar-ultra"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(models.Default.Reset)
+
+	t.Setenv("PERPLEXITY_API_KEY", "test-key")
+	t.Setenv("PERPLEXITY_MODELS_MANIFEST", manifest)
+	t.Setenv("PERPLEXITY_DEFAULT_MODEL", "sonar-ultra")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DefaultModel != "sonar-ultra" || cfg.ModelsManifest != manifest {
+		t.Errorf("Expected the manifest's model accepted, got %q", cfg.DefaultModel)
+	}
+	if err := ValidateModel(types.ModelSonarPro); err == nil {
+		t.Error("Expected models missing from the manifest to be rejected")
+	}
+
+	t.Setenv("PERPLEXITY_MODELS_MANIFEST", filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := LoadConfig(); err == nil {
+		t.Error("Expected an error for a missing manifest file")
+	}
+}
+
+func TestParseTemplates(t *testing.T) {
+	templates, err := ParseTemplates([]byte(`{"news": {"query": "{{topic}} news"}, "rivals": {"tool": "perplexity_competitors", "arguments": {"company": "{{company}}"}}}`))
+	if err != nil {
+		t.Fatalf("ParseTemplates failed: %v", err)
+	}
+	if templates["news"].Tool != "perplexity_search" || templates["rivals"].Tool != "perplexity_competitors" {
+		t.Errorf("Unexpected templates: %+v", templates)
+	}
+
+	for _, data := range []string{
+		`not json`,
+		`{"bad name": {"query": "q"}}`,
+		`{"empty": {"tool": "perplexity_search"}}`,
+		`{"loop": {"tool": "run_template", "query": "q"}}`,
+	} {
+		if _, err := ParseTemplates([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func TestParseClients(t *testing.T) {
+	clients, err := ParseClients([]byte(`{"ci": {"token": "ci-secret", "rate_limit": 10, "daily_requests": 500}, "notebook": {"token": "nb-secret"}}`))
+	if err != nil {
+		t.Fatalf("ParseClients failed: %v", err)
+	}
+	if clients["ci"].RateLimit != 10 || clients["ci"].DailyRequests != 500 || clients["notebook"].Token != "nb-secret" {
+		t.Errorf("Unexpected clients: %+v", clients)
+	}
+
+	for _, data := range []string{
+		`not json`,
+		`{"bad name": {"token": "t"}}`,
+		`{"ci": {"rate_limit": 10}}`,
+		`{"a": {"token": "same"}, "b": {"token": "same"}}`,
+		`{"ci": {"token": "t", "daily_requests": -1}}`,
+	} {
+		if _, err := ParseClients([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func TestParseWatches(t *testing.T) {
+	watches, err := ParseWatches([]byte(`{"gpu": {"query": "GPU prices", "period": "daily", "recipients": ["hw@example.com"]}, "fed": {"query": "Fed rate decision", "search_type": "financial", "period": "weekly"}}`))
+	if err != nil {
+		t.Fatalf("ParseWatches failed: %v", err)
+	}
+	if watches["gpu"].SearchType != "general" || watches["gpu"].Recipients[0] != "hw@example.com" || watches["fed"].SearchType != "financial" || watches["fed"].Period != "weekly" {
+		t.Errorf("Unexpected watches: %+v", watches)
+	}
+
+	for _, data := range []string{
+		`not json`,
+		`{"bad name": {"query": "q", "period": "daily"}}`,
+		`{"gpu": {"period": "daily"}}`,
+		`{"gpu": {"query": "q", "period": "monthly"}}`,
+		`{"gpu": {"query": "q", "period": "daily", "recipients": ["not-an-address"]}}`,
+	} {
+		if _, err := ParseWatches([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+
+	// Recipients of a watch are emailed, so SMTP must be configured
+	path := filepath.Join(t.TempDir(), "watches.json")
+	if err := os.WriteFile(path, []byte(`{"gpu": {"query": "GPU prices", "period": "daily", "recipients": ["hw@example.com"]}}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	t.Setenv("PERPLEXITY_API_KEY", "test-key")
+	t.Setenv("PERPLEXITY_WATCHES_FILE", path)
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "PERPLEXITY_SMTP_HOST and PERPLEXITY_SMTP_FROM are required when a watch has recipients") {
+		t.Errorf("Expected an error for watch recipients without SMTP, got %v", err)
+	}
+	t.Setenv("PERPLEXITY_SMTP_HOST", "smtp.example.com")
+	t.Setenv("PERPLEXITY_SMTP_FROM", "perplexity@example.com")
+	if cfg, err := LoadConfig(); err != nil || cfg.Watches["gpu"].Query != "GPU prices" {
+		t.Errorf("Expected the watches loaded, got %v", err)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders("x-gateway-key: abc=123; X-Trace-Source:mcp ;")
+	if err != nil {
+		t.Fatalf("parseHeaders failed: %v", err)
+	}
+	if len(headers) != 2 {
+		t.Fatalf("Header count mismatch: got %d, want 2", len(headers))
+	}
+	if headers["X-Gateway-Key"] != "abc=123" {
+		t.Errorf("X-Gateway-Key mismatch: got %q, want %q", headers["X-Gateway-Key"], "abc=123")
+	}
+	if headers["X-Trace-Source"] != "mcp" {
+		t.Errorf("X-Trace-Source mismatch: got %q, want %q", headers["X-Trace-Source"], "mcp")
+	}
+
+	for _, invalid := range []string{"no-colon", ": value", "Bad Name: value", "authorization: Bearer x"} {
+		if _, err := parseHeaders(invalid); err == nil {
+			t.Errorf("parseHeaders(%q) should have failed", invalid)
+		}
+	}
+}
+
+func TestLoadAnonymizeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "anonymize.txt")
+	content := "# internal codenames\nProject Falcon\n\nJane Doe = the CEO\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	rules, err := loadAnonymizeFile(path)
+	if err != nil {
+		t.Fatalf("loadAnonymizeFile failed: %v", err)
+	}
+	want := []AnonymizeRule{{Term: "Project Falcon"}, {Term: "Jane Doe", Replacement: "the CEO"}}
+	if len(rules) != len(want) {
+		t.Fatalf("Rule count mismatch: got %d, want %d", len(rules), len(want))
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("Rule %d mismatch: got %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("= replacement only\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := loadAnonymizeFile(path); err == nil {
+		t.Error("Expected error for a line without a term, got nil")
+	}
+}
+
+func TestLoadExamplesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "examples.json")
+	content := `{
+		"default": [{"question": "What is X?", "answer": "X is ..."}],
+		"tools": {"perplexity_financial_search": [{"question": "AAPL revenue?", "answer": "| Year | Revenue |"}]}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	examples, err := loadExamplesFile(path)
+	if err != nil {
+		t.Fatalf("loadExamplesFile failed: %v", err)
+	}
+	cfg := &Config{Examples: *examples}
+	if got := cfg.ExamplesFor("perplexity_financial_search"); len(got) != 1 || got[0].Question != "AAPL revenue?" {
+		t.Errorf("Financial examples mismatch: got %v", got)
+	}
+	if got := cfg.ExamplesFor("perplexity_search"); len(got) != 1 || got[0].Question != "What is X?" {
+		t.Errorf("Default examples mismatch: got %v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"default": [{"question": "Q only"}]}`), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := loadExamplesFile(path); err == nil {
+		t.Error("Expected error for example without an answer, got nil")
+	}
+}
+
+func TestParseTransforms(t *testing.T) {
+	transforms, err := parseTransforms("strip_reasoning, collapse_whitespace; perplexity_academic_search=normalize_tables")
+	if err != nil {
+		t.Fatalf("parseTransforms failed: %v", err)
+	}
+
+	cfg := &Config{Transforms: transforms}
+	if got := cfg.TransformsFor("perplexity_search"); len(got) != 2 || got[1] != "collapse_whitespace" {
+		t.Errorf("Default transforms mismatch: got %v", got)
+	}
+	if got := cfg.TransformsFor("perplexity_academic_search"); len(got) != 1 || got[0] != "normalize_tables" {
+		t.Errorf("Academic transforms mismatch: got %v", got)
+	}
+
+	if _, err := parseTransforms("=normalize_tables"); err == nil {
+		t.Error("Expected error for section without a tool name, got nil")
+	}
+}
+
 func containsString(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr
-}
\ No newline at end of file
+}
+
+func TestLoadTickersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tickers.csv")
+	content := "# custom listings\nacme, Acme Rockets, Acme\n\nRIVN, Rivian Automotive\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write tickers file: %v", err)
+	}
+
+	tickers, err := loadTickersFile(path)
+	if err != nil {
+		t.Fatalf("loadTickersFile failed: %v", err)
+	}
+	if len(tickers) != 2 || len(tickers["ACME"]) != 2 || tickers["RIVN"][0] != "Rivian Automotive" {
+		t.Errorf("Tickers mismatch: got %v", tickers)
+	}
+
+	if err := os.WriteFile(path, []byte("ACME\n"), 0644); err != nil {
+		t.Fatalf("Failed to write tickers file: %v", err)
+	}
+	if _, err := loadTickersFile(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected line error, got %v", err)
+	}
+}
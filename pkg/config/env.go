@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// secretVariables may name a file holding their value in <name>_FILE instead, as container
+// platforms mount secrets, so the value never appears in the process environment
+var secretVariables = []string{
+	"PERPLEXITY_API_KEY",
+	"PERPLEXITY_NOTION_TOKEN",
+	"PERPLEXITY_CONFLUENCE_TOKEN",
+	"PERPLEXITY_CALENDAR_TOKEN",
+	"PERPLEXITY_FEED_TOKEN",
+	"PERPLEXITY_NOTIFY_WEBHOOK",
+	"PERPLEXITY_SMTP_PASSWORD",
+	"PERPLEXITY_REDIS_URL",
+	"PERPLEXITY_S3_SECRET_ACCESS_KEY",
+	"PERPLEXITY_S3_SESSION_TOKEN",
+}
+
+var (
+	envFileMu   sync.RWMutex
+	envFilePath string
+)
+
+// UseEnvFile makes LoadConfig read settings from a file of KEY=VALUE lines as well as from
+// the environment, which wins when both set a variable. The file is read again on each
+// LoadConfig, so a reload picks up changes to it. An empty path stops using a file.
+func UseEnvFile(path string) {
+	envFileMu.Lock()
+	defer envFileMu.Unlock()
+	envFilePath = path
+}
+
+// environment holds the values of variables read from the env file and secret files
+type environment map[string]string
+
+// loadEnvironment reads the env file, if one is used, and the files named by the _FILE
+// variables of secrets
+func loadEnvironment() (environment, error) {
+	envFileMu.RLock()
+	path := envFilePath
+	envFileMu.RUnlock()
+
+	env := environment{}
+	if path != "" {
+		values, err := ReadEnvFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env file: %w", err)
+		}
+		env = values
+	}
+
+	for _, name := range secretVariables {
+		file := env.get(name + "_FILE")
+		if file == "" {
+			continue
+		}
+		if env.get(name) != "" {
+			return nil, fmt.Errorf("%s and %s_FILE cannot both be set", name, name)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_FILE: %w", name, err)
+		}
+		// Secret files usually end with a newline that is not part of the value
+		env[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return env, nil
+}
+
+// get returns a variable from the process environment, falling back to the files
+func (e environment) get(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return e[name]
+}
+
+// first returns the first of the variables that is set
+func (e environment) first(names ...string) string {
+	for _, name := range names {
+		if value := e.get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ReadEnvFile parses a file of KEY=VALUE lines, as used by docker --env-file. Blank lines and
+// lines starting with # are skipped, an "export " prefix is allowed, and a value may be
+// wrapped in single or double quotes.
+func ReadEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%s line %d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	return values, scanner.Err()
+}
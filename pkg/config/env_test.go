@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigSecretFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "api-key")
+	if err := os.WriteFile(secret, []byte("pplx-from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PERPLEXITY_API_KEY", "")
+	t.Setenv("PERPLEXITY_API_KEY_FILE", secret)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.APIKey != "pplx-from-file" {
+		t.Errorf("APIKey = %q, want the file's content without its newline", cfg.APIKey)
+	}
+
+	t.Setenv("PERPLEXITY_API_KEY", "pplx-from-env")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "cannot both be set") {
+		t.Errorf("Expected an error for a key set both ways, got %v", err)
+	}
+
+	t.Setenv("PERPLEXITY_API_KEY", "")
+	t.Setenv("PERPLEXITY_API_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "invalid PERPLEXITY_API_KEY_FILE") {
+		t.Errorf("Expected an error for a missing key file, got %v", err)
+	}
+}
+
+func TestLoadConfigEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "perplexity.env")
+	data := "# Deployment settings\nPERPLEXITY_API_KEY=pplx-from-env-file\nexport PERPLEXITY_DEFAULT_MODEL='sonar-pro'\n\nPERPLEXITY_MAX_TOKENS=\"900\"\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PERPLEXITY_API_KEY", "")
+	t.Setenv("PERPLEXITY_DEFAULT_MODEL", "")
+	t.Setenv("PERPLEXITY_MAX_TOKENS", "1200")
+	UseEnvFile(path)
+	t.Cleanup(func() { UseEnvFile("") })
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.APIKey != "pplx-from-env-file" || cfg.DefaultModel != "sonar-pro" {
+		t.Errorf("Expected settings from the env file, got key %q and model %q", cfg.APIKey, cfg.DefaultModel)
+	}
+	if cfg.MaxTokens != 1200 {
+		t.Errorf("MaxTokens = %d, want the environment's 1200 over the file's", cfg.MaxTokens)
+	}
+
+	// The file is read again on each load
+	if err := os.WriteFile(path, []byte("PERPLEXITY_API_KEY=rotated\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := LoadConfig(); err != nil || cfg.APIKey != "rotated" {
+		t.Errorf("Expected the changed file read on reload, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a setting\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error naming the bad line, got %v", err)
+	}
+}
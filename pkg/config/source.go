@@ -0,0 +1,75 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Source supplies the configuration in effect. Packages that read settings while running
+// hold a Source rather than a *Config and call Snapshot where they read, so a reloaded,
+// remote, or per-tenant configuration can be layered in without changing them. A *Config
+// is a Source of itself.
+type Source interface {
+	// Snapshot returns the current configuration, which callers must not modify
+	Snapshot() *Config
+}
+
+// Snapshot returns c, so a fixed configuration can be used as a Source
+func (c *Config) Snapshot() *Config {
+	return c
+}
+
+// Reloader is a Source whose configuration can be replaced while the server runs. A
+// replacement never modifies the previous Config, so snapshots taken before it stay valid.
+// Settings read per call, such as the default model, token limits, and results folder, take
+// effect on the next call; those used to build clients at startup, such as the API key,
+// rate limit, proxy, and HTTP address, need a restart.
+type Reloader struct {
+	current atomic.Pointer[Config]
+	load    func() (*Config, error)
+
+	mu    sync.Mutex // serializes replacements and guards hooks
+	hooks []func(prev, next *Config)
+}
+
+// NewReloader creates a Reloader starting at cfg. Reload replaces it with the result of
+// load, such as LoadConfig or a read from a remote store.
+func NewReloader(cfg *Config, load func() (*Config, error)) *Reloader {
+	r := &Reloader{load: load}
+	r.current.Store(cfg)
+	return r
+}
+
+// Snapshot returns the current configuration
+func (r *Reloader) Snapshot() *Config {
+	return r.current.Load()
+}
+
+// OnChange registers a hook called after each replacement with the previous and new
+// configuration. Hooks run in registration order on the goroutine that replaced it.
+func (r *Reloader) OnChange(hook func(prev, next *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Store replaces the configuration with cfg and runs the change hooks
+func (r *Reloader) Store(cfg *Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev := r.current.Swap(cfg)
+	for _, hook := range r.hooks {
+		hook(prev, cfg)
+	}
+}
+
+// Reload loads a new configuration and stores it. When loading fails, the current
+// configuration stays in effect and the error is returned.
+func (r *Reloader) Reload() error {
+	cfg, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.Store(cfg)
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReloader(t *testing.T) {
+	first := Default()
+	second := Default()
+	second.MaxTokens = 2000
+
+	next, loadErr := second, error(nil)
+	r := NewReloader(first, func() (*Config, error) { return next, loadErr })
+
+	var changes [][2]*Config
+	r.OnChange(func(prev, cfg *Config) { changes = append(changes, [2]*Config{prev, cfg}) })
+
+	if r.Snapshot() != first {
+		t.Fatal("Expected the initial configuration")
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if r.Snapshot() != second || first.MaxTokens == 2000 {
+		t.Error("Expected the loaded configuration without modifying the previous one")
+	}
+	if len(changes) != 1 || changes[0][0] != first || changes[0][1] != second {
+		t.Errorf("Expected one change hook call, got %v", changes)
+	}
+
+	loadErr = errors.New("invalid PERPLEXITY_MAX_TOKENS")
+	if err := r.Reload(); err == nil {
+		t.Error("Expected the load error")
+	}
+	if r.Snapshot() != second || len(changes) != 1 {
+		t.Error("Expected a failed reload to keep the current configuration")
+	}
+
+	var src Source = first
+	if src.Snapshot() != first {
+		t.Error("Expected a Config to be its own snapshot")
+	}
+}
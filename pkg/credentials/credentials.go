@@ -0,0 +1,82 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The API key is stored under this service and account in the credential store
+const (
+	Service = "perplexity-mcp"
+	Account = "api-key"
+)
+
+// ErrNotFound is returned by Get when no API key is stored
+var ErrNotFound = errors.New("no API key is stored in the credential store")
+
+// Get returns the API key from the OS credential store: the login keychain on macOS, the
+// Credential Manager on Windows, and the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool elsewhere
+func Get() (string, error) {
+	return get()
+}
+
+// Set stores the API key in the OS credential store, replacing a stored one
+func Set(key string) error {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t\r\n\"'\\") {
+		return fmt.Errorf("the API key must be a single word without quotes")
+	}
+	return set(key)
+}
+
+// Delete removes the API key from the OS credential store
+func Delete() error {
+	return remove()
+}
+
+// run runs a credential tool with stdin as its input and returns its output; tests replace it
+var run = func(stdin, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s is not installed: %w", name, err)
+	}
+	if err != nil {
+		return out, &toolError{name: name, err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return out, nil
+}
+
+// toolError is a failed run of a credential tool
+type toolError struct {
+	name   string
+	err    error
+	stderr string
+}
+
+func (e *toolError) Error() string {
+	if e.stderr != "" {
+		return fmt.Sprintf("%s failed: %v: %s", e.name, e.err, e.stderr)
+	}
+	return fmt.Sprintf("%s failed: %v", e.name, e.err)
+}
+
+func (e *toolError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit status of a failed tool run, or -1 for other errors
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
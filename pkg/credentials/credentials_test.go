@@ -0,0 +1,66 @@
+//go:build !darwin && !windows
+
+package credentials
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool stands in for secret-tool, keeping what it stores in memory
+func fakeSecretTool(t *testing.T) *string {
+	t.Helper()
+	stored := new(string)
+	orig := run
+	t.Cleanup(func() { run = orig })
+	run = func(stdin, name string, args ...string) ([]byte, error) {
+		switch args[0] {
+		case "store":
+			*stored = stdin
+			return nil, nil
+		case "lookup":
+			if *stored == "" {
+				// secret-tool exits with 1 when nothing matches
+				return nil, exec.Command("sh", "-c", "exit 1").Run()
+			}
+			return []byte(*stored), nil
+		case "clear":
+			*stored = ""
+			return nil, nil
+		}
+		t.Fatalf("unexpected secret-tool call %v", args)
+		return nil, nil
+	}
+	return stored
+}
+
+func TestCredentialStore(t *testing.T) {
+	stored := fakeSecretTool(t)
+
+	if _, err := Get(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound before a key is stored, got %v", err)
+	}
+	if err := Set("  pplx-abc123\n"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if *stored != "pplx-abc123" {
+		t.Errorf("Expected the trimmed key passed on stdin, got %q", *stored)
+	}
+	if key, err := Get(); err != nil || key != "pplx-abc123" {
+		t.Errorf("Get = %q, %v; want the stored key", key, err)
+	}
+	if err := Delete(); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := Delete(); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	for _, key := range []string{"", "two words", `pplx-"quoted"`} {
+		if err := Set(key); err == nil || !strings.Contains(err.Error(), "single word") {
+			t.Errorf("Expected %q rejected, got %v", key, err)
+		}
+	}
+}
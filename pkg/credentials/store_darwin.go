@@ -0,0 +1,36 @@
+package credentials
+
+import (
+	"fmt"
+	"strings"
+)
+
+// securityItemNotFound is the exit status of security when no keychain item matches
+const securityItemNotFound = 44
+
+func get() (string, error) {
+	out, err := run("", "security", "find-generic-password", "-s", Service, "-a", Account, "-w")
+	if exitCode(err) == securityItemNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// set passes the key to security's interactive mode on stdin, so it never appears in the
+// process list
+func set(key string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -w %q\n", Service, Account, "Perplexity MCP API key", key)
+	_, err := run(command, "security", "-i")
+	return err
+}
+
+func remove() error {
+	_, err := run("", "security", "delete-generic-password", "-s", Service, "-a", Account)
+	if exitCode(err) == securityItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
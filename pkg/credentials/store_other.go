@@ -0,0 +1,32 @@
+//go:build !darwin && !windows
+
+package credentials
+
+import "strings"
+
+// get looks the key up with secret-tool, which exits with 1 and no output when nothing matches
+func get() (string, error) {
+	out, err := run("", "secret-tool", "lookup", "service", Service, "account", Account)
+	if err != nil && exitCode(err) != 1 {
+		return "", err
+	}
+	key := strings.TrimRight(string(out), "\n")
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+// set passes the key to secret-tool on stdin, so it never appears in the process list
+func set(key string) error {
+	_, err := run(key, "secret-tool", "store", "--label=Perplexity MCP API key", "service", Service, "account", Account)
+	return err
+}
+
+func remove() error {
+	if _, err := get(); err != nil {
+		return err
+	}
+	_, err := run("", "secret-tool", "clear", "service", Service, "account", Account)
+	return err
+}
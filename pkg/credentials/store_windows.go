@@ -0,0 +1,85 @@
+package credentials
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32       = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// Values of the Credential Manager API
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = 1168
+)
+
+// credential mirrors CREDENTIALW
+type credential struct {
+	flags              uint32
+	credType           uint32
+	targetName         *uint16
+	comment            *uint16
+	lastWritten        syscall.Filetime
+	credentialBlobSize uint32
+	credentialBlob     *byte
+	persist            uint32
+	attributeCount     uint32
+	attributes         uintptr
+	targetAlias        *uint16
+	userName           *uint16
+}
+
+// target names the generic credential holding the key
+func target() *uint16 {
+	name, _ := syscall.UTF16PtrFromString(Service + ":" + Account)
+	return name
+}
+
+func get() (string, error) {
+	var cred *credential
+	ok, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(target())), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		if err == syscall.Errno(errorNotFound) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read from the Credential Manager: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	return string(unsafe.Slice(cred.credentialBlob, cred.credentialBlobSize)), nil
+}
+
+func set(key string) error {
+	blob := []byte(key)
+	user, _ := syscall.UTF16PtrFromString(Account)
+	cred := credential{
+		credType:           credTypeGeneric,
+		targetName:         target(),
+		credentialBlobSize: uint32(len(blob)),
+		credentialBlob:     &blob[0],
+		persist:            credPersistLocalMachine,
+		userName:           user,
+	}
+	if ok, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0); ok == 0 {
+		return fmt.Errorf("failed to write to the Credential Manager: %w", err)
+	}
+	return nil
+}
+
+func remove() error {
+	ok, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(target())), credTypeGeneric, 0)
+	if ok == 0 {
+		if err == syscall.Errno(errorNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete from the Credential Manager: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Service states reported to systemd with Notify
+const (
+	Ready     = "READY=1"
+	Reloading = "RELOADING=1"
+	Stopping  = "STOPPING=1"
+)
+
+// ServiceName is the name the server is installed under as a Windows service
+const ServiceName = "perplexity"
+
+// Notify sends a state to systemd over the socket in NOTIFY_SOCKET, as sd_notify does. It
+// does nothing when the server was not started by systemd with Type=notify.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ names a socket in the abstract namespace
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to reach systemd: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to notify systemd: %w", err)
+	}
+	return nil
+}
+
+// WritePidfile writes the process ID to path, replacing the file left by an earlier run. The
+// returned function removes the file, unless another process has written its own ID since.
+func WritePidfile(path string) (func(), error) {
+	pid := strconv.Itoa(os.Getpid())
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	_, err = tmp.WriteString(pid + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to write pidfile: %w", err)
+	}
+	return func() {
+		if data, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(data)) == pid {
+			os.Remove(path)
+		}
+	}, nil
+}
+
+// LogFile is a log file that can be reopened after log rotation has moved it away. A nil
+// LogFile stands for stderr.
+type LogFile struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// OpenLogFile opens path for appending, creating it if needed
+func OpenLogFile(path string) (*LogFile, error) {
+	l := &LogFile{path: path}
+	if err := l.Reopen(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Write appends p to the current file
+func (l *LogFile) Write(p []byte) (int, error) {
+	if l == nil {
+		return os.Stderr.Write(p)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Write(p)
+}
+
+// Reopen closes the file and opens its path again, so that logs go to a new file after the
+// old one was rotated. If the path can't be opened, logging continues in the old file.
+func (l *LogFile) Reopen() error {
+	if l == nil {
+		return nil
+	}
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = file
+	return nil
+}
@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNotify(t *testing.T) {
+	if err := Notify(Ready); err != nil {
+		t.Errorf("Expected Notify to do nothing without NOTIFY_SOCKET, got %v", err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	if err := Notify(Ready); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "READY=1" {
+		t.Errorf("Expected READY=1, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestWritePidfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "perplexity.pid")
+	if err := os.WriteFile(path, []byte("99999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	remove, err := WritePidfile(path)
+	if err != nil {
+		t.Fatalf("WritePidfile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Expected the stale pidfile replaced with our ID, got %q", data)
+	}
+	remove()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the pidfile removed, got %v", err)
+	}
+
+	// A pidfile taken over by another process is left alone
+	remove, err = WritePidfile(path)
+	if err != nil {
+		t.Fatalf("WritePidfile failed: %v", err)
+	}
+	os.WriteFile(path, []byte("12345\n"), 0644)
+	remove()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected another process's pidfile kept, got %v", err)
+	}
+}
+
+func TestLogFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "perplexity.log")
+	logFile, err := OpenLogFile(path)
+	if err != nil {
+		t.Fatalf("OpenLogFile failed: %v", err)
+	}
+	logFile.Write([]byte("before rotation\n"))
+
+	// Rotation moves the file away; writes follow it until the file is reopened
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	logFile.Write([]byte("still old\n"))
+	if err := logFile.Reopen(); err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	logFile.Write([]byte("after rotation\n"))
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if string(rotated) != "before rotation\nstill old\n" || string(current) != "after rotation\n" {
+		t.Errorf("Unexpected log contents: rotated %q, current %q", rotated, current)
+	}
+}
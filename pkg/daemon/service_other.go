@@ -0,0 +1,24 @@
+//go:build !windows
+
+package daemon
+
+import "errors"
+
+// errNotWindows is returned by the Windows service helpers on other systems
+var errNotWindows = errors.New("Windows services are only available on Windows; elsewhere run -daemon under systemd or another supervisor")
+
+// RunService runs run as the Windows service when the service manager started the process.
+// Elsewhere it returns false without calling run.
+func RunService(run func(stop <-chan struct{}) error) (bool, error) {
+	return false, nil
+}
+
+// InstallService registers the executable as an automatically started Windows service
+func InstallService(args []string) error {
+	return errNotWindows
+}
+
+// UninstallService removes the Windows service
+func UninstallService() error {
+	return errNotWindows
+}
@@ -0,0 +1,183 @@
+//go:build windows
+
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                         = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcher   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerEx = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus             = advapi32.NewProc("SetServiceStatus")
+)
+
+// Values of the service control manager API
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped     = 1
+	serviceStopPending = 3
+	serviceRunning     = 4
+
+	acceptStop     = 0x1
+	acceptShutdown = 0x4
+
+	controlStop     = 1
+	controlShutdown = 5
+
+	errorServiceSpecific                = 1066
+	errorFailedServiceControllerConnect = 1063
+)
+
+// serviceStatus mirrors SERVICE_STATUS
+type serviceStatus struct {
+	serviceType             uint32
+	currentState            uint32
+	controlsAccepted        uint32
+	win32ExitCode           uint32
+	serviceSpecificExitCode uint32
+	checkPoint              uint32
+	waitHint                uint32
+}
+
+// serviceTableEntry mirrors SERVICE_TABLE_ENTRYW
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+// service is the state shared with the callbacks the service manager calls. Callbacks are
+// created once, since Windows allows only a limited number per process.
+var service struct {
+	run    func(stop <-chan struct{}) error
+	stop   chan struct{}
+	once   sync.Once
+	handle uintptr
+	err    error
+}
+
+var (
+	serviceMainCallback = syscall.NewCallback(serviceMain)
+	controlCallback     = syscall.NewCallback(serviceControl)
+)
+
+// RunService runs run as the Windows service when the service manager started the process,
+// closing stop when the service is stopped or the system shuts down. It returns false
+// without calling run when the process was started from a console.
+func RunService(run func(stop <-chan struct{}) error) (bool, error) {
+	name, err := syscall.UTF16PtrFromString(ServiceName)
+	if err != nil {
+		return false, err
+	}
+	service.run = run
+	service.stop = make(chan struct{})
+	table := []serviceTableEntry{{name: name, proc: serviceMainCallback}, {}}
+	// Blocks until the service has stopped
+	ok, _, err := procStartServiceCtrlDispatcher.Call(uintptr(unsafe.Pointer(&table[0])))
+	if ok == 0 {
+		if errno, isErrno := err.(syscall.Errno); isErrno && errno == errorFailedServiceControllerConnect {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to start the service dispatcher: %w", err)
+	}
+	return true, service.err
+}
+
+// serviceMain is called by the service manager on its own thread to run the service
+func serviceMain(argc, argv uintptr) uintptr {
+	name, _ := syscall.UTF16PtrFromString(ServiceName)
+	handle, _, err := procRegisterServiceCtrlHandlerEx.Call(uintptr(unsafe.Pointer(name)), controlCallback, 0)
+	if handle == 0 {
+		service.err = fmt.Errorf("failed to register the service control handler: %w", err)
+		return 0
+	}
+	service.handle = handle
+	setServiceStatus(serviceRunning, acceptStop|acceptShutdown, 0)
+
+	var exitCode uint32
+	if service.err = service.run(service.stop); service.err != nil {
+		exitCode = 1
+	}
+	setServiceStatus(serviceStopped, 0, exitCode)
+	return 0
+}
+
+// serviceControl handles the stop and shutdown requests of the service manager
+func serviceControl(control, eventType, eventData, context uintptr) uintptr {
+	switch control {
+	case controlStop, controlShutdown:
+		setServiceStatus(serviceStopPending, 0, 0)
+		service.once.Do(func() { close(service.stop) })
+	}
+	return 0
+}
+
+// setServiceStatus reports the service's state; a nonzero exitCode marks a failed run
+func setServiceStatus(state, accepts, exitCode uint32) {
+	status := serviceStatus{
+		serviceType:      serviceWin32OwnProcess,
+		currentState:     state,
+		controlsAccepted: accepts,
+	}
+	if exitCode != 0 {
+		status.win32ExitCode = errorServiceSpecific
+		status.serviceSpecificExitCode = exitCode
+	}
+	if state == serviceStopPending {
+		status.waitHint = 15000
+	}
+	procSetServiceStatus.Call(service.handle, uintptr(unsafe.Pointer(&status)))
+}
+
+// InstallService registers the executable as an automatically started Windows service that
+// runs with -daemon and args. Services don't see the installing user's environment, so the
+// PERPLEXITY_ variables set now are stored with the service.
+func InstallService(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	binPath := syscall.EscapeArg(exe) + " -daemon"
+	for _, arg := range args {
+		binPath += " " + syscall.EscapeArg(arg)
+	}
+	if err := runTool("sc.exe", "create", ServiceName, "binPath=", binPath, "start=", "auto", "DisplayName=", "Perplexity MCP Server"); err != nil {
+		return err
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "PERPLEXITY_") {
+			env = append(env, kv)
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	key := `HKLM\SYSTEM\CurrentControlSet\Services\` + ServiceName
+	return runTool("reg.exe", "add", key, "/v", "Environment", "/t", "REG_MULTI_SZ", "/d", strings.Join(env, `\0`), "/f")
+}
+
+// UninstallService stops the Windows service if it is running and removes it
+func UninstallService() error {
+	runTool("sc.exe", "stop", ServiceName)
+	return runTool("sc.exe", "delete", ServiceName)
+}
+
+// runTool runs a Windows administration tool, returning its output as the error on failure
+func runTool(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return errors.New(strings.TrimSpace(fmt.Sprintf("%s %s failed: %v\n%s", name, args[0], err, out)))
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// DefaultTool is the tool a case runs when it names none
+const DefaultTool = "perplexity_search"
+
+// metadataHeader starts the footer of search answers
+const metadataHeader = "\n\n## Search Metadata\n"
+
+// Spec is an evaluation: the cases to run and the two variants to run them with
+type Spec struct {
+	A     Variant `json:"a"`
+	B     Variant `json:"b"`
+	Cases []Case  `json:"cases"`
+}
+
+// Variant is one side of an evaluation. Prompt frames every search prompt, with {{prompt}}
+// standing for the prompt the tool built and {{query}} for the query; empty keeps the tool's.
+// Arguments are added to every case, replacing the case's own.
+type Variant struct {
+	Name      string                 `json:"name"`
+	Prompt    string                 `json:"prompt,omitempty"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// Case is a fixture query: a tool call made once per variant
+type Case struct {
+	Name      string                 `json:"name"`
+	Tool      string                 `json:"tool,omitempty"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// Caller makes tool calls, as the MCP handler does
+type Caller interface {
+	CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error)
+}
+
+// Answer is the outcome of one case under one variant
+type Answer struct {
+	Text    string        `json:"text,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Result pairs the answers of a case under both variants
+type Result struct {
+	Case Case   `json:"case"`
+	A    Answer `json:"a"`
+	B    Answer `json:"b"`
+}
+
+// LoadSpec reads and checks an evaluation spec from a JSON file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read eval spec: %w", err)
+	}
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid eval spec %s: %w", path, err)
+	}
+	if len(spec.Cases) == 0 {
+		return nil, fmt.Errorf("eval spec %s has no cases", path)
+	}
+	for i := range spec.Cases {
+		c := &spec.Cases[i]
+		if query, _ := c.Arguments["query"].(string); strings.TrimSpace(query) == "" {
+			return nil, fmt.Errorf("eval case %d has no query argument", i+1)
+		}
+		if c.Tool == "" {
+			c.Tool = DefaultTool
+		}
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case %d", i+1)
+		}
+	}
+	if spec.A.Name == "" {
+		spec.A.Name = "A"
+	}
+	if spec.B.Name == "" {
+		spec.B.Name = "B"
+	}
+	return &spec, nil
+}
+
+// Run calls every case with variant A through a and with variant B through b, one case at a
+// time so both variants see the same moment's sources
+func Run(ctx context.Context, spec *Spec, a, b Caller) []Result {
+	results := make([]Result, len(spec.Cases))
+	for i, c := range spec.Cases {
+		results[i] = Result{
+			Case: c,
+			A:    call(ctx, a, c, spec.A),
+			B:    call(ctx, b, c, spec.B),
+		}
+	}
+	return results
+}
+
+// call runs one case with a variant's arguments
+func call(ctx context.Context, caller Caller, c Case, v Variant) Answer {
+	args := make(map[string]interface{}, len(c.Arguments)+len(v.Arguments))
+	for name, value := range c.Arguments {
+		args[name] = value
+	}
+	for name, value := range v.Arguments {
+		args[name] = value
+	}
+
+	start := time.Now()
+	resp, err := caller.CallTool(ctx, &protocol.CallToolRequest{Name: c.Tool, Arguments: args})
+	answer := Answer{Latency: time.Since(start)}
+	if err != nil {
+		answer.Error = err.Error()
+		return answer
+	}
+	var text []string
+	for _, content := range resp.Content {
+		text = append(text, content.Text)
+	}
+	if resp.IsError {
+		answer.Error = strings.Join(text, "\n")
+		return answer
+	}
+	// The metadata footer differs on every call, so it is left out of the comparison
+	answer.Text, _, _ = strings.Cut(strings.Join(text, "\n"), metadataHeader)
+	return answer
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s)\]>]+`)
+
+// sourceCount counts the distinct URLs an answer cites
+func sourceCount(a Answer) int {
+	seen := make(map[string]bool)
+	for _, url := range urlPattern.FindAllString(a.Text, -1) {
+		seen[strings.TrimRight(url, ".,;")] = true
+	}
+	return len(seen)
+}
+
+// Report renders the results as markdown: a table comparing the variants on each case, then
+// a line diff of each case's answers
+func Report(spec *Spec, results []Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Prompt Evaluation: %s vs %s\n\n", spec.A.Name, spec.B.Name)
+	fmt.Fprintf(&b, "| Case | Tool | Words (%[1]s / %[2]s) | Sources (%[1]s / %[2]s) | Latency (%[1]s / %[2]s) | Changed lines |\n", spec.A.Name, spec.B.Name)
+	b.WriteString("|---|---|---|---|---|---|\n")
+	words := func(a Answer) int { return len(strings.Fields(a.Text)) }
+	for _, r := range results {
+		changed := "n/a"
+		if r.A.Error == "" && r.B.Error == "" {
+			changed = fmt.Sprintf("%d", changedLines(diffLines(lines(r.A.Text), lines(r.B.Text))))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s / %s | %s / %s | %s / %s | %s |\n", r.Case.Name, r.Case.Tool,
+			stat(r.A, words), stat(r.B, words), stat(r.A, sourceCount), stat(r.B, sourceCount),
+			r.A.Latency.Round(time.Millisecond), r.B.Latency.Round(time.Millisecond), changed)
+	}
+
+	for _, r := range results {
+		query, _ := r.Case.Arguments["query"].(string)
+		fmt.Fprintf(&b, "\n## %s\n\nQuery: %s\n\n", r.Case.Name, query)
+		if r.A.Error != "" || r.B.Error != "" {
+			for _, side := range []struct {
+				name   string
+				answer Answer
+			}{{spec.A.Name, r.A}, {spec.B.Name, r.B}} {
+				if side.answer.Error != "" {
+					fmt.Fprintf(&b, "**%s failed:** %s\n\n", side.name, side.answer.Error)
+				}
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "```diff\n--- %s\n+++ %s\n", spec.A.Name, spec.B.Name)
+		for _, line := range diffLines(lines(r.A.Text), lines(r.B.Text)) {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("```\n")
+	}
+	return b.String()
+}
+
+// stat formats a statistic of an answer, or "error" for a failed one
+func stat(a Answer, value func(Answer) int) string {
+	if a.Error != "" {
+		return "error"
+	}
+	return fmt.Sprintf("%d", value(a))
+}
+
+func lines(text string) []string {
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+// diffLines returns a line diff of a and b: unchanged lines start with two spaces, removed
+// ones with "- " and added ones with "+ "
+func diffLines(a, b []string) []string {
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, "  "+a[i])
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+a[i])
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, "+ "+b[j])
+	}
+	return diff
+}
+
+// changedLines counts the removed and added lines of a diff
+func changedLines(diff []string) int {
+	n := 0
+	for _, line := range diff {
+		if !strings.HasPrefix(line, "  ") {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,81 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+)
+
+// fakeCaller answers with a fixed text per query, recording the arguments of each call
+type fakeCaller struct {
+	answers map[string]string
+	calls   []map[string]interface{}
+}
+
+func (f *fakeCaller) CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
+	f.calls = append(f.calls, req.Arguments)
+	answer, ok := f.answers[req.Arguments["query"].(string)]
+	if !ok {
+		return &protocol.CallToolResponse{Content: []protocol.ToolContent{{Type: "text", Text: "no answer"}}, IsError: true}, nil
+	}
+	return &protocol.CallToolResponse{Content: []protocol.ToolContent{{Type: "text", Text: answer + "\n\n## Search Metadata\n- Request ID: " + fmt.Sprint(len(f.calls))}}}, nil
+}
+
+func TestLoadSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	spec := `{"a": {"name": "current"}, "b": {"prompt": "Cite peer-reviewed work. {{prompt}}"}, "cases": [{"arguments": {"query": "CRISPR off-target effects"}}]}`
+	if err := os.WriteFile(path, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSpec(path)
+	if err != nil {
+		t.Fatalf("LoadSpec failed: %v", err)
+	}
+	if got.A.Name != "current" || got.B.Name != "B" || got.Cases[0].Tool != DefaultTool || got.Cases[0].Name != "case 1" {
+		t.Errorf("Expected defaults filled in, got %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"cases": [{"arguments": {}}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSpec(path); err == nil {
+		t.Error("Expected an error for a case without a query")
+	}
+}
+
+func TestRunAndReport(t *testing.T) {
+	spec := &Spec{
+		A:     Variant{Name: "sonar"},
+		B:     Variant{Name: "pro", Arguments: map[string]interface{}{"model": "sonar-pro"}},
+		Cases: []Case{{Name: "rates", Tool: DefaultTool, Arguments: map[string]interface{}{"query": "rates", "model": "sonar"}}, {Name: "missing", Tool: DefaultTool, Arguments: map[string]interface{}{"query": "missing"}}},
+	}
+	a := &fakeCaller{answers: map[string]string{"rates": "Rates held.\nSee https://example.com/a"}}
+	b := &fakeCaller{answers: map[string]string{"rates": "Rates held.\nMarkets rallied.\nSee https://example.com/a and https://example.com/b"}}
+
+	results := Run(context.Background(), spec, a, b)
+	if b.calls[0]["model"] != "sonar-pro" || a.calls[0]["model"] != "sonar" {
+		t.Errorf("Expected variant arguments to replace the case's, got %v and %v", a.calls[0], b.calls[0])
+	}
+	if strings.Contains(results[0].A.Text, "Search Metadata") {
+		t.Errorf("Expected the metadata footer left out, got %q", results[0].A.Text)
+	}
+
+	report := Report(spec, results)
+	for _, want := range []string{
+		"# Prompt Evaluation: sonar vs pro",
+		"| rates | perplexity_search | 4 / 8 | 1 / 2 |",
+		"| 3 |\n",
+		"  Rates held.\n- See https://example.com/a\n+ Markets rallied.\n+ See https://example.com/a and https://example.com/b\n",
+		"| missing | perplexity_search | error / error | error / error |",
+		"**sonar failed:** no answer",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,813 @@
+// Search service for Go services that embed Perplexity search. The messages mirror
+// search.SearchParams, and each RPC maps onto the Searcher method of the same name, so the
+// service shares caching and rate limiting with the MCP handler.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: perplexity/v1/search.proto
+
+package perplexityv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Parameters shared by every search. Optional fields fall back to the server's configuration.
+type SearchRequest struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Query                  string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Model                  string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	SearchDomainFilter     []string               `protobuf:"bytes,3,rep,name=search_domain_filter,json=searchDomainFilter,proto3" json:"search_domain_filter,omitempty"`
+	SearchExcludeDomains   []string               `protobuf:"bytes,4,rep,name=search_exclude_domains,json=searchExcludeDomains,proto3" json:"search_exclude_domains,omitempty"`
+	SearchRecencyFilter    string                 `protobuf:"bytes,5,opt,name=search_recency_filter,json=searchRecencyFilter,proto3" json:"search_recency_filter,omitempty"` // hour, day, week, month, or year
+	ReturnImages           *bool                  `protobuf:"varint,6,opt,name=return_images,json=returnImages,proto3,oneof" json:"return_images,omitempty"`
+	ReturnRelatedQuestions *bool                  `protobuf:"varint,7,opt,name=return_related_questions,json=returnRelatedQuestions,proto3,oneof" json:"return_related_questions,omitempty"`
+	MaxTokens              *int32                 `protobuf:"varint,8,opt,name=max_tokens,json=maxTokens,proto3,oneof" json:"max_tokens,omitempty"`
+	Temperature            *float64               `protobuf:"fixed64,9,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	DateRangeStart         string                 `protobuf:"bytes,10,opt,name=date_range_start,json=dateRangeStart,proto3" json:"date_range_start,omitempty"` // YYYY-MM-DD
+	DateRangeEnd           string                 `protobuf:"bytes,11,opt,name=date_range_end,json=dateRangeEnd,proto3" json:"date_range_end,omitempty"`       // YYYY-MM-DD
+	Location               string                 `protobuf:"bytes,12,opt,name=location,proto3" json:"location,omitempty"`
+	SearchMode             string                 `protobuf:"bytes,13,opt,name=search_mode,json=searchMode,proto3" json:"search_mode,omitempty"`
+	RetryOnEmpty           *bool                  `protobuf:"varint,14,opt,name=retry_on_empty,json=retryOnEmpty,proto3,oneof" json:"retry_on_empty,omitempty"`
+	MinCitations           int32                  `protobuf:"varint,15,opt,name=min_citations,json=minCitations,proto3" json:"min_citations,omitempty"`
+	DeepSources            bool                   `protobuf:"varint,16,opt,name=deep_sources,json=deepSources,proto3" json:"deep_sources,omitempty"`
+	AnswerLanguage         string                 `protobuf:"bytes,17,opt,name=answer_language,json=answerLanguage,proto3" json:"answer_language,omitempty"`
+	TimeoutSeconds         int32                  `protobuf:"varint,18,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	Project                string                 `protobuf:"bytes,19,opt,name=project,proto3" json:"project,omitempty"` // Caches the result in the project's folder
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetSearchDomainFilter() []string {
+	if x != nil {
+		return x.SearchDomainFilter
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetSearchExcludeDomains() []string {
+	if x != nil {
+		return x.SearchExcludeDomains
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetSearchRecencyFilter() string {
+	if x != nil {
+		return x.SearchRecencyFilter
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetReturnImages() bool {
+	if x != nil && x.ReturnImages != nil {
+		return *x.ReturnImages
+	}
+	return false
+}
+
+func (x *SearchRequest) GetReturnRelatedQuestions() bool {
+	if x != nil && x.ReturnRelatedQuestions != nil {
+		return *x.ReturnRelatedQuestions
+	}
+	return false
+}
+
+func (x *SearchRequest) GetMaxTokens() int32 {
+	if x != nil && x.MaxTokens != nil {
+		return *x.MaxTokens
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetTemperature() float64 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetDateRangeStart() string {
+	if x != nil {
+		return x.DateRangeStart
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetDateRangeEnd() string {
+	if x != nil {
+		return x.DateRangeEnd
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetSearchMode() string {
+	if x != nil {
+		return x.SearchMode
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetRetryOnEmpty() bool {
+	if x != nil && x.RetryOnEmpty != nil {
+		return *x.RetryOnEmpty
+	}
+	return false
+}
+
+func (x *SearchRequest) GetMinCitations() int32 {
+	if x != nil {
+		return x.MinCitations
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetDeepSources() bool {
+	if x != nil {
+		return x.DeepSources
+	}
+	return false
+}
+
+func (x *SearchRequest) GetAnswerLanguage() string {
+	if x != nil {
+		return x.AnswerLanguage
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+type AcademicSearchRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Search           *SearchRequest         `protobuf:"bytes,1,opt,name=search,proto3" json:"search,omitempty"`
+	SubjectArea      string                 `protobuf:"bytes,2,opt,name=subject_area,json=subjectArea,proto3" json:"subject_area,omitempty"`
+	PeerReviewedOnly bool                   `protobuf:"varint,3,opt,name=peer_reviewed_only,json=peerReviewedOnly,proto3" json:"peer_reviewed_only,omitempty"`
+	IncludePreprints bool                   `protobuf:"varint,4,opt,name=include_preprints,json=includePreprints,proto3" json:"include_preprints,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AcademicSearchRequest) Reset() {
+	*x = AcademicSearchRequest{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcademicSearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcademicSearchRequest) ProtoMessage() {}
+
+func (x *AcademicSearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcademicSearchRequest.ProtoReflect.Descriptor instead.
+func (*AcademicSearchRequest) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AcademicSearchRequest) GetSearch() *SearchRequest {
+	if x != nil {
+		return x.Search
+	}
+	return nil
+}
+
+func (x *AcademicSearchRequest) GetSubjectArea() string {
+	if x != nil {
+		return x.SubjectArea
+	}
+	return ""
+}
+
+func (x *AcademicSearchRequest) GetPeerReviewedOnly() bool {
+	if x != nil {
+		return x.PeerReviewedOnly
+	}
+	return false
+}
+
+func (x *AcademicSearchRequest) GetIncludePreprints() bool {
+	if x != nil {
+		return x.IncludePreprints
+	}
+	return false
+}
+
+type FinancialSearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Search        *SearchRequest         `protobuf:"bytes,1,opt,name=search,proto3" json:"search,omitempty"`
+	Ticker        string                 `protobuf:"bytes,2,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	CompanyName   string                 `protobuf:"bytes,3,opt,name=company_name,json=companyName,proto3" json:"company_name,omitempty"`
+	ReportType    string                 `protobuf:"bytes,4,opt,name=report_type,json=reportType,proto3" json:"report_type,omitempty"` // SEC report type, e.g. 10-K, 10-Q, or 8-K
+	AssetClass    string                 `protobuf:"bytes,5,opt,name=asset_class,json=assetClass,proto3" json:"asset_class,omitempty"`
+	Event         string                 `protobuf:"bytes,6,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FinancialSearchRequest) Reset() {
+	*x = FinancialSearchRequest{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FinancialSearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinancialSearchRequest) ProtoMessage() {}
+
+func (x *FinancialSearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinancialSearchRequest.ProtoReflect.Descriptor instead.
+func (*FinancialSearchRequest) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FinancialSearchRequest) GetSearch() *SearchRequest {
+	if x != nil {
+		return x.Search
+	}
+	return nil
+}
+
+func (x *FinancialSearchRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *FinancialSearchRequest) GetCompanyName() string {
+	if x != nil {
+		return x.CompanyName
+	}
+	return ""
+}
+
+func (x *FinancialSearchRequest) GetReportType() string {
+	if x != nil {
+		return x.ReportType
+	}
+	return ""
+}
+
+func (x *FinancialSearchRequest) GetAssetClass() string {
+	if x != nil {
+		return x.AssetClass
+	}
+	return ""
+}
+
+func (x *FinancialSearchRequest) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Result        string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`                     // Markdown answer with its Source URLs section
+	UniqueId      string                 `protobuf:"bytes,2,opt,name=unique_id,json=uniqueId,proto3" json:"unique_id,omitempty"` // Cache ID, empty when caching is disabled
+	RequestId     string                 `protobuf:"bytes,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetUniqueId() string {
+	if x != nil {
+		return x.UniqueId
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type ListPreviousRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Project       string                 `protobuf:"bytes,1,opt,name=project,proto3" json:"project,omitempty"`
+	Entity        string                 `protobuf:"bytes,2,opt,name=entity,proto3" json:"entity,omitempty"`
+	Keyword       string                 `protobuf:"bytes,3,opt,name=keyword,proto3" json:"keyword,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPreviousRequest) Reset() {
+	*x = ListPreviousRequest{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPreviousRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPreviousRequest) ProtoMessage() {}
+
+func (x *ListPreviousRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPreviousRequest.ProtoReflect.Descriptor instead.
+func (*ListPreviousRequest) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListPreviousRequest) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *ListPreviousRequest) GetEntity() string {
+	if x != nil {
+		return x.Entity
+	}
+	return ""
+}
+
+func (x *ListPreviousRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+type ListPreviousResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Queries       []*CachedQuery         `protobuf:"bytes,1,rep,name=queries,proto3" json:"queries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPreviousResponse) Reset() {
+	*x = ListPreviousResponse{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPreviousResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPreviousResponse) ProtoMessage() {}
+
+func (x *ListPreviousResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPreviousResponse.ProtoReflect.Descriptor instead.
+func (*ListPreviousResponse) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListPreviousResponse) GetQueries() []*CachedQuery {
+	if x != nil {
+		return x.Queries
+	}
+	return nil
+}
+
+// CachedQuery mirrors cache.QueryListItem
+type CachedQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	UniqueId      string                 `protobuf:"bytes,2,opt,name=unique_id,json=uniqueId,proto3" json:"unique_id,omitempty"`
+	Datetime      string                 `protobuf:"bytes,3,opt,name=datetime,proto3" json:"datetime,omitempty"` // RFC 3339
+	SearchType    string                 `protobuf:"bytes,4,opt,name=search_type,json=searchType,proto3" json:"search_type,omitempty"`
+	Keywords      []string               `protobuf:"bytes,5,rep,name=keywords,proto3" json:"keywords,omitempty"`
+	Entities      []string               `protobuf:"bytes,6,rep,name=entities,proto3" json:"entities,omitempty"`
+	Shared        bool                   `protobuf:"varint,7,opt,name=shared,proto3" json:"shared,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CachedQuery) Reset() {
+	*x = CachedQuery{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CachedQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CachedQuery) ProtoMessage() {}
+
+func (x *CachedQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CachedQuery.ProtoReflect.Descriptor instead.
+func (*CachedQuery) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CachedQuery) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *CachedQuery) GetUniqueId() string {
+	if x != nil {
+		return x.UniqueId
+	}
+	return ""
+}
+
+func (x *CachedQuery) GetDatetime() string {
+	if x != nil {
+		return x.Datetime
+	}
+	return ""
+}
+
+func (x *CachedQuery) GetSearchType() string {
+	if x != nil {
+		return x.SearchType
+	}
+	return ""
+}
+
+func (x *CachedQuery) GetKeywords() []string {
+	if x != nil {
+		return x.Keywords
+	}
+	return nil
+}
+
+func (x *CachedQuery) GetEntities() []string {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+func (x *CachedQuery) GetShared() bool {
+	if x != nil {
+		return x.Shared
+	}
+	return false
+}
+
+type GetPreviousResultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UniqueId      string                 `protobuf:"bytes,1,opt,name=unique_id,json=uniqueId,proto3" json:"unique_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPreviousResultRequest) Reset() {
+	*x = GetPreviousResultRequest{}
+	mi := &file_perplexity_v1_search_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPreviousResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPreviousResultRequest) ProtoMessage() {}
+
+func (x *GetPreviousResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_perplexity_v1_search_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPreviousResultRequest.ProtoReflect.Descriptor instead.
+func (*GetPreviousResultRequest) Descriptor() ([]byte, []int) {
+	return file_perplexity_v1_search_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetPreviousResultRequest) GetUniqueId() string {
+	if x != nil {
+		return x.UniqueId
+	}
+	return ""
+}
+
+var File_perplexity_v1_search_proto protoreflect.FileDescriptor
+
+const file_perplexity_v1_search_proto_rawDesc = "" +
+	"\n" +
+	"\x1aperplexity/v1/search.proto\x12\rperplexity.v1\"\xd8\x06\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x120\n" +
+	"\x14search_domain_filter\x18\x03 \x03(\tR\x12searchDomainFilter\x124\n" +
+	"\x16search_exclude_domains\x18\x04 \x03(\tR\x14searchExcludeDomains\x122\n" +
+	"\x15search_recency_filter\x18\x05 \x01(\tR\x13searchRecencyFilter\x12(\n" +
+	"\rreturn_images\x18\x06 \x01(\bH\x00R\freturnImages\x88\x01\x01\x12=\n" +
+	"\x18return_related_questions\x18\a \x01(\bH\x01R\x16returnRelatedQuestions\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"max_tokens\x18\b \x01(\x05H\x02R\tmaxTokens\x88\x01\x01\x12%\n" +
+	"\vtemperature\x18\t \x01(\x01H\x03R\vtemperature\x88\x01\x01\x12(\n" +
+	"\x10date_range_start\x18\n" +
+	" \x01(\tR\x0edateRangeStart\x12$\n" +
+	"\x0edate_range_end\x18\v \x01(\tR\fdateRangeEnd\x12\x1a\n" +
+	"\blocation\x18\f \x01(\tR\blocation\x12\x1f\n" +
+	"\vsearch_mode\x18\r \x01(\tR\n" +
+	"searchMode\x12)\n" +
+	"\x0eretry_on_empty\x18\x0e \x01(\bH\x04R\fretryOnEmpty\x88\x01\x01\x12#\n" +
+	"\rmin_citations\x18\x0f \x01(\x05R\fminCitations\x12!\n" +
+	"\fdeep_sources\x18\x10 \x01(\bR\vdeepSources\x12'\n" +
+	"\x0fanswer_language\x18\x11 \x01(\tR\x0eanswerLanguage\x12'\n" +
+	"\x0ftimeout_seconds\x18\x12 \x01(\x05R\x0etimeoutSeconds\x12\x18\n" +
+	"\aproject\x18\x13 \x01(\tR\aprojectB\x10\n" +
+	"\x0e_return_imagesB\x1b\n" +
+	"\x19_return_related_questionsB\r\n" +
+	"\v_max_tokensB\x0e\n" +
+	"\f_temperatureB\x11\n" +
+	"\x0f_retry_on_empty\"\xcb\x01\n" +
+	"\x15AcademicSearchRequest\x124\n" +
+	"\x06search\x18\x01 \x01(\v2\x1c.perplexity.v1.SearchRequestR\x06search\x12!\n" +
+	"\fsubject_area\x18\x02 \x01(\tR\vsubjectArea\x12,\n" +
+	"\x12peer_reviewed_only\x18\x03 \x01(\bR\x10peerReviewedOnly\x12+\n" +
+	"\x11include_preprints\x18\x04 \x01(\bR\x10includePreprints\"\xe1\x01\n" +
+	"\x16FinancialSearchRequest\x124\n" +
+	"\x06search\x18\x01 \x01(\v2\x1c.perplexity.v1.SearchRequestR\x06search\x12\x16\n" +
+	"\x06ticker\x18\x02 \x01(\tR\x06ticker\x12!\n" +
+	"\fcompany_name\x18\x03 \x01(\tR\vcompanyName\x12\x1f\n" +
+	"\vreport_type\x18\x04 \x01(\tR\n" +
+	"reportType\x12\x1f\n" +
+	"\vasset_class\x18\x05 \x01(\tR\n" +
+	"assetClass\x12\x14\n" +
+	"\x05event\x18\x06 \x01(\tR\x05event\"d\n" +
+	"\x0eSearchResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\x12\x1b\n" +
+	"\tunique_id\x18\x02 \x01(\tR\buniqueId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\tR\trequestId\"a\n" +
+	"\x13ListPreviousRequest\x12\x18\n" +
+	"\aproject\x18\x01 \x01(\tR\aproject\x12\x16\n" +
+	"\x06entity\x18\x02 \x01(\tR\x06entity\x12\x18\n" +
+	"\akeyword\x18\x03 \x01(\tR\akeyword\"L\n" +
+	"\x14ListPreviousResponse\x124\n" +
+	"\aqueries\x18\x01 \x03(\v2\x1a.perplexity.v1.CachedQueryR\aqueries\"\xcd\x01\n" +
+	"\vCachedQuery\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1b\n" +
+	"\tunique_id\x18\x02 \x01(\tR\buniqueId\x12\x1a\n" +
+	"\bdatetime\x18\x03 \x01(\tR\bdatetime\x12\x1f\n" +
+	"\vsearch_type\x18\x04 \x01(\tR\n" +
+	"searchType\x12\x1a\n" +
+	"\bkeywords\x18\x05 \x03(\tR\bkeywords\x12\x1a\n" +
+	"\bentities\x18\x06 \x03(\tR\bentities\x12\x16\n" +
+	"\x06shared\x18\a \x01(\bR\x06shared\"7\n" +
+	"\x18GetPreviousResultRequest\x12\x1b\n" +
+	"\tunique_id\x18\x01 \x01(\tR\buniqueId2\xbc\x03\n" +
+	"\rSearchService\x12E\n" +
+	"\x06Search\x12\x1c.perplexity.v1.SearchRequest\x1a\x1d.perplexity.v1.SearchResponse\x12U\n" +
+	"\x0eAcademicSearch\x12$.perplexity.v1.AcademicSearchRequest\x1a\x1d.perplexity.v1.SearchResponse\x12W\n" +
+	"\x0fFinancialSearch\x12%.perplexity.v1.FinancialSearchRequest\x1a\x1d.perplexity.v1.SearchResponse\x12W\n" +
+	"\fListPrevious\x12\".perplexity.v1.ListPreviousRequest\x1a#.perplexity.v1.ListPreviousResponse\x12[\n" +
+	"\x11GetPreviousResult\x12'.perplexity.v1.GetPreviousResultRequest\x1a\x1d.perplexity.v1.SearchResponseB;Z9github.com/prasanthmj/perplexity/pkg/grpcapi/perplexityv1b\x06proto3"
+
+var (
+	file_perplexity_v1_search_proto_rawDescOnce sync.Once
+	file_perplexity_v1_search_proto_rawDescData []byte
+)
+
+func file_perplexity_v1_search_proto_rawDescGZIP() []byte {
+	file_perplexity_v1_search_proto_rawDescOnce.Do(func() {
+		file_perplexity_v1_search_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_perplexity_v1_search_proto_rawDesc), len(file_perplexity_v1_search_proto_rawDesc)))
+	})
+	return file_perplexity_v1_search_proto_rawDescData
+}
+
+var file_perplexity_v1_search_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_perplexity_v1_search_proto_goTypes = []any{
+	(*SearchRequest)(nil),            // 0: perplexity.v1.SearchRequest
+	(*AcademicSearchRequest)(nil),    // 1: perplexity.v1.AcademicSearchRequest
+	(*FinancialSearchRequest)(nil),   // 2: perplexity.v1.FinancialSearchRequest
+	(*SearchResponse)(nil),           // 3: perplexity.v1.SearchResponse
+	(*ListPreviousRequest)(nil),      // 4: perplexity.v1.ListPreviousRequest
+	(*ListPreviousResponse)(nil),     // 5: perplexity.v1.ListPreviousResponse
+	(*CachedQuery)(nil),              // 6: perplexity.v1.CachedQuery
+	(*GetPreviousResultRequest)(nil), // 7: perplexity.v1.GetPreviousResultRequest
+}
+var file_perplexity_v1_search_proto_depIdxs = []int32{
+	0, // 0: perplexity.v1.AcademicSearchRequest.search:type_name -> perplexity.v1.SearchRequest
+	0, // 1: perplexity.v1.FinancialSearchRequest.search:type_name -> perplexity.v1.SearchRequest
+	6, // 2: perplexity.v1.ListPreviousResponse.queries:type_name -> perplexity.v1.CachedQuery
+	0, // 3: perplexity.v1.SearchService.Search:input_type -> perplexity.v1.SearchRequest
+	1, // 4: perplexity.v1.SearchService.AcademicSearch:input_type -> perplexity.v1.AcademicSearchRequest
+	2, // 5: perplexity.v1.SearchService.FinancialSearch:input_type -> perplexity.v1.FinancialSearchRequest
+	4, // 6: perplexity.v1.SearchService.ListPrevious:input_type -> perplexity.v1.ListPreviousRequest
+	7, // 7: perplexity.v1.SearchService.GetPreviousResult:input_type -> perplexity.v1.GetPreviousResultRequest
+	3, // 8: perplexity.v1.SearchService.Search:output_type -> perplexity.v1.SearchResponse
+	3, // 9: perplexity.v1.SearchService.AcademicSearch:output_type -> perplexity.v1.SearchResponse
+	3, // 10: perplexity.v1.SearchService.FinancialSearch:output_type -> perplexity.v1.SearchResponse
+	5, // 11: perplexity.v1.SearchService.ListPrevious:output_type -> perplexity.v1.ListPreviousResponse
+	3, // 12: perplexity.v1.SearchService.GetPreviousResult:output_type -> perplexity.v1.SearchResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_perplexity_v1_search_proto_init() }
+func file_perplexity_v1_search_proto_init() {
+	if File_perplexity_v1_search_proto != nil {
+		return
+	}
+	file_perplexity_v1_search_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_perplexity_v1_search_proto_rawDesc), len(file_perplexity_v1_search_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_perplexity_v1_search_proto_goTypes,
+		DependencyIndexes: file_perplexity_v1_search_proto_depIdxs,
+		MessageInfos:      file_perplexity_v1_search_proto_msgTypes,
+	}.Build()
+	File_perplexity_v1_search_proto = out.File
+	file_perplexity_v1_search_proto_goTypes = nil
+	file_perplexity_v1_search_proto_depIdxs = nil
+}
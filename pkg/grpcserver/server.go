@@ -0,0 +1,169 @@
+// Package grpcserver serves the search service of proto/perplexity/v1/search.proto for Go
+// services that embed search. It calls the Searcher of the MCP handler, so gRPC calls share
+// its caching and rate limiting.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/grpcapi/perplexityv1"
+	"github.com/prasanthmj/perplexity/pkg/httpserver"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+	"github.com/prasanthmj/perplexity/pkg/search"
+	"github.com/prasanthmj/perplexity/pkg/stats"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrOpenGRPC is returned by Listen for a service without client tokens on an address other
+// machines can reach, since it would run searches, with the server's API key, for anyone
+var ErrOpenGRPC = errors.New("the gRPC service requires PERPLEXITY_CLIENTS_FILE unless PERPLEXITY_GRPC_ADDR is a loopback address")
+
+// methodTools names the tool each RPC corresponds to in metrics and usage stats
+var methodTools = map[string]string{
+	perplexityv1.SearchService_Search_FullMethodName:            "perplexity_search",
+	perplexityv1.SearchService_AcademicSearch_FullMethodName:    "perplexity_academic_search",
+	perplexityv1.SearchService_FinancialSearch_FullMethodName:   "perplexity_financial_search",
+	perplexityv1.SearchService_ListPrevious_FullMethodName:      "list_previous",
+	perplexityv1.SearchService_GetPreviousResult_FullMethodName: "get_previous_result",
+}
+
+// Server serves the search service on PERPLEXITY_GRPC_ADDR
+type Server struct {
+	addr    string
+	service *service
+	clients *quota.Tracker
+	srv     *grpc.Server
+	// Files of the TLS certificate, key, and client CA; no certificate serves plaintext
+	tlsCert, tlsKey, tlsClientCA string
+}
+
+// NewServer creates a server for the configured address that runs searches on searcher.
+// With a non-nil clients tracker, every call needs the bearer token of one of its clients
+// and counts toward that client's quotas, as REST calls do.
+func NewServer(cfg *config.Config, searcher *search.Searcher, clients *quota.Tracker) *Server {
+	return &Server{
+		addr:        cfg.GRPCAddr,
+		service:     &service{searcher: searcher},
+		clients:     clients,
+		tlsCert:     cfg.TLSCert,
+		tlsKey:      cfg.TLSKey,
+		tlsClientCA: cfg.TLSClientCA,
+	}
+}
+
+// ListenAndServe starts serving, over TLS when a certificate is configured, and blocks until
+// the server stops
+func (s *Server) ListenAndServe() error {
+	ln, err := s.Listen()
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Listen binds the configured address and loads the TLS certificate, so that a service can
+// report readiness before calling Serve
+func (s *Server) Listen() (net.Listener, error) {
+	if s.clients == nil && !httpserver.IsLoopbackAddr(s.addr) {
+		return nil, ErrOpenGRPC
+	}
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(s.intercept)}
+	if s.tlsCert != "" {
+		tlsConfig, err := httpserver.ServerTLSConfig(s.tlsCert, s.tlsKey, s.tlsClientCA)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	s.srv = grpc.NewServer(opts...)
+	perplexityv1.RegisterSearchServiceServer(s.srv, s.service)
+	return ln, nil
+}
+
+// Serve serves on a listener from Listen and blocks until the server stops
+func (s *Server) Serve(ln net.Listener) error {
+	if err := s.srv.Serve(ln); err != grpc.ErrServerStopped {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting calls and waits for those in progress until ctx is done, then
+// cancels the rest
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.srv == nil {
+		return nil
+	}
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
+}
+
+// intercept authenticates each call, gives it a request ID, and records it in the metrics
+// and usage stats under the name of the matching tool
+func (s *Server) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.clients != nil {
+		client, ok := s.clients.Authenticate(bearerToken(ctx))
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "a client token is required as a bearer token")
+		}
+		if err := s.clients.Allow(client); err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+		ctx = quota.WithClient(ctx, client)
+	}
+
+	start := time.Now()
+	tool := methodTools[info.FullMethod]
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	log := logging.FromContext(ctx).With("tool", tool, "transport", "grpc")
+	log.Debug("tool call started")
+
+	resp, err := handler(ctx, req)
+	metrics.ToolRequests.Inc(tool)
+	errorType := ""
+	if err != nil {
+		metrics.ToolErrors.Inc(tool)
+		errorType = errorTypeOf(err)
+		log.Warn("tool call failed", "elapsed", time.Since(start), "error", err)
+	} else {
+		log.Info("tool call completed", "elapsed", time.Since(start))
+	}
+	stats.Default.Call(tool, errorType)
+	return resp, toStatus(err)
+}
+
+// bearerToken returns the token of the call's authorization metadata
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	token, _ := strings.CutPrefix(values[0], "Bearer ")
+	return token
+}
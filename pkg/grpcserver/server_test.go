@@ -0,0 +1,144 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/grpcapi/perplexityv1"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+	"github.com/prasanthmj/perplexity/pkg/search"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// startServer serves the search service on a loopback port and returns a client of it
+func startServer(t *testing.T, searcher *search.Searcher, clients *quota.Tracker) perplexityv1.SearchServiceClient {
+	t.Helper()
+	s := NewServer(&config.Config{GRPCAddr: "127.0.0.1:0"}, searcher, clients)
+	ln, err := s.Listen()
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	go s.Serve(ln)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return perplexityv1.NewSearchServiceClient(conn)
+}
+
+func TestSearchServiceCache(t *testing.T) {
+	root := t.TempDir()
+	id, err := cache.SaveResult(root, "Fed rate decision", "financial", "sonar", "Rates held.\n\n## Source URLs\n1. https://fed.gov/a\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searcher, err := search.New("test-key", search.WithResultsFolder(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := startServer(t, searcher, nil)
+	ctx := context.Background()
+
+	list, err := client.ListPrevious(ctx, &perplexityv1.ListPreviousRequest{})
+	if err != nil {
+		t.Fatalf("ListPrevious failed: %v", err)
+	}
+	if len(list.Queries) != 1 || list.Queries[0].UniqueId != id || list.Queries[0].Query != "Fed rate decision" || list.Queries[0].SearchType != "financial" {
+		t.Errorf("Unexpected query list: %v", list.Queries)
+	}
+	if list, err := client.ListPrevious(ctx, &perplexityv1.ListPreviousRequest{Keyword: "no-such-keyword"}); err != nil || len(list.Queries) != 0 {
+		t.Errorf("Expected an empty list for an unmatched keyword, got %v, %v", list, err)
+	}
+
+	resp, err := client.GetPreviousResult(ctx, &perplexityv1.GetPreviousResultRequest{UniqueId: id})
+	if err != nil {
+		t.Fatalf("GetPreviousResult failed: %v", err)
+	}
+	if !strings.Contains(resp.Result, "Rates held.") || resp.UniqueId != id || resp.RequestId == "" {
+		t.Errorf("Unexpected result: %+v", resp)
+	}
+	if _, err := client.GetPreviousResult(ctx, &perplexityv1.GetPreviousResultRequest{UniqueId: "ZZZZZZZZZZ"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown ID, got %v", err)
+	}
+	if _, err := client.GetPreviousResult(ctx, &perplexityv1.GetPreviousResultRequest{UniqueId: "../etc"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed ID, got %v", err)
+	}
+}
+
+func TestSearchServiceInvalidArguments(t *testing.T) {
+	searcher, err := search.New("test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := startServer(t, searcher, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"missing query", func() error {
+			_, err := client.Search(ctx, &perplexityv1.SearchRequest{})
+			return err
+		}},
+		{"invalid search mode", func() error {
+			_, err := client.Search(ctx, &perplexityv1.SearchRequest{Query: "q", SearchMode: "news"})
+			return err
+		}},
+		{"invalid project", func() error {
+			_, err := client.AcademicSearch(ctx, &perplexityv1.AcademicSearchRequest{Search: &perplexityv1.SearchRequest{Query: "q", Project: "../other"}})
+			return err
+		}},
+		{"event without company", func() error {
+			_, err := client.FinancialSearch(ctx, &perplexityv1.FinancialSearchRequest{Search: &perplexityv1.SearchRequest{Query: "q"}, Event: "earnings"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: expected InvalidArgument, got %v", tt.name, err)
+		}
+	}
+
+	// The cache RPCs need a results folder
+	if _, err := client.ListPrevious(ctx, &perplexityv1.ListPreviousRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without caching, got %v", err)
+	}
+}
+
+func TestSearchServiceRequiresClients(t *testing.T) {
+	searcher, err := search.New("test-key", search.WithResultsFolder(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An open service is only served on a loopback address
+	if _, err := NewServer(&config.Config{GRPCAddr: ":0"}, searcher, nil).Listen(); !errors.Is(err, ErrOpenGRPC) {
+		t.Errorf("Expected ErrOpenGRPC for an open service on all interfaces, got %v", err)
+	}
+
+	clients := quota.NewTracker(map[string]config.ClientConfig{"ci": {Token: "ci-secret", DailyRequests: 1}}, nil)
+	client := startServer(t, searcher, clients)
+	if _, err := client.ListPrevious(context.Background(), &perplexityv1.ListPreviousRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a token, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer ci-secret")
+	if _, err := client.ListPrevious(ctx, &perplexityv1.ListPreviousRequest{}); err != nil {
+		t.Errorf("Expected the client's call accepted, got %v", err)
+	}
+	if _, err := client.ListPrevious(ctx, &perplexityv1.ListPreviousRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted over the daily quota, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/grpcapi/perplexityv1"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/search"
+	"github.com/prasanthmj/perplexity/pkg/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error type identifiers recorded for failures that happen before the API is called, as the
+// MCP handler records them
+const (
+	errorTypeInvalidParameters = "invalid_parameters"
+	errorTypeTool              = "tool_error"
+)
+
+// errInvalidParameters marks errors caused by bad request fields
+var errInvalidParameters = errors.New("invalid parameters")
+
+// errCachingDisabled is returned by the cache RPCs when results are not cached
+var errCachingDisabled = errors.New("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
+
+// service implements the RPCs on a Searcher
+type service struct {
+	perplexityv1.UnimplementedSearchServiceServer
+	searcher *search.Searcher
+}
+
+// Search runs Searcher.Search
+func (s *service) Search(ctx context.Context, req *perplexityv1.SearchRequest) (*perplexityv1.SearchResponse, error) {
+	params, err := searchParams(req, "general")
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, (*search.Searcher).Search, params)
+}
+
+// AcademicSearch runs Searcher.AcademicSearch
+func (s *service) AcademicSearch(ctx context.Context, req *perplexityv1.AcademicSearchRequest) (*perplexityv1.SearchResponse, error) {
+	params, err := searchParams(req.GetSearch(), "academic")
+	if err != nil {
+		return nil, err
+	}
+	params.SubjectArea = req.GetSubjectArea()
+	params.PeerReviewedOnly = req.GetPeerReviewedOnly()
+	params.IncludePreprints = req.GetIncludePreprints()
+	return s.run(ctx, (*search.Searcher).AcademicSearch, params)
+}
+
+// FinancialSearch runs Searcher.FinancialSearch
+func (s *service) FinancialSearch(ctx context.Context, req *perplexityv1.FinancialSearchRequest) (*perplexityv1.SearchResponse, error) {
+	params, err := searchParams(req.GetSearch(), "financial")
+	if err != nil {
+		return nil, err
+	}
+	params.Ticker = req.GetTicker()
+	params.CompanyName = req.GetCompanyName()
+	params.ReportType = req.GetReportType()
+	if assetClass := strings.ToLower(strings.TrimSpace(req.GetAssetClass())); assetClass != "" {
+		if !search.IsValidAssetClass(assetClass) {
+			return nil, fmt.Errorf("%w: invalid asset_class '%s'. Use equity, crypto, fx, or commodity", errInvalidParameters, assetClass)
+		}
+		params.AssetClass = assetClass
+	}
+	if event := strings.ToLower(strings.TrimSpace(req.GetEvent())); event != "" {
+		if !search.IsValidEvent(event) {
+			return nil, fmt.Errorf("%w: invalid event '%s'. Use earnings, dividend, or split", errInvalidParameters, event)
+		}
+		if params.Ticker == "" && params.CompanyName == "" {
+			return nil, fmt.Errorf("%w: event requires ticker or company_name", errInvalidParameters)
+		}
+		params.Event = event
+	}
+	return s.run(ctx, (*search.Searcher).FinancialSearch, params)
+}
+
+// ListPrevious runs Searcher.ListPrevious. A folder without matching results gives an empty
+// list rather than an error.
+func (s *service) ListPrevious(ctx context.Context, req *perplexityv1.ListPreviousRequest) (*perplexityv1.ListPreviousResponse, error) {
+	if !s.searcher.CachingEnabled() {
+		return nil, errCachingDisabled
+	}
+	if req.GetProject() != "" {
+		if err := config.ValidateProjectName(req.GetProject()); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidParameters, err)
+		}
+	}
+
+	// ListPrevious reports an empty or unmatched folder as an error with an empty list
+	output, err := s.searcher.ListPrevious(ctx, req.GetProject(), cache.QueryFilter{Entity: req.GetEntity(), Keyword: req.GetKeyword()})
+	if err != nil && output == "[]" {
+		return &perplexityv1.ListPreviousResponse{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []cache.QueryListItem
+	if err := json.Unmarshal([]byte(output), &items); err != nil {
+		return nil, fmt.Errorf("failed to read query list: %w", err)
+	}
+
+	resp := &perplexityv1.ListPreviousResponse{}
+	for _, item := range items {
+		resp.Queries = append(resp.Queries, &perplexityv1.CachedQuery{
+			Query:      item.Query,
+			UniqueId:   item.UniqueID,
+			Datetime:   item.DateTime.Format(time.RFC3339),
+			SearchType: item.SearchType,
+			Keywords:   item.Keywords,
+			Entities:   item.Entities,
+			Shared:     item.Shared,
+		})
+	}
+	return resp, nil
+}
+
+// GetPreviousResult runs Searcher.GetPreviousResult
+func (s *service) GetPreviousResult(ctx context.Context, req *perplexityv1.GetPreviousResultRequest) (*perplexityv1.SearchResponse, error) {
+	if !s.searcher.CachingEnabled() {
+		return nil, errCachingDisabled
+	}
+	if !cache.IsValidID(req.GetUniqueId()) {
+		return nil, fmt.Errorf("%w: unique_id must be a 10-character result ID", errInvalidParameters)
+	}
+	result, err := s.searcher.GetPreviousResult(ctx, req.GetUniqueId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &perplexityv1.SearchResponse{Result: result, UniqueId: req.GetUniqueId(), RequestId: logging.RequestID(ctx)}, nil
+}
+
+// run performs a search and returns the whole result, read back from the cache when it was
+// saved there
+func (s *service) run(ctx context.Context, method search.SearchMethod, params *search.SearchParams) (*perplexityv1.SearchResponse, error) {
+	resp, err := s.searcher.Run(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	return &perplexityv1.SearchResponse{Result: resp.Content, UniqueId: resp.ID, RequestId: logging.RequestID(ctx)}, nil
+}
+
+// searchParams converts the shared search fields, validating them as the MCP handler does
+func searchParams(req *perplexityv1.SearchRequest, searchType string) (*search.SearchParams, error) {
+	if strings.TrimSpace(req.GetQuery()) == "" {
+		return nil, fmt.Errorf("%w: query is required", errInvalidParameters)
+	}
+	params := &search.SearchParams{
+		Query:                  req.GetQuery(),
+		SearchType:             searchType,
+		Model:                  req.GetModel(),
+		SearchDomainFilter:     req.GetSearchDomainFilter(),
+		SearchExcludeDomains:   req.GetSearchExcludeDomains(),
+		SearchRecencyFilter:    req.GetSearchRecencyFilter(),
+		ReturnImages:           req.ReturnImages,
+		ReturnRelatedQuestions: req.ReturnRelatedQuestions,
+		Temperature:            req.Temperature,
+		DateRangeStart:         req.GetDateRangeStart(),
+		DateRangeEnd:           req.GetDateRangeEnd(),
+		Location:               req.GetLocation(),
+		RetryOnEmpty:           req.RetryOnEmpty,
+		DeepSources:            req.GetDeepSources(),
+		AnswerLanguage:         req.GetAnswerLanguage(),
+	}
+	if req.MaxTokens != nil {
+		maxTokens := int(req.GetMaxTokens())
+		params.MaxTokens = &maxTokens
+	}
+	if req.GetMinCitations() < 0 {
+		return nil, fmt.Errorf("%w: min_citations must be non-negative", errInvalidParameters)
+	}
+	params.MinCitations = int(req.GetMinCitations())
+	if req.GetTimeoutSeconds() < 0 {
+		return nil, fmt.Errorf("%w: timeout_seconds must not be negative", errInvalidParameters)
+	}
+	params.TimeoutSeconds = int(req.GetTimeoutSeconds())
+
+	switch mode := req.GetSearchMode(); mode {
+	case "", types.SearchModeWeb, types.SearchModeAcademic, types.SearchModeSEC:
+		params.SearchMode = mode
+	default:
+		return nil, fmt.Errorf("%w: search_mode '%s' is not valid. Use 'web', 'academic', or 'sec'", errInvalidParameters, mode)
+	}
+	if project := req.GetProject(); project != "" {
+		if err := config.ValidateProjectName(project); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidParameters, err)
+		}
+		params.CacheProject = project
+	}
+	return params, nil
+}
+
+// toStatus converts an RPC error to a gRPC status, mapping API failures by their type
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, errInvalidParameters) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, errCachingDisabled) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	var apiErr *search.APIError
+	if !errors.As(err, &apiErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	switch apiErr.ErrorType {
+	case search.ErrorTypeRateLimit:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case search.ErrorTypeTimeout:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case search.ErrorTypeCanceled:
+		return status.Error(codes.Canceled, err.Error())
+	case search.ErrorTypeBadRequest:
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if apiErr.Retryable {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// errorTypeOf returns the error type an RPC error is recorded under in the usage stats
+func errorTypeOf(err error) string {
+	var apiErr *search.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.ErrorType
+	case errors.Is(err, errInvalidParameters):
+		return errorTypeInvalidParameters
+	}
+	return errorTypeTool
+}
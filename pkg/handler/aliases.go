@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// argumentAliases maps argument names agents commonly send by mistake to the names the
+// search tools accept. None of them is an argument of any tool.
+var argumentAliases = map[string]string{
+	"q":                 "query",
+	"prompt":            "query",
+	"domains":           "search_domain_filter",
+	"domain_filter":     "search_domain_filter",
+	"include_domains":   "search_domain_filter",
+	"sites":             "search_domain_filter",
+	"exclude_domains":   "search_exclude_domains",
+	"excluded_domains":  "search_exclude_domains",
+	"recency":           "search_recency_filter",
+	"recency_filter":    "search_recency_filter",
+	"time_range":        "search_recency_filter",
+	"images":            "return_images",
+	"related_questions": "return_related_questions",
+	"lang":              "answer_language",
+	"timeout":           "timeout_seconds",
+	"max_output_tokens": "max_tokens",
+	"start_date":        "date_range_start",
+	"end_date":          "date_range_end",
+	"min_sources":       "min_citations",
+	"refs":              "context_refs",
+}
+
+// numberArguments are the arguments read as JSON numbers
+var numberArguments = []string{"min_citations", "timeout_seconds", "max_tokens", "temperature"}
+
+// boolArguments are the arguments read as JSON booleans
+var boolArguments = []string{
+	"return_images", "return_related_questions", "retry_on_empty", "deep_sources", "separate_opinions", "extract_tables",
+}
+
+// listArguments are the arguments read as JSON arrays of strings
+var listArguments = []string{"search_domain_filter", "search_exclude_domains", "context_refs", "regions"}
+
+// recencyAliases maps spellings of a recency window to the filter value it means
+var recencyAliases = map[string]string{
+	"1h":         types.RecencyHour,
+	"60m":        types.RecencyHour,
+	"hourly":     types.RecencyHour,
+	"past hour":  types.RecencyHour,
+	"last hour":  types.RecencyHour,
+	"1d":         types.RecencyDay,
+	"24h":        types.RecencyDay,
+	"today":      types.RecencyDay,
+	"daily":      types.RecencyDay,
+	"past day":   types.RecencyDay,
+	"last day":   types.RecencyDay,
+	"7d":         types.RecencyWeek,
+	"1w":         types.RecencyWeek,
+	"weekly":     types.RecencyWeek,
+	"past week":  types.RecencyWeek,
+	"last week":  types.RecencyWeek,
+	"30d":        types.RecencyMonth,
+	"31d":        types.RecencyMonth,
+	"monthly":    types.RecencyMonth,
+	"past month": types.RecencyMonth,
+	"last month": types.RecencyMonth,
+	"365d":       types.RecencyYear,
+	"1y":         types.RecencyYear,
+	"12m":        types.RecencyYear,
+	"yearly":     types.RecencyYear,
+	"annual":     types.RecencyYear,
+	"past year":  types.RecencyYear,
+	"last year":  types.RecencyYear,
+}
+
+// NormalizeArguments returns a copy of args with aliased names renamed and loosely typed
+// values coerced: numbers and booleans sent as strings, a single domain or a comma-separated
+// list where an array is expected, and recency windows such as "7d" or "past week". An
+// argument given under its own name wins over any alias for it. Values that cannot be
+// coerced are left as they are.
+func NormalizeArguments(args map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		normalized[k] = v
+	}
+	for alias, name := range argumentAliases {
+		value, ok := normalized[alias]
+		if !ok {
+			continue
+		}
+		if _, exists := args[name]; !exists {
+			normalized[name] = value
+			delete(normalized, alias)
+		}
+	}
+
+	for _, name := range numberArguments {
+		if s, ok := normalized[name].(string); ok {
+			if n, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				normalized[name] = n
+			}
+		}
+	}
+	for _, name := range boolArguments {
+		if s, ok := normalized[name].(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				normalized[name] = b
+			}
+		}
+	}
+	for _, name := range listArguments {
+		if s, ok := normalized[name].(string); ok {
+			normalized[name] = splitList(s)
+		}
+	}
+
+	if recency, ok := normalized["search_recency_filter"].(string); ok {
+		normalized["search_recency_filter"] = normalizeRecency(recency)
+	}
+	return normalized
+}
+
+// splitList splits a comma-separated string into a JSON-style array, dropping empty items
+func splitList(s string) []interface{} {
+	items := make([]interface{}, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// normalizeRecency maps a recency window to a filter value, leaving unknown values as given
+func normalizeRecency(recency string) string {
+	key := strings.ToLower(strings.TrimSpace(recency))
+	switch key {
+	case types.RecencyHour, types.RecencyDay, types.RecencyWeek, types.RecencyMonth, types.RecencyYear:
+		return key
+	}
+	if value, ok := recencyAliases[key]; ok {
+		return value
+	}
+	if value, ok := recencyAliases[strings.ReplaceAll(key, " ", "")]; ok {
+		return value
+	}
+	return recency
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestNormalizeArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			"aliased names",
+			map[string]interface{}{"q": "go", "domains": []interface{}{"go.dev"}, "recency": "week"},
+			map[string]interface{}{"query": "go", "search_domain_filter": []interface{}{"go.dev"}, "search_recency_filter": "week"},
+		},
+		{
+			"canonical name wins",
+			map[string]interface{}{"query": "go", "q": "rust"},
+			map[string]interface{}{"query": "go", "q": "rust"},
+		},
+		{
+			"numbers and booleans as strings",
+			map[string]interface{}{"max_tokens": "1024", "temperature": " 0.2", "return_images": "true", "min_citations": "three"},
+			map[string]interface{}{"max_tokens": 1024.0, "temperature": 0.2, "return_images": true, "min_citations": "three"},
+		},
+		{
+			"comma-separated list",
+			map[string]interface{}{"exclude_domains": "reddit.com, quora.com,"},
+			map[string]interface{}{"search_exclude_domains": []interface{}{"reddit.com", "quora.com"}},
+		},
+		{
+			"recency windows",
+			map[string]interface{}{"search_recency_filter": "7d"},
+			map[string]interface{}{"search_recency_filter": "week"},
+		},
+		{
+			"unknown recency kept",
+			map[string]interface{}{"time_range": "fortnight"},
+			map[string]interface{}{"search_recency_filter": "fortnight"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeArguments(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecency(t *testing.T) {
+	for in, want := range map[string]string{
+		"Day": "day", "24h": "day", "past week": "week", "Last Month": "month", "1y": "year", "1 h": "hour",
+	} {
+		if got := normalizeRecency(in); got != want {
+			t.Errorf("normalizeRecency(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExtractSearchParamsAliases(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	args := map[string]interface{}{"q": "latest Go release", "domains": "go.dev", "recency": "7d", "max_tokens": "512"}
+	params, err := h.extractSearchParams(args, "general")
+	if err != nil {
+		t.Fatalf("extractSearchParams failed: %v", err)
+	}
+	if params.Query != "latest Go release" || params.SearchRecencyFilter != "week" {
+		t.Errorf("unexpected params: %+v", params)
+	}
+	if !reflect.DeepEqual(params.SearchDomainFilter, []string{"go.dev"}) {
+		t.Errorf("SearchDomainFilter = %v", params.SearchDomainFilter)
+	}
+	if params.MaxTokens == nil || *params.MaxTokens != 512 {
+		t.Errorf("MaxTokens = %v, want 512", params.MaxTokens)
+	}
+	if _, ok := args["query"]; ok {
+		t.Error("normalizing changed the caller's arguments")
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+)
+
+// acceptedArguments returns the sorted argument names in a tool's input schema, reporting
+// false for a tool that is not listed
+func (h *Handler) acceptedArguments(ctx context.Context, name string) ([]string, bool) {
+	list, err := h.ListTools(ctx)
+	if err != nil {
+		return nil, false
+	}
+	for _, tool := range list.Tools {
+		if tool.Name != name {
+			continue
+		}
+		var schema struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if json.Unmarshal(tool.InputSchema, &schema) != nil {
+			return nil, false
+		}
+		names := make([]string, 0, len(schema.Properties))
+		for property := range schema.Properties {
+			names = append(names, property)
+		}
+		sort.Strings(names)
+		return names, true
+	}
+	return nil, false
+}
+
+// unknownArguments returns the sorted names in args that a tool does not accept. The aliases
+// read by the search tools count as accepted when the tool takes the argument they stand for.
+func unknownArguments(tool string, args map[string]interface{}, accepted []string) []string {
+	known := make(map[string]bool, len(accepted))
+	for _, name := range accepted {
+		known[name] = true
+	}
+	var unknown []string
+	for name := range args {
+		if known[name] {
+			continue
+		}
+		if target, ok := argumentAliases[name]; ok && known[target] && strings.HasPrefix(tool, "perplexity_") {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// checkArguments looks for arguments the tool does not accept. In strict mode they fail the
+// call with the names the tool accepts; in lenient mode they are ignored, as the handlers
+// have always done, and only logged at debug level.
+func (h *Handler) checkArguments(ctx context.Context, tool string, args map[string]interface{}) error {
+	accepted, ok := h.acceptedArguments(ctx, tool)
+	if !ok {
+		return nil // Unknown tools are reported by CallTool
+	}
+	mode := h.config().ArgumentMode
+	unknown := unknownArguments(tool, args, accepted)
+	if len(unknown) == 0 {
+		return nil
+	}
+	if mode == config.ArgumentModeStrict {
+		return fmt.Errorf("%w: unknown argument(s) %s for %s; accepted arguments: %s",
+			errInvalidParameters, strings.Join(unknown, ", "), tool, strings.Join(accepted, ", "))
+	}
+	logging.FromContext(ctx).Debug("ignoring unknown arguments", "argument_mode", mode, "ignored", unknown)
+	return nil
+}
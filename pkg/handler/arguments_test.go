@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestUnknownArguments(t *testing.T) {
+	accepted := []string{"query", "search_domain_filter"}
+	args := map[string]interface{}{"query": "q", "domains": "go.dev", "colour": "blue", "recency": "week"}
+
+	got := unknownArguments("perplexity_search", args, accepted)
+	if want := []string{"colour", "recency"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unknownArguments() = %v, want %v", got, want)
+	}
+	got = unknownArguments("list_previous", args, accepted)
+	if want := []string{"colour", "domains", "recency"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected aliases unknown outside the search tools, got %v", got)
+	}
+}
+
+func TestCheckArguments(t *testing.T) {
+	ctx := context.Background()
+	args := map[string]interface{}{"query": "q", "colour": "blue"}
+
+	lenient, err := NewHandler(&config.Config{APIKey: "test-api-key", ArgumentMode: config.ArgumentModeLenient}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	if err := lenient.checkArguments(ctx, "perplexity_search", args); err != nil {
+		t.Errorf("Expected lenient mode to ignore unknown arguments, got %v", err)
+	}
+
+	strict, err := NewHandler(&config.Config{APIKey: "test-api-key", ArgumentMode: config.ArgumentModeStrict}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	err = strict.checkArguments(ctx, "perplexity_search", args)
+	if !errors.Is(err, errInvalidParameters) || !strings.Contains(err.Error(), "colour") || !strings.Contains(err.Error(), "search_recency_filter") {
+		t.Errorf("Expected an error naming the unknown and accepted arguments, got %v", err)
+	}
+	if err := strict.checkArguments(ctx, "perplexity_search", map[string]interface{}{"query": "q", "recency": "7d"}); err != nil {
+		t.Errorf("Expected aliases accepted in strict mode, got %v", err)
+	}
+
+	resp, err := strict.CallTool(ctx, &protocol.CallToolRequest{Name: "perplexity_search", Arguments: args})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !resp.IsError || !strings.Contains(resp.Content[0].Text, "accepted arguments") {
+		t.Errorf("Expected an error response listing accepted arguments, got %+v", resp)
+	}
+}
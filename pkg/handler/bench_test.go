@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// BenchmarkCallToolParallel dispatches cache tool calls from many goroutines through one
+// Handler, measuring argument handling and dispatch without network calls
+func BenchmarkCallToolParallel(b *testing.B) {
+	root := b.TempDir()
+	var ids []string
+	for i := 0; i < 100; i++ {
+		id, err := cache.SaveResult(root, fmt.Sprintf("query %d", i), "general", "sonar", "result text", nil)
+		if err != nil {
+			b.Fatalf("SaveResult failed: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key", ResultsRootFolder: root}, false)
+	if err != nil {
+		b.Fatalf("NewHandler failed: %v", err)
+	}
+
+	var calls atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			n := calls.Add(1)
+			req := &protocol.CallToolRequest{
+				Name:      "get_previous_result",
+				Arguments: map[string]interface{}{"unique_id": ids[n%int64(len(ids))]},
+			}
+			if n%10 == 0 {
+				req = &protocol.CallToolRequest{Name: "list_previous", Arguments: map[string]interface{}{}}
+			}
+			resp, err := h.CallTool(context.Background(), req)
+			if err != nil || resp.IsError {
+				b.Fatalf("CallTool %s failed: %v", req.Name, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxRecentCalls bounds how many results the duplicate guard remembers at once
+const maxRecentCalls = 256
+
+// freshTools read or change local state, so repeating them is never a duplicate
+var freshTools = map[string]bool{
+	"list_previous":       true,
+	"get_previous_result": true,
+	"save_profile":        true,
+	"perplexity_usage":    true,
+}
+
+// toolCall identifies a call for duplicate detection
+type toolCall struct {
+	// key is the tool and all of its arguments
+	key string
+	// shape is the tool and every argument except the query, set only for calls with a query
+	shape string
+	query string
+}
+
+// newToolCall builds the identity of a call. Object keys are marshalled in sorted order, so
+// argument order does not matter. It reports false for tools that are never deduplicated.
+func newToolCall(name string, args map[string]interface{}) (toolCall, bool) {
+	if freshTools[name] {
+		return toolCall{}, false
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return toolCall{}, false
+	}
+	call := toolCall{key: name + "\x00" + string(data)}
+
+	if query, ok := args["query"].(string); ok && query != "" {
+		rest := make(map[string]interface{}, len(args))
+		for k, v := range args {
+			if k != "query" {
+				rest[k] = v
+			}
+		}
+		if data, err := json.Marshal(rest); err == nil {
+			call.shape = name + "\x00" + string(data)
+			call.query = query
+		}
+	}
+	return call, true
+}
+
+// recentCall is a successful tool result and when it was produced
+type recentCall struct {
+	toolCall
+	vector queryVector
+	result string
+	at     time.Time
+}
+
+// similarCall is an earlier result reused for a query close to the one it answered
+type similarCall struct {
+	query      string
+	similarity float64
+	result     string
+	age        time.Duration
+}
+
+// sharedResults keeps recent results where every server instance behind a load balancer
+// sees them; *redis.Client implements it
+type sharedResults interface {
+	Key(parts ...string) string
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	PushCapped(ctx context.Context, key, value string, max int, ttl time.Duration) error
+	List(ctx context.Context, key string) ([]string, error)
+}
+
+// sharedCall is a result as kept in the shared store
+type sharedCall struct {
+	Query  string    `json:"query,omitempty"`
+	Result string    `json:"result"`
+	At     time.Time `json:"at"`
+}
+
+// recentCalls remembers the results of recent tool calls. An identical call repeated within
+// the window, as happens when an agent loops, gets the earlier result instead of another API
+// call; with a similarity threshold, so does a call whose query is nearly the same, within
+// the similar-query TTL. With a shared store, results are matched across every instance;
+// the local map then only serves while the store cannot be reached.
+type recentCalls struct {
+	mu         sync.Mutex
+	window     time.Duration
+	threshold  float64
+	similarTTL time.Duration
+	calls      map[string]*recentCall
+	now        func() time.Time
+	shared     sharedResults
+}
+
+// newRecentCalls returns a guard, or nil when both exact and similar matching are off
+func newRecentCalls(window time.Duration, threshold float64, similarTTL time.Duration) *recentCalls {
+	if threshold <= 0 || similarTTL <= 0 {
+		threshold, similarTTL = 0, 0
+	}
+	if window <= 0 && threshold == 0 {
+		return nil
+	}
+	return &recentCalls{
+		window:     window,
+		threshold:  threshold,
+		similarTTL: similarTTL,
+		calls:      make(map[string]*recentCall),
+		now:        time.Now,
+	}
+}
+
+// retention is how long a result can still be reused one way or the other
+func (r *recentCalls) retention() time.Duration {
+	if r.similarTTL > r.window {
+		return r.similarTTL
+	}
+	return r.window
+}
+
+// lookup returns the result of an identical call made within the window and its age
+func (r *recentCalls) lookup(call toolCall) (string, time.Duration, bool) {
+	if r == nil || r.window <= 0 {
+		return "", 0, false
+	}
+	if r.shared != nil {
+		result, age, ok, err := r.lookupShared(call)
+		if err == nil {
+			return result, age, ok
+		}
+		slog.Warn("shared duplicate lookup failed, using local results", "error", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recent, ok := r.calls[call.key]
+	if !ok {
+		return "", 0, false
+	}
+	age := r.now().Sub(recent.at)
+	if age > r.window {
+		return "", 0, false
+	}
+	return recent.result, age, true
+}
+
+// lookupSimilar returns the most similar earlier call with the same tool and other arguments
+// whose query reaches the similarity threshold within the TTL
+func (r *recentCalls) lookupSimilar(call toolCall) (similarCall, bool) {
+	if r == nil || r.threshold == 0 || call.shape == "" {
+		return similarCall{}, false
+	}
+	vector := newQueryVector(call.query)
+
+	if r.shared != nil {
+		best, found, err := r.lookupSimilarShared(call, vector)
+		if err == nil {
+			return best, found
+		}
+		slog.Warn("shared similar-query lookup failed, using local results", "error", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var best similarCall
+	found := false
+	for _, recent := range r.calls {
+		if recent.shape != call.shape || now.Sub(recent.at) > r.similarTTL {
+			continue
+		}
+		similarity := vector.cosine(recent.vector)
+		if similarity >= r.threshold && similarity > best.similarity {
+			best = similarCall{query: recent.query, similarity: similarity, result: recent.result, age: now.Sub(recent.at)}
+			found = true
+		}
+	}
+	return best, found
+}
+
+// store remembers a successful result, dropping expired entries and, when full, the oldest
+func (r *recentCalls) store(call toolCall, result string) {
+	if r == nil {
+		return
+	}
+	recent := &recentCall{toolCall: call, result: result}
+	if call.shape != "" && r.threshold > 0 {
+		recent.vector = newQueryVector(call.query)
+	}
+	if r.shared != nil {
+		if err := r.storeShared(call, result); err != nil {
+			slog.Warn("failed to share tool result", "error", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if len(r.calls) >= maxRecentCalls {
+		oldestKey, oldest := "", now
+		for k, c := range r.calls {
+			if now.Sub(c.at) > r.retention() {
+				delete(r.calls, k)
+				continue
+			}
+			if !c.at.After(oldest) {
+				oldestKey, oldest = k, c.at
+			}
+		}
+		if len(r.calls) >= maxRecentCalls {
+			delete(r.calls, oldestKey)
+		}
+	}
+	recent.at = now
+	r.calls[call.key] = recent
+}
+
+// sharedKey names a call's entry in the shared store
+func (r *recentCalls) sharedKey(kind, identity string) string {
+	sum := sha256.Sum256([]byte(identity))
+	return r.shared.Key("recent", kind, hex.EncodeToString(sum[:]))
+}
+
+// lookupShared returns the result of an identical call made by any instance within the window
+func (r *recentCalls) lookupShared(call toolCall) (string, time.Duration, bool, error) {
+	data, ok, err := r.shared.Get(context.Background(), r.sharedKey("call", call.key))
+	if err != nil || !ok {
+		return "", 0, false, err
+	}
+	var shared sharedCall
+	if err := json.Unmarshal([]byte(data), &shared); err != nil {
+		return "", 0, false, err
+	}
+	age := r.now().Sub(shared.At)
+	if age > r.window {
+		return "", 0, false, nil
+	}
+	if age < 0 {
+		age = 0 // Another instance's clock is ahead
+	}
+	return shared.Result, age, true, nil
+}
+
+// lookupSimilarShared returns the most similar call with the same shape made by any instance
+func (r *recentCalls) lookupSimilarShared(call toolCall, vector queryVector) (similarCall, bool, error) {
+	entries, err := r.shared.List(context.Background(), r.sharedKey("shape", call.shape))
+	if err != nil {
+		return similarCall{}, false, err
+	}
+
+	now := r.now()
+	var best similarCall
+	found := false
+	for _, entry := range entries {
+		var shared sharedCall
+		if json.Unmarshal([]byte(entry), &shared) != nil || now.Sub(shared.At) > r.similarTTL {
+			continue
+		}
+		similarity := vector.cosine(newQueryVector(shared.Query))
+		if similarity >= r.threshold && similarity > best.similarity {
+			best = similarCall{query: shared.Query, similarity: similarity, result: shared.Result, age: now.Sub(shared.At)}
+			found = true
+		}
+	}
+	return best, found, nil
+}
+
+// storeShared publishes a result for exact matching and, with a query, similar-query matching
+func (r *recentCalls) storeShared(call toolCall, result string) error {
+	data, err := json.Marshal(sharedCall{Query: call.query, Result: result, At: r.now()})
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if r.window > 0 {
+		if err := r.shared.Set(ctx, r.sharedKey("call", call.key), string(data), r.window); err != nil {
+			return err
+		}
+	}
+	if r.threshold > 0 && call.shape != "" {
+		return r.shared.PushCapped(ctx, r.sharedKey("shape", call.shape), string(data), maxRecentCalls, r.similarTTL)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestRecentCallsWindow(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := newRecentCalls(30*time.Second, 0, 0)
+	recent.now = func() time.Time { return now }
+	call, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "q"})
+
+	recent.store(call, "result")
+	now = now.Add(10 * time.Second)
+	result, age, ok := recent.lookup(call)
+	if !ok || result != "result" || age != 10*time.Second {
+		t.Errorf("Expected result from 10s ago, got %q, %v, %v", result, age, ok)
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, _, ok := recent.lookup(call); ok {
+		t.Error("Expected the result to expire after the window")
+	}
+
+	disabled := newRecentCalls(0, 0, 0)
+	disabled.store(call, "result")
+	if _, _, ok := disabled.lookup(call); ok {
+		t.Error("Expected no duplicate detection with a zero window")
+	}
+}
+
+func TestRecentCallsBounded(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := newRecentCalls(time.Hour, 0, 0)
+	recent.now = func() time.Time { return now }
+
+	var first toolCall
+	for i := 0; i <= maxRecentCalls; i++ {
+		call, _ := newToolCall("perplexity_search", map[string]interface{}{"query": fmt.Sprintf("query %d", i)})
+		if i == 0 {
+			first = call
+		}
+		recent.store(call, "result")
+		now = now.Add(time.Second)
+	}
+	if len(recent.calls) != maxRecentCalls {
+		t.Errorf("Expected %d remembered calls, got %d", maxRecentCalls, len(recent.calls))
+	}
+	if _, _, ok := recent.lookup(first); ok {
+		t.Error("Expected the oldest call to be evicted")
+	}
+}
+
+func TestNewToolCall(t *testing.T) {
+	a, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "q", "model": "sonar"})
+	b, _ := newToolCall("perplexity_search", map[string]interface{}{"model": "sonar", "query": "q"})
+	c, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "q", "model": "sonar-pro"})
+	d, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "other", "model": "sonar"})
+	if a != b {
+		t.Error("Expected argument order not to change the call")
+	}
+	if a.key == c.key || a.shape == c.shape {
+		t.Error("Expected different arguments to give different keys and shapes")
+	}
+	if a.key == d.key || a.shape != d.shape {
+		t.Error("Expected a different query to change the key but not the shape")
+	}
+	if _, ok := newToolCall("list_previous", nil); ok {
+		t.Error("Expected list_previous never to be treated as a duplicate")
+	}
+}
+
+func TestCallToolReturnsDuplicateResult(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key", DuplicateWindow: time.Minute}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	req := &protocol.CallToolRequest{Name: "perplexity_search", Arguments: map[string]interface{}{"query": "q"}}
+	call, _ := newToolCall(req.Name, req.Arguments)
+	h.recent.store(call, "Earlier answer")
+
+	resp, err := h.CallTool(context.Background(), req)
+	if err != nil || resp.IsError {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(resp.Content) != 2 || resp.Content[0].Text != "Earlier answer" ||
+		!strings.HasPrefix(resp.Content[1].Text, "Duplicate call detected") {
+		t.Errorf("Expected the earlier result and a duplicate note, got %+v", resp.Content)
+	}
+}
+
+func TestCallToolDoesNotRememberErrors(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key", DuplicateWindow: time.Minute}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	req := &protocol.CallToolRequest{Name: "perplexity_search", Arguments: map[string]interface{}{}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := h.CallTool(context.Background(), req)
+		if err != nil || !resp.IsError || len(resp.Content) != 1 {
+			t.Fatalf("Call %d: expected a fresh error response, got %+v, %v", i+1, resp, err)
+		}
+	}
+}
+
+// memoryShared is an in-memory shared store; with down set every command fails
+type memoryShared struct {
+	mu      sync.Mutex
+	strings map[string]string
+	lists   map[string][]string
+	down    bool
+}
+
+func newMemoryShared() *memoryShared {
+	return &memoryShared{strings: map[string]string{}, lists: map[string][]string{}}
+}
+
+func (m *memoryShared) Key(parts ...string) string { return "test:" + strings.Join(parts, ":") }
+
+func (m *memoryShared) Get(ctx context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return "", false, errors.New("connection refused")
+	}
+	value, ok := m.strings[key]
+	return value, ok, nil
+}
+
+func (m *memoryShared) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return errors.New("connection refused")
+	}
+	m.strings[key] = value
+	return nil
+}
+
+func (m *memoryShared) PushCapped(ctx context.Context, key, value string, max int, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return errors.New("connection refused")
+	}
+	m.lists[key] = append([]string{value}, m.lists[key]...)
+	if len(m.lists[key]) > max {
+		m.lists[key] = m.lists[key][:max]
+	}
+	return nil
+}
+
+func (m *memoryShared) List(ctx context.Context, key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down {
+		return nil, errors.New("connection refused")
+	}
+	return m.lists[key], nil
+}
+
+func TestRecentCallsShared(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	shared := newMemoryShared()
+	instances := make([]*recentCalls, 2)
+	for i := range instances {
+		instances[i] = newRecentCalls(30*time.Second, 0.9, time.Hour)
+		instances[i].now = func() time.Time { return now }
+		instances[i].shared = shared
+	}
+
+	call, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "What is the capital of France?"})
+	instances[0].store(call, "Paris")
+	now = now.Add(10 * time.Second)
+
+	if result, age, ok := instances[1].lookup(call); !ok || result != "Paris" || age != 10*time.Second {
+		t.Errorf("Expected the other instance's result from 10s ago, got %q, %v, %v", result, age, ok)
+	}
+	rephrased, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "what's the capital of france"})
+	if similar, ok := instances[1].lookupSimilar(rephrased); !ok || similar.result != "Paris" {
+		t.Errorf("Expected the other instance's answer for a rephrased query, got %+v, %v", similar, ok)
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, _, ok := instances[1].lookup(call); ok {
+		t.Error("Expected the shared result to expire after the window")
+	}
+
+	// While the store is down each instance falls back to its own results
+	shared.down = true
+	other, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "Population of Lyon"})
+	instances[0].store(other, "about 520,000")
+	if result, _, ok := instances[0].lookup(other); !ok || result != "about 520,000" {
+		t.Errorf("Expected the local result while the store is down, got %q, %v", result, ok)
+	}
+	if _, _, ok := instances[1].lookup(other); ok {
+		t.Error("Expected no result from another instance while the store is down")
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/search"
+)
+
+// Error type identifiers for failures that happen before the API is called
+const (
+	errorTypeInvalidParameters = "invalid_parameters"
+	errorTypeTool              = "tool_error"
+)
+
+// errInvalidParameters marks errors caused by bad tool arguments
+var errInvalidParameters = errors.New("invalid parameters")
+
+// toolError is the structured error block returned to MCP clients
+type toolError struct {
+	ErrorType  string `json:"error_type"`
+	Message    string `json:"message"`
+	Retryable  bool   `json:"retryable"`
+	Hint       string `json:"hint,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// newToolError classifies an error into a structured tool error
+func newToolError(err error) toolError {
+	var apiErr *search.APIError
+	if errors.As(err, &apiErr) {
+		return toolError{
+			ErrorType:  apiErr.ErrorType,
+			Message:    err.Error(),
+			Retryable:  apiErr.Retryable,
+			Hint:       apiErr.Hint,
+			StatusCode: apiErr.StatusCode,
+		}
+	}
+
+	if errors.Is(err, errInvalidParameters) {
+		return toolError{
+			ErrorType: errorTypeInvalidParameters,
+			Message:   err.Error(),
+			Hint:      "Check the tool's input schema for required and accepted arguments",
+		}
+	}
+
+	return toolError{
+		ErrorType: errorTypeTool,
+		Message:   err.Error(),
+	}
+}
+
+// errorResponse builds a CallToolResponse flagged with isError carrying the structured error
+// and the ID of the failed call
+func errorResponse(err error, requestID string) *protocol.CallToolResponse {
+	te := newToolError(err)
+	te.RequestID = requestID
+
+	text := err.Error()
+	if data, marshalErr := json.MarshalIndent(te, "", "  "); marshalErr == nil {
+		text = string(data)
+	}
+
+	return &protocol.CallToolResponse{
+		Content: []protocol.ToolContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		IsError: true,
+	}
+}
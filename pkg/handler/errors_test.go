@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/search"
+)
+
+func TestNewToolErrorFromAPIError(t *testing.T) {
+	apiErr := &search.APIError{
+		ErrorType:  search.ErrorTypeRateLimit,
+		StatusCode: 429,
+		Message:    "rate limit exceeded: slow down",
+		Hint:       "Try reducing request frequency",
+		Retryable:  true,
+	}
+
+	te := newToolError(fmt.Errorf("search failed: %w", apiErr))
+	if te.ErrorType != search.ErrorTypeRateLimit {
+		t.Errorf("ErrorType mismatch: got %s, want %s", te.ErrorType, search.ErrorTypeRateLimit)
+	}
+	if te.StatusCode != 429 {
+		t.Errorf("StatusCode mismatch: got %d, want 429", te.StatusCode)
+	}
+	if !te.Retryable {
+		t.Error("Expected rate limit error to be retryable")
+	}
+	if te.Hint != apiErr.Hint {
+		t.Errorf("Hint mismatch: got %s, want %s", te.Hint, apiErr.Hint)
+	}
+}
+
+func TestNewToolErrorInvalidParameters(t *testing.T) {
+	te := newToolError(fmt.Errorf("%w: query parameter is required", errInvalidParameters))
+	if te.ErrorType != errorTypeInvalidParameters {
+		t.Errorf("ErrorType mismatch: got %s, want %s", te.ErrorType, errorTypeInvalidParameters)
+	}
+	if te.Retryable {
+		t.Error("Invalid parameters should not be retryable")
+	}
+	if te.Message != "invalid parameters: query parameter is required" {
+		t.Errorf("Message mismatch: got %s", te.Message)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	resp := errorResponse(fmt.Errorf("results caching is not enabled"), "0123456789abcdef")
+	if !resp.IsError {
+		t.Fatal("Expected IsError to be set")
+	}
+	if len(resp.Content) != 1 {
+		t.Fatalf("Content count mismatch: got %d, want 1", len(resp.Content))
+	}
+
+	var decoded toolError
+	if err := json.Unmarshal([]byte(resp.Content[0].Text), &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal error content: %v", err)
+	}
+	if decoded.ErrorType != errorTypeTool {
+		t.Errorf("ErrorType mismatch: got %s, want %s", decoded.ErrorType, errorTypeTool)
+	}
+	if decoded.RequestID != "0123456789abcdef" {
+		t.Errorf("RequestID mismatch: got %q", decoded.RequestID)
+	}
+}
+
+func TestCallToolLogsFailureWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(logging.New(&buf, config.LogLevelInfo, config.LogFormatJSON))
+
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	req := &protocol.CallToolRequest{Name: "perplexity_search", Arguments: map[string]interface{}{}}
+	if _, err := h.CallTool(context.Background(), req); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON log record, got %q: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "tool call failed" || record["tool"] != "perplexity_search" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+	if id, _ := record["request_id"].(string); len(id) != 16 {
+		t.Errorf("Expected a request ID, got %v", record["request_id"])
+	}
+}
+
+func TestCallToolUnknownTool(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	before := metrics.ToolErrors.Value("no_such_tool")
+	resp, err := h.CallTool(context.Background(), &protocol.CallToolRequest{Name: "no_such_tool", Arguments: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("Expected an error response rather than an error, got %v", err)
+	}
+	var decoded toolError
+	if !resp.IsError || json.Unmarshal([]byte(resp.Content[0].Text), &decoded) != nil {
+		t.Fatalf("Expected a structured error response, got %+v", resp)
+	}
+	if decoded.ErrorType != errorTypeInvalidParameters || decoded.Message != "invalid parameters: unknown tool: no_such_tool" || decoded.RequestID == "" {
+		t.Errorf("Unexpected error: %+v", decoded)
+	}
+	if got := metrics.ToolErrors.Value("no_such_tool"); got != before+1 {
+		t.Errorf("Expected the failed call recorded, got %v errors", got)
+	}
+}
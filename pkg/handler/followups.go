@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	relatedQuestionsHeader = "\n\n## Related Questions\n"
+	followUpHeader         = "\n\n## Follow-up Calls\n"
+)
+
+// followUpTools answer a related question as well as they answered the original query, so
+// follow-ups stay with them; every other tool's follow-ups go to perplexity_search
+var followUpTools = map[string]bool{
+	"perplexity_search":              true,
+	"perplexity_academic_search":     true,
+	"perplexity_financial_search":    true,
+	"perplexity_patent_search":       true,
+	"perplexity_legal_search":        true,
+	"perplexity_medical_search":      true,
+	"perplexity_product_search":      true,
+	"perplexity_people_search":       true,
+	"perplexity_company_search":      true,
+	"perplexity_travel_search":       true,
+	"perplexity_dev_search":          true,
+	"perplexity_security_search":     true,
+	"perplexity_filtered_search":     true,
+	"perplexity_search_with_context": true,
+}
+
+// followUpArguments are carried from the original call to its follow-ups, so they search the
+// same sources, period, and language and are cached in the same project
+var followUpArguments = []string{
+	"model",
+	"search_mode",
+	"search_domain_filter",
+	"search_exclude_domains",
+	"search_recency_filter",
+	"answer_language",
+	"location",
+	"project",
+}
+
+// followUpCall is a ready-to-run tool call answering one related question
+type followUpCall struct {
+	Question  string                 `json:"question"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// withFollowUps adds a Follow-up Calls section after a result's related questions, turning
+// each into a tool call an agent can make as is. Results without related questions are
+// returned unchanged.
+func withFollowUps(tool string, args map[string]interface{}, result string) string {
+	start := strings.Index(result, relatedQuestionsHeader)
+	if start < 0 || !strings.HasPrefix(tool, "perplexity_") {
+		return result
+	}
+	end := start + len(relatedQuestionsHeader)
+	if next := strings.Index(result[end:], "\n\n"); next >= 0 {
+		end += next
+	} else {
+		end = len(result)
+	}
+
+	var questions []string
+	for _, line := range strings.Split(result[start+len(relatedQuestionsHeader):end], "\n") {
+		if question, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && question != "" {
+			questions = append(questions, question)
+		}
+	}
+	if len(questions) == 0 {
+		return result
+	}
+
+	data, err := json.MarshalIndent(followUpCalls(tool, args, questions), "", "  ")
+	if err != nil {
+		return result
+	}
+	section := followUpHeader + "```json\n" + string(data) + "\n```\n"
+	return strings.TrimRight(result[:end], "\n") + section + result[end:]
+}
+
+// followUpCalls builds a call per question with the arguments carried from the original call
+func followUpCalls(tool string, args map[string]interface{}, questions []string) []followUpCall {
+	if !followUpTools[tool] {
+		tool = "perplexity_search"
+	}
+	args = NormalizeArguments(args)
+
+	calls := make([]followUpCall, 0, len(questions))
+	for _, question := range questions {
+		arguments := map[string]interface{}{"query": question}
+		for _, name := range followUpArguments {
+			if value, ok := args[name]; ok {
+				arguments[name] = value
+			}
+		}
+		calls = append(calls, followUpCall{Question: question, Tool: tool, Arguments: arguments})
+	}
+	return calls
+}
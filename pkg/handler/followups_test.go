@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithFollowUps(t *testing.T) {
+	result := "Answer.\n\n## Related Questions\n- What is Go?\n- Who made Go?\n\n\n## Search Metadata\n- Request ID: abc\n"
+	args := map[string]interface{}{"query": "go", "domains": []interface{}{"go.dev"}, "max_tokens": 512.0, "project": "lang"}
+
+	got := withFollowUps("perplexity_dev_search", args, result)
+	section := strings.Index(got, followUpHeader)
+	if section < 0 || section > strings.Index(got, "## Search Metadata") {
+		t.Fatalf("Expected a Follow-up Calls section before the metadata footer, got:\n%s", got)
+	}
+
+	block := got[section+len(followUpHeader):]
+	block = strings.TrimPrefix(block[:strings.Index(block, "\n```\n")], "```json\n")
+	var calls []followUpCall
+	if err := json.Unmarshal([]byte(block), &calls); err != nil {
+		t.Fatalf("Follow-up block is not JSON: %v\n%s", err, block)
+	}
+	want := []followUpCall{
+		{Question: "What is Go?", Tool: "perplexity_dev_search", Arguments: map[string]interface{}{
+			"query": "What is Go?", "search_domain_filter": []interface{}{"go.dev"}, "project": "lang"}},
+		{Question: "Who made Go?", Tool: "perplexity_dev_search", Arguments: map[string]interface{}{
+			"query": "Who made Go?", "search_domain_filter": []interface{}{"go.dev"}, "project": "lang"}},
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %+v, want %+v", calls, want)
+	}
+
+	if got := withFollowUps("perplexity_trend", args, result); !strings.Contains(got, `"tool": "perplexity_search"`) {
+		t.Errorf("Expected trend follow-ups to use perplexity_search, got:\n%s", got)
+	}
+	if got := withFollowUps("list_previous", args, result); got != result {
+		t.Error("Expected results of non-search tools unchanged")
+	}
+	if got := withFollowUps("perplexity_search", args, "Answer only"); got != "Answer only" {
+		t.Error("Expected results without related questions unchanged")
+	}
+}
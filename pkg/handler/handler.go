@@ -3,33 +3,128 @@ package handler
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
 	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+	"github.com/prasanthmj/perplexity/pkg/redis"
 	"github.com/prasanthmj/perplexity/pkg/search"
+	"github.com/prasanthmj/perplexity/pkg/stats"
 )
 
-// Handler handles MCP protocol operations
+// Handler handles MCP protocol operations. CallTool may be invoked concurrently; every
+// call shares the one Searcher, which is safe for concurrent use.
 type Handler struct {
 	searcher *search.Searcher
-	config   *config.Config
+	source   config.Source
+	recent   *recentCalls
+	clients  *quota.Tracker
 }
 
-// NewHandler creates a new handler instance
-func NewHandler(cfg *config.Config, debugMode bool) (*Handler, error) {
-	searcher, err := search.NewSearcher(cfg)
+// NewHandler creates a new handler instance. Duplicate detection is set up from the
+// configuration in effect now; other settings are read from src on each call.
+func NewHandler(src config.Source, debugMode bool) (*Handler, error) {
+	cfg := src.Snapshot()
+	searcher, err := search.NewSearcher(src)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create searcher: %w", err)
 	}
 
+	recent := newRecentCalls(cfg.DuplicateWindow, cfg.SimilarityThreshold, cfg.SimilarityTTL)
+	if recent != nil && cfg.RedisURL != "" {
+		shared, err := redis.New(cfg.RedisURL, cfg.RedisPrefix)
+		if err != nil {
+			return nil, err
+		}
+		recent.shared = shared
+	}
+
 	return &Handler{
 		searcher: searcher,
-		config:   cfg,
+		source:   src,
+		recent:   recent,
 	}, nil
 }
 
-// CallTool handles MCP tool calls
+// SetPromptScaffold frames every search prompt with template (see Searcher.SetPromptScaffold).
+// Call it before the Handler is used.
+func (h *Handler) SetPromptScaffold(template string) {
+	h.searcher.SetPromptScaffold(template)
+}
+
+// SetClients makes perplexity_usage report the usage of the REST API clients tracked by
+// clients. Call it before the Handler is used.
+func (h *Handler) SetClients(clients *quota.Tracker) {
+	h.clients = clients
+}
+
+// Searcher returns the Searcher the tools run on, for servers that share its caching and
+// rate limiting
+func (h *Handler) Searcher() *search.Searcher {
+	return h.searcher
+}
+
+// config returns the configuration in effect
+func (h *Handler) config() *config.Config {
+	return h.source.Snapshot()
+}
+
+// CallTool handles MCP tool calls. Each call gets a request ID that tags its log records and
+// appears in its response footer, cached metadata, and error responses.
 func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
+	start := time.Now()
+	requestID := logging.NewRequestID()
+	ctx = logging.WithRequestID(ctx, requestID)
+	log := logging.FromContext(ctx).With("tool", req.Name)
+	log.Debug("tool call started")
+
+	// Templates and profiles run as a call of the tool they fill in
+	if req.Name == "run_template" || req.Name == "run_profile" {
+		var expanded *protocol.CallToolRequest
+		var err error
+		if req.Name == "run_template" {
+			now := time.Now()
+			if loc := h.config().Location(); loc != nil {
+				now = now.In(loc)
+			}
+			expanded, err = h.expandTemplate(ctx, req.Arguments, now)
+		} else {
+			expanded, err = h.expandProfile(ctx, req.Arguments)
+		}
+		if err != nil {
+			recordCall(req.Name, err)
+			log.Warn("tool call failed", "error", err)
+			return errorResponse(err, requestID), nil
+		}
+		log = log.With("name", req.Arguments["name"], "expanded_tool", expanded.Name)
+		req = expanded
+	}
+
+	if err := h.checkArguments(ctx, req.Name, req.Arguments); err != nil {
+		recordCall(req.Name, err)
+		log.Warn("tool call failed", "error", err)
+		return errorResponse(err, requestID), nil
+	}
+
+	// An identical call repeated within the duplicate window, or one with a nearly identical
+	// query when similarity matching is on, gets the earlier result back
+	call, dedupe := newToolCall(req.Name, req.Arguments)
+	if dedupe {
+		if result, age, ok := h.recent.lookup(call); ok {
+			recordCall(req.Name, nil)
+			log.Info("tool call reused duplicate result", "age", age)
+			return duplicateResponse(result, age), nil
+		}
+		if similar, ok := h.recent.lookupSimilar(call); ok {
+			recordCall(req.Name, nil)
+			log.Info("tool call reused similar query", "matched_query", similar.query, "similarity", similar.similarity)
+			return similarResponse(similar), nil
+		}
+	}
+
 	var result string
 	var err error
 
@@ -40,18 +135,67 @@ func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (
 		result, err = h.handleAcademicSearch(ctx, req.Arguments)
 	case "perplexity_financial_search":
 		result, err = h.handleFinancialSearch(ctx, req.Arguments)
+	case "perplexity_patent_search":
+		result, err = h.handlePatentSearch(ctx, req.Arguments)
+	case "perplexity_legal_search":
+		result, err = h.handleLegalSearch(ctx, req.Arguments)
+	case "perplexity_medical_search":
+		result, err = h.handleMedicalSearch(ctx, req.Arguments)
+	case "perplexity_product_search":
+		result, err = h.handleProductSearch(ctx, req.Arguments)
+	case "perplexity_people_search":
+		result, err = h.handlePeopleSearch(ctx, req.Arguments)
+	case "perplexity_company_search":
+		result, err = h.handleCompanySearch(ctx, req.Arguments)
+	case "perplexity_travel_search":
+		result, err = h.handleTravelSearch(ctx, req.Arguments)
+	case "perplexity_dev_search":
+		result, err = h.handleDevSearch(ctx, req.Arguments)
+	case "perplexity_competitors":
+		result, err = h.handleCompetitors(ctx, req.Arguments)
+	case "perplexity_trend":
+		result, err = h.handleTrend(ctx, req.Arguments)
+	case "perplexity_security_search":
+		result, err = h.handleSecuritySearch(ctx, req.Arguments)
+	case "perplexity_release_watch":
+		result, err = h.handleReleaseWatch(ctx, req.Arguments)
+	case "perplexity_local_now":
+		result, err = h.handleLocalNow(ctx, req.Arguments)
 	case "perplexity_filtered_search":
 		result, err = h.handleFilteredSearch(ctx, req.Arguments)
+	case "perplexity_search_with_context":
+		result, err = h.handleSearchWithContext(ctx, req.Arguments)
+	case "perplexity_compare_models":
+		result, err = h.handleCompareModels(ctx, req.Arguments)
+	case "verify_result":
+		result, err = h.handleVerifyResult(ctx, req.Arguments)
+	case "translate_result":
+		result, err = h.handleTranslateResult(ctx, req.Arguments)
+	case "check_links":
+		result, err = h.handleCheckLinks(ctx, req.Arguments)
+	case "publish_result":
+		result, err = h.handlePublishResult(ctx, req.Arguments)
+	case "save_profile":
+		result, err = h.handleSaveProfile(ctx, req.Arguments)
 	case "list_previous":
 		result, err = h.handleListPrevious(ctx, req.Arguments)
 	case "get_previous_result":
 		result, err = h.handleGetPreviousResult(ctx, req.Arguments)
+	case "perplexity_usage":
+		result, err = h.handleUsage(ctx)
 	default:
-		return nil, fmt.Errorf("unknown tool: %s", req.Name)
+		err = fmt.Errorf("%w: unknown tool: %s", errInvalidParameters, req.Name)
 	}
 
+	recordCall(req.Name, err)
 	if err != nil {
-		return nil, err
+		log.Warn("tool call failed", "elapsed", time.Since(start), "error", err)
+		return errorResponse(err, requestID), nil
+	}
+	log.Info("tool call completed", "elapsed", time.Since(start))
+	result = withFollowUps(req.Name, req.Arguments, result)
+	if dedupe {
+		h.recent.store(call, result)
 	}
 
 	return &protocol.CallToolResponse{
@@ -62,4 +206,46 @@ func (h *Handler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (
 			},
 		},
 	}, nil
-}
\ No newline at end of file
+}
+
+// recordCall counts a finished tool call, and its error type when it failed, in the metrics
+// and the local usage stats
+func recordCall(tool string, err error) {
+	metrics.ToolRequests.Inc(tool)
+	errorType := ""
+	if err != nil {
+		metrics.ToolErrors.Inc(tool)
+		errorType = newToolError(err).ErrorType
+	}
+	stats.Default.Call(tool, errorType)
+}
+
+// duplicateResponse returns an earlier result with a note saying it was reused
+func duplicateResponse(result string, age time.Duration) *protocol.CallToolResponse {
+	note := fmt.Sprintf("Duplicate call detected: the same tool call with the same arguments was made %s ago, "+
+		"so its result is returned again without a new API call", age.Round(time.Second))
+	return reusedResponse(result, note)
+}
+
+// similarResponse returns the result of an earlier, nearly identical query, naming that query
+func similarResponse(similar similarCall) *protocol.CallToolResponse {
+	note := fmt.Sprintf("Similar query reused: this is the answer to %q from %s ago (similarity %.2f), "+
+		"returned without a new API call", similar.query, similar.age.Round(time.Second), similar.similarity)
+	return reusedResponse(similar.result, note)
+}
+
+// reusedResponse pairs an earlier result with a note explaining why it was reused
+func reusedResponse(result, note string) *protocol.CallToolResponse {
+	return &protocol.CallToolResponse{
+		Content: []protocol.ToolContent{
+			{
+				Type: "text",
+				Text: result,
+			},
+			{
+				Type: "text",
+				Text: note,
+			},
+		},
+	}
+}
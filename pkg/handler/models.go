@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/models"
+)
+
+// addManifestModels extends the model enums of the tool schemas with the models a manifest
+// added to the bundled ones, so clients that enforce enums can use them. Schemas are left
+// untouched when no models were added.
+func addManifestModels(tools []protocol.Tool) {
+	added := models.Default.Added()
+	if len(added) == 0 {
+		return
+	}
+	for i, tool := range tools {
+		var schema map[string]interface{}
+		if json.Unmarshal(tool.InputSchema, &schema) != nil {
+			continue
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		changed := false
+		if model, ok := properties["model"].(map[string]interface{}); ok {
+			changed = extendEnum(model, added) || changed
+		}
+		if list, ok := properties["models"].(map[string]interface{}); ok {
+			if items, ok := list["items"].(map[string]interface{}); ok {
+				changed = extendEnum(items, added) || changed
+			}
+		}
+		if !changed {
+			continue
+		}
+		if data, err := json.Marshal(schema); err == nil {
+			tools[i].InputSchema = data
+		}
+	}
+}
+
+// extendEnum appends the ids missing from a property's enum, reporting whether it had one
+func extendEnum(property map[string]interface{}, ids []string) bool {
+	enum, ok := property["enum"].([]interface{})
+	if !ok {
+		return false
+	}
+	present := make(map[string]bool, len(enum))
+	for _, value := range enum {
+		if s, ok := value.(string); ok {
+			present[s] = true
+		}
+	}
+	for _, id := range ids {
+		if !present[id] {
+			enum = append(enum, id)
+		}
+	}
+	property["enum"] = enum
+	return true
+}
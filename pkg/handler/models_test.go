@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/models"
+)
+
+// modelEnumPattern matches a model enum in a schema, whether or not it is indented
+var modelEnumPattern = regexp.MustCompile(`"enum":\s*\["sonar"`)
+
+func TestManifestModelsInSchemas(t *testing.T) {
+	h := &Handler{}
+	before, err := h.ListTools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := models.Default.Load([]byte(`{"models": [{"id": "sonar"}, {"id": "sonar-pro"}, {"id": "sonar-ultra"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(models.Default.Reset)
+	after, err := h.ListTools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tool := range after.Tools {
+		schema, original := string(tool.InputSchema), string(before.Tools[i].InputSchema)
+		hasModel := strings.Contains(original, `"model"`) || strings.Contains(original, `"models"`)
+		modelEnum := modelEnumPattern.MatchString(original)
+		if hasModel && modelEnum && !strings.Contains(schema, `"sonar-ultra"`) {
+			t.Errorf("%s: expected sonar-ultra in the model enum", tool.Name)
+		}
+		if !modelEnum && schema != original {
+			t.Errorf("%s: expected the schema without a model enum unchanged", tool.Name)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+// profileNamePattern restricts profile names to letters, digits, - and _
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// profilesMu serializes saving profiles, which rewrites the whole profiles file
+var profilesMu sync.Mutex
+
+// searchProfile is a named bundle of tool arguments applied to new queries
+type searchProfile struct {
+	Description string                 `json:"description,omitempty"`
+	Tool        string                 `json:"tool"`
+	Arguments   map[string]interface{} `json:"arguments"`
+	Saved       time.Time              `json:"saved"`
+}
+
+// profiles returns the profiles saved in the results root
+func (h *Handler) profiles() (map[string]searchProfile, error) {
+	root := h.config().ResultsRootFolder
+	if !cache.IsCachingEnabled(root) {
+		return nil, fmt.Errorf("%w: profiles are saved in the results folder; set PERPLEXITY_RESULTS_ROOT_FOLDER to use them", errInvalidParameters)
+	}
+	data, err := cache.ReadProfiles(root)
+	if err != nil {
+		return nil, err
+	}
+	profiles := map[string]searchProfile{}
+	if data != nil {
+		if err := json.Unmarshal(data, &profiles); err != nil {
+			return nil, fmt.Errorf("invalid profiles.json in the results folder: %w", err)
+		}
+	}
+	return profiles, nil
+}
+
+// handleSaveProfile saves a named bundle of tool arguments, replacing any profile of that name
+func (h *Handler) handleSaveProfile(ctx context.Context, args map[string]interface{}) (string, error) {
+	name, _ := args["name"].(string)
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("%w: name is required and may only contain letters, digits, - and _", errInvalidParameters)
+	}
+	tool, _ := args["tool"].(string)
+	if tool == "" {
+		tool = "perplexity_search"
+	}
+	if !h.isRunnableTool(ctx, tool) {
+		return "", fmt.Errorf("%w: unknown tool '%s'", errInvalidParameters, tool)
+	}
+	arguments, _ := args["arguments"].(map[string]interface{})
+	if len(arguments) == 0 {
+		return "", fmt.Errorf("%w: arguments must be an object of the tool arguments to save", errInvalidParameters)
+	}
+	if _, ok := arguments["query"]; ok {
+		return "", fmt.Errorf("%w: a profile holds parameters, not a query; give the query to run_profile", errInvalidParameters)
+	}
+	description, _ := args["description"].(string)
+
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles, err := h.profiles()
+	if err != nil {
+		return "", err
+	}
+	_, replaced := profiles[name]
+	profiles[name] = searchProfile{Description: description, Tool: tool, Arguments: arguments, Saved: time.Now().UTC()}
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode profiles: %w", err)
+	}
+	if err := cache.WriteProfiles(h.config().ResultsRootFolder, data); err != nil {
+		return "", err
+	}
+
+	result, err := json.MarshalIndent(map[string]interface{}{
+		"saved":     name,
+		"replaced":  replaced,
+		"tool":      tool,
+		"arguments": arguments,
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to format result: %w", err)
+	}
+	return string(result), nil
+}
+
+// expandProfile turns a run_profile call into a call of the profile's tool with its saved
+// arguments, the query, and any arguments given for this call
+func (h *Handler) expandProfile(ctx context.Context, args map[string]interface{}) (*protocol.CallToolRequest, error) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", errInvalidParameters)
+	}
+	profiles, err := h.profiles()
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown profile '%s'. %s", errInvalidParameters, name, describeProfiles(profiles))
+	}
+	if !h.isRunnableTool(ctx, profile.Tool) {
+		return nil, fmt.Errorf("profile '%s' runs unknown tool '%s'", name, profile.Tool)
+	}
+
+	arguments := make(map[string]interface{}, len(profile.Arguments)+1)
+	for key, value := range profile.Arguments {
+		arguments[key] = value
+	}
+	if overrides, ok := args["arguments"].(map[string]interface{}); ok {
+		for key, value := range overrides {
+			arguments[key] = value
+		}
+	}
+	if query, _ := args["query"].(string); strings.TrimSpace(query) != "" {
+		arguments["query"] = query
+	}
+	return &protocol.CallToolRequest{Name: profile.Tool, Arguments: arguments}, nil
+}
+
+// describeProfiles lists the saved profiles with their tools and descriptions
+func describeProfiles(profiles map[string]searchProfile) string {
+	if len(profiles) == 0 {
+		return "No profiles are saved; create one with save_profile"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Saved profiles:")
+	for _, name := range names {
+		profile := profiles[name]
+		fmt.Fprintf(&b, "\n- %s (%s)", name, profile.Tool)
+		if profile.Description != "" {
+			fmt.Fprintf(&b, ": %s", profile.Description)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestProfiles(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key", ResultsRootFolder: t.TempDir()}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	ctx := context.Background()
+
+	saved, err := h.handleSaveProfile(ctx, map[string]interface{}{
+		"name":        "biotech",
+		"description": "Recent peer-reviewed biotech",
+		"tool":        "perplexity_academic_search",
+		"arguments":   map[string]interface{}{"model": "sonar-pro", "search_recency_filter": "month", "peer_reviewed_only": true},
+	})
+	if err != nil {
+		t.Fatalf("handleSaveProfile failed: %v", err)
+	}
+	var result struct {
+		Saved    string `json:"saved"`
+		Replaced bool   `json:"replaced"`
+	}
+	if err := json.Unmarshal([]byte(saved), &result); err != nil || result.Saved != "biotech" || result.Replaced {
+		t.Errorf("Unexpected save result %s", saved)
+	}
+
+	req, err := h.expandProfile(ctx, map[string]interface{}{
+		"name":      "biotech",
+		"query":     "CRISPR delivery methods",
+		"arguments": map[string]interface{}{"search_recency_filter": "year"},
+	})
+	if err != nil {
+		t.Fatalf("expandProfile failed: %v", err)
+	}
+	if req.Name != "perplexity_academic_search" || req.Arguments["query"] != "CRISPR delivery methods" ||
+		req.Arguments["model"] != "sonar-pro" || req.Arguments["peer_reviewed_only"] != true || req.Arguments["search_recency_filter"] != "year" {
+		t.Errorf("Expected the profile applied to the query, got %s %v", req.Name, req.Arguments)
+	}
+
+	for name, args := range map[string]map[string]interface{}{
+		"bad name":      {"name": "a b", "arguments": map[string]interface{}{"model": "sonar"}},
+		"unknown tool":  {"name": "x", "tool": "nope", "arguments": map[string]interface{}{"model": "sonar"}},
+		"nested runner": {"name": "x", "tool": "run_profile", "arguments": map[string]interface{}{"model": "sonar"}},
+		"with query":    {"name": "x", "arguments": map[string]interface{}{"query": "q"}},
+		"no arguments":  {"name": "x"},
+	} {
+		if _, err := h.handleSaveProfile(ctx, args); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: "run_profile", Arguments: map[string]interface{}{"name": "missing", "query": "q"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError || !strings.Contains(resp.Content[0].Text, "biotech (perplexity_academic_search): Recent peer-reviewed biotech") {
+		t.Errorf("Expected the saved profiles listed, got %+v", resp)
+	}
+
+	uncached, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uncached.handleSaveProfile(ctx, map[string]interface{}{"name": "x", "arguments": map[string]interface{}{"model": "sonar"}}); err == nil {
+		t.Error("Expected an error without a results folder")
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/search"
+)
+
+// resultMimeType is the MIME type of cached result resources
+const resultMimeType = "text/markdown"
+
+// ListResources exposes cached results as MCP resources
+func (h *Handler) ListResources(ctx context.Context) (*protocol.ListResourcesResponse, error) {
+	resources := []protocol.Resource{}
+	if !cache.IsCachingEnabled(h.config().ResultsRootFolder) {
+		return &protocol.ListResourcesResponse{Resources: resources}, nil
+	}
+
+	items, err := cache.ListPreviousQueries(h.config().ResultsRootFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached results: %w", err)
+	}
+
+	for _, item := range items {
+		resources = append(resources, protocol.Resource{
+			URI:         search.ResultURI(item.UniqueID),
+			Name:        item.Query,
+			Description: fmt.Sprintf("%s search from %s", item.SearchType, item.DateTime.Format("2006-01-02 15:04:05")),
+			MimeType:    resultMimeType,
+		})
+	}
+
+	return &protocol.ListResourcesResponse{Resources: resources}, nil
+}
+
+// ReadResource returns the content of a cached result resource
+func (h *Handler) ReadResource(ctx context.Context, req *protocol.ReadResourceRequest) (*protocol.ReadResourceResponse, error) {
+	if !strings.HasPrefix(req.URI, search.ResultURIPrefix) {
+		return nil, fmt.Errorf("unknown resource: %s", req.URI)
+	}
+
+	doc, err := h.searcher.LoadContextRef(req.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.ReadResourceResponse{
+		Contents: []protocol.ResourceContent{
+			{
+				URI:      req.URI,
+				MimeType: resultMimeType,
+				Text:     doc.Content,
+			},
+		},
+	}, nil
+}
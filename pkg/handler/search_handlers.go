@@ -3,15 +3,35 @@ package handler
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
 	"github.com/prasanthmj/perplexity/pkg/search"
+	"github.com/prasanthmj/perplexity/pkg/types"
 )
 
 // handlePerplexitySearch handles general web search
 func (h *Handler) handlePerplexitySearch(ctx context.Context, args map[string]interface{}) (string, error) {
 	params, err := h.extractSearchParams(args, "general")
 	if err != nil {
-		return "", fmt.Errorf("invalid parameters: %w", err)
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	if err := extractSearchMode(args, params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Regions run the query once per user location and compare the answers
+	if list, ok := args["regions"].([]interface{}); ok && len(list) > 0 {
+		if params.Location != "" {
+			return "", fmt.Errorf("%w: regions replaces location; give one or the other", errInvalidParameters)
+		}
+		regions := convertToStringSlice(list)
+		if err := search.ValidateRegions(regions); err != nil {
+			return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+		}
+		return h.searcher.RegionSearch(ctx, params, regions)
 	}
 
 	return h.searcher.Search(ctx, params)
@@ -21,13 +41,19 @@ func (h *Handler) handlePerplexitySearch(ctx context.Context, args map[string]in
 func (h *Handler) handleAcademicSearch(ctx context.Context, args map[string]interface{}) (string, error) {
 	params, err := h.extractSearchParams(args, "academic")
 	if err != nil {
-		return "", fmt.Errorf("invalid parameters: %w", err)
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
 	}
 
 	// Add academic-specific parameter
 	if subjectArea, ok := args["subject_area"].(string); ok && subjectArea != "" {
 		params.SubjectArea = subjectArea
 	}
+	if peerReviewed, ok := args["peer_reviewed_only"].(bool); ok {
+		params.PeerReviewedOnly = peerReviewed
+	}
+	if preprints, ok := args["include_preprints"].(bool); ok {
+		params.IncludePreprints = preprints
+	}
 
 	return h.searcher.AcademicSearch(ctx, params)
 }
@@ -36,7 +62,7 @@ func (h *Handler) handleAcademicSearch(ctx context.Context, args map[string]inte
 func (h *Handler) handleFinancialSearch(ctx context.Context, args map[string]interface{}) (string, error) {
 	params, err := h.extractSearchParams(args, "financial")
 	if err != nil {
-		return "", fmt.Errorf("invalid parameters: %w", err)
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
 	}
 
 	// Add financial-specific parameters
@@ -49,15 +75,298 @@ func (h *Handler) handleFinancialSearch(ctx context.Context, args map[string]int
 	if reportType, ok := args["report_type"].(string); ok && reportType != "" {
 		params.ReportType = reportType
 	}
+	if assetClass, ok := args["asset_class"].(string); ok && assetClass != "" {
+		assetClass = strings.ToLower(strings.TrimSpace(assetClass))
+		if !search.IsValidAssetClass(assetClass) {
+			return "", fmt.Errorf("%w: invalid asset_class '%s'. Use equity, crypto, fx, or commodity", errInvalidParameters, assetClass)
+		}
+		params.AssetClass = assetClass
+	}
+	if event, ok := args["event"].(string); ok && event != "" {
+		event = strings.ToLower(strings.TrimSpace(event))
+		if !search.IsValidEvent(event) {
+			return "", fmt.Errorf("%w: invalid event '%s'. Use earnings, dividend, or split", errInvalidParameters, event)
+		}
+		if params.Ticker == "" && params.CompanyName == "" {
+			return "", fmt.Errorf("%w: event requires ticker or company_name", errInvalidParameters)
+		}
+		params.Event = event
+	}
 
 	return h.searcher.FinancialSearch(ctx, params)
 }
 
+// handlePatentSearch handles patent search
+func (h *Handler) handlePatentSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "patent")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add patent-specific parameters
+	if inventor, ok := args["inventor"].(string); ok && inventor != "" {
+		params.Inventor = inventor
+	}
+	if assignee, ok := args["assignee"].(string); ok && assignee != "" {
+		params.Assignee = assignee
+	}
+	if cpcClass, ok := args["cpc_class"].(string); ok && cpcClass != "" {
+		params.CPCClass = cpcClass
+	}
+	if jurisdiction, ok := args["jurisdiction"].(string); ok && jurisdiction != "" {
+		params.Jurisdiction = jurisdiction
+	}
+	if filingStart, ok := args["filing_date_start"].(string); ok && filingStart != "" {
+		params.FilingDateStart = filingStart
+	}
+	if filingEnd, ok := args["filing_date_end"].(string); ok && filingEnd != "" {
+		params.FilingDateEnd = filingEnd
+	}
+	if err := search.NormalizePatentParams(params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	return h.searcher.PatentSearch(ctx, params)
+}
+
+// handleLegalSearch handles legal and case-law search
+func (h *Handler) handleLegalSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "legal")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add legal-specific parameters
+	if jurisdiction, ok := args["jurisdiction"].(string); ok && jurisdiction != "" {
+		params.Jurisdiction = jurisdiction
+	}
+	if court, ok := args["court"].(string); ok && court != "" {
+		params.Court = court
+	}
+
+	return h.searcher.LegalSearch(ctx, params)
+}
+
+// handleMedicalSearch handles medical and clinical evidence search
+func (h *Handler) handleMedicalSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "medical")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add medical-specific parameters
+	if studyType, ok := args["study_type"].(string); ok && studyType != "" {
+		studyType = strings.ToLower(strings.TrimSpace(studyType))
+		if !search.IsValidStudyType(studyType) {
+			return "", fmt.Errorf("%w: invalid study_type '%s'. Use one of: %s", errInvalidParameters, studyType, strings.Join(search.StudyTypeNames(), ", "))
+		}
+		params.StudyType = studyType
+	}
+	if population, ok := args["population"].(string); ok && population != "" {
+		params.Population = population
+	}
+
+	return h.searcher.MedicalSearch(ctx, params)
+}
+
+// handleProductSearch handles shopping and product comparison search
+func (h *Handler) handleProductSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "product")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add product-specific parameters
+	if category, ok := args["category"].(string); ok && category != "" {
+		params.Category = category
+	}
+	if budget, ok := args["budget"].(string); ok && budget != "" {
+		params.Budget = budget
+	}
+	if region, ok := args["region"].(string); ok && region != "" {
+		params.Region = region
+	}
+	if features, ok := args["must_have_features"].([]interface{}); ok {
+		params.MustHaveFeatures = convertToStringSlice(features)
+	}
+
+	return h.searcher.ProductSearch(ctx, params)
+}
+
+// handlePeopleSearch handles people research
+func (h *Handler) handlePeopleSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "people")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+	if err := extractCompanyParams(args, params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	if name, ok := args["name"].(string); ok && name != "" {
+		params.PersonName = name
+	}
+	if params.PersonName == "" && params.Role == "" {
+		return "", fmt.Errorf("%w: name or role is required", errInvalidParameters)
+	}
+
+	return h.searcher.PeopleSearch(ctx, params)
+}
+
+// handleCompanySearch handles company research
+func (h *Handler) handleCompanySearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "company")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+	if err := extractCompanyParams(args, params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	if focus, ok := args["focus"].(string); ok && focus != "" {
+		focus = strings.ToLower(strings.TrimSpace(focus))
+		if !search.IsValidCompanyFocus(focus) {
+			return "", fmt.Errorf("%w: invalid focus '%s'. Use overview, funding, jobs, leadership, or news", errInvalidParameters, focus)
+		}
+		params.Focus = focus
+	}
+	if params.CompanyName == "" && params.CompanyDomain == "" {
+		return "", fmt.Errorf("%w: company or company_domain is required", errInvalidParameters)
+	}
+
+	return h.searcher.CompanySearch(ctx, params)
+}
+
+// extractCompanyParams extracts the role, company, and company_domain parameters shared by people and company search
+func extractCompanyParams(args map[string]interface{}, params *search.SearchParams) error {
+	if role, ok := args["role"].(string); ok && role != "" {
+		params.Role = role
+	}
+	if company, ok := args["company"].(string); ok && company != "" {
+		params.CompanyName = company
+	}
+	if domain, ok := args["company_domain"].(string); ok && domain != "" {
+		normalized, err := search.NormalizeCompanyDomain(domain)
+		if err != nil {
+			return err
+		}
+		params.CompanyDomain = normalized
+	}
+	return nil
+}
+
+// handleTravelSearch handles trip planning
+func (h *Handler) handleTravelSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "travel")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add travel-specific parameters
+	if origin, ok := args["origin"].(string); ok && origin != "" {
+		params.Origin = origin
+	}
+	if destination, ok := args["destination"].(string); ok && destination != "" {
+		params.Destination = destination
+	}
+	if start, ok := args["travel_date_start"].(string); ok && start != "" {
+		params.TravelDateStart = start
+	}
+	if end, ok := args["travel_date_end"].(string); ok && end != "" {
+		params.TravelDateEnd = end
+	}
+	if constraints, ok := args["constraints"].([]interface{}); ok {
+		params.Constraints = convertToStringSlice(constraints)
+	}
+	if err := search.ValidateTravelParams(params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	return h.searcher.TravelSearch(ctx, params)
+}
+
+// handleDevSearch handles programming and documentation search
+func (h *Handler) handleDevSearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "dev")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add dev-specific parameters
+	if language, ok := args["language"].(string); ok && language != "" {
+		params.ProgrammingLanguage = language
+	}
+	if framework, ok := args["framework"].(string); ok && framework != "" {
+		params.Framework = framework
+	}
+	if version, ok := args["version"].(string); ok && version != "" {
+		params.Version = version
+	}
+
+	return h.searcher.DevSearch(ctx, params)
+}
+
+// handleSecuritySearch handles vulnerability and security advisory search
+func (h *Handler) handleSecuritySearch(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "security")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	// Add security-specific parameters
+	if cveID, ok := args["cve_id"].(string); ok && cveID != "" {
+		params.CVEID = cveID
+	}
+	if product, ok := args["product"].(string); ok && product != "" {
+		params.Product = product
+	}
+	if severity, ok := args["severity"].(string); ok && severity != "" {
+		params.Severity = severity
+	}
+	if err := search.NormalizeSecurityParams(params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	return h.searcher.SecuritySearch(ctx, params)
+}
+
+// handleReleaseWatch handles release and changelog monitoring for a project
+func (h *Handler) handleReleaseWatch(ctx context.Context, args map[string]interface{}) (string, error) {
+	project, _ := args["project"].(string)
+	repo, _ := args["repo"].(string)
+
+	// Without a query, every watch of the project uses the same one so digests can compare runs
+	params, err := h.extractSearchParams(withDefaultQuery(args, search.ReleaseWatchQuery(project, repo)), "release")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+	params.Project = project
+	params.Repo = repo
+	if err := search.ValidateReleaseParams(params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	return h.searcher.ReleaseWatch(ctx, params)
+}
+
+// handleLocalNow handles quick "right now near a place" questions
+func (h *Handler) handleLocalNow(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "local")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+	if strings.TrimSpace(params.Location) == "" {
+		return "", fmt.Errorf("%w: location parameter is required", errInvalidParameters)
+	}
+
+	return h.searcher.LocalNow(ctx, params)
+}
+
 // handleFilteredSearch handles filtered search
 func (h *Handler) handleFilteredSearch(ctx context.Context, args map[string]interface{}) (string, error) {
 	params, err := h.extractSearchParams(args, "filtered")
 	if err != nil {
-		return "", fmt.Errorf("invalid parameters: %w", err)
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
 	}
 
 	// Add filtering-specific parameters
@@ -76,27 +385,226 @@ func (h *Handler) handleFilteredSearch(ctx context.Context, args map[string]inte
 	if customFilters, ok := args["custom_filters"].(map[string]interface{}); ok {
 		params.CustomFilters = customFilters
 	}
+	if err := extractSearchMode(args, params); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
 
 	return h.searcher.FilteredSearch(ctx, params)
 }
 
+// handleSearchWithContext handles document-grounded search
+func (h *Handler) handleSearchWithContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "context")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	if document, ok := args["document"].(string); ok && document != "" {
+		params.Documents = append(params.Documents, search.Document{Name: "pasted document", Content: document})
+	}
+	if filePath, ok := args["file_path"].(string); ok && filePath != "" {
+		doc, err := search.LoadDocument(h.config().DocumentRoot, filePath, h.config().MaxDocumentBytes)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+		}
+		params.Documents = append(params.Documents, doc)
+	}
+	if len(params.Documents) == 0 {
+		return "", fmt.Errorf("%w: one of document, file_path, or context_refs is required", errInvalidParameters)
+	}
+
+	return h.searcher.SearchWithContext(ctx, params)
+}
+
+// handleTrend handles trend analysis across date windows
+func (h *Handler) handleTrend(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "trend")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	period := search.TrendMonth
+	if p, ok := args["period"].(string); ok && p != "" {
+		period = strings.ToLower(strings.TrimSpace(p))
+	}
+	windows := search.DefaultTrendWindows
+	if w, ok := args["windows"].(float64); ok {
+		windows = int(w)
+	}
+	if err := search.ValidateTrendParams(period, windows); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	return h.searcher.TrendSearch(ctx, params, period, windows)
+}
+
+// handleCompetitors handles competitive landscape research
+func (h *Handler) handleCompetitors(ctx context.Context, args map[string]interface{}) (string, error) {
+	company, _ := args["company"].(string)
+	if strings.TrimSpace(company) == "" {
+		return "", fmt.Errorf("%w: company parameter is required", errInvalidParameters)
+	}
+
+	params, err := h.extractSearchParams(withDefaultQuery(args, search.CompetitorsQuery(company)), "competitors")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+	params.CompanyName = strings.TrimSpace(company)
+
+	var competitors, scopes []string
+	if list, ok := args["competitors"].([]interface{}); ok {
+		competitors = convertToStringSlice(list)
+	}
+	if list, ok := args["scopes"].([]interface{}); ok {
+		for _, scope := range convertToStringSlice(list) {
+			scope = strings.ToLower(strings.TrimSpace(scope))
+			if !search.IsValidCompetitorScope(scope) {
+				return "", fmt.Errorf("%w: invalid scope '%s'. Use %s", errInvalidParameters, scope, strings.Join(search.DefaultCompetitorScopes, ", "))
+			}
+			scopes = append(scopes, scope)
+		}
+	}
+	maxCompetitors := search.DefaultMaxCompetitors
+	if max, ok := args["max_competitors"].(float64); ok {
+		maxCompetitors = int(max)
+		if maxCompetitors < 1 || maxCompetitors > search.MaxCompetitors {
+			return "", fmt.Errorf("%w: max_competitors must be between 1 and %d", errInvalidParameters, search.MaxCompetitors)
+		}
+	}
+
+	return h.searcher.CompetitorSearch(ctx, params, competitors, scopes, maxCompetitors)
+}
+
+// handleCompareModels handles side-by-side model comparison
+func (h *Handler) handleCompareModels(ctx context.Context, args map[string]interface{}) (string, error) {
+	params, err := h.extractSearchParams(args, "compare")
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+	}
+
+	models := []string{types.ModelSonar, types.ModelSonarPro}
+	if modelList, ok := args["models"].([]interface{}); ok {
+		models = convertToStringSlice(modelList)
+	}
+	if len(models) != 2 {
+		return "", fmt.Errorf("%w: models must contain exactly two model names", errInvalidParameters)
+	}
+	for _, model := range models {
+		if err := config.ValidateModel(model); err != nil {
+			return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+		}
+		if model == types.ModelAuto {
+			return "", fmt.Errorf("%w: 'auto' cannot be used in a model comparison; specify concrete models", errInvalidParameters)
+		}
+	}
+
+	return h.searcher.CompareModels(ctx, params, models)
+}
+
 // handleListPrevious handles listing previous queries
 func (h *Handler) handleListPrevious(ctx context.Context, args map[string]interface{}) (string, error) {
-	return h.searcher.ListPrevious(ctx)
+	var filter cache.QueryFilter
+	if entity, ok := args["entity"].(string); ok {
+		filter.Entity = strings.TrimSpace(entity)
+	}
+	if keyword, ok := args["keyword"].(string); ok {
+		filter.Keyword = strings.TrimSpace(keyword)
+	}
+	project, _ := args["project"].(string)
+	project = strings.TrimSpace(project)
+	if project != "" {
+		if err := config.ValidateProjectName(project); err != nil {
+			return "", fmt.Errorf("%w: %w", errInvalidParameters, err)
+		}
+	}
+
+	return h.searcher.ListPrevious(ctx, project, filter)
 }
 
 // handleGetPreviousResult handles getting previous results
 func (h *Handler) handleGetPreviousResult(ctx context.Context, args map[string]interface{}) (string, error) {
 	uniqueID, ok := args["unique_id"].(string)
 	if !ok || uniqueID == "" {
-		return "", fmt.Errorf("unique_id parameter is required")
+		return "", fmt.Errorf("%w: unique_id parameter is required", errInvalidParameters)
+	}
+
+	offset, chunkSize := 0, 0
+	if val, ok := args["offset"].(float64); ok {
+		offset = int(val)
+	}
+	if val, ok := args["chunk_size"].(float64); ok {
+		chunkSize = int(val)
+	}
+	if offset < 0 || chunkSize < 0 {
+		return "", fmt.Errorf("%w: offset and chunk_size must be non-negative", errInvalidParameters)
 	}
 
-	return h.searcher.GetPreviousResult(ctx, uniqueID)
+	return h.searcher.GetPreviousResultChunk(ctx, uniqueID, offset, chunkSize)
 }
 
-// extractSearchParams extracts common search parameters from map[string]interface{}
+// handleVerifyResult handles claim verification of a cached result
+func (h *Handler) handleVerifyResult(ctx context.Context, args map[string]interface{}) (string, error) {
+	resultID, ok := args["result_id"].(string)
+	if !ok || resultID == "" {
+		return "", fmt.Errorf("%w: result_id parameter is required", errInvalidParameters)
+	}
+
+	return h.searcher.VerifyResult(ctx, resultID, maxClaimsArgument(args))
+}
+
+// maxClaimsArgument returns the max_claims argument, limited to search.MaxClaims since each
+// claim is checked with its own API request; 0 means the default
+func maxClaimsArgument(args map[string]interface{}) int {
+	val, ok := args["max_claims"].(float64)
+	if !ok {
+		return 0
+	}
+	return min(int(val), search.MaxClaims)
+}
+
+// handleCheckLinks handles link rot checking of a cached result
+func (h *Handler) handleCheckLinks(ctx context.Context, args map[string]interface{}) (string, error) {
+	resultID, ok := args["result_id"].(string)
+	if !ok || resultID == "" {
+		return "", fmt.Errorf("%w: result_id parameter is required", errInvalidParameters)
+	}
+	useArchive, _ := args["use_archive"].(bool)
+
+	return h.searcher.CheckLinks(ctx, resultID, useArchive)
+}
+
+// handleTranslateResult handles translation of a cached result
+func (h *Handler) handleTranslateResult(ctx context.Context, args map[string]interface{}) (string, error) {
+	resultID, ok := args["result_id"].(string)
+	if !ok || resultID == "" {
+		return "", fmt.Errorf("%w: result_id parameter is required", errInvalidParameters)
+	}
+
+	language, ok := args["target_language"].(string)
+	if !ok || strings.TrimSpace(language) == "" {
+		return "", fmt.Errorf("%w: target_language parameter is required", errInvalidParameters)
+	}
+
+	return h.searcher.TranslateResult(ctx, resultID, strings.TrimSpace(language))
+}
+
+// handlePublishResult handles publishing a cached result to a wiki
+func (h *Handler) handlePublishResult(ctx context.Context, args map[string]interface{}) (string, error) {
+	resultID, ok := args["result_id"].(string)
+	if !ok || resultID == "" {
+		return "", fmt.Errorf("%w: result_id parameter is required", errInvalidParameters)
+	}
+
+	target, _ := args["target"].(string)
+	return h.searcher.PublishResult(ctx, resultID, strings.TrimSpace(target))
+}
+
+// extractSearchParams extracts common search parameters from map[string]interface{}, after
+// normalizing aliased argument names and loosely typed values
 func (h *Handler) extractSearchParams(args map[string]interface{}, searchType string) (*search.SearchParams, error) {
+	// Accept common misspellings of argument names and values sent with the wrong JSON type
+	args = NormalizeArguments(args)
+
 	// Required parameter
 	query, ok := args["query"].(string)
 	if !ok || query == "" {
@@ -133,6 +641,62 @@ func (h *Handler) extractSearchParams(args map[string]interface{}, searchType st
 		params.ReturnRelatedQuestions = &related
 	}
 
+	if retryOnEmpty, ok := args["retry_on_empty"].(bool); ok {
+		params.RetryOnEmpty = &retryOnEmpty
+	}
+
+	if minCitations, ok := args["min_citations"].(float64); ok {
+		if minCitations < 0 {
+			return nil, fmt.Errorf("min_citations must be non-negative")
+		}
+		params.MinCitations = int(minCitations)
+	}
+
+	if deepSources, ok := args["deep_sources"].(bool); ok {
+		params.DeepSources = deepSources
+	}
+
+	if confidence, ok := args["confidence"].(string); ok && confidence != "" {
+		if err := config.ValidateConfidence(confidence); err != nil {
+			return nil, err
+		}
+		params.Confidence = confidence
+	}
+
+	if separate, ok := args["separate_opinions"].(bool); ok {
+		params.SeparateOpinions = &separate
+	}
+
+	if extract, ok := args["extract_tables"].(bool); ok {
+		params.ExtractTables = extract
+	}
+
+	if timezone, ok := args["timezone"].(string); ok && timezone != "" {
+		if err := config.ValidateTimezone(timezone); err != nil {
+			return nil, err
+		}
+		params.Timezone = timezone
+	}
+
+	if language, ok := args["answer_language"].(string); ok && language != "" {
+		params.AnswerLanguage = language
+	}
+
+	if timeout, ok := args["timeout_seconds"].(float64); ok {
+		if timeout <= 0 {
+			return nil, fmt.Errorf("timeout_seconds must be positive")
+		}
+		params.TimeoutSeconds = int(timeout)
+	}
+
+	// Release watch uses project for the software project being watched
+	if project, ok := args["project"].(string); ok && project != "" && searchType != "release" {
+		if err := config.ValidateProjectName(project); err != nil {
+			return nil, err
+		}
+		params.CacheProject = project
+	}
+
 	if maxTokens, ok := args["max_tokens"].(float64); ok {
 		maxTokensInt := int(maxTokens)
 		params.MaxTokens = &maxTokensInt
@@ -154,9 +718,104 @@ func (h *Handler) extractSearchParams(args map[string]interface{}, searchType st
 		params.Location = location
 	}
 
+	// Explicit examples (even an empty list) replace those configured for the tool
+	if rawExamples, ok := args["examples"].([]interface{}); ok {
+		examples, err := parseExamples(rawExamples)
+		if err != nil {
+			return nil, err
+		}
+		params.Examples = examples
+	} else {
+		params.Examples = h.config().ExamplesFor(searchTypeTools[searchType])
+	}
+
+	params.Transforms = h.config().TransformsFor(searchTypeTools[searchType])
+
+	if refs, ok := args["context_refs"].([]interface{}); ok {
+		for _, ref := range convertToStringSlice(refs) {
+			doc, err := h.searcher.LoadContextRef(ref)
+			if err != nil {
+				return nil, err
+			}
+			params.Documents = append(params.Documents, doc)
+		}
+	}
+
 	return params, nil
 }
 
+// searchTypeTools maps search types to the tool names used as keys for per-tool examples and transforms
+var searchTypeTools = map[string]string{
+	"general":     "perplexity_search",
+	"academic":    "perplexity_academic_search",
+	"financial":   "perplexity_financial_search",
+	"patent":      "perplexity_patent_search",
+	"legal":       "perplexity_legal_search",
+	"medical":     "perplexity_medical_search",
+	"product":     "perplexity_product_search",
+	"people":      "perplexity_people_search",
+	"company":     "perplexity_company_search",
+	"travel":      "perplexity_travel_search",
+	"dev":         "perplexity_dev_search",
+	"security":    "perplexity_security_search",
+	"release":     "perplexity_release_watch",
+	"local":       "perplexity_local_now",
+	"filtered":    "perplexity_filtered_search",
+	"context":     "perplexity_search_with_context",
+	"compare":     "perplexity_compare_models",
+	"trend":       "perplexity_trend",
+	"competitors": "perplexity_competitors",
+}
+
+// parseExamples converts the examples argument into question/answer pairs
+func parseExamples(raw []interface{}) ([]types.Example, error) {
+	examples := make([]types.Example, 0, len(raw))
+	for i, item := range raw {
+		pair, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("examples[%d] must be an object with question and answer", i)
+		}
+		question, _ := pair["question"].(string)
+		answer, _ := pair["answer"].(string)
+		examples = append(examples, types.Example{Question: question, Answer: answer})
+	}
+
+	if err := config.ValidateExamples(examples); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// extractSearchMode reads and validates the optional search_mode argument
+func extractSearchMode(args map[string]interface{}, params *search.SearchParams) error {
+	mode, ok := args["search_mode"].(string)
+	if !ok || mode == "" {
+		return nil
+	}
+
+	switch mode {
+	case types.SearchModeWeb, types.SearchModeAcademic, types.SearchModeSEC:
+		params.SearchMode = mode
+		return nil
+	default:
+		return fmt.Errorf("search_mode '%s' is not valid. Use 'web', 'academic', or 'sec'", mode)
+	}
+}
+
+// withDefaultQuery returns args with query set to defaultQuery when the caller gave none,
+// leaving the caller's map untouched
+func withDefaultQuery(args map[string]interface{}, defaultQuery string) map[string]interface{} {
+	if query, ok := args["query"].(string); ok && query != "" {
+		return args
+	}
+	withQuery := make(map[string]interface{}, len(args)+1)
+	for key, value := range args {
+		withQuery[key] = value
+	}
+	withQuery["query"] = defaultQuery
+	return withQuery
+}
+
 // convertToStringSlice safely converts []interface{} to []string
 func convertToStringSlice(interfaces []interface{}) []string {
 	result := make([]string, 0, len(interfaces))
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/search"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestExtractSearchMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		{"absent", map[string]interface{}{}, "", false},
+		{"web", map[string]interface{}{"search_mode": "web"}, types.SearchModeWeb, false},
+		{"academic", map[string]interface{}{"search_mode": "academic"}, types.SearchModeAcademic, false},
+		{"sec", map[string]interface{}{"search_mode": "sec"}, types.SearchModeSEC, false},
+		{"invalid", map[string]interface{}{"search_mode": "news"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &search.SearchParams{}
+			err := extractSearchMode(tt.args, params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractSearchMode error mismatch: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if params.SearchMode != tt.want {
+				t.Errorf("SearchMode mismatch: got %s, want %s", params.SearchMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxClaimsArgument(t *testing.T) {
+	for _, tt := range []struct {
+		args map[string]interface{}
+		want int
+	}{
+		{map[string]interface{}{}, 0},
+		{map[string]interface{}{"max_claims": 3.0}, 3},
+		{map[string]interface{}{"max_claims": 10000.0}, search.MaxClaims},
+	} {
+		if got := maxClaimsArgument(tt.args); got != tt.want {
+			t.Errorf("maxClaimsArgument(%v) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExtractSearchParamsExamples(t *testing.T) {
+	cfg := &config.Config{
+		APIKey: "test-api-key",
+		Examples: config.ExamplesConfig{
+			Default: []types.Example{{Question: "Default Q", Answer: "Default A"}},
+			Tools: map[string][]types.Example{
+				"perplexity_academic_search": {{Question: "Academic Q", Answer: "| Paper | Year |"}},
+			},
+		},
+	}
+	h, err := NewHandler(cfg, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		searchType string
+		args       map[string]interface{}
+		want       []types.Example
+	}{
+		{"default", "general", map[string]interface{}{"query": "q"}, cfg.Examples.Default},
+		{"per tool", "academic", map[string]interface{}{"query": "q"}, cfg.Examples.Tools["perplexity_academic_search"]},
+		{"explicit", "general", map[string]interface{}{
+			"query":    "q",
+			"examples": []interface{}{map[string]interface{}{"question": "Arg Q", "answer": "Arg A"}},
+		}, []types.Example{{Question: "Arg Q", Answer: "Arg A"}}},
+		{"disabled", "general", map[string]interface{}{"query": "q", "examples": []interface{}{}}, []types.Example{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := h.extractSearchParams(tt.args, tt.searchType)
+			if err != nil {
+				t.Fatalf("extractSearchParams failed: %v", err)
+			}
+			if !reflect.DeepEqual(params.Examples, tt.want) {
+				t.Errorf("Examples mismatch: got %v, want %v", params.Examples, tt.want)
+			}
+		})
+	}
+
+	invalid := map[string]interface{}{"query": "q", "examples": []interface{}{map[string]interface{}{"question": "No answer"}}}
+	if _, err := h.extractSearchParams(invalid, "general"); err == nil {
+		t.Error("Expected error for example without an answer, got nil")
+	}
+}
+
+func TestExtractSearchParamsProject(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+
+	params, err := h.extractSearchParams(map[string]interface{}{"query": "q", "project": "thesis"}, "general")
+	if err != nil || params.CacheProject != "thesis" {
+		t.Errorf("Expected project thesis, got %+v, %v", params, err)
+	}
+	params, err = h.extractSearchParams(map[string]interface{}{"query": "q", "project": "kubernetes"}, "release")
+	if err != nil || params.CacheProject != "" {
+		t.Errorf("Expected release watch to keep project for the watched software, got %+v, %v", params, err)
+	}
+	if _, err := h.extractSearchParams(map[string]interface{}{"query": "q", "project": "../escape"}, "general"); err == nil {
+		t.Error("Expected an error for a project name that is not a plain folder name")
+	}
+}
+
+func TestHandleCompareModelsValidatesModels(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	for _, models := range [][]interface{}{
+		{types.ModelSonar, "gpt-unknown"},
+		{types.ModelAuto, types.ModelSonarPro},
+	} {
+		_, err := h.handleCompareModels(context.Background(), map[string]interface{}{"query": "q", "models": models})
+		if !errors.Is(err, errInvalidParameters) {
+			t.Errorf("Expected invalid parameters for models %v, got %v", models, err)
+		}
+	}
+}
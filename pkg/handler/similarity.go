@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// similarityStopwords are question words too common to say anything about a query's topic
+var similarityStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"can": true, "do": true, "does": true, "for": true, "from": true, "how": true, "i": true,
+	"in": true, "is": true, "it": true, "me": true, "of": true, "on": true, "or": true,
+	"tell": true, "the": true, "to": true, "was": true, "what": true, "whats": true, "when": true,
+	"where": true, "which": true, "who": true, "why": true, "with": true,
+}
+
+// queryVector is a sparse embedding of a query: its topic words, roughly stemmed, and the
+// character trigrams within them so that small spelling differences stay close. Numbers such
+// as years and quarters count as whole words only, so a different number is never close.
+type queryVector map[string]float64
+
+// newQueryVector embeds a query, ignoring case, punctuation, and common question words
+func newQueryVector(query string) queryVector {
+	vector := queryVector{}
+	words := strings.FieldsFunc(strings.ToLower(strings.ReplaceAll(query, "'", "")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, word := range words {
+		if similarityStopwords[word] {
+			continue
+		}
+		if strings.IndexFunc(word, unicode.IsNumber) >= 0 {
+			vector["n:"+word] += 4
+			continue
+		}
+		if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+			word = strings.TrimSuffix(word, "s")
+		}
+		vector["w:"+word] += 2
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			vector["t:"+string(padded[i:i+3])]++
+		}
+	}
+	return vector
+}
+
+// cosine returns the cosine similarity of two vectors, from 0 (unrelated) to 1 (same topic words)
+func (v queryVector) cosine(other queryVector) float64 {
+	var dot, normV, normOther float64
+	for feature, weight := range v {
+		dot += weight * other[feature]
+		normV += weight * weight
+	}
+	for _, weight := range other {
+		normOther += weight * weight
+	}
+	if normV == 0 || normOther == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normV*normOther)
+}
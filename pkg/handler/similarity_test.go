@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestQueryVectorCosine(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		close bool
+	}{
+		{"What is the capital of France?", "what's the capital of france", true},
+		{"latest AI news", "latest news on AI", true},
+		{"best electric cars 2025", "best electric car 2025", true},
+		{"What is the capital of France?", "What is the capital of Spain?", false},
+		{"best electric cars 2025", "best electric cars 2024", false},
+		{"effects of caffeine on sleep", "effects of caffeine on anxiety", false},
+	}
+	for _, tt := range tests {
+		similarity := newQueryVector(tt.a).cosine(newQueryVector(tt.b))
+		if close := similarity >= 0.9; close != tt.close {
+			t.Errorf("%q vs %q: similarity %.3f, expected close=%v", tt.a, tt.b, similarity, tt.close)
+		}
+	}
+
+	if got := newQueryVector("what is").cosine(newQueryVector("what is")); got != 0 {
+		t.Errorf("Expected 0 for queries of only stopwords, got %f", got)
+	}
+}
+
+func TestRecentCallsLookupSimilar(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	recent := newRecentCalls(0, 0.9, time.Hour)
+	recent.now = func() time.Time { return now }
+
+	stored, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "What is the capital of France?", "model": "sonar"})
+	recent.store(stored, "Paris")
+	now = now.Add(5 * time.Minute)
+
+	rephrased, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "what's the capital of france", "model": "sonar"})
+	similar, ok := recent.lookupSimilar(rephrased)
+	if !ok || similar.result != "Paris" || similar.query != stored.query || similar.age != 5*time.Minute {
+		t.Errorf("Expected the stored answer for a rephrased query, got %+v, %v", similar, ok)
+	}
+	if _, _, ok := recent.lookup(rephrased); ok {
+		t.Error("Expected no exact match with a zero duplicate window")
+	}
+
+	for name, call := range map[string]map[string]interface{}{
+		"different topic": {"query": "What is the capital of Spain?", "model": "sonar"},
+		"different model": {"query": "what's the capital of france", "model": "sonar-pro"},
+	} {
+		other, _ := newToolCall("perplexity_search", call)
+		if _, ok := recent.lookupSimilar(other); ok {
+			t.Errorf("%s: expected no similar match", name)
+		}
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok := recent.lookupSimilar(rephrased); ok {
+		t.Error("Expected the answer to expire after the similarity TTL")
+	}
+
+	if newRecentCalls(0, 0, time.Hour) != nil {
+		t.Error("Expected no guard with both matching modes off")
+	}
+}
+
+func TestCallToolReturnsSimilarResult(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key", SimilarityThreshold: 0.9, SimilarityTTL: time.Hour}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	stored, _ := newToolCall("perplexity_search", map[string]interface{}{"query": "What is the capital of France?"})
+	h.recent.store(stored, "Paris")
+
+	req := &protocol.CallToolRequest{Name: "perplexity_search", Arguments: map[string]interface{}{"query": "what's the capital of france"}}
+	resp, err := h.CallTool(context.Background(), req)
+	if err != nil || resp.IsError {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(resp.Content) != 2 || resp.Content[0].Text != "Paris" ||
+		!strings.HasPrefix(resp.Content[1].Text, "Similar query reused") ||
+		!strings.Contains(resp.Content[1].Text, `"What is the capital of France?"`) {
+		t.Errorf("Expected the earlier answer and the matched query, got %+v", resp.Content)
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// placeholderPattern matches {{name}} placeholders, allowing spaces inside the braces
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// templates returns the configured query templates and those saved in the results root.
+// The file in the results root is read on each call, so edits to it apply immediately;
+// configured templates win when both define a name.
+func (h *Handler) templates() (map[string]config.QueryTemplate, error) {
+	templates := make(map[string]config.QueryTemplate, len(h.config().Templates))
+	data, err := cache.ReadTemplates(h.config().ResultsRootFolder)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		saved, err := config.ParseTemplates(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid templates.json in the results folder: %w", err)
+		}
+		for name, tmpl := range saved {
+			templates[name] = tmpl
+		}
+	}
+	for name, tmpl := range h.config().Templates {
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
+
+// expandTemplate turns a run_template call into a call of the template's tool, with its
+// placeholders filled from the variables, the template's defaults, and the date
+func (h *Handler) expandTemplate(ctx context.Context, args map[string]interface{}, now time.Time) (*protocol.CallToolRequest, error) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", errInvalidParameters)
+	}
+	templates, err := h.templates()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, ok := templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown template '%s'. %s", errInvalidParameters, name, describeTemplates(templates))
+	}
+
+	values := map[string]string{"date": now.Format("2006-01-02")}
+	for key, value := range tmpl.Defaults {
+		values[key] = value
+	}
+	if variables, ok := args["variables"].(map[string]interface{}); ok {
+		for key, value := range variables {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	arguments := make(map[string]interface{}, len(tmpl.Arguments)+1)
+	for key, value := range tmpl.Arguments {
+		arguments[key] = value
+	}
+	if tmpl.Query != "" {
+		arguments["query"] = tmpl.Query
+	}
+	if overrides, ok := args["arguments"].(map[string]interface{}); ok {
+		for key, value := range overrides {
+			arguments[key] = value
+		}
+	}
+
+	missing := map[string]bool{}
+	for key, value := range arguments {
+		arguments[key] = fillPlaceholders(value, values, missing)
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: template '%s' needs values for: %s", errInvalidParameters, name, strings.Join(names, ", "))
+	}
+
+	if !h.isRunnableTool(ctx, tmpl.Tool) {
+		return nil, fmt.Errorf("template '%s' runs unknown tool '%s'", name, tmpl.Tool)
+	}
+	return &protocol.CallToolRequest{Name: tmpl.Tool, Arguments: arguments}, nil
+}
+
+// isRunnableTool reports whether a template or profile can run the named tool: any tool
+// except those that run or save templates and profiles themselves
+func (h *Handler) isRunnableTool(ctx context.Context, name string) bool {
+	switch name {
+	case "run_template", "run_profile", "save_profile":
+		return false
+	}
+	list, err := h.ListTools(ctx)
+	if err != nil {
+		return false
+	}
+	for _, tool := range list.Tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fillPlaceholders replaces the placeholders in the strings of value, recording those
+// without a value in missing
+func fillPlaceholders(value interface{}, values map[string]string, missing map[string]bool) interface{} {
+	switch v := value.(type) {
+	case string:
+		return placeholderPattern.ReplaceAllStringFunc(v, func(match string) string {
+			key := placeholderPattern.FindStringSubmatch(match)[1]
+			if filled, ok := values[key]; ok {
+				return filled
+			}
+			missing[key] = true
+			return match
+		})
+	case []interface{}:
+		filled := make([]interface{}, len(v))
+		for i, item := range v {
+			filled[i] = fillPlaceholders(item, values, missing)
+		}
+		return filled
+	case map[string]interface{}:
+		filled := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			filled[key] = fillPlaceholders(item, values, missing)
+		}
+		return filled
+	}
+	return value
+}
+
+// describeTemplates lists the templates with their descriptions and placeholders
+func describeTemplates(templates map[string]config.QueryTemplate) string {
+	if len(templates) == 0 {
+		return "No templates are defined; add them to PERPLEXITY_TEMPLATES_FILE or templates.json in the results folder"
+	}
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Available templates:")
+	for _, name := range names {
+		tmpl := templates[name]
+		fmt.Fprintf(&b, "\n- %s", name)
+		if tmpl.Description != "" {
+			fmt.Fprintf(&b, ": %s", tmpl.Description)
+		}
+		if placeholders := templatePlaceholders(tmpl); len(placeholders) > 0 {
+			fmt.Fprintf(&b, " (variables: %s)", strings.Join(placeholders, ", "))
+		}
+	}
+	return b.String()
+}
+
+// templatePlaceholders returns the sorted placeholder names of a template that have no default
+func templatePlaceholders(tmpl config.QueryTemplate) []string {
+	found := map[string]bool{}
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch v := value.(type) {
+		case string:
+			for _, match := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+				if _, ok := tmpl.Defaults[match[1]]; !ok && match[1] != "date" {
+					found[match[1]] = true
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		}
+	}
+	collect(tmpl.Query)
+	collect(tmpl.Arguments)
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	root := t.TempDir()
+	saved := `{
+		"weekly_competitors": {"tool": "perplexity_competitors", "arguments": {"company": "{{company}}", "search_recency_filter": "{{ period }}"}, "defaults": {"period": "week"}},
+		"news": {"query": "saved version"}
+	}`
+	if err := os.WriteFile(filepath.Join(root, "templates.json"), []byte(saved), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(&config.Config{
+		APIKey:            "test-api-key",
+		ResultsRootFolder: root,
+		Templates: map[string]config.QueryTemplate{
+			"news": {Tool: "perplexity_search", Query: "{{topic}} news as of {{date}}", Arguments: map[string]interface{}{"search_domain_filter": []interface{}{"{{site}}"}}},
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	req, err := h.expandTemplate(context.Background(), map[string]interface{}{
+		"name":      "news",
+		"variables": map[string]interface{}{"topic": "GPU", "site": "reuters.com"},
+		"arguments": map[string]interface{}{"model": "sonar-pro"},
+	}, now)
+	if err != nil {
+		t.Fatalf("expandTemplate failed: %v", err)
+	}
+	if req.Name != "perplexity_search" || req.Arguments["query"] != "GPU news as of 2026-10-18" || req.Arguments["model"] != "sonar-pro" {
+		t.Errorf("Expected the configured template filled in, got %s %v", req.Name, req.Arguments)
+	}
+	if domains, _ := req.Arguments["search_domain_filter"].([]interface{}); len(domains) != 1 || domains[0] != "reuters.com" {
+		t.Errorf("Expected placeholders filled in list arguments, got %v", req.Arguments["search_domain_filter"])
+	}
+
+	req, err = h.expandTemplate(context.Background(), map[string]interface{}{
+		"name":      "weekly_competitors",
+		"variables": map[string]interface{}{"company": "Nvidia"},
+	}, now)
+	if err != nil {
+		t.Fatalf("expandTemplate failed: %v", err)
+	}
+	if req.Name != "perplexity_competitors" || req.Arguments["company"] != "Nvidia" || req.Arguments["search_recency_filter"] != "week" {
+		t.Errorf("Expected the saved template filled in with its defaults, got %s %v", req.Name, req.Arguments)
+	}
+
+	if _, err := h.expandTemplate(context.Background(), map[string]interface{}{"name": "weekly_competitors"}, now); err == nil || !strings.Contains(err.Error(), "needs values for: company") {
+		t.Errorf("Expected the missing variable named, got %v", err)
+	}
+	resp, err := h.CallTool(context.Background(), &protocol.CallToolRequest{Name: "run_template", Arguments: map[string]interface{}{"name": "missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError || !strings.Contains(resp.Content[0].Text, "weekly_competitors (variables: company)") {
+		t.Errorf("Expected the available templates listed, got %+v", resp)
+	}
+}
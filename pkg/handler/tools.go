@@ -3,270 +3,1165 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
 )
 
 // ListTools returns the list of available MCP tools
 func (h *Handler) ListTools(ctx context.Context) (*protocol.ListToolsResponse, error) {
-	return &protocol.ListToolsResponse{
-		Tools: []protocol.Tool{
-			{
-				Name:        "perplexity_search",
-				Description: "General web search with real-time information and source URLs. Best for: current events, general knowledge, quick facts, web content. Always includes source URLs for follow-up fetching. Use 'sonar' model for quick searches, 'sonar-pro' for comprehensive results.",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "The search query. Be specific and clear for best results."
-						},
-						"model": {
-							"type": "string",
-							"description": "Choose 'sonar' for quick factual searches (faster, cheaper) or 'sonar-pro' for comprehensive searches (better depth, more thorough)",
-							"enum": ["sonar", "sonar-pro"],
-							"default": "sonar"
-						},
-						"search_domain_filter": {
-							"type": "array",
-							"items": {"type": "string"},
-							"description": "Limit search to specific domains (e.g., ['wikipedia.org', 'nature.com'])"
-						},
-						"search_exclude_domains": {
-							"type": "array",
-							"items": {"type": "string"},
-							"description": "Exclude specific domains from results (e.g., ['reddit.com', 'quora.com'])"
-						},
-						"search_recency_filter": {
-							"type": "string",
-							"description": "Filter by recency: 'hour' for breaking news, 'day' for today's updates, 'week' for recent events, 'month' for recent trends, 'year' for current year",
-							"enum": ["hour", "day", "week", "month", "year"]
-						},
-						"return_images": {
-							"type": "boolean",
-							"description": "Include images in response"
-						},
-						"return_related_questions": {
-							"type": "boolean",
-							"description": "Include related questions"
-						},
-						"max_tokens": {
-							"type": "number",
-							"description": "Maximum tokens in response"
-						},
-						"temperature": {
-							"type": "number",
-							"description": "Response randomness (0-2)"
-						},
-						"date_range_start": {
-							"type": "string",
-							"description": "Start date for filtering (YYYY-MM-DD)"
-						},
-						"date_range_end": {
-							"type": "string",
-							"description": "End date for filtering (YYYY-MM-DD)"
-						},
-						"location": {
-							"type": "string",
-							"description": "Location for geo-specific search"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "perplexity_academic_search",
-				Description: "Search academic papers, research articles, and scholarly content. Automatically filters to academic sources (arxiv.org, pubmed, journals). Best for: research papers, scientific studies, academic citations.",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "The academic search query. Include key terms, authors, or specific topics."
-						},
-						"subject_area": {
-							"type": "string",
-							"description": "Optional: Specify academic field to narrow results (e.g., 'Physics', 'Computer Science', 'Medicine')"
-						},
-						"model": {
-							"type": "string",
-							"description": "Defaults to 'sonar-pro' for comprehensive academic results. Use 'sonar' only for quick lookups.",
-							"enum": ["sonar", "sonar-pro"],
-							"default": "sonar-pro"
-						},
-						"search_domain_filter": {
-							"type": "array",
-							"items": {"type": "string"},
-							"description": "List of academic domains to include"
-						},
-						"search_recency_filter": {
-							"type": "string",
-							"description": "Time-based filter",
-							"enum": ["hour", "day", "week", "month", "year"]
-						},
-						"max_tokens": {
-							"type": "number",
-							"description": "Maximum tokens in response"
-						},
-						"temperature": {
-							"type": "number",
-							"description": "Response randomness (0-2)"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "perplexity_financial_search",
-				Description: "Search financial data, SEC filings, earnings reports, and market information. Optimized for financial domains and recent data. Best for: stock analysis, earnings, SEC filings, market trends.",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "The financial search query. Include company names, tickers, or specific financial metrics."
-						},
-						"ticker": {
-							"type": "string",
-							"description": "Optional: Stock ticker symbol (e.g., 'AAPL', 'MSFT') to focus search"
-						},
-						"company_name": {
-							"type": "string",
-							"description": "Optional: Company name to ensure accurate results"
-						},
-						"report_type": {
-							"type": "string",
-							"description": "Optional: SEC report type (e.g., '10-K' for annual, '10-Q' for quarterly, '8-K' for current)"
-						},
-						"model": {
-							"type": "string",
-							"description": "Defaults to 'sonar-pro' for comprehensive financial data. Use 'sonar' for quick stock quotes.",
-							"enum": ["sonar", "sonar-pro"],
-							"default": "sonar-pro"
-						},
-						"search_recency_filter": {
-							"type": "string",
-							"description": "Time-based filter",
-							"enum": ["hour", "day", "week", "month", "year"]
-						},
-						"date_range_start": {
-							"type": "string",
-							"description": "Start date for reports (YYYY-MM-DD)"
-						},
-						"date_range_end": {
-							"type": "string",
-							"description": "End date for reports (YYYY-MM-DD)"
-						},
-						"max_tokens": {
-							"type": "number",
-							"description": "Maximum tokens in response"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "perplexity_filtered_search",
-				Description: "Advanced search with multiple filters. Best for: specific requirements, domain-specific searches, content type filtering, location-based searches. Use when other specialized searches don't fit your needs.",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"query": {
-							"type": "string",
-							"description": "The search query"
-						},
-						"model": {
-							"type": "string",
-							"description": "Choose based on needs: 'sonar' for quick filtered searches, 'sonar-pro' for comprehensive filtered results",
-							"enum": ["sonar", "sonar-pro"],
-							"default": "sonar-pro"
-						},
-						"search_domain_filter": {
-							"type": "array",
-							"items": {"type": "string"},
-							"description": "List of domains to include"
-						},
-						"search_exclude_domains": {
-							"type": "array",
-							"items": {"type": "string"},
-							"description": "List of domains to exclude"
-						},
-						"search_recency_filter": {
-							"type": "string",
-							"description": "Time-based filter",
-							"enum": ["hour", "day", "week", "month", "year"]
-						},
-						"content_type": {
-							"type": "string",
-							"description": "Type of content (news, academic, blog, etc.)"
-						},
-						"file_type": {
-							"type": "string",
-							"description": "File type filter (pdf, doc, html, etc.)"
-						},
-						"language": {
-							"type": "string",
-							"description": "Language filter"
-						},
-						"country": {
-							"type": "string",
-							"description": "Country for geo-specific search"
-						},
-						"date_range_start": {
-							"type": "string",
-							"description": "Start date (YYYY-MM-DD)"
-						},
-						"date_range_end": {
-							"type": "string",
-							"description": "End date (YYYY-MM-DD)"
-						},
-						"return_images": {
-							"type": "boolean",
-							"description": "Include images"
-						},
-						"return_related_questions": {
-							"type": "boolean",
-							"description": "Include related questions"
-						},
-						"max_tokens": {
-							"type": "number",
-							"description": "Maximum tokens in response"
-						},
-						"temperature": {
-							"type": "number",
-							"description": "Response randomness (0-2)"
-						},
-						"custom_filters": {
-							"type": "object",
-							"description": "Additional custom filters as key-value pairs"
-						}
-					},
-					"required": ["query"]
-				}`),
-			},
-			{
-				Name:        "list_previous",
-				Description: "List previous search queries with their unique IDs, sorted by recency. Returns JSON array with query details.",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {},
-					"required": []
-				}`),
-			},
-			{
-				Name:        "get_previous_result",
-				Description: "Retrieve a previously cached search result by its unique ID.",
-				InputSchema: json.RawMessage(`{
-					"type": "object",
-					"properties": {
-						"unique_id": {
-							"type": "string",
-							"description": "The unique 10-character alphanumeric ID of the cached result to retrieve"
-						}
-					},
-					"required": ["unique_id"]
-				}`),
+	resp := &protocol.ListToolsResponse{Tools: slices.Clone(toolList())}
+	addManifestModels(resp.Tools)
+	return resp, nil
+}
+
+// toolList returns the MCP tools. It is built once, since most schemas are merged from
+// sharedProperties, and callers must copy it before changing a tool.
+var toolList = sync.OnceValue(func() []protocol.Tool {
+	return []protocol.Tool{
+		{
+			Name:        "perplexity_search",
+			Description: "General web search with real-time information and source URLs. Best for: current events, general knowledge, quick facts, web content. Always includes source URLs for follow-up fetching. Use 'sonar' model for quick searches, 'sonar-pro' for comprehensive results.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The search query. Be specific and clear for best results."
+					},
+					"model": {
+						"type": "string",
+						"description": "Choose 'sonar' for quick factual searches (faster, cheaper) or 'sonar-pro' for comprehensive searches (better depth, more thorough). 'auto' picks a model from the query (length, tickers, research or analysis keywords)",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Limit search to specific domains (e.g., ['wikipedia.org', 'nature.com'])"
+					},
+					"search_exclude_domains": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Exclude specific domains from results (e.g., ['reddit.com', 'quora.com'])"
+					},
+					"search_recency_filter": {
+						"type": "string",
+						"description": "Filter by recency: 'hour' for breaking news, 'day' for today's updates, 'week' for recent events, 'month' for recent trends, 'year' for current year",
+						"enum": ["hour", "day", "week", "month", "year"]
+					},
+					"return_images": {
+						"type": "boolean",
+						"description": "Include images in response"
+					},
+					"date_range_start": {
+						"type": "string",
+						"description": "Start date for filtering (YYYY-MM-DD)"
+					},
+					"date_range_end": {
+						"type": "string",
+						"description": "End date for filtering (YYYY-MM-DD)"
+					},
+					"location": {
+						"type": "string",
+						"description": "Location for geo-specific search"
+					},
+					"regions": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Compare answers across 2 to 8 regions (e.g. [\"DE\", \"FR\", \"US\"]): the query runs once per region as its user location, concurrently, and the result is one comparison table with each region's answer. Replaces location"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_mode", "return_related_questions", "temperature")...),
+		},
+		{
+			Name:        "perplexity_academic_search",
+			Description: "Search academic papers, research articles, and scholarly content. Automatically filters to academic sources (arxiv.org, pubmed, journals). Best for: research papers, scientific studies, academic citations.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The academic search query. Include key terms, authors, or specific topics."
+					},
+					"subject_area": {
+						"type": "string",
+						"description": "Optional: Specify academic field to narrow results (e.g., 'Physics', 'Computer Science', 'Medicine')"
+					},
+					"peer_reviewed_only": {
+						"type": "boolean",
+						"description": "Restrict sources to peer-reviewed journals and conference proceedings. Unless search_domain_filter is given, searches journal publishers and PubMed only; blogs, forums, Wikipedia, and preprint servers are removed from the sources",
+						"default": false
+					},
+					"include_preprints": {
+						"type": "boolean",
+						"description": "With peer_reviewed_only, keep preprints (arXiv, bioRxiv, medRxiv) as sources; they are labelled as not peer reviewed",
+						"default": false
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for comprehensive academic results. Use 'sonar' only for quick lookups.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "List of academic domains to include"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter", "temperature")...),
+		},
+		{
+			Name:        "perplexity_financial_search",
+			Description: "Search financial data, SEC filings, earnings reports, and market information. Optimized for financial domains and recent data. Covers equities, crypto, FX, and commodities via asset_class. Best for: stock analysis, earnings, SEC filings, market trends.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The financial search query. Include company names, tickers, or specific financial metrics."
+					},
+					"asset_class": {
+						"type": "string",
+						"enum": ["equity", "crypto", "fx", "commodity"],
+						"description": "Optional: Asset class. Tailors the prompt and, unless search_domain_filter is given, searches specialist sources for it (e.g., coindesk.com and coingecko.com for crypto)"
+					},
+					"ticker": {
+						"type": "string",
+						"description": "Optional: Stock ticker symbol (e.g., 'AAPL', 'MSFT') to focus search. For other asset classes, the coin symbol ('BTC'), currency pair ('EUR/USD'), or contract ('Brent crude')"
+					},
+					"company_name": {
+						"type": "string",
+						"description": "Optional: Company name to ensure accurate results. For equities, whichever of ticker and company_name is missing is resolved automatically"
+					},
+					"report_type": {
+						"type": "string",
+						"description": "Optional: SEC report type (e.g., '10-K' for annual, '10-Q' for quarterly, '8-K' for current)"
+					},
+					"event": {
+						"type": "string",
+						"enum": ["earnings", "dividend", "split"],
+						"description": "Optional: Corporate event to focus on. The event date closest to today is looked up first and the date range is scoped around it unless date_range_start/date_range_end are given. Requires ticker or company_name"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for comprehensive financial data. Use 'sonar' for quick stock quotes.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"date_range_start": {
+						"type": "string",
+						"description": "Start date for reports (YYYY-MM-DD)"
+					},
+					"date_range_end": {
+						"type": "string",
+						"description": "End date for reports (YYYY-MM-DD)"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter")...),
+		},
+		{
+			Name:        "perplexity_patent_search",
+			Description: "Search patents and patent applications. Searches Google Patents, USPTO, Espacenet, and WIPO by default and asks for publication numbers, assignees, and filing dates. Best for: prior art, freedom-to-operate research, competitor patent portfolios.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The patent search query. Describe the invention, technique, or claims of interest."
+					},
+					"inventor": {
+						"type": "string",
+						"description": "Optional: Inventor name"
+					},
+					"assignee": {
+						"type": "string",
+						"description": "Optional: Assignee (patent owner), e.g. a company or university"
+					},
+					"cpc_class": {
+						"type": "string",
+						"description": "Optional: Cooperative Patent Classification section, class, subclass, or group (e.g., 'G06F', 'H04L 9/32')"
+					},
+					"jurisdiction": {
+						"type": "string",
+						"description": "Optional: Two-letter patent office code (e.g., 'US', 'EP', 'WO', 'CN', 'JP')"
+					},
+					"filing_date_start": {
+						"type": "string",
+						"description": "Optional: Earliest filing date (YYYY-MM-DD)"
+					},
+					"filing_date_end": {
+						"type": "string",
+						"description": "Optional: Latest filing date (YYYY-MM-DD)"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for thorough patent coverage. Use 'sonar' for quick lookups of a known patent.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of the default patent databases"
+					},
+					"search_recency_filter": {
+						"type": "string",
+						"description": "Time-based filter on when pages were published (use filing_date_start/filing_date_end for filing dates)",
+						"enum": ["hour", "day", "week", "month", "year"]
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties()...),
+		},
+		{
+			Name:        "perplexity_legal_search",
+			Description: "Search case law, statutes, and court decisions. Searches court and legal databases for the jurisdiction (CourtListener, Cornell LII, BAILII, EUR-Lex, CanLII, AustLII, ...) and asks for full case citations. Results open with a not-legal-advice disclaimer. Best for: legal research, precedent, statutory interpretation.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The legal research question. Name the legal issue, statute, or case of interest."
+					},
+					"jurisdiction": {
+						"type": "string",
+						"description": "Optional: Jurisdiction, e.g. 'US', 'UK', 'EU', 'Canada', 'Australia', 'India', or a state such as 'California'. Known jurisdictions select their court and legislation sources"
+					},
+					"court": {
+						"type": "string",
+						"description": "Optional: Court, e.g. 'Supreme Court', '9th Circuit', 'Court of Appeal'"
+					},
+					"date_range_start": {
+						"type": "string",
+						"description": "Optional: Earliest decision date (YYYY-MM-DD)"
+					},
+					"date_range_end": {
+						"type": "string",
+						"description": "Optional: Latest decision date (YYYY-MM-DD)"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for thorough legal research. Use 'sonar' for quick lookups of a known case.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of the default legal sources"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties()...),
+		},
+		{
+			Name:        "perplexity_medical_search",
+			Description: "Search clinical and biomedical evidence. Searches PubMed, Cochrane, NEJM, and other major journals and guideline bodies by default, and lists each source with its study design and level of evidence. Best for: treatment evidence, clinical trials, systematic reviews, guidelines.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The clinical question. Include the condition, intervention, and outcome of interest."
+					},
+					"study_type": {
+						"type": "string",
+						"enum": ["meta-analysis", "systematic-review", "rct", "cohort", "case-control", "cross-sectional", "case-report", "guideline"],
+						"description": "Optional: Study design to focus on"
+					},
+					"population": {
+						"type": "string",
+						"description": "Optional: Patient population, e.g. 'adults over 65', 'children with asthma', 'pregnant women'"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for thorough evidence review. Use 'sonar' for quick lookups.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of the default medical sources"
+					},
+					"date_range_start": {
+						"type": "string",
+						"description": "Start date for publications (YYYY-MM-DD)"
+					},
+					"date_range_end": {
+						"type": "string",
+						"description": "End date for publications (YYYY-MM-DD)"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter")...),
+		},
+		{
+			Name:        "perplexity_product_search",
+			Description: "Compare products for a purchase. Answers with a comparison table of products, prices, key features, and where to buy, followed by a recommendation, and includes product images when available. Best for: shopping research, choosing between models, finding options within a budget.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "What you want to buy or compare, e.g. 'noise-cancelling headphones for travel'"
+					},
+					"category": {
+						"type": "string",
+						"description": "Optional: Product category, e.g. 'laptops', 'espresso machines'"
+					},
+					"budget": {
+						"type": "string",
+						"description": "Optional: Budget, e.g. 'under $300', '€500-800'"
+					},
+					"region": {
+						"type": "string",
+						"description": "Optional: Country or region to buy in, used for prices, availability, and retailers (e.g. 'US', 'UK', 'Germany')"
+					},
+					"must_have_features": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Optional: Features every product must have, e.g. ['USB-C charging', 'at least 16GB RAM']"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for broader product coverage. Use 'sonar' for a quick price check.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"return_images": {
+						"type": "boolean",
+						"description": "Include product images (default: true)"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Limit search to specific retailers or review sites"
+					},
+					"search_exclude_domains": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Exclude specific domains from results"
+					},
+					"search_recency_filter": {
+						"type": "string",
+						"description": "Time-based filter; 'month' keeps prices current",
+						"enum": ["hour", "day", "week", "month", "year"]
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties()...),
+		},
+		{
+			Name:        "perplexity_people_search",
+			Description: "Research professionals by name, role, company, and location. Searches LinkedIn, Crunchbase, The Org, GitHub, and the company's own site by default, and returns public professional information only. Best for: recruiting, sales and partnership prospecting, finding the right contact at a company.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "What you want to know, e.g. 'background and recent talks' or 'who leads platform engineering'"
+					},
+					"name": {
+						"type": "string",
+						"description": "Person's name. Either name or role is required"
+					},
+					"role": {
+						"type": "string",
+						"description": "Job title or function, e.g. 'VP Engineering', 'head of procurement'"
+					},
+					"company": {
+						"type": "string",
+						"description": "Optional: Current or former employer"
+					},
+					"company_domain": {
+						"type": "string",
+						"description": "Optional: Company website (e.g. 'acme.com'), added to the searched domains"
+					},
+					"location": {
+						"type": "string",
+						"description": "Optional: City, region, or country"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro'. Use 'sonar' for quick lookups.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of the defaults"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter")...),
+		},
+		{
+			Name:        "perplexity_company_search",
+			Description: "Research a company: overview, funding, open jobs, leadership, or recent news. Searches sources suited to the focus (Crunchbase, LinkedIn, job boards, press wires) plus the company's own site. Best for: business development, due diligence, job hunting, competitor tracking.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "What you want to know about the company"
+					},
+					"company": {
+						"type": "string",
+						"description": "Company name. Either company or company_domain is required"
+					},
+					"company_domain": {
+						"type": "string",
+						"description": "Company website (e.g. 'acme.com'), added to the searched domains"
+					},
+					"focus": {
+						"type": "string",
+						"enum": ["overview", "funding", "jobs", "leadership", "news"],
+						"description": "What to research (default: overview). 'jobs' searches job boards for open positions",
+						"default": "overview"
+					},
+					"role": {
+						"type": "string",
+						"description": "Optional: With focus 'jobs', the kind of role to look for, e.g. 'backend engineer'"
+					},
+					"location": {
+						"type": "string",
+						"description": "Optional: City, region, or country, e.g. for job postings or regional offices"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro'. Use 'sonar' for quick lookups.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of the defaults"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter")...),
+		},
+		{
+			Name:        "perplexity_travel_search",
+			Description: "Plan a trip. Takes origin, destination, travel dates, and constraints and returns an itinerary: getting there, a day-by-day plan, where to stay, and practical notes such as entry requirements, weather, and events during the dates. Best for: trip planning, weekend breaks, business travel.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "What kind of trip, e.g. 'food-focused long weekend' or 'family holiday with a day at the beach'"
+					},
+					"destination": {
+						"type": "string",
+						"description": "City, region, or country to visit"
+					},
+					"origin": {
+						"type": "string",
+						"description": "Optional: Where the trip starts, for travel options to the destination"
+					},
+					"travel_date_start": {
+						"type": "string",
+						"description": "Optional: First day of the trip (YYYY-MM-DD)"
+					},
+					"travel_date_end": {
+						"type": "string",
+						"description": "Optional: Last day of the trip (YYYY-MM-DD); at most 30 days after the start"
+					},
+					"constraints": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Optional: Requirements such as 'budget under $2000', 'vegetarian', 'no flights', 'wheelchair accessible'"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for detailed itineraries. Use 'sonar' for quick answers.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_recency_filter": {
+						"type": "string",
+						"description": "Time-based filter (default: 'year' so prices and opening hours are current)",
+						"enum": ["hour", "day", "week", "month", "year"]
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Limit search to specific travel sites"
+					}
+				},
+				"required": ["query", "destination"]
+			}`, answerProperties()...),
+		},
+		{
+			Name:        "perplexity_dev_search",
+			Description: "Programming search biased to GitHub, Stack Overflow, and official documentation. Returns version-accurate answers with code in fenced blocks, kept verbatim. Best for: API usage, error messages, migration between versions, library comparisons.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The programming question or error message"
+					},
+					"language": {
+						"type": "string",
+						"description": "Programming language, e.g. 'go', 'python', 'typescript'. Adds its official docs to the searched domains"
+					},
+					"framework": {
+						"type": "string",
+						"description": "Framework or library, e.g. 'react', 'django'. Adds its official docs to the searched domains"
+					},
+					"version": {
+						"type": "string",
+						"description": "Version of the language or framework the answer must work with, e.g. '1.23' or '5.0'"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for detailed answers. Use 'sonar' for quick answers.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of GitHub, Stack Overflow, and the official docs"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter")...),
+		},
+		{
+			Name:        "perplexity_competitors",
+			Description: "Map a company's or product's competitive landscape. Searches each company for pricing, features, funding, and recent news in parallel and merges the findings into a comparison matrix, followed by each company's detailed findings with sources. Competitors are found automatically unless given. Costs one quick 'sonar' call per company per scope.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"company": {
+						"type": "string",
+						"description": "The company or product to analyze, e.g. 'Notion'"
+					},
+					"competitors": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Optional: Competitors to compare. If omitted, the main competitors are identified first"
+					},
+					"scopes": {
+						"type": "array",
+						"items": {"type": "string", "enum": ["pricing", "features", "funding", "news"]},
+						"description": "Matrix columns to research (default: all four)"
+					},
+					"max_competitors": {
+						"type": "number",
+						"description": "Maximum competitors to compare, 1 to 6 (default: 4)"
+					},
+					"query": {
+						"type": "string",
+						"description": "Optional: Extra context for identifying competitors, e.g. 'in the team wiki market'"
+					},
+					"location": {
+						"type": "string",
+						"description": "Market to focus on, e.g. 'Germany'"
+					}
+				},
+				"required": ["company"]
+			}`),
+		},
+		{
+			Name:        "perplexity_trend",
+			Description: "Track how a topic changed over time. Runs the query once per date window (e.g. each of the last 6 months) with quick 'sonar' searches, then synthesizes how coverage and sentiment shifted, returning a timeline table, per-window summaries, and a trend analysis. Costs one API call per window plus one. Best for: how opinion on X evolved, when interest in Y peaked.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The topic to track, e.g. 'public sentiment on remote work'"
+					},
+					"period": {
+						"type": "string",
+						"description": "Length of each window (default: month)",
+						"enum": ["week", "month", "quarter", "year"],
+						"default": "month"
+					},
+					"windows": {
+						"type": "number",
+						"description": "Number of consecutive windows ending today, 2 to 12 (default: 6)"
+					},
+					"model": {
+						"type": "string",
+						"description": "Model for the final analysis; window searches always use 'sonar'. Defaults to 'sonar-pro'.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Limit every window to specific domains, e.g. news sites"
+					},
+					"location": {
+						"type": "string",
+						"description": "Location for localized coverage"
+					},
+					"retry_on_empty": {
+						"type": "boolean",
+						"description": "Retry the analysis once with a rephrased prompt if it is empty or a refusal (default: true)"
+					},
+					"max_tokens": {
+						"type": "number",
+						"description": "Maximum tokens in the analysis"
+					}
+				},
+				"required": ["query"]
+			}`, "deep_sources", "confidence", "separate_opinions", "extract_tables", "timezone", "answer_language", "timeout_seconds", "project"),
+		},
+		{
+			Name:        "perplexity_security_search",
+			Description: "Vulnerability and security advisory search over NVD, CVE.org, CISA, GitHub advisories, OSV, and vendor advisories. Answers list affected versions, CVSS scores, exploitation status, and fixes, end with mitigation steps, and add an Advisories table with CVSS severity and NVD links. Best for: looking up a CVE, checking a product's exposure, triaging recent critical vulnerabilities.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The security question, e.g. 'is OpenSSH on Ubuntu 22.04 affected' or 'recent critical vulnerabilities'"
+					},
+					"cve_id": {
+						"type": "string",
+						"description": "Optional: CVE to focus on, e.g. 'CVE-2024-3094'"
+					},
+					"product": {
+						"type": "string",
+						"description": "Optional: Affected product or package, with version if known, e.g. 'xz-utils 5.6.0'"
+					},
+					"severity": {
+						"type": "string",
+						"description": "Optional: Minimum severity to include",
+						"enum": ["low", "medium", "high", "critical"]
+					},
+					"date_range_start": {
+						"type": "string",
+						"description": "Published on or after (YYYY-MM-DD)"
+					},
+					"date_range_end": {
+						"type": "string",
+						"description": "Published on or before (YYYY-MM-DD)"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro' for detailed advisories. Use 'sonar' for quick lookups.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Domains to search instead of the vulnerability databases and vendor advisories"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_recency_filter")...),
+		},
+		{
+			Name:        "perplexity_release_watch",
+			Description: "Check a software project for new releases, changelog entries, and security advisories. Answers with the latest version and date, notable changes, breaking changes, and CVEs. Searches the last day by default (widened to the last month if nothing is found), so repeated calls work as a watch; with caching enabled the digest highlights what changed between runs.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"project": {
+						"type": "string",
+						"description": "Project name, e.g. 'Kubernetes' or 'PostgreSQL'. Required unless repo is given"
+					},
+					"repo": {
+						"type": "string",
+						"description": "Repository as GitHub owner/name (e.g. 'golang/go') or a repository URL"
+					},
+					"query": {
+						"type": "string",
+						"description": "Optional focus, e.g. 'security fixes in the 1.x line'. Defaults to 'Latest release of <project>' so repeated watches are compared"
+					},
+					"model": {
+						"type": "string",
+						"description": "Defaults to 'sonar-pro'. Use 'sonar' for cheaper checks.",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_recency_filter": {
+						"type": "string",
+						"description": "Time-based filter (default: 'day', widened to 'month' if the day has no sources)",
+						"enum": ["hour", "day", "week", "month", "year"]
+					},
+					"search_domain_filter": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Limit search to specific domains, e.g. the project's website"
+					}
+				}
+			}`, "retry_on_empty", "deep_sources", "confidence", "separate_opinions", "extract_tables", "timezone", "answer_language", "timeout_seconds", "max_tokens"),
+		},
+		{
+			Name:        "perplexity_local_now",
+			Description: "Quick, low-cost answers about what is happening near a place right now: weather, traffic, transit disruptions, events, openings, and local news. Always uses 'sonar' with sources from the last hour (widened to the last day if the hour has none) and short answers. Best for: 'is it raining in X', 'what's on near Y tonight', 'are trains running from Z'.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The question, e.g. 'current weather and any warnings' or 'live music tonight'"
+					},
+					"location": {
+						"type": "string",
+						"description": "Where: a neighbourhood, city, venue, or address"
+					},
+					"max_tokens": {
+						"type": "number",
+						"description": "Maximum tokens in response (default: 400)"
+					}
+				},
+				"required": ["query", "location"]
+			}`, "retry_on_empty"),
+		},
+		{
+			Name:        "perplexity_filtered_search",
+			Description: "Advanced search with multiple filters. Best for: specific requirements, domain-specific searches, content type filtering, location-based searches. Use when other specialized searches don't fit your needs.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The search query"
+					},
+					"model": {
+						"type": "string",
+						"description": "Choose based on needs: 'sonar' for quick filtered searches, 'sonar-pro' for comprehensive filtered results",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar-pro"
+					},
+					"search_exclude_domains": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "List of domains to exclude"
+					},
+					"content_type": {
+						"type": "string",
+						"description": "Type of content (news, academic, blog, etc.)"
+					},
+					"file_type": {
+						"type": "string",
+						"description": "File type filter (pdf, doc, html, etc.)"
+					},
+					"language": {
+						"type": "string",
+						"description": "Language filter"
+					},
+					"country": {
+						"type": "string",
+						"description": "Country for geo-specific search"
+					},
+					"date_range_start": {
+						"type": "string",
+						"description": "Start date (YYYY-MM-DD)"
+					},
+					"date_range_end": {
+						"type": "string",
+						"description": "End date (YYYY-MM-DD)"
+					},
+					"return_images": {
+						"type": "boolean",
+						"description": "Include images"
+					},
+					"custom_filters": {
+						"type": "object",
+						"description": "Additional custom filters as key-value pairs"
+					}
+				},
+				"required": ["query"]
+			}`, answerProperties("search_mode", "search_domain_filter", "search_recency_filter", "return_related_questions", "temperature")...),
+		},
+		{
+			Name:        "perplexity_search_with_context",
+			Description: "Answer a question grounded in a supplied document (pasted text or a local file path) as well as current web sources. Best for: checking a draft, report, or notes against the web, or asking questions about a document that need up-to-date context.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The question to answer with respect to the document and the web"
+					},
+					"document": {
+						"type": "string",
+						"description": "Pasted document text to ground the answer in"
+					},
+					"file_path": {
+						"type": "string",
+						"description": "Path to a local UTF-8 text file to ground the answer in, absolute or relative to the server's document folder. Only files inside that folder can be read"
+					},
+					"model": {
+						"type": "string",
+						"description": "Choose 'sonar' for quick answers or 'sonar-pro' for more thorough grounding",
+						"enum": ["sonar", "sonar-pro", "auto"],
+						"default": "sonar"
+					}
+				},
+				"required": ["query"]
+			}`, "examples", "context_refs", "search_domain_filter", "search_recency_filter", "max_tokens"),
+		},
+		{
+			Name:        "perplexity_compare_models",
+			Description: "Run the same query against two models concurrently and return a side-by-side comparison with latency, token usage, citation counts, and both answers. Best for: deciding whether 'sonar-pro' or 'sonar-reasoning' is worth the extra cost for a workload.",
+			InputSchema: withShared(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "The search query to run against both models"
+					},
+					"models": {
+						"type": "array",
+						"items": {"type": "string", "enum": ["sonar", "sonar-pro", "sonar-reasoning"]},
+						"minItems": 2,
+						"maxItems": 2,
+						"description": "The two models to compare (default: ['sonar', 'sonar-pro'])"
+					},
+					"max_tokens": {
+						"type": "number",
+						"description": "Maximum tokens in each response"
+					}
+				},
+				"required": ["query"]
+			}`, "search_domain_filter", "search_recency_filter"),
+		},
+		{
+			Name:        "verify_result",
+			Description: "Fact-check a cached result: extracts its key claims and runs a targeted verification search for each, returning a table of claims marked supported, contradicted, or unclear with the checking sources. Requires result caching.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"result_id": {
+						"type": "string",
+						"description": "The unique 10-character ID of the cached result to verify"
+					},
+					"max_claims": {
+						"type": "number",
+						"description": "Maximum number of claims to check (default: 5, at most 10)",
+						"maximum": 10
+					}
+				},
+				"required": ["result_id"]
+			}`),
+		},
+		{
+			Name:        "translate_result",
+			Description: "Translate the answer of a cached result into another language, keeping markdown, citation markers, and source URLs intact. The translation is cached as a new result linked to the original. Requires result caching.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"result_id": {
+						"type": "string",
+						"description": "The unique 10-character ID of the cached result to translate"
+					},
+					"target_language": {
+						"type": "string",
+						"description": "Language to translate into, e.g. 'German', 'Japanese', or 'pt-BR'"
+					}
+				},
+				"required": ["result_id", "target_language"]
+			}`),
+		},
+		{
+			Name:        "check_links",
+			Description: "Check that the source URLs of a cached result are still reachable. Returns a status table and a copy of the result with dead links marked, optionally pointing them at Wayback Machine snapshots. The report is cached as a new result linked to the original. Requires result caching.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"result_id": {
+						"type": "string",
+						"description": "The unique 10-character ID of the cached result to check"
+					},
+					"use_archive": {
+						"type": "boolean",
+						"description": "Replace dead links with their archive.org snapshot where one exists (default: false)"
+					}
+				},
+				"required": ["result_id"]
+			}`),
+		},
+		{
+			Name:        "publish_result",
+			Description: "Publish a cached result to the team wiki configured on the server (a Notion database or a Confluence space). The page gets the query as its title, the answer as its body, a Sources list, and the result's keywords and entities as tags. Returns the URL of the new page. Requires result caching.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"result_id": {
+						"type": "string",
+						"description": "The unique 10-character ID of the cached result to publish"
+					},
+					"target": {
+						"type": "string",
+						"enum": ["notion", "confluence"],
+						"description": "Where to publish. Optional when only one target is configured"
+					}
+				},
+				"required": ["result_id"]
+			}`),
+		},
+		{
+			Name:        "run_template",
+			Description: "Run a saved query template: a search with {{placeholders}} that are filled from the given variables, so recurring research (e.g. a weekly competitor check) is one call. Templates come from the server's PERPLEXITY_TEMPLATES_FILE and the templates.json file of the results folder. {{date}} is today's date unless given. The result is that of the template's tool; an unknown name lists the available templates.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "Name of the template to run"
+					},
+					"variables": {
+						"type": "object",
+						"additionalProperties": {"type": "string"},
+						"description": "Values of the template's placeholders, e.g. {\"company\": \"Nvidia\"}"
+					},
+					"arguments": {
+						"type": "object",
+						"description": "Tool arguments that replace the template's own, e.g. {\"model\": \"sonar-pro\"}"
+					}
+				},
+				"required": ["name"]
+			}`),
+		},
+		{
+			Name:        "save_profile",
+			Description: "Save a named bundle of tool arguments (model, filters, domains, recency, formatting) as a profile, so later searches can reuse it with run_profile instead of repeating long arguments. Saving under an existing name replaces that profile. Requires result caching, since profiles are kept in the results folder.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "Profile name (letters, digits, - and _)"
+					},
+					"tool": {
+						"type": "string",
+						"description": "Tool the profile runs (default: perplexity_search)"
+					},
+					"arguments": {
+						"type": "object",
+						"description": "Arguments of the tool to save, without the query, e.g. {\"model\": \"sonar-pro\", \"search_domain_filter\": [\"nature.com\"], \"search_recency_filter\": \"month\"}"
+					},
+					"description": {
+						"type": "string",
+						"description": "What the profile is for, shown when profiles are listed"
+					}
+				},
+				"required": ["name", "arguments"]
+			}`),
+		},
+		{
+			Name:        "run_profile",
+			Description: "Run a query with the arguments saved in a profile by save_profile. The result is that of the profile's tool; an unknown name lists the saved profiles.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"name": {
+						"type": "string",
+						"description": "Name of the profile to apply"
+					},
+					"query": {
+						"type": "string",
+						"description": "The query to run with the profile's arguments"
+					},
+					"arguments": {
+						"type": "object",
+						"description": "Tool arguments that replace the profile's own for this call"
+					}
+				},
+				"required": ["name", "query"]
+			}`),
+		},
+		{
+			Name:        "list_previous",
+			Description: "List previous search queries with their unique IDs, sorted by recency. Returns JSON array with query details, including the keywords and entities (companies, people, technologies) extracted from each answer. Can be filtered by entity or keyword. Lists the default results folder unless a project is given.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"entity": {
+						"type": "string",
+						"description": "Only list results tagged with this entity, e.g. 'Nvidia' or 'Jensen Huang' (case-insensitive)"
+					},
+					"keyword": {
+						"type": "string",
+						"description": "Only list results tagged with this keyword, e.g. 'semiconductors' (case-insensitive)"
+					},
+					"project": {
+						"type": "string",
+						"description": "List the results cached under this project instead of the default folder"
+					}
+				},
+				"required": []
+			}`),
+		},
+		{
+			Name:        "get_previous_result",
+			Description: "Retrieve a previously cached search result by its unique ID. Long results can be read in pieces with offset and chunk_size.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"unique_id": {
+						"type": "string",
+						"description": "The unique 10-character alphanumeric ID of the cached result to retrieve"
+					},
+					"offset": {
+						"type": "number",
+						"description": "Byte offset to start reading from, as given by the previous chunk's continuation footer (default: 0)"
+					},
+					"chunk_size": {
+						"type": "number",
+						"description": "Maximum bytes to return. Use for long reports that exceed your context; omit to return the whole result"
+					}
+				},
+				"required": ["unique_id"]
+			}`),
+		},
+		{
+			Name:        "perplexity_usage",
+			Description: "Report how much of their quotas the REST API clients have used: tool calls in the last minute and today against each client's rate limit and daily budget, and calls rejected today, plus the hit rate of the in-memory result cache. Over HTTP a client sees only its own usage. Calls to this tool don't count against the quotas.",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {},
+				"required": []
+			}`),
+		},
+	}
+})
+
+// sharedProperties are the schema properties of arguments that many tools accept with the
+// same meaning, so each is described in one place
+var sharedProperties = map[string]string{
+	"search_mode": `{
+		"type": "string",
+		"description": "Search index to use: 'web' (default) for general web results, 'academic' for scholarly sources, 'sec' for SEC filings",
+		"enum": ["web", "academic", "sec"]
+	}`,
+	"search_domain_filter": `{
+		"type": "array",
+		"items": {"type": "string"},
+		"description": "List of domains to include"
+	}`,
+	"search_recency_filter": `{
+		"type": "string",
+		"description": "Time-based filter",
+		"enum": ["hour", "day", "week", "month", "year"]
+	}`,
+	"return_related_questions": `{
+		"type": "boolean",
+		"description": "Include related questions"
+	}`,
+	"examples": `{
+		"type": "array",
+		"items": {
+			"type": "object",
+			"properties": {
+				"question": {"type": "string"},
+				"answer": {"type": "string"}
 			},
+			"required": ["question", "answer"]
 		},
-	}, nil
-}
\ No newline at end of file
+		"description": "Few-shot question/answer pairs sent before the query to steer answer structure (e.g. always answer with a table). Overrides examples configured for this tool; pass [] to disable them"
+	}`,
+	"context_refs": `{
+		"type": "array",
+		"items": {"type": "string"},
+		"description": "Material to ground the search in: cached result IDs, perplexity://results/<ID> resource URIs, or file:// paths inside the server's document folder"
+	}`,
+	"min_citations": `{
+		"type": "number",
+		"description": "Minimum number of cited sources. If the answer has fewer, the search is retried once with a larger search context (and sonar-pro); the result notes if the requirement still isn't met"
+	}`,
+	"retry_on_empty": `{
+		"type": "boolean",
+		"description": "Retry once with a rephrased prompt if the answer is empty or a refusal (default: true)"
+	}`,
+	"deep_sources": `{
+		"type": "boolean",
+		"description": "Read the top cited pages (respecting robots.txt) and append the passages most relevant to the query as an Extended Evidence section (default: false)"
+	}`,
+	"confidence": `{
+		"type": "string",
+		"description": "Annotate each paragraph of the answer with a confidence level: 'citations' rates paragraphs by how many of their sentences cite a source, 'model' makes a second call that rates the key claims, 'off' adds nothing (default: PERPLEXITY_CONFIDENCE, off unless set)",
+		"enum": ["off", "citations", "model"]
+	}`,
+	"separate_opinions": `{
+		"type": "boolean",
+		"description": "Rework the answer, with a cheap second call, into 'Established Facts' backed by its cited sources and 'Analysis and Opinion', so reports can cite responsibly (default: PERPLEXITY_SEPARATE_OPINIONS, false unless set)"
+	}`,
+	"extract_tables": `{
+		"type": "boolean",
+		"description": "Extract the answer's tables and lists of numbers (market sizes, benchmark results) as CSV and JSON files saved in the result folder and listed under paths.table_files, or as a JSON section when results are not cached (default: false)"
+	}`,
+	"timezone": `{
+		"type": "string",
+		"description": "IANA time zone of the user (e.g. Europe/Berlin, America/New_York). Date ranges such as 'last week' are computed on its calendar and times in the answer and footer are given in it (default: PERPLEXITY_TIMEZONE, the server's zone unless set)"
+	}`,
+	"answer_language": `{
+		"type": "string",
+		"description": "Language to answer in, as a name or code (e.g. German, ja, pt-BR). Without it the answer is expected in the language of the query"
+	}`,
+	"timeout_seconds": `{
+		"type": "number",
+		"description": "Seconds to wait for each API call of this search, replacing PERPLEXITY_TIMEOUT. Raise it for long sonar-pro or reasoning answers"
+	}`,
+	"project": `{
+		"type": "string",
+		"description": "Project to cache the result under, in its own results folder, so separate research efforts stay apart (letters, digits, - and _)"
+	}`,
+	"max_tokens": `{
+		"type": "number",
+		"description": "Maximum tokens in response"
+	}`,
+	"temperature": `{
+		"type": "number",
+		"description": "Response randomness (0-2)"
+	}`,
+}
+
+// answerProperties returns the names of the shared properties of the search tools that
+// return one answer, followed by extra
+func answerProperties(extra ...string) []string {
+	return append([]string{
+		"examples", "context_refs", "min_citations", "retry_on_empty", "deep_sources", "confidence", "separate_opinions",
+		"extract_tables", "timezone", "answer_language", "timeout_seconds", "project", "max_tokens",
+	}, extra...)
+}
+
+// withShared returns a tool's input schema with the named shared properties added to its own.
+// It panics on a schema or name that is not valid, as the tool list is fixed at build time.
+func withShared(schema string, names ...string) json.RawMessage {
+	var s map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		panic(fmt.Sprintf("invalid tool schema: %v", err))
+	}
+	properties := map[string]json.RawMessage{}
+	if err := json.Unmarshal(s["properties"], &properties); err != nil {
+		panic(fmt.Sprintf("invalid tool schema properties: %v", err))
+	}
+	for _, name := range names {
+		property, ok := sharedProperties[name]
+		if !ok {
+			panic("unknown shared property " + name)
+		}
+		properties[name] = json.RawMessage(property)
+	}
+	var err error
+	if s["properties"], err = json.Marshal(properties); err != nil {
+		panic(fmt.Sprintf("invalid shared property: %v", err))
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		panic(fmt.Sprintf("invalid tool schema: %v", err))
+	}
+	return data
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSharedProperties(t *testing.T) {
+	h := &Handler{}
+	list, err := h.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+
+	used := map[string]int{}
+	for _, tool := range list.Tools {
+		var schema struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(tool.InputSchema, &schema); err != nil {
+			t.Fatalf("%s: invalid schema: %v", tool.Name, err)
+		}
+		for name, property := range schema.Properties {
+			shared, ok := sharedProperties[name]
+			if !ok {
+				continue
+			}
+			var want, got interface{}
+			if err := json.Unmarshal([]byte(shared), &want); err != nil {
+				t.Fatalf("Shared property %s is not valid JSON: %v", name, err)
+			}
+			if json.Unmarshal(property, &got) == nil && reflect.DeepEqual(got, want) {
+				used[name]++
+			}
+		}
+	}
+	for name := range sharedProperties {
+		if used[name] < 2 {
+			t.Errorf("Shared property %s is used by %d tool(s); describe it in the tool's own schema", name, used[name])
+		}
+	}
+	if used["deep_sources"] != 15 {
+		t.Errorf("Expected deep_sources in 15 tool schemas, got %d", used["deep_sources"])
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/quota"
+)
+
+// handleUsage reports the quota usage of the REST API clients and the hit rate of the
+// in-memory result cache. A call made by a client over HTTP sees only its own row.
+func (h *Handler) handleUsage(ctx context.Context) (string, error) {
+	var b strings.Builder
+	b.WriteString("# Client Usage\n\n")
+	usage := h.clients.Usage()
+	if len(usage) == 0 {
+		b.WriteString("No REST API clients are configured, so no per-client usage is tracked. Set PERPLEXITY_CLIENTS_FILE to give each client a token and quotas.\n")
+	} else {
+		if caller := quota.ClientFromContext(ctx); caller != "" {
+			var own []quota.Usage
+			for _, u := range usage {
+				if u.Client == caller {
+					own = append(own, u)
+				}
+			}
+			usage = own
+		}
+		b.WriteString("| Client | Last minute | Today | Rejected today | Since start |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, u := range usage {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %d |\n", u.Client, ofQuota(u.LastMinute, u.RateLimit), ofQuota(u.Today, u.DailyRequests), u.RejectedToday, u.Total)
+		}
+	}
+
+	b.WriteString("\n# In-Memory Result Cache\n\n")
+	b.WriteString(h.memoryCacheSummary())
+	return b.String(), nil
+}
+
+// memoryCacheSummary reports how many of the cached results read since the server started
+// were served from memory rather than the results folder
+func (h *Handler) memoryCacheSummary() string {
+	if !h.searcher.CachingEnabled() {
+		return "Results are not cached. Set PERPLEXITY_RESULTS_ROOT_FOLDER to enable caching.\n"
+	}
+	stats := h.searcher.MemoryCacheStats()
+	if stats.Capacity == 0 {
+		return "Off: PERPLEXITY_RESULT_CACHE_SIZE is 0, so every cached result is read from the results folder.\n"
+	}
+	lookups := stats.Hits + stats.Misses
+	if lookups == 0 {
+		return fmt.Sprintf("Holds %d of up to %d results. No cached results have been read since the server started.\n", stats.Size, stats.Capacity)
+	}
+	return fmt.Sprintf("Holds %d of up to %d results. Of %d cached result reads since the server started, %d came from memory and %d from the results folder: a hit rate of %.1f%%.\n",
+		stats.Size, stats.Capacity, lookups, stats.Hits, stats.Misses, 100*stats.HitRate())
+}
+
+// ofQuota shows a count against its quota, where zero means unlimited
+func ofQuota(used, max int) string {
+	if max <= 0 {
+		return fmt.Sprintf("%d (unlimited)", used)
+	}
+	return fmt.Sprintf("%d / %d", used, max)
+}
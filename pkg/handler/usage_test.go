@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+)
+
+func TestUsageTool(t *testing.T) {
+	h, err := NewHandler(&config.Config{APIKey: "test-api-key"}, false)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	call := func(ctx context.Context) string {
+		resp, err := h.CallTool(ctx, &protocol.CallToolRequest{Name: "perplexity_usage", Arguments: map[string]interface{}{}})
+		if err != nil || resp.IsError {
+			t.Fatalf("perplexity_usage failed: %v %+v", err, resp)
+		}
+		return resp.Content[0].Text
+	}
+
+	if got := call(context.Background()); !strings.Contains(got, "PERPLEXITY_CLIENTS_FILE") {
+		t.Errorf("Expected a note that no clients are configured, got %q", got)
+	}
+
+	clients := quota.NewTracker(map[string]config.ClientConfig{
+		"ci":       {Token: "ci-secret", RateLimit: 10, DailyRequests: 500},
+		"notebook": {Token: "nb-secret"},
+	}, nil)
+	clients.Allow("ci")
+	h.SetClients(clients)
+
+	all := call(context.Background())
+	for _, want := range []string{"| ci | 1 / 10 | 1 / 500 | 0 | 1 |", "| notebook | 0 (unlimited) | 0 (unlimited) | 0 | 0 |"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Usage missing %q:\n%s", want, all)
+		}
+	}
+	if own := call(quota.WithClient(context.Background(), "notebook")); strings.Contains(own, "| ci |") || !strings.Contains(own, "| notebook |") {
+		t.Errorf("Expected a client to see only its own usage, got:\n%s", own)
+	}
+}
+
+func TestUsageToolMemoryCache(t *testing.T) {
+	usage := func(cfg *config.Config) (*Handler, func() string) {
+		h, err := NewHandler(cfg, false)
+		if err != nil {
+			t.Fatalf("NewHandler failed: %v", err)
+		}
+		return h, func() string {
+			got, err := h.handleUsage(context.Background())
+			if err != nil {
+				t.Fatalf("handleUsage failed: %v", err)
+			}
+			return got
+		}
+	}
+
+	if _, call := usage(&config.Config{APIKey: "test-api-key"}); !strings.Contains(call(), "Results are not cached") {
+		t.Errorf("Expected a note that caching is off, got:\n%s", call())
+	}
+	root := t.TempDir()
+	if _, call := usage(&config.Config{APIKey: "test-api-key", ResultsRootFolder: root}); !strings.Contains(call(), "PERPLEXITY_RESULT_CACHE_SIZE is 0") {
+		t.Errorf("Expected a note that the memory cache is off, got:\n%s", call())
+	}
+
+	h, call := usage(&config.Config{APIKey: "test-api-key", ResultsRootFolder: root, ResultCacheSize: 4})
+	if got := call(); !strings.Contains(got, "Holds 0 of up to 4 results. No cached results have been read") {
+		t.Errorf("Expected an empty memory cache, got:\n%s", got)
+	}
+	id, err := cache.SaveResult(root, "q", "general", "sonar", "answer", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := h.searcher.GetPreviousResult(context.Background(), id); err != nil {
+			t.Fatalf("GetPreviousResult failed: %v", err)
+		}
+	}
+	want := "Holds 1 of up to 4 results. Of 4 cached result reads since the server started, 3 came from memory and 1 from the results folder: a hit rate of 75.0%."
+	if got := call(); !strings.Contains(got, want) {
+		t.Errorf("Usage missing %q:\n%s", want, got)
+	}
+}
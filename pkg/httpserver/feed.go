@@ -0,0 +1,160 @@
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/search"
+)
+
+const (
+	// feedWindow is how far back the feed looks for cached results
+	feedWindow = 30 * 24 * time.Hour
+	// feedLimit bounds the number of entries in the feed
+	feedLimit = 50
+)
+
+// atomFeed is an Atom 1.0 feed document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title    string         `xml:"title"`
+	ID       string         `xml:"id"`
+	Updated  string         `xml:"updated"`
+	Link     *atomLink      `xml:"link,omitempty"`
+	Category []atomCategory `xml:"category"`
+	Content  atomContent    `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// handleFeed serves an Atom feed of the results cached in the last 30 days, newest first.
+// Queries that were run before are watch updates, titled by whether their answer or sources
+// changed since the previous run. Readers authenticate with the feed token, as a bearer
+// token or a token query parameter since many feed readers can only set the URL.
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.config().FeedToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="perplexity feed"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	feed := atomFeed{
+		Title:   "Perplexity results",
+		ID:      "urn:perplexity:feed",
+		Updated: now.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "perplexity"},
+	}
+	if cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		entries, err := search.BuildDigest(s.config().ResultsRootFolder, now.Add(-feedWindow))
+		if err != nil {
+			slog.Error("failed to build feed", "error", err)
+			http.Error(w, "failed to build feed", http.StatusInternalServerError)
+			return
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].LatestTime.After(entries[j].LatestTime)
+		})
+		if len(entries) > feedLimit {
+			entries = entries[:feedLimit]
+		}
+		if len(entries) > 0 {
+			feed.Updated = entries[0].LatestTime.UTC().Format(time.RFC3339)
+		}
+		for _, e := range entries {
+			feed.Entries = append(feed.Entries, s.feedEntry(r, e))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		slog.Error("failed to write feed", "error", err)
+	}
+}
+
+// feedEntry describes one cached result, linking to the web UI when it is enabled
+func (s *Server) feedEntry(r *http.Request, e search.DigestEntry) atomEntry {
+	title := e.Query
+	switch {
+	case e.Watched() && (e.Changed || len(e.AddedSources) > 0 || len(e.DroppedSources) > 0):
+		title = "Changed: " + e.Query
+	case e.Watched():
+		title = "Unchanged: " + e.Query
+	}
+
+	var b strings.Builder
+	if e.Summary != "" {
+		b.WriteString(e.Summary + "\n")
+	}
+	fmt.Fprintf(&b, "\nResult ID: %s (%s search", e.LatestID, e.SearchType)
+	if e.Watched() {
+		fmt.Fprintf(&b, ", run %d times", e.Runs)
+	}
+	b.WriteString(")\n")
+	if e.Watched() && e.Changed {
+		b.WriteString("Answer changed since the previous run\n")
+	}
+	for _, source := range e.AddedSources {
+		fmt.Fprintf(&b, "+ %s\n", source)
+	}
+	for _, source := range e.DroppedSources {
+		fmt.Fprintf(&b, "- %s\n", source)
+	}
+
+	entry := atomEntry{
+		Title:    title,
+		ID:       "urn:perplexity:result:" + e.LatestID,
+		Updated:  e.LatestTime.UTC().Format(time.RFC3339),
+		Category: []atomCategory{{Term: e.SearchType}},
+		Content:  atomContent{Type: "text", Text: b.String()},
+	}
+	if s.ui {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		entry.Link = &atomLink{Href: fmt.Sprintf("%s://%s/ui/results/%s/", scheme, r.Host, e.LatestID)}
+	}
+	return entry
+}
@@ -0,0 +1,71 @@
+package httpserver
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestFeed(t *testing.T) {
+	root := t.TempDir()
+	if _, err := cache.SaveResult(root, "Fed rate decision", "financial", "sonar", "Rates held.\n\n## Source URLs\n1. https://fed.gov/a\n", nil); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := cache.SaveResult(root, "Fed rate decision", "financial", "sonar", "Rates cut.\n\n## Source URLs\n1. https://fed.gov/b\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewServer(&config.Config{ResultsRootFolder: root}).mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no feed without a token, got %d", rec.Code)
+	}
+
+	s := NewServer(&config.Config{ResultsRootFolder: root, FeedToken: "s3cret"})
+	s.EnableUI()
+	for path, want := range map[string]int{
+		"/feed":              http.StatusUnauthorized,
+		"/feed?token=wrong":  http.StatusUnauthorized,
+		"/feed?token=s3cret": http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:9090/feed", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("Expected the feed, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var feed atomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Feed is not valid XML: %v", err)
+	}
+	if len(feed.Entries) != 1 {
+		t.Fatalf("Expected one entry for the watched query, got %+v", feed.Entries)
+	}
+	entry := feed.Entries[0]
+	if entry.Title != "Changed: Fed rate decision" || entry.ID != "urn:perplexity:result:"+latest {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+	if entry.Link == nil || entry.Link.Href != "http://localhost:9090/ui/results/"+latest+"/" {
+		t.Errorf("Expected a link to the web UI, got %+v", entry.Link)
+	}
+	for _, want := range []string{"Rates cut.", "+ https://fed.gov/b", "- https://fed.gov/a"} {
+		if !strings.Contains(entry.Content.Text, want) {
+			t.Errorf("Expected %q in the entry content:\n%s", want, entry.Content.Text)
+		}
+	}
+}
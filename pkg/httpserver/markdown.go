@@ -0,0 +1,253 @@
+package httpserver
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletPattern    = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	numberedPattern  = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.*)$`)
+	tableRulePattern = regexp.MustCompile(`^\|?[\s:|-]+\|?$`)
+	sourcePattern    = regexp.MustCompile(`^\d+\.\s+(\S+)`)
+
+	// inlinePattern matches, in order: an image, a link, a citation marker, a bare URL,
+	// bold text, and italic text. It runs on HTML-escaped text.
+	inlinePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)|\[([^\]]+)\]\(([^)\s]+)\)|\[(\d+)\]|(https?://[^\s<>()\[\]|]+)|\*\*([^*]+)\*\*|\*([^*\s][^*]*)\*`)
+)
+
+// renderMarkdown converts a cached result to HTML. It covers what answers contain: headings,
+// lists, fenced code, tables, emphasis, links, and images. Citation markers such as [2] link
+// to the matching entry of the Source URLs section.
+func renderMarkdown(content string) template.HTML {
+	r := &markdownRenderer{sources: sourceURLs(content)}
+	for _, line := range strings.Split(content, "\n") {
+		r.line(line)
+	}
+	r.closeCode()
+	r.flush()
+	return template.HTML(r.b.String())
+}
+
+// markdownRenderer holds the blocks still open while lines are rendered
+type markdownRenderer struct {
+	b         strings.Builder
+	sources   map[string]string // Source URL by citation number
+	paragraph []string
+	list      string // "ul" or "ol" while a list is open
+	table     [][]string
+	code      []string
+	inCode    bool
+}
+
+func (r *markdownRenderer) line(line string) {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "```") {
+		if r.inCode {
+			r.closeCode()
+		} else {
+			r.flush()
+			r.inCode = true
+		}
+		return
+	}
+	if r.inCode {
+		r.code = append(r.code, line)
+		return
+	}
+
+	switch {
+	case trimmed == "":
+		// Lists stay open across blank lines so that spaced-out items form one list
+		r.flushParagraph()
+		r.flushTable()
+	case strings.HasPrefix(trimmed, "|"):
+		r.flushParagraph()
+		r.closeList()
+		if !tableRulePattern.MatchString(trimmed) {
+			r.table = append(r.table, splitRow(trimmed))
+		}
+	case headingPattern.MatchString(trimmed):
+		r.flush()
+		m := headingPattern.FindStringSubmatch(trimmed)
+		fmt.Fprintf(&r.b, "<h%d>%s</h%d>\n", len(m[1]), r.inline(m[2]), len(m[1]))
+	case bulletPattern.MatchString(line):
+		r.openList("ul")
+		fmt.Fprintf(&r.b, "<li>%s</li>\n", r.inline(bulletPattern.FindStringSubmatch(line)[1]))
+	case numberedPattern.MatchString(line):
+		r.openList("ol")
+		m := numberedPattern.FindStringSubmatch(line)
+		fmt.Fprintf(&r.b, "<li value=\"%s\">%s</li>\n", m[1], r.inline(m[2]))
+	case r.list != "" && line != trimmed:
+		// An indented line continues the list item above it
+		r.continueItem(trimmed)
+	default:
+		r.flushTable()
+		r.closeList()
+		r.paragraph = append(r.paragraph, trimmed)
+	}
+}
+
+// continueItem appends a line to the last list item
+func (r *markdownRenderer) continueItem(text string) {
+	rendered := strings.TrimSuffix(r.b.String(), "</li>\n")
+	r.b.Reset()
+	r.b.WriteString(rendered + "<br>" + r.inline(text) + "</li>\n")
+}
+
+func (r *markdownRenderer) openList(kind string) {
+	r.flushParagraph()
+	r.flushTable()
+	if r.list == kind {
+		return
+	}
+	r.closeList()
+	r.list = kind
+	fmt.Fprintf(&r.b, "<%s>\n", kind)
+}
+
+func (r *markdownRenderer) closeList() {
+	if r.list != "" {
+		fmt.Fprintf(&r.b, "</%s>\n", r.list)
+		r.list = ""
+	}
+}
+
+func (r *markdownRenderer) closeCode() {
+	if r.inCode {
+		fmt.Fprintf(&r.b, "<pre><code>%s</code></pre>\n", html.EscapeString(strings.Join(r.code, "\n")))
+		r.code = nil
+		r.inCode = false
+	}
+}
+
+// flush closes every open block
+func (r *markdownRenderer) flush() {
+	r.flushParagraph()
+	r.flushTable()
+	r.closeList()
+}
+
+func (r *markdownRenderer) flushParagraph() {
+	if len(r.paragraph) > 0 {
+		fmt.Fprintf(&r.b, "<p>%s</p>\n", r.inline(strings.Join(r.paragraph, "\n")))
+		r.paragraph = nil
+	}
+}
+
+func (r *markdownRenderer) flushTable() {
+	if len(r.table) == 0 {
+		return
+	}
+	r.b.WriteString("<table>\n")
+	for i, row := range r.table {
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+		}
+		r.b.WriteString("<tr>")
+		for _, text := range row {
+			fmt.Fprintf(&r.b, "<%s>%s</%s>", cell, r.inline(text), cell)
+		}
+		r.b.WriteString("</tr>\n")
+	}
+	r.b.WriteString("</table>\n")
+	r.table = nil
+}
+
+// splitRow returns the trimmed cells of a table row
+func splitRow(row string) []string {
+	cells := strings.Split(strings.Trim(row, "|"), "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// inline escapes text and renders its code spans, links, images, citations, and emphasis
+func (r *markdownRenderer) inline(text string) string {
+	parts := strings.Split(text, "`")
+	var b strings.Builder
+	for i, part := range parts {
+		switch {
+		case i%2 == 1 && i < len(parts)-1:
+			b.WriteString("<code>" + html.EscapeString(part) + "</code>")
+		case i%2 == 1:
+			// An unmatched backtick stays literal
+			b.WriteString("`" + r.inlineEscaped(html.EscapeString(part)))
+		default:
+			b.WriteString(r.inlineEscaped(html.EscapeString(part)))
+		}
+	}
+	return strings.ReplaceAll(b.String(), "\n", "<br>\n")
+}
+
+// inlineEscaped renders the inline markup of text that is already HTML-escaped
+func (r *markdownRenderer) inlineEscaped(text string) string {
+	return inlinePattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := inlinePattern.FindStringSubmatch(match)
+		switch {
+		case m[2] != "":
+			if !safeURL(m[2]) {
+				return match
+			}
+			return fmt.Sprintf(`<img src="%s" alt="%s">`, m[2], m[1])
+		case m[4] != "":
+			if !safeURL(m[4]) {
+				return match
+			}
+			return fmt.Sprintf(`<a href="%s">%s</a>`, m[4], m[3])
+		case m[5] != "":
+			source, ok := r.sources[m[5]]
+			if !ok {
+				return match
+			}
+			escaped := html.EscapeString(source)
+			return fmt.Sprintf(`<a class="cite" href="%s" title="%s">[%s]</a>`, escaped, escaped, m[5])
+		case m[6] != "":
+			return fmt.Sprintf(`<a href="%s">%s</a>`, m[6], m[6])
+		case m[7] != "":
+			return "<strong>" + r.inlineEscaped(m[7]) + "</strong>"
+		case m[8] != "":
+			return "<em>" + r.inlineEscaped(m[8]) + "</em>"
+		}
+		return match
+	})
+}
+
+// safeURL reports whether an escaped link target is a web URL or a relative path, never a
+// javascript: or other scheme
+func safeURL(escaped string) bool {
+	target := html.UnescapeString(escaped)
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		return true
+	}
+	colon := strings.Index(target, ":")
+	return colon < 0 || strings.ContainsAny(target[:colon], "/?#")
+}
+
+// sourceURLs returns the web URLs of a result's Source URLs section by citation number
+func sourceURLs(content string) map[string]string {
+	const header = "## Source URLs\n"
+	sources := map[string]string{}
+	start := strings.Index(content, header)
+	if start < 0 {
+		return sources
+	}
+	for _, line := range strings.Split(content[start+len(header):], "\n") {
+		if strings.HasPrefix(line, "## ") {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if m := sourcePattern.FindStringSubmatch(line); m != nil {
+			if strings.HasPrefix(m[1], "http://") || strings.HasPrefix(m[1], "https://") {
+				sources[line[:strings.Index(line, ".")]] = m[1]
+			}
+		}
+	}
+	return sources
+}
@@ -0,0 +1,351 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/handler"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+)
+
+// maxRESTBodyBytes bounds a REST request body, which may carry a grounding document
+const maxRESTBodyBytes = 8 << 20
+
+// ToolHandler runs the MCP tools; the MCP handler implements it
+type ToolHandler interface {
+	ListTools(ctx context.Context) (*protocol.ListToolsResponse, error)
+	CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error)
+}
+
+// ErrOpenREST is returned by Listen for a REST API without client tokens on an address other
+// machines can reach, since it would run every tool, with the server's credentials, for anyone
+var ErrOpenREST = errors.New("the REST API requires PERPLEXITY_CLIENTS_FILE unless PERPLEXITY_HTTP_ADDR is a loopback address")
+
+// IsLoopbackAddr reports whether a listen address only accepts connections from this machine
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// usageTool reports the caller's usage, so it stays available to clients over their quotas
+const usageTool = "perplexity_usage"
+
+// restResult is the body of a successful REST call
+type restResult struct {
+	Result string   `json:"result"`
+	Notes  []string `json:"notes,omitempty"` // e.g. that a duplicate call's earlier result was reused
+}
+
+// EnableREST serves each tool as POST /v1/<operation>, taking the tool's arguments as a JSON
+// object, and an OpenAPI description of them at /v1/openapi.json. Calls go through the same
+// handler as MCP calls, so they share its caching, duplicate detection, and rate limiting.
+func (s *Server) EnableREST(tools ToolHandler) {
+	s.tools = tools
+	s.mux.HandleFunc("GET /v1/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc("POST /v1/{operation}", s.handleREST)
+}
+
+// restOperation returns the path segment of a tool, e.g. academic for
+// perplexity_academic_search and search-with-context for perplexity_search_with_context
+func restOperation(tool string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(tool, "perplexity_"), "_search")
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// handleREST runs the tool named by the path with the arguments in the body
+func (s *Server) handleREST(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+	list, err := s.tools.ListTools(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error_type": "tool_error", "message": err.Error()})
+		return
+	}
+	tool := ""
+	for _, t := range list.Tools {
+		if restOperation(t.Name) == r.PathValue("operation") {
+			tool = t.Name
+			break
+		}
+	}
+	if tool == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error_type": "not_found", "message": "unknown operation " + r.PathValue("operation")})
+		return
+	}
+
+	arguments := map[string]interface{}{}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRESTBodyBytes))
+	if err == nil && len(strings.TrimSpace(string(body))) > 0 {
+		err = json.Unmarshal(body, &arguments)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error_type": "invalid_parameters", "message": "request body is too large"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error_type": "invalid_parameters", "message": "request body must be a JSON object of tool arguments: " + err.Error()})
+		return
+	}
+
+	if err := checkLocalFiles(arguments); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error_type": "invalid_parameters", "message": err.Error()})
+		return
+	}
+
+	ctx := r.Context()
+	if client != "" {
+		if tool != usageTool {
+			if err := s.clients.Allow(client); err != nil {
+				writeQuotaError(w, err)
+				return
+			}
+		}
+		ctx = quota.WithClient(ctx, client)
+	}
+
+	resp, err := s.tools.CallTool(ctx, &protocol.CallToolRequest{Name: tool, Arguments: arguments})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error_type": "tool_error", "message": err.Error()})
+		return
+	}
+	if resp.IsError {
+		writeToolError(w, resp)
+		return
+	}
+
+	var result restResult
+	for i, content := range resp.Content {
+		if i == 0 {
+			result.Result = content.Text
+		} else {
+			result.Notes = append(result.Notes, content.Text)
+		}
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// checkLocalFiles rejects arguments that name files on the server's disk, at any depth, so
+// also in the saved arguments of a profile. Each object is checked as the tools read it, with
+// aliases such as refs renamed and comma-separated lists split. Reading local files is for
+// the MCP client running on the same machine; REST callers send a document's text instead.
+func checkLocalFiles(value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range handler.NormalizeArguments(v) {
+			if key == "file_path" && item != nil && item != "" {
+				return errors.New("file_path is not accepted over the REST API; send the text as document")
+			}
+			if refs, ok := item.([]interface{}); ok && key == "context_refs" {
+				for _, ref := range refs {
+					if ref, ok := ref.(string); ok && strings.HasPrefix(strings.TrimSpace(ref), "file://") {
+						return errors.New("file:// context references are not accepted over the REST API")
+					}
+				}
+			}
+			if err := checkLocalFiles(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := checkLocalFiles(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// authenticate identifies the client calling when clients are required, writing an
+// authentication error and returning false for a missing or unknown token. Without clients
+// every caller is accepted, as the empty client.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.clients == nil {
+		return "", true
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	client, ok := s.clients.Authenticate(token)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="perplexity api"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error_type": "authentication", "message": "a client token is required as a bearer token"})
+		return "", false
+	}
+	return client, true
+}
+
+// quotaError is the body of a call rejected for being over a client quota
+type quotaError struct {
+	ErrorType         string `json:"error_type"`
+	Message           string `json:"message"`
+	Retryable         bool   `json:"retryable"`
+	Limit             string `json:"limit"`
+	Max               int    `json:"max"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
+
+// writeQuotaError rejects a call over a client quota with 429 and when to retry
+func writeQuotaError(w http.ResponseWriter, err error) {
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error_type": "tool_error", "message": err.Error()})
+		return
+	}
+	seconds := int((exceeded.RetryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeJSON(w, http.StatusTooManyRequests, quotaError{
+		ErrorType:         "quota_exceeded",
+		Message:           err.Error(),
+		Retryable:         true,
+		Limit:             exceeded.Limit,
+		Max:               exceeded.Max,
+		RetryAfterSeconds: seconds,
+	})
+}
+
+// writeToolError passes on a tool's structured error with a matching HTTP status
+func writeToolError(w http.ResponseWriter, resp *protocol.CallToolResponse) {
+	var toolErr struct {
+		ErrorType string `json:"error_type"`
+	}
+	text := ""
+	if len(resp.Content) > 0 {
+		text = resp.Content[0].Text
+	}
+	if json.Unmarshal([]byte(text), &toolErr) != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error_type": "tool_error", "message": text})
+		return
+	}
+
+	status := http.StatusUnprocessableEntity
+	switch toolErr.ErrorType {
+	case "invalid_parameters", "bad_request":
+		status = http.StatusBadRequest
+	case "rate_limit":
+		status = http.StatusTooManyRequests
+	case "timeout":
+		status = http.StatusGatewayTimeout
+	case "authentication", "server_error", "network", "api_error":
+		status = http.StatusBadGateway
+	case "canceled":
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, text)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Warn("failed to write REST response", "error", err)
+	}
+}
+
+// handleOpenAPI describes every REST operation, using each tool's input schema as its
+// request body
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.authenticate(w, r); !ok {
+		return
+	}
+	list, err := s.tools.ListTools(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error_type": "tool_error", "message": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, openAPISpec(list.Tools))
+}
+
+// openAPISpec builds an OpenAPI 3.0 document for the tools
+func openAPISpec(tools []protocol.Tool) map[string]interface{} {
+	errorResponse := map[string]interface{}{
+		"description": "The tool failed",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]string{"$ref": "#/components/schemas/Error"}},
+		},
+	}
+
+	paths := map[string]interface{}{}
+	for _, tool := range tools {
+		schema := json.RawMessage(tool.InputSchema)
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object"}`)
+		}
+		summary, _, _ := strings.Cut(tool.Description, ". ")
+		paths["/v1/"+restOperation(tool.Name)] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": tool.Name,
+				"summary":     strings.TrimSuffix(summary, "."),
+				"description": tool.Description,
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "The tool's result",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": map[string]string{"$ref": "#/components/schemas/Result"}},
+						},
+					},
+					"400":     errorResponse,
+					"429":     errorResponse,
+					"default": errorResponse,
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":       "Perplexity search",
+			"version":     "1",
+			"description": "REST access to the Perplexity MCP server's tools",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Result": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"result"},
+					"properties": map[string]interface{}{
+						"result": map[string]string{"type": "string", "description": "The tool's markdown result"},
+						"notes":  map[string]interface{}{"type": "array", "items": map[string]string{"type": "string"}},
+					},
+				},
+				"Error": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"error_type", "message"},
+					"properties": map[string]interface{}{
+						"error_type":  map[string]string{"type": "string"},
+						"message":     map[string]string{"type": "string"},
+						"retryable":   map[string]string{"type": "boolean"},
+						"hint":        map[string]string{"type": "string"},
+						"status_code": map[string]string{"type": "integer"},
+						"request_id":  map[string]string{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,238 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+)
+
+// fakeTools answers with its query, or fails with a rate limit error for "busy"
+type fakeTools struct {
+	calls []*protocol.CallToolRequest
+}
+
+func (f *fakeTools) ListTools(ctx context.Context) (*protocol.ListToolsResponse, error) {
+	return &protocol.ListToolsResponse{Tools: []protocol.Tool{
+		{Name: "perplexity_search", Description: "General search. Uses sonar.", InputSchema: json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"}},"required":["query"]}`)},
+		{Name: "perplexity_academic_search", Description: "Academic search."},
+		{Name: "perplexity_search_with_context", Description: "Grounded search."},
+	}}, nil
+}
+
+func (f *fakeTools) CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
+	f.calls = append(f.calls, req)
+	if req.Arguments["query"] == "busy" {
+		return &protocol.CallToolResponse{IsError: true, Content: []protocol.ToolContent{{Type: "text", Text: `{"error_type": "rate_limit", "message": "slow down", "retryable": true}`}}}, nil
+	}
+	return &protocol.CallToolResponse{Content: []protocol.ToolContent{
+		{Type: "text", Text: "answer to " + req.Arguments["query"].(string)},
+		{Type: "text", Text: "Duplicate call detected"},
+	}}, nil
+}
+
+func TestRestOperation(t *testing.T) {
+	for tool, want := range map[string]string{
+		"perplexity_search":              "search",
+		"perplexity_academic_search":     "academic",
+		"perplexity_search_with_context": "search-with-context",
+		"perplexity_local_now":           "local-now",
+		"get_previous_result":            "get-previous-result",
+	} {
+		if got := restOperation(tool); got != want {
+			t.Errorf("restOperation(%q) = %q, want %q", tool, got, want)
+		}
+	}
+}
+
+func TestREST(t *testing.T) {
+	tools := &fakeTools{}
+	s := NewServer(&config.Config{})
+	s.EnableREST(tools)
+	post := func(path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post("/v1/academic", `{"query": "CRISPR", "max_results": 3}`)
+	var result restResult
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &result) != nil {
+		t.Fatalf("Expected a JSON result, got %d %s", rec.Code, rec.Body.String())
+	}
+	if result.Result != "answer to CRISPR" || len(result.Notes) != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if call := tools.calls[0]; call.Name != "perplexity_academic_search" || call.Arguments["max_results"] != float64(3) {
+		t.Errorf("Expected the arguments passed to the academic tool, got %+v", call)
+	}
+
+	for path, want := range map[string]struct {
+		body   string
+		status int
+	}{
+		"/v1/search":              {`{"query": "busy"}`, http.StatusTooManyRequests},
+		"/v1/unknown":             {`{}`, http.StatusNotFound},
+		"/v1/search-with-context": {`["not", "an", "object"]`, http.StatusBadRequest},
+	} {
+		if rec := post(path, want.body); rec.Code != want.status || !strings.Contains(rec.Body.String(), `"error_type"`) {
+			t.Errorf("%s: expected %d with an error body, got %d %s", path, want.status, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Files on the server's disk cannot be read by REST callers
+	calls := len(tools.calls)
+	for _, body := range []string{
+		`{"query": "q", "file_path": "/etc/passwd"}`,
+		`{"query": "q", "context_refs": ["A1B2C3D4E5", " file:///home/me/.ssh/id_rsa"]}`,
+		`{"name": "p", "arguments": {"context_refs": ["file:///etc/shadow"]}}`,
+		`{"query": "q", "refs": ["file:///etc/passwd"]}`,
+		`{"query": "q", "context_refs": "A1B2C3D4E5, file:///etc/passwd"}`,
+		`{"name": "p", "arguments": {"refs": "file:///etc/shadow"}}`,
+	} {
+		if rec := post("/v1/search-with-context", body); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "REST API") {
+			t.Errorf("Expected %s rejected, got %d %s", body, rec.Code, rec.Body.String())
+		}
+	}
+	if len(tools.calls) != calls {
+		t.Errorf("Expected no tool calls for rejected file arguments, got %d", len(tools.calls)-calls)
+	}
+	if rec := post("/v1/search-with-context", `{"query": "file:// in text", "document": "file:///etc/passwd is only quoted here", "file_path": ""}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected file:// in text arguments allowed, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Post struct {
+				OperationID string `json:"operationId"`
+				Summary     string `json:"summary"`
+				RequestBody struct {
+					Content map[string]struct {
+						Schema map[string]interface{} `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+			} `json:"post"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Spec is not JSON: %v", err)
+	}
+	search := spec.Paths["/v1/search"].Post
+	if spec.OpenAPI != "3.0.3" || len(spec.Paths) != 3 || search.OperationID != "perplexity_search" || search.Summary != "General search" {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+	if schema := search.RequestBody.Content["application/json"].Schema; schema["required"] == nil {
+		t.Errorf("Expected the tool's input schema as the request body, got %v", schema)
+	}
+}
+
+// clientTools adds perplexity_usage to fakeTools and records the client of each call
+type clientTools struct {
+	fakeTools
+	clients []string
+}
+
+func (c *clientTools) ListTools(ctx context.Context) (*protocol.ListToolsResponse, error) {
+	list, _ := c.fakeTools.ListTools(ctx)
+	list.Tools = append(list.Tools, protocol.Tool{Name: usageTool, Description: "Usage."})
+	return list, nil
+}
+
+func (c *clientTools) CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
+	c.clients = append(c.clients, quota.ClientFromContext(ctx))
+	if req.Name == usageTool {
+		return &protocol.CallToolResponse{Content: []protocol.ToolContent{{Type: "text", Text: "usage"}}}, nil
+	}
+	return c.fakeTools.CallTool(ctx, req)
+}
+
+func TestRESTClients(t *testing.T) {
+	tools := &clientTools{}
+	s := NewServer(&config.Config{})
+	s.EnableREST(tools)
+	s.RequireClients(quota.NewTracker(map[string]config.ClientConfig{"ci": {Token: "ci-secret", DailyRequests: 1}}, nil))
+	post := func(path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := post("/v1/search", token, `{"query": "q"}`); rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), `"authentication"`) {
+			t.Errorf("token %q: expected 401, got %d %s", token, rec.Code, rec.Body.String())
+		}
+	}
+	if len(tools.clients) != 0 {
+		t.Fatalf("Expected no calls without a valid token, got %v", tools.clients)
+	}
+
+	if rec := post("/v1/search", "ci-secret", `{"query": "q"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected the first call allowed, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec := post("/v1/search", "ci-secret", `{"query": "q"}`)
+	var body quotaError
+	if rec.Code != http.StatusTooManyRequests || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body.ErrorType != "quota_exceeded" || body.Limit != quota.LimitDaily || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected the daily budget rejected with a structured error, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// The usage report stays available over quota
+	if rec := post("/v1/usage", "ci-secret", ``); rec.Code != http.StatusOK {
+		t.Errorf("Expected usage reported over quota, got %d %s", rec.Code, rec.Body.String())
+	}
+	if len(tools.clients) != 2 || tools.clients[0] != "ci" || tools.clients[1] != "ci" {
+		t.Errorf("Expected two calls made as ci, got %v", tools.clients)
+	}
+}
+
+func TestRESTRequiresClientsOffLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:9090": true,
+		"localhost:9090": true,
+		"[::1]:9090":     true,
+		":9090":          false,
+		"0.0.0.0:9090":   false,
+		"10.0.0.5:9090":  false,
+	} {
+		if got := IsLoopbackAddr(addr); got != want {
+			t.Errorf("IsLoopbackAddr(%q) = %v, want %v", addr, got, want)
+		}
+	}
+
+	s := NewServer(&config.Config{HTTPAddr: "0.0.0.0:0"})
+	s.EnableREST(&fakeTools{})
+	if ln, err := s.Listen(); !errors.Is(err, ErrOpenREST) {
+		if ln != nil {
+			ln.Close()
+		}
+		t.Fatalf("Expected an open REST API on all interfaces refused, got %v", err)
+	}
+
+	s.RequireClients(quota.NewTracker(map[string]config.ClientConfig{"ci": {Token: "secret"}}, nil))
+	ln, err := s.Listen()
+	if err != nil {
+		t.Fatalf("Expected the REST API with client tokens to listen, got %v", err)
+	}
+	ln.Close()
+
+	local := NewServer(&config.Config{HTTPAddr: "127.0.0.1:0"})
+	local.EnableREST(&fakeTools{})
+	ln, err = local.Listen()
+	if err != nil {
+		t.Fatalf("Expected an open REST API on loopback to listen, got %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,118 @@
+package httpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+)
+
+// Server serves the HTTP endpoints available when PERPLEXITY_HTTP_ADDR is set
+type Server struct {
+	source  config.Source
+	mux     *http.ServeMux
+	srv     *http.Server
+	ui      bool
+	tools   ToolHandler
+	clients *quota.Tracker
+	// Files of the TLS certificate, key, and client CA; no certificate serves plain HTTP
+	tlsCert, tlsKey, tlsClientCA string
+}
+
+// NewServer creates a new HTTP server bound to the configured address. The address and the
+// feed route come from the configuration in effect now; pages read the rest from src.
+func NewServer(src config.Source) *Server {
+	cfg := src.Snapshot()
+	s := &Server{
+		source: src,
+		mux:    http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+	if cfg.FeedToken != "" {
+		s.mux.HandleFunc("/feed", s.handleFeed)
+	}
+
+	s.srv = &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	s.tlsCert, s.tlsKey, s.tlsClientCA = cfg.TLSCert, cfg.TLSKey, cfg.TLSClientCA
+	return s
+}
+
+// RequireClients makes the REST API and the UI accept only requests with the token of one of
+// the tracker's clients, and rejects tool calls over that client's quotas. A nil tracker
+// leaves them open, which Listen allows only on a loopback address.
+func (s *Server) RequireClients(clients *quota.Tracker) {
+	s.clients = clients
+}
+
+// config returns the configuration in effect
+func (s *Server) config() *config.Config {
+	return s.source.Snapshot()
+}
+
+// ListenAndServe starts serving, over TLS when a certificate is configured, and blocks until
+// the server stops
+func (s *Server) ListenAndServe() error {
+	ln, err := s.Listen()
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Listen binds the configured address and loads the TLS certificate, so that a service can
+// report readiness before calling Serve
+func (s *Server) Listen() (net.Listener, error) {
+	if s.tools != nil && s.clients == nil && !IsLoopbackAddr(s.srv.Addr) {
+		return nil, ErrOpenREST
+	}
+	if s.ui && s.clients == nil && !IsLoopbackAddr(s.srv.Addr) {
+		return nil, ErrOpenUI
+	}
+	if s.tlsCert != "" {
+		tlsConfig, err := ServerTLSConfig(s.tlsCert, s.tlsKey, s.tlsClientCA)
+		if err != nil {
+			return nil, err
+		}
+		s.srv.TLSConfig = tlsConfig
+	}
+	return net.Listen("tcp", s.srv.Addr)
+}
+
+// Serve serves on a listener from Listen and blocks until the server stops
+func (s *Server) Serve(ln net.Listener) error {
+	var err error
+	if s.srv.TLSConfig != nil {
+		err = s.srv.ServeTLS(ln, "", "")
+	} else {
+		err = s.srv.Serve(ln)
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// handleMetrics writes all counters in Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	metrics.Default.WriteTo(w)
+}
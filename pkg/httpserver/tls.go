@@ -0,0 +1,84 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// ServerTLSConfig returns the TLS settings of the HTTP and gRPC servers: TLS 1.2 or later,
+// the certificate in certFile and keyFile, and with a clientCA file, a verified client
+// certificate on every connection
+func ServerTLSConfig(certFile, keyFile, clientCA string) (*tls.Config, error) {
+	certs := &certificateLoader{cert: certFile, key: keyFile}
+	if _, err := certs.get(nil); err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.get,
+	}
+	if clientCA != "" {
+		pool, err := config.LoadCertPool(clientCA)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// certificateLoader reads the certificate and key again when either file changes, so rotated
+// certificates are served without a restart
+type certificateLoader struct {
+	cert, key string
+
+	mu      sync.Mutex
+	stamp   [2]time.Time // Modification times of the certificate and key last read
+	current *tls.Certificate
+}
+
+// get returns the current certificate. A rotation that can't be loaded, e.g. because only
+// one of the files was replaced so far, keeps the previous certificate until a file changes
+// again.
+func (l *certificateLoader) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stamp, err := modTimes(l.cert, l.key)
+	if err == nil && l.current != nil && stamp == l.stamp {
+		return l.current, nil
+	}
+	if err == nil {
+		var pair tls.Certificate
+		if pair, err = tls.LoadX509KeyPair(l.cert, l.key); err == nil {
+			l.current = &pair
+		}
+	}
+	l.stamp = stamp
+	if err != nil {
+		if l.current != nil {
+			slog.Warn("failed to reload TLS certificate, serving the previous one", "cert", l.cert, "error", err)
+			return l.current, nil
+		}
+		return nil, err
+	}
+	return l.current, nil
+}
+
+// modTimes returns the modification times of two files
+func modTimes(a, b string) ([2]time.Time, error) {
+	var stamp [2]time.Time
+	for i, name := range []string{a, b} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return stamp, err
+		}
+		stamp[i] = info.ModTime()
+	}
+	return stamp, nil
+}
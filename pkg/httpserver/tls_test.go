@@ -0,0 +1,155 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate with its key, signed by parent or self-signed
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, serial int64, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write saves the certificate and key as PEM files in dir, returning their paths
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test CA", 1, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "server", 2, ca).write(t, dir, "server")
+
+	tlsConfig, err := ServerTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("ServerTLSConfig failed: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	})}
+	go srv.Serve(tls.NewListener(ln, tlsConfig))
+	t.Cleanup(func() { srv.Close() })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certs ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+		defer client.CloseIdleConnections()
+		return client.Get("https://" + ln.Addr().String() + "/")
+	}
+
+	if resp, err := get(); err == nil {
+		resp.Body.Close()
+		t.Error("Expected a connection without a client certificate rejected")
+	}
+	outsider := newTestCert(t, "outsider", 3, newTestCert(t, "other CA", 4, nil))
+	if resp, err := get(outsider.tlsCertificate()); err == nil {
+		resp.Body.Close()
+		t.Error("Expected a client certificate from another CA rejected")
+	}
+	resp, err := get(newTestCert(t, "ci", 5, ca).tlsCertificate())
+	if err != nil {
+		t.Fatalf("Expected a client certificate from the CA accepted, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestCertificateRotation(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "test CA", 1, nil)
+	certFile, keyFile := newTestCert(t, "server", 2, ca).write(t, dir, "server")
+	certs := &certificateLoader{cert: certFile, key: keyFile}
+	first, err := certs.get(nil)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+
+	// A half-finished rotation keeps the previous certificate
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(keyFile, later, later)
+	if got, err := certs.get(nil); err != nil || got != first {
+		t.Errorf("Expected the previous certificate kept, got %v", err)
+	}
+
+	newTestCert(t, "server", 6, ca).write(t, dir, "server")
+	later = later.Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	got, err := certs.get(nil)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if leaf, _ := x509.ParseCertificate(got.Certificate[0]); leaf.SerialNumber.Int64() != 6 {
+		t.Errorf("Expected the rotated certificate served, got serial %v", leaf.SerialNumber)
+	}
+}
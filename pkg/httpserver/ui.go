@@ -0,0 +1,252 @@
+package httpserver
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+// ErrOpenUI is returned by Listen for a UI without client tokens on an address other machines
+// can reach, since it would show every cached result to anyone
+var ErrOpenUI = errors.New("the web UI requires PERPLEXITY_CLIENTS_FILE unless PERPLEXITY_HTTP_ADDR is a loopback address")
+
+// EnableUI serves a browser UI for cached results under /ui/: a list of results filtered by
+// text and tag, and each result rendered from its markdown with clickable citations. Results
+// come from the results root and, marked as shared, the shared folder. With clients required,
+// pages need one of their tokens, as a bearer token or the password of a browser login.
+func (s *Server) EnableUI() {
+	s.ui = true
+	s.mux.HandleFunc("GET /ui/{$}", s.requireUIClient(s.handleUIList))
+	s.mux.HandleFunc("GET /ui/results/{id}", s.requireUIClient(s.handleUIResultRedirect))
+	s.mux.HandleFunc("GET /ui/results/{id}/{$}", s.requireUIClient(s.handleUIResult))
+	s.mux.HandleFunc("GET /ui/results/{id}/images/{name}", s.requireUIClient(s.handleUIImage))
+}
+
+// requireUIClient wraps a UI page so that, when clients are required, it is only served for a
+// client token. Browsers send it as the password of HTTP basic authentication, which they ask
+// for after the challenge; the user name is ignored. Pages don't count toward quotas, since
+// they never call the API.
+func (s *Server) requireUIClient(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.clients != nil {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if _, password, ok := r.BasicAuth(); ok {
+				token = password
+			}
+			if _, ok := s.clients.Authenticate(token); !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="perplexity ui"`)
+				http.Error(w, "a client token is required", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// uiListPage is the data of the result list
+type uiListPage struct {
+	Enabled bool
+	Query   string
+	Tag     string
+	Total   int
+	Items   []cache.QueryListItem
+}
+
+// uiResultPage is the data of one rendered result
+type uiResultPage struct {
+	ID       string
+	Shared   bool
+	Metadata *cache.QueryMetadata
+	Body     template.HTML
+}
+
+// handleUIList lists cached results, most recent first. The q parameter keeps results whose
+// query contains it and tag those tagged with it as a keyword or entity, both ignoring case.
+func (s *Server) handleUIList(w http.ResponseWriter, r *http.Request) {
+	page := uiListPage{
+		Enabled: cache.IsCachingEnabled(s.config().ResultsRootFolder),
+		Query:   strings.TrimSpace(r.URL.Query().Get("q")),
+		Tag:     strings.TrimSpace(r.URL.Query().Get("tag")),
+	}
+	if page.Enabled {
+		items, err := s.listResults()
+		if err != nil {
+			slog.Error("failed to list cached results", "error", err)
+			http.Error(w, "failed to list cached results", http.StatusInternalServerError)
+			return
+		}
+		page.Total = len(items)
+		for _, item := range items {
+			if matchesUIFilter(item, page.Query, page.Tag) {
+				page.Items = append(page.Items, item)
+			}
+		}
+	}
+	s.renderUI(w, "list", page)
+}
+
+// handleUIResultRedirect adds the trailing slash that image links in a result are relative to
+func (s *Server) handleUIResultRedirect(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+}
+
+// handleUIResult renders one cached result
+func (s *Server) handleUIResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	root, shared, ok := s.resultRoot(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	content, err := cache.GetPreviousResult(root, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	metadata, err := cache.GetMetadata(root, id)
+	if err != nil {
+		// The result is still worth showing without its query and date
+		slog.Warn("failed to read metadata of cached result", "result_id", id, "error", err)
+		metadata = &cache.QueryMetadata{Query: id}
+	}
+	s.renderUI(w, "result", uiResultPage{ID: id, Shared: shared, Metadata: metadata, Body: renderMarkdown(content)})
+}
+
+// handleUIImage serves an image downloaded into a result's folder
+func (s *Server) handleUIImage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	root, _, ok := s.resultRoot(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := cache.ReadImage(root, id, r.PathValue("name"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(data)
+}
+
+// listResults returns the results of the results root and the shared folder, most recent first
+func (s *Server) listResults() ([]cache.QueryListItem, error) {
+	items, err := cache.ListPreviousQueries(s.config().ResultsRootFolder)
+	if err != nil {
+		return nil, err
+	}
+	if s.config().SharedResultsFolder == "" {
+		return items, nil
+	}
+	shared, err := cache.ListPreviousQueries(s.config().SharedResultsFolder)
+	if err != nil {
+		slog.Warn("failed to list shared results", "folder", s.config().SharedResultsFolder, "error", err)
+		return items, nil
+	}
+
+	local := make(map[string]bool, len(items))
+	for _, item := range items {
+		local[item.UniqueID] = true
+	}
+	for _, item := range shared {
+		if !local[item.UniqueID] {
+			item.Shared = true
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DateTime.After(items[j].DateTime)
+	})
+	return items, nil
+}
+
+// resultRoot returns the folder holding a cached result and whether it is the shared folder
+func (s *Server) resultRoot(id string) (string, bool, bool) {
+	if cache.HasResult(s.config().ResultsRootFolder, id) {
+		return s.config().ResultsRootFolder, false, true
+	}
+	if cache.IsCachingEnabled(s.config().ResultsRootFolder) && cache.HasResult(s.config().SharedResultsFolder, id) {
+		return s.config().SharedResultsFolder, true, true
+	}
+	return "", false, false
+}
+
+// matchesUIFilter reports whether an item's query contains text and it carries tag
+func matchesUIFilter(item cache.QueryListItem, text, tag string) bool {
+	if text != "" && !strings.Contains(strings.ToLower(item.Query), strings.ToLower(text)) {
+		return false
+	}
+	if tag == "" {
+		return true
+	}
+	for _, value := range append(append([]string{}, item.Keywords...), item.Entities...) {
+		if strings.EqualFold(value, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) renderUI(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src * data:; style-src 'unsafe-inline'")
+	if err := uiTemplates.ExecuteTemplate(w, name, data); err != nil {
+		slog.Error("failed to render UI page", "page", name, "error", err)
+	}
+}
+
+var uiTemplates = template.Must(template.New("ui").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}} · Perplexity results</title>
+<style>
+body { font: 15px/1.55 system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+a { color: #1f5fbf; } header a { text-decoration: none; color: inherit; }
+table { border-collapse: collapse; width: 100%; } th, td { text-align: left; padding: .35rem .5rem; border-bottom: 1px solid #e4e4e4; vertical-align: top; }
+form input { padding: .3rem .5rem; } pre { background: #f5f5f5; padding: .75rem; overflow-x: auto; }
+.tag { display: inline-block; background: #eef2f8; border-radius: 3px; padding: 0 .35rem; margin: 0 .2rem .2rem 0; font-size: 85%; text-decoration: none; }
+.muted { color: #777; font-size: 90%; } a.cite { text-decoration: none; font-size: 85%; vertical-align: super; }
+article img { max-width: 100%; }
+</style></head><body>
+<header><h1><a href="/ui/">Perplexity results</a></h1></header>
+{{end}}
+
+{{define "tags"}}{{range .Entities}}<a class="tag" href="/ui/?tag={{.}}">{{.}}</a>{{end}}{{range .Keywords}}<a class="tag" href="/ui/?tag={{.}}">{{.}}</a>{{end}}{{end}}
+
+{{define "list"}}{{template "head" "Results"}}
+{{if not .Enabled}}<p>Result caching is disabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER to keep results.</p>{{else}}
+<form method="get" action="/ui/">
+<input type="search" name="q" value="{{.Query}}" placeholder="Search queries">
+<input type="text" name="tag" value="{{.Tag}}" placeholder="Tag">
+<button type="submit">Filter</button>{{if or .Query .Tag}} <a href="/ui/">Clear</a>{{end}}
+</form>
+<p class="muted">{{len .Items}} of {{.Total}} result(s)</p>
+{{if .Items}}<table>
+<tr><th>Date</th><th>Query</th><th>Type</th><th>Tags</th></tr>
+{{range .Items}}<tr>
+<td class="muted">{{date .DateTime}}</td>
+<td><a href="/ui/results/{{.UniqueID}}/">{{.Query}}</a>{{if .Shared}} <span class="muted">(shared)</span>{{end}}</td>
+<td class="muted">{{.SearchType}}</td>
+<td>{{template "tags" .}}</td>
+</tr>{{end}}
+</table>{{end}}{{end}}
+</body></html>{{end}}
+
+{{define "result"}}{{template "head" .Metadata.Query}}
+<h2>{{.Metadata.Query}}</h2>
+<p class="muted">{{.ID}}{{if .Metadata.SearchType}} · {{.Metadata.SearchType}}{{end}}{{if .Metadata.Model}} · {{.Metadata.Model}}{{end}}{{if not .Metadata.Timestamp.IsZero}} · {{date .Metadata.Timestamp}}{{end}}{{if .Shared}} · shared{{end}}</p>
+<p>{{template "tags" .Metadata}}</p>
+<article>
+{{.Body}}
+</article>
+</body></html>{{end}}
+`))
@@ -0,0 +1,136 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/quota"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	content := "# Chips\n\nNvidia leads [1] with **record** revenue [2], see `H100` and [docs](https://nvidia.com/docs).\n" +
+		"<script>alert(1)</script> [bad](javascript:alert(1))\n\n" +
+		"- first\n- second\n\n## Source URLs\n1. https://example.com/a?x=1&y=2\n2. https://example.com/b\n"
+	got := string(renderMarkdown(content))
+
+	for _, want := range []string{
+		"<h1>Chips</h1>",
+		`<a class="cite" href="https://example.com/a?x=1&amp;y=2" title="https://example.com/a?x=1&amp;y=2">[1]</a>`,
+		`<a class="cite" href="https://example.com/b" title="https://example.com/b">[2]</a>`,
+		"<strong>record</strong>",
+		"<code>H100</code>",
+		`<a href="https://nvidia.com/docs">docs</a>`,
+		"&lt;script&gt;",
+		"[bad](javascript:alert(1))",
+		"<ul>\n<li>first</li>\n<li>second</li>\n</ul>",
+		`<li value="2"><a href="https://example.com/b">https://example.com/b</a></li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in rendered HTML:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") || strings.Contains(got, `href="javascript`) {
+		t.Errorf("Expected markup in the answer to be escaped:\n%s", got)
+	}
+}
+
+func TestUI(t *testing.T) {
+	root := t.TempDir()
+	nvidia, err := cache.SaveResult(root, "Nvidia earnings", "financial", "sonar", "Revenue rose [1].\n\n## Images\n1. ![image 1](images/1.png)\n\n## Source URLs\n1. https://nvidia.com\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.TagResult(root, nvidia, []string{"revenue"}, []string{"Nvidia"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.SaveImage(root, nvidia, "1.png", []byte("\x89PNG\r\n\x1a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.SaveResult(root, "Rust <async> traits", "dev", "sonar", "Stable since 1.75.", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(&config.Config{ResultsRootFolder: root})
+	s.EnableUI()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	body := get("/ui/").Body.String()
+	if !strings.Contains(body, "Nvidia earnings") || !strings.Contains(body, "Rust &lt;async&gt; traits") {
+		t.Errorf("Expected both results listed with escaped queries:\n%s", body)
+	}
+	for path, want := range map[string]string{
+		"/ui/?q=rust":     "1 of 2 result(s)",
+		"/ui/?tag=nvidia": "1 of 2 result(s)",
+		"/ui/?tag=Rust":   "0 of 2 result(s)",
+	} {
+		if body := get(path).Body.String(); !strings.Contains(body, want) {
+			t.Errorf("%s: expected %q", path, want)
+		}
+	}
+
+	if rec := get("/ui/results/" + nvidia); rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/ui/results/"+nvidia+"/" {
+		t.Errorf("Expected a redirect to the trailing slash, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	rec := get("/ui/results/" + nvidia + "/")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `<a class="cite" href="https://nvidia.com"`) ||
+		!strings.Contains(rec.Body.String(), `<img src="images/1.png"`) {
+		t.Errorf("Expected the rendered result with its citation and image, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+	if rec := get("/ui/results/" + nvidia + "/images/1.png"); rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected the image, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	for _, path := range []string{"/ui/results/MISSING001/", "/ui/results/" + nvidia + "/images/..%2Fresult.md"} {
+		if rec := get(path); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestUIRequiresClients(t *testing.T) {
+	open := NewServer(&config.Config{HTTPAddr: "0.0.0.0:0"})
+	open.EnableUI()
+	if ln, err := open.Listen(); !errors.Is(err, ErrOpenUI) {
+		if ln != nil {
+			ln.Close()
+		}
+		t.Fatalf("Expected an open UI on all interfaces refused, got %v", err)
+	}
+
+	s := NewServer(&config.Config{HTTPAddr: "0.0.0.0:0", ResultsRootFolder: t.TempDir()})
+	s.EnableUI()
+	s.RequireClients(quota.NewTracker(map[string]config.ClientConfig{"ci": {Token: "secret"}}, nil))
+	ln, err := s.Listen()
+	if err != nil {
+		t.Fatalf("Expected the UI with client tokens to listen, got %v", err)
+	}
+	ln.Close()
+
+	get := func(setAuth func(r *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ui/", nil)
+		setAuth(req)
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := get(func(r *http.Request) {}); rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("Expected a login challenge without a token, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	if rec := get(func(r *http.Request) { r.SetBasicAuth("me", "wrong") }); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an unknown token refused, got %d", rec.Code)
+	}
+	if rec := get(func(r *http.Request) { r.SetBasicAuth("me", "secret") }); rec.Code != http.StatusOK {
+		t.Errorf("Expected the page for a browser login, got %d", rec.Code)
+	}
+	if rec := get(func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }); rec.Code != http.StatusOK {
+		t.Errorf("Expected the page for a bearer token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestNewLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, config.LogLevelWarn, config.LogFormatText)
+
+	logger.Info("hidden")
+	logger.Warn("shown", "tool", "perplexity_search")
+	if strings.Contains(buf.String(), "hidden") {
+		t.Error("Expected info records to be dropped at warn level")
+	}
+	if !strings.Contains(buf.String(), "level=WARN msg=shown tool=perplexity_search") {
+		t.Errorf("Unexpected text output: %q", buf.String())
+	}
+}
+
+func TestNewJSONWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(&buf, config.LogLevelDebug, config.LogFormatJSON))
+	ctx = WithRequestID(ctx, "0123456789abcdef")
+
+	FromContext(ctx).Debug("tool call started", "tool", "perplexity_search")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["level"] != "DEBUG" || record["msg"] != "tool call started" || record["request_id"] != "0123456789abcdef" {
+		t.Errorf("Unexpected record: %v", record)
+	}
+	if RequestID(ctx) != "0123456789abcdef" {
+		t.Errorf("RequestID mismatch: got %q", RequestID(ctx))
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 16 || a == b {
+		t.Errorf("Expected distinct 16-character IDs, got %q and %q", a, b)
+	}
+	if RequestID(context.Background()) != "" {
+		t.Error("Expected no request ID outside a request")
+	}
+}
@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds a set of counters and renders them in Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	counters []*Counter
+}
+
+// Counter is a monotonically increasing value partitioned by label values
+type Counter struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// Default is the process-wide registry served on /metrics
+var Default = NewRegistry()
+
+// Counters recorded by the server
+var (
+	ToolRequests = Default.NewCounter("perplexity_tool_requests_total", "Total MCP tool calls by tool name.", "tool")
+	ToolErrors   = Default.NewCounter("perplexity_tool_errors_total", "Total failed MCP tool calls by tool name.", "tool")
+	APIRequests  = Default.NewCounter("perplexity_api_requests_total", "Total Perplexity API requests by model and HTTP status class.", "model", "status_class")
+	Tokens       = Default.NewCounter("perplexity_tokens_total", "Total tokens reported by the Perplexity API by model and kind.", "model", "kind")
+	CacheLookups = Default.NewCounter("perplexity_cache_lookups_total", "Total cached result lookups by outcome (hit or miss).", "result")
+
+	RateLimitRejections = Default.NewCounter("perplexity_rate_limit_rejections_total", "Total API calls abandoned while waiting for the rate limiter.")
+	MemoryLookups       = Default.NewCounter("perplexity_memory_cache_lookups_total", "Total cached result reads by whether the in-memory cache served them (hit) or the disk did (miss).", "result")
+)
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounter registers a new counter with the given label names
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+	}
+
+	r.mu.Lock()
+	r.counters = append(r.counters, c)
+	r.mu.Unlock()
+
+	return c
+}
+
+// Inc increments the counter for the given label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by delta
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if len(labelValues) != len(c.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.name, len(c.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	c.values[key] += delta
+	c.mu.Unlock()
+}
+
+// Value returns the current value for the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+// WriteTo writes all counters in Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	counters := make([]*Counter, len(r.counters))
+	copy(counters, r.counters)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, c := range counters {
+		c.writeTo(&b)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeTo renders a single counter with its HELP and TYPE lines
+func (c *Counter) writeTo(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", c.name)
+
+	if len(c.labelNames) == 0 {
+		fmt.Fprintf(b, "%s %g\n", c.name, c.values[""])
+		return
+	}
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		labelValues := strings.Split(key, "\xff")
+		pairs := make([]string, len(c.labelNames))
+		for i, name := range c.labelNames {
+			pairs[i] = fmt.Sprintf("%s=%q", name, labelValues[i])
+		}
+		fmt.Fprintf(b, "%s{%s} %g\n", c.name, strings.Join(pairs, ","), c.values[key])
+	}
+}
+
+// StatusClass maps an HTTP status code to its Prometheus-friendly class label
+func StatusClass(statusCode int) string {
+	if statusCode <= 0 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", statusCode/100)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCounterIncAndAdd(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "Test counter.", "tool")
+
+	c.Inc("perplexity_search")
+	c.Add(2, "perplexity_search")
+	c.Inc("list_previous")
+
+	if got := c.Value("perplexity_search"); got != 3 {
+		t.Errorf("Value mismatch: got %g, want 3", got)
+	}
+	if got := c.Value("list_previous"); got != 1 {
+		t.Errorf("Value mismatch: got %g, want 1", got)
+	}
+	if got := c.Value("unknown"); got != 0 {
+		t.Errorf("Value mismatch: got %g, want 0", got)
+	}
+}
+
+func TestRegistryWriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("api_requests_total", "API requests.", "model", "status_class")
+	plain := r.NewCounter("rejections_total", "Rejections.")
+
+	c.Inc("sonar", "2xx")
+	c.Inc("sonar-pro", "4xx")
+	plain.Inc()
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	out := b.String()
+
+	expected := []string{
+		"# HELP api_requests_total API requests.",
+		"# TYPE api_requests_total counter",
+		`api_requests_total{model="sonar",status_class="2xx"} 1`,
+		`api_requests_total{model="sonar-pro",status_class="4xx"} 1`,
+		"rejections_total 1",
+	}
+	for _, want := range expected {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[int]string{
+		200: "2xx",
+		429: "4xx",
+		503: "5xx",
+		0:   "error",
+	}
+
+	for code, want := range tests {
+		if got := StatusClass(code); got != want {
+			t.Errorf("StatusClass(%d) mismatch: got %s, want %s", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package models keeps the list of Perplexity models the server accepts. The list is
+// bundled with the binary and can be replaced from a manifest, a local file or URL in the
+// same JSON format, so models released after the server work without a new release.
+package models
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fetchTimeout bounds downloading a manifest when the caller's context has no deadline
+	fetchTimeout = 10 * time.Second
+	// maxManifestBytes bounds a manifest
+	maxManifestBytes = 1 << 20
+)
+
+//go:embed models.json
+var bundled []byte
+
+// Model describes one model
+type Model struct {
+	ID            string `json:"id"`
+	Description   string `json:"description"`              // Shown in validation errors, e.g. "fast, basic search"
+	ContextWindow int    `json:"context_window,omitempty"` // In tokens; 0 when unknown
+}
+
+// manifest is the JSON format of models.json and of remote manifests
+type manifest struct {
+	Models []Model `json:"models"`
+}
+
+// Registry is a list of models that can be replaced while it is read. It is safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	models  []Model
+	byID    map[string]Model
+	bundled map[string]bool
+}
+
+// Default is the registry the server validates models against
+var Default = NewRegistry()
+
+// NewRegistry creates a registry holding the bundled models
+func NewRegistry() *Registry {
+	list, err := parse(bundled)
+	if err != nil {
+		panic("models: invalid bundled models.json: " + err.Error())
+	}
+	r := &Registry{bundled: make(map[string]bool, len(list))}
+	for _, m := range list {
+		r.bundled[m.ID] = true
+	}
+	r.set(list)
+	return r
+}
+
+// Reset restores the bundled models
+func (r *Registry) Reset() {
+	list, _ := parse(bundled)
+	r.set(list)
+}
+
+// parse reads a manifest, rejecting one without models or with unnamed or repeated ones
+func parse(data []byte) ([]Model, error) {
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid model manifest: %w", err)
+	}
+	if len(m.Models) == 0 {
+		return nil, fmt.Errorf("model manifest lists no models")
+	}
+	seen := make(map[string]bool, len(m.Models))
+	for i, model := range m.Models {
+		id := strings.TrimSpace(model.ID)
+		if id == "" {
+			return nil, fmt.Errorf("model %d in the manifest has no id", i+1)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("model %q is listed twice in the manifest", id)
+		}
+		seen[id] = true
+		m.Models[i].ID = id
+	}
+	return m.Models, nil
+}
+
+func (r *Registry) set(list []Model) {
+	byID := make(map[string]Model, len(list))
+	for _, m := range list {
+		byID[m.ID] = m
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.models, r.byID = list, byID
+}
+
+// Lookup returns the model with id
+func (r *Registry) Lookup(id string) (Model, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.byID[id]
+	return m, ok
+}
+
+// Valid reports whether id is a known model
+func (r *Registry) Valid(id string) bool {
+	_, ok := r.Lookup(id)
+	return ok
+}
+
+// List returns the models in manifest order
+func (r *Registry) List() []Model {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Model(nil), r.models...)
+}
+
+// Added returns the IDs of the models that a manifest added to the bundled ones
+func (r *Registry) Added() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ids []string
+	for _, m := range r.models {
+		if !r.bundled[m.ID] {
+			ids = append(ids, m.ID)
+		}
+	}
+	return ids
+}
+
+// Load replaces the models with those of a manifest. An invalid manifest leaves them as
+// they were.
+func (r *Registry) Load(data []byte) error {
+	list, err := parse(data)
+	if err != nil {
+		return err
+	}
+	r.set(list)
+	return nil
+}
+
+// LoadManifest replaces the models with those of the manifest at source, an http(s) URL or
+// a file path
+func (r *Registry) LoadManifest(ctx context.Context, source string) error {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read model manifest: %w", err)
+		}
+		return r.Load(data)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return fmt.Errorf("invalid model manifest URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch model manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch model manifest: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to fetch model manifest: %w", err)
+	}
+	if len(data) > maxManifestBytes {
+		return fmt.Errorf("model manifest is larger than %d bytes", maxManifestBytes)
+	}
+	return r.Load(data)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundled(t *testing.T) {
+	r := NewRegistry()
+	for _, id := range []string{"sonar", "sonar-pro", "sonar-reasoning", "sonar-reasoning-pro", "sonar-deep-research"} {
+		if !r.Valid(id) {
+			t.Errorf("Expected %s to be bundled", id)
+		}
+	}
+	if r.Valid("gpt-4") || r.Valid("auto") {
+		t.Error("Expected only Perplexity models")
+	}
+	if m, _ := r.Lookup("sonar-pro"); m.ContextWindow != 200000 {
+		t.Errorf("Expected the sonar-pro context window, got %d", m.ContextWindow)
+	}
+	if added := r.Added(); len(added) != 0 {
+		t.Errorf("Expected no added models, got %v", added)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	const manifest = `{"models": [{"id": "sonar", "description": "fast"}, {"id": "sonar-ultra", "description": "new", "context_window": 400000}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(manifest))
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "models.json")
+	if err := os.WriteFile(file, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range []string{srv.URL + "/models.json", file} {
+		r := NewRegistry()
+		if err := r.LoadManifest(context.Background(), source); err != nil {
+			t.Fatalf("%s: LoadManifest failed: %v", source, err)
+		}
+		if !r.Valid("sonar-ultra") || r.Valid("sonar-pro") {
+			t.Errorf("%s: expected the manifest to replace the models, got %v", source, r.List())
+		}
+		if added := r.Added(); len(added) != 1 || added[0] != "sonar-ultra" {
+			t.Errorf("%s: expected sonar-ultra added, got %v", source, added)
+		}
+	}
+
+	r := NewRegistry()
+	for _, source := range []string{srv.URL + "/missing.json", filepath.Join(t.TempDir(), "missing.json")} {
+		if err := r.LoadManifest(context.Background(), source); err == nil {
+			t.Errorf("%s: expected an error", source)
+		}
+	}
+	for _, data := range []string{`not json`, `{"models": []}`, `{"models": [{"id": ""}]}`, `{"models": [{"id": "a"}, {"id": "a"}]}`} {
+		if err := r.Load([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+	if !r.Valid("sonar-pro") {
+		t.Error("Expected failed loads to keep the models")
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mailer sends plain-text email through an SMTP server. net/smtp upgrades the
+// connection with STARTTLS whenever the server offers it.
+type Mailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewMailer creates a mailer; credentials are optional for relays that accept unauthenticated mail
+func NewMailer(host string, port int, username, password, from string, to []string) *Mailer {
+	return &Mailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Send emails subject and body to every recipient
+func (m *Mailer) Send(subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	if err := m.sendMail(addr, auth, m.from, m.to, m.message(subject, body, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// message builds an RFC 5322 message with CRLF line endings
+func (m *Mailer) message(subject, body string, now time.Time) []byte {
+	headers := []string{
+		"From: " + m.from,
+		"To: " + strings.Join(m.to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+}
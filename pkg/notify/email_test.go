@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMailerSend(t *testing.T) {
+	mailer := NewMailer("smtp.example.com", 587, "user", "pass", "bot@example.com", []string{"a@example.com", "b@example.com"})
+
+	var gotAddr string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+	mailer.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotTo, gotMsg = addr, a, to, msg
+		return nil
+	}
+
+	if err := mailer.Send("Weekly digest", "line one\nline two\n"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if gotAddr != "smtp.example.com:587" || gotAuth == nil || len(gotTo) != 2 {
+		t.Errorf("Send arguments mismatch: addr %s, auth %v, to %v", gotAddr, gotAuth, gotTo)
+	}
+
+	msg := string(gotMsg)
+	expected := []string{"To: a@example.com, b@example.com\r\n", "Subject: Weekly digest\r\n", "\r\n\r\nline one\r\nline two\r\n"}
+	for _, want := range expected {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestMailerEncodesSubject(t *testing.T) {
+	mailer := NewMailer("localhost", 25, "", "", "bot@example.com", []string{"a@example.com"})
+	msg := string(mailer.message("Résumé digest", "body", time.Now()))
+	if !strings.Contains(msg, "Subject: =?utf-8?q?R=C3=A9sum=C3=A9_digest?=") {
+		t.Errorf("Subject not encoded:\n%s", msg)
+	}
+}
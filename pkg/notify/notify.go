@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Notification summarizes a completed job
+type Notification struct {
+	Title      string
+	Summary    string
+	ResultID   string
+	SearchType string
+}
+
+// Webhook posts notifications to a Slack or Discord incoming webhook
+type Webhook struct {
+	url        string
+	discord    bool
+	httpClient *http.Client
+}
+
+// NewWebhook creates a notifier for webhookURL. Discord webhooks are recognised by
+// their host; any other URL is treated as a Slack-compatible webhook.
+func NewWebhook(webhookURL string, httpClient *http.Client) *Webhook {
+	return &Webhook{
+		url:        webhookURL,
+		discord:    IsDiscordURL(webhookURL),
+		httpClient: httpClient,
+	}
+}
+
+// IsDiscordURL reports whether webhookURL points at Discord
+func IsDiscordURL(webhookURL string) bool {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range []string{"discord.com", "discordapp.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send posts the notification
+func (w *Webhook) Send(ctx context.Context, n Notification) error {
+	payload := map[string]string{"text": w.message(n)}
+	if w.discord {
+		payload = map[string]string{"content": w.message(n)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// message renders the notification in the webhook's markdown dialect
+func (w *Webhook) message(n Notification) string {
+	bold := "*"
+	if w.discord {
+		bold = "**"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s%s", bold, n.Title, bold)
+	if n.SearchType != "" {
+		fmt.Fprintf(&b, " (%s)", n.SearchType)
+	}
+	if n.Summary != "" {
+		b.WriteString("\n" + n.Summary)
+	}
+	if n.ResultID != "" {
+		fmt.Fprintf(&b, "\nResult ID: `%s` (retrieve with get_previous_result)", n.ResultID)
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsDiscordURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://discord.com/api/webhooks/1/abc":      true,
+		"https://ptb.discordapp.com/api/webhooks/1/a": true,
+		"https://hooks.slack.com/services/T/B/X":      false,
+		"https://notdiscord.com/hook":                 false,
+	}
+	for webhookURL, want := range tests {
+		if got := IsDiscordURL(webhookURL); got != want {
+			t.Errorf("IsDiscordURL(%q) mismatch: got %v, want %v", webhookURL, got, want)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	n := Notification{Title: "Verification of result A", Summary: "2 claims checked", ResultID: "ABCDE12345", SearchType: "verification"}
+
+	slack := NewWebhook(server.URL, server.Client())
+	if err := slack.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	want := "*Verification of result A* (verification)\n2 claims checked\nResult ID: `ABCDE12345` (retrieve with get_previous_result)"
+	if payload["text"] != want {
+		t.Errorf("Slack payload mismatch:\ngot  %q\nwant %q", payload["text"], want)
+	}
+
+	discord := NewWebhook(server.URL, server.Client())
+	discord.discord = true
+	if err := discord.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if !strings.HasPrefix(payload["content"], "**Verification of result A**") {
+		t.Errorf("Discord payload mismatch: got %q", payload["content"])
+	}
+}
+
+func TestSendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL, server.Client()).Send(context.Background(), Notification{Title: "t"})
+	if err == nil || !strings.Contains(err.Error(), "status 403: invalid_token") {
+		t.Errorf("Expected webhook error, got %v", err)
+	}
+}
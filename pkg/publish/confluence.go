@@ -0,0 +1,143 @@
+package publish
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// ConfluencePublisher creates pages in a Confluence space
+type ConfluencePublisher struct {
+	baseURL    string
+	user       string
+	token      string
+	space      string
+	httpClient *http.Client
+}
+
+// NewConfluencePublisher creates a publisher for the given space. baseURL is the site root,
+// including the /wiki path on Confluence Cloud. With a user the token is sent as a Cloud API
+// token using basic auth; without one it is sent as a Server/Data Center personal access token.
+func NewConfluencePublisher(baseURL, user, token, space string, httpClient *http.Client) *ConfluencePublisher {
+	return &ConfluencePublisher{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		user:       user,
+		token:      token,
+		space:      space,
+		httpClient: httpClient,
+	}
+}
+
+// Name returns the target name
+func (c *ConfluencePublisher) Name() string {
+	return TargetConfluence
+}
+
+// Publish creates a page in the space's root and returns its URL
+func (c *ConfluencePublisher) Publish(ctx context.Context, page *Page) (string, error) {
+	labels := make([]map[string]string, 0, len(page.Tags))
+	for _, tag := range page.Tags {
+		if label := confluenceLabel(tag); label != "" {
+			labels = append(labels, map[string]string{"prefix": "global", "name": label})
+		}
+	}
+
+	body := map[string]interface{}{
+		"type":  "page",
+		"title": page.Title,
+		"space": map[string]string{"key": c.space},
+		"body": map[string]interface{}{
+			"storage": map[string]string{
+				"value":          storageFormat(page),
+				"representation": "storage",
+			},
+		},
+		"metadata": map[string]interface{}{"labels": labels},
+	}
+
+	var created struct {
+		Links struct {
+			Base  string `json:"base"`
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	}
+	if err := sendJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/rest/api/content", c.headers(), body, &created); err != nil {
+		return "", fmt.Errorf("confluence: %w", err)
+	}
+
+	base := created.Links.Base
+	if base == "" {
+		base = c.baseURL
+	}
+	return base + created.Links.WebUI, nil
+}
+
+// headers returns the authentication header for the configured token type
+func (c *ConfluencePublisher) headers() map[string]string {
+	if c.user != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(c.user + ":" + c.token))
+		return map[string]string{"Authorization": "Basic " + credentials}
+	}
+	return map[string]string{"Authorization": "Bearer " + c.token}
+}
+
+// storageFormat renders the page as Confluence storage format XHTML
+func storageFormat(page *Page) string {
+	var b strings.Builder
+	list := ""
+	closeList := func() {
+		if list != "" {
+			fmt.Fprintf(&b, "</%s>", list)
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			fmt.Fprintf(&b, "<%s>", tag)
+			list = tag
+		}
+	}
+
+	for _, blk := range parseBlocks(page.Body) {
+		switch blk.kind {
+		case blockBullet:
+			openList("ul")
+			fmt.Fprintf(&b, "<li>%s</li>", html.EscapeString(blk.text))
+		case blockNumbered:
+			openList("ol")
+			fmt.Fprintf(&b, "<li>%s</li>", html.EscapeString(blk.text))
+		case blockHeading:
+			closeList()
+			fmt.Fprintf(&b, "<h%d>%s</h%d>", blk.level, html.EscapeString(blk.text), blk.level)
+		case blockCode:
+			closeList()
+			// A CDATA section cannot contain "]]>", so split it across two sections
+			code := strings.ReplaceAll(blk.text, "]]>", "]]]]><![CDATA[>")
+			fmt.Fprintf(&b, `<ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[%s]]></ac:plain-text-body></ac:structured-macro>`, code)
+		default:
+			closeList()
+			fmt.Fprintf(&b, "<p>%s</p>", strings.ReplaceAll(html.EscapeString(blk.text), "\n", "<br/>"))
+		}
+	}
+	closeList()
+
+	if len(page.Sources) > 0 {
+		b.WriteString("<h2>Sources</h2><ol>")
+		for _, source := range page.Sources {
+			escaped := html.EscapeString(source)
+			fmt.Fprintf(&b, `<li><a href="%s">%s</a></li>`, escaped, escaped)
+		}
+		b.WriteString("</ol>")
+	}
+	fmt.Fprintf(&b, "<p><em>Perplexity result ID: %s</em></p>", html.EscapeString(page.ResultID))
+	return b.String()
+}
+
+// confluenceLabel converts a tag to a label; labels are lowercase and cannot contain spaces
+func confluenceLabel(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+}
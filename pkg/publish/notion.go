@@ -0,0 +1,168 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	notionAPIURL  = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+	// Notion limits a rich text object to 2000 characters and a request to 100 child blocks
+	notionMaxText   = 2000
+	notionMaxBlocks = 100
+)
+
+// NotionPublisher creates pages in a Notion database
+type NotionPublisher struct {
+	token         string
+	databaseID    string
+	titleProperty string
+	tagsProperty  string
+	baseURL       string
+	httpClient    *http.Client
+}
+
+// NewNotionPublisher creates a publisher for the given database. Tags are written to
+// tagsProperty as a multi-select when it is set, and listed in the page body otherwise.
+func NewNotionPublisher(token, databaseID, titleProperty, tagsProperty string, httpClient *http.Client) *NotionPublisher {
+	return &NotionPublisher{
+		token:         token,
+		databaseID:    databaseID,
+		titleProperty: titleProperty,
+		tagsProperty:  tagsProperty,
+		baseURL:       notionAPIURL,
+		httpClient:    httpClient,
+	}
+}
+
+// Name returns the target name
+func (n *NotionPublisher) Name() string {
+	return TargetNotion
+}
+
+// Publish creates a database page, appending blocks beyond the first request in batches
+func (n *NotionPublisher) Publish(ctx context.Context, page *Page) (string, error) {
+	properties := map[string]interface{}{
+		n.titleProperty: map[string]interface{}{"title": richText(page.Title)},
+	}
+	if n.tagsProperty != "" && len(page.Tags) > 0 {
+		options := make([]map[string]string, 0, len(page.Tags))
+		for _, tag := range page.Tags {
+			// Multi-select options cannot contain commas
+			options = append(options, map[string]string{"name": strings.ReplaceAll(tag, ",", " ")})
+		}
+		properties[n.tagsProperty] = map[string]interface{}{"multi_select": options}
+	}
+
+	children := n.pageBlocks(page)
+	first := children
+	if len(first) > notionMaxBlocks {
+		first = first[:notionMaxBlocks]
+	}
+
+	var created struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	body := map[string]interface{}{
+		"parent":     map[string]string{"database_id": n.databaseID},
+		"properties": properties,
+		"children":   first,
+	}
+	if err := sendJSON(ctx, n.httpClient, http.MethodPost, n.baseURL+"/pages", n.headers(), body, &created); err != nil {
+		return "", fmt.Errorf("notion: %w", err)
+	}
+
+	for start := len(first); start < len(children); start += notionMaxBlocks {
+		end := start + notionMaxBlocks
+		if end > len(children) {
+			end = len(children)
+		}
+		batch := map[string]interface{}{"children": children[start:end]}
+		if err := sendJSON(ctx, n.httpClient, http.MethodPatch, n.baseURL+"/blocks/"+created.ID+"/children", n.headers(), batch, nil); err != nil {
+			return "", fmt.Errorf("notion: page %s created but appending content failed: %w", created.URL, err)
+		}
+	}
+
+	return created.URL, nil
+}
+
+// headers returns the authentication and versioning headers for the Notion API
+func (n *NotionPublisher) headers() map[string]string {
+	return map[string]string{
+		"Authorization":  "Bearer " + n.token,
+		"Notion-Version": notionVersion,
+	}
+}
+
+// pageBlocks converts the answer, sources, and tags into Notion blocks
+func (n *NotionPublisher) pageBlocks(page *Page) []map[string]interface{} {
+	var blocks []map[string]interface{}
+	for _, b := range parseBlocks(page.Body) {
+		switch b.kind {
+		case blockHeading:
+			blocks = append(blocks, notionBlock(fmt.Sprintf("heading_%d", b.level), richText(b.text)))
+		case blockBullet:
+			blocks = append(blocks, notionBlock("bulleted_list_item", richText(b.text)))
+		case blockNumbered:
+			blocks = append(blocks, notionBlock("numbered_list_item", richText(b.text)))
+		case blockCode:
+			code := notionBlock("code", richText(b.text))
+			code["code"].(map[string]interface{})["language"] = "plain text"
+			blocks = append(blocks, code)
+		default:
+			blocks = append(blocks, notionBlock("paragraph", richText(b.text)))
+		}
+	}
+
+	if len(page.Sources) > 0 {
+		blocks = append(blocks, notionBlock("heading_2", richText("Sources")))
+		for _, source := range page.Sources {
+			link := richText(source)
+			for _, text := range link {
+				text["text"].(map[string]interface{})["link"] = map[string]string{"url": source}
+			}
+			blocks = append(blocks, notionBlock("numbered_list_item", link))
+		}
+	}
+
+	if n.tagsProperty == "" && len(page.Tags) > 0 {
+		blocks = append(blocks, notionBlock("paragraph", richText("Tags: "+strings.Join(page.Tags, ", "))))
+	}
+	blocks = append(blocks, notionBlock("paragraph", richText("Perplexity result ID: "+page.ResultID)))
+	return blocks
+}
+
+// notionBlock builds a block object of the given type
+func notionBlock(kind string, text []map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"object": "block",
+		"type":   kind,
+		kind:     map[string]interface{}{"rich_text": text},
+	}
+}
+
+// richText splits text into rich text objects within Notion's length limit
+func richText(text string) []map[string]interface{} {
+	var parts []map[string]interface{}
+	for text != "" {
+		end := len(text)
+		if utf8.RuneCountInString(text) > notionMaxText {
+			end = 0
+			for i := 0; i < notionMaxText; i++ {
+				_, size := utf8.DecodeRuneInString(text[end:])
+				end += size
+			}
+		}
+		parts = append(parts, map[string]interface{}{
+			"type": "text",
+			"text": map[string]interface{}{"content": text[:end]},
+		})
+		text = text[end:]
+	}
+	return parts
+}
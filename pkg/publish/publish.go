@@ -0,0 +1,161 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Page is a cached result prepared for publishing
+type Page struct {
+	ResultID string
+	Title    string
+	Body     string   // Answer markdown without the appended source sections
+	Sources  []string // Source URLs in citation order
+	Tags     []string
+}
+
+// Publisher pushes pages to an external wiki
+type Publisher interface {
+	// Name identifies the publishing target, e.g. "notion"
+	Name() string
+	// Publish creates a page and returns its URL
+	Publish(ctx context.Context, page *Page) (string, error)
+}
+
+// Target names accepted by the publish_result tool
+const (
+	TargetNotion     = "notion"
+	TargetConfluence = "confluence"
+)
+
+// sendJSON sends body as JSON and decodes a successful response into out
+func sendJSON(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("API error (status %d): %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Markdown block kinds understood by the page renderers
+const (
+	blockParagraph = "paragraph"
+	blockHeading   = "heading"
+	blockBullet    = "bullet"
+	blockNumbered  = "numbered"
+	blockCode      = "code"
+)
+
+// block is one structural element of an answer
+type block struct {
+	kind  string
+	level int // Heading level, 1-3
+	text  string
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletPattern   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	numberedPattern = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+)
+
+// parseBlocks splits markdown into headings, list items, code blocks, and paragraphs.
+// Inline formatting is kept as literal text.
+func parseBlocks(markdown string) []block {
+	var blocks []block
+	var paragraph, code []string
+	inCode := false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, block{kind: blockParagraph, text: strings.Join(paragraph, "\n")})
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inCode {
+				blocks = append(blocks, block{kind: blockCode, text: strings.Join(code, "\n")})
+				code = nil
+			} else {
+				flush()
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			code = append(code, line)
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			level := len(m[1])
+			if level > 3 {
+				level = 3
+			}
+			blocks = append(blocks, block{kind: blockHeading, level: level, text: m[2]})
+		} else if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			blocks = append(blocks, block{kind: blockBullet, text: m[1]})
+		} else if m := numberedPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			blocks = append(blocks, block{kind: blockNumbered, text: m[1]})
+		} else if trimmed == "" {
+			flush()
+		} else {
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+
+	if inCode && len(code) > 0 {
+		blocks = append(blocks, block{kind: blockCode, text: strings.Join(code, "\n")})
+	}
+	flush()
+	return blocks
+}
@@ -0,0 +1,178 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testBody = "## Summary\nGo is fast.\nIt compiles quickly.\n\n- one\n- two\n\n1. first\n\n```\nx := 1\n```"
+
+func TestParseBlocks(t *testing.T) {
+	blocks := parseBlocks(testBody)
+	want := []block{
+		{kind: blockHeading, level: 2, text: "Summary"},
+		{kind: blockParagraph, text: "Go is fast.\nIt compiles quickly."},
+		{kind: blockBullet, text: "one"},
+		{kind: blockBullet, text: "two"},
+		{kind: blockNumbered, text: "first"},
+		{kind: blockCode, text: "x := 1"},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("Block count mismatch: got %d (%+v), want %d", len(blocks), blocks, len(want))
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("Block %d mismatch: got %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+}
+
+func TestRichTextSplitsLongText(t *testing.T) {
+	parts := richText(strings.Repeat("é", notionMaxText+5))
+	if len(parts) != 2 {
+		t.Fatalf("Part count mismatch: got %d, want 2", len(parts))
+	}
+	second := parts[1]["text"].(map[string]interface{})["content"].(string)
+	if second != strings.Repeat("é", 5) {
+		t.Errorf("Second part mismatch: got %q", second)
+	}
+}
+
+func TestNotionPublish(t *testing.T) {
+	var created map[string]interface{}
+	var appended int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") != notionVersion {
+			t.Errorf("Missing Notion headers: %v", r.Header)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/pages":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"id": "page-1", "url": "https://notion.so/page-1"}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/blocks/page-1/children":
+			var batch map[string][]interface{}
+			json.NewDecoder(r.Body).Decode(&batch)
+			appended += len(batch["children"])
+			w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	publisher := NewNotionPublisher("secret", "db-1", "Name", "Tags", server.Client())
+	publisher.baseURL = server.URL
+
+	body := testBody + strings.Repeat("\n\n- item", notionMaxBlocks)
+	url, err := publisher.Publish(context.Background(), &Page{
+		ResultID: "ABCDE12345",
+		Title:    "What is Go?",
+		Body:     body,
+		Sources:  []string{"https://go.dev"},
+		Tags:     []string{"go", "Google"},
+	})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if url != "https://notion.so/page-1" {
+		t.Errorf("URL mismatch: got %s", url)
+	}
+
+	properties := created["properties"].(map[string]interface{})
+	if _, ok := properties["Name"]; !ok {
+		t.Errorf("Title property missing: %v", properties)
+	}
+	if tags := properties["Tags"].(map[string]interface{})["multi_select"].([]interface{}); len(tags) != 2 {
+		t.Errorf("Tag count mismatch: got %d, want 2", len(tags))
+	}
+	if n := len(created["children"].([]interface{})); n != notionMaxBlocks {
+		t.Errorf("First request block count mismatch: got %d, want %d", n, notionMaxBlocks)
+	}
+	// 6 parsed blocks + 100 items + sources heading + 1 source + result ID line
+	if total := notionMaxBlocks + appended; total != 6+notionMaxBlocks+3 {
+		t.Errorf("Total block count mismatch: got %d, want %d", total, 6+notionMaxBlocks+3)
+	}
+}
+
+func TestConfluencePublish(t *testing.T) {
+	var created struct {
+		Title string `json:"title"`
+		Space struct {
+			Key string `json:"key"`
+		} `json:"space"`
+		Body struct {
+			Storage struct {
+				Value string `json:"value"`
+			} `json:"storage"`
+		} `json:"body"`
+		Metadata struct {
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		} `json:"metadata"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "secret" {
+			t.Errorf("Basic auth mismatch: %v", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/wiki/rest/api/content" {
+			t.Errorf("Path mismatch: got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&created)
+		w.Write([]byte(`{"_links": {"base": "https://example.atlassian.net/wiki", "webui": "/spaces/RES/pages/1"}}`))
+	}))
+	defer server.Close()
+
+	publisher := NewConfluencePublisher(server.URL+"/wiki/", "me@example.com", "secret", "RES", server.Client())
+	url, err := publisher.Publish(context.Background(), &Page{
+		ResultID: "ABCDE12345",
+		Title:    "What is Go?",
+		Body:     testBody + "\n\nA <b> & ]]> test",
+		Sources:  []string{"https://go.dev/?a=1&b=2"},
+		Tags:     []string{"Google Cloud"},
+	})
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if url != "https://example.atlassian.net/wiki/spaces/RES/pages/1" {
+		t.Errorf("URL mismatch: got %s", url)
+	}
+	if created.Space.Key != "RES" || created.Title != "What is Go?" {
+		t.Errorf("Page fields mismatch: %+v", created)
+	}
+	if len(created.Metadata.Labels) != 1 || created.Metadata.Labels[0].Name != "google-cloud" {
+		t.Errorf("Labels mismatch: %+v", created.Metadata.Labels)
+	}
+
+	expected := []string{
+		"<h2>Summary</h2><p>Go is fast.<br/>It compiles quickly.</p><ul><li>one</li><li>two</li></ul><ol><li>first</li></ol>",
+		"<![CDATA[x := 1]]>",
+		"<p>A &lt;b&gt; &amp; ]]&gt; test</p>",
+		`<a href="https://go.dev/?a=1&amp;b=2">`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(created.Body.Storage.Value, want) {
+			t.Errorf("Storage format missing %q:\n%s", want, created.Body.Storage.Value)
+		}
+	}
+}
+
+func TestPublishAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "Name is not a property that exists."}`))
+	}))
+	defer server.Close()
+
+	publisher := NewNotionPublisher("secret", "db-1", "Name", "", server.Client())
+	publisher.baseURL = server.URL
+
+	_, err := publisher.Publish(context.Background(), &Page{Title: "t", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), "status 400): Name is not a property") {
+		t.Errorf("Expected API error message, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package quota
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// Limits exceeded by a rejected call
+const (
+	LimitRate  = "rate_limit"
+	LimitDaily = "daily_budget"
+)
+
+// ExceededError is returned for a call over one of its client's quotas
+type ExceededError struct {
+	Client     string
+	Limit      string // LimitRate or LimitDaily
+	Max        int
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	if e.Limit == LimitDaily {
+		return fmt.Sprintf("client '%s' has used its daily budget of %d tool calls; it resets in %s", e.Client, e.Max, e.RetryAfter.Round(time.Minute))
+	}
+	return fmt.Sprintf("client '%s' is over its rate limit of %d tool calls per minute; retry in %s", e.Client, e.Max, e.RetryAfter.Round(time.Second))
+}
+
+// Usage is what one client has used of its quotas
+type Usage struct {
+	Client        string
+	RateLimit     int
+	LastMinute    int
+	DailyRequests int
+	Today         int
+	RejectedToday int
+	Total         int // Calls allowed since the server started
+}
+
+type clientKey struct{}
+
+// WithClient returns a context whose tool calls are made on behalf of the named client
+func WithClient(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, clientKey{}, name)
+}
+
+// ClientFromContext returns the client stored in ctx, or "" for calls not made over HTTP
+func ClientFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(clientKey{}).(string)
+	return name
+}
+
+// client is the configuration and recent calls of one client
+type client struct {
+	config.ClientConfig
+	recent   []time.Time // Calls allowed in the last minute, oldest first
+	day      string
+	today    int
+	rejected int
+	total    int
+}
+
+// Tracker authenticates clients by token and enforces their per-minute and daily quotas.
+// Days start at midnight in the tracker's time zone. A nil Tracker has no clients.
+type Tracker struct {
+	loc *time.Location
+	now func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*client
+}
+
+// NewTracker creates a tracker for clients, counting days in loc (the local zone when nil).
+// It returns nil when there are no clients.
+func NewTracker(clients map[string]config.ClientConfig, loc *time.Location) *Tracker {
+	if len(clients) == 0 {
+		return nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	t := &Tracker{
+		loc:     loc,
+		now:     time.Now,
+		clients: make(map[string]*client, len(clients)),
+	}
+	for name, cfg := range clients {
+		t.clients[name] = &client{ClientConfig: cfg}
+	}
+	return t
+}
+
+// Authenticate returns the name of the client a bearer token belongs to
+func (t *Tracker) Authenticate(token string) (string, bool) {
+	if t == nil || token == "" {
+		return "", false
+	}
+	found := ""
+	for name, c := range t.clients {
+		// Compare against every token so the time taken doesn't reveal which one matched
+		if subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) == 1 {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+// Allow counts a call by the named client, or returns an *ExceededError without counting it
+// when the client is over a quota
+func (t *Tracker) Allow(name string) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c, ok := t.clients[name]
+	if !ok {
+		return fmt.Errorf("unknown client '%s'", name)
+	}
+
+	now := t.now().In(t.loc)
+	t.advance(c, now)
+	if c.DailyRequests > 0 && c.today >= c.DailyRequests {
+		c.rejected++
+		year, month, day := now.Date()
+		midnight := time.Date(year, month, day+1, 0, 0, 0, 0, t.loc)
+		return &ExceededError{Client: name, Limit: LimitDaily, Max: c.DailyRequests, RetryAfter: midnight.Sub(now)}
+	}
+	if c.RateLimit > 0 && len(c.recent) >= c.RateLimit {
+		c.rejected++
+		return &ExceededError{Client: name, Limit: LimitRate, Max: c.RateLimit, RetryAfter: c.recent[0].Add(time.Minute).Sub(now)}
+	}
+
+	c.recent = append(c.recent, now)
+	c.today++
+	c.total++
+	return nil
+}
+
+// advance drops calls older than a minute and restarts the daily counts on a new day
+func (t *Tracker) advance(c *client, now time.Time) {
+	cutoff := now.Add(-time.Minute)
+	kept := 0
+	for kept < len(c.recent) && !c.recent[kept].After(cutoff) {
+		kept++
+	}
+	c.recent = c.recent[kept:]
+
+	if day := now.Format(time.DateOnly); day != c.day {
+		c.day, c.today, c.rejected = day, 0, 0
+	}
+}
+
+// Usage returns the usage of every client, sorted by name
+func (t *Tracker) Usage() []Usage {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now().In(t.loc)
+	usage := make([]Usage, 0, len(t.clients))
+	for name, c := range t.clients {
+		t.advance(c, now)
+		usage = append(usage, Usage{
+			Client:        name,
+			RateLimit:     c.RateLimit,
+			LastMinute:    len(c.recent),
+			DailyRequests: c.DailyRequests,
+			Today:         c.today,
+			RejectedToday: c.rejected,
+			Total:         c.total,
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Client < usage[j].Client })
+	return usage
+}
@@ -0,0 +1,62 @@
+package quota
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+func TestTrackerAuthenticate(t *testing.T) {
+	tracker := NewTracker(map[string]config.ClientConfig{"ci": {Token: "ci-secret"}, "notebook": {Token: "nb-secret"}}, time.UTC)
+	if name, ok := tracker.Authenticate("nb-secret"); !ok || name != "notebook" {
+		t.Errorf("Authenticate = %q, %v; want notebook", name, ok)
+	}
+	for _, token := range []string{"", "ci-secre", "wrong"} {
+		if _, ok := tracker.Authenticate(token); ok {
+			t.Errorf("Expected token %q rejected", token)
+		}
+	}
+	if NewTracker(nil, nil) != nil {
+		t.Error("Expected no tracker without clients")
+	}
+}
+
+func TestTrackerQuotas(t *testing.T) {
+	tracker := NewTracker(map[string]config.ClientConfig{"ci": {Token: "t", RateLimit: 2, DailyRequests: 3}}, time.UTC)
+	now := time.Date(2026, 3, 10, 23, 58, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := tracker.Allow("ci"); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	var exceeded *ExceededError
+	if err := tracker.Allow("ci"); !errors.As(err, &exceeded) || exceeded.Limit != LimitRate || exceeded.RetryAfter != time.Minute {
+		t.Fatalf("Expected the rate limit hit with a minute to wait, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := tracker.Allow("ci"); err != nil {
+		t.Fatalf("Expected a call allowed a minute later, got %v", err)
+	}
+	now = now.Add(time.Second)
+	if err := tracker.Allow("ci"); !errors.As(err, &exceeded) || exceeded.Limit != LimitDaily || exceeded.RetryAfter != 59*time.Second {
+		t.Fatalf("Expected the daily budget used up until midnight, got %v", err)
+	}
+
+	usage := tracker.Usage()
+	if len(usage) != 1 || usage[0].Today != 3 || usage[0].RejectedToday != 2 || usage[0].LastMinute != 1 || usage[0].Total != 3 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+
+	now = now.Add(time.Minute)
+	if err := tracker.Allow("ci"); err != nil {
+		t.Fatalf("Expected the budget reset at midnight, got %v", err)
+	}
+	if usage := tracker.Usage(); usage[0].Today != 1 || usage[0].RejectedToday != 0 || usage[0].Total != 4 {
+		t.Errorf("Unexpected usage after midnight: %+v", usage)
+	}
+}
@@ -0,0 +1,234 @@
+package ratelimit
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+)
+
+// Priority orders waiters competing for the same rate-limit budget; lower values go first
+type Priority int
+
+// Priority levels, from most to least urgent
+const (
+	PriorityInteractive Priority = iota
+	PriorityBatch
+	PriorityWatch
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose API calls are queued at the given priority
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority stored in ctx, defaulting to interactive
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityInteractive
+}
+
+// SlotReserver hands out request slots of a limit shared by several server instances. It
+// claims the next slot, spaced interval after the previous one, and returns how long to wait
+// for it.
+type SlotReserver interface {
+	ReserveSlot(ctx context.Context, interval time.Duration) (time.Duration, error)
+}
+
+// Limiter spaces requests evenly and grants slots to the highest-priority waiter first
+type Limiter struct {
+	interval time.Duration
+	shared   SlotReserver
+
+	mu    sync.Mutex
+	next  time.Time
+	queue waiterQueue
+	timer *time.Timer
+	seq   uint64
+}
+
+// NewLimiter creates a limiter allowing requestsPerMinute requests; zero or less disables limiting
+func NewLimiter(requestsPerMinute int) *Limiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	return &Limiter{
+		interval: time.Minute / time.Duration(requestsPerMinute),
+	}
+}
+
+// NewSharedLimiter creates a limiter whose requestsPerMinute are shared with every instance
+// using the same reserver. Priorities order the waiters of this instance only.
+func NewSharedLimiter(requestsPerMinute int, shared SlotReserver) *Limiter {
+	l := NewLimiter(requestsPerMinute)
+	if l != nil {
+		l.shared = shared
+	}
+	return l
+}
+
+// Wait blocks until a slot is available for the caller's priority or ctx is done.
+// A nil Limiter never blocks.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	if err := l.waitLocal(ctx); err != nil {
+		return err
+	}
+	return l.waitShared(ctx)
+}
+
+// waitShared waits for the caller's slot of the shared limit. When the reserver cannot be
+// reached the local limit alone applies, so an outage never stops API calls.
+func (l *Limiter) waitShared(ctx context.Context) error {
+	if l.shared == nil {
+		return nil
+	}
+	delay, err := l.shared.ReserveSlot(ctx, l.interval)
+	if err != nil {
+		logging.FromContext(ctx).Warn("shared rate limit unavailable, using the local limit", "error", err)
+		return nil
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		metrics.RateLimitRejections.Inc()
+		return ctx.Err()
+	}
+}
+
+// waitLocal blocks until this instance has a slot for the caller's priority or ctx is done
+func (l *Limiter) waitLocal(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if len(l.queue) == 0 && !now.Before(l.next) {
+		l.next = now.Add(l.interval)
+		l.mu.Unlock()
+		return nil
+	}
+
+	w := &waiter{
+		priority: PriorityFromContext(ctx),
+		seq:      l.seq,
+		ready:    make(chan struct{}),
+	}
+	l.seq++
+	heap.Push(&l.queue, w)
+	l.schedule()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		if w.index >= 0 {
+			heap.Remove(&l.queue, w.index)
+		} else {
+			// The slot was granted as ctx was done; pass it on rather than lose it
+			l.handOff()
+		}
+		l.mu.Unlock()
+		metrics.RateLimitRejections.Inc()
+		return ctx.Err()
+	}
+}
+
+// schedule arms the release timer for the next slot; callers must hold l.mu
+func (l *Limiter) schedule() {
+	if l.timer != nil || len(l.queue) == 0 {
+		return
+	}
+	l.timer = time.AfterFunc(time.Until(l.next), l.release)
+}
+
+// release hands the current slot to the highest-priority waiter
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.timer = nil
+	if len(l.queue) == 0 {
+		return
+	}
+
+	now := time.Now()
+	if now.Before(l.next) {
+		l.schedule()
+		return
+	}
+
+	w := heap.Pop(&l.queue).(*waiter)
+	close(w.ready)
+	l.next = now.Add(l.interval)
+	l.schedule()
+}
+
+// handOff gives a granted slot its waiter no longer needs to the next waiter, or frees it
+// when none is queued; callers must hold l.mu
+func (l *Limiter) handOff() {
+	if len(l.queue) > 0 {
+		close(heap.Pop(&l.queue).(*waiter).ready)
+		return
+	}
+	if now := time.Now(); now.Before(l.next) {
+		l.next = now
+	}
+}
+
+// waiter is a caller blocked in Wait
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// waiterQueue is a heap ordered by priority, then arrival order
+type waiterQueue []*waiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
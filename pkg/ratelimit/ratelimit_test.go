@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNilLimiterNeverBlocks(t *testing.T) {
+	l := NewLimiter(0)
+	if l != nil {
+		t.Fatal("Expected nil limiter for zero rate")
+	}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("Wait on nil limiter failed: %v", err)
+	}
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	if p := PriorityFromContext(context.Background()); p != PriorityInteractive {
+		t.Errorf("Default priority mismatch: got %d, want %d", p, PriorityInteractive)
+	}
+	ctx := WithPriority(context.Background(), PriorityBatch)
+	if p := PriorityFromContext(ctx); p != PriorityBatch {
+		t.Errorf("Priority mismatch: got %d, want %d", p, PriorityBatch)
+	}
+}
+
+func TestInteractiveServedBeforeBatch(t *testing.T) {
+	// 600 per minute = one slot every 100ms
+	l := NewLimiter(600)
+
+	// Consume the immediately available slot
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Initial Wait failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+
+	enqueue := func(p Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(WithPriority(context.Background(), p)); err != nil {
+				t.Errorf("Wait failed: %v", err)
+				return
+			}
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+		}()
+	}
+
+	enqueue(PriorityWatch)
+	enqueue(PriorityBatch)
+	time.Sleep(20 * time.Millisecond)
+	enqueue(PriorityInteractive)
+	wg.Wait()
+
+	want := []Priority{PriorityInteractive, PriorityBatch, PriorityWatch}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Order mismatch: got %v, want %v", order, want)
+		}
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	l := NewLimiter(1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Initial Wait failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); err == nil {
+		t.Fatal("Expected error for canceled wait, got nil")
+	}
+	if len(l.queue) != 0 {
+		t.Errorf("Queue length mismatch: got %d, want 0", len(l.queue))
+	}
+}
+
+func TestCanceledWaiterPassesOnGrantedSlot(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		l := NewLimiter(1)
+		l.next = time.Now().Add(time.Hour) // Slots only come from the grant below
+
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error, 1)
+		go func() { first <- l.Wait(ctx) }()
+		second := make(chan error, 1)
+		go func() { second <- l.Wait(WithPriority(context.Background(), PriorityBatch)) }()
+		for {
+			l.mu.Lock()
+			queued := len(l.queue)
+			l.mu.Unlock()
+			if queued == 2 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		// Grant the first waiter its slot just as its context is canceled
+		l.mu.Lock()
+		cancel()
+		close(heap.Pop(&l.queue).(*waiter).ready)
+		l.mu.Unlock()
+
+		if err := <-first; err == nil {
+			continue // The waiter took its slot
+		}
+		select {
+		case err := <-second:
+			if err != nil {
+				t.Fatalf("Expected the next waiter to get the slot, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the canceled waiter's slot passed to the next waiter")
+		}
+		return
+	}
+	t.Skip("The canceled waiter always took its slot")
+}
+
+// fakeReserver hands out slots of a limit shared with other limiters
+type fakeReserver struct {
+	mu   sync.Mutex
+	next time.Time
+	err  error
+}
+
+func (f *fakeReserver) ReserveSlot(ctx context.Context, interval time.Duration) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	now := time.Now()
+	if f.next.Before(now) {
+		f.next = now
+	}
+	wait := f.next.Sub(now)
+	f.next = f.next.Add(interval)
+	return wait, nil
+}
+
+func TestSharedLimiter(t *testing.T) {
+	// Two instances sharing 600 per minute = one slot every 100ms across both
+	shared := &fakeReserver{}
+	a := NewSharedLimiter(600, shared)
+	b := NewSharedLimiter(600, shared)
+
+	start := time.Now()
+	if err := a.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait on a failed: %v", err)
+	}
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait on b failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the second instance to wait for the shared slot, waited %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err == nil {
+		t.Error("Expected error for canceled wait on the shared limit, got nil")
+	}
+
+	// An unreachable reserver leaves only the local limit
+	down := NewSharedLimiter(600, &fakeReserver{err: errors.New("connection refused")})
+	if err := down.Wait(context.Background()); err != nil {
+		t.Errorf("Expected the local limit to apply when the reserver fails, got %v", err)
+	}
+}
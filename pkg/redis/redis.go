@@ -0,0 +1,315 @@
+// Package redis is a minimal Redis client for state that server instances behind a load
+// balancer share: recent tool results and the API rate limit.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// dialTimeout bounds connecting to the server
+	dialTimeout = 5 * time.Second
+	// commandTimeout bounds each command when the caller's context has no deadline
+	commandTimeout = 5 * time.Second
+	// maxIdleConns is how many connections are kept open between commands
+	maxIdleConns = 8
+	// maxBulkBytes bounds each string read from the server
+	maxBulkBytes = 64 << 20
+)
+
+// Error is an error reply from the server
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// Client sends commands to one Redis server. It is safe for concurrent use; each command
+// takes a pooled connection, dialing a new one when none is idle.
+type Client struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      *tls.Config
+	prefix   string
+	idle     chan *conn
+}
+
+// New returns a client for a redis:// or rediss:// (TLS) URL such as
+// redis://:password@localhost:6379/0. Keys are namespaced with prefix. No connection is made
+// until the first command.
+func New(rawURL, prefix string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("Redis URL must start with redis:// or rediss://")
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("Redis URL has no host")
+	}
+
+	c := &Client{addr: u.Host, prefix: prefix, idle: make(chan *conn, maxIdleConns)}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.Scheme == "rediss" {
+		c.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil || c.db < 0 {
+			return nil, fmt.Errorf("Redis URL database must be a non-negative number")
+		}
+	}
+	return c, nil
+}
+
+// Key returns the namespaced key for parts joined with colons
+func (c *Client) Key(parts ...string) string {
+	return c.prefix + strings.Join(parts, ":")
+}
+
+// Do sends a command and returns its reply: a string for simple and bulk strings, an int64,
+// a []interface{} for arrays, or nil for a null reply. Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(commandTimeout)
+	}
+	cn.SetDeadline(deadline)
+
+	reply, err := cn.do(args...)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may be mid-reply; never reuse it
+		cn.Close()
+		return nil, fmt.Errorf("redis %s failed: %w", strings.ToUpper(args[0]), err)
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Ping checks that the server is reachable and accepts the credentials
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Get returns the string stored at key and whether it exists
+func (c *Client) Get(ctx context.Context, key string) (string, bool, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return "", false, fmt.Errorf("redis GET returned %T", reply)
+	}
+	return value, true, nil
+}
+
+// Set stores value at key, expiring after ttl
+func (c *Client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	_, err := c.Do(ctx, "SET", key, value, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// PushCapped adds value to the front of the list at key, keeps only its newest max entries,
+// and expires the list ttl after the last push
+func (c *Client) PushCapped(ctx context.Context, key, value string, max int, ttl time.Duration) error {
+	_, err := c.Do(ctx, "EVAL", pushCappedScript, "1", key, value, strconv.Itoa(max-1), strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+// pushCappedScript pushes, trims, and sets the expiry in one step
+const pushCappedScript = `redis.call('LPUSH', KEYS[1], ARGV[1])
+redis.call('LTRIM', KEYS[1], 0, tonumber(ARGV[2]))
+redis.call('PEXPIRE', KEYS[1], tonumber(ARGV[3]))
+return 1`
+
+// List returns every entry of the list at key, newest first
+func (c *Client) List(ctx context.Context, key string) ([]string, error) {
+	reply, err := c.Do(ctx, "LRANGE", key, "0", "-1")
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis LRANGE returned %T", reply)
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// ReserveSlot claims the next request slot of a limit shared by every instance, spacing slots
+// interval apart, and returns how long to wait before using it. Slots are timed by the Redis
+// server's clock, so instances need not agree on the time.
+func (c *Client) ReserveSlot(ctx context.Context, interval time.Duration) (time.Duration, error) {
+	reply, err := c.Do(ctx, "EVAL", reserveSlotScript, "1", c.Key("ratelimit"), strconv.FormatInt(interval.Microseconds(), 10))
+	if err != nil {
+		return 0, err
+	}
+	wait, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis slot reservation returned %T", reply)
+	}
+	return time.Duration(wait) * time.Microsecond, nil
+}
+
+// reserveSlotScript keeps the time of the next free slot, in microseconds, at KEYS[1]
+const reserveSlotScript = `local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local slot = tonumber(redis.call('GET', KEYS[1]) or '0')
+if slot < now then slot = now end
+redis.call('SET', KEYS[1], slot + interval, 'PX', math.ceil((slot + interval - now) / 1000) + 1000)
+return slot - now`
+
+// get takes an idle connection or dials a new one
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var nc net.Conn
+	var err error
+	if c.tls != nil {
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: c.tls}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	cn.SetDeadline(time.Now().Add(dialTimeout))
+	if c.password != "" {
+		auth := []string{"AUTH", c.password}
+		if c.username != "" {
+			auth = []string{"AUTH", c.username, c.password}
+		}
+		if _, err := cn.do(auth...); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("Redis authentication failed: %w", err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.do("SELECT", strconv.Itoa(c.db)); err != nil {
+			cn.Close()
+			return nil, fmt.Errorf("failed to select Redis database %d: %w", c.db, err)
+		}
+	}
+	return cn, nil
+}
+
+// put returns a connection to the pool, closing it when the pool is full
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// conn is one connection speaking RESP2
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// do writes a command as an array of bulk strings and reads its reply
+func (cn *conn) do(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(cn.Conn, b.String()); err != nil {
+		return nil, err
+	}
+	return cn.readReply()
+}
+
+func (cn *conn) readReply() (interface{}, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n > maxBulkBytes {
+			return nil, fmt.Errorf("invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid array length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			// An error inside an array belongs to that element, not the whole reply
+			item, err := cn.readReply()
+			var replyErr Error
+			if err != nil && !errors.As(err, &replyErr) {
+				return nil, err
+			}
+			if err != nil {
+				item = replyErr
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
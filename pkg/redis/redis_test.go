@@ -0,0 +1,241 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer speaks enough RESP to run the client's commands, keeping data in memory
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	mu      sync.Mutex
+	strings map[string]string
+	lists   map[string][]string
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, password: password, strings: map[string]string{}, lists: map[string][]string{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(nc)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) url(userinfo string) string {
+	return "redis://" + userinfo + s.ln.Addr().String() + "/2"
+}
+
+func (s *fakeServer) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			io.WriteString(nc, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		if cmd == "AUTH" {
+			if args[len(args)-1] != s.password {
+				io.WriteString(nc, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+		}
+		io.WriteString(nc, s.handle(cmd, args[1:]))
+	}
+}
+
+func (s *fakeServer) handle(cmd string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch cmd {
+	case "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		value, ok := s.strings[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "SET":
+		s.strings[args[0]] = args[1]
+		return "+OK\r\n"
+	case "LRANGE":
+		list := s.lists[args[0]]
+		reply := fmt.Sprintf("*%d\r\n", len(list))
+		for _, item := range list {
+			reply += bulk(item)
+		}
+		return reply
+	case "EVAL":
+		switch args[0] {
+		case pushCappedScript:
+			max, _ := strconv.Atoi(args[4])
+			list := append([]string{args[3]}, s.lists[args[2]]...)
+			if len(list) > max+1 {
+				list = list[:max+1]
+			}
+			s.lists[args[2]] = list
+			return ":1\r\n"
+		case reserveSlotScript:
+			now := time.Now().UnixMicro()
+			interval, _ := strconv.ParseInt(args[3], 10, 64)
+			slot, _ := strconv.ParseInt(s.strings[args[2]], 10, 64)
+			if slot < now {
+				slot = now
+			}
+			s.strings[args[2]] = strconv.FormatInt(slot+interval, 10)
+			return fmt.Sprintf(":%d\r\n", slot-now)
+		}
+	}
+	return "-ERR unknown command '" + cmd + "'\r\n"
+}
+
+func bulk(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url     string
+		addr    string
+		wantErr bool
+	}{
+		{"redis://localhost", "localhost:6379", false},
+		{"rediss://user:pw@cache.example.com:6380/3", "cache.example.com:6380", false},
+		{"http://localhost:6379", "", true},
+		{"redis://localhost/db", "", true},
+	}
+	for _, tt := range tests {
+		c, err := New(tt.url, "p:")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if err == nil && c.addr != tt.addr {
+			t.Errorf("New(%q) addr = %q, want %q", tt.url, c.addr, tt.addr)
+		}
+	}
+}
+
+func TestClientCommands(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	ctx := context.Background()
+
+	unauthenticated, err := New(srv.url(""), "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replyErr Error
+	if err := unauthenticated.Ping(ctx); !errors.As(err, &replyErr) {
+		t.Errorf("Expected an error reply without the password, got %v", err)
+	}
+
+	c, err := New(srv.url(":secret@"), "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+
+	if _, ok, err := c.Get(ctx, c.Key("missing")); ok || err != nil {
+		t.Errorf("Expected a missing key, got %v, %v", ok, err)
+	}
+	if err := c.Set(ctx, c.Key("a", "b"), "multi\r\nline", time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if value, ok, err := c.Get(ctx, "test:a:b"); !ok || err != nil || value != "multi\r\nline" {
+		t.Errorf("Get: got %q, %v, %v", value, ok, err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := c.PushCapped(ctx, c.Key("list"), strconv.Itoa(i), 3, time.Minute); err != nil {
+			t.Fatalf("PushCapped failed: %v", err)
+		}
+	}
+	if values, err := c.List(ctx, c.Key("list")); err != nil || strings.Join(values, ",") != "4,3,2" {
+		t.Errorf("Expected the newest three entries, got %v, %v", values, err)
+	}
+
+	if _, err := c.Do(ctx, "FLUSHALL"); !errors.As(err, &replyErr) {
+		t.Errorf("Expected an error reply, got %v", err)
+	}
+	// The connection stays usable after an error reply
+	if err := c.Ping(ctx); err != nil {
+		t.Errorf("Ping after an error reply failed: %v", err)
+	}
+}
+
+func TestReserveSlot(t *testing.T) {
+	srv := newFakeServer(t, "")
+	c, err := New(srv.url(""), "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	interval := time.Second
+	first, err := c.ReserveSlot(context.Background(), interval)
+	if err != nil || first != 0 {
+		t.Fatalf("Expected the first slot immediately, got %v, %v", first, err)
+	}
+	second, err := c.ReserveSlot(context.Background(), interval)
+	if err != nil || second <= interval/2 || second > interval {
+		t.Errorf("Expected the second slot about an interval later, got %v, %v", second, err)
+	}
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// anonymizeRule is a compiled term → placeholder substitution
+type anonymizeRule struct {
+	term        string
+	placeholder string
+	termPattern *regexp.Regexp
+	restore     *regexp.Regexp
+}
+
+// anonymizer replaces configured sensitive terms before a request leaves the server
+// and restores them in the answer
+type anonymizer struct {
+	rules []anonymizeRule
+}
+
+// newAnonymizer compiles the configured rules, returning nil when there are none
+func newAnonymizer(rules []config.AnonymizeRule) *anonymizer {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	a := &anonymizer{}
+	for i, rule := range rules {
+		placeholder := rule.Replacement
+		if placeholder == "" {
+			placeholder = fmt.Sprintf("ENTITY_%d", i+1)
+		}
+		a.rules = append(a.rules, anonymizeRule{
+			term:        rule.Term,
+			placeholder: placeholder,
+			termPattern: wordPattern(rule.Term, true),
+			restore:     wordPattern(placeholder, false),
+		})
+	}
+
+	// Longer terms first so "Project Falcon" wins over "Falcon"
+	sort.SliceStable(a.rules, func(i, j int) bool {
+		return len(a.rules[i].term) > len(a.rules[j].term)
+	})
+	return a
+}
+
+// wordPattern matches text as a whole word, optionally ignoring case
+func wordPattern(text string, ignoreCase bool) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(text)
+	if first, _ := utf8.DecodeRuneInString(text); isWordRune(first) {
+		pattern = `\b` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(text); isWordRune(last) {
+		pattern += `\b`
+	}
+	if ignoreCase {
+		pattern = `(?i)` + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// isWordRune reports whether r counts as a word character for \b matching
+func isWordRune(r rune) bool {
+	return r == '_' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+// apply returns a copy of req with sensitive terms replaced and the number of replacements made
+func (a *anonymizer) apply(req *types.PerplexityRequest) (*types.PerplexityRequest, int) {
+	anonymized := *req
+	anonymized.Messages = make([]types.Message, len(req.Messages))
+	copy(anonymized.Messages, req.Messages)
+
+	count := 0
+	for i := range anonymized.Messages {
+		for _, rule := range a.rules {
+			content := anonymized.Messages[i].Content
+			count += len(rule.termPattern.FindAllStringIndex(content, -1))
+			anonymized.Messages[i].Content = rule.termPattern.ReplaceAllLiteralString(content, rule.placeholder)
+		}
+	}
+	return &anonymized, count
+}
+
+// restore returns a copy of resp with placeholders in the answer and related questions
+// swapped back for the original terms
+func (a *anonymizer) restore(resp *types.PerplexityResponse) *types.PerplexityResponse {
+	restored := *resp
+
+	restored.Choices = make([]types.Choice, len(resp.Choices))
+	copy(restored.Choices, resp.Choices)
+	for i := range restored.Choices {
+		restored.Choices[i].Message.Content = a.restoreText(restored.Choices[i].Message.Content)
+	}
+
+	restored.RelatedQuestions = make([]string, len(resp.RelatedQuestions))
+	for i, question := range resp.RelatedQuestions {
+		restored.RelatedQuestions[i] = a.restoreText(question)
+	}
+	return &restored
+}
+
+// restoreText swaps placeholders in text back for their original terms
+func (a *anonymizer) restoreText(text string) string {
+	for _, rule := range a.rules {
+		text = rule.restore.ReplaceAllLiteralString(text, rule.term)
+	}
+	return text
+}
+
+// callAnonymized sends a standalone request with sensitive terms replaced, restoring them in the answer
+func (s *Searcher) callAnonymized(ctx context.Context, req *types.PerplexityRequest) (*types.PerplexityResponse, error) {
+	if s.anonymizer == nil {
+		return s.client.callAPI(ctx, req)
+	}
+
+	anonymized, _ := s.anonymizer.apply(req)
+	resp, err := s.client.callAPI(ctx, anonymized)
+	if err != nil {
+		return nil, err
+	}
+	return s.anonymizer.restore(resp), nil
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestAnonymizedSearch(t *testing.T) {
+	var sent string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req.Messages[len(req.Messages)-1].Content
+		resp := textResponse(req.Model, "ENTITY_3 competes with Acme Corp in the ENTITY_1 space.")
+		resp.RelatedQuestions = []string{"Who funds ENTITY_3?"}
+		return resp
+	})
+	s.anonymizer = newAnonymizer([]config.AnonymizeRule{
+		{Term: "Falcon"},
+		{Term: "Jane Doe", Replacement: "Acme Corp"},
+		{Term: "Project Falcon"},
+	})
+
+	params := &SearchParams{Query: "Who competes with project falcon, led by Jane Doe? Is Falconry related?"}
+	if _, err := s.Search(context.Background(), params); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	for _, leaked := range []string{"falcon,", "Jane Doe"} {
+		if strings.Contains(sent, leaked) {
+			t.Errorf("Sent query leaked %q: %s", leaked, sent)
+		}
+	}
+	if !strings.Contains(sent, "Falconry") {
+		t.Errorf("Partial word matches should be left alone: %s", sent)
+	}
+
+	resp, err := s.execute(context.Background(), s.buildRequest(&SearchParams{Query: "Project Falcon"}, types.ModelSonar), &SearchParams{})
+	if err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	want := "Project Falcon competes with Jane Doe in the Falcon space."
+	if got := resp.Choices[0].Message.Content; got != want {
+		t.Errorf("Restored answer mismatch: got %q, want %q", got, want)
+	}
+	if got := resp.RelatedQuestions[0]; got != "Who funds Project Falcon?" {
+		t.Errorf("Restored related question mismatch: got %q", got)
+	}
+}
+
+func TestNewAnonymizerEmpty(t *testing.T) {
+	if newAnonymizer(nil) != nil {
+		t.Error("Expected nil anonymizer with no rules")
+	}
+}
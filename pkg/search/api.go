@@ -0,0 +1,188 @@
+// Package search runs Perplexity searches and caches their results. The MCP handler is one
+// client of it; Go programs can use it directly through New and Run.
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// Option adjusts the configuration of a Searcher created with New. Settings without an
+// option of their own can be changed with a custom Option that edits the config.Config.
+type Option func(*config.Config)
+
+// WithModel sets the default model, used when SearchParams.Model is empty
+func WithModel(model string) Option {
+	return func(cfg *config.Config) { cfg.DefaultModel = model }
+}
+
+// WithTimeout sets the timeout of each API call
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *config.Config) { cfg.Timeout = timeout }
+}
+
+// WithRateLimit spaces API calls to at most requestsPerMinute; 0 disables the limit
+func WithRateLimit(requestsPerMinute int) Option {
+	return func(cfg *config.Config) { cfg.RateLimit = requestsPerMinute }
+}
+
+// WithResultsFolder caches every result in folder, as PERPLEXITY_RESULTS_ROOT_FOLDER does
+func WithResultsFolder(folder string) Option {
+	return func(cfg *config.Config) { cfg.ResultsRootFolder = folder }
+}
+
+// WithMaxTokens sets the default answer length limit
+func WithMaxTokens(maxTokens int) Option {
+	return func(cfg *config.Config) { cfg.MaxTokens = maxTokens }
+}
+
+// WithTemperature sets the default sampling temperature
+func WithTemperature(temperature float64) Option {
+	return func(cfg *config.Config) { cfg.Temperature = temperature }
+}
+
+// WithUserAgent sets the User-Agent header of API and page requests
+func WithUserAgent(userAgent string) Option {
+	return func(cfg *config.Config) { cfg.UserAgent = userAgent }
+}
+
+// WithContextTokenBudget sets the token budget of the grounding context sent with a query
+func WithContextTokenBudget(tokens int) Option {
+	return func(cfg *config.Config) { cfg.ContextTokenBudget = tokens }
+}
+
+// WithOutlineThreshold sets the result length, in bytes, above which a table of contents is
+// prepended; 0 disables it
+func WithOutlineThreshold(bytes int) Option {
+	return func(cfg *config.Config) { cfg.OutlineThreshold = bytes }
+}
+
+// New creates a Searcher for programs that import this package instead of running the MCP
+// server. It starts from config.Default, applies opts in order, and reads no environment
+// variables.
+func New(apiKey string, opts ...Option) (*Searcher, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	cfg := config.Default()
+	cfg.APIKey = apiKey
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := config.ValidateModel(cfg.DefaultModel); err != nil {
+		return nil, fmt.Errorf("invalid model: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive")
+	}
+	if cfg.RateLimit < 0 {
+		return nil, fmt.Errorf("rate limit must not be negative")
+	}
+	return NewSearcher(cfg)
+}
+
+// With returns a Searcher that applies opts on top of this Searcher's configuration, leaving
+// this one unchanged. The two share their HTTP clients, rate limiter, and caches, so With is
+// cheap enough to call per request. Options for settings that New turns into those shared
+// parts, such as WithRateLimit, have no effect on the derived Searcher. Custom options must
+// replace the maps and slices of the config.Config rather than modify them in place.
+func (s *Searcher) With(opts ...Option) (*Searcher, error) {
+	if len(opts) == 0 {
+		return s, nil
+	}
+	cfg := *s.config()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := config.ValidateModel(cfg.DefaultModel); err != nil {
+		return nil, fmt.Errorf("invalid model: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive")
+	}
+
+	client := *s.client
+	client.timeout = cfg.Timeout
+	client.userAgent = cfg.UserAgent
+	client.stream = cfg.Stream
+
+	derived := *s
+	derived.source = &cfg
+	derived.client = &client
+	return &derived, nil
+}
+
+// CachingEnabled reports whether results are cached, so that ListPrevious and
+// GetPreviousResult can find them
+func (s *Searcher) CachingEnabled() bool {
+	return cache.IsCachingEnabled(s.config().ResultsRootFolder)
+}
+
+// MemoryCacheStats returns the size and lookup counts of the in-memory result cache. Its
+// Capacity is 0 when PERPLEXITY_RESULT_CACHE_SIZE turned it off.
+func (s *Searcher) MemoryCacheStats() cache.LRUStats {
+	return s.results.Stats()
+}
+
+// optionsKey is the context key of the options added by WithOptions
+type optionsKey struct{}
+
+// WithOptions returns a context carrying opts, which Run applies to its call through With.
+// Options already in ctx apply first, so an inner WithOptions overrides an outer one.
+func WithOptions(ctx context.Context, opts ...Option) context.Context {
+	prev, _ := ctx.Value(optionsKey{}).([]Option)
+	return context.WithValue(ctx, optionsKey{}, append(append([]Option{}, prev...), opts...))
+}
+
+// Response is a search result split into typed fields
+type Response struct {
+	ID      string   // Cache ID of the result; empty when caching is disabled
+	Content string   // The whole markdown result, with its appended sections and notes
+	Answer  string   // The answer alone
+	Sources []string // Source URLs, in citation order
+}
+
+// SearchMethod is a search of a Searcher, such as (*Searcher).AcademicSearch. Searches that
+// take more arguments, such as CompareModels, can be wrapped in a function literal.
+type SearchMethod func(s *Searcher, ctx context.Context, params *SearchParams) (string, error)
+
+// Run performs a search and returns its result as a Response. With caching enabled, the
+// searches return a summary of the saved result, so its content is read back from the cache.
+// Options added to ctx with WithOptions apply to this call only.
+//
+//	resp, err := s.Run(ctx, (*search.Searcher).AcademicSearch, &search.SearchParams{Query: "CRISPR off-target effects"})
+func (s *Searcher) Run(ctx context.Context, method SearchMethod, params *SearchParams) (*Response, error) {
+	if opts, _ := ctx.Value(optionsKey{}).([]Option); len(opts) > 0 {
+		derived, err := s.With(opts...)
+		if err != nil {
+			return nil, err
+		}
+		s = derived
+	}
+
+	output, err := method(s, ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{Content: output}
+	var saved struct {
+		UniqueID string `json:"unique_id"`
+	}
+	if strings.HasPrefix(output, "{") && json.Unmarshal([]byte(output), &saved) == nil && saved.UniqueID != "" {
+		content, err := s.readResult(saved.UniqueID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cached result: %w", err)
+		}
+		resp.ID, resp.Content = saved.UniqueID, content
+	}
+	resp.Answer = strings.TrimSpace(answerBody(resp.Content))
+	resp.Sources = listedSources(resp.Content)
+	return resp, nil
+}
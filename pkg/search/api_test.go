@@ -0,0 +1,125 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNew(t *testing.T) {
+	t.Setenv("PERPLEXITY_DEFAULT_MODEL", "sonar-reasoning")
+
+	s, err := New("key", WithModel(types.ModelSonarPro), WithTimeout(5*time.Second), WithRateLimit(30),
+		Option(func(cfg *config.Config) { cfg.DeepSourcesCount = 5 }))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if s.config().DefaultModel != types.ModelSonarPro || s.config().Timeout != 5*time.Second || s.config().RateLimit != 30 || s.config().DeepSourcesCount != 5 {
+		t.Errorf("Expected the options applied, got %+v", s.config())
+	}
+	if s.config().MaxTokens != types.DefaultMaxTokens || !s.config().RetryOnEmpty {
+		t.Errorf("Expected the remaining settings at their defaults, got %+v", s.config())
+	}
+
+	for name, opts := range map[string][]Option{
+		"unknown model":  {WithModel("gpt-4")},
+		"zero timeout":   {WithTimeout(0)},
+		"negative limit": {WithRateLimit(-1)},
+	} {
+		if _, err := New("key", opts...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := New(""); err == nil {
+		t.Error("Expected an error without an API key")
+	}
+}
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(textResponse("sonar-pro", "Off-target edits are rare [1].", "https://nature.com/a"))
+	}))
+	defer srv.Close()
+
+	for _, folder := range []string{"", t.TempDir()} {
+		s, err := New("key", WithResultsFolder(folder))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.client.baseURL = srv.URL
+
+		resp, err := s.Run(context.Background(), (*Searcher).Search, &SearchParams{Query: "CRISPR off-target effects"})
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if (resp.ID != "") != (folder != "") {
+			t.Errorf("Expected a cache ID only with a results folder, got %q", resp.ID)
+		}
+		if resp.Answer != "Off-target edits are rare [1]." || len(resp.Sources) != 1 || resp.Sources[0] != "https://nature.com/a" {
+			t.Errorf("Unexpected response %+v", resp)
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model     string `json:"model"`
+			MaxTokens int    `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		models = append(models, fmt.Sprintf("%s/%d", req.Model, req.MaxTokens))
+		json.NewEncoder(w).Encode(textResponse(req.Model, "Answer [1].", "https://example.com/a"))
+	}))
+	defer srv.Close()
+
+	s, err := New("key", WithModel(types.ModelSonar), WithMaxTokens(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.client.baseURL = srv.URL
+
+	derived, err := s.With(WithModel(types.ModelSonarPro), WithMaxTokens(900), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("With failed: %v", err)
+	}
+	if derived.client.timeout != time.Second || s.client.timeout == time.Second {
+		t.Errorf("Expected the timeout overridden on the derived client only")
+	}
+	if _, err := s.With(WithModel("gpt-4")); err == nil {
+		t.Error("Expected an error for an unknown model")
+	}
+
+	ctx := WithOptions(context.Background(), WithModel(types.ModelSonarReasoning))
+	ctx = WithOptions(ctx, WithMaxTokens(700))
+	params := func() *SearchParams { return &SearchParams{Query: "q"} }
+	if _, err := derived.Run(context.Background(), (*Searcher).Search, params()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Run(ctx, (*Searcher).Search, params()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Run(context.Background(), (*Searcher).Search, params()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		types.ModelSonarPro + "/900",
+		types.ModelSonarReasoning + "/700",
+		types.ModelSonar + "/500",
+	}
+	if fmt.Sprint(models) != fmt.Sprint(want) {
+		t.Errorf("Expected requests %v, got %v", want, models)
+	}
+	if s.config().DefaultModel != types.ModelSonar || s.config().MaxTokens != 500 {
+		t.Errorf("Expected the original config unchanged, got %+v", s.config())
+	}
+}
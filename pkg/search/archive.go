@@ -0,0 +1,183 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+)
+
+const (
+	// waybackAvailableURL is the Wayback Machine availability API
+	waybackAvailableURL = "https://archive.org/wayback/available"
+	// waybackSaveURL is the Wayback Machine Save Page Now endpoint; the page URL is appended
+	waybackSaveURL = "https://web.archive.org/save/"
+	// waybackBase resolves the relative snapshot paths returned by Save Page Now
+	waybackBase = "https://web.archive.org"
+)
+
+// archiveConcurrency bounds parallel Save Page Now submissions, which archive.org rate limits
+const archiveConcurrency = 3
+
+// maxArchivedCitations bounds how many citations of one result are submitted
+const maxArchivedCitations = 20
+
+// archiveTimeout bounds the archiving of one result's citations
+const archiveTimeout = 5 * time.Minute
+
+// waybackClient looks up and creates archived copies of pages in the Wayback Machine
+type waybackClient struct {
+	httpClient   *http.Client
+	userAgent    string
+	availableURL string
+	saveURL      string
+}
+
+// snapshot returns the URL of the closest archived copy of pageURL, or "" when none exists
+func (c *waybackClient) snapshot(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.availableURL+"?url="+url.QueryEscape(pageURL), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var availability struct {
+		ArchivedSnapshots struct {
+			Closest struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+				Status    string `json:"status"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.Unmarshal(body, &availability); err != nil {
+		return "", fmt.Errorf("failed to parse Wayback Machine response: %w", err)
+	}
+	closest := availability.ArchivedSnapshots.Closest
+	if !closest.Available || closest.Status != "200" {
+		return "", nil
+	}
+	return closest.URL, nil
+}
+
+// save submits pageURL to Save Page Now and returns the URL of the new snapshot
+func (c *waybackClient) save(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.saveURL+pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	// Save Page Now names the snapshot in Content-Location, or redirects to it
+	if location := resp.Header.Get("Content-Location"); strings.HasPrefix(location, "/web/") {
+		return waybackBase + location, nil
+	}
+	if final := resp.Request.URL; strings.HasPrefix(final.Path, "/web/") {
+		return final.String(), nil
+	}
+	return "", fmt.Errorf("response did not name a snapshot")
+}
+
+// archiveInBackground archives a saved result's citations after the call returns, since Save
+// Page Now often takes tens of seconds per page, within archiveTimeout overall
+func (s *Searcher) archiveInBackground(ctx context.Context, root, uniqueID, content string) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		ctx, cancel := context.WithTimeout(ctx, archiveTimeout)
+		defer cancel()
+		s.archiveCitations(ctx, root, uniqueID, content)
+	}()
+}
+
+// Wait blocks until the background work of earlier calls, such as citation archiving, is
+// done. Commands that exit right after a search call it first.
+func (s *Searcher) Wait() {
+	if s.background != nil {
+		s.background.Wait()
+	}
+}
+
+// archiveCitations submits the source URLs of a saved result in root to the Wayback Machine and
+// records the snapshots in its metadata. A URL that cannot be saved falls back to its
+// closest existing snapshot; URLs with neither are left out.
+func (s *Searcher) archiveCitations(ctx context.Context, root, uniqueID, content string) {
+	links := listedSources(content)
+	if len(links) > maxArchivedCitations {
+		links = links[:maxArchivedCitations]
+	}
+	if len(links) == 0 {
+		return
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		archived = map[string]string{}
+		sem      = make(chan struct{}, archiveConcurrency)
+	)
+	for _, link := range links {
+		wg.Add(1)
+		go func(link string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			snapshot, err := s.wayback.save(ctx, link)
+			if err != nil {
+				logging.FromContext(ctx).Warn("failed to archive citation", "url", link, "result_id", uniqueID, "error", err)
+				if snapshot, err = s.wayback.snapshot(ctx, link); err != nil {
+					return
+				}
+			}
+			if snapshot != "" {
+				mu.Lock()
+				archived[link] = snapshot
+				mu.Unlock()
+			}
+		}(link)
+	}
+	wg.Wait()
+
+	if len(archived) == 0 {
+		return
+	}
+	if err := cache.RecordArchivedURLs(root, uniqueID, archived); err != nil {
+		logging.FromContext(ctx).Warn("failed to record archived URLs", "result_id", uniqueID, "error", err)
+	}
+}
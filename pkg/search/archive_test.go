@@ -0,0 +1,105 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestArchiveCitations(t *testing.T) {
+	wayback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/web/"):
+		case r.URL.Path == "/save/https://example.com/a":
+			w.Header().Set("Content-Location", "/web/20240101000000/https://example.com/a")
+		case r.URL.Path == "/save/https://example.com/b":
+			w.Header().Set("Location", "/web/20240101000000/https://example.com/b")
+			w.WriteHeader(http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, "/save/"):
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Query().Get("url") == "https://example.com/c":
+			fmt.Fprint(w, `{"archived_snapshots":{"closest":{"available":true,"status":"200","url":"http://web.archive.org/web/2019/https://example.com/c"}}}`)
+		default:
+			fmt.Fprint(w, `{"archived_snapshots":{}}`)
+		}
+	}))
+	defer wayback.Close()
+
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(types.ModelSonar, "Answer[1][2][3][4].",
+			"https://example.com/a", "https://example.com/b", "https://example.com/c", "https://example.com/d")
+	})
+	s.config().ResultsRootFolder = t.TempDir()
+	s.config().ArchiveCitations = true
+	s.wayback = &waybackClient{httpClient: wayback.Client(), availableURL: wayback.URL, saveURL: wayback.URL + "/save/"}
+
+	if _, err := s.Search(context.Background(), &SearchParams{Query: "q"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	s.Wait()
+
+	queries, _ := cache.ListPreviousQueries(s.config().ResultsRootFolder)
+	if len(queries) != 1 {
+		t.Fatalf("Expected 1 cached result, got %d", len(queries))
+	}
+	metadata, err := cache.GetMetadata(s.config().ResultsRootFolder, queries[0].UniqueID)
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"https://example.com/a": "https://web.archive.org/web/20240101000000/https://example.com/a",
+		"https://example.com/b": wayback.URL + "/web/20240101000000/https://example.com/b",
+		"https://example.com/c": "http://web.archive.org/web/2019/https://example.com/c",
+	}
+	if len(metadata.ArchivedURLs) != len(expected) {
+		t.Errorf("Expected %d archived URLs, got %v", len(expected), metadata.ArchivedURLs)
+	}
+	for link, want := range expected {
+		if got := metadata.ArchivedURLs[link]; got != want {
+			t.Errorf("Archived URL for %s = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestArchiveCitationsInBackground(t *testing.T) {
+	release := make(chan struct{})
+	wayback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Location", "/web/20240101000000/https://example.com/a")
+	}))
+	defer wayback.Close()
+
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(types.ModelSonar, "Answer[1].", "https://example.com/a")
+	})
+	s.config().ResultsRootFolder = t.TempDir()
+	s.config().ArchiveCitations = true
+	s.wayback = &waybackClient{httpClient: wayback.Client(), availableURL: wayback.URL, saveURL: wayback.URL + "/save/"}
+
+	// The search returns while Save Page Now is still working
+	if _, err := s.Search(context.Background(), &SearchParams{Query: "q"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	queries, _ := cache.ListPreviousQueries(s.config().ResultsRootFolder)
+	if len(queries) != 1 {
+		t.Fatalf("Expected 1 cached result, got %d", len(queries))
+	}
+	id := queries[0].UniqueID
+	if metadata, err := cache.GetMetadata(s.config().ResultsRootFolder, id); err != nil || len(metadata.ArchivedURLs) != 0 {
+		t.Fatalf("Expected no snapshots before archiving finishes, got %v, %v", metadata, err)
+	}
+
+	close(release)
+	s.Wait()
+	metadata, err := cache.GetMetadata(s.config().ResultsRootFolder, id)
+	if err != nil || metadata.ArchivedURLs["https://example.com/a"] == "" {
+		t.Errorf("Expected the snapshot recorded once archiving finished, got %v, %v", metadata, err)
+	}
+}
@@ -0,0 +1,51 @@
+package search
+
+// Asset classes accepted by the financial search asset_class parameter
+const (
+	AssetEquity    = "equity"
+	AssetCrypto    = "crypto"
+	AssetFX        = "fx"
+	AssetCommodity = "commodity"
+)
+
+// assetProfile tailors financial search to an asset class
+type assetProfile struct {
+	// symbolLabel names the ticker argument in the query context
+	symbolLabel string
+	// guidance is prepended to the query to shape the answer
+	guidance string
+	// domains are searched when the caller gives no domain filter
+	domains []string
+}
+
+var assetProfiles = map[string]assetProfile{
+	AssetEquity: {
+		symbolLabel: "Ticker",
+		guidance:    "Asset class: listed equity. Cite filings and earnings releases where possible, and give prices with their exchange and date",
+		domains:     []string{"sec.gov", "reuters.com", "bloomberg.com", "wsj.com", "finance.yahoo.com"},
+	},
+	AssetCrypto: {
+		symbolLabel: "Symbol",
+		guidance: "Asset class: cryptocurrency. Quote prices in USD with a timestamp and the aggregator or exchange used, " +
+			"include 24h change and market capitalization, and note that markets trade around the clock",
+		domains: []string{"coindesk.com", "coingecko.com", "coinmarketcap.com", "theblock.co", "cointelegraph.com"},
+	},
+	AssetFX: {
+		symbolLabel: "Currency pair",
+		guidance: "Asset class: foreign exchange. Quote rates as BASE/QUOTE with a timestamp, and mention relevant " +
+			"central bank policy and macroeconomic releases",
+		domains: []string{"reuters.com", "bloomberg.com", "fxstreet.com", "investing.com", "ecb.europa.eu"},
+	},
+	AssetCommodity: {
+		symbolLabel: "Contract",
+		guidance: "Asset class: commodity. Distinguish spot from futures prices, name the benchmark and contract month, " +
+			"and give units (e.g. per barrel, per troy ounce)",
+		domains: []string{"reuters.com", "bloomberg.com", "cmegroup.com", "eia.gov", "investing.com"},
+	},
+}
+
+// IsValidAssetClass reports whether assetClass is supported
+func IsValidAssetClass(assetClass string) bool {
+	_, ok := assetProfiles[assetClass]
+	return ok
+}
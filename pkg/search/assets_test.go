@@ -0,0 +1,61 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestFinancialSearchAssetClass(t *testing.T) {
+	var got *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		got = req
+		return textResponse(req.Model, "ETH rose 4%.")
+	})
+
+	_, err := s.FinancialSearch(context.Background(), &SearchParams{Query: "why did it move", SearchType: "financial", AssetClass: AssetCrypto, Ticker: "ETH"})
+	if err != nil {
+		t.Fatalf("FinancialSearch failed: %v", err)
+	}
+	if strings.Join(got.SearchDomainFilter, ",") != strings.Join(assetProfiles[AssetCrypto].domains, ",") {
+		t.Errorf("Domain filter mismatch: got %v", got.SearchDomainFilter)
+	}
+	prompt := got.Messages[0].Content
+	if !strings.Contains(prompt, "Asset class: cryptocurrency") || !strings.Contains(prompt, "Symbol: ETH") {
+		t.Errorf("Prompt missing asset scaffolding: %s", prompt)
+	}
+
+	// Caller-supplied domains win over the asset class defaults
+	_, err = s.FinancialSearch(context.Background(), &SearchParams{Query: "EUR outlook", SearchType: "financial", AssetClass: AssetFX, SearchDomainFilter: []string{"ecb.europa.eu"}})
+	if err != nil {
+		t.Fatalf("FinancialSearch failed: %v", err)
+	}
+	if len(got.SearchDomainFilter) != 1 || got.SearchDomainFilter[0] != "ecb.europa.eu" {
+		t.Errorf("Explicit domain filter overridden: got %v", got.SearchDomainFilter)
+	}
+
+	// Without an asset class the search is unfiltered as before
+	_, err = s.FinancialSearch(context.Background(), &SearchParams{Query: "AAPL news", SearchType: "financial", Ticker: "AAPL"})
+	if err != nil {
+		t.Fatalf("FinancialSearch failed: %v", err)
+	}
+	if len(got.SearchDomainFilter) != 0 || !strings.Contains(got.Messages[0].Content, "Ticker: AAPL") {
+		t.Errorf("Default financial search changed: filter %v, prompt %s", got.SearchDomainFilter, got.Messages[0].Content)
+	}
+}
+
+func TestFinancialSearchAssetClassErrors(t *testing.T) {
+	s := newTestSearcher(t, nil)
+
+	_, err := s.FinancialSearch(context.Background(), &SearchParams{Query: "q", AssetClass: "bonds"})
+	if err == nil || !strings.Contains(err.Error(), "invalid asset_class") {
+		t.Errorf("Expected invalid asset class error, got %v", err)
+	}
+
+	_, err = s.FinancialSearch(context.Background(), &SearchParams{Query: "q", AssetClass: AssetCrypto, Ticker: "BTC", Event: EventSplit})
+	if err == nil || !strings.Contains(err.Error(), "event is only supported") {
+		t.Errorf("Expected event asset class error, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// autoLongQueryWords is the word count above which a query is considered complex
+const autoLongQueryWords = 25
+
+var (
+	cashtagPattern = regexp.MustCompile(`\$[A-Z]{1,5}\b`)
+
+	autoResearchKeywords = []string{
+		"paper", "papers", "study", "studies", "research", "journal", "peer-reviewed",
+		"earnings", "10-k", "10-q", "8-k", "sec filing", "revenue", "balance sheet",
+	}
+	autoDepthKeywords = []string{
+		"in-depth", "in depth", "comprehensive", "detailed", "thorough", "exhaustive", "deep dive",
+	}
+	autoReasoningKeywords = []string{
+		"why ", "explain", "compare", "comparison", "versus", " vs ", "analyze", "analyse",
+		"pros and cons", "trade-off", "tradeoff", "step by step", "implications",
+	}
+)
+
+// selectModel picks a concrete model for the auto pseudo-model using local query heuristics.
+// It returns the model and a short human-readable reason for the choice.
+func selectModel(params *SearchParams) (string, string) {
+	query := strings.ToLower(params.Query)
+
+	if params.Ticker != "" || cashtagPattern.MatchString(params.Query) {
+		return types.ModelSonarPro, "ticker detected"
+	}
+	if params.SearchType == "academic" || params.SearchType == "financial" {
+		return types.ModelSonarPro, params.SearchType + " search"
+	}
+	if keyword := matchKeyword(query, autoResearchKeywords); keyword != "" {
+		return types.ModelSonarPro, "research keyword '" + keyword + "'"
+	}
+	if keyword := matchKeyword(query, autoDepthKeywords); keyword != "" {
+		return types.ModelSonarPro, "depth requested ('" + keyword + "')"
+	}
+	if keyword := matchKeyword(" "+query+" ", autoReasoningKeywords); keyword != "" {
+		return types.ModelSonarReasoning, "analytical keyword '" + strings.TrimSpace(keyword) + "'"
+	}
+	if len(strings.Fields(query)) > autoLongQueryWords {
+		return types.ModelSonarPro, "long query"
+	}
+	return types.ModelSonar, "short factual query"
+}
+
+// matchKeyword returns the first keyword contained in s, or an empty string
+func matchKeyword(s string, keywords []string) string {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
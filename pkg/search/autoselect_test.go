@@ -0,0 +1,53 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestSelectModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		params SearchParams
+		want   string
+	}{
+		{"short fact", SearchParams{Query: "capital of France", SearchType: "general"}, types.ModelSonar},
+		{"cashtag", SearchParams{Query: "how is $NVDA doing", SearchType: "general"}, types.ModelSonarPro},
+		{"ticker param", SearchParams{Query: "latest news", Ticker: "AAPL", SearchType: "general"}, types.ModelSonarPro},
+		{"academic type", SearchParams{Query: "graphene", SearchType: "academic"}, types.ModelSonarPro},
+		{"research keyword", SearchParams{Query: "recent papers on CRISPR", SearchType: "general"}, types.ModelSonarPro},
+		{"depth keyword", SearchParams{Query: "comprehensive overview of Rust async", SearchType: "general"}, types.ModelSonarPro},
+		{"analytical", SearchParams{Query: "compare Postgres and MySQL replication", SearchType: "general"}, types.ModelSonarReasoning},
+		{"long query", SearchParams{Query: strings.Repeat("word ", 30), SearchType: "general"}, types.ModelSonarPro},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := selectModel(&tt.params)
+			if got != tt.want {
+				t.Errorf("selectModel mismatch: got %s (%s), want %s", got, reason, tt.want)
+			}
+			if reason == "" {
+				t.Error("Expected a non-empty reason")
+			}
+		})
+	}
+}
+
+func TestBuildRequestResolvesAutoModel(t *testing.T) {
+	s := &Searcher{source: testConfig()}
+	params := &SearchParams{Query: "capital of France", SearchType: "general", Model: types.ModelAuto}
+
+	req := s.buildRequest(params, types.DefaultModel)
+	if req.Model != types.ModelSonar {
+		t.Errorf("Model mismatch: got %s, want %s", req.Model, types.ModelSonar)
+	}
+	if params.Model != types.ModelSonar {
+		t.Errorf("params.Model mismatch: got %s, want %s", params.Model, types.ModelSonar)
+	}
+	if len(params.notes) != 1 || !strings.Contains(params.notes[0], "auto-selected") {
+		t.Errorf("Expected auto-selection note, got %v", params.notes)
+	}
+}
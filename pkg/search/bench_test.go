@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// largeResponse builds a sonar-pro style answer citing n sources, each with a search result
+func largeResponse(n int) *types.PerplexityResponse {
+	var content strings.Builder
+	citations := make([]string, n)
+	results := make([]types.SearchResult, n)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&content, "Finding %d is supported by this source [%d]. ", i+1, i+1)
+		citations[i] = fmt.Sprintf("https://source%d.example.com/articles/%d", i, i)
+		results[i] = types.SearchResult{
+			Title:   fmt.Sprintf("Source %d: a study of the topic", i+1),
+			URL:     citations[i],
+			Snippet: strings.Repeat("Relevant snippet text from the cited page. ", 5),
+		}
+	}
+
+	resp := textResponse(types.ModelSonarPro, content.String(), citations...)
+	resp.SearchResults = results
+	for i := 0; i < n/10; i++ {
+		resp.Images = append(resp.Images, types.Image{ImageURL: fmt.Sprintf("https://img.example.com/%d.png", i)})
+		resp.RelatedQuestions = append(resp.RelatedQuestions, fmt.Sprintf("Follow-up question %d?", i))
+	}
+	return resp
+}
+
+func BenchmarkBuildRequest(b *testing.B) {
+	s, err := NewSearcher(testConfig())
+	if err != nil {
+		b.Fatalf("NewSearcher failed: %v", err)
+	}
+	maxTokens := 2000
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		params := &SearchParams{
+			Query:                "latest results on solid-state battery energy density",
+			SearchDomainFilter:   []string{"nature.com", "science.org", "arxiv.org"},
+			SearchExcludeDomains: []string{"pinterest.com"},
+			SearchRecencyFilter:  "month",
+			MaxTokens:            &maxTokens,
+		}
+		s.buildRequest(params, types.ModelSonarPro)
+	}
+}
+
+func BenchmarkFormatResponse(b *testing.B) {
+	s, err := NewSearcher(testConfig())
+	if err != nil {
+		b.Fatalf("NewSearcher failed: %v", err)
+	}
+
+	for _, n := range []int{10, 100, 500} {
+		resp := largeResponse(n)
+		b.Run(fmt.Sprintf("citations=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s.formatResponse(resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// GetPreviousResultChunk returns part of a cached result starting at offset (in bytes).
+// A chunkSize of zero returns everything from offset onwards.
+func (s *Searcher) GetPreviousResultChunk(ctx context.Context, uniqueID string, offset, chunkSize int) (string, error) {
+	if offset < 0 || chunkSize < 0 {
+		return "", fmt.Errorf("offset and chunk_size must be non-negative")
+	}
+
+	result, err := s.GetPreviousResult(ctx, uniqueID)
+	if err != nil {
+		return "", err
+	}
+	if offset == 0 && (chunkSize == 0 || chunkSize >= len(result)) {
+		return result, nil
+	}
+
+	return chunkResult(result, offset, chunkSize)
+}
+
+// chunkResult slices content into a chunk that ends on a line break where possible,
+// followed by a footer telling the caller where to continue
+func chunkResult(content string, offset, chunkSize int) (string, error) {
+	if offset >= len(content) {
+		return "", fmt.Errorf("offset %d is beyond the end of the result (%d bytes)", offset, len(content))
+	}
+	for offset > 0 && offset < len(content) && !utf8.RuneStart(content[offset]) {
+		offset++
+	}
+
+	end := len(content)
+	if chunkSize > 0 && offset+chunkSize < end {
+		end = offset + chunkSize
+		// Prefer breaking after a newline in the second half of the chunk
+		if i := strings.LastIndex(content[offset:end], "\n"); i >= chunkSize/2 {
+			end = offset + i + 1
+		}
+		for end > offset && !utf8.RuneStart(content[end]) {
+			end--
+		}
+		// A chunk smaller than the character at offset still returns that character, so
+		// paging always moves forward
+		if end == offset {
+			end++
+			for end < len(content) && !utf8.RuneStart(content[end]) {
+				end++
+			}
+		}
+	}
+
+	chunk := content[offset:end]
+	if end < len(content) {
+		chunk += fmt.Sprintf("\n\n---\n**Chunk:** bytes %d-%d of %d. Continue with `offset: %d`.", offset, end, len(content), end)
+	} else {
+		chunk += fmt.Sprintf("\n\n---\n**Chunk:** bytes %d-%d of %d. End of result.", offset, end, len(content))
+	}
+	return chunk, nil
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkResult(t *testing.T) {
+	content := "first line\nsecond line\nthird line\n"
+
+	chunk, err := chunkResult(content, 0, 16)
+	if err != nil {
+		t.Fatalf("chunkResult failed: %v", err)
+	}
+	if !strings.HasPrefix(chunk, "first line\n\n") {
+		t.Errorf("Chunk should break at the line ending, got %q", chunk)
+	}
+	if !strings.Contains(chunk, "Continue with `offset: 11`") {
+		t.Errorf("Chunk missing continuation offset: %q", chunk)
+	}
+
+	chunk, err = chunkResult(content, 11, 0)
+	if err != nil {
+		t.Fatalf("chunkResult failed: %v", err)
+	}
+	if !strings.HasPrefix(chunk, "second line\nthird line\n") || !strings.Contains(chunk, "End of result") {
+		t.Errorf("Final chunk mismatch: %q", chunk)
+	}
+
+	if _, err := chunkResult(content, len(content), 10); err == nil {
+		t.Error("Expected out of range error, got nil")
+	}
+}
+
+func TestChunkResultRuneBoundaries(t *testing.T) {
+	content := "ééééé"
+
+	chunk, err := chunkResult(content, 1, 3)
+	if err != nil {
+		t.Fatalf("chunkResult failed: %v", err)
+	}
+	body := chunk[:strings.Index(chunk, "\n\n---")]
+	if body != "é" {
+		t.Errorf("Chunk body mismatch: got %q, want %q", body, "é")
+	}
+}
+
+func TestChunkResultSmallChunks(t *testing.T) {
+	content := "aé"
+
+	// An offset inside the last character has nothing left to return
+	chunk, err := chunkResult(content, 2, 10)
+	if err != nil {
+		t.Fatalf("chunkResult failed: %v", err)
+	}
+	if !strings.HasPrefix(chunk, "\n\n---") || !strings.Contains(chunk, "End of result") {
+		t.Errorf("Expected an empty final chunk, got %q", chunk)
+	}
+
+	// A chunk smaller than a character returns the whole character and moves past it
+	chunk, err = chunkResult(content, 1, 1)
+	if err != nil {
+		t.Fatalf("chunkResult failed: %v", err)
+	}
+	if body := chunk[:strings.Index(chunk, "\n\n---")]; body != "é" || !strings.Contains(chunk, "End of result") {
+		t.Errorf("Expected the whole character, got %q", chunk)
+	}
+
+	chunk, err = chunkResult("ééé", 0, 1)
+	if err != nil {
+		t.Fatalf("chunkResult failed: %v", err)
+	}
+	if !strings.HasPrefix(chunk, "é") || !strings.Contains(chunk, "Continue with `offset: 2`") {
+		t.Errorf("Expected paging to continue after the first character, got %q", chunk)
+	}
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// citationMarkerPattern matches [n]-style inline citation markers
+var citationMarkerPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// outsideFences applies fn to the text outside fenced code blocks, so indexes such as
+// items[1] in code are never mistaken for citation markers
+func outsideFences(content string, fn func(string) string) string {
+	var out, prose strings.Builder
+	inFence := false
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```")
+		if !inFence && !fence {
+			prose.WriteString(line)
+			continue
+		}
+		out.WriteString(fn(prose.String()))
+		prose.Reset()
+		out.WriteString(line)
+		if fence {
+			inFence = !inFence
+		}
+	}
+	out.WriteString(fn(prose.String()))
+	return out.String()
+}
+
+// remapMarkers rewrites [n] markers using a 1-based old→new index mapping.
+// Markers mapped to 0 are removed; markers absent from the mapping are left untouched.
+// Fenced code blocks are left verbatim.
+func remapMarkers(content string, mapping map[int]int) string {
+	return outsideFences(content, func(prose string) string {
+		return citationMarkerPattern.ReplaceAllStringFunc(prose, func(marker string) string {
+			n, _ := strconv.Atoi(marker[1 : len(marker)-1])
+			newIndex, ok := mapping[n]
+			if !ok {
+				return marker
+			}
+			if newIndex == 0 {
+				return ""
+			}
+			return fmt.Sprintf("[%d]", newIndex)
+		})
+	})
+}
+
+// dedupeCitations removes duplicate URLs, returning the unique list and the old→new mapping
+func dedupeCitations(citations []string) ([]string, map[int]int) {
+	unique := make([]string, 0, len(citations))
+	mapping := make(map[int]int, len(citations))
+	seen := make(map[string]int, len(citations))
+
+	for i, citation := range citations {
+		key := citationKey(citation)
+		if existing, ok := seen[key]; ok {
+			mapping[i+1] = existing
+			continue
+		}
+		unique = append(unique, citation)
+		seen[key] = len(unique)
+		mapping[i+1] = len(unique)
+	}
+	return unique, mapping
+}
+
+// citationKey normalizes a URL for duplicate detection
+func citationKey(citation string) string {
+	key := strings.TrimSpace(citation)
+	if i := strings.Index(key, "#"); i >= 0 {
+		key = key[:i]
+	}
+	return strings.ToLower(strings.TrimSuffix(key, "/"))
+}
+
+// markerIndices returns the distinct marker numbers used in content outside code blocks, in ascending order
+func markerIndices(content string) []int {
+	var prose strings.Builder
+	outsideFences(content, func(text string) string {
+		prose.WriteString(text)
+		return text
+	})
+
+	seen := make(map[int]bool)
+	var indices []int
+	for _, match := range citationMarkerPattern.FindAllStringSubmatch(prose.String(), -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || seen[n] {
+			continue
+		}
+		seen[n] = true
+		indices = append(indices, n)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// normalizeCitations dedupes citations, renumbers the answer's markers to match,
+// and returns a markdown table mapping each marker to its source
+func normalizeCitations(content string, citations []string) (string, []string, string) {
+	unique, mapping := dedupeCitations(citations)
+	if len(unique) != len(citations) {
+		content = remapMarkers(content, mapping)
+	}
+
+	indices := markerIndices(content)
+	if len(indices) == 0 {
+		return content, unique, ""
+	}
+
+	referenced := make(map[int]bool, len(indices))
+	var table strings.Builder
+	table.WriteString("\n\n## Citation Map\n| Marker | Source |\n|---|---|\n")
+	for _, n := range indices {
+		referenced[n] = true
+		if n < 1 || n > len(unique) {
+			fmt.Fprintf(&table, "| [%d] | ⚠️ no matching source |\n", n)
+			continue
+		}
+		fmt.Fprintf(&table, "| [%d] | %s |\n", n, unique[n-1])
+	}
+	for i, citation := range unique {
+		if !referenced[i+1] {
+			fmt.Fprintf(&table, "| (unreferenced %d) | %s |\n", i+1, citation)
+		}
+	}
+
+	return content, unique, table.String()
+}
@@ -0,0 +1,77 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupeCitations(t *testing.T) {
+	citations := []string{"https://a.com/x", "https://b.com", "https://a.com/x/", "https://c.com#top"}
+
+	unique, mapping := dedupeCitations(citations)
+	if len(unique) != 3 {
+		t.Fatalf("Unique count mismatch: got %d, want 3", len(unique))
+	}
+
+	want := map[int]int{1: 1, 2: 2, 3: 1, 4: 3}
+	for old, newIndex := range want {
+		if mapping[old] != newIndex {
+			t.Errorf("mapping[%d] mismatch: got %d, want %d", old, mapping[old], newIndex)
+		}
+	}
+}
+
+func TestRemapMarkers(t *testing.T) {
+	got := remapMarkers("Fact one[1][3]. Fact two[2]. Fact three[4].", map[int]int{1: 1, 2: 0, 3: 1, 4: 2})
+	want := "Fact one[1][1]. Fact two. Fact three[2]."
+	if got != want {
+		t.Errorf("remapMarkers mismatch: got %q, want %q", got, want)
+	}
+}
+
+func TestRemapMarkersSkipsCodeBlocks(t *testing.T) {
+	content := "Use a slice[2].\n```go\nfirst := items[1]\nlast := items[2]\n```\nDone[1]."
+	got := remapMarkers(content, map[int]int{1: 0, 2: 1})
+	want := "Use a slice[1].\n```go\nfirst := items[1]\nlast := items[2]\n```\nDone."
+	if got != want {
+		t.Errorf("remapMarkers mismatch: got %q, want %q", got, want)
+	}
+	if indices := markerIndices(got); len(indices) != 1 || indices[0] != 1 {
+		t.Errorf("markerIndices mismatch: got %v, want [1]", indices)
+	}
+}
+
+func TestNormalizeCitations(t *testing.T) {
+	content := "Alpha[1]. Beta[3]. Gamma[5]."
+	citations := []string{"https://a.com", "https://b.com", "https://a.com/", "https://d.com"}
+
+	got, unique, table := normalizeCitations(content, citations)
+	if got != "Alpha[1]. Beta[1]. Gamma[5]." {
+		t.Errorf("Content mismatch: got %q", got)
+	}
+	if len(unique) != 3 {
+		t.Errorf("Unique count mismatch: got %d, want 3", len(unique))
+	}
+
+	expected := []string{
+		"## Citation Map",
+		"| [1] | https://a.com |",
+		"| [5] | ⚠️ no matching source |",
+		"| (unreferenced 2) | https://b.com |",
+	}
+	for _, want := range expected {
+		if !strings.Contains(table, want) {
+			t.Errorf("Table missing %q:\n%s", want, table)
+		}
+	}
+}
+
+func TestNormalizeCitationsWithoutMarkers(t *testing.T) {
+	_, unique, table := normalizeCitations("No markers here.", []string{"https://a.com"})
+	if table != "" {
+		t.Errorf("Expected no table, got %q", table)
+	}
+	if len(unique) != 1 {
+		t.Errorf("Unique count mismatch: got %d, want 1", len(unique))
+	}
+}
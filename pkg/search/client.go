@@ -9,6 +9,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/stats"
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
 	"github.com/prasanthmj/perplexity/pkg/types"
 )
 
@@ -16,26 +20,67 @@ const (
 	baseURL = "https://api.perplexity.ai/chat/completions"
 )
 
-// Client handles Perplexity API communication
+// Client handles Perplexity API communication. It is safe for concurrent use; all calls
+// share one connection pool through the HTTP client's transport.
 type Client struct {
 	apiKey     string
 	httpClient *http.Client
 	baseURL    string
+	limiter    *ratelimit.Limiter
+	userAgent  string
+	headers    map[string]string
+	timeout    time.Duration
+	stream     bool
 }
 
-// NewClient creates a new Perplexity API client
+// NewClient creates a new Perplexity API client whose calls time out after timeout
 func NewClient(apiKey string, timeout time.Duration) *Client {
 	return &Client{
-		apiKey: apiKey,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		baseURL: baseURL,
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+		baseURL:    baseURL,
+		timeout:    timeout,
 	}
 }
 
+type callTimeoutKey struct{}
+
+// withCallTimeout returns a context whose API calls time out after timeout instead of the
+// client's default
+func withCallTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, callTimeoutKey{}, timeout)
+}
+
 // callAPI makes a request to the Perplexity API
 func (c *Client) callAPI(ctx context.Context, req *types.PerplexityRequest) (*types.PerplexityResponse, error) {
+	start := time.Now()
+
+	// Wait for a rate limit slot; interactive calls are served before batch and watch work
+	if err := c.limiter.Wait(ctx); err != nil {
+		if ctxErr := contextError(err, time.Since(start)); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("rate limiter wait aborted: %w", err)
+	}
+
+	// The timeout covers the request itself, not time spent queued behind the rate limiter
+	timeout := c.timeout
+	if callTimeout, ok := ctx.Value(callTimeoutKey{}).(time.Duration); ok {
+		timeout = callTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Streamed requests are copied so the caller's request is left untouched
+	if c.stream && !req.Stream {
+		streamed := *req
+		streamed.Stream = true
+		req = &streamed
+	}
+
 	// Marshal request
 	reqBody, err := json.Marshal(req)
 	if err != nil {
@@ -48,20 +93,51 @@ func (c *Client) callAPI(ctx context.Context, req *types.PerplexityRequest) (*ty
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
+	// Set headers; operator-supplied headers go first so they can't replace authentication
+	for name, value := range c.headers {
+		httpReq.Header.Set(name, value)
+	}
+	if c.userAgent != "" {
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	// Make request
+	stats.Default.Model(req.Model)
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		metrics.APIRequests.Inc(req.Model, metrics.StatusClass(0))
+		if ctxErr := contextError(err, time.Since(start)); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &APIError{
+			ErrorType: ErrorTypeNetwork,
+			Message:   fmt.Sprintf("request failed: %v", err),
+			Hint:      "Check network connectivity to api.perplexity.ai and try again",
+			Retryable: true,
+			Err:       err,
+		}
 	}
 	defer resp.Body.Close()
+	metrics.APIRequests.Inc(req.Model, metrics.StatusClass(resp.StatusCode))
+	logging.FromContext(ctx).Debug("api response", "model", req.Model, "status", resp.StatusCode, "stream", req.Stream, "elapsed", time.Since(start))
+
+	if req.Stream && resp.StatusCode == http.StatusOK {
+		streamed, err := readStream(resp.Body, start)
+		if err != nil {
+			return nil, err
+		}
+		recordUsage(req.Model, streamed.Usage)
+		return streamed, nil
+	}
 
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		if ctxErr := contextError(err, time.Since(start)); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
@@ -69,7 +145,12 @@ func (c *Client) callAPI(ctx context.Context, req *types.PerplexityRequest) (*ty
 	if resp.StatusCode != http.StatusOK {
 		var errResp types.ErrorResponse
 		if err := json.Unmarshal(body, &errResp); err != nil {
-			return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+			return nil, &APIError{
+				ErrorType:  errorTypeForStatus(resp.StatusCode),
+				StatusCode: resp.StatusCode,
+				Message:    fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)),
+				Retryable:  isRetryableStatus(resp.StatusCode),
+			}
 		}
 		return nil, handleAPIError(resp.StatusCode, &errResp)
 	}
@@ -80,27 +161,47 @@ func (c *Client) callAPI(ctx context.Context, req *types.PerplexityRequest) (*ty
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	recordUsage(req.Model, perplexityResp.Usage)
 	return &perplexityResp, nil
 }
 
+// recordUsage counts a response's tokens for spend monitoring
+func recordUsage(model string, usage types.Usage) {
+	metrics.Tokens.Add(float64(usage.PromptTokens), model, "prompt")
+	metrics.Tokens.Add(float64(usage.CompletionTokens), model, "completion")
+}
+
 // handleAPIError converts API errors to meaningful error messages with helpful hints
 func handleAPIError(statusCode int, errResp *types.ErrorResponse) error {
+	apiErr := &APIError{
+		ErrorType:  errorTypeForStatus(statusCode),
+		StatusCode: statusCode,
+		Retryable:  isRetryableStatus(statusCode),
+	}
+
 	switch statusCode {
 	case http.StatusUnauthorized:
-		return fmt.Errorf("authentication failed: invalid API key. Please check your PERPLEXITY_API_KEY environment variable")
+		apiErr.Hint = "Please check your PERPLEXITY_API_KEY environment variable"
+		apiErr.Message = "authentication failed: invalid API key. " + apiErr.Hint
 	case http.StatusTooManyRequests:
-		return fmt.Errorf("rate limit exceeded: %s. Try reducing request frequency or using 'sonar' model for lower rate limits", errResp.Error.Message)
+		apiErr.Hint = "Try reducing request frequency or using 'sonar' model for lower rate limits"
+		apiErr.Message = fmt.Sprintf("rate limit exceeded: %s. %s", errResp.Error.Message, apiErr.Hint)
 	case http.StatusBadRequest:
 		// Add model-specific hints
 		if contains(errResp.Error.Message, "Invalid model") {
-			return fmt.Errorf("bad request: %s. Use 'sonar' for quick searches or 'sonar-pro' for comprehensive searches", errResp.Error.Message)
+			apiErr.Hint = "Use 'sonar' for quick searches or 'sonar-pro' for comprehensive searches"
+		} else {
+			apiErr.Hint = "Check your query parameters and try simplifying the request"
 		}
-		return fmt.Errorf("bad request: %s. Check your query parameters and try simplifying the request", errResp.Error.Message)
+		apiErr.Message = fmt.Sprintf("bad request: %s. %s", errResp.Error.Message, apiErr.Hint)
 	case http.StatusInternalServerError:
-		return fmt.Errorf("server error: %s. The Perplexity API is experiencing issues, please try again later", errResp.Error.Message)
+		apiErr.Hint = "The Perplexity API is experiencing issues, please try again later"
+		apiErr.Message = fmt.Sprintf("server error: %s. %s", errResp.Error.Message, apiErr.Hint)
 	default:
-		return fmt.Errorf("API error (%s): %s", errResp.Error.Type, errResp.Error.Message)
+		apiErr.Message = fmt.Sprintf("API error (%s): %s", errResp.Error.Type, errResp.Error.Message)
 	}
+
+	return apiErr
 }
 
 func contains(s, substr string) bool {
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestCallAPIHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		json.NewEncoder(w).Encode(textResponse(types.ModelSonar, "ok"))
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-api-key", 5*time.Second)
+	client.baseURL = srv.URL
+	client.userAgent = "acme-research/1.0"
+	client.headers = map[string]string{"X-Gateway-Key": "abc123"}
+
+	if _, err := client.callAPI(context.Background(), &types.PerplexityRequest{Model: types.ModelSonar}); err != nil {
+		t.Fatalf("callAPI failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"User-Agent":    "acme-research/1.0",
+		"X-Gateway-Key": "abc123",
+		"Authorization": "Bearer test-api-key",
+		"Content-Type":  "application/json",
+	}
+	for name, want := range expected {
+		if got.Get(name) != want {
+			t.Errorf("%s mismatch: got %q, want %q", name, got.Get(name), want)
+		}
+	}
+}
+
+// slowServer answers after delay, or gives up when the test ends
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			json.NewEncoder(w).Encode(textResponse(types.ModelSonar, "ok"))
+		case <-done:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+	return srv
+}
+
+func TestCallAPITimeout(t *testing.T) {
+	client := NewClient("test-api-key", 50*time.Millisecond)
+	client.baseURL = slowServer(t, time.Second).URL
+
+	_, err := client.callAPI(context.Background(), &types.PerplexityRequest{Model: types.ModelSonarPro})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorType != ErrorTypeTimeout {
+		t.Fatalf("Expected timeout APIError, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "search timed out after ") ||
+		!strings.HasSuffix(err.Error(), "s; consider increasing timeout_seconds or using the sonar model") {
+		t.Errorf("Unexpected timeout message: %q", err.Error())
+	}
+	if !apiErr.Retryable || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a retryable error wrapping the deadline, got %+v", apiErr)
+	}
+}
+
+func TestCallAPICallTimeoutOverride(t *testing.T) {
+	client := NewClient("test-api-key", 50*time.Millisecond)
+	client.baseURL = slowServer(t, 150*time.Millisecond).URL
+
+	ctx := withCallTimeout(context.Background(), 5*time.Second)
+	if _, err := client.callAPI(ctx, &types.PerplexityRequest{Model: types.ModelSonar}); err != nil {
+		t.Errorf("callAPI with a longer call timeout failed: %v", err)
+	}
+}
+
+func TestCallAPICanceled(t *testing.T) {
+	client := NewClient("test-api-key", 5*time.Second)
+	client.baseURL = slowServer(t, time.Second).URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := client.callAPI(ctx, &types.PerplexityRequest{Model: types.ModelSonar})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorType != ErrorTypeCanceled {
+		t.Fatalf("Expected canceled APIError, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "search canceled after") {
+		t.Errorf("Unexpected cancel message: %q", err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// modelRun holds the outcome of one model's run in a comparison
+type modelRun struct {
+	model   string
+	resp    *types.PerplexityResponse
+	latency time.Duration
+	err     error
+}
+
+// CompareModels runs the same query against each model concurrently and returns a side-by-side comparison
+func (s *Searcher) CompareModels(ctx context.Context, params *SearchParams, models []string) (string, error) {
+	if len(models) != 2 {
+		return "", fmt.Errorf("exactly two models are required for comparison, got %d", len(models))
+	}
+	for _, model := range models {
+		if model == types.ModelAuto {
+			return "", fmt.Errorf("'auto' cannot be used in a model comparison; specify concrete models")
+		}
+	}
+	if models[0] == models[1] {
+		return "", fmt.Errorf("models must differ, got '%s' twice", models[0])
+	}
+
+	runs := make([]modelRun, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+
+			// Each run gets its own copy so request building never shares state
+			runParams := *params
+			runParams.Model = model
+			runParams.notes = nil
+			req := withExamples(s.buildRequest(&runParams, s.config().DefaultModel), runParams.Examples)
+
+			start := s.currentTime()
+			resp, err := s.callAnonymized(ctx, req)
+			if err == nil {
+				resp = applyTransforms(resp, runParams.Transforms, s.now(&runParams))
+			}
+			runs[i] = modelRun{model: model, resp: resp, latency: s.currentTime().Sub(start), err: err}
+		}(i, model)
+	}
+	wg.Wait()
+
+	if runs[0].err != nil && runs[1].err != nil {
+		return "", fmt.Errorf("both models failed: %s: %v; %s: %v", runs[0].model, runs[0].err, runs[1].model, runs[1].err)
+	}
+
+	params.Model = strings.Join(models, " vs ")
+	return s.saveWithCache(s.formatComparison(runs)+formatNotes(params.notes), params), nil
+}
+
+// formatComparison renders a metrics table followed by each model's answer
+func (s *Searcher) formatComparison(runs []modelRun) string {
+	var b strings.Builder
+	b.WriteString("# Model Comparison\n\n")
+	fmt.Fprintf(&b, "| Metric | %s | %s |\n", runs[0].model, runs[1].model)
+	b.WriteString("|---|---|---|\n")
+
+	rows := []struct {
+		label string
+		value func(run modelRun) string
+	}{
+		{"Latency", func(run modelRun) string { return run.latency.Round(time.Millisecond).String() }},
+		{"Prompt tokens", func(run modelRun) string { return fmt.Sprintf("%d", run.resp.Usage.PromptTokens) }},
+		{"Completion tokens", func(run modelRun) string { return fmt.Sprintf("%d", run.resp.Usage.CompletionTokens) }},
+		{"Total tokens", func(run modelRun) string { return fmt.Sprintf("%d", run.resp.Usage.TotalTokens) }},
+		{"Citations", func(run modelRun) string { return fmt.Sprintf("%d", len(run.resp.Citations)) }},
+	}
+	for _, row := range rows {
+		cells := make([]string, len(runs))
+		for i, run := range runs {
+			if run.err != nil && row.label != "Latency" {
+				cells[i] = "n/a"
+				continue
+			}
+			cells[i] = row.value(run)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", row.label, cells[0], cells[1])
+	}
+
+	for _, run := range runs {
+		fmt.Fprintf(&b, "\n\n---\n\n## Answer from %s\n\n", run.model)
+		if run.err != nil {
+			fmt.Fprintf(&b, "**Error:** %v\n", run.err)
+			continue
+		}
+		b.WriteString(s.formatResponse(run.resp))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestCompareModels(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		resp := textResponse(req.Model, "answer from "+req.Model, "https://example.com")
+		resp.Usage.TotalTokens = 42
+		return resp
+	})
+
+	params := &SearchParams{Query: "test query", SearchType: "compare"}
+	result, err := s.CompareModels(context.Background(), params, []string{types.ModelSonar, types.ModelSonarPro})
+	if err != nil {
+		t.Fatalf("CompareModels failed: %v", err)
+	}
+
+	expected := []string{
+		"| Metric | sonar | sonar-pro |",
+		"| Total tokens | 42 | 42 |",
+		"## Answer from sonar",
+		"answer from sonar-pro",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestCompareModelsValidation(t *testing.T) {
+	s := &Searcher{source: testConfig()}
+	invalid := [][]string{
+		{types.ModelSonar},
+		{types.ModelSonar, types.ModelSonar},
+		{types.ModelSonar, types.ModelAuto},
+	}
+
+	for _, models := range invalid {
+		if _, err := s.CompareModels(context.Background(), &SearchParams{Query: "q"}, models); err == nil {
+			t.Errorf("CompareModels(%v) should have failed", models)
+		}
+	}
+}
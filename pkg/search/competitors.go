@@ -0,0 +1,265 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// Competitor comparison scopes, one column of the matrix each
+const (
+	ScopePricing  = "pricing"
+	ScopeFeatures = "features"
+	ScopeFunding  = "funding"
+	ScopeNews     = "news"
+)
+
+// DefaultCompetitorScopes are compared when the caller gives none, in column order
+var DefaultCompetitorScopes = []string{ScopePricing, ScopeFeatures, ScopeFunding, ScopeNews}
+
+// Limits on how many competitors are compared; each company costs one API call per scope
+const (
+	DefaultMaxCompetitors = 4
+	MaxCompetitors        = 6
+)
+
+// competitorMaxTokens keeps each scoped search short; the matrix only needs its summary line
+const competitorMaxTokens = 300
+
+// competitorScope tailors one scoped search
+type competitorScope struct {
+	instructions string
+	// recency limits the scope to recent sources; empty searches all dates
+	recency string
+}
+
+var competitorScopes = map[string]competitorScope{
+	ScopePricing:  {instructions: "Describe current pricing: plans or tiers, list prices, free tier or trial, and pricing model."},
+	ScopeFeatures: {instructions: "Describe the main product features, target customers, and notable strengths and gaps."},
+	ScopeFunding:  {instructions: "Describe total funding, the latest round with date, amount, and lead investors, or the public listing and market value."},
+	ScopeNews:     {instructions: "Describe the most important news from the last month with dates, such as launches, partnerships, layoffs, and leadership changes.", recency: "month"},
+}
+
+// IsValidCompetitorScope reports whether scope is a supported comparison scope
+func IsValidCompetitorScope(scope string) bool {
+	_, ok := competitorScopes[scope]
+	return ok
+}
+
+// CompetitorsQuery is the query used when the caller gives none
+func CompetitorsQuery(company string) string {
+	return fmt.Sprintf("Competitive landscape of %s", strings.TrimSpace(company))
+}
+
+// competitorCell holds the outcome of one company's search for one scope
+type competitorCell struct {
+	summary string
+	details string
+	sources []string
+	err     error
+}
+
+var listMarkerPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// parseCompetitorList reads one company name per line, dropping list markers, emphasis,
+// trailing descriptions, and the subject company itself
+func parseCompetitorList(answer, subject string, max int) []string {
+	var names []string
+	seen := map[string]bool{strings.ToLower(subject): true}
+	for _, line := range strings.Split(answer, "\n") {
+		name := listMarkerPattern.ReplaceAllString(line, "")
+		name = citationMarkerPattern.ReplaceAllString(name, "")
+		for _, sep := range []string{" - ", " – ", " — ", ":", " ("} {
+			if i := strings.Index(name, sep); i > 0 {
+				name = name[:i]
+			}
+		}
+		name = strings.TrimSpace(strings.Trim(strings.TrimSpace(name), "*_`"))
+		if name == "" || len(name) > 60 || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+		if len(names) == max {
+			break
+		}
+	}
+	return names
+}
+
+// CompetitorSearch maps a company's competitive landscape. Without a competitor list it first
+// asks for the main competitors, then searches every company for every scope in parallel and
+// merges the answers into a comparison matrix. The matrix and the scoped findings are saved
+// together as one cached result.
+func (s *Searcher) CompetitorSearch(ctx context.Context, params *SearchParams, competitors, scopes []string, maxCompetitors int) (string, error) {
+	if strings.TrimSpace(params.CompanyName) == "" {
+		return "", fmt.Errorf("company is required")
+	}
+	if len(scopes) == 0 {
+		scopes = DefaultCompetitorScopes
+	}
+	for _, scope := range scopes {
+		if !IsValidCompetitorScope(scope) {
+			return "", fmt.Errorf("invalid scope '%s'. Use %s", scope, strings.Join(DefaultCompetitorScopes, ", "))
+		}
+	}
+	if maxCompetitors <= 0 {
+		maxCompetitors = DefaultMaxCompetitors
+	}
+	if maxCompetitors > MaxCompetitors {
+		maxCompetitors = MaxCompetitors
+	}
+	if params.Model == "" {
+		params.Model = types.ModelSonar
+	}
+
+	// Background work relative to interactive tool calls
+	batchCtx := ratelimit.WithPriority(ctx, ratelimit.PriorityBatch)
+
+	if len(competitors) == 0 {
+		found, err := s.findCompetitors(batchCtx, params, maxCompetitors)
+		if err != nil {
+			return "", err
+		}
+		competitors = found
+		params.addNote(fmt.Sprintf("Competitors: identified automatically (%s); pass competitors to choose them", strings.Join(found, ", ")))
+	} else if len(competitors) > maxCompetitors {
+		params.addNote(fmt.Sprintf("Competitors: compared the first %d of %d given", maxCompetitors, len(competitors)))
+		competitors = competitors[:maxCompetitors]
+	}
+	companies := append([]string{params.CompanyName}, competitors...)
+
+	cells := make([][]competitorCell, len(companies))
+	var wg sync.WaitGroup
+	for i, company := range companies {
+		cells[i] = make([]competitorCell, len(scopes))
+		for j, scope := range scopes {
+			wg.Add(1)
+			go func(i, j int, company, scope string) {
+				defer wg.Done()
+				cells[i][j] = s.searchCompetitorScope(batchCtx, params, company, scope)
+			}(i, j, company, scope)
+		}
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, row := range cells {
+		for _, cell := range row {
+			if cell.err != nil {
+				failed++
+			}
+		}
+	}
+	if failed == len(companies)*len(scopes) {
+		return "", fmt.Errorf("all %d scoped searches failed: %v", failed, cells[0][0].err)
+	}
+	if failed > 0 {
+		params.addNote(fmt.Sprintf("Competitors: %d of %d scoped searches failed", failed, len(companies)*len(scopes)))
+	}
+
+	return s.saveWithCache(formatCompetitorMatrix(params.CompanyName, companies, scopes, cells)+formatNotes(params.notes), params), nil
+}
+
+// findCompetitors asks for the company's main competitors
+func (s *Searcher) findCompetitors(ctx context.Context, params *SearchParams, max int) ([]string, error) {
+	runParams := *params
+	runParams.notes = nil
+	req := s.buildRequest(&runParams, types.ModelSonar)
+	req.Messages[0].Content = fmt.Sprintf("[Competitor discovery, Company: %s] %s\n\nList the %d most direct competitors of %s, "+
+		"one company or product name per line, with no numbering, descriptions, or other text.", params.CompanyName, params.Query, max, params.CompanyName)
+
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify competitors: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("failed to identify competitors: no response from Perplexity API")
+	}
+	names := parseCompetitorList(resp.Choices[0].Message.Content, params.CompanyName, max)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no competitors of %s found; pass competitors explicitly", params.CompanyName)
+	}
+	return names, nil
+}
+
+// searchCompetitorScope runs one company's search for one scope
+func (s *Searcher) searchCompetitorScope(ctx context.Context, params *SearchParams, company, scope string) competitorCell {
+	// Each search gets its own copy so request building never shares state
+	runParams := *params
+	runParams.notes = nil
+	if recency := competitorScopes[scope].recency; recency != "" {
+		runParams.SearchRecencyFilter = recency
+	}
+	maxTokens := competitorMaxTokens
+	runParams.MaxTokens = &maxTokens
+
+	req := s.buildRequest(&runParams, types.ModelSonar)
+	req.Messages[0].Content = fmt.Sprintf("[Competitive analysis, Company: %s, Scope: %s] %s\n\nStart with one line \"Summary: ...\" of at most 15 words for a comparison table, "+
+		"then give details in up to four bullet points. %s", company, scope, company, competitorScopes[scope].instructions)
+
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil {
+		return competitorCell{err: err}
+	}
+	resp = applyTransforms(resp, runParams.Transforms, s.now(&runParams))
+	if len(resp.Choices) == 0 {
+		return competitorCell{err: fmt.Errorf("no response from Perplexity API")}
+	}
+
+	content, sources, _ := normalizeCitations(resp.Choices[0].Message.Content, resp.Citations)
+	summary, details, ok := leadingField(content, "summary")
+	if !ok {
+		summary = firstLine(details)
+	}
+	return competitorCell{summary: summary, details: details, sources: sources}
+}
+
+// matrixCell makes text safe for a single markdown table cell
+func matrixCell(text string) string {
+	text = strings.TrimSpace(citationMarkerPattern.ReplaceAllString(text, ""))
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", "\\|")
+}
+
+// formatCompetitorMatrix renders the comparison matrix followed by each company's scoped findings
+func formatCompetitorMatrix(subject string, companies, scopes []string, cells [][]competitorCell) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Competitive Landscape: %s\n\n## Comparison Matrix\n| Company |", subject)
+	for _, scope := range scopes {
+		fmt.Fprintf(&b, " %s%s |", strings.ToUpper(scope[:1]), scope[1:])
+	}
+	b.WriteString("\n|---|" + strings.Repeat("---|", len(scopes)) + "\n")
+	for i, company := range companies {
+		fmt.Fprintf(&b, "| **%s** |", matrixCell(company))
+		for _, cell := range cells[i] {
+			if cell.err != nil {
+				b.WriteString(" n/a |")
+				continue
+			}
+			fmt.Fprintf(&b, " %s |", matrixCell(cell.summary))
+		}
+		b.WriteString("\n")
+	}
+
+	for i, company := range companies {
+		fmt.Fprintf(&b, "\n## %s\n", company)
+		for j, scope := range scopes {
+			cell := cells[i][j]
+			fmt.Fprintf(&b, "\n### %s%s\n\n", strings.ToUpper(scope[:1]), scope[1:])
+			if cell.err != nil {
+				fmt.Fprintf(&b, "**Error:** %v\n", cell.err)
+				continue
+			}
+			b.WriteString(cell.details + "\n")
+			for n, source := range cell.sources {
+				fmt.Fprintf(&b, "%d. %s\n", n+1, source)
+			}
+		}
+	}
+	return b.String()
+}
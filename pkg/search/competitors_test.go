@@ -0,0 +1,74 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestParseCompetitorList(t *testing.T) {
+	answer := "1. **Confluence** - Atlassian's wiki[1]\n- Coda\n\n* Notion\n• Obsidian (local-first)\nSlite: team docs\n- Coda"
+	got := parseCompetitorList(answer, "Notion", 3)
+	want := []string{"Confluence", "Coda", "Obsidian"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Competitors mismatch: got %v, want %v", got, want)
+	}
+}
+
+func TestCompetitorSearch(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		prompt := req.Messages[len(req.Messages)-1].Content
+		prompts = append(prompts, prompt)
+		if strings.HasPrefix(prompt, "[Competitor discovery") {
+			return textResponse(req.Model, "Confluence\nCoda")
+		}
+		company := strings.TrimPrefix(strings.SplitN(prompt, ",", 3)[1], " Company: ")
+		return textResponse(req.Model, "Summary: "+company+" | plans from $8[1]\n- Detail", "https://example.com/"+company)
+	})
+
+	params := &SearchParams{Query: CompetitorsQuery("Notion"), SearchType: "competitors", CompanyName: "Notion"}
+	result, err := s.CompetitorSearch(context.Background(), params, nil, []string{ScopePricing, ScopeNews}, 2)
+	if err != nil {
+		t.Fatalf("CompetitorSearch failed: %v", err)
+	}
+
+	// One discovery call plus three companies times two scopes
+	if len(prompts) != 7 {
+		t.Errorf("Request count mismatch: got %d, want 7", len(prompts))
+	}
+	expected := []string{
+		"| Company | Pricing | News |",
+		"| **Notion** | Notion \\| plans from $8 | Notion \\| plans from $8 |",
+		"| **Coda** |",
+		"## Confluence\n\n### Pricing",
+		"1. https://example.com/Confluence",
+		"Competitors: identified automatically (Confluence, Coda)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+}
+
+func TestCompetitorSearchValidation(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		t.Fatal("Unexpected API call")
+		return nil
+	})
+
+	if _, err := s.CompetitorSearch(context.Background(), &SearchParams{Query: "x"}, nil, nil, 0); err == nil {
+		t.Error("Expected error without company")
+	}
+	params := &SearchParams{Query: "x", CompanyName: "Notion"}
+	if _, err := s.CompetitorSearch(context.Background(), params, []string{"Coda"}, []string{"hiring"}, 0); err == nil {
+		t.Error("Expected error for invalid scope")
+	}
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// echoAnswer answers each request with its own query so results can be matched to callers
+func echoAnswer(req *types.PerplexityRequest) *types.PerplexityResponse {
+	query := req.Messages[len(req.Messages)-1].Content
+	return textResponse(req.Model, "Answer to: "+query, "https://example.com/"+req.Model)
+}
+
+func TestSearcherConcurrentCalls(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+
+	const calls = 32
+	results := make([]string, calls)
+	errs := make([]error, calls)
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			params := &SearchParams{Query: fmt.Sprintf("question number %d?", i), Model: types.ModelAuto}
+			results[i], errs[i] = s.Search(context.Background(), params)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if errs[i] != nil {
+			t.Fatalf("call %d failed: %v", i, errs[i])
+		}
+		if !strings.Contains(result, fmt.Sprintf("Answer to: question number %d?", i)) {
+			t.Errorf("call %d got another call's answer:\n%s", i, result)
+		}
+		if n := strings.Count(result, "Model auto-selected"); n != 1 {
+			t.Errorf("call %d has %d auto-selection notes, want 1:\n%s", i, n, result)
+		}
+	}
+}
+
+func TestBuildRequestCopiesDomainLists(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	params := &SearchParams{Query: "prior art", SearchDomainFilter: patentDomains}
+
+	req := s.buildRequest(params, types.ModelSonar)
+	req.SearchDomainFilter[0] = "changed.example"
+
+	if patentDomains[0] == "changed.example" {
+		t.Error("buildRequest aliased the shared patent domain list")
+	}
+}
+
+func BenchmarkSearchParallel(b *testing.B) {
+	s := newTestSearcher(b, echoAnswer)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			params := &SearchParams{Query: "what is the speed of light"}
+			if _, err := s.Search(context.Background(), params); err != nil {
+				b.Fatalf("Search failed: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// Confidence levels, from least to most confident
+const (
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+const (
+	confidenceHeader = "\n\n## Confidence\n"
+	// confidenceNotePrefix starts the line added after each rated paragraph
+	confidenceNotePrefix = "_Confidence: "
+)
+
+// confidenceRatingPattern matches a line of the model's rating reply, such as "2. HIGH - reason"
+var confidenceRatingPattern = regexp.MustCompile(`(?im)^\s*(\d+)[.):]?\s*\**(high|medium|low)\b\**[\s:.,—–-]*(.*)$`)
+
+// confidenceRank orders levels so a section takes the lowest rating of its claims
+var confidenceRank = map[string]int{ConfidenceLow: 0, ConfidenceMedium: 1, ConfidenceHigh: 2}
+
+// claimRating is the model's confidence in one claim of an answer
+type claimRating struct {
+	claim  string
+	level  string
+	reason string
+}
+
+// confidenceMode returns the confidence mode of a call, falling back to the configured one
+func (s *Searcher) confidenceMode(params *SearchParams) string {
+	if params.Confidence != "" {
+		return params.Confidence
+	}
+	return s.config().Confidence
+}
+
+// withConfidence annotates each paragraph of an answer with a confidence level and adds a
+// Confidence section before the metadata footer. In citations mode a paragraph's level comes
+// from the share of its sentences that cite a source; in model mode a second call rates the
+// answer's key claims and each paragraph takes the lowest rating of the claims in it. When
+// that call fails the citation levels are used instead.
+func (s *Searcher) withConfidence(ctx context.Context, mode, content string) string {
+	if mode != config.ConfidenceCitations && mode != config.ConfidenceModel {
+		return content
+	}
+	body := answerBody(content)
+	start := strings.Index(content, body)
+	if strings.TrimSpace(body) == "" || start < 0 {
+		return content
+	}
+
+	var ratings []claimRating
+	var section strings.Builder
+	section.WriteString(confidenceHeader)
+	if mode == config.ConfidenceModel {
+		var err error
+		ratings, err = s.rateClaims(ctx, body)
+		if err != nil {
+			logging.FromContext(ctx).Warn("confidence rating failed, using citation density", "error", err)
+			fmt.Fprintf(&section, "- Method: citation density (model rating failed: %v)\n", err)
+		} else {
+			section.WriteString("- Method: model rating of key claims\n")
+			for _, rating := range ratings {
+				fmt.Fprintf(&section, "- **%s**: %s", rating.level, rating.claim)
+				if rating.reason != "" {
+					fmt.Fprintf(&section, " (%s)", rating.reason)
+				}
+				section.WriteString("\n")
+			}
+		}
+	} else {
+		section.WriteString("- Method: citation density\n")
+	}
+
+	annotated, cited, total := annotateParagraphs(body, ratings)
+	if len(ratings) == 0 {
+		fmt.Fprintf(&section, "- %d of %d sentences cite a source\n", cited, total)
+	}
+
+	content = content[:start] + annotated + content[start+len(body):]
+	return beforeMetadata(content, section.String())
+}
+
+// annotateParagraphs adds a confidence line after each prose paragraph of body, taking levels
+// from ratings when there are any and from citation density otherwise. It also returns how
+// many sentences of those paragraphs cite a source, out of how many.
+func annotateParagraphs(body string, ratings []claimRating) (string, int, int) {
+	paragraphs := strings.Split(body, "\n\n")
+	cited, total := 0, 0
+	for i, paragraph := range paragraphs {
+		trimmed := strings.TrimSpace(paragraph)
+		if len(trimmed) < minClaimLength || strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+
+		var sentences, sentencesCited int
+		for _, sentence := range splitSentences(trimmed) {
+			if strings.TrimSpace(sentence) == "" {
+				continue
+			}
+			sentences++
+			if citationMarkerPattern.MatchString(sentence) {
+				sentencesCited++
+			}
+		}
+		cited += sentencesCited
+		total += sentences
+
+		var note string
+		if len(ratings) > 0 {
+			level, ok := paragraphRating(trimmed, ratings)
+			if !ok {
+				continue
+			}
+			note = fmt.Sprintf(confidenceNotePrefix+"%s (model rating)_", level)
+		} else {
+			note = fmt.Sprintf(confidenceNotePrefix+"%s (%d of %d sentences cited)_",
+				densityLevel(sentencesCited, sentences), sentencesCited, sentences)
+		}
+		paragraphs[i] = strings.TrimRight(paragraph, "\n") + "\n\n" + note
+	}
+	return strings.Join(paragraphs, "\n\n"), cited, total
+}
+
+// densityLevel rates a paragraph by the share of its sentences that cite a source
+func densityLevel(cited, sentences int) string {
+	switch {
+	case sentences == 0:
+		return ConfidenceLow
+	case cited*4 >= sentences*3:
+		return ConfidenceHigh
+	case cited*5 >= sentences*2:
+		return ConfidenceMedium
+	default:
+		return ConfidenceLow
+	}
+}
+
+// paragraphRating returns the lowest rating of the claims a paragraph contains
+func paragraphRating(paragraph string, ratings []claimRating) (string, bool) {
+	plain := citationMarkerPattern.ReplaceAllString(paragraph, "")
+	level, found := "", false
+	for _, rating := range ratings {
+		if !strings.Contains(plain, rating.claim) {
+			continue
+		}
+		if !found || confidenceRank[rating.level] < confidenceRank[level] {
+			level, found = rating.level, true
+		}
+	}
+	return level, found
+}
+
+// rateClaims asks the model how confident it is in each of an answer's key claims
+func (s *Searcher) rateClaims(ctx context.Context, body string) ([]claimRating, error) {
+	claims := extractClaims(body, DefaultMaxClaims)
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("no claims to rate")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("Rate how confident you are that each numbered claim is accurate, based on current sources. " +
+		"Reply with one line per claim in the form \"<number>. HIGH|MEDIUM|LOW - <short reason>\" and nothing else.\n\n")
+	for i, claim := range claims {
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, claim)
+	}
+	req := &types.PerplexityRequest{
+		Model:       types.ModelSonar,
+		Messages:    []types.Message{{Role: "user", Content: prompt.String()}},
+		MaxTokens:   512,
+		Temperature: 0,
+	}
+
+	// Rating is background work relative to the interactive search it annotates
+	resp, err := s.callAnonymized(ratelimit.WithPriority(ctx, ratelimit.PriorityBatch), req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from rating call")
+	}
+	ratings := parseClaimRatings(resp.Choices[0].Message.Content, claims)
+	if len(ratings) == 0 {
+		return nil, fmt.Errorf("rating reply could not be parsed")
+	}
+	return ratings, nil
+}
+
+// parseClaimRatings reads "<number>. LEVEL - reason" lines, ignoring numbers without a claim
+func parseClaimRatings(reply string, claims []string) []claimRating {
+	reply = citationMarkerPattern.ReplaceAllString(reply, "")
+	seen := make(map[int]bool)
+	var ratings []claimRating
+	for _, match := range confidenceRatingPattern.FindAllStringSubmatch(reply, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 || n > len(claims) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		ratings = append(ratings, claimRating{
+			claim:  claims[n-1],
+			level:  strings.ToLower(match[2]),
+			reason: strings.TrimSpace(match[3]),
+		})
+	}
+	return ratings
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const confidenceFixture = "The Eiffel Tower was completed in March 1889 for the World's Fair [1]. It is 330 metres tall [2].\n\n" +
+	"Paris has about 2.1 million residents within its city limits. Tourism brings tens of millions of visitors to France each year.\n\n" +
+	"## Source URLs\n1. https://a.com\n2. https://b.com\n\n\n## Search Metadata\n- Model: sonar\n"
+
+func TestWithConfidenceCitations(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		t.Error("Citation density should not call the API")
+		return nil
+	})
+
+	got := s.withConfidence(context.Background(), config.ConfidenceCitations, confidenceFixture)
+	for _, want := range []string{
+		"[2].\n\n_Confidence: high (2 of 2 sentences cited)_\n\nParis",
+		"each year.\n\n_Confidence: low (0 of 2 sentences cited)_\n\n## Source URLs",
+		"\n\n## Confidence\n- Method: citation density\n- 2 of 4 sentences cite a source\n\n\n## Search Metadata\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Missing %q in:\n%s", want, got)
+		}
+	}
+
+	if got := s.withConfidence(context.Background(), config.ConfidenceOff, confidenceFixture); got != confidenceFixture {
+		t.Error("Expected content unchanged with confidence off")
+	}
+}
+
+func TestWithConfidenceModel(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt := req.Messages[0].Content
+		var reply []string
+		for i, line := range strings.Split(prompt, "\n") {
+			switch {
+			case strings.Contains(line, "1889"):
+				reply = append(reply, line[:2]+" HIGH - widely documented")
+			case strings.Contains(line, "2.1 million"):
+				reply = append(reply, line[:2]+" **MEDIUM**: census estimates vary")
+			case i > 0 && strings.Contains(line, "Tourism"):
+				reply = append(reply, line[:2]+" LOW")
+			}
+		}
+		return textResponse(req.Model, strings.Join(reply, "\n"))
+	})
+
+	got := s.withConfidence(context.Background(), config.ConfidenceModel, confidenceFixture)
+	for _, want := range []string{
+		"[2].\n\n_Confidence: high (model rating)_",
+		"each year.\n\n_Confidence: low (model rating)_",
+		"- **high**: The Eiffel Tower was completed in March 1889",
+		"- Method: model rating of key claims\n",
+		"- **medium**: Paris has about 2.1 million residents within its city limits. (census estimates vary)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestParseClaimRatings(t *testing.T) {
+	claims := []string{"First claim", "Second claim"}
+	ratings := parseClaimRatings("1. High - sourced [1]\n3. LOW - no such claim\n2) low\n2. HIGH - repeated", claims)
+	if len(ratings) != 2 || ratings[0].level != ConfidenceHigh || ratings[0].reason != "sourced" ||
+		ratings[1].claim != "Second claim" || ratings[1].level != ConfidenceLow {
+		t.Errorf("Unexpected ratings: %+v", ratings)
+	}
+}
@@ -0,0 +1,177 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// filterSeverities ranks the PERPLEXITY_CONTENT_FILTER levels; a level filters its own terms
+// and every more severe term
+var filterSeverities = map[string]int{
+	config.ContentFilterMild:   1,
+	config.ContentFilterStrong: 2,
+	config.ContentFilterSevere: 3,
+}
+
+// filterTerms lists the built-in terms by severity. Configured extra terms are treated as
+// severe. Terms match whole words, including common suffixed forms.
+var filterTerms = map[int][]string{
+	1: {"damn", "crap", "piss", "arse", "bollocks", "bugger"},
+	2: {"shit", "bullshit", "fuck", "motherfucker", "bitch", "bastard", "asshole", "twat", "wanker"},
+	3: {"cunt", "porn", "porno", "hentai", "xxx", "nsfw"},
+}
+
+// filterSuffixes are the word endings matched after a term, e.g. "fucking" for "fuck"
+const filterSuffixes = `(s|es|ed|ing|er|ers|y|ty)?`
+
+// sentencePattern splits a line into sentences, keeping trailing punctuation and spaces
+var sentencePattern = regexp.MustCompile(`[^.!?]+[.!?]*\s*`)
+
+// contentFilter masks or drops flagged words in answers and drops flagged images
+type contentFilter struct {
+	pattern *regexp.Regexp
+	drop    bool
+}
+
+// newContentFilter builds the filter for a level and action, or returns nil when the level is off
+func newContentFilter(level, action string, extra []string) *contentFilter {
+	severity, ok := filterSeverities[level]
+	if !ok {
+		return nil
+	}
+
+	var terms []string
+	for s := severity; s <= 3; s++ {
+		for _, term := range filterTerms[s] {
+			terms = append(terms, regexp.QuoteMeta(term))
+		}
+	}
+	for _, term := range extra {
+		if term = strings.TrimSpace(strings.ToLower(term)); term != "" {
+			terms = append(terms, regexp.QuoteMeta(term))
+		}
+	}
+	return &contentFilter{
+		pattern: regexp.MustCompile(`(?i)\b(` + strings.Join(terms, "|") + `)` + filterSuffixes + `\b`),
+		drop:    action == config.ContentFilterDrop,
+	}
+}
+
+// mask replaces every letter of each flagged word after the first with an asterisk
+func (f *contentFilter) mask(text string) (string, int) {
+	count := 0
+	masked := f.pattern.ReplaceAllStringFunc(text, func(word string) string {
+		count++
+		runes := []rune(word)
+		return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	})
+	return masked, count
+}
+
+// dropSentences replaces each sentence containing a flagged word with a removal marker
+func (f *contentFilter) dropSentences(text string) (string, int) {
+	count := 0
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if !f.pattern.MatchString(line) {
+			continue
+		}
+		lines[i] = sentencePattern.ReplaceAllStringFunc(line, func(sentence string) string {
+			if !f.pattern.MatchString(sentence) {
+				return sentence
+			}
+			count++
+			trailing := sentence[len(strings.TrimRight(sentence, " \t")):]
+			return "[removed]" + trailing
+		})
+	}
+	return strings.Join(lines, "\n"), count
+}
+
+// text filters one piece of text according to the configured action
+func (f *contentFilter) text(text string) (string, int) {
+	if f.drop {
+		return f.dropSentences(text)
+	}
+	return f.mask(text)
+}
+
+// flaggedURL reports whether an image or page URL names flagged content in its host or path
+func (f *contentFilter) flaggedURL(rawURL string) bool {
+	tokens := strings.FieldsFunc(strings.ToLower(rawURL), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return f.pattern.MatchString(strings.Join(tokens, " "))
+}
+
+// apply returns a copy of resp with flagged words filtered from the answer, source titles and
+// snippets, and related questions, and with flagged images removed. It reports how many
+// words or sentences were filtered and how many images were dropped.
+func (f *contentFilter) apply(resp *types.PerplexityResponse) (*types.PerplexityResponse, int, int) {
+	filtered := *resp
+	total := 0
+
+	if len(resp.Choices) > 0 {
+		filtered.Choices = make([]types.Choice, len(resp.Choices))
+		copy(filtered.Choices, resp.Choices)
+		content, n := f.text(resp.Choices[0].Message.Content)
+		filtered.Choices[0].Message.Content = content
+		total += n
+	}
+
+	filtered.SearchResults = make([]types.SearchResult, len(resp.SearchResults))
+	for i, result := range resp.SearchResults {
+		var n, m int
+		result.Title, n = f.mask(result.Title)
+		result.Snippet, m = f.text(result.Snippet)
+		filtered.SearchResults[i] = result
+		total += n + m
+	}
+
+	filtered.RelatedQuestions = nil
+	for _, question := range resp.RelatedQuestions {
+		if f.drop && f.pattern.MatchString(question) {
+			total++
+			continue
+		}
+		question, n := f.mask(question)
+		filtered.RelatedQuestions = append(filtered.RelatedQuestions, question)
+		total += n
+	}
+
+	filtered.Images = nil
+	droppedImages := 0
+	for _, image := range resp.Images {
+		if f.flaggedURL(image.ImageURL) || f.flaggedURL(image.OriginURL) {
+			droppedImages++
+			continue
+		}
+		filtered.Images = append(filtered.Images, image)
+	}
+
+	return &filtered, total, droppedImages
+}
+
+// filterContent applies the configured content filter to a response and notes what it changed
+func (s *Searcher) filterContent(resp *types.PerplexityResponse, params *SearchParams) *types.PerplexityResponse {
+	if s.filter == nil {
+		return resp
+	}
+
+	filtered, count, droppedImages := s.filter.apply(resp)
+	if count > 0 {
+		if s.filter.drop {
+			params.addNote(fmt.Sprintf("Content filter: removed %d passage(s) with flagged words", count))
+		} else {
+			params.addNote(fmt.Sprintf("Content filter: masked %d flagged word(s)", count))
+		}
+	}
+	if droppedImages > 0 {
+		params.addNote(fmt.Sprintf("Content filter: dropped %d flagged image(s)", droppedImages))
+	}
+	return filtered
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestContentFilterLevels(t *testing.T) {
+	text := "Damn, the shitty review called it porn for gamers. Scunthorpe and Middlesex are fine."
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{config.ContentFilterMild, "D***, the s***** review called it p*** for gamers. Scunthorpe and Middlesex are fine."},
+		{config.ContentFilterStrong, "Damn, the s***** review called it p*** for gamers. Scunthorpe and Middlesex are fine."},
+		{config.ContentFilterSevere, "Damn, the shitty review called it p*** for gamers. Scunthorpe and Middlesex are fine."},
+	}
+	for _, tt := range tests {
+		got, _ := newContentFilter(tt.level, config.ContentFilterMask, nil).text(text)
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.level, got, tt.want)
+		}
+	}
+
+	if newContentFilter(config.ContentFilterOff, config.ContentFilterMask, nil) != nil {
+		t.Error("Expected no filter when the level is off")
+	}
+	if newContentFilter("", config.ContentFilterMask, nil) != nil {
+		t.Error("Expected no filter when the level is unset")
+	}
+}
+
+func TestContentFilterDrop(t *testing.T) {
+	f := newContentFilter(config.ContentFilterStrong, config.ContentFilterDrop, []string{"frak"})
+	got, count := f.text("The launch went well[1]. Critics said the UI is fraking awful[2]! Sales doubled.\n- What a shitshow")
+	want := "The launch went well[1]. [removed] Sales doubled.\n- What a shitshow"
+	if got != want || count != 1 {
+		t.Errorf("got %q (%d), want %q (1)", got, count, want)
+	}
+}
+
+func TestContentFilterSearch(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		resp := textResponse(types.ModelSonar, "Reviewers called the ending a bullshit twist[1].", "https://example.com/review")
+		resp.Images = []types.Image{
+			{ImageURL: "https://img.example.com/poster.jpg", OriginURL: "https://example.com/review"},
+			{ImageURL: "https://cdn.example.net/nsfw/1.jpg", OriginURL: "https://example.net/gallery"},
+			{ImageURL: "https://cdn.example.org/a.jpg", OriginURL: "https://xxx.example.org/post"},
+		}
+		resp.RelatedQuestions = []string{"Why is the twist so damn divisive?"}
+		return resp
+	})
+	s.filter = newContentFilter(config.ContentFilterMild, config.ContentFilterMask, nil)
+
+	result, err := s.Search(context.Background(), &SearchParams{Query: "movie ending reviews"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	expected := []string{
+		"Reviewers called the ending a b******* twist[1].",
+		"1. ![image 1](https://img.example.com/poster.jpg)",
+		"- Why is the twist so d*** divisive?",
+		"Content filter: masked 2 flagged word(s)",
+		"Content filter: dropped 2 flagged image(s)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "nsfw/1.jpg") || strings.Contains(result, "image 2") {
+		t.Errorf("Expected flagged images to be dropped:\n%s", result)
+	}
+}
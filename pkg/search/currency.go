@@ -0,0 +1,297 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fxCacheTTL is how long rates read from PERPLEXITY_FX_RATES are reused before reading again
+const fxCacheTTL = time.Hour
+
+// FXRates are exchange rates quoted as units of each currency per one unit of Base, the way
+// most rate feeds publish them: with base USD, EUR 0.92 means one dollar buys 0.92 euros
+type FXRates struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date,omitempty"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// rate returns how many units of to one unit of from buys, reporting false when either
+// currency is missing
+func (r *FXRates) rate(from, to string) (float64, bool) {
+	quote := func(code string) (float64, bool) {
+		if code == r.Base {
+			return 1, true
+		}
+		v, ok := r.Rates[code]
+		return v, ok && v > 0
+	}
+	f, ok := quote(from)
+	if !ok {
+		return 0, false
+	}
+	t, ok := quote(to)
+	if !ok {
+		return 0, false
+	}
+	return t / f, true
+}
+
+// FXSource supplies the exchange rates used to express money figures in the base currency.
+// The server reads them from PERPLEXITY_FX_RATES; programs using the package can plug in
+// their own with SetFXSource.
+type FXSource interface {
+	FXRates(ctx context.Context) (*FXRates, error)
+}
+
+// SetFXSource replaces the exchange rate source. Call it before the Searcher is used.
+func (s *Searcher) SetFXSource(src FXSource) {
+	s.fx = src
+}
+
+// fxFeed reads rates from a JSON file or URL, keeping them for fxCacheTTL
+type fxFeed struct {
+	location   string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	rates   *FXRates
+	fetched time.Time
+}
+
+// FXRates returns the feed's rates, reading them again once they are older than fxCacheTTL.
+// Stale rates are kept when a new read fails.
+func (f *fxFeed) FXRates(ctx context.Context) (*FXRates, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rates != nil && time.Since(f.fetched) < fxCacheTTL {
+		return f.rates, nil
+	}
+
+	rates, err := f.read(ctx)
+	if err != nil {
+		if f.rates != nil {
+			return f.rates, nil
+		}
+		return nil, err
+	}
+	f.rates, f.fetched = rates, time.Now()
+	return rates, nil
+}
+
+// read loads and checks the rates at the feed's location
+func (f *fxFeed) read(ctx context.Context) (*FXRates, error) {
+	var data []byte
+	if strings.HasPrefix(f.location, "http://") || strings.HasPrefix(f.location, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.location, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := f.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch FX rates: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch FX rates: status %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err != nil {
+			return nil, fmt.Errorf("failed to read FX rates: %w", err)
+		}
+	} else {
+		var err error
+		if data, err = os.ReadFile(f.location); err != nil {
+			return nil, fmt.Errorf("failed to read FX rates: %w", err)
+		}
+	}
+
+	var rates FXRates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("invalid FX rates: %w", err)
+	}
+	rates.Base = strings.ToUpper(rates.Base)
+	if rates.Base == "" || len(rates.Rates) == 0 {
+		return nil, fmt.Errorf("invalid FX rates: base and rates are required")
+	}
+	upper := make(map[string]float64, len(rates.Rates))
+	for code, v := range rates.Rates {
+		upper[strings.ToUpper(code)] = v
+	}
+	rates.Rates = upper
+	return &rates, nil
+}
+
+var (
+	// moneyPattern matches a money figure written with a leading symbol or code ("€4.5bn",
+	// "USD 120 million") or a trailing code or currency word ("3.2 billion euros")
+	moneyPattern = regexp.MustCompile(`(?i)(US\$|A\$|C\$|HK\$|\$|€|£|¥|₹|\b(?:USD|EUR|GBP|JPY|CNY|INR|CAD|AUD|CHF|HKD|KRW)\s?)(\d[\d,]*(?:\.\d+)?)(?:\s?(trillion|billion|million|thousand|tn|bn|mn|[tbmk])\b)?` +
+		`|\b(\d[\d,]*(?:\.\d+)?)(?:\s?(trillion|billion|million|thousand|tn|bn|mn))?\s(USD|EUR|GBP|JPY|CNY|INR|CAD|AUD|CHF|HKD|KRW|euros?|dollars?|pounds?|yen|yuan|rupees?)\b`)
+
+	// currencyCodes maps symbols and currency words to ISO codes; a bare $ is taken as USD
+	currencyCodes = map[string]string{
+		"us$": "USD", "$": "USD", "a$": "AUD", "c$": "CAD", "hk$": "HKD", "€": "EUR", "£": "GBP",
+		"¥": "JPY", "₹": "INR", "euro": "EUR", "euros": "EUR", "dollar": "USD", "dollars": "USD",
+		"pound": "GBP", "pounds": "GBP", "yen": "JPY", "yuan": "CNY", "rupee": "INR", "rupees": "INR",
+	}
+
+	// scaleMultipliers maps the scale words and suffixes of money figures to their value
+	scaleMultipliers = map[string]float64{
+		"trillion": 1e12, "tn": 1e12, "t": 1e12,
+		"billion": 1e9, "bn": 1e9, "b": 1e9,
+		"million": 1e6, "mn": 1e6, "m": 1e6,
+		"thousand": 1e3, "k": 1e3,
+	}
+)
+
+// moneyFigure is an amount of money found in an answer
+type moneyFigure struct {
+	currency   string
+	amount     float64
+	multiplier float64 // Scale the figure was written in; 1 without one
+}
+
+// parseMoney reads a moneyPattern match
+func parseMoney(match []string) (moneyFigure, bool) {
+	currency, number, scale := match[1], match[2], match[3]
+	if number == "" {
+		number, scale, currency = match[4], match[5], match[6]
+	}
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if mapped, ok := currencyCodes[strings.ToLower(strings.TrimSpace(currency))]; ok {
+		code = mapped
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+	if err != nil {
+		return moneyFigure{}, false
+	}
+	multiplier := 1.0
+	if scale != "" {
+		multiplier = scaleMultipliers[strings.ToLower(scale)]
+	}
+	return moneyFigure{currency: code, amount: value * multiplier, multiplier: multiplier}, true
+}
+
+// canonicalScale returns the largest scale an amount reaches, with its suffix
+func canonicalScale(amount float64) (float64, string) {
+	switch abs := math.Abs(amount); {
+	case abs >= 1e12:
+		return 1e12, "T"
+	case abs >= 1e9:
+		return 1e9, "B"
+	case abs >= 1e6:
+		return 1e6, "M"
+	default:
+		return 1, ""
+	}
+}
+
+// formatMoney writes an amount in a currency at its canonical scale, e.g. "USD 4.86B"
+func formatMoney(code string, amount float64) string {
+	scale, suffix := canonicalScale(amount)
+	if suffix == "" {
+		return fmt.Sprintf("%s %s", code, strconv.FormatFloat(math.Round(amount*100)/100, 'f', -1, 64))
+	}
+	return fmt.Sprintf("%s %.2f%s", code, amount/scale, suffix)
+}
+
+// normalizeMoney annotates the money figures of an answer in the base currency, at a
+// consistent scale: figures in other currencies get their converted value, and base currency
+// figures of a million or more written at another scale ("$1,200 million") get the canonical
+// one. Figures already followed by a parenthesis are left alone. It returns the annotated
+// answer and the currencies that could not be converted.
+func normalizeMoney(content, base string, rates *FXRates) (string, []string) {
+	var b strings.Builder
+	last := 0
+	missing := make(map[string]bool)
+	for _, loc := range moneyPattern.FindAllStringSubmatchIndex(content, -1) {
+		end := loc[1]
+		if strings.HasPrefix(content[end:], " (") {
+			continue
+		}
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = content[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		figure, ok := parseMoney(match)
+		if !ok {
+			continue
+		}
+
+		var note string
+		if figure.currency == base {
+			if scale, _ := canonicalScale(figure.amount); scale > 1 && scale != figure.multiplier {
+				note = formatMoney(base, figure.amount)
+			}
+		} else if rates == nil {
+			missing[figure.currency] = true
+		} else if rate, ok := rates.rate(figure.currency, base); ok {
+			note = "≈ " + formatMoney(base, figure.amount*rate)
+		} else {
+			missing[figure.currency] = true
+		}
+		if note == "" {
+			continue
+		}
+
+		b.WriteString(content[last:end])
+		fmt.Fprintf(&b, " (%s)", note)
+		last = end
+	}
+	b.WriteString(content[last:])
+
+	codes := make([]string, 0, len(missing))
+	for code := range missing {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return b.String(), codes
+}
+
+// normalizeCurrencies annotates the money figures of a financial answer in the configured
+// base currency, noting the rates used and the currencies left unconverted
+func (s *Searcher) normalizeCurrencies(ctx context.Context, content string, params *SearchParams) string {
+	base := s.config().BaseCurrency
+	if base == "" {
+		return content
+	}
+
+	var rates *FXRates
+	if s.fx != nil {
+		var err error
+		if rates, err = s.fx.FXRates(ctx); err != nil {
+			params.log().Warn("FX rates unavailable, normalizing units only", "error", err)
+			params.addNote(fmt.Sprintf("Currency: FX rates unavailable (%v); only %s figures were normalized", err, base))
+		}
+	}
+
+	body := answerBody(content)
+	start := strings.Index(content, body)
+	if start < 0 {
+		return content
+	}
+	annotated, missing := normalizeMoney(body, base, rates)
+	if rates != nil {
+		note := fmt.Sprintf("Currency: figures annotated in %s using %s-based FX rates", base, rates.Base)
+		if rates.Date != "" {
+			note += " of " + rates.Date
+		}
+		params.addNote(note)
+	}
+	if len(missing) > 0 && (rates != nil || s.fx == nil) {
+		params.addNote(fmt.Sprintf("Currency: no FX rate to convert %s to %s", strings.Join(missing, ", "), base))
+	}
+	return content[:start] + annotated + content[start+len(body):]
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testRates = &FXRates{Base: "USD", Date: "2026-10-16", Rates: map[string]float64{"EUR": 0.8, "GBP": 0.5, "JPY": 150}}
+
+func TestNormalizeMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		missing []string
+	}{
+		{"euro billions", "Revenue was €4.5bn.", "Revenue was €4.5bn (≈ USD 5.62B).", nil},
+		{"trailing currency word", "It raised 200 million pounds last year.", "It raised 200 million pounds (≈ USD 400.00M) last year.", nil},
+		{"code prefix", "Profit reached JPY 300 billion [1].", "Profit reached JPY 300 billion (≈ USD 2.00B) [1].", nil},
+		{"base currency rescaled", "Capex of $1,200 million.", "Capex of $1,200 million (USD 1.20B).", nil},
+		{"base currency already canonical", "Sales of $1.2 billion and a $45 price.", "Sales of $1.2 billion and a $45 price.", nil},
+		{"already annotated", "€10m (about $11m)", "€10m (about $11m)", nil},
+		{"unknown rate", "Sales of CHF 3 million.", "Sales of CHF 3 million.", []string{"CHF"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := normalizeMoney(tt.in, "USD", testRates)
+			if got != tt.want {
+				t.Errorf("normalizeMoney() = %q, want %q", got, tt.want)
+			}
+			if strings.Join(missing, ",") != strings.Join(tt.missing, ",") {
+				t.Errorf("missing = %v, want %v", missing, tt.missing)
+			}
+		})
+	}
+
+	got, missing := normalizeMoney("€5m and $3,000 thousand", "USD", nil)
+	if got != "€5m and $3,000 thousand (USD 3.00M)" || len(missing) != 1 || missing[0] != "EUR" {
+		t.Errorf("Without rates expected only base figures normalized, got %q, %v", got, missing)
+	}
+}
+
+func TestFXRatesCrossRate(t *testing.T) {
+	// Converting to a base other than the feed's goes through the feed's base
+	rate, ok := testRates.rate("GBP", "EUR")
+	if !ok || rate != 1.6 {
+		t.Errorf("rate(GBP, EUR) = %v, %v; want 1.6", rate, ok)
+	}
+	if _, ok := testRates.rate("CHF", "USD"); ok {
+		t.Error("Expected no rate for a currency missing from the feed")
+	}
+}
+
+func TestFXFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(`{"base": "usd", "rates": {"eur": 0.8}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rates, err := (&fxFeed{location: path}).FXRates(context.Background())
+	if err != nil || rates.Base != "USD" || rates.Rates["EUR"] != 0.8 {
+		t.Fatalf("Expected rates from file, got %+v, %v", rates, err)
+	}
+
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write([]byte(`{"base": "EUR", "date": "2026-10-16", "rates": {"USD": 1.25}}`))
+	}))
+	defer srv.Close()
+	feed := &fxFeed{location: srv.URL, httpClient: srv.Client()}
+	for i := 0; i < 2; i++ {
+		if rates, err = feed.FXRates(context.Background()); err != nil || rates.Rates["USD"] != 1.25 {
+			t.Fatalf("Expected rates from URL, got %+v, %v", rates, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("Expected rates reused within the cache TTL, fetched %d times", fetches)
+	}
+
+	if _, err := (&fxFeed{location: filepath.Join(t.TempDir(), "missing.json")}).FXRates(context.Background()); err == nil {
+		t.Error("Expected an error for a missing rates file")
+	}
+}
+
+// staticFX is an FXSource with fixed rates
+type staticFX struct{ rates *FXRates }
+
+func (f staticFX) FXRates(context.Context) (*FXRates, error) { return f.rates, nil }
+
+func TestNormalizeCurrencies(t *testing.T) {
+	s := newTestSearcher(t, nil)
+	content := "Revenue was €4.5bn [1].\n\n## Source URLs\n1. https://example.com/€1bn\n"
+
+	params := &SearchParams{}
+	if got := s.normalizeCurrencies(context.Background(), content, params); got != content || len(params.notes) != 0 {
+		t.Errorf("Expected no change without a base currency, got %q, %v", got, params.notes)
+	}
+
+	s.config().BaseCurrency = "USD"
+	s.SetFXSource(staticFX{testRates})
+	got := s.normalizeCurrencies(context.Background(), content, params)
+	if !strings.HasPrefix(got, "Revenue was €4.5bn (≈ USD 5.62B) [1].") || !strings.HasSuffix(got, "https://example.com/€1bn\n") {
+		t.Errorf("Expected only the answer annotated, got:\n%s", got)
+	}
+	if len(params.notes) != 1 || params.notes[0] != "Currency: figures annotated in USD using USD-based FX rates of 2026-10-16" {
+		t.Errorf("Unexpected notes: %v", params.notes)
+	}
+}
@@ -0,0 +1,379 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// deepSourceMaxBytes bounds how much of a page is read for passage extraction
+	deepSourceMaxBytes = 2 << 20
+	// robotsMaxBytes bounds how much of a robots.txt file is read
+	robotsMaxBytes = 512 << 10
+	// passagesPerSource is the number of passages quoted from each page
+	passagesPerSource = 2
+	// maxPassageLength trims long paragraphs to a quotable size
+	maxPassageLength = 400
+	// minPassageLength skips navigation links, captions, and other fragments
+	minPassageLength = 80
+)
+
+var (
+	// invisibleElementPattern matches page elements whose text is never shown as content
+	invisibleElementPattern = regexp.MustCompile(`(?is)<(script|style|noscript|template|svg|head|nav|footer)\b.*?</(script|style|noscript|template|svg|head|nav|footer)>|<!--.*?-->`)
+	// blockTagPattern matches tags that end a paragraph of text
+	blockTagPattern  = regexp.MustCompile(`(?i)</?(p|div|br|li|ul|ol|h[1-6]|tr|table|section|article|blockquote|pre)\b[^>]*>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+	titlePattern     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	queryTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// pageFetcher reads cited pages for deep_sources, honouring each site's robots.txt
+type pageFetcher struct {
+	httpClient *http.Client
+	userAgent  string
+}
+
+// robotsRules holds the Allow and Disallow paths that apply to this fetcher
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots extracts the rules of the group naming agent, falling back to the "*" group
+func parseRobots(body, agent string) robotsRules {
+	agent = strings.ToLower(agent)
+	var named, wildcard robotsRules
+	var foundNamed bool
+
+	var current []*robotsRules
+	inAgents := false
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share one group of rules
+			if !inAgents {
+				current = nil
+				inAgents = true
+			}
+			token := strings.ToLower(value)
+			switch {
+			case token == "*":
+				current = append(current, &wildcard)
+			case token != "" && strings.Contains(agent, token):
+				current = append(current, &named)
+				foundNamed = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+			for _, rules := range current {
+				if key == "allow" {
+					rules.allow = append(rules.allow, value)
+				} else {
+					rules.disallow = append(rules.disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if foundNamed {
+		return named
+	}
+	return wildcard
+}
+
+// allowed applies the longest matching rule to path; Allow wins a tie
+func (r robotsRules) allowed(path string) bool {
+	longestAllow, longestDisallow := -1, -1
+	for _, rule := range r.allow {
+		if robotsMatch(rule, path) && len(rule) > longestAllow {
+			longestAllow = len(rule)
+		}
+	}
+	for _, rule := range r.disallow {
+		if robotsMatch(rule, path) && len(rule) > longestDisallow {
+			longestDisallow = len(rule)
+		}
+	}
+	return longestAllow >= longestDisallow
+}
+
+// robotsMatch reports whether a robots.txt path rule, with its * and $ wildcards, matches path
+func robotsMatch(rule, path string) bool {
+	anchored := strings.HasSuffix(rule, "$")
+	rule = strings.TrimSuffix(rule, "$")
+	pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(rule), `\*`, ".*")
+	if anchored {
+		pattern += "$"
+	}
+	matched, err := regexp.MatchString(pattern, path)
+	return err == nil && matched
+}
+
+// agentToken is the product name robots.txt groups are matched against
+func (f *pageFetcher) agentToken() string {
+	token, _, _ := strings.Cut(strings.TrimSpace(f.userAgent), "/")
+	if fields := strings.Fields(token); len(fields) > 0 {
+		return fields[0]
+	}
+	return "Go-http-client"
+}
+
+// robotsAllowed fetches the site's robots.txt and checks the page against it. A missing file
+// allows everything; a server error or unreachable file is treated as a disallow.
+func (f *pageFetcher) robotsAllowed(ctx context.Context, page *url.URL) (bool, error) {
+	robotsURL := page.Scheme + "://" + page.Host + "/robots.txt"
+	body, status, _, err := f.get(ctx, robotsURL, robotsMaxBytes)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case status >= 500:
+		return false, fmt.Errorf("robots.txt returned HTTP %d", status)
+	case status >= 400:
+		return true, nil
+	case status != http.StatusOK:
+		return false, fmt.Errorf("robots.txt returned HTTP %d", status)
+	}
+
+	path := page.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if page.RawQuery != "" {
+		path += "?" + page.RawQuery
+	}
+	return parseRobots(string(body), f.agentToken()).allowed(path), nil
+}
+
+// get performs a GET request and reads at most limit bytes of the body, returning the status
+// and media type alongside it
+func (f *pageFetcher) get(ctx context.Context, target string, limit int64) ([]byte, int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, "", nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	return body, resp.StatusCode, mediaType, err
+}
+
+// fetch reads a cited page, if robots.txt permits, and returns its title and text paragraphs
+func (f *pageFetcher) fetch(ctx context.Context, pageURL string) (string, []string, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil || (page.Scheme != "http" && page.Scheme != "https") || page.Host == "" {
+		return "", nil, fmt.Errorf("unsupported URL")
+	}
+	allowed, err := f.robotsAllowed(ctx, page)
+	if err != nil {
+		return "", nil, err
+	}
+	if !allowed {
+		return "", nil, fmt.Errorf("disallowed by robots.txt")
+	}
+
+	body, status, mediaType, err := f.get(ctx, pageURL, deepSourceMaxBytes)
+	if err != nil {
+		return "", nil, err
+	}
+	if status != http.StatusOK {
+		return "", nil, fmt.Errorf("HTTP %d", status)
+	}
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" && mediaType != "text/plain" {
+		return "", nil, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	title, paragraphs := pageText(string(body))
+	return title, paragraphs, nil
+}
+
+// pageText reduces an HTML (or plain text) page to its title and visible paragraphs
+func pageText(page string) (string, []string) {
+	var title string
+	if m := titlePattern.FindStringSubmatch(page); m != nil {
+		title = strings.Join(strings.Fields(html.UnescapeString(m[1])), " ")
+	}
+
+	page = invisibleElementPattern.ReplaceAllString(page, " ")
+	page = blockTagPattern.ReplaceAllString(page, "\n")
+	page = html.UnescapeString(tagPattern.ReplaceAllString(page, " "))
+
+	var paragraphs []string
+	for _, line := range strings.Split(page, "\n") {
+		if text := strings.Join(strings.Fields(line), " "); len(text) >= minPassageLength {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return title, paragraphs
+}
+
+// queryTerms lists the distinct words of the query worth matching in page text
+func queryTerms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, word := range queryTermPattern.FindAllString(strings.ToLower(query), -1) {
+		if len(word) < 3 || stopwords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// relevantPassages picks the paragraphs sharing the most query terms, in page order
+func relevantPassages(paragraphs, terms []string, limit int) []string {
+	type scored struct {
+		index int
+		score int
+	}
+	var candidates []scored
+	for i, paragraph := range paragraphs {
+		lower := strings.ToLower(paragraph)
+		score := 0
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				score++
+			}
+		}
+		if score > 0 {
+			candidates = append(candidates, scored{i, score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].index < candidates[j].index })
+
+	passages := make([]string, len(candidates))
+	for i, c := range candidates {
+		passages[i] = trimPassage(paragraphs[c.index])
+	}
+	return passages
+}
+
+// trimPassage shortens a paragraph to maxPassageLength at a word boundary
+func trimPassage(paragraph string) string {
+	if len(paragraph) <= maxPassageLength {
+		return paragraph
+	}
+	cut := paragraph[:maxPassageLength]
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + " …"
+}
+
+// deepSource is the outcome of reading one cited page
+type deepSource struct {
+	index    int
+	url      string
+	title    string
+	passages []string
+	err      error
+}
+
+// extendedEvidence reads the top cited pages of content and renders the passages relevant to
+// the query as an Extended Evidence section
+func (s *Searcher) extendedEvidence(ctx context.Context, query, content string) string {
+	links := listedSources(content)
+	if len(links) > s.config().DeepSourcesCount {
+		links = links[:s.config().DeepSourcesCount]
+	}
+	if len(links) == 0 {
+		return ""
+	}
+
+	terms := queryTerms(query)
+	sources := make([]deepSource, len(links))
+	var wg sync.WaitGroup
+	for i, link := range links {
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			source := deepSource{index: i + 1, url: link}
+			title, paragraphs, err := s.pages.fetch(ctx, link)
+			source.title, source.err = title, err
+			if err == nil {
+				source.passages = relevantPassages(paragraphs, terms, passagesPerSource)
+			}
+			sources[i] = source
+		}(i, link)
+	}
+	wg.Wait()
+
+	return formatExtendedEvidence(sources)
+}
+
+// formatExtendedEvidence renders the passages of each page, noting pages that were skipped
+func formatExtendedEvidence(sources []deepSource) string {
+	var b strings.Builder
+	b.WriteString("\n\n## Extended Evidence\n")
+	b.WriteString("Passages matching the query, read directly from the top cited pages.\n")
+	for _, source := range sources {
+		heading := source.title
+		if heading == "" {
+			heading = source.url
+		}
+		fmt.Fprintf(&b, "\n### [%d] %s\n", source.index, heading)
+		if source.title != "" {
+			fmt.Fprintf(&b, "URL: %s\n", source.url)
+		}
+		switch {
+		case source.err != nil:
+			fmt.Fprintf(&b, "Skipped: %v\n", source.err)
+		case len(source.passages) == 0:
+			b.WriteString("No passages matching the query.\n")
+		default:
+			for _, passage := range source.passages {
+				fmt.Fprintf(&b, "\n> %s\n", passage)
+			}
+		}
+	}
+	return b.String()
+}
+
+// withEvidence places the Extended Evidence section before the metadata footer
+func withEvidence(content, evidence string) string {
+	if evidence == "" {
+		return content
+	}
+	return beforeMetadata(content, evidence)
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestRobotsRules(t *testing.T) {
+	robots := `
+# Example robots.txt
+User-agent: *
+Disallow: /private
+Allow: /private/press
+Disallow: /*.pdf$
+
+User-agent: BadBot
+User-agent: PerplexityMCP
+Disallow: /drafts/
+`
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"Go-http-client", "/article", true},
+		{"Go-http-client", "/private/notes", false},
+		{"Go-http-client", "/private/press/release", true},
+		{"Go-http-client", "/report.pdf", false},
+		{"Go-http-client", "/report.pdf?download=1", true},
+		{"PerplexityMCP", "/private/notes", true},
+		{"PerplexityMCP", "/drafts/plan", false},
+	}
+	for _, tt := range tests {
+		if got := parseRobots(robots, tt.agent).allowed(tt.path); got != tt.allowed {
+			t.Errorf("%s %s: allowed = %v, want %v", tt.agent, tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestDeepSources(t *testing.T) {
+	filler := strings.Repeat("Unrelated filler text about the weather and gardening. ", 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/article":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, `<html><head><title>Battery Study</title><script>var battery = "solid-state";</script></head><body>
+<nav>Home | Solid-state battery news | About</nav>
+<p>%s</p>
+<p>The solid-state battery prototype retained 90%% of its capacity after 1,000 charge cycles, according to the lab&#39;s results.</p>
+<div>%s</div>
+</body></html>`, filler, filler)
+		case "/private":
+			t.Error("Fetched a page disallowed by robots.txt")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(types.ModelSonar, "Answer[1][2][3].", srv.URL+"/article", srv.URL+"/private", srv.URL+"/missing")
+	})
+	s.config().DeepSourcesCount = 3
+	s.pages = &pageFetcher{httpClient: srv.Client(), userAgent: "PerplexityMCP/1.0"}
+
+	result, err := s.Search(context.Background(), &SearchParams{Query: "solid-state battery capacity cycles", DeepSources: true})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	expected := []string{
+		"## Extended Evidence",
+		"### [1] Battery Study\nURL: " + srv.URL + "/article",
+		"> The solid-state battery prototype retained 90% of its capacity after 1,000 charge cycles, according to the lab's results.",
+		"### [2] " + srv.URL + "/private\nSkipped: disallowed by robots.txt",
+		"### [3] " + srv.URL + "/missing\nSkipped: HTTP 404",
+	}
+	for _, want := range expected {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "gardening") || strings.Contains(result, "var battery") {
+		t.Errorf("Expected only relevant visible passages:\n%s", result)
+	}
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// devDomains are always searched by dev search when the caller gives no domain filter
+var devDomains = []string{"github.com", "stackoverflow.com"}
+
+// devDocsDomains maps a language or framework to its official documentation sites
+var devDocsDomains = map[string][]string{
+	"go":         {"go.dev", "pkg.go.dev"},
+	"golang":     {"go.dev", "pkg.go.dev"},
+	"python":     {"docs.python.org", "pypi.org"},
+	"javascript": {"developer.mozilla.org", "nodejs.org"},
+	"typescript": {"typescriptlang.org", "developer.mozilla.org"},
+	"node":       {"nodejs.org"},
+	"nodejs":     {"nodejs.org"},
+	"rust":       {"doc.rust-lang.org", "docs.rs"},
+	"java":       {"docs.oracle.com"},
+	"kotlin":     {"kotlinlang.org"},
+	"swift":      {"developer.apple.com", "swift.org"},
+	"c#":         {"learn.microsoft.com"},
+	"csharp":     {"learn.microsoft.com"},
+	"c++":        {"en.cppreference.com"},
+	"c":          {"en.cppreference.com"},
+	"ruby":       {"ruby-doc.org", "rubygems.org"},
+	"php":        {"php.net"},
+	"react":      {"react.dev"},
+	"vue":        {"vuejs.org"},
+	"angular":    {"angular.dev"},
+	"svelte":     {"svelte.dev"},
+	"next.js":    {"nextjs.org"},
+	"nextjs":     {"nextjs.org"},
+	"django":     {"docs.djangoproject.com"},
+	"flask":      {"flask.palletsprojects.com"},
+	"fastapi":    {"fastapi.tiangolo.com"},
+	"rails":      {"guides.rubyonrails.org", "api.rubyonrails.org"},
+	"spring":     {"docs.spring.io"},
+	"laravel":    {"laravel.com"},
+	"kubernetes": {"kubernetes.io"},
+	"docker":     {"docs.docker.com"},
+	"terraform":  {"developer.hashicorp.com"},
+}
+
+// devCodeInstructions ask for runnable, version-accurate code in fenced blocks
+const devCodeInstructions = "Put every code sample in a fenced code block tagged with its language, " +
+	"use only APIs that exist in the stated version, and say when an API was added, deprecated, or removed. " +
+	"Prefer the official documentation over blog posts, and link the docs page, issue, or answer each code sample is based on."
+
+// devSearchDomains returns github.com and stackoverflow.com plus the official docs for the language and framework
+func devSearchDomains(language, framework string) []string {
+	domains := append([]string{}, devDomains...)
+	seen := map[string]bool{}
+	for _, domain := range domains {
+		seen[domain] = true
+	}
+	for _, name := range []string{language, framework} {
+		for _, domain := range devDocsDomains[strings.ToLower(strings.TrimSpace(name))] {
+			if !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+	return domains
+}
+
+// DevSearch performs a programming search biased to code hosts, Q&A sites, and official docs
+func (s *Searcher) DevSearch(ctx context.Context, params *SearchParams) (string, error) {
+	// Use sonar-pro model for dev search if not specified
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+	if len(params.SearchDomainFilter) == 0 {
+		params.SearchDomainFilter = devSearchDomains(params.ProgrammingLanguage, params.Framework)
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the dev-specific parameters
+	contextAdditions := []string{"Programming"}
+	if params.ProgrammingLanguage != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Language: %s", params.ProgrammingLanguage))
+	}
+	if params.Framework != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Framework: %s", params.Framework))
+	}
+	if params.Version != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Version: %s", params.Version))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\n%s", strings.Join(contextAdditions, ", "), params.Query, devCodeInstructions)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
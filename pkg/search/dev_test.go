@@ -0,0 +1,50 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestDevSearchDomains(t *testing.T) {
+	got := devSearchDomains("Go", "golang")
+	want := []string{"github.com", "stackoverflow.com", "go.dev", "pkg.go.dev"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Domains mismatch: got %v, want %v", got, want)
+	}
+
+	if got := devSearchDomains("cobol", ""); len(got) != len(devDomains) {
+		t.Errorf("Unknown language should add no domains: got %v", got)
+	}
+}
+
+func TestDevSearch(t *testing.T) {
+	answer := "Use `slices.Index`[1]:\n```go\ni := slices.Index(items[1:], x)\n```"
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, answer, "https://pkg.go.dev/slices")
+	})
+
+	params := &SearchParams{Query: "find an element in a slice", SearchType: "dev", ProgrammingLanguage: "go", Version: "1.21"}
+	result, err := s.DevSearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("DevSearch failed: %v", err)
+	}
+
+	if sent.Model != types.ModelSonarPro {
+		t.Errorf("Model mismatch: got %s, want %s", sent.Model, types.ModelSonarPro)
+	}
+	if !strings.Contains(strings.Join(sent.SearchDomainFilter, ","), "pkg.go.dev") {
+		t.Errorf("Domain filter missing Go docs: %v", sent.SearchDomainFilter)
+	}
+	prompt := sent.Messages[0].Content
+	if !strings.Contains(prompt, "Language: go") || !strings.Contains(prompt, "Version: 1.21") || !strings.Contains(prompt, "fenced code block") {
+		t.Errorf("Prompt missing dev context: %s", prompt)
+	}
+	if !strings.Contains(result, "i := slices.Index(items[1:], x)") {
+		t.Errorf("Code block not preserved verbatim: %s", result)
+	}
+}
@@ -0,0 +1,262 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// Digest periods accepted by DigestSince and set per watch
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestEntry summarizes a query that ran during the digest period. In BuildDigest, queries
+// that have been run more than once are treated as watched and compared with their previous
+// run; in BuildWatchDigest, each entry is a configured watch.
+type DigestEntry struct {
+	Watch          string // Name of the configured watch; empty in BuildDigest
+	Query          string
+	SearchType     string
+	Runs           int    // Total runs of the query in the cache
+	LatestID       string // Empty for a watch without a run in the period
+	LatestTime     time.Time
+	Summary        string
+	Changed        bool     // Answer differs from the previous run
+	AddedSources   []string // Sources cited now but not in the previous run
+	DroppedSources []string // Sources cited in the previous run but not now
+}
+
+// Watched reports whether the query has an earlier run to compare against
+func (e DigestEntry) Watched() bool {
+	return e.Runs > 1
+}
+
+// changed reports whether the answer or its sources differ from the previous run
+func (e DigestEntry) changed() bool {
+	return e.Watched() && (e.Changed || len(e.AddedSources) > 0 || len(e.DroppedSources) > 0)
+}
+
+// DigestSince returns the start of the period ending at now
+func DigestSince(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case DigestDaily:
+		return now.AddDate(0, 0, -1), nil
+	case DigestWeekly:
+		return now.AddDate(0, 0, -7), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid digest period '%s'. Use %s or %s", period, DigestDaily, DigestWeekly)
+	}
+}
+
+// BuildDigest collects the queries cached since the given time, comparing each with its
+// previous run. The results feed lists them; the email digest uses BuildWatchDigest.
+func BuildDigest(rootFolder string, since time.Time) ([]DigestEntry, error) {
+	runs, order, err := groupRuns(rootFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []DigestEntry
+	for _, key := range order {
+		group := runs[key]
+		if group[0].DateTime.Before(since) {
+			continue
+		}
+		entry, err := compareRuns(rootFolder, group)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// Watched queries with changes first, then other watched queries, then new queries
+	sort.SliceStable(entries, func(i, j int) bool {
+		return digestRank(entries[i]) < digestRank(entries[j])
+	})
+	return entries, nil
+}
+
+// BuildWatchDigest reports on each watch: its latest run since the given time, compared with
+// the run before it. Watches without a run in the period are listed last, with no result.
+func BuildWatchDigest(rootFolder string, watches map[string]config.WatchConfig, since time.Time) ([]DigestEntry, error) {
+	runs, _, err := groupRuns(rootFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(watches))
+	for name := range watches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]DigestEntry, 0, len(names))
+	for _, name := range names {
+		watch := watches[name]
+		group := runs[runKey(watch.SearchType, watch.Query)]
+		entry := DigestEntry{Query: watch.Query, SearchType: watch.SearchType, Runs: len(group)}
+		if len(group) > 0 && !group[0].DateTime.Before(since) {
+			if compared, err := compareRuns(rootFolder, group); err == nil {
+				entry = compared
+			}
+		}
+		entry.Watch, entry.Query = name, watch.Query
+		entries = append(entries, entry)
+	}
+
+	// Watches with changes first, then the others that ran, then those that did not
+	sort.SliceStable(entries, func(i, j int) bool {
+		return watchRank(entries[i]) < watchRank(entries[j])
+	})
+	return entries, nil
+}
+
+// groupRuns groups the cached runs of each query, most recent first, returning the groups
+// by runKey and their keys from the most recently run
+func groupRuns(rootFolder string) (map[string][]cache.QueryListItem, []string, error) {
+	if !cache.IsCachingEnabled(rootFolder) {
+		return nil, nil, fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable")
+	}
+
+	items, err := cache.ListPreviousQueries(rootFolder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list cached results: %w", err)
+	}
+
+	// Items are already sorted most recent first
+	runs := make(map[string][]cache.QueryListItem)
+	var order []string
+	for _, item := range items {
+		key := runKey(item.SearchType, item.Query)
+		if _, ok := runs[key]; !ok {
+			order = append(order, key)
+		}
+		runs[key] = append(runs[key], item)
+	}
+	return runs, order, nil
+}
+
+// runKey identifies the runs of a query, ignoring case and spacing
+func runKey(searchType, query string) string {
+	return searchType + "\x00" + strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// compareRuns summarizes the latest run of a group and compares it with the run before it
+func compareRuns(rootFolder string, group []cache.QueryListItem) (DigestEntry, error) {
+	latest := group[0]
+	content, err := cache.GetPreviousResult(rootFolder, latest.UniqueID)
+	if err != nil {
+		return DigestEntry{}, err
+	}
+	entry := DigestEntry{
+		Query:      latest.Query,
+		SearchType: latest.SearchType,
+		Runs:       len(group),
+		LatestID:   latest.UniqueID,
+		LatestTime: latest.DateTime,
+		Summary:    summarize(answerBody(content)),
+	}
+
+	if len(group) > 1 {
+		previous, err := cache.GetPreviousResult(rootFolder, group[1].UniqueID)
+		if err == nil {
+			entry.Changed = strings.TrimSpace(answerBody(content)) != strings.TrimSpace(answerBody(previous))
+			entry.AddedSources = missingFrom(listedSources(content), listedSources(previous))
+			entry.DroppedSources = missingFrom(listedSources(previous), listedSources(content))
+		}
+	}
+	return entry, nil
+}
+
+// digestRank orders entries by how much attention they need
+func digestRank(e DigestEntry) int {
+	switch {
+	case e.changed():
+		return 0
+	case e.Watched():
+		return 1
+	default:
+		return 2
+	}
+}
+
+// watchRank orders the entries of watches by how much attention they need
+func watchRank(e DigestEntry) int {
+	switch {
+	case e.changed():
+		return 0
+	case e.LatestID != "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// missingFrom returns the items of a that are not in b
+func missingFrom(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, item := range b {
+		seen[item] = true
+	}
+	var missing []string
+	for _, item := range a {
+		if !seen[item] {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
+
+// FormatDigest renders the entries of BuildWatchDigest as a plain-text email, returning the
+// subject and body
+func FormatDigest(entries []DigestEntry, period string, since, now time.Time) (string, string) {
+	changed := 0
+	for _, e := range entries {
+		if watchRank(e) == 0 {
+			changed++
+		}
+	}
+	subject := fmt.Sprintf("Perplexity %s digest: %d of %d watch(es) changed", period, changed, len(entries))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Watched queries from %s to %s.\n", since.Format("2006-01-02 15:04"), now.Format("2006-01-02 15:04"))
+
+	section := -1
+	titles := []string{"Watches with changes", "Watches without changes", "Watches not run in this period"}
+	for _, e := range entries {
+		if rank := watchRank(e); rank != section {
+			section = rank
+			fmt.Fprintf(&b, "\n== %s ==\n", titles[rank])
+		}
+
+		fmt.Fprintf(&b, "\n- %s: %s [%s]\n", e.Watch, e.Query, e.SearchType)
+		if e.LatestID == "" {
+			fmt.Fprintf(&b, "  No run since %s (%d earlier run(s)); schedule the query so the digest can compare it\n", since.Format("2006-01-02 15:04"), e.Runs)
+			continue
+		}
+		fmt.Fprintf(&b, "  Result ID: %s (%s, run %d time(s))\n", e.LatestID, e.LatestTime.Format("2006-01-02 15:04"), e.Runs)
+		if !e.Watched() {
+			b.WriteString("  First run, nothing to compare yet\n")
+		} else if e.Changed {
+			b.WriteString("  Answer changed since the previous run\n")
+		}
+		if e.Summary != "" {
+			fmt.Fprintf(&b, "  %s\n", e.Summary)
+		}
+		for _, source := range e.AddedSources {
+			fmt.Fprintf(&b, "  + %s\n", source)
+		}
+		for _, source := range e.DroppedSources {
+			fmt.Fprintf(&b, "  - %s\n", source)
+		}
+	}
+
+	b.WriteString("\nRetrieve any result with get_previous_result and its Result ID.\n")
+	return subject, b.String()
+}
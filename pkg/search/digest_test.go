@@ -0,0 +1,123 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestBuildDigest(t *testing.T) {
+	root := t.TempDir()
+	save := func(query, content string) string {
+		id, err := cache.SaveResult(root, query, "general", types.ModelSonar, content, nil)
+		if err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+		return id
+	}
+
+	save("old query", "Not in this period.")
+	save("GPU prices", "Prices are high[1].\n\n## Source URLs\n1. https://a.com\n2. https://b.com\n")
+	since := time.Now()
+	save("gpu  prices", "Prices are falling[1].\n\n## Source URLs\n1. https://a.com\n2. https://c.com\n")
+	newID := save("new query", "A fresh answer.")
+
+	entries, err := BuildDigest(root, since)
+	if err != nil {
+		t.Fatalf("BuildDigest failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Entry count mismatch: got %d (%+v), want 2", len(entries), entries)
+	}
+
+	watched := entries[0]
+	if watched.Runs != 2 || !watched.Changed {
+		t.Errorf("Watched entry mismatch: %+v", watched)
+	}
+	if strings.Join(watched.AddedSources, ",") != "https://c.com" || strings.Join(watched.DroppedSources, ",") != "https://b.com" {
+		t.Errorf("Source changes mismatch: added %v, dropped %v", watched.AddedSources, watched.DroppedSources)
+	}
+	if watched.Summary != "Prices are falling." {
+		t.Errorf("Summary mismatch: got %q", watched.Summary)
+	}
+	if entries[1].LatestID != newID || entries[1].Watched() {
+		t.Errorf("New entry mismatch: %+v", entries[1])
+	}
+}
+
+func TestBuildWatchDigest(t *testing.T) {
+	root := t.TempDir()
+	save := func(query, searchType, content string) string {
+		id, err := cache.SaveResult(root, query, searchType, types.ModelSonar, content, nil)
+		if err != nil {
+			t.Fatalf("SaveResult failed: %v", err)
+		}
+		return id
+	}
+
+	save("GPU prices", "general", "Prices are high[1].\n\n## Source URLs\n1. https://a.com\n2. https://b.com\n")
+	save("Fed rate decision", "financial", "Rates held.")
+	since := time.Now()
+	save("gpu  prices", "general", "Prices are falling[1].\n\n## Source URLs\n1. https://a.com\n2. https://c.com\n")
+	firstID := save("Latest release of kubernetes", "release", "Kubernetes 1.40 was released.")
+	save("unwatched query", "general", "Not in the digest.")
+	save("Fed rate decision", "general", "A general search is not the financial watch.")
+
+	watches := map[string]config.WatchConfig{
+		"gpu-prices": {Query: "GPU prices", SearchType: "general", Period: DigestDaily},
+		"fed-rates":  {Query: "Fed rate decision", SearchType: "financial", Period: DigestDaily},
+		"kubernetes": {Query: "Latest release of kubernetes", SearchType: "release", Period: DigestDaily},
+	}
+	entries, err := BuildWatchDigest(root, watches, since)
+	if err != nil {
+		t.Fatalf("BuildWatchDigest failed: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Watch)
+	}
+	if strings.Join(names, ",") != "gpu-prices,kubernetes,fed-rates" {
+		t.Fatalf("Expected changed, first-run, then not-run watches, got %v", names)
+	}
+	if gpu := entries[0]; gpu.Runs != 2 || !gpu.Changed || strings.Join(gpu.AddedSources, ",") != "https://c.com" {
+		t.Errorf("Changed watch mismatch: %+v", gpu)
+	}
+	if k8s := entries[1]; k8s.LatestID != firstID || k8s.Watched() {
+		t.Errorf("First-run watch mismatch: %+v", k8s)
+	}
+	if fed := entries[2]; fed.LatestID != "" || fed.Runs != 1 {
+		t.Errorf("Expected the financial watch without a run in the period, got %+v", fed)
+	}
+
+	subject, body := FormatDigest(entries, DigestDaily, since, time.Now())
+	if subject != "Perplexity daily digest: 1 of 3 watch(es) changed" {
+		t.Errorf("Subject mismatch: got %q", subject)
+	}
+	expected := []string{
+		"== Watches with changes ==", "- gpu-prices: GPU prices [general]", "Answer changed since the previous run", "  + https://c.com", "  - https://b.com",
+		"== Watches without changes ==", firstID, "First run, nothing to compare yet",
+		"== Watches not run in this period ==", "- fed-rates: Fed rate decision [financial]", "(1 earlier run(s))",
+	}
+	for _, want := range expected {
+		if !strings.Contains(body, want) {
+			t.Errorf("Digest missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "unwatched query") {
+		t.Errorf("Expected only watches in the digest:\n%s", body)
+	}
+}
+
+func TestDigestSince(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	if since, _ := DigestSince(DigestWeekly, now); !since.Equal(now.AddDate(0, 0, -7)) {
+		t.Errorf("Weekly since mismatch: got %v", since)
+	}
+	if _, err := DigestSince("monthly", now); err == nil {
+		t.Error("Expected error for unknown period")
+	}
+}
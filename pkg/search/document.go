@@ -0,0 +1,191 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// ResultURIPrefix is the MCP resource URI scheme for cached results
+const ResultURIPrefix = "perplexity://results/"
+
+// Document is grounding material injected into a search prompt
+type Document struct {
+	Name    string
+	Content string
+}
+
+// ErrOutsideDocumentRoot is returned for a document path that resolves outside the document root
+var ErrOutsideDocumentRoot = errors.New("document is outside PERPLEXITY_DOCUMENT_ROOT")
+
+// LoadDocument reads a local text file inside root for use as grounding context, enforcing
+// maxBytes. A relative path is taken from root. The path comes from a tool call and the file
+// is sent to the API, so nothing can be read when root is empty.
+func LoadDocument(root, path string, maxBytes int) (Document, error) {
+	resolved, err := resolveDocumentPath(root, path)
+	if err != nil {
+		return Document{}, err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to read document: %w", err)
+	}
+	if info.IsDir() {
+		return Document{}, fmt.Errorf("document path '%s' is a directory", path)
+	}
+	if maxBytes > 0 && info.Size() > int64(maxBytes) {
+		return Document{}, fmt.Errorf("document '%s' is %d bytes, exceeding the %d byte limit", path, info.Size(), maxBytes)
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to read document: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return Document{}, fmt.Errorf("document '%s' is not valid UTF-8 text", path)
+	}
+
+	return Document{Name: path, Content: string(data)}, nil
+}
+
+// resolveDocumentPath returns path with its symlinks resolved after checking that both the
+// path as given and the file it leads to are inside root. The lexical check comes first, so
+// paths outside the root are refused without revealing whether they exist.
+func resolveDocumentPath(root, path string) (string, error) {
+	if root == "" {
+		return "", fmt.Errorf("reading local documents is disabled; set PERPLEXITY_DOCUMENT_ROOT to the folder they may be read from")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid document root: %w", err)
+	}
+	resolvedRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return "", fmt.Errorf("invalid document root: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(absRoot, path)
+	}
+	path = filepath.Clean(path)
+	if !isInside(absRoot, path) && !isInside(resolvedRoot, path) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideDocumentRoot, path)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read document: %w", err)
+	}
+	if !isInside(resolvedRoot, resolved) {
+		return "", fmt.Errorf("%w: %s links to a file outside it", ErrOutsideDocumentRoot, path)
+	}
+	return resolved, nil
+}
+
+// isInside reports whether path is root or below it
+func isInside(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
+// SearchWithContext answers a query grounded in the supplied documents as well as the web
+func (s *Searcher) SearchWithContext(ctx context.Context, params *SearchParams) (string, error) {
+	if len(params.Documents) == 0 {
+		return "", fmt.Errorf("at least one document is required for a context search")
+	}
+
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
+
+// LoadContextRef resolves a cached result ID, perplexity://results/ URI, or file:// URI into a
+// document. Every search tool accepts context references, so file:// URIs are held to the
+// document root just like the file_path of a context search.
+func (s *Searcher) LoadContextRef(ref string) (Document, error) {
+	switch {
+	case strings.HasPrefix(ref, ResultURIPrefix):
+		return s.loadCachedDocument(strings.TrimPrefix(ref, ResultURIPrefix))
+	case strings.HasPrefix(ref, "file://"):
+		if s.config().DocumentRoot == "" {
+			return Document{}, fmt.Errorf("file:// context references are disabled; set PERPLEXITY_DOCUMENT_ROOT to the folder they may be read from")
+		}
+		return LoadDocument(s.config().DocumentRoot, strings.TrimPrefix(ref, "file://"), s.config().MaxDocumentBytes)
+	case cache.IsValidID(ref):
+		return s.loadCachedDocument(ref)
+	default:
+		return Document{}, fmt.Errorf("unsupported context reference '%s': use a result ID, %s<ID>, or file:// URI", ref, ResultURIPrefix)
+	}
+}
+
+// loadCachedDocument loads a cached result as a grounding document
+func (s *Searcher) loadCachedDocument(resultID string) (Document, error) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		return Document{}, fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
+	}
+
+	content, err := s.readResult(resultID)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to load context reference: %w", err)
+	}
+	return Document{Name: ResultURI(resultID), Content: content}, nil
+}
+
+// ResultURI returns the MCP resource URI for a cached result
+func ResultURI(resultID string) string {
+	return ResultURIPrefix + resultID
+}
+
+// groundRequest injects the call's documents into the request's final message, keeping
+// them within the context token budget
+func (s *Searcher) groundRequest(req *types.PerplexityRequest, params *SearchParams) error {
+	if len(params.Documents) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, doc := range params.Documents {
+		total += len(doc.Content)
+	}
+	if limit := s.config().MaxDocumentBytes; limit > 0 && total > limit {
+		return fmt.Errorf("grounding documents total %d bytes, exceeding the %d byte limit", total, limit)
+	}
+
+	// Long documents are cut down to the passages most relevant to the question
+	docs, note := selectDocumentChunks(params.Documents, params.Query, s.config().ContextTokenBudget)
+	if note != "" {
+		params.addNote(note)
+	}
+
+	last := len(req.Messages) - 1
+	req.Messages[last].Content = groundedPrompt(req.Messages[last].Content, docs)
+	return nil
+}
+
+// groundedPrompt wraps the query with the documents and instructions to use both them and the web
+func groundedPrompt(query string, docs []Document) string {
+	var b strings.Builder
+	b.WriteString("Answer the question using both the documents below and current web sources. ")
+	b.WriteString("Prefer the documents for facts they cover, cite web sources for everything else, ")
+	b.WriteString("and point out where the web disagrees with the documents.\n\n")
+
+	for i, doc := range docs {
+		fmt.Fprintf(&b, "<document index=\"%d\" name=%q>\n%s\n</document>\n\n", i+1, doc.Name, strings.TrimSpace(doc.Content))
+	}
+
+	b.WriteString("Question: ")
+	b.WriteString(query)
+	return b.String()
+}
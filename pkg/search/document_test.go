@@ -0,0 +1,164 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestLoadDocument(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(path, []byte("Project notes"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	doc, err := LoadDocument(dir, path, 1024)
+	if err != nil {
+		t.Fatalf("LoadDocument failed: %v", err)
+	}
+	if doc.Content != "Project notes" || doc.Name != path {
+		t.Errorf("Document mismatch: got %+v", doc)
+	}
+	if doc, err := LoadDocument(dir, "notes.md", 1024); err != nil || doc.Content != "Project notes" {
+		t.Errorf("Expected a relative path read from the root, got %+v, %v", doc, err)
+	}
+
+	if _, err := LoadDocument(dir, path, 5); err == nil {
+		t.Error("Expected size limit error, got nil")
+	}
+	if _, err := LoadDocument(dir, dir, 1024); err == nil {
+		t.Error("Expected directory error, got nil")
+	}
+	if _, err := LoadDocument("", path, 1024); err == nil || !strings.Contains(err.Error(), "PERPLEXITY_DOCUMENT_ROOT") {
+		t.Errorf("Expected reading to be disabled without a root, got %v", err)
+	}
+}
+
+func TestLoadDocumentOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.env")
+	if err := os.WriteFile(outside, []byte("API_KEY=secret"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	link := filepath.Join(root, "notes.md")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	traversal, err := filepath.Rel(root, outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{outside, traversal, link, "/etc/passwd"} {
+		doc, err := LoadDocument(root, path, 1024)
+		if !errors.Is(err, ErrOutsideDocumentRoot) {
+			t.Errorf("LoadDocument(%q) = %q, %v; want ErrOutsideDocumentRoot", path, doc.Content, err)
+		}
+	}
+}
+
+func TestSearchWithContext(t *testing.T) {
+	var prompt string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt = req.Messages[len(req.Messages)-1].Content
+		return textResponse(req.Model, "Grounded answer")
+	})
+
+	params := &SearchParams{
+		Query:      "Is the launch date still accurate?",
+		SearchType: "context",
+		Documents:  []Document{{Name: "plan.md", Content: "Launch is planned for May."}},
+	}
+	if _, err := s.SearchWithContext(context.Background(), params); err != nil {
+		t.Fatalf("SearchWithContext failed: %v", err)
+	}
+
+	expected := []string{`name="plan.md"`, "Launch is planned for May.", "Question: Is the launch date still accurate?"}
+	for _, want := range expected {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	s.config().MaxDocumentBytes = 10
+	if _, err := s.SearchWithContext(context.Background(), params); err == nil {
+		t.Error("Expected size limit error, got nil")
+	}
+}
+
+func TestLoadContextRef(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(req.Model, "unused")
+	})
+	s.config().ResultsRootFolder = t.TempDir()
+
+	id, err := cache.SaveResult(s.config().ResultsRootFolder, "earlier query", "general", "sonar", "Earlier findings", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	for _, ref := range []string{id, ResultURI(id)} {
+		doc, err := s.LoadContextRef(ref)
+		if err != nil {
+			t.Fatalf("LoadContextRef(%q) failed: %v", ref, err)
+		}
+		if doc.Name != ResultURI(id) || !strings.Contains(doc.Content, "Earlier findings") {
+			t.Errorf("Document mismatch for %q: got %+v", ref, doc)
+		}
+	}
+
+	s.config().DocumentRoot = t.TempDir()
+	path := filepath.Join(s.config().DocumentRoot, "notes.txt")
+	if err := os.WriteFile(path, []byte("Local notes"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	doc, err := s.LoadContextRef("file://" + path)
+	if err != nil {
+		t.Fatalf("LoadContextRef(file) failed: %v", err)
+	}
+	if doc.Content != "Local notes" {
+		t.Errorf("Content mismatch: got %q, want %q", doc.Content, "Local notes")
+	}
+
+	if _, err := s.LoadContextRef("file:///etc/passwd"); !errors.Is(err, ErrOutsideDocumentRoot) {
+		t.Errorf("Expected a file outside the document root refused, got %v", err)
+	}
+	s.config().DocumentRoot = ""
+	if _, err := s.LoadContextRef("file://" + path); err == nil || !strings.Contains(err.Error(), "PERPLEXITY_DOCUMENT_ROOT") {
+		t.Errorf("Expected file:// references refused without a document root, got %v", err)
+	}
+
+	if _, err := s.LoadContextRef("https://example.com/page"); err == nil {
+		t.Error("Expected unsupported reference error, got nil")
+	}
+	if _, err := s.LoadContextRef("ZZZZZZZZZZ"); err == nil {
+		t.Error("Expected missing result error, got nil")
+	}
+}
+
+func TestGroundedAcademicSearch(t *testing.T) {
+	var prompt string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt = req.Messages[len(req.Messages)-1].Content
+		return textResponse(req.Model, "Grounded answer")
+	})
+
+	params := &SearchParams{
+		Query:     "What has changed since?",
+		Documents: []Document{{Name: "perplexity://results/ABCDEFGHIJ", Content: "Earlier findings"}},
+	}
+	if _, err := s.AcademicSearch(context.Background(), params); err != nil {
+		t.Fatalf("AcademicSearch failed: %v", err)
+	}
+
+	if !strings.Contains(prompt, "Earlier findings") || !strings.Contains(prompt, "Question: ") {
+		t.Errorf("Prompt missing grounding:\n%s", prompt)
+	}
+}
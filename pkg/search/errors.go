@@ -0,0 +1,88 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Error type identifiers reported to MCP clients
+const (
+	ErrorTypeAuthentication = "authentication"
+	ErrorTypeRateLimit      = "rate_limit"
+	ErrorTypeBadRequest     = "bad_request"
+	ErrorTypeServer         = "server_error"
+	ErrorTypeNetwork        = "network"
+	ErrorTypeTimeout        = "timeout"
+	ErrorTypeCanceled       = "canceled"
+	ErrorTypeAPI            = "api_error"
+)
+
+// APIError describes a failed Perplexity API call with enough structure for
+// callers to decide whether to retry, change model, or give up
+type APIError struct {
+	ErrorType  string
+	StatusCode int
+	Message    string
+	Hint       string
+	Retryable  bool
+	Err        error
+}
+
+// Error returns the human-readable message
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying transport error, if any
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// isRetryableStatus reports whether a request failing with the status may succeed on retry
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// errorTypeForStatus maps an HTTP status code to an error type identifier
+func errorTypeForStatus(statusCode int) string {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return ErrorTypeAuthentication
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorTypeRateLimit
+	case statusCode == http.StatusBadRequest:
+		return ErrorTypeBadRequest
+	case statusCode >= http.StatusInternalServerError:
+		return ErrorTypeServer
+	default:
+		return ErrorTypeAPI
+	}
+}
+
+// contextError reports a call that failed because its deadline passed or it was canceled,
+// saying how long it ran; it returns nil for any other failure
+func contextError(err error, elapsed time.Duration) *APIError {
+	seconds := elapsed.Round(100 * time.Millisecond).Seconds()
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return &APIError{
+			ErrorType: ErrorTypeTimeout,
+			Message:   fmt.Sprintf("search timed out after %gs; consider increasing timeout_seconds or using the sonar model", seconds),
+			Hint:      "Raise timeout_seconds (or PERPLEXITY_TIMEOUT for every search), or use 'sonar', which answers faster",
+			Retryable: true,
+			Err:       err,
+		}
+	case errors.Is(err, context.Canceled):
+		return &APIError{
+			ErrorType: ErrorTypeCanceled,
+			Message:   fmt.Sprintf("search canceled after %gs", seconds),
+			Err:       err,
+		}
+	}
+	return nil
+}
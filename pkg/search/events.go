@@ -0,0 +1,190 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// Corporate events accepted by the financial search event parameter
+const (
+	EventEarnings = "earnings"
+	EventDividend = "dividend"
+	EventSplit    = "split"
+)
+
+// eventWindow is the date range searched around an event, in days before and after it
+type eventWindow struct {
+	before int
+	after  int
+	label  string
+}
+
+// eventWindows covers the run-up and the coverage that follows each kind of event
+var eventWindows = map[string]eventWindow{
+	EventEarnings: {before: 3, after: 7, label: "earnings report"},
+	EventDividend: {before: 7, after: 3, label: "ex-dividend date"},
+	EventSplit:    {before: 14, after: 7, label: "stock split effective date"},
+}
+
+const dateLayout = "2006-01-02"
+
+var isoDatePattern = regexp.MustCompile(`\b(\d{4}-\d{2}-\d{2})\b`)
+
+// IsValidEvent reports whether event is a supported corporate event
+func IsValidEvent(event string) bool {
+	_, ok := eventWindows[event]
+	return ok
+}
+
+// calendarClient looks up event dates from a configured calendar API
+type calendarClient struct {
+	urlTemplate string
+	token       string
+	httpClient  *http.Client
+}
+
+// lookup fetches the event date nearest to now. The API must return either an object
+// with a "date" field or an array of such objects, with dates in YYYY-MM-DD form.
+func (c *calendarClient) lookup(ctx context.Context, ticker, event string, now time.Time) (time.Time, error) {
+	endpoint := strings.NewReplacer("{ticker}", url.QueryEscape(ticker), "{event}", url.QueryEscape(event)).Replace(c.urlTemplate)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create calendar request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("calendar request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read calendar response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("calendar API returned status %d", resp.StatusCode)
+	}
+
+	type entry struct {
+		Date string `json:"date"`
+	}
+	var entries []entry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		var single entry
+		if err := json.Unmarshal(body, &single); err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse calendar response: %w", err)
+		}
+		entries = []entry{single}
+	}
+
+	var dates []time.Time
+	for _, e := range entries {
+		if date, err := time.Parse(dateLayout, strings.TrimSpace(e.Date)); err == nil {
+			dates = append(dates, date)
+		}
+	}
+	if len(dates) == 0 {
+		return time.Time{}, fmt.Errorf("calendar returned no %s dates for %s", event, ticker)
+	}
+	return nearestDate(dates, now), nil
+}
+
+// nearestDate returns the date closest to now
+func nearestDate(dates []time.Time, now time.Time) time.Time {
+	best := dates[0]
+	for _, date := range dates[1:] {
+		if math.Abs(float64(date.Sub(now))) < math.Abs(float64(best.Sub(now))) {
+			best = date
+		}
+	}
+	return best
+}
+
+// lookupEventBySearch asks Perplexity for the event date
+func (s *Searcher) lookupEventBySearch(ctx context.Context, subject, event string, now time.Time) (time.Time, error) {
+	req := &types.PerplexityRequest{
+		Model: types.ModelSonar,
+		Messages: []types.Message{
+			{
+				Role: "user",
+				Content: fmt.Sprintf("Today is %s. What is the date of the %s for %s that is closest to today, either the most recent "+
+					"or the next scheduled one? Reply with the date only, in YYYY-MM-DD format.", now.Format(dateLayout), eventWindows[event].label, subject),
+			},
+		},
+		MaxTokens:   32,
+		Temperature: 0,
+	}
+
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return time.Time{}, fmt.Errorf("date lookup returned no answer")
+	}
+
+	match := isoDatePattern.FindString(resp.Choices[0].Message.Content)
+	if match == "" {
+		return time.Time{}, fmt.Errorf("date lookup answer has no date: %q", firstLine(resp.Choices[0].Message.Content))
+	}
+	return time.Parse(dateLayout, match)
+}
+
+// scopeToEvent resolves the event date for a financial search and narrows the date range
+// around it, unless the caller already set one. Lookup failures are noted, not returned,
+// so the search still runs unscoped.
+func (s *Searcher) scopeToEvent(ctx context.Context, params *SearchParams, now time.Time) {
+	subject := params.Ticker
+	if subject == "" {
+		subject = params.CompanyName
+	} else if params.CompanyName != "" {
+		subject = fmt.Sprintf("%s (%s)", params.CompanyName, params.Ticker)
+	}
+
+	var date time.Time
+	var err error
+	via := "search"
+	if s.calendar != nil && params.Ticker != "" {
+		via = "calendar API"
+		date, err = s.calendar.lookup(ctx, params.Ticker, params.Event, now)
+		if err != nil {
+			// Fall back to asking Perplexity when the calendar has no answer
+			via = "search, calendar API failed"
+			date, err = s.lookupEventBySearch(ctx, subject, params.Event, now)
+		}
+	} else {
+		date, err = s.lookupEventBySearch(ctx, subject, params.Event, now)
+	}
+	if err != nil {
+		params.addNote(fmt.Sprintf("Event scoping: could not resolve the %s date (%v); searched without event date filters", params.Event, err))
+		return
+	}
+
+	params.EventDate = date.Format(dateLayout)
+	if params.DateRangeStart != "" || params.DateRangeEnd != "" {
+		params.addNote(fmt.Sprintf("Event scoping: %s on %s (via %s); kept the requested date range", params.Event, params.EventDate, via))
+		return
+	}
+
+	window := eventWindows[params.Event]
+	params.DateRangeStart = date.AddDate(0, 0, -window.before).Format(dateLayout)
+	params.DateRangeEnd = date.AddDate(0, 0, window.after).Format(dateLayout)
+	params.addNote(fmt.Sprintf("Event scoping: %s on %s (via %s); searched %s to %s",
+		params.Event, params.EventDate, via, params.DateRangeStart, params.DateRangeEnd))
+}
@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestFinancialSearchEventViaSearch(t *testing.T) {
+	var searchReq *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		if strings.Contains(req.Messages[0].Content, "YYYY-MM-DD format") {
+			if !strings.Contains(req.Messages[0].Content, "earnings report for Apple (AAPL)") {
+				t.Errorf("Lookup prompt mismatch: %s", req.Messages[0].Content)
+			}
+			return textResponse(req.Model, "The report is on 2026-10-30.")
+		}
+		searchReq = req
+		return textResponse(req.Model, "Apple beat estimates.", "https://example.com/aapl")
+	})
+
+	result, err := s.FinancialSearch(context.Background(), &SearchParams{
+		Query:       "How did the quarter go?",
+		SearchType:  "financial",
+		Ticker:      "AAPL",
+		CompanyName: "Apple",
+		Event:       EventEarnings,
+	})
+	if err != nil {
+		t.Fatalf("FinancialSearch failed: %v", err)
+	}
+
+	if searchReq.DateRangeStart != "2026-10-27" || searchReq.DateRangeEnd != "2026-11-06" {
+		t.Errorf("Date range mismatch: got %s to %s", searchReq.DateRangeStart, searchReq.DateRangeEnd)
+	}
+	if !strings.Contains(searchReq.Messages[0].Content, "Event: earnings on 2026-10-30") {
+		t.Errorf("Query missing event context: %s", searchReq.Messages[0].Content)
+	}
+	if !strings.Contains(result, "Event scoping: earnings on 2026-10-30 (via search)") {
+		t.Errorf("Result missing event note:\n%s", result)
+	}
+}
+
+func TestFinancialSearchEventViaCalendar(t *testing.T) {
+	calendar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("symbol") != "MSFT" || r.Header.Get("Authorization") != "Bearer cal-token" {
+			t.Errorf("Calendar request mismatch: %s %v", r.URL, r.Header)
+		}
+		now := time.Now()
+		w.Write([]byte(`[{"date": "` + now.AddDate(0, 0, -90).Format(dateLayout) + `"}, {"date": "` + now.AddDate(0, 0, 5).Format(dateLayout) + `"}]`))
+	}))
+	defer calendar.Close()
+
+	var searchReq *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		searchReq = req
+		return textResponse(req.Model, "Microsoft declared a dividend.")
+	})
+	s.calendar = &calendarClient{urlTemplate: calendar.URL + "/?symbol={ticker}&type={event}", token: "cal-token", httpClient: calendar.Client()}
+
+	_, err := s.FinancialSearch(context.Background(), &SearchParams{Query: "dividend size", SearchType: "financial", Ticker: "MSFT", Event: EventDividend})
+	if err != nil {
+		t.Fatalf("FinancialSearch failed: %v", err)
+	}
+
+	eventDate := time.Now().AddDate(0, 0, 5)
+	if want := eventDate.AddDate(0, 0, -7).Format(dateLayout); searchReq.DateRangeStart != want {
+		t.Errorf("DateRangeStart mismatch: got %s, want %s", searchReq.DateRangeStart, want)
+	}
+}
+
+func TestScopeToEventKeepsExplicitRange(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(req.Model, "2026-06-10")
+	})
+
+	params := &SearchParams{Ticker: "NVDA", Event: EventSplit, DateRangeStart: "2026-01-01"}
+	s.scopeToEvent(context.Background(), params, time.Now())
+	if params.EventDate != "2026-06-10" || params.DateRangeStart != "2026-01-01" || params.DateRangeEnd != "" {
+		t.Errorf("Params mismatch: %+v", params)
+	}
+}
+
+func TestScopeToEventLookupFailure(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(req.Model, "No split has been announced.")
+	})
+
+	params := &SearchParams{Ticker: "NVDA", Event: EventSplit}
+	s.scopeToEvent(context.Background(), params, time.Now())
+	if params.DateRangeStart != "" || len(params.notes) != 1 || !strings.Contains(params.notes[0], "could not resolve the split date") {
+		t.Errorf("Expected unscoped search with a note, got %+v", params)
+	}
+}
@@ -0,0 +1,270 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const (
+	// maxRefusalLength is the answer length above which a refusal-like opening is treated as a real answer
+	maxRefusalLength = 400
+	// statusOverloaded is the non-standard status some APIs return when a model is overloaded
+	statusOverloaded = 529
+)
+
+// refusalPrefixes are lowercase openings that indicate the model declined to answer
+var refusalPrefixes = []string{
+	"i'm sorry",
+	"i am sorry",
+	"sorry,",
+	"i cannot",
+	"i can't",
+	"i couldn't find",
+	"i could not find",
+	"i was unable",
+	"i am unable",
+	"i'm unable",
+	"i don't have enough information",
+	"i do not have enough information",
+	"there is no information",
+	"no relevant information",
+	"unfortunately, i",
+}
+
+// execute calls the API for a prepared request and applies response safeguards
+func (s *Searcher) execute(ctx context.Context, req *types.PerplexityRequest, params *SearchParams) (*types.PerplexityResponse, error) {
+	params.logger = logging.FromContext(ctx)
+	if id := logging.RequestID(ctx); id != "" && params.requestID == "" {
+		params.requestID = id
+		params.addNote("Request ID: " + id)
+	}
+	req = withExamples(s.withScaffold(req, params), params.Examples)
+	if _, language := normalizeLanguage(params.AnswerLanguage); language != "" {
+		req = withAnswerLanguage(req, language)
+	}
+	// Local-now searches already ask for the location's local time
+	if loc := s.location(params); loc != nil && params.SearchType != "local" {
+		req = withTimezone(req, s.now(params))
+	}
+	if loc := s.location(params); loc != nil && !params.timezoneNoted {
+		params.timezoneNoted = true
+		params.addNote(fmt.Sprintf("Time zone: %s (dates computed as of %s)", loc, s.now(params).Format("2006-01-02 15:04 MST")))
+	}
+
+	if err := s.groundRequest(req, params); err != nil {
+		return nil, err
+	}
+	if err := fitContext(req, params); err != nil {
+		return nil, err
+	}
+
+	if s.anonymizer != nil {
+		var count int
+		req, count = s.anonymizer.apply(req)
+		if count > 0 {
+			params.addNote(fmt.Sprintf("Anonymization: replaced %d sensitive term(s) before sending; restored in the answer where possible", count))
+		}
+	}
+
+	if params.TimeoutSeconds > 0 {
+		ctx = withCallTimeout(ctx, time.Duration(params.TimeoutSeconds)*time.Second)
+	}
+
+	resp, err := s.client.callAPI(ctx, req)
+	if fallback := s.config().FallbackModel; err != nil && fallback != "" && fallback != req.Model && isModelUnavailable(err) {
+		req, resp, err = s.retryWithFallbackModel(ctx, req, params, fallback, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isTruncated(resp) {
+		params.addNote("Timeout: the streamed answer was cut off, so only the part received before the timeout is shown")
+	}
+
+	if s.retryOnEmpty(params) && isEmptyAnswer(resp) {
+		resp = s.retryRephrased(ctx, req, params, resp)
+	}
+
+	if params.MinCitations > 0 && citationCount(resp) < params.MinCitations {
+		resp = s.retryForCitations(ctx, req, params, resp)
+	}
+
+	resp = s.checkLanguage(ctx, req, params, resp)
+
+	resp = s.enforcePolicy(ctx, req, params, resp)
+	if s.anonymizer != nil {
+		resp = s.anonymizer.restore(resp)
+	}
+	resp = s.filterContent(resp, params)
+	return applyTransforms(resp, params.Transforms, s.now(params)), nil
+}
+
+// modelUnavailableHints are lowercase phrases of API errors that reject the model itself
+var modelUnavailableHints = []string{"invalid model", "unsupported model", "not supported", "not available", "does not exist", "not found", "deprecated"}
+
+// capacityHints are lowercase phrases of API errors caused by a lack of capacity for a model
+var capacityHints = []string{"capacity", "overloaded"}
+
+// isModelUnavailable reports whether err means the API rejected the request's model or had
+// no capacity for it, so another model may succeed where retrying the same one would not
+func isModelUnavailable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode == 0 {
+		return false
+	}
+	message := strings.ToLower(apiErr.Message)
+	containsAny := func(hints []string) bool {
+		for _, hint := range hints {
+			if strings.Contains(message, hint) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusServiceUnavailable || apiErr.StatusCode == statusOverloaded:
+		return true
+	case apiErr.StatusCode == http.StatusBadRequest || apiErr.StatusCode == http.StatusNotFound:
+		return strings.Contains(message, "model") && containsAny(modelUnavailableHints)
+	case apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError:
+		return containsAny(capacityHints)
+	}
+	return false
+}
+
+// retryWithFallbackModel re-sends a request that failed because of its model once with the
+// fallback model, returning the request that produced the answer so later retries keep it.
+// When the fallback fails too, the original error is returned.
+func (s *Searcher) retryWithFallbackModel(ctx context.Context, req *types.PerplexityRequest, params *SearchParams, fallback string, cause error) (*types.PerplexityRequest, *types.PerplexityResponse, error) {
+	retryReq := *req
+	retryReq.Model = fallback
+	params.log().Warn("model unavailable, retrying with fallback model", "model", req.Model, "fallback_model", fallback, "error", cause)
+
+	resp, err := s.client.callAPI(ctx, &retryReq)
+	if err != nil {
+		params.log().Warn("fallback model failed", "fallback_model", fallback, "error", err)
+		return req, nil, cause
+	}
+	var apiErr *APIError
+	errors.As(cause, &apiErr)
+	params.Model = fallback
+	params.addNote(fmt.Sprintf("Fallback model: %s was unavailable (HTTP %d); answer below is from %s", req.Model, apiErr.StatusCode, fallback))
+	return &retryReq, resp, nil
+}
+
+// withExamples returns a copy of req with few-shot question/answer pairs inserted before the final message
+func withExamples(req *types.PerplexityRequest, examples []types.Example) *types.PerplexityRequest {
+	if len(examples) == 0 {
+		return req
+	}
+
+	withPairs := *req
+	last := len(req.Messages) - 1
+	withPairs.Messages = append([]types.Message{}, req.Messages[:last]...)
+	for _, example := range examples {
+		withPairs.Messages = append(withPairs.Messages,
+			types.Message{Role: "user", Content: example.Question},
+			types.Message{Role: "assistant", Content: example.Answer},
+		)
+	}
+	withPairs.Messages = append(withPairs.Messages, req.Messages[last])
+	return &withPairs
+}
+
+// retryOnEmpty reports whether empty-answer retries are enabled for this call
+func (s *Searcher) retryOnEmpty(params *SearchParams) bool {
+	if params.RetryOnEmpty != nil {
+		return *params.RetryOnEmpty
+	}
+	return s.config().RetryOnEmpty
+}
+
+// retryRephrased re-sends the request once with an explicit answer instruction,
+// keeping the original response if the retry fails or is still empty
+func (s *Searcher) retryRephrased(ctx context.Context, req *types.PerplexityRequest, params *SearchParams, original *types.PerplexityResponse) *types.PerplexityResponse {
+	retryReq := *req
+	retryReq.Messages = make([]types.Message, len(req.Messages))
+	copy(retryReq.Messages, req.Messages)
+
+	last := len(retryReq.Messages) - 1
+	retryReq.Messages[last].Content = "Using the available search results, give a direct and factual answer. " +
+		"If information is incomplete, summarize what the sources do say.\n\n" + retryReq.Messages[last].Content
+
+	resp, err := s.client.callAPI(ctx, &retryReq)
+	if err != nil || isEmptyAnswer(resp) {
+		params.addNote("Fallback used: empty or refusal answer detected; retry with rephrased prompt did not produce an answer")
+		return original
+	}
+
+	params.addNote("Fallback used: empty or refusal answer detected; answer below is from a retry with a rephrased prompt")
+	return resp
+}
+
+// retryForCitations re-sends the request once with a larger search context (and sonar-pro
+// when sonar was used), keeping whichever response cites more sources
+func (s *Searcher) retryForCitations(ctx context.Context, req *types.PerplexityRequest, params *SearchParams, original *types.PerplexityResponse) *types.PerplexityResponse {
+	retryReq := *req
+	if retryReq.Model == types.ModelSonar {
+		retryReq.Model = types.ModelSonarPro
+	}
+	if retryReq.SearchContextSize > 0 {
+		retryReq.SearchContextSize *= 2
+	} else {
+		retryReq.SearchContextSize = types.DefaultContextSize * 2
+	}
+
+	resp := original
+	retried, err := s.client.callAPI(ctx, &retryReq)
+	if err == nil && !isEmptyAnswer(retried) && citationCount(retried) > citationCount(original) {
+		resp = retried
+		if retryReq.Model != req.Model {
+			params.Model = retryReq.Model
+		}
+		params.addNote(fmt.Sprintf("Citation guard: first answer had %d citations (minimum %d); retried with model %s and search context size %d",
+			citationCount(original), params.MinCitations, retryReq.Model, retryReq.SearchContextSize))
+	}
+
+	if count := citationCount(resp); count < params.MinCitations {
+		params.addNote(fmt.Sprintf("Citation guard: requirement not met, answer has %d of the %d required citations", count, params.MinCitations))
+	}
+	return resp
+}
+
+// citationCount returns the number of distinct sources cited by a response
+func citationCount(resp *types.PerplexityResponse) int {
+	if len(resp.Citations) >= len(resp.SearchResults) {
+		return len(resp.Citations)
+	}
+	return len(resp.SearchResults)
+}
+
+// isEmptyAnswer detects responses with no usable answer text or a short refusal
+func isEmptyAnswer(resp *types.PerplexityResponse) bool {
+	if len(resp.Choices) == 0 {
+		return true
+	}
+
+	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if content == "" {
+		return true
+	}
+	if len(content) > maxRefusalLength {
+		return false
+	}
+
+	lower := strings.ToLower(content)
+	for _, prefix := range refusalPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
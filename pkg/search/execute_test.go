@@ -0,0 +1,254 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestIsEmptyAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", true},
+		{"whitespace", "  \n ", true},
+		{"refusal", "I'm sorry, but I couldn't find information about that.", true},
+		{"answer", "Paris is the capital of France.", false},
+		{"long answer with apology", "I'm sorry to report that " + strings.Repeat("details ", 80), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := textResponse(types.ModelSonar, tt.content)
+			if got := isEmptyAnswer(resp); got != tt.want {
+				t.Errorf("isEmptyAnswer mismatch: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !isEmptyAnswer(&types.PerplexityResponse{}) {
+		t.Error("Response without choices should be empty")
+	}
+}
+
+func TestExecuteRetriesOnEmpty(t *testing.T) {
+	var calls int32
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return textResponse(req.Model, "", "https://example.com")
+		}
+		return textResponse(req.Model, "Rephrased answer", "https://example.com")
+	})
+
+	params := &SearchParams{Query: "test", SearchType: "general"}
+	result, err := s.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("API call count mismatch: got %d, want 2", calls)
+	}
+	if !strings.Contains(result, "Rephrased answer") {
+		t.Errorf("Expected retried answer, got:\n%s", result)
+	}
+	if !strings.Contains(result, "Fallback used") {
+		t.Errorf("Expected fallback marker, got:\n%s", result)
+	}
+}
+
+func TestExecuteRetryDisabled(t *testing.T) {
+	var calls int32
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		atomic.AddInt32(&calls, 1)
+		return textResponse(req.Model, "")
+	})
+
+	disabled := false
+	params := &SearchParams{Query: "test", SearchType: "general", RetryOnEmpty: &disabled}
+	if _, err := s.Search(context.Background(), params); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("API call count mismatch: got %d, want 1", calls)
+	}
+}
+
+func TestExecuteMinCitations(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		if req.Model == types.ModelSonarPro {
+			return textResponse(req.Model, "Better answer", "https://a.com", "https://b.com", "https://c.com")
+		}
+		return textResponse(req.Model, "Thin answer", "https://a.com")
+	})
+
+	params := &SearchParams{Query: "test", SearchType: "general", MinCitations: 3}
+	result, err := s.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if !strings.Contains(result, "Better answer") {
+		t.Errorf("Expected retried answer, got:\n%s", result)
+	}
+	if params.Model != types.ModelSonarPro {
+		t.Errorf("Model mismatch: got %s, want %s", params.Model, types.ModelSonarPro)
+	}
+	if strings.Contains(result, "requirement not met") {
+		t.Errorf("Requirement should be met, got:\n%s", result)
+	}
+}
+
+func TestExecuteMinCitationsUnmet(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(req.Model, "Thin answer", "https://a.com")
+	})
+
+	params := &SearchParams{Query: "test", SearchType: "general", MinCitations: 5}
+	result, err := s.Search(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	if !strings.Contains(result, "answer has 1 of the 5 required citations") {
+		t.Errorf("Expected unmet annotation, got:\n%s", result)
+	}
+}
+
+func TestExecuteWithExamples(t *testing.T) {
+	var messages []types.Message
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		messages = req.Messages
+		return textResponse(req.Model, "| Item | Value |")
+	})
+
+	params := &SearchParams{
+		Query:    "Compare the two plans",
+		Examples: []types.Example{{Question: "Compare A and B", Answer: "| Item | A | B |"}},
+	}
+	if _, err := s.execute(context.Background(), s.buildRequest(params, types.ModelSonar), params); err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+
+	want := []types.Message{
+		{Role: "user", Content: "Compare A and B"},
+		{Role: "assistant", Content: "| Item | A | B |"},
+		{Role: "user", Content: "Compare the two plans"},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("Message count mismatch: got %d, want %d", len(messages), len(want))
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Message %d mismatch: got %+v, want %+v", i, messages[i], want[i])
+		}
+	}
+}
+
+func TestExecuteRecordsRequestID(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	s.config().ResultsRootFolder = t.TempDir()
+	ctx := logging.WithRequestID(context.Background(), "0123456789abcdef")
+
+	result, err := s.Search(ctx, &SearchParams{Query: "test", SearchType: "general"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	var artifact struct {
+		UniqueID  string `json:"unique_id"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal([]byte(result), &artifact); err != nil {
+		t.Fatalf("Expected artifact JSON, got %q: %v", result, err)
+	}
+	if artifact.RequestID != "0123456789abcdef" {
+		t.Errorf("Artifact request ID mismatch: got %q", artifact.RequestID)
+	}
+
+	metadata, err := cache.GetMetadata(s.config().ResultsRootFolder, artifact.UniqueID)
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.RequestID != "0123456789abcdef" {
+		t.Errorf("Metadata request ID mismatch: got %q", metadata.RequestID)
+	}
+	saved, err := cache.GetPreviousResult(s.config().ResultsRootFolder, artifact.UniqueID)
+	if err != nil {
+		t.Fatalf("GetPreviousResult failed: %v", err)
+	}
+	if !strings.Contains(saved, "\n- Request ID: 0123456789abcdef\n") {
+		t.Errorf("Expected the request ID in the footer, got:\n%s", saved)
+	}
+}
+
+func TestExecuteFallbackModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		message      string
+		wantFallback bool
+	}{
+		{"invalid model", http.StatusBadRequest, "Invalid model 'sonar-pro'", true},
+		{"overloaded", http.StatusServiceUnavailable, "overloaded", true},
+		{"capacity", http.StatusTooManyRequests, "sonar-pro is at capacity", true},
+		{"plain rate limit", http.StatusTooManyRequests, "too many requests", false},
+		{"bad query", http.StatusBadRequest, "max_tokens must be positive", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var models []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req types.PerplexityRequest
+				json.NewDecoder(r.Body).Decode(&req)
+				models = append(models, req.Model)
+				if req.Model == types.ModelSonarPro {
+					w.WriteHeader(tt.status)
+					fmt.Fprintf(w, `{"error": {"type": "error", "message": %q}}`, tt.message)
+					return
+				}
+				json.NewEncoder(w).Encode(textResponse(req.Model, "Fallback answer", "https://a.com"))
+			}))
+			defer srv.Close()
+
+			cfg := testConfig()
+			cfg.FallbackModel = types.ModelSonar
+			s, err := NewSearcher(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.client.baseURL = srv.URL
+
+			params := &SearchParams{Query: "test", SearchType: "general", Model: types.ModelSonarPro}
+			result, err := s.Search(context.Background(), params)
+			if !tt.wantFallback {
+				if err == nil || len(models) != 1 {
+					t.Errorf("Expected the error without a fallback, got %v after %v", err, models)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(models) != 2 || models[1] != types.ModelSonar || params.Model != types.ModelSonar {
+				t.Errorf("Expected one retry with the fallback model, got %v", models)
+			}
+			if !strings.Contains(result, "Fallback answer") || !strings.Contains(result, fmt.Sprintf("Fallback model: sonar-pro was unavailable (HTTP %d); answer below is from sonar", tt.status)) {
+				t.Errorf("Expected the substitution noted, got:\n%s", result)
+			}
+		})
+	}
+}
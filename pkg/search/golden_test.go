@@ -0,0 +1,258 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// Run "go test ./pkg/search -run TestGolden -update" after an intended change to what agents
+// see, and review the diff of testdata/golden before committing it
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenTime is the clock of every golden case, so trend windows, local times, timestamps
+// and latencies come out the same on each run
+var goldenTime = time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)
+
+// goldenCases run every search tool against the canned response
+var goldenCases = []struct {
+	name string
+	run  func(s *Searcher, ctx context.Context) (string, error)
+}{
+	{"search", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.Search(ctx, &SearchParams{Query: "solid-state batteries", SearchType: "general"})
+	}},
+	{"academic", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.AcademicSearch(ctx, &SearchParams{Query: "solid-state electrolytes", SearchType: "academic", SubjectArea: "Materials Science", PeerReviewedOnly: true})
+	}},
+	{"financial", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.FinancialSearch(ctx, &SearchParams{Query: "battery division results", SearchType: "financial", Ticker: "TM", CompanyName: "Toyota", ReportType: "10-K"})
+	}},
+	{"filtered", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.FilteredSearch(ctx, &SearchParams{Query: "solid-state batteries", SearchType: "filtered", ContentType: "news", FileType: "pdf", Language: "en", Country: "JP"})
+	}},
+	{"patent", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.PatentSearch(ctx, &SearchParams{Query: "sulfide electrolyte", SearchType: "patent", Assignee: "Toyota", CPCClass: "H01M", Jurisdiction: "US"})
+	}},
+	{"legal", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.LegalSearch(ctx, &SearchParams{Query: "battery recycling obligations", SearchType: "legal", Jurisdiction: "EU", Court: "CJEU"})
+	}},
+	{"medical", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.MedicalSearch(ctx, &SearchParams{Query: "lithium exposure", SearchType: "medical", Population: "adults"})
+	}},
+	{"product", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.ProductSearch(ctx, &SearchParams{Query: "portable power station", SearchType: "product", Category: "electronics", Budget: "under $500", MustHaveFeatures: []string{"LiFePO4"}})
+	}},
+	{"people", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.PeopleSearch(ctx, &SearchParams{Query: "battery research lead", SearchType: "people", PersonName: "Jane Doe", CompanyName: "Acme Cells"})
+	}},
+	{"company", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.CompanySearch(ctx, &SearchParams{Query: "funding history", SearchType: "company", CompanyName: "Acme Cells", Focus: FocusFunding})
+	}},
+	{"travel", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.TravelSearch(ctx, &SearchParams{Query: "battery factory tours", SearchType: "travel", Origin: "Berlin", Destination: "Nagoya", DateRangeStart: "2026-01-01"})
+	}},
+	{"dev", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.DevSearch(ctx, &SearchParams{Query: "parse battery telemetry", SearchType: "dev", ProgrammingLanguage: "go", Framework: "stdlib", Version: "1.23"})
+	}},
+	{"security", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.SecuritySearch(ctx, &SearchParams{Query: "battery management firmware flaws", SearchType: "security", Product: "BMS firmware", Severity: "high", DateRangeStart: "2026-01-01"})
+	}},
+	{"context", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.SearchWithContext(ctx, &SearchParams{Query: "what does the memo conclude?", SearchType: "context", Documents: []Document{{Name: "memo.txt", Content: "Pilot lines open in 2025."}}})
+	}},
+	{"regions", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.RegionSearch(ctx, &SearchParams{Query: "EV incentives", SearchType: "general"}, []string{"DE", "US"})
+	}},
+	{"competitors", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.CompetitorSearch(ctx, &SearchParams{Query: CompetitorsQuery("Acme Cells"), SearchType: "competitors", CompanyName: "Acme Cells"}, []string{"Volt Co"}, []string{ScopePricing}, 1)
+	}},
+	{"trend", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.TrendSearch(ctx, &SearchParams{Query: "solid-state battery announcements", SearchType: "trend"}, TrendQuarter, 3)
+	}},
+	{"release_watch", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.ReleaseWatch(ctx, &SearchParams{Query: ReleaseWatchQuery("", "golang/go"), SearchType: "release", Repo: "golang/go"})
+	}},
+	{"local_now", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.LocalNow(ctx, &SearchParams{Query: "traffic and events", SearchType: "local", Location: "Nagoya", Timezone: "Asia/Tokyo"})
+	}},
+	{"compare", func(s *Searcher, ctx context.Context) (string, error) {
+		return s.CompareModels(ctx, &SearchParams{Query: "solid-state batteries", SearchType: "comparison"}, []string{types.ModelSonar, types.ModelSonarPro})
+	}},
+}
+
+// goldenCachedCases run the tools that work on a cached result against the output of the
+// search case, cached in a temporary results folder. Their golden files include the result
+// they cache, with the folder and the random result IDs replaced by placeholders.
+var goldenCachedCases = []struct {
+	name string
+	run  func(s *Searcher, ctx context.Context, sourceID string) (string, error)
+}{
+	{"verify", func(s *Searcher, ctx context.Context, sourceID string) (string, error) {
+		return s.VerifyResult(ctx, sourceID, 3)
+	}},
+	{"translate", func(s *Searcher, ctx context.Context, sourceID string) (string, error) {
+		return s.TranslateResult(ctx, sourceID, "German")
+	}},
+}
+
+// loadGoldenResponse reads the canned API response every golden case is answered with
+func loadGoldenResponse(t *testing.T) *types.PerplexityResponse {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "golden", "response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp types.PerplexityResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+// goldenRequest renders an API request with its messages as plain text and its other fields as JSON
+func goldenRequest(req *types.PerplexityRequest) string {
+	data, _ := json.Marshal(req)
+	var fields map[string]interface{}
+	json.Unmarshal(data, &fields)
+	delete(fields, "messages")
+	data, _ = json.MarshalIndent(fields, "", "  ")
+
+	var b strings.Builder
+	b.Write(data)
+	for _, message := range req.Messages {
+		fmt.Fprintf(&b, "\n--- %s\n%s", message.Role, message.Content)
+	}
+	return b.String()
+}
+
+// newGoldenSearcher returns a searcher on goldenTime whose API answers every request with
+// canned, and the requests it was sent
+func newGoldenSearcher(t *testing.T, canned *types.PerplexityResponse) (*Searcher, *[]string) {
+	var mu sync.Mutex
+	var requests []string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, goldenRequest(req))
+		resp := *canned
+		resp.Model = req.Model
+		return &resp
+	})
+	s.clock = func() time.Time { return goldenTime }
+	return s, &requests
+}
+
+// checkGolden compares the requests a case sent and its output with its golden file, or
+// rewrites the file with -update
+func checkGolden(t *testing.T, name string, requests []string, output string) {
+	t.Helper()
+
+	// Tools that fan out send their requests concurrently, so their order is not fixed
+	sort.Strings(requests)
+	var b strings.Builder
+	for i, req := range requests {
+		fmt.Fprintf(&b, "=== request %d\n%s\n\n", i+1, req)
+	}
+	fmt.Fprintf(&b, "=== output\n%s\n", output)
+	got := b.String()
+
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Missing golden file, run with -update to create it: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Output differs from %s; if the change is intended, run with -update and review the diff.\n%s", path, firstDifference(string(want), got))
+	}
+}
+
+func TestGolden(t *testing.T) {
+	canned := loadGoldenResponse(t)
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, requests := newGoldenSearcher(t, canned)
+			output, err := tc.run(s, context.Background())
+			if err != nil {
+				t.Fatalf("%s failed: %v", tc.name, err)
+			}
+			checkGolden(t, tc.name, *requests, output)
+		})
+	}
+
+	for _, tc := range goldenCachedCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, requests := newGoldenSearcher(t, canned)
+			root := t.TempDir()
+			s.config().ResultsRootFolder = root
+			sourceID, err := cache.SaveResult(root, "solid-state batteries", "general", types.ModelSonar, s.formatResponse(canned), nil)
+			if err != nil {
+				t.Fatalf("SaveResult failed: %v", err)
+			}
+
+			output, err := tc.run(s, context.Background(), sourceID)
+			if err != nil {
+				t.Fatalf("%s failed: %v", tc.name, err)
+			}
+
+			placeholders := []string{root, "<results>", sourceID, "<source id>"}
+			queries, err := cache.ListPreviousQueries(root)
+			if err != nil {
+				t.Fatalf("ListPreviousQueries failed: %v", err)
+			}
+			for _, q := range queries {
+				if q.UniqueID == sourceID {
+					continue
+				}
+				placeholders = append(placeholders, q.UniqueID, "<result id>")
+
+				// The output points at the cached result, so the result itself is checked too
+				result, err := cache.GetPreviousResult(root, q.UniqueID)
+				if err != nil {
+					t.Fatalf("GetPreviousResult failed: %v", err)
+				}
+				output += "\n\n=== cached result\n" + result
+			}
+			replacer := strings.NewReplacer(placeholders...)
+			for i, req := range *requests {
+				(*requests)[i] = replacer.Replace(req)
+			}
+			checkGolden(t, tc.name, *requests, replacer.Replace(output))
+		})
+	}
+}
+
+// firstDifference shows the first line where two texts differ
+func firstDifference(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n  want: %q\n  got:  %q", i+1, w, g)
+		}
+	}
+	return "no line differs"
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+)
+
+// maxDownloadedImages bounds how many images of one result are downloaded
+const maxDownloadedImages = 10
+
+// imageExtensions lists the image types that are downloaded, by sniffed content type.
+// SVG is left remote because it can carry scripts.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// imageLinkPattern matches the image lines formatResponse writes in the Images section
+var imageLinkPattern = regexp.MustCompile(`(?m)^(\d+\. !\[image \d+\]\()(\S+?)(\).*)$`)
+
+// imageDownloader fetches result images into the cache so research bundles survive link rot
+type imageDownloader struct {
+	httpClient *http.Client
+	userAgent  string
+	maxBytes   int
+}
+
+// download fetches one image, enforcing the size limit and allowed types
+func (d *imageDownloader) download(ctx context.Context, imageURL string) ([]byte, string, error) {
+	if !strings.HasPrefix(imageURL, "https://") && !strings.HasPrefix(imageURL, "http://") {
+		return nil, "", fmt.Errorf("unsupported image URL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if d.userAgent != "" {
+		req.Header.Set("User-Agent", d.userAgent)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > int64(d.maxBytes) {
+		return nil, "", fmt.Errorf("image is %d bytes, over the %d byte limit", resp.ContentLength, d.maxBytes)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(d.maxBytes)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > d.maxBytes {
+		return nil, "", fmt.Errorf("image is over the %d byte limit", d.maxBytes)
+	}
+
+	// Trust the bytes rather than the Content-Type header
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported image type %s", contentType)
+	}
+	return data, ext, nil
+}
+
+// localizeImages downloads the images listed in a cached result's Images section into its
+// folder under root and points the links at the local copies, keeping the original URL alongside.
+// Images that fail to download stay linked remotely.
+func (s *Searcher) localizeImages(ctx context.Context, root, uniqueID, content string) (string, bool) {
+	start := strings.Index(content, "\n\n## Images\n")
+	if start < 0 {
+		return content, false
+	}
+	end := len(content)
+	if next := strings.Index(content[start+len("\n\n## Images\n"):], "\n\n## "); next >= 0 {
+		end = start + len("\n\n## Images\n") + next
+	}
+	section := content[start:end]
+
+	matches := imageLinkPattern.FindAllStringSubmatch(section, maxDownloadedImages)
+	local := make([]string, len(matches))
+	var wg sync.WaitGroup
+	for i, match := range matches {
+		wg.Add(1)
+		go func(i int, imageURL string) {
+			defer wg.Done()
+			data, ext, err := s.images.download(ctx, imageURL)
+			if err == nil {
+				local[i], err = cache.SaveImage(root, uniqueID, fmt.Sprintf("%d%s", i+1, ext), data)
+			}
+			if err != nil {
+				logging.FromContext(ctx).Warn("failed to download image", "url", imageURL, "result_id", uniqueID, "error", err)
+			}
+		}(i, match[2])
+	}
+	wg.Wait()
+
+	changed := false
+	for i, match := range matches {
+		if local[i] == "" {
+			continue
+		}
+		section = strings.Replace(section, match[0], match[1]+local[i]+match[3]+fmt.Sprintf(" (original: %s)", match[2]), 1)
+		changed = true
+	}
+	return content[:start] + section + content[end:], changed
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestLocalizeImages(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chart.png":
+			w.Write(png)
+		case "/page":
+			w.Write([]byte("<html><body>not an image</body></html>"))
+		case "/huge.png":
+			w.Write(append(png, bytes.Repeat([]byte{0}, 2048)...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.ResultsRootFolder = t.TempDir()
+	s := &Searcher{source: cfg, images: &imageDownloader{httpClient: srv.Client(), maxBytes: 1024}}
+
+	resp := &types.PerplexityResponse{
+		Choices: []types.Choice{{Message: types.Message{Content: "Answer."}}},
+		Images: []types.Image{
+			{ImageURL: srv.URL + "/chart.png", OriginURL: "https://example.com/report"},
+			{ImageURL: srv.URL + "/page"},
+			{ImageURL: srv.URL + "/huge.png"},
+		},
+		RelatedQuestions: []string{"What next?"},
+	}
+	content := s.formatResponse(resp)
+	id, err := cache.SaveResult(cfg.ResultsRootFolder, "q", "general", "sonar", content, nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	got, changed := s.localizeImages(context.Background(), cfg.ResultsRootFolder, id, content)
+	if !changed {
+		t.Fatal("Expected images to be localized")
+	}
+	want := "1. ![image 1](images/1.png) from https://example.com/report (original: " + srv.URL + "/chart.png)"
+	if !strings.Contains(got, want) {
+		t.Errorf("Local link missing %q:\n%s", want, got)
+	}
+	for _, remote := range []string{"](" + srv.URL + "/page)", "](" + srv.URL + "/huge.png)", "## Related Questions"} {
+		if !strings.Contains(got, remote) {
+			t.Errorf("Expected %q to be kept:\n%s", remote, got)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.ResultsRootFolder, id, "images", "1.png"))
+	if err != nil || !bytes.Equal(data, png) {
+		t.Errorf("Downloaded image mismatch: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ResultsRootFolder, id, "images", "3.png")); err == nil {
+		t.Error("Oversized image should not be saved")
+	}
+}
+
+func TestLocalizedImagesKeepOutlineOffsets(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(png)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.ResultsRootFolder = t.TempDir()
+	cfg.OutlineThreshold = 100
+	s := &Searcher{source: cfg, images: &imageDownloader{httpClient: srv.Client(), maxBytes: 1024}}
+
+	resp := &types.PerplexityResponse{
+		Choices:          []types.Choice{{Message: types.Message{Content: "Answer.\n\n## Details\n" + strings.Repeat("text ", 40)}}},
+		Images:           []types.Image{{ImageURL: srv.URL + "/a-long-remote-image-name.png"}},
+		RelatedQuestions: []string{"What next?"},
+	}
+	s.formatResponseWithCache(resp, &SearchParams{Query: "q", SearchType: "general"})
+
+	items, err := cache.ListPreviousQueries(cfg.ResultsRootFolder)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected one cached result, got %v, %v", items, err)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.ResultsRootFolder, items[0].UniqueID, "result.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := string(data)
+	if !strings.Contains(cached, "](images/1.png)") {
+		t.Fatalf("Expected the image localized:\n%s", cached)
+	}
+	checkOutlineOffsets(t, cached, "## Details", "## Images", "## Related Questions")
+}
@@ -0,0 +1,228 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// languageSampleBytes bounds how much of an answer is read to detect its language
+const languageSampleBytes = 2000
+
+// languageNames maps the detectable languages' codes to the names used in prompts
+var languageNames = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"de": "German",
+	"pt": "Portuguese",
+	"it": "Italian",
+	"nl": "Dutch",
+	"ru": "Russian",
+	"uk": "Ukrainian",
+	"el": "Greek",
+	"ar": "Arabic",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"th": "Thai",
+	"zh": "Chinese",
+	"ja": "Japanese",
+	"ko": "Korean",
+}
+
+// latinFunctionWords are frequent short words that tell Latin-script languages apart
+var latinFunctionWords = map[string]map[string]bool{
+	"en": wordSet("the and of to is are was were what how why which who in on for with that this it be by from does do"),
+	"es": wordSet("el la los las de del que y en es son por para con una un cómo qué cuál cuáles se lo su al"),
+	"fr": wordSet("le la les de des du que et en est sont pour avec une un dans qui quel quelle quels comment ce au aux"),
+	"de": wordSet("der die das und ist sind von zu mit den dem ein eine wie was welche nicht für auf im auch es"),
+	"pt": wordSet("o a os as de do da dos das que e em é são para com uma um como qual quais no na não se"),
+	"it": wordSet("il lo la gli le di del della che e è sono per con una un come quale quali nel non si"),
+	"nl": wordSet("de het een en van is zijn wat hoe welke met voor op in niet dat die er ook"),
+}
+
+// latinMarkers are letters that occur in one Latin-script language far more than the others
+var latinMarkers = map[string]string{
+	"es": "ñ¿¡",
+	"fr": "çèêëœ",
+	"de": "ßäöü",
+	"pt": "ãõç",
+	"it": "ìò",
+}
+
+var (
+	fencedBlockPattern = regexp.MustCompile("(?s)```.*?```")
+	bareURLPattern     = regexp.MustCompile(`https?://\S+`)
+	letterRunPattern   = regexp.MustCompile(`\p{L}+`)
+)
+
+// normalizeLanguage turns an answer_language value into a code and display name. Names and
+// codes of detectable languages map to their code; any other name is kept for the prompt
+// with an empty code, so it is requested but never checked.
+func normalizeLanguage(language string) (string, string) {
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return "", ""
+	}
+	lower := strings.ToLower(language)
+	if name, ok := languageNames[lower]; ok {
+		return lower, name
+	}
+	// Regional variants such as pt-BR are checked as their base language
+	if base, _, ok := strings.Cut(lower, "-"); ok && languageNames[base] != "" {
+		return base, fmt.Sprintf("%s (%s)", languageNames[base], language)
+	}
+	for code, name := range languageNames {
+		if strings.EqualFold(name, language) {
+			return code, name
+		}
+	}
+	return "", language
+}
+
+// detectLanguage guesses the language of text from its script, and for Latin script from
+// its function words and accented letters. It returns "" when the evidence is too thin.
+func detectLanguage(text string) string {
+	if len(text) > languageSampleBytes {
+		text = text[:languageSampleBytes]
+	}
+	text = bareURLPattern.ReplaceAllString(fencedBlockPattern.ReplaceAllString(text, " "), " ")
+
+	scripts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			scripts["ja"]++
+		case unicode.Is(unicode.Han, r):
+			scripts["zh"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				scripts["uk"]++
+			}
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		case unicode.Is(unicode.Latin, r):
+			scripts["latin"]++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Kana marks Japanese even though most of its characters are Han
+	if scripts["ja"] > 0 && scripts["ja"]+scripts["zh"] > letters/2 {
+		return "ja"
+	}
+	if scripts["uk"] > 0 && scripts["ru"] > letters/2 {
+		return "uk"
+	}
+	for _, code := range []string{"zh", "ko", "ru", "el", "ar", "he", "hi", "th"} {
+		if scripts[code] > letters/2 {
+			return code
+		}
+	}
+	if scripts["latin"] <= letters/2 {
+		return ""
+	}
+	return detectLatinLanguage(strings.ToLower(text))
+}
+
+// detectLatinLanguage scores Latin-script text against each language's function words and
+// marker letters, requiring a clear winner
+func detectLatinLanguage(text string) string {
+	scores := map[string]int{}
+	for _, word := range letterRunPattern.FindAllString(text, -1) {
+		for code, words := range latinFunctionWords {
+			if words[word] {
+				scores[code]++
+			}
+		}
+	}
+	for code, markers := range latinMarkers {
+		if strings.ContainsAny(text, markers) {
+			scores[code] += 2
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for code, score := range scores {
+		switch {
+		case score > bestScore:
+			best, secondScore, bestScore = code, bestScore, score
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+	if bestScore < 2 || bestScore == secondScore {
+		return ""
+	}
+	return best
+}
+
+// withAnswerLanguage returns a copy of req asking for the answer in language
+func withAnswerLanguage(req *types.PerplexityRequest, language string) *types.PerplexityRequest {
+	if language == "" {
+		return req
+	}
+
+	withLanguage := *req
+	withLanguage.Messages = make([]types.Message, len(req.Messages))
+	copy(withLanguage.Messages, req.Messages)
+	last := len(withLanguage.Messages) - 1
+	withLanguage.Messages[last].Content += fmt.Sprintf("\n\nWrite the answer in %s.", language)
+	return &withLanguage
+}
+
+// checkLanguage compares the answer's language with the requested answer_language, or else
+// the query's language, and on a mismatch notes it or re-requests the answer in the expected
+// language, depending on the configured mode
+func (s *Searcher) checkLanguage(ctx context.Context, req *types.PerplexityRequest, params *SearchParams, resp *types.PerplexityResponse) *types.PerplexityResponse {
+	mode := s.config().LanguageMismatch
+	if (mode != config.LanguageMismatchWarn && mode != config.LanguageMismatchRetry) || len(resp.Choices) == 0 {
+		return resp
+	}
+
+	expected, name := normalizeLanguage(params.AnswerLanguage)
+	if params.AnswerLanguage == "" {
+		expected = detectLanguage(params.Query)
+	}
+	got := detectLanguage(resp.Choices[0].Message.Content)
+	if expected == "" || got == "" || got == expected {
+		return resp
+	}
+
+	if mode == config.LanguageMismatchRetry {
+		if name == "" {
+			name = languageNames[expected]
+		}
+		retried, err := s.client.callAPI(ctx, withAnswerLanguage(req, name))
+		if err == nil && !isEmptyAnswer(retried) && detectLanguage(retried.Choices[0].Message.Content) == expected {
+			params.addNote(fmt.Sprintf("Language: first answer was in %s; answer below was re-requested in %s", languageNames[got], languageNames[expected]))
+			return retried
+		}
+	}
+
+	params.addNote(fmt.Sprintf("Language mismatch: answer appears to be in %s, but %s was expected", languageNames[got], languageNames[expected]))
+	return resp
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"What is the capital of France?", "en"},
+		{"¿Cuál es la capital de Francia?", "es"},
+		{"Quelle est la capitale de la France ?", "fr"},
+		{"Wie viele Einwohner hat die Stadt München?", "de"},
+		{"Qual é a população do Brasil?", "pt"},
+		{"東京の天気はどうですか", "ja"},
+		{"北京的天气怎么样", "zh"},
+		{"서울의 날씨는 어때요", "ko"},
+		{"Какая погода в Москве?", "ru"},
+		{"Яка погода в Києві?", "uk"},
+		{"Kubernetes CRD", ""},
+		{"```go\nfunc main() {}\n```\nhttps://example.com/the/of/and", ""},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		input, code, name string
+	}{
+		{"de", "de", "German"},
+		{"japanese", "ja", "Japanese"},
+		{"pt-BR", "pt", "Portuguese (pt-BR)"},
+		{"Swahili", "", "Swahili"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		code, name := normalizeLanguage(tt.input)
+		if code != tt.code || name != tt.name {
+			t.Errorf("normalizeLanguage(%q) = (%q, %q), want (%q, %q)", tt.input, code, name, tt.code, tt.name)
+		}
+	}
+}
+
+func TestLanguageMismatch(t *testing.T) {
+	english := "The capital of France is Paris, which is also the largest city in the country."
+	spanish := "La capital de Francia es París, que también es la ciudad más grande del país."
+
+	t.Run("warn", func(t *testing.T) {
+		calls := 0
+		s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+			calls++
+			return textResponse(types.ModelSonar, english)
+		})
+		s.config().LanguageMismatch = config.LanguageMismatchWarn
+
+		result, err := s.Search(context.Background(), &SearchParams{Query: "¿Cuál es la capital de Francia?"})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected 1 API call in warn mode, got %d", calls)
+		}
+		if !strings.Contains(result, "Language mismatch: answer appears to be in English, but Spanish was expected") {
+			t.Errorf("Expected mismatch note:\n%s", result)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var prompts []string
+		s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+			prompt := req.Messages[len(req.Messages)-1].Content
+			prompts = append(prompts, prompt)
+			if strings.Contains(prompt, "Write the answer in Spanish.") {
+				return textResponse(types.ModelSonar, spanish)
+			}
+			return textResponse(types.ModelSonar, english)
+		})
+		s.config().LanguageMismatch = config.LanguageMismatchRetry
+
+		result, err := s.Search(context.Background(), &SearchParams{Query: "¿Cuál es la capital de Francia?"})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(prompts) != 2 {
+			t.Fatalf("Expected a retry, got %d API calls", len(prompts))
+		}
+		if !strings.HasPrefix(result, spanish) {
+			t.Errorf("Expected the Spanish retry answer:\n%s", result)
+		}
+		if !strings.Contains(result, "Language: first answer was in English; answer below was re-requested in Spanish") {
+			t.Errorf("Expected retry note:\n%s", result)
+		}
+	})
+
+	t.Run("answer language", func(t *testing.T) {
+		var prompt string
+		s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+			prompt = req.Messages[len(req.Messages)-1].Content
+			return textResponse(types.ModelSonar, spanish)
+		})
+		s.config().LanguageMismatch = config.LanguageMismatchWarn
+
+		result, err := s.Search(context.Background(), &SearchParams{Query: "What is the capital of France?", AnswerLanguage: "es"})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if !strings.HasSuffix(prompt, "Write the answer in Spanish.") {
+			t.Errorf("Expected answer language instruction, got prompt %q", prompt)
+		}
+		if strings.Contains(result, "Language") {
+			t.Errorf("Expected no language note when the answer matches answer_language:\n%s", result)
+		}
+	})
+}
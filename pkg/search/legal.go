@@ -0,0 +1,133 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// legalDisclaimer opens every legal search result
+const legalDisclaimer = "> **Not legal advice.** This is an automated research summary of public legal sources. " +
+	"It may be incomplete, out of date, or wrong for your situation. Check the cited decisions and consult a qualified lawyer before relying on it.\n\n"
+
+// legalJurisdiction holds the case-law sources searched for a jurisdiction
+type legalJurisdiction struct {
+	name    string
+	domains []string
+}
+
+// legalJurisdictions maps lowercase jurisdiction names and codes to their sources
+var legalJurisdictions = map[string]legalJurisdiction{
+	"us": {
+		name:    "United States",
+		domains: []string{"courtlistener.com", "law.cornell.edu", "justia.com", "supremecourt.gov", "uscourts.gov", "govinfo.gov", "casetext.com"},
+	},
+	"uk": {
+		name:    "United Kingdom",
+		domains: []string{"bailii.org", "caselaw.nationalarchives.gov.uk", "legislation.gov.uk", "supremecourt.uk", "judiciary.uk"},
+	},
+	"eu": {
+		name:    "European Union",
+		domains: []string{"eur-lex.europa.eu", "curia.europa.eu", "hudoc.echr.coe.int"},
+	},
+	"ca": {
+		name:    "Canada",
+		domains: []string{"canlii.org", "scc-csc.ca", "laws-lois.justice.gc.ca"},
+	},
+	"au": {
+		name:    "Australia",
+		domains: []string{"austlii.edu.au", "hcourt.gov.au", "legislation.gov.au"},
+	},
+	"in": {
+		name:    "India",
+		domains: []string{"indiankanoon.org", "main.sci.gov.in", "indiacode.nic.in"},
+	},
+}
+
+// legalJurisdictionAliases maps common spellings to legalJurisdictions keys
+var legalJurisdictionAliases = map[string]string{
+	"usa": "us", "united states": "us", "us federal": "us", "federal": "us",
+	"gb": "uk", "united kingdom": "uk", "england": "uk", "england and wales": "uk",
+	"european union": "eu", "echr": "eu",
+	"canada": "ca", "australia": "au", "india": "in",
+}
+
+// defaultLegalDomains are searched when the jurisdiction is unknown or not given
+var defaultLegalDomains = []string{
+	"courtlistener.com", "law.cornell.edu", "justia.com", "supremecourt.gov", "casetext.com",
+	"bailii.org", "caselaw.nationalarchives.gov.uk", "eur-lex.europa.eu", "curia.europa.eu",
+	"canlii.org", "austlii.edu.au", "indiankanoon.org",
+}
+
+// lookupJurisdiction finds the sources for a jurisdiction, matching names and codes case-insensitively
+func lookupJurisdiction(jurisdiction string) (legalJurisdiction, bool) {
+	key := strings.ToLower(strings.TrimSpace(jurisdiction))
+	if alias, ok := legalJurisdictionAliases[key]; ok {
+		key = alias
+	}
+	j, ok := legalJurisdictions[key]
+	return j, ok
+}
+
+// decidedRange describes the decision date range for the prompt
+func decidedRange(start, end string) string {
+	switch {
+	case start != "" && end != "":
+		return fmt.Sprintf("Decided: %s to %s", start, end)
+	case start != "":
+		return fmt.Sprintf("Decided: on or after %s", start)
+	case end != "":
+		return fmt.Sprintf("Decided: on or before %s", end)
+	default:
+		return ""
+	}
+}
+
+// LegalSearch performs a case-law focused search. Results open with a disclaimer that
+// they are not legal advice.
+func (s *Searcher) LegalSearch(ctx context.Context, params *SearchParams) (string, error) {
+	// Use sonar-pro model for legal search if not specified
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	jurisdiction, known := lookupJurisdiction(params.Jurisdiction)
+	if len(params.SearchDomainFilter) == 0 {
+		if known {
+			params.SearchDomainFilter = jurisdiction.domains
+		} else {
+			params.SearchDomainFilter = defaultLegalDomains
+		}
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the legal-specific parameters
+	contextAdditions := []string{"Legal research"}
+	if params.Jurisdiction != "" {
+		name := params.Jurisdiction
+		if known {
+			name = jurisdiction.name
+		}
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Jurisdiction: %s", name))
+	}
+	if params.Court != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Court: %s", params.Court))
+	}
+	if decided := decidedRange(params.DateRangeStart, params.DateRangeEnd); decided != "" {
+		contextAdditions = append(contextAdditions, decided)
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\nCite each case by name, court, year, and reporter or neutral citation, and cite the source of the decision. "+
+		"Distinguish binding from persuasive authority, and say if a decision has been overturned or superseded.", strings.Join(contextAdditions, ", "), params.Query)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.saveWithCache(legalDisclaimer+s.formatResponse(resp)+formatNotes(params.notes), params), nil
+}
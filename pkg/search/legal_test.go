@@ -0,0 +1,62 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestLegalSearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Donoghue v Stevenson [1932] UKHL 100 established the neighbour principle [1].", "https://www.bailii.org/uk/cases/UKHL/1932/100.html")
+	})
+
+	params := &SearchParams{
+		Query:          "duty of care to third parties",
+		SearchType:     "legal",
+		Jurisdiction:   "England and Wales",
+		Court:          "House of Lords",
+		DateRangeStart: "1930-01-01",
+	}
+	result, err := s.LegalSearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("LegalSearch failed: %v", err)
+	}
+
+	if !strings.HasPrefix(result, legalDisclaimer) {
+		t.Errorf("Result should open with the disclaimer:\n%s", result)
+	}
+	if strings.Join(sent.SearchDomainFilter, ",") != strings.Join(legalJurisdictions["uk"].domains, ",") {
+		t.Errorf("Domain filter mismatch: got %v", sent.SearchDomainFilter)
+	}
+	want := "[Legal research, Jurisdiction: United Kingdom, Court: House of Lords, Decided: on or after 1930-01-01] duty of care to third parties"
+	if prompt := sent.Messages[0].Content; !strings.HasPrefix(prompt, want) {
+		t.Errorf("Prompt mismatch: got %q, want prefix %q", prompt, want)
+	}
+	if body := answerBody(result); strings.Contains(body, "Not legal advice") {
+		t.Errorf("answerBody should exclude the disclaimer: %q", body)
+	}
+}
+
+func TestLegalSearchUnknownJurisdiction(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Answer")
+	})
+
+	params := &SearchParams{Query: "tenant deposit rules", SearchType: "legal", Jurisdiction: "California"}
+	if _, err := s.LegalSearch(context.Background(), params); err != nil {
+		t.Fatalf("LegalSearch failed: %v", err)
+	}
+	if len(sent.SearchDomainFilter) != len(defaultLegalDomains) {
+		t.Errorf("Unknown jurisdictions should use the default domains: got %v", sent.SearchDomainFilter)
+	}
+	if !strings.Contains(sent.Messages[0].Content, "Jurisdiction: California") {
+		t.Errorf("Prompt should name the jurisdiction as given: %q", sent.Messages[0].Content)
+	}
+}
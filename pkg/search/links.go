@@ -0,0 +1,199 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+const (
+	// linkCheckConcurrency bounds the requests in flight while checking one result
+	linkCheckConcurrency = 5
+	// linkCheckTimeout bounds each link check
+	linkCheckTimeout = 15 * time.Second
+)
+
+// linkStatus is the outcome of checking one cited URL
+type linkStatus struct {
+	url      string
+	status   int
+	err      error
+	snapshot string
+}
+
+// dead reports whether the link is gone: unreachable, not found, or failing on the server.
+// Links that answer 401, 403, or 429 are reachable but refuse automated requests, so they count as alive.
+func (l linkStatus) dead() bool {
+	if l.err != nil {
+		return true
+	}
+	return l.status == http.StatusNotFound || l.status == http.StatusGone || l.status >= 500
+}
+
+// describe renders the status for the report
+func (l linkStatus) describe() string {
+	switch {
+	case l.err != nil:
+		return fmt.Sprintf("dead (%v)", l.err)
+	case l.dead():
+		return fmt.Sprintf("dead (HTTP %d)", l.status)
+	case l.status >= 400:
+		return fmt.Sprintf("reachable, refuses automated requests (HTTP %d)", l.status)
+	default:
+		return fmt.Sprintf("ok (HTTP %d)", l.status)
+	}
+}
+
+// checkLink requests url with HEAD, falling back to GET for servers that reject HEAD
+func (s *Searcher) checkLink(ctx context.Context, client *http.Client, link string) linkStatus {
+	ctx, cancel := context.WithTimeout(ctx, linkCheckTimeout)
+	defer cancel()
+
+	var status int
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, link, nil)
+		if err != nil {
+			return linkStatus{url: link, err: fmt.Errorf("invalid URL")}
+		}
+		if s.config().UserAgent != "" {
+			req.Header.Set("User-Agent", s.config().UserAgent)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return linkStatus{url: link, err: fmt.Errorf("unreachable")}
+		}
+		resp.Body.Close()
+		status = resp.StatusCode
+		if method == http.MethodHead && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented ||
+			status == http.StatusForbidden || status == http.StatusNotFound) {
+			continue
+		}
+		break
+	}
+	return linkStatus{url: link, status: status}
+}
+
+// CheckLinks checks that every source of a cached result is still reachable and caches a report
+// with the dead links annotated. With useArchive, dead links are replaced by Wayback Machine
+// snapshots where one exists.
+func (s *Searcher) CheckLinks(ctx context.Context, resultID string, useArchive bool) (string, error) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		return "", fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
+	}
+
+	content, err := s.readResult(resultID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get previous result: %w", err)
+	}
+	links := listedSources(content)
+	if len(links) == 0 {
+		return "", fmt.Errorf("result '%s' has no source URLs to check", resultID)
+	}
+
+	client := &http.Client{Transport: s.client.httpClient.Transport}
+	statuses := make([]linkStatus, len(links))
+	sem := make(chan struct{}, linkCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, link := range links {
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			statuses[i] = s.checkLink(ctx, client, link)
+			if useArchive && statuses[i].dead() {
+				if snapshot, err := s.wayback.snapshot(ctx, link); err == nil {
+					statuses[i].snapshot = snapshot
+				}
+			}
+		}(i, link)
+	}
+	wg.Wait()
+
+	params := &SearchParams{
+		Query:          fmt.Sprintf("Link check of result %s", resultID),
+		SearchType:     "link_check",
+		SourceResultID: resultID,
+		Summary:        linkCheckSummary(statuses),
+	}
+	return s.saveWithCache(formatLinkCheck(resultID, content, statuses), params), nil
+}
+
+// linkCheckSummary counts the dead links for the report and cache metadata
+func linkCheckSummary(statuses []linkStatus) string {
+	dead, archived := 0, 0
+	for _, l := range statuses {
+		if l.dead() {
+			dead++
+			if l.snapshot != "" {
+				archived++
+			}
+		}
+	}
+	summary := fmt.Sprintf("%d of %d links dead", dead, len(statuses))
+	if archived > 0 {
+		summary += fmt.Sprintf(", %d replaced with archived copies", archived)
+	}
+	return summary
+}
+
+// formatLinkCheck renders the status table followed by the result with dead links annotated
+func formatLinkCheck(resultID, content string, statuses []linkStatus) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Link Check of Result %s\n\n%s.\n\n", resultID, linkCheckSummary(statuses))
+	b.WriteString("| # | URL | Status | Archived copy |\n|---|---|---|---|\n")
+	for i, l := range statuses {
+		snapshot := "-"
+		if l.snapshot != "" {
+			snapshot = l.snapshot
+		}
+		fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", i+1, l.url, l.describe(), snapshot)
+	}
+
+	b.WriteString("\n## Annotated Result\n\n")
+	b.WriteString(annotateDeadLinks(content, statuses))
+	return b.String()
+}
+
+// annotateDeadLinks marks dead entries in the Source URLs list, pointing at archived copies where found
+func annotateDeadLinks(content string, statuses []linkStatus) string {
+	start := strings.Index(content, appendedSectionHeaders[0])
+	if start < 0 {
+		return content
+	}
+	start += len(appendedSectionHeaders[0])
+	end := len(content)
+	if next := strings.Index(content[start:], "\n## "); next >= 0 {
+		end = start + next
+	}
+
+	byURL := make(map[string]linkStatus, len(statuses))
+	for _, l := range statuses {
+		byURL[l.url] = l
+	}
+
+	lines := strings.Split(content[start:end], "\n")
+	for i, line := range lines {
+		m := sourceListItemPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		l, ok := byURL[m[1]]
+		if !ok || !l.dead() {
+			continue
+		}
+		if l.snapshot != "" {
+			lines[i] = strings.Replace(line, l.url, fmt.Sprintf("%s (archived copy; original %s is %s)", l.snapshot, l.url, l.describe()), 1)
+		} else {
+			lines[i] = line + " ⚠️ " + l.describe()
+		}
+	}
+	return content[:start] + strings.Join(lines, "\n") + content[end:]
+}
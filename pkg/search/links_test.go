@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestCheckLinks(t *testing.T) {
+	sites := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/nohead":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer sites.Close()
+
+	wayback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Query().Get("url"), "/gone") {
+			fmt.Fprint(w, `{"archived_snapshots":{"closest":{"available":true,"status":"200","url":"http://web.archive.org/web/2024/gone"}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"archived_snapshots":{}}`)
+	}))
+	defer wayback.Close()
+
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		t.Fatal("Unexpected API call")
+		return nil
+	})
+	s.config().ResultsRootFolder = t.TempDir()
+	s.wayback = &waybackClient{httpClient: wayback.Client(), availableURL: wayback.URL}
+
+	content := s.formatResponse(textResponse(types.ModelSonar, "Answer[1][2][3][4][5].",
+		sites.URL+"/ok", sites.URL+"/nohead", sites.URL+"/forbidden", sites.URL+"/gone", sites.URL+"/missing"))
+	id, err := cache.SaveResult(s.config().ResultsRootFolder, "q", "general", types.ModelSonar, content, nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	if _, err := s.CheckLinks(context.Background(), id, true); err != nil {
+		t.Fatalf("CheckLinks failed: %v", err)
+	}
+
+	var reportID string
+	queries, _ := cache.ListPreviousQueries(s.config().ResultsRootFolder)
+	for _, q := range queries {
+		if q.SearchType == "link_check" {
+			reportID = q.UniqueID
+		}
+	}
+	report, err := cache.GetPreviousResult(s.config().ResultsRootFolder, reportID)
+	if err != nil {
+		t.Fatalf("Expected link check report to be cached: %v", err)
+	}
+
+	expected := []string{
+		"2 of 5 links dead, 1 replaced with archived copies.",
+		"| 1 | " + sites.URL + "/ok | ok (HTTP 200) | - |",
+		"| 2 | " + sites.URL + "/nohead | ok (HTTP 200) | - |",
+		"| 3 | " + sites.URL + "/forbidden | reachable, refuses automated requests (HTTP 403) | - |",
+		"4. http://web.archive.org/web/2024/gone (archived copy; original " + sites.URL + "/gone is dead (HTTP 404))",
+		"5. " + sites.URL + "/missing ⚠️ dead (HTTP 404)",
+	}
+	for _, want := range expected {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// localNowMaxTokens keeps "right now" answers short and cheap unless the caller asks for more
+const localNowMaxTokens = 400
+
+// LocalNow answers "what's happening near X right now" questions at minimal cost: it always
+// uses sonar, searches the last hour around the location, and widens to the last day only
+// when the last hour has no sources.
+func (s *Searcher) LocalNow(ctx context.Context, params *SearchParams) (string, error) {
+	if strings.TrimSpace(params.Location) == "" {
+		return "", fmt.Errorf("location is required")
+	}
+	params.Model = types.ModelSonar
+	params.SearchRecencyFilter = "hour"
+	if params.MaxTokens == nil {
+		maxTokens := localNowMaxTokens
+		params.MaxTokens = &maxTokens
+	}
+
+	now := s.now(params)
+	resp, err := s.localNowRequest(ctx, params, now)
+	if err != nil {
+		return "", err
+	}
+	if citationCount(resp) == 0 {
+		params.SearchRecencyFilter = "day"
+		if widened, err := s.localNowRequest(ctx, params, now); err == nil && citationCount(widened) > 0 {
+			params.addNote("Recency widened: no sources from the last hour; answer uses sources from the last day")
+			resp = widened
+		} else {
+			params.SearchRecencyFilter = "hour"
+		}
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
+
+// localNowRequest builds and runs one local-now request at the params' current recency
+func (s *Searcher) localNowRequest(ctx context.Context, params *SearchParams, now time.Time) (*types.PerplexityResponse, error) {
+	req := s.buildRequest(params, types.ModelSonar)
+	req.Messages[0].Content = fmt.Sprintf("[Location: %s, Current time: %s] %s\n\nAnswer briefly with current conditions and events only, "+
+		"giving times in local time and saying how recent each piece of information is. Do not pad the answer with background.",
+		params.Location, now.UTC().Format("2006-01-02 15:04 UTC"), params.Query)
+	return s.execute(ctx, req, params)
+}
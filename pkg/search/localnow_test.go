@@ -0,0 +1,72 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestLocalNow(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Light rain, clearing by 6pm.", "https://weather.example.com/now")
+	})
+
+	params := &SearchParams{Query: "is it raining", SearchType: "local", Location: "Shoreditch, London", Model: types.ModelSonarPro}
+	result, err := s.LocalNow(context.Background(), params)
+	if err != nil {
+		t.Fatalf("LocalNow failed: %v", err)
+	}
+
+	if sent.Model != types.ModelSonar || sent.SearchRecencyFilter != "hour" {
+		t.Errorf("Request mismatch: model %q, recency %q", sent.Model, sent.SearchRecencyFilter)
+	}
+	if sent.MaxTokens != localNowMaxTokens {
+		t.Errorf("MaxTokens mismatch: got %v, want %d", sent.MaxTokens, localNowMaxTokens)
+	}
+	prompt := sent.Messages[0].Content
+	if !strings.Contains(prompt, "Location: Shoreditch, London") || !strings.Contains(prompt, "Current time:") {
+		t.Errorf("Prompt missing location or time: %s", prompt)
+	}
+	if strings.Contains(result, "Recency widened") {
+		t.Errorf("Unexpected widening note: %s", result)
+	}
+}
+
+func TestLocalNowWidensToDay(t *testing.T) {
+	var recencies []string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		recencies = append(recencies, req.SearchRecencyFilter)
+		if req.SearchRecencyFilter == "hour" {
+			return textResponse(req.Model, "Nothing reported in the last hour.")
+		}
+		return textResponse(req.Model, "Road closed for a marathon until 2pm.", "https://news.example.com/marathon")
+	})
+
+	params := &SearchParams{Query: "road closures", SearchType: "local", Location: "Brooklyn"}
+	result, err := s.LocalNow(context.Background(), params)
+	if err != nil {
+		t.Fatalf("LocalNow failed: %v", err)
+	}
+
+	if len(recencies) < 2 || recencies[len(recencies)-1] != "day" {
+		t.Errorf("Recency mismatch: got %v, want a final day search", recencies)
+	}
+	if !strings.Contains(result, "marathon") || !strings.Contains(result, "Recency widened") {
+		t.Errorf("Expected widened answer with note: %s", result)
+	}
+}
+
+func TestLocalNowRequiresLocation(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		t.Fatal("Unexpected API call")
+		return nil
+	})
+
+	if _, err := s.LocalNow(context.Background(), &SearchParams{Query: "weather"}); err == nil {
+		t.Error("Expected error for missing location")
+	}
+}
@@ -0,0 +1,256 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// todayPattern matches financial queries about the current session's prices
+var todayPattern = regexp.MustCompile(`(?i)\b(today|tonight|right now|currently|current (?:price|quote|value|level)|live|real[- ]?time|intraday|this (?:morning|afternoon))\b`)
+
+// exchange is a stock exchange's regular trading session and the holidays it closes for
+type exchange struct {
+	name     string
+	zone     string
+	open     time.Duration // Session open, as time since local midnight
+	close    time.Duration
+	holidays func(year int) map[string]string // Holiday names by date, for the dates observed that year
+}
+
+// exchangeSuffixes maps the ticker suffixes of common listings to their exchange; tickers
+// without one are taken as US listings
+var exchangeSuffixes = map[string]*exchange{
+	".L":  {name: "LSE", zone: "Europe/London", open: 8 * time.Hour, close: 16*time.Hour + 30*time.Minute, holidays: europeanHolidays},
+	".DE": {name: "Xetra", zone: "Europe/Berlin", open: 9 * time.Hour, close: 17*time.Hour + 30*time.Minute, holidays: europeanHolidays},
+	".PA": {name: "Euronext Paris", zone: "Europe/Paris", open: 9 * time.Hour, close: 17*time.Hour + 30*time.Minute, holidays: europeanHolidays},
+	".AS": {name: "Euronext Amsterdam", zone: "Europe/Amsterdam", open: 9 * time.Hour, close: 17*time.Hour + 30*time.Minute, holidays: europeanHolidays},
+	".T":  {name: "TSE", zone: "Asia/Tokyo", open: 9 * time.Hour, close: 15*time.Hour + 30*time.Minute, holidays: tokyoHolidays},
+	".HK": {name: "HKEX", zone: "Asia/Hong_Kong", open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour, holidays: hkexHolidays},
+	".TO": {name: "TSX", zone: "America/Toronto", open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour, holidays: tsxHolidays},
+}
+
+// usExchange is the regular session of the NYSE and Nasdaq
+var usExchange = &exchange{name: "NYSE/Nasdaq", zone: "America/New_York", open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour, holidays: usHolidays}
+
+// exchangeFor returns the exchange a ticker is listed on
+func exchangeFor(ticker string) *exchange {
+	if i := strings.LastIndex(ticker, "."); i > 0 {
+		if ex, ok := exchangeSuffixes[strings.ToUpper(ticker[i:])]; ok {
+			return ex
+		}
+	}
+	return usExchange
+}
+
+// marketStatus describes whether an exchange is trading at a moment
+type marketStatus struct {
+	open        bool
+	reason      string    // Why the market is closed: "weekend", a holiday name, "before the open" or "after the close"
+	lastSession time.Time // Date of the latest session with prices, in the exchange's zone
+}
+
+// status reports whether the exchange is trading at now and which session the latest prices
+// come from. Only regular sessions are known; early closes count as full days.
+func (ex *exchange) status(now time.Time) marketStatus {
+	if loc, err := time.LoadLocation(ex.zone); err == nil {
+		now = now.In(loc)
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	elapsed := now.Sub(day)
+
+	if reason := ex.closedReason(day); reason != "" {
+		return marketStatus{reason: reason, lastSession: ex.previousSession(day)}
+	}
+	switch {
+	case elapsed < ex.open:
+		return marketStatus{reason: "before the open", lastSession: ex.previousSession(day)}
+	case elapsed >= ex.close:
+		return marketStatus{reason: "after the close", lastSession: day}
+	default:
+		return marketStatus{open: true, lastSession: day}
+	}
+}
+
+// closedReason returns why the exchange does not trade on day, or "" on trading days
+func (ex *exchange) closedReason(day time.Time) string {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return "weekend"
+	}
+	return ex.holidays(day.Year())[day.Format(dateLayout)]
+}
+
+// previousSession returns the last trading day before day
+func (ex *exchange) previousSession(day time.Time) time.Time {
+	for d := day.AddDate(0, 0, -1); ; d = d.AddDate(0, 0, -1) {
+		if ex.closedReason(d) == "" {
+			return d
+		}
+	}
+}
+
+// usHolidays returns the NYSE holidays of a year, moved to the Friday before or Monday after
+// when they fall on a weekend. New Year's Day on a Saturday is not observed.
+func usHolidays(year int) map[string]string {
+	holidays := make(map[string]string)
+	observe := func(date time.Time, name string) {
+		switch date.Weekday() {
+		case time.Saturday:
+			if date.Month() == time.January && date.Day() == 1 {
+				return
+			}
+			date = date.AddDate(0, 0, -1)
+		case time.Sunday:
+			date = date.AddDate(0, 0, 1)
+		}
+		holidays[date.Format(dateLayout)] = name
+	}
+	observe(civilDate(year, time.January, 1), "New Year's Day")
+	holidays[nthWeekday(year, time.January, time.Monday, 3).Format(dateLayout)] = "Martin Luther King Jr. Day"
+	holidays[nthWeekday(year, time.February, time.Monday, 3).Format(dateLayout)] = "Washington's Birthday"
+	holidays[easter(year).AddDate(0, 0, -2).Format(dateLayout)] = "Good Friday"
+	holidays[nthWeekday(year, time.June, time.Monday, 1).AddDate(0, 0, -7).Format(dateLayout)] = "Memorial Day" // Last Monday of May
+	observe(civilDate(year, time.June, 19), "Juneteenth")
+	observe(civilDate(year, time.July, 4), "Independence Day")
+	holidays[nthWeekday(year, time.September, time.Monday, 1).Format(dateLayout)] = "Labor Day"
+	holidays[nthWeekday(year, time.November, time.Thursday, 4).Format(dateLayout)] = "Thanksgiving Day"
+	observe(civilDate(year, time.December, 25), "Christmas Day")
+	return holidays
+}
+
+// europeanHolidays returns the closures most European exchanges share: New Year's Day, Good
+// Friday, Easter Monday, Christmas and Boxing Day. Each exchange's other holidays are not
+// known.
+func europeanHolidays(year int) map[string]string {
+	e := easter(year)
+	return map[string]string{
+		civilDate(year, time.January, 1).Format(dateLayout):   "New Year's Day",
+		e.AddDate(0, 0, -2).Format(dateLayout):                "Good Friday",
+		e.AddDate(0, 0, 1).Format(dateLayout):                 "Easter Monday",
+		civilDate(year, time.December, 25).Format(dateLayout): "Christmas Day",
+		civilDate(year, time.December, 26).Format(dateLayout): "Boxing Day",
+	}
+}
+
+// tsxHolidays returns the Toronto Stock Exchange holidays of a year. Holidays on a weekend
+// move to the next weekday that is not already a holiday.
+func tsxHolidays(year int) map[string]string {
+	holidays := make(map[string]string)
+	observe := func(date time.Time, name string) {
+		for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday || holidays[date.Format(dateLayout)] != "" {
+			date = date.AddDate(0, 0, 1)
+		}
+		holidays[date.Format(dateLayout)] = name
+	}
+	victoriaDay := civilDate(year, time.May, 24) // The Monday before May 25
+	for victoriaDay.Weekday() != time.Monday {
+		victoriaDay = victoriaDay.AddDate(0, 0, -1)
+	}
+
+	observe(civilDate(year, time.January, 1), "New Year's Day")
+	holidays[nthWeekday(year, time.February, time.Monday, 3).Format(dateLayout)] = "Family Day"
+	holidays[easter(year).AddDate(0, 0, -2).Format(dateLayout)] = "Good Friday"
+	holidays[victoriaDay.Format(dateLayout)] = "Victoria Day"
+	observe(civilDate(year, time.July, 1), "Canada Day")
+	holidays[nthWeekday(year, time.August, time.Monday, 1).Format(dateLayout)] = "Civic Holiday"
+	holidays[nthWeekday(year, time.September, time.Monday, 1).Format(dateLayout)] = "Labour Day"
+	holidays[nthWeekday(year, time.October, time.Monday, 2).Format(dateLayout)] = "Thanksgiving Day"
+	observe(civilDate(year, time.December, 25), "Christmas Day")
+	observe(civilDate(year, time.December, 26), "Boxing Day")
+	return holidays
+}
+
+// hkexHolidays returns the Hong Kong Stock Exchange holidays of a year that follow the
+// Western calendar. A holiday on a Sunday moves to the next day that is not already a
+// holiday; one on a Saturday is not moved. Holidays set by the lunar calendar (Lunar New
+// Year, Ching Ming, Buddha's Birthday, Tuen Ng, the day after Mid-Autumn and Chung Yeung)
+// are not known.
+func hkexHolidays(year int) map[string]string {
+	holidays := make(map[string]string)
+	observe := func(date time.Time, name string) {
+		for date.Weekday() == time.Sunday || holidays[date.Format(dateLayout)] != "" {
+			date = date.AddDate(0, 0, 1)
+		}
+		holidays[date.Format(dateLayout)] = name
+	}
+	e := easter(year)
+
+	observe(civilDate(year, time.January, 1), "New Year's Day")
+	holidays[e.AddDate(0, 0, -2).Format(dateLayout)] = "Good Friday"
+	holidays[e.AddDate(0, 0, 1).Format(dateLayout)] = "Easter Monday"
+	observe(civilDate(year, time.May, 1), "Labour Day")
+	observe(civilDate(year, time.July, 1), "HKSAR Establishment Day")
+	observe(civilDate(year, time.October, 1), "National Day")
+	observe(civilDate(year, time.December, 26), "first weekday after Christmas Day") // Taken before Christmas so a Sunday Christmas moves past it
+	observe(civilDate(year, time.December, 25), "Christmas Day")
+	return holidays
+}
+
+// tokyoHolidays returns the Tokyo Stock Exchange's year-end closure; national holidays are
+// not known
+func tokyoHolidays(year int) map[string]string {
+	return map[string]string{
+		civilDate(year, time.January, 1).Format(dateLayout):   "New Year holiday",
+		civilDate(year, time.January, 2).Format(dateLayout):   "New Year holiday",
+		civilDate(year, time.January, 3).Format(dateLayout):   "New Year holiday",
+		civilDate(year, time.December, 31).Format(dateLayout): "New Year holiday",
+	}
+}
+
+func civilDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekday returns the nth given weekday of a month, counting from 1
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := civilDate(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// easter returns Western Easter Sunday of a year (anonymous Gregorian algorithm)
+func easter(year int) time.Time {
+	a, b, c := year%19, year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return civilDate(year, time.Month(month), day)
+}
+
+// checkMarketHours handles financial queries about today's prices asked while the market is
+// closed: it returns an instruction to report the latest session's prices instead of finding
+// no data, and moves a same-day date filter back to that session. It returns "" for other
+// queries, asset classes without sessions, and while the market is open.
+func checkMarketHours(params *SearchParams, now time.Time) string {
+	if params.AssetClass != "" && params.AssetClass != AssetEquity {
+		return ""
+	}
+	if !todayPattern.MatchString(params.Query) {
+		return ""
+	}
+	ex := exchangeFor(params.Ticker)
+	status := ex.status(now)
+	if status.open {
+		return ""
+	}
+
+	session := status.lastSession.Format(dateLayout)
+	note := fmt.Sprintf("Market hours: %s closed (%s); latest prices are from the %s session", ex.name, status.reason, session)
+	sameDay := params.SearchRecencyFilter == "" || params.SearchRecencyFilter == "hour" || params.SearchRecencyFilter == "day"
+	if sameDay && params.DateRangeStart == "" && params.DateRangeEnd == "" && status.reason != "after the close" {
+		params.SearchRecencyFilter = ""
+		params.DateRangeStart = session
+		note += fmt.Sprintf("; searched from %s", session)
+	}
+	params.addNote(note)
+
+	return fmt.Sprintf("The %s market is closed now (%s). Report prices from the latest session, %s, and say they are "+
+		"that session's figures, rather than saying no data is available for today.", ex.name, status.reason, session)
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUSHolidays(t *testing.T) {
+	holidays := usHolidays(2026)
+	for date, name := range map[string]string{
+		"2026-01-19": "Martin Luther King Jr. Day",
+		"2026-04-03": "Good Friday",
+		"2026-05-25": "Memorial Day",
+		"2026-07-03": "Independence Day", // July 4th is a Saturday
+		"2026-11-26": "Thanksgiving Day",
+	} {
+		if holidays[date] != name {
+			t.Errorf("usHolidays(2026)[%s] = %q, want %q", date, holidays[date], name)
+		}
+	}
+	if _, ok := usHolidays(2022)["2021-12-31"]; ok {
+		t.Error("New Year's Day on a Saturday should not be observed on the Friday before")
+	}
+}
+
+func TestTSXHolidays(t *testing.T) {
+	holidays := tsxHolidays(2023)
+	for date, name := range map[string]string{
+		"2023-02-20": "Family Day",
+		"2023-05-22": "Victoria Day",
+		"2023-07-03": "Canada Day", // July 1st is a Saturday
+		"2023-08-07": "Civic Holiday",
+		"2023-10-09": "Thanksgiving Day",
+	} {
+		if holidays[date] != name {
+			t.Errorf("tsxHolidays(2023)[%s] = %q, want %q", date, holidays[date], name)
+		}
+	}
+	// Christmas on a Saturday closes the Monday and Tuesday after
+	if holidays := tsxHolidays(2021); holidays["2021-12-27"] != "Christmas Day" || holidays["2021-12-28"] != "Boxing Day" {
+		t.Errorf("Unexpected Christmas closures in 2021: %v", holidays)
+	}
+}
+
+func TestHKEXHolidays(t *testing.T) {
+	holidays := hkexHolidays(2022)
+	for date, name := range map[string]string{
+		"2022-04-18": "Easter Monday",
+		"2022-05-02": "Labour Day", // May 1st is a Sunday
+		"2022-07-01": "HKSAR Establishment Day",
+		"2022-12-26": "first weekday after Christmas Day",
+		"2022-12-27": "Christmas Day", // December 25th is a Sunday
+	} {
+		if holidays[date] != name {
+			t.Errorf("hkexHolidays(2022)[%s] = %q, want %q", date, holidays[date], name)
+		}
+	}
+	if _, ok := hkexHolidays(2022)["2022-10-03"]; ok {
+		t.Error("National Day on a Saturday should not move to the Monday after")
+	}
+}
+
+func TestExchangeStatus(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		ticker      string
+		now         time.Time
+		open        bool
+		reason      string
+		lastSession string
+	}{
+		{"open", "AAPL", time.Date(2026, 10, 16, 11, 0, 0, 0, ny), true, "", "2026-10-16"},
+		{"weekend", "AAPL", time.Date(2026, 10, 18, 11, 0, 0, 0, ny), false, "weekend", "2026-10-16"},
+		{"holiday", "AAPL", time.Date(2026, 11, 26, 11, 0, 0, 0, ny), false, "Thanksgiving Day", "2026-11-25"},
+		{"before the open after a long weekend", "AAPL", time.Date(2026, 4, 6, 8, 0, 0, 0, ny), false, "before the open", "2026-04-02"},
+		{"after the close", "AAPL", time.Date(2026, 10, 16, 17, 0, 0, 0, ny), false, "after the close", "2026-10-16"},
+		// 11:00 in New York is 16:00 in London, still trading
+		{"London listing", "VOD.L", time.Date(2026, 10, 16, 11, 0, 0, 0, ny), true, "", "2026-10-16"},
+		// Thursday 11:30 in New York is just past midnight on Friday in Tokyo
+		{"Tokyo listing", "7203.T", time.Date(2026, 10, 15, 11, 30, 0, 0, ny), false, "before the open", "2026-10-15"},
+		{"Toronto listing on Victoria Day", "RY.TO", time.Date(2026, 5, 18, 11, 0, 0, 0, ny), false, "Victoria Day", "2026-05-15"},
+		// Wednesday 23:00 in New York is Thursday, October 1st, in Hong Kong
+		{"Hong Kong listing on National Day", "0700.HK", time.Date(2026, 9, 30, 23, 0, 0, 0, ny), false, "National Day", "2026-09-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := exchangeFor(tt.ticker).status(tt.now)
+			if got.open != tt.open || got.reason != tt.reason || got.lastSession.Format(dateLayout) != tt.lastSession {
+				t.Errorf("status() = %v, %q, %s; want %v, %q, %s", got.open, got.reason, got.lastSession.Format(dateLayout), tt.open, tt.reason, tt.lastSession)
+			}
+		})
+	}
+}
+
+func TestCheckMarketHours(t *testing.T) {
+	saturday := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+
+	params := &SearchParams{Query: "What is AAPL trading at today?", Ticker: "AAPL", SearchRecencyFilter: "day"}
+	instruction := checkMarketHours(params, saturday)
+	if !strings.Contains(instruction, "latest session, 2026-10-16") {
+		t.Errorf("Expected an instruction to use Friday's session, got %q", instruction)
+	}
+	if params.DateRangeStart != "2026-10-16" || params.SearchRecencyFilter != "" {
+		t.Errorf("Expected the day filter moved to the last session, got start %q, recency %q", params.DateRangeStart, params.SearchRecencyFilter)
+	}
+	if len(params.notes) != 1 || !strings.HasPrefix(params.notes[0], "Market hours: NYSE/Nasdaq closed (weekend)") {
+		t.Errorf("Unexpected notes: %v", params.notes)
+	}
+
+	params = &SearchParams{Query: "AAPL price today", Ticker: "AAPL", DateRangeStart: "2026-10-01"}
+	checkMarketHours(params, saturday)
+	if params.DateRangeStart != "2026-10-01" {
+		t.Errorf("Expected an explicit date range kept, got %q", params.DateRangeStart)
+	}
+
+	for _, params := range []*SearchParams{
+		{Query: "AAPL earnings history", Ticker: "AAPL"},
+		{Query: "BTC price today", Ticker: "BTC", AssetClass: AssetCrypto},
+	} {
+		if got := checkMarketHours(params, saturday); got != "" || len(params.notes) != 0 {
+			t.Errorf("Expected %q left alone, got %q, %v", params.Query, got, params.notes)
+		}
+	}
+}
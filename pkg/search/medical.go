@@ -0,0 +1,147 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// medicalDomains are searched when the caller gives no domain filter
+var medicalDomains = []string{
+	"pubmed.ncbi.nlm.nih.gov", "ncbi.nlm.nih.gov", "cochranelibrary.com", "nejm.org", "thelancet.com",
+	"jamanetwork.com", "bmj.com", "annals.org", "clinicaltrials.gov", "nice.org.uk", "who.int", "cdc.gov",
+}
+
+// studyType describes a study design filter and its level of evidence
+type studyType struct {
+	label  string // plural, used in the prompt
+	design string // singular, used in the evidence list
+	level  string
+	// keywords identify the design in a source's title or snippet
+	keywords []string
+}
+
+// studyTypes are the designs accepted by the study_type parameter, keyed by parameter value
+var studyTypes = map[string]studyType{
+	"meta-analysis":     {label: "meta-analyses", design: "meta-analysis", level: "Level 1", keywords: []string{"meta-analysis", "meta analysis", "metaanalysis"}},
+	"systematic-review": {label: "systematic reviews", design: "systematic review", level: "Level 1", keywords: []string{"systematic review", "cochrane review"}},
+	"rct":               {label: "randomized controlled trials", design: "randomized controlled trial", level: "Level 2", keywords: []string{"randomized", "randomised", "controlled trial", "rct"}},
+	"cohort":            {label: "cohort studies", design: "cohort study", level: "Level 3", keywords: []string{"cohort", "prospective study", "longitudinal"}},
+	"case-control":      {label: "case-control studies", design: "case-control study", level: "Level 3", keywords: []string{"case-control", "case control"}},
+	"cross-sectional":   {label: "cross-sectional studies", design: "cross-sectional study", level: "Level 4", keywords: []string{"cross-sectional", "cross sectional", "survey"}},
+	"case-report":       {label: "case reports and case series", design: "case report or series", level: "Level 4", keywords: []string{"case report", "case series"}},
+	"guideline":         {label: "clinical practice guidelines", design: "clinical guideline", level: "Guideline", keywords: []string{"guideline", "recommendation", "consensus statement"}},
+}
+
+// studyTypeOrder is the order designs are checked in, strongest evidence first, so a
+// "systematic review and meta-analysis of randomized trials" is classed as a meta-analysis
+var studyTypeOrder = []string{"meta-analysis", "systematic-review", "guideline", "rct", "case-control", "cohort", "cross-sectional", "case-report"}
+
+// IsValidStudyType reports whether studyType is a supported study_type value
+func IsValidStudyType(studyType string) bool {
+	_, ok := studyTypes[studyType]
+	return ok
+}
+
+// StudyTypeNames returns the accepted study_type values
+func StudyTypeNames() []string {
+	names := make([]string, 0, len(studyTypes))
+	for name := range studyTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// classifyEvidence guesses a source's study design from its URL, title, and snippet
+func classifyEvidence(rawURL, text string) (string, string) {
+	if domainMatches(hostOf(rawURL), "cochranelibrary.com") {
+		return studyTypes["systematic-review"].design, studyTypes["systematic-review"].level
+	}
+	if domainMatches(hostOf(rawURL), "clinicaltrials.gov") {
+		return "trial registration", "Not graded"
+	}
+
+	text = strings.ToLower(text)
+	for _, name := range studyTypeOrder {
+		for _, keyword := range studyTypes[name].keywords {
+			if strings.Contains(text, keyword) {
+				return studyTypes[name].design, studyTypes[name].level
+			}
+		}
+	}
+	return "", ""
+}
+
+// formatEvidenceLevels lists each source with the study design and level of evidence
+// inferred from its title and snippet. Sources that can't be classified are marked as such.
+func formatEvidenceLevels(resp *types.PerplexityResponse) string {
+	// Number sources as formatResponse does: deduped citations, or search results without them
+	_, urls, _ := normalizeCitations("", resp.Citations)
+	if len(urls) == 0 {
+		urls = sourceURLs(resp)
+	}
+	if len(urls) == 0 {
+		return ""
+	}
+
+	details := make(map[string]string, len(resp.SearchResults))
+	for _, result := range resp.SearchResults {
+		details[citationKey(result.URL)] = result.Title + " " + result.Snippet
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n## Evidence Levels\n")
+	for i, u := range urls {
+		design, level := classifyEvidence(u, u+" "+details[citationKey(u)])
+		if design == "" {
+			fmt.Fprintf(&b, "%d. %s: design not determined\n", i+1, u)
+			continue
+		}
+		fmt.Fprintf(&b, "%d. %s: %s (%s)\n", i+1, u, design, level)
+	}
+	b.WriteString("\nLevels follow the Oxford CEBM hierarchy: 1 systematic reviews and meta-analyses, 2 randomized trials, " +
+		"3 cohort and case-control studies, 4 cross-sectional studies and case reports. Designs are inferred from source titles and snippets.\n")
+	return b.String()
+}
+
+// MedicalSearch performs a clinical evidence search biased towards PubMed and major journals
+func (s *Searcher) MedicalSearch(ctx context.Context, params *SearchParams) (string, error) {
+	// Use sonar-pro model for medical search if not specified
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	if params.StudyType != "" && !IsValidStudyType(params.StudyType) {
+		return "", fmt.Errorf("invalid study_type '%s'. Use one of: %s", params.StudyType, strings.Join(StudyTypeNames(), ", "))
+	}
+	if len(params.SearchDomainFilter) == 0 {
+		params.SearchDomainFilter = medicalDomains
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the medical-specific parameters
+	contextAdditions := []string{"Clinical evidence search"}
+	if params.StudyType != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Study type: %s", studyTypes[params.StudyType].label))
+	}
+	if params.Population != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Population: %s", params.Population))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\nPrefer peer-reviewed clinical evidence. For each study cited, state its design, population, "+
+		"sample size, and main result, and give its level of evidence.", strings.Join(contextAdditions, ", "), params.Query)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	content := s.formatResponse(resp) + formatEvidenceLevels(resp)
+	return s.saveWithCache(content+formatNotes(params.notes), params), nil
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestClassifyEvidence(t *testing.T) {
+	tests := []struct {
+		url, text string
+		want      string
+	}{
+		{"https://www.cochranelibrary.com/cdsr/doi/10.1002/14651858.CD000001", "", "systematic review"},
+		{"https://pubmed.ncbi.nlm.nih.gov/1/", "Statins for primary prevention: a systematic review and meta-analysis of randomised trials", "meta-analysis"},
+		{"https://www.nejm.org/doi/1", "A Randomized Trial of Semaglutide in Obesity", "randomized controlled trial"},
+		{"https://pubmed.ncbi.nlm.nih.gov/2/", "Coffee intake and mortality in a prospective cohort", "cohort study"},
+		{"https://www.nice.org.uk/guidance/ng28", "Type 2 diabetes in adults: management guideline", "clinical guideline"},
+		{"https://example.com/news", "Doctors discuss new findings", ""},
+	}
+
+	for _, tt := range tests {
+		if got, _ := classifyEvidence(tt.url, tt.text); got != tt.want {
+			t.Errorf("classifyEvidence(%q) mismatch: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestMedicalSearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		resp := textResponse(req.Model, "Exercise reduces falls [1][2].",
+			"https://pubmed.ncbi.nlm.nih.gov/111/", "https://pubmed.ncbi.nlm.nih.gov/111/", "https://example.org/blog")
+		resp.SearchResults = []types.SearchResult{
+			{Title: "Exercise for preventing falls: a randomised controlled trial", URL: "https://pubmed.ncbi.nlm.nih.gov/111/"},
+		}
+		return resp
+	})
+
+	params := &SearchParams{Query: "exercise to prevent falls", SearchType: "medical", StudyType: "rct", Population: "adults over 65"}
+	result, err := s.MedicalSearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("MedicalSearch failed: %v", err)
+	}
+
+	if strings.Join(sent.SearchDomainFilter, ",") != strings.Join(medicalDomains, ",") {
+		t.Errorf("Domain filter mismatch: got %v", sent.SearchDomainFilter)
+	}
+	want := "[Clinical evidence search, Study type: randomized controlled trials, Population: adults over 65] exercise to prevent falls"
+	if prompt := sent.Messages[0].Content; !strings.HasPrefix(prompt, want) {
+		t.Errorf("Prompt mismatch: got %q, want prefix %q", prompt, want)
+	}
+	for _, line := range []string{
+		"1. https://pubmed.ncbi.nlm.nih.gov/111/: randomized controlled trial (Level 2)\n",
+		"2. https://example.org/blog: design not determined\n",
+	} {
+		if !strings.Contains(result, line) {
+			t.Errorf("Evidence list missing %q:\n%s", line, result)
+		}
+	}
+
+	if _, err := s.MedicalSearch(context.Background(), &SearchParams{Query: "q", StudyType: "anecdote"}); err == nil {
+		t.Errorf("Expected error for invalid study_type")
+	}
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prasanthmj/perplexity/pkg/notify"
+)
+
+const (
+	// notifyTimeout bounds how long a slow webhook can delay the tool response
+	notifyTimeout = 10 * time.Second
+	// maxSummaryLength caps the answer excerpt posted to the webhook, in runes
+	maxSummaryLength = 280
+)
+
+// notifyCompleted posts a summary of a cached result to the notification webhook when
+// its search type is one of PERPLEXITY_NOTIFY_SEARCH_TYPES. Failures are only logged.
+func (s *Searcher) notifyCompleted(uniqueID, content string, params *SearchParams) {
+	if s.notifier == nil || !notifiedType(s.config().NotifySearchTypes, params.SearchType) {
+		return
+	}
+
+	summary := params.Summary
+	if summary == "" {
+		summary = summarize(answerBody(content))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	err := s.notifier.Send(ctx, notify.Notification{
+		Title:      params.Query,
+		Summary:    summary,
+		ResultID:   uniqueID,
+		SearchType: params.SearchType,
+	})
+	if err != nil {
+		params.log().Warn("failed to send notification", "result_id", uniqueID, "error", err)
+	}
+}
+
+// notifiedType reports whether searchType is in the configured list; "all" matches every type
+func notifiedType(searchTypes []string, searchType string) bool {
+	for _, t := range searchTypes {
+		if strings.EqualFold(t, searchType) || strings.EqualFold(t, "all") {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize returns the first prose paragraph of an answer, shortened for a chat message
+func summarize(body string) string {
+	for _, paragraph := range strings.Split(body, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" || strings.HasPrefix(paragraph, "#") || strings.HasPrefix(paragraph, "|") || strings.HasPrefix(paragraph, "```") {
+			continue
+		}
+		summary := strings.Join(strings.Fields(citationMarkerPattern.ReplaceAllString(paragraph, "")), " ")
+		if utf8.RuneCountInString(summary) > maxSummaryLength {
+			summary = strings.TrimSpace(string([]rune(summary)[:maxSummaryLength-1])) + "…"
+		}
+		return summary
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/notify"
+)
+
+func TestSummarize(t *testing.T) {
+	body := "# Title\n\n| a | b |\n|---|---|\n\nParis is the capital[1] of\nFrance.\n\nSecond paragraph."
+	if got := summarize(body); got != "Paris is the capital of France." {
+		t.Errorf("summarize mismatch: got %q", got)
+	}
+
+	long := summarize(strings.Repeat("word ", 100))
+	if n := len([]rune(long)); n > maxSummaryLength || !strings.HasSuffix(long, "…") {
+		t.Errorf("Long summary not truncated: %d runes, %q", n, long)
+	}
+}
+
+func TestNotifyCompleted(t *testing.T) {
+	var messages []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		messages = append(messages, payload["text"])
+	}))
+	defer webhook.Close()
+
+	s := newTestSearcher(t, nil)
+	s.config().ResultsRootFolder = t.TempDir()
+	s.config().NotifySearchTypes = []string{"verification"}
+	s.notifier = notify.NewWebhook(webhook.URL, webhook.Client())
+
+	s.saveWithCache("Paris is the capital of France.", &SearchParams{Query: "paris", SearchType: "general"})
+	if len(messages) != 0 {
+		t.Fatalf("Expected no notification for general search, got %v", messages)
+	}
+
+	result := s.saveWithCache(verifyFixture, &SearchParams{
+		Query:      "Verification of result ABCDE12345",
+		SearchType: "verification",
+		Summary:    "2 claims checked: 1 supported, 1 contradicted, 0 unclear",
+	})
+	if len(messages) != 1 {
+		t.Fatalf("Notification count mismatch: got %d, want 1", len(messages))
+	}
+
+	var artifact map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &artifact); err != nil {
+		t.Fatalf("Failed to parse artifact JSON: %v", err)
+	}
+	expected := []string{"*Verification of result ABCDE12345* (verification)", "2 claims checked", artifact["unique_id"].(string)}
+	for _, want := range expected {
+		if !strings.Contains(messages[0], want) {
+			t.Errorf("Notification missing %q:\n%s", want, messages[0])
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const (
+	factsHeader   = "## Established Facts"
+	opinionHeader = "## Analysis and Opinion"
+)
+
+// separateOpinions reports whether a call's answer is split into facts and opinion
+func (s *Searcher) separateOpinions(params *SearchParams) bool {
+	if params.SeparateOpinions != nil {
+		return *params.SeparateOpinions
+	}
+	return s.config().SeparateOpinions
+}
+
+// withFactsSeparated has a cheap model pass rework the answer into an Established Facts
+// section, holding only statements backed by the answer's cited sources, and an Analysis and
+// Opinion section for everything else. The answer is kept as it was when the pass fails or
+// its reply cites a source the answer does not.
+func (s *Searcher) withFactsSeparated(ctx context.Context, content string) string {
+	body := answerBody(content)
+	start := strings.Index(content, body)
+	if strings.TrimSpace(body) == "" || start < 0 {
+		return content
+	}
+
+	separated, err := s.separateFacts(ctx, body)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to separate facts from opinion, keeping the answer as is", "error", err)
+		return content
+	}
+	return content[:start] + separated + content[start+len(body):]
+}
+
+// separateFacts asks the model to sort the statements of an answer into facts and opinion
+func (s *Searcher) separateFacts(ctx context.Context, body string) (string, error) {
+	prompt := "Reorganize the answer below into exactly two sections, headed \"" + factsHeader + "\" and \"" +
+		opinionHeader + "\". Under the first, put only statements supported by a cited source, keeping their " +
+		"[n] citation markers exactly as written. Under the second, put interpretation, predictions, " +
+		"recommendations, value judgements, and any statement without a citation. Keep the wording; do not add " +
+		"information, sources, or commentary. If a section would be empty, write \"None.\" under it.\n\n" + body
+	req := &types.PerplexityRequest{
+		Model:       types.ModelSonar,
+		Messages:    []types.Message{{Role: "user", Content: prompt}},
+		MaxTokens:   2 * s.config().MaxTokens,
+		Temperature: 0,
+	}
+
+	// Reworking is background work relative to the interactive search it belongs to
+	resp, err := s.callAnonymized(ratelimit.WithPriority(ctx, ratelimit.PriorityBatch), req)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from separation call")
+	}
+	reply, _ := splitReasoning(resp.Choices[0].Message.Content)
+	return checkSeparation(reply, body)
+}
+
+// checkSeparation returns the facts and opinion sections of a reply, provided it has both
+// and cites no source missing from the original answer
+func checkSeparation(reply, body string) (string, error) {
+	facts := strings.Index(reply, factsHeader)
+	opinion := strings.Index(reply, opinionHeader)
+	if facts < 0 || opinion < facts {
+		return "", fmt.Errorf("reply lacks the %q and %q sections", factsHeader, opinionHeader)
+	}
+
+	cited := make(map[string]bool)
+	for _, marker := range citationMarkerPattern.FindAllString(body, -1) {
+		cited[marker] = true
+	}
+	for _, marker := range citationMarkerPattern.FindAllString(reply, -1) {
+		if !cited[marker] {
+			return "", fmt.Errorf("reply cites %s, which the answer does not", marker)
+		}
+	}
+	return strings.TrimSpace(reply[facts:]), nil
+}
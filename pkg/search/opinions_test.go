@@ -0,0 +1,49 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const opinionFixture = "Rust 1.0 shipped in May 2015 [1]. It will likely overtake C++ for new systems code.\n\n" +
+	"## Source URLs\n1. https://blog.rust-lang.org\n\n\n## Search Metadata\n- Model: sonar\n"
+
+func TestWithFactsSeparated(t *testing.T) {
+	reply := "## Established Facts\n- Rust 1.0 shipped in May 2015 [1].\n\n## Analysis and Opinion\n- It will likely overtake C++ for new systems code."
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		if !strings.Contains(req.Messages[0].Content, "Rust 1.0 shipped") || strings.Contains(req.Messages[0].Content, "Source URLs") {
+			t.Errorf("Expected only the answer body in the prompt, got:\n%s", req.Messages[0].Content)
+		}
+		return textResponse(req.Model, "Here you go:\n\n"+reply)
+	})
+
+	got := s.withFactsSeparated(context.Background(), opinionFixture)
+	want := reply + "\n\n## Source URLs\n1. https://blog.rust-lang.org\n\n\n## Search Metadata\n- Model: sonar\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCheckSeparation(t *testing.T) {
+	body := "Fact [1]. Opinion."
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr bool
+	}{
+		{"both sections", "## Established Facts\nFact [1].\n\n## Analysis and Opinion\nOpinion.", false},
+		{"missing opinion", "## Established Facts\nFact [1].", true},
+		{"sections reversed", "## Analysis and Opinion\nOpinion.\n\n## Established Facts\nFact [1].", true},
+		{"invented source", "## Established Facts\nFact [1]. Other [2].\n\n## Analysis and Opinion\nNone.", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := checkSeparation(tt.reply, body); (err != nil) != tt.wantErr {
+				t.Errorf("checkSeparation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
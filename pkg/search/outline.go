@@ -0,0 +1,95 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	outlineHeader    = "## Outline\n"
+	outlineSeparator = "\n---\n\n"
+)
+
+// outlineEntry is a markdown heading and its byte offset within the content
+type outlineEntry struct {
+	level  int
+	title  string
+	offset int
+}
+
+// withOutline prepends a table of contents to content longer than threshold bytes.
+// Offsets refer to the returned string, so they can be passed to chunked retrieval as-is.
+func withOutline(content string, threshold int) string {
+	if threshold <= 0 || len(content) <= threshold {
+		return content
+	}
+
+	entries := outlineEntries(content)
+	if len(entries) < 2 {
+		return content
+	}
+
+	// The outline shifts every offset by its own length; repeat until its length is stable
+	shift := 0
+	for {
+		outline := formatOutline(entries, len(content), shift)
+		if len(outline) == shift {
+			return outline + content
+		}
+		shift = len(outline)
+	}
+}
+
+// withoutOutline removes the outline withOutline prepended, if there is one
+func withoutOutline(content string) string {
+	if !strings.HasPrefix(content, outlineHeader) {
+		return content
+	}
+	if i := strings.Index(content, outlineSeparator); i >= 0 {
+		return content[i+len(outlineSeparator):]
+	}
+	return content
+}
+
+// outlineEntries finds the markdown headings in content, skipping fenced code blocks
+func outlineEntries(content string) []outlineEntry {
+	var entries []outlineEntry
+	inFence := false
+	offset := 0
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			inFence = !inFence
+		case !inFence && strings.HasPrefix(line, "#"):
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			title := strings.TrimSpace(line[level:])
+			if level <= 6 && title != "" && strings.HasPrefix(line[level:], " ") {
+				entries = append(entries, outlineEntry{level: level, title: title, offset: offset})
+			}
+		}
+		offset += len(line)
+	}
+	return entries
+}
+
+// formatOutline renders the outline with every offset moved forward by shift
+func formatOutline(entries []outlineEntry, contentLength, shift int) string {
+	minLevel := entries[0].level
+	for _, entry := range entries {
+		if entry.level < minLevel {
+			minLevel = entry.level
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(outlineHeader)
+	fmt.Fprintf(&b, "This result is %d bytes. Pass a section's offset to `get_previous_result` to read from there.\n\n", contentLength+shift)
+	for _, entry := range entries {
+		indent := strings.Repeat("  ", entry.level-minLevel)
+		fmt.Fprintf(&b, "%s- %s (offset %d)\n", indent, entry.title, entry.offset+shift)
+	}
+	b.WriteString(outlineSeparator)
+	return b.String()
+}
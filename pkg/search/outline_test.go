@@ -0,0 +1,63 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWithOutline(t *testing.T) {
+	content := "Intro text\n\n## Background\n" + strings.Repeat("a", 200) +
+		"\n\n```\n# not a heading\n```\n\n### Details\nMore text\n\n## Source URLs\n1. https://example.com\n"
+
+	if got := withOutline(content, 0); got != content {
+		t.Error("Outline should be disabled with a zero threshold")
+	}
+	if got := withOutline(content, len(content)); got != content {
+		t.Error("Outline should not be added below the threshold")
+	}
+
+	got := withOutline(content, 100)
+	if !strings.HasPrefix(got, "## Outline\n") {
+		t.Fatalf("Expected outline prefix, got:\n%s", got)
+	}
+	if strings.Contains(got[:strings.Index(got, "---")], "not a heading") {
+		t.Error("Headings inside code fences should be skipped")
+	}
+	if !strings.Contains(got, "  - Details (offset ") {
+		t.Errorf("Expected nested Details entry, got:\n%s", got)
+	}
+
+	checkOutlineOffsets(t, got, "## Background", "### Details", "## Source URLs")
+
+	if stripped := withoutOutline(got); stripped != content {
+		t.Errorf("Expected withoutOutline to restore the content, got:\n%s", stripped)
+	}
+	if stripped := withoutOutline(content); stripped != content {
+		t.Error("Content without an outline should be unchanged")
+	}
+}
+
+// checkOutlineOffsets checks that the outline of content points each heading at its offset
+func checkOutlineOffsets(t *testing.T, content string, headings ...string) {
+	t.Helper()
+	for _, title := range headings {
+		entry := strings.TrimLeft(title, "# ")
+		offset := -1
+		for _, line := range strings.Split(content, "\n") {
+			if strings.HasSuffix(strings.TrimSpace(line), ")") && strings.Contains(line, "- "+entry+" (offset ") {
+				fmt.Sscanf(line[strings.LastIndex(line, "offset ")+len("offset "):], "%d", &offset)
+			}
+		}
+		if offset < 0 || offset >= len(content) || !strings.HasPrefix(content[offset:], title) {
+			t.Errorf("Offset for %q does not point at the heading (offset %d)", title, offset)
+		}
+	}
+}
+
+func TestWithOutlineNeedsTwoHeadings(t *testing.T) {
+	content := "## Only\n" + strings.Repeat("a", 200)
+	if got := withOutline(content, 10); got != content {
+		t.Error("Outline should not be added for a single heading")
+	}
+}
@@ -0,0 +1,331 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const (
+	crossrefURL = "https://api.crossref.org/works/"
+	arxivURL    = "https://export.arxiv.org/api/query"
+
+	// maxPapers caps the identifiers enriched per result
+	maxPapers = 10
+	// maxAbstractLength keeps abstracts to a short paragraph
+	maxAbstractLength = 600
+	// maxListedAuthors is the number of authors shown before "et al."
+	maxListedAuthors = 5
+)
+
+var (
+	doiPattern        = regexp.MustCompile(`\b10\.\d{4,9}/[-._;()/:A-Za-z0-9]+`)
+	arxivURLPattern   = regexp.MustCompile(`(?i)arxiv\.org/(?:abs|pdf)/(\d{4}\.\d{4,5})`)
+	arxivIDPattern    = regexp.MustCompile(`(?i)\barxiv:\s*(\d{4}\.\d{4,5})`)
+	arxivDOIPattern   = regexp.MustCompile(`(?i)^10\.48550/arxiv\.(\d{4}\.\d{4,5})`)
+	markupPattern     = regexp.MustCompile(`<[^>]+>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// paperRef identifies a paper by DOI or arXiv ID
+type paperRef struct {
+	kind string // "doi" or "arxiv"
+	id   string
+}
+
+// link returns the canonical URL for the identifier
+func (r paperRef) link() string {
+	if r.kind == "arxiv" {
+		return "https://arxiv.org/abs/" + r.id
+	}
+	return "https://doi.org/" + r.id
+}
+
+// paper holds the metadata fetched for a reference; err is set when the lookup failed
+type paper struct {
+	ref      paperRef
+	title    string
+	authors  []string
+	year     int
+	venue    string
+	abstract string
+	err      error
+}
+
+// paperClient fetches paper metadata from Crossref and arXiv
+type paperClient struct {
+	httpClient  *http.Client
+	userAgent   string
+	crossrefURL string
+	arxivURL    string
+}
+
+// detectPapers finds DOIs and arXiv IDs in the citations, search results, and answer text,
+// in that order, without duplicates
+func detectPapers(resp *types.PerplexityResponse) []paperRef {
+	var texts []string
+	texts = append(texts, resp.Citations...)
+	for _, result := range resp.SearchResults {
+		texts = append(texts, result.URL)
+	}
+	if len(resp.Choices) > 0 {
+		texts = append(texts, resp.Choices[0].Message.Content)
+	}
+
+	seen := make(map[string]bool)
+	var refs []paperRef
+	add := func(ref paperRef) {
+		key := ref.kind + ":" + strings.ToLower(ref.id)
+		if !seen[key] {
+			seen[key] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	for _, text := range texts {
+		for _, match := range arxivURLPattern.FindAllStringSubmatch(text, -1) {
+			add(paperRef{kind: "arxiv", id: match[1]})
+		}
+		for _, match := range arxivIDPattern.FindAllStringSubmatch(text, -1) {
+			add(paperRef{kind: "arxiv", id: match[1]})
+		}
+		for _, doi := range doiPattern.FindAllString(text, -1) {
+			doi = strings.TrimRight(doi, ".,;:)")
+			if match := arxivDOIPattern.FindStringSubmatch(doi); match != nil {
+				add(paperRef{kind: "arxiv", id: match[1]})
+				continue
+			}
+			add(paperRef{kind: "doi", id: doi})
+		}
+	}
+	return refs
+}
+
+// enrich fetches metadata for up to maxPapers references in parallel
+func (c *paperClient) enrich(ctx context.Context, refs []paperRef) []paper {
+	if len(refs) > maxPapers {
+		refs = refs[:maxPapers]
+	}
+
+	papers := make([]paper, len(refs))
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		go func(i int, ref paperRef) {
+			defer wg.Done()
+			if ref.kind == "arxiv" {
+				papers[i] = c.fetchArxiv(ctx, ref)
+			} else {
+				papers[i] = c.fetchCrossref(ctx, ref)
+			}
+		}(i, ref)
+	}
+	wg.Wait()
+	return papers
+}
+
+// get performs a GET request and returns the body of a 200 response
+func (c *paperClient) get(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// fetchCrossref looks up a DOI in the Crossref works API
+func (c *paperClient) fetchCrossref(ctx context.Context, ref paperRef) paper {
+	p := paper{ref: ref}
+	body, err := c.get(ctx, c.crossrefURL+url.PathEscape(ref.id))
+	if err != nil {
+		p.err = err
+		return p
+	}
+
+	var work struct {
+		Message struct {
+			Title  []string `json:"title"`
+			Author []struct {
+				Given  string `json:"given"`
+				Family string `json:"family"`
+				Name   string `json:"name"`
+			} `json:"author"`
+			Issued struct {
+				DateParts [][]int `json:"date-parts"`
+			} `json:"issued"`
+			ContainerTitle []string `json:"container-title"`
+			Abstract       string   `json:"abstract"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &work); err != nil {
+		p.err = fmt.Errorf("failed to parse Crossref response: %w", err)
+		return p
+	}
+
+	msg := work.Message
+	if len(msg.Title) > 0 {
+		p.title = cleanText(msg.Title[0])
+	}
+	for _, author := range msg.Author {
+		name := strings.TrimSpace(author.Given + " " + author.Family)
+		if name == "" {
+			name = author.Name
+		}
+		if name != "" {
+			p.authors = append(p.authors, name)
+		}
+	}
+	if len(msg.Issued.DateParts) > 0 && len(msg.Issued.DateParts[0]) > 0 {
+		p.year = msg.Issued.DateParts[0][0]
+	}
+	if len(msg.ContainerTitle) > 0 {
+		p.venue = cleanText(msg.ContainerTitle[0])
+	}
+	p.abstract = cleanText(msg.Abstract)
+	return p
+}
+
+// fetchArxiv looks up an arXiv ID in the arXiv Atom API
+func (c *paperClient) fetchArxiv(ctx context.Context, ref paperRef) paper {
+	p := paper{ref: ref, venue: "arXiv"}
+	body, err := c.get(ctx, c.arxivURL+"?id_list="+url.QueryEscape(ref.id))
+	if err != nil {
+		p.err = err
+		return p
+	}
+
+	var feed struct {
+		Entries []struct {
+			Title     string `xml:"title"`
+			Summary   string `xml:"summary"`
+			Published string `xml:"published"`
+			Authors   []struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		p.err = fmt.Errorf("failed to parse arXiv response: %w", err)
+		return p
+	}
+	// Unknown IDs come back as an entry titled "Error"
+	if len(feed.Entries) == 0 || feed.Entries[0].Title == "Error" {
+		p.err = fmt.Errorf("arXiv has no entry for %s", ref.id)
+		return p
+	}
+
+	entry := feed.Entries[0]
+	p.title = cleanText(entry.Title)
+	for _, author := range entry.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			p.authors = append(p.authors, name)
+		}
+	}
+	if published, err := time.Parse(time.RFC3339, strings.TrimSpace(entry.Published)); err == nil {
+		p.year = published.Year()
+	}
+	p.abstract = cleanText(entry.Summary)
+	return p
+}
+
+// cleanText strips markup (such as Crossref's JATS tags) and collapses whitespace
+func cleanText(text string) string {
+	text = html.UnescapeString(markupPattern.ReplaceAllString(text, " "))
+	text = strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+	return strings.TrimPrefix(text, "Abstract ")
+}
+
+// truncateWords shortens text to at most limit bytes, cutting at a word boundary
+func truncateWords(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	cut := strings.LastIndex(text[:limit], " ")
+	if cut <= 0 {
+		cut = limit
+	}
+	return strings.TrimRight(text[:cut], " ,.;:") + "…"
+}
+
+// formatPapers renders the paper list appended to academic results
+func formatPapers(papers []paper) string {
+	if len(papers) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n## Papers\n")
+	for i, p := range papers {
+		label := "DOI"
+		if p.ref.kind == "arxiv" {
+			label = "arXiv"
+		}
+		if p.err != nil || p.title == "" {
+			fmt.Fprintf(&b, "\n%d. %s %s (metadata unavailable)\n", i+1, label, p.ref.id)
+			fmt.Fprintf(&b, "   Link: %s\n", p.ref.link())
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n%d. **%s**", i+1, p.title)
+		if p.year > 0 {
+			fmt.Fprintf(&b, " (%d)", p.year)
+		}
+		b.WriteString("\n")
+		if len(p.authors) > 0 {
+			authors := p.authors
+			suffix := ""
+			if len(authors) > maxListedAuthors {
+				authors, suffix = authors[:maxListedAuthors], ", et al."
+			}
+			fmt.Fprintf(&b, "   Authors: %s%s\n", strings.Join(authors, ", "), suffix)
+		}
+		if p.venue != "" {
+			fmt.Fprintf(&b, "   Venue: %s\n", p.venue)
+		}
+		fmt.Fprintf(&b, "   %s: %s (%s)\n", label, p.ref.id, p.ref.link())
+		if p.abstract != "" {
+			fmt.Fprintf(&b, "   Abstract: %s\n", truncateWords(p.abstract, maxAbstractLength))
+		}
+	}
+	return b.String()
+}
+
+// paperSection detects papers cited in an academic response and renders their metadata
+func (s *Searcher) paperSection(ctx context.Context, resp *types.PerplexityResponse) string {
+	if s.papers == nil {
+		return ""
+	}
+	refs := detectPapers(resp)
+	if len(refs) == 0 {
+		return ""
+	}
+	return formatPapers(s.papers.enrich(ctx, refs))
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestDetectPapers(t *testing.T) {
+	resp := textResponse(types.ModelSonarPro,
+		"Transformers were introduced in arXiv:1706.03762 and later surveyed (doi:10.1145/3505244).",
+		"https://arxiv.org/abs/1706.03762v7",
+		"https://doi.org/10.1038/s41586-021-03819-2",
+		"https://doi.org/10.48550/arXiv.2303.08774",
+	)
+
+	var got []string
+	for _, ref := range detectPapers(resp) {
+		got = append(got, ref.kind+":"+ref.id)
+	}
+	want := []string{"arxiv:1706.03762", "doi:10.1038/s41586-021-03819-2", "arxiv:2303.08774", "doi:10.1145/3505244"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Detected papers mismatch: got %v, want %v", got, want)
+	}
+}
+
+func TestAcademicSearchPaperMetadata(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/works/10.1038"):
+			w.Write([]byte(`{"message": {
+				"title": ["Highly accurate protein structure prediction with AlphaFold"],
+				"author": [{"given": "John", "family": "Jumper"}, {"given": "Richard", "family": "Evans"}],
+				"issued": {"date-parts": [[2021, 7, 15]]},
+				"container-title": ["Nature"],
+				"abstract": "<jats:title>Abstract</jats:title><jats:p>Proteins are essential to life.</jats:p>"
+			}}`))
+		case r.URL.Path == "/arxiv" && r.URL.Query().Get("id_list") == "1706.03762":
+			w.Write([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><entry>
+				<title>Attention Is All
+				You Need</title>
+				<summary>The dominant sequence transduction models...</summary>
+				<published>2017-06-12T17:57:34Z</published>
+				<author><name>Ashish Vaswani</name></author>
+			</entry></feed>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		return textResponse(req.Model, "AlphaFold [1] builds on attention [2].",
+			"https://doi.org/10.1038/s41586-021-03819-2", "https://arxiv.org/abs/1706.03762", "https://doi.org/10.9999/missing")
+	})
+	s.papers = &paperClient{httpClient: srv.Client(), crossrefURL: srv.URL + "/works/", arxivURL: srv.URL + "/arxiv"}
+
+	result, err := s.AcademicSearch(context.Background(), &SearchParams{Query: "protein structure prediction", SearchType: "academic"})
+	if err != nil {
+		t.Fatalf("AcademicSearch failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"\n\n## Papers\n",
+		"1. **Highly accurate protein structure prediction with AlphaFold** (2021)\n   Authors: John Jumper, Richard Evans\n   Venue: Nature\n",
+		"Abstract: Proteins are essential to life.",
+		"2. **Attention Is All You Need** (2017)\n   Authors: Ashish Vaswani\n   Venue: arXiv\n   arXiv: 1706.03762 (https://arxiv.org/abs/1706.03762)",
+		"3. DOI 10.9999/missing (metadata unavailable)\n   Link: https://doi.org/10.9999/missing",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(answerBody(result), "## Papers") {
+		t.Errorf("answerBody should exclude the paper list")
+	}
+}
+
+func TestTruncateWords(t *testing.T) {
+	if got := truncateWords("one two three", 9); got != "one two…" {
+		t.Errorf("truncateWords mismatch: got %q, want %q", got, "one two…")
+	}
+	if got := truncateWords("short", 9); got != "short" {
+		t.Errorf("truncateWords mismatch: got %q, want %q", got, "short")
+	}
+}
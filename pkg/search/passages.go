@@ -0,0 +1,203 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// chunkTargetTokens is the size passages are grown to when splitting documents
+	chunkTargetTokens = 300
+	// omittedMarker stands in for passages dropped between kept ones
+	omittedMarker = "[…]"
+	// BM25 parameters for term frequency saturation and length normalization
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// docChunk is one passage of a grounding document
+type docChunk struct {
+	doc    int
+	index  int
+	text   string
+	tokens int
+	score  float64
+}
+
+// splitChunks splits a document into passages of about chunkTargetTokens, breaking at blank
+// lines, then at line ends, then between words
+func splitChunks(content string) []string {
+	var pieces []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(content), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		if estimateTokens(paragraph) <= chunkTargetTokens {
+			pieces = append(pieces, paragraph)
+			continue
+		}
+		for _, line := range strings.Split(paragraph, "\n") {
+			pieces = append(pieces, splitWords(line, chunkTargetTokens)...)
+		}
+	}
+
+	// Merge small neighbouring pieces so every chunk carries enough context to score
+	var chunks []string
+	current, currentTokens := "", 0
+	for _, piece := range pieces {
+		tokens := estimateTokens(piece)
+		if current != "" && currentTokens+tokens > chunkTargetTokens {
+			chunks = append(chunks, current)
+			current, currentTokens = "", 0
+		}
+		if current != "" {
+			current += "\n\n"
+		}
+		current += piece
+		currentTokens += tokens
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// splitWords breaks a long line into pieces of at most limit tokens at word boundaries
+func splitWords(line string, limit int) []string {
+	var pieces []string
+	var words []string
+	tokens := 0
+	for _, word := range strings.Fields(line) {
+		wordTokens := estimateTokens(word)
+		if len(words) > 0 && tokens+wordTokens > limit {
+			pieces = append(pieces, strings.Join(words, " "))
+			words, tokens = nil, 0
+		}
+		words = append(words, word)
+		tokens += wordTokens
+	}
+	if len(words) > 0 {
+		pieces = append(pieces, strings.Join(words, " "))
+	}
+	return pieces
+}
+
+// scoreChunks ranks chunks against the query terms with BM25
+func scoreChunks(chunks []*docChunk, terms []string) {
+	if len(terms) == 0 || len(chunks) == 0 {
+		return
+	}
+
+	frequencies := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	documentFrequency := map[string]int{}
+	totalLength := 0
+	for i, chunk := range chunks {
+		frequencies[i] = map[string]int{}
+		for _, word := range letterRunPattern.FindAllString(strings.ToLower(chunk.text), -1) {
+			frequencies[i][word]++
+			lengths[i]++
+		}
+		totalLength += lengths[i]
+		for _, term := range terms {
+			if frequencies[i][term] > 0 {
+				documentFrequency[term]++
+			}
+		}
+	}
+	averageLength := math.Max(float64(totalLength)/float64(len(chunks)), 1)
+
+	n := float64(len(chunks))
+	for i, chunk := range chunks {
+		for _, term := range terms {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			chunk.score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/averageLength))
+		}
+	}
+}
+
+// selectDocumentChunks fits the documents into budget tokens by keeping the passages most
+// relevant to the query, plus the opening passage of each document when it fits, in their
+// original order. Documents already within budget are returned unchanged.
+func selectDocumentChunks(docs []Document, query string, budget int) ([]Document, string) {
+	total := 0
+	for _, doc := range docs {
+		total += estimateTokens(doc.Content)
+	}
+	if budget <= 0 || total <= budget {
+		return docs, ""
+	}
+
+	var chunks []*docChunk
+	for d, doc := range docs {
+		for i, text := range splitChunks(doc.Content) {
+			chunks = append(chunks, &docChunk{doc: d, index: i, text: text, tokens: estimateTokens(text)})
+		}
+	}
+	scoreChunks(chunks, queryTerms(query))
+
+	// Openings first, then by relevance, then in document order
+	ranked := append([]*docChunk{}, chunks...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if (ranked[i].index == 0) != (ranked[j].index == 0) {
+			return ranked[i].index == 0
+		}
+		return ranked[i].score > ranked[j].score
+	})
+
+	kept := map[*docChunk]bool{}
+	used := 0
+	for _, chunk := range ranked {
+		if used+chunk.tokens <= budget {
+			kept[chunk] = true
+			used += chunk.tokens
+		}
+	}
+
+	selected := make([]Document, len(docs))
+	for d, doc := range docs {
+		var b strings.Builder
+		previous := -1
+		for _, chunk := range chunks {
+			if chunk.doc != d || !kept[chunk] {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			if chunk.index != previous+1 {
+				b.WriteString(omittedMarker + "\n\n")
+			}
+			b.WriteString(chunk.text)
+			previous = chunk.index
+		}
+		if b.Len() == 0 {
+			b.WriteString(omittedMarker)
+		} else if previous != lastChunkIndex(chunks, d) {
+			b.WriteString("\n\n" + omittedMarker)
+		}
+		selected[d] = Document{Name: doc.Name, Content: b.String()}
+	}
+
+	note := fmt.Sprintf("Context selection: documents are about %d tokens, over the %d-token budget; kept the %d of %d passages most relevant to the query (about %d tokens)",
+		total, budget, len(kept), len(chunks), used)
+	return selected, note
+}
+
+// lastChunkIndex returns the index of the last chunk of document d
+func lastChunkIndex(chunks []*docChunk, d int) int {
+	last := -1
+	for _, chunk := range chunks {
+		if chunk.doc == d {
+			last = chunk.index
+		}
+	}
+	return last
+}
@@ -0,0 +1,98 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// longDocument builds a document with an introduction, filler sections, and one section
+// about refunds near the end
+func longDocument() string {
+	var b strings.Builder
+	b.WriteString("Acme Handbook. This handbook describes company policies for staff.\n\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "Section %d covers office seating, parking permits, and kitchen etiquette for every team member. %s\n\n",
+			i, strings.Repeat("Keep shared spaces tidy and respect quiet hours. ", 8))
+	}
+	b.WriteString("Refund policy: customers may request a refund within 30 days of purchase with the original receipt.\n\n")
+	b.WriteString("Closing remarks about seating and parking.")
+	return b.String()
+}
+
+func TestSplitChunks(t *testing.T) {
+	content := longDocument()
+	chunks := splitChunks(content)
+	if len(chunks) < 10 {
+		t.Fatalf("Expected the document to be split into many chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if tokens := estimateTokens(chunk); tokens > chunkTargetTokens {
+			t.Errorf("Chunk %d is %d tokens, over the %d target", i, tokens, chunkTargetTokens)
+		}
+	}
+	if got, want := strings.Join(strings.Fields(strings.Join(chunks, " ")), " "), strings.Join(strings.Fields(content), " "); got != want {
+		t.Error("Expected chunks to keep all of the document's text")
+	}
+
+	long := strings.Repeat("word ", 1000)
+	for _, piece := range splitChunks(long) {
+		if tokens := estimateTokens(piece); tokens > chunkTargetTokens {
+			t.Errorf("Expected a long paragraph to be split at words, got a %d-token chunk", tokens)
+		}
+	}
+}
+
+func TestSelectDocumentChunks(t *testing.T) {
+	docs := []Document{{Name: "handbook.md", Content: longDocument()}}
+
+	unchanged, note := selectDocumentChunks(docs, "refund policy", 100000)
+	if note != "" || unchanged[0].Content != docs[0].Content {
+		t.Errorf("Expected documents within budget to be unchanged, got note %q", note)
+	}
+
+	selected, note := selectDocumentChunks(docs, "What is the refund policy?", 700)
+	content := selected[0].Content
+	if estimateTokens(content) > 750 {
+		t.Errorf("Expected the selection to fit the budget, got %d tokens", estimateTokens(content))
+	}
+	for _, want := range []string{"Acme Handbook.", "Refund policy: customers may request a refund within 30 days", omittedMarker} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Selection missing %q:\n%s", want, content)
+		}
+	}
+	if !strings.HasPrefix(note, "Context selection: documents are about") || !strings.Contains(note, "over the 700-token budget") {
+		t.Errorf("Unexpected note %q", note)
+	}
+	if selected[0].Name != "handbook.md" {
+		t.Errorf("Expected document name to be kept, got %q", selected[0].Name)
+	}
+}
+
+func TestSearchWithContextSelectsChunks(t *testing.T) {
+	var prompt string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt = req.Messages[len(req.Messages)-1].Content
+		return textResponse(req.Model, "Refunds are allowed within 30 days.")
+	})
+	s.config().MaxDocumentBytes = 1 << 20
+	s.config().ContextTokenBudget = 700
+
+	params := &SearchParams{
+		Query:     "What is the refund policy?",
+		Documents: []Document{{Name: "handbook.md", Content: longDocument()}},
+	}
+	result, err := s.SearchWithContext(context.Background(), params)
+	if err != nil {
+		t.Fatalf("SearchWithContext failed: %v", err)
+	}
+	if !strings.Contains(prompt, "Refund policy: customers may request a refund") || strings.Count(prompt, "Section ") > 10 {
+		t.Errorf("Expected the prompt to keep the relevant passage and drop most filler:\n%s", prompt)
+	}
+	if !strings.Contains(result, "Context selection:") {
+		t.Errorf("Expected a context selection note:\n%s", result)
+	}
+}
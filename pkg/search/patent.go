@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// patentDomains are searched when the caller gives no domain filter
+var patentDomains = []string{"patents.google.com", "uspto.gov", "espacenet.com", "epo.org", "wipo.int"}
+
+// patentOffices names the jurisdictions most often searched; other two-letter codes are passed through
+var patentOffices = map[string]string{
+	"US": "United States (USPTO)",
+	"EP": "European Patent Office",
+	"WO": "PCT international applications (WIPO)",
+	"CN": "China (CNIPA)",
+	"JP": "Japan (JPO)",
+	"KR": "South Korea (KIPO)",
+	"DE": "Germany (DPMA)",
+	"GB": "United Kingdom (UKIPO)",
+	"FR": "France (INPI)",
+	"CA": "Canada (CIPO)",
+	"AU": "Australia (IP Australia)",
+	"IN": "India (IPO)",
+}
+
+var (
+	cpcClassPattern     = regexp.MustCompile(`^[A-HY](\d{2}([A-Z](\s*\d{1,4}(/\d{1,6})?)?)?)?$`)
+	jurisdictionPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// NormalizePatentParams upper-cases and validates the patent-specific parameters
+func NormalizePatentParams(params *SearchParams) error {
+	params.CPCClass = strings.ToUpper(strings.TrimSpace(params.CPCClass))
+	if params.CPCClass != "" && !cpcClassPattern.MatchString(params.CPCClass) {
+		return fmt.Errorf("invalid cpc_class '%s'. Use a CPC section, class, subclass, or group such as G, G06, G06F, or G06F 16/00", params.CPCClass)
+	}
+
+	params.Jurisdiction = strings.ToUpper(strings.TrimSpace(params.Jurisdiction))
+	if params.Jurisdiction != "" && !jurisdictionPattern.MatchString(params.Jurisdiction) {
+		return fmt.Errorf("invalid jurisdiction '%s'. Use a two-letter office code such as US, EP, WO, CN, or JP", params.Jurisdiction)
+	}
+
+	_, _, err := parseDateRange("filing_date_start", params.FilingDateStart, "filing_date_end", params.FilingDateEnd)
+	return err
+}
+
+// filingRange describes the filing date range for the prompt
+func filingRange(start, end string) string {
+	switch {
+	case start != "" && end != "":
+		return fmt.Sprintf("Filed: %s to %s", start, end)
+	case start != "":
+		return fmt.Sprintf("Filed: on or after %s", start)
+	case end != "":
+		return fmt.Sprintf("Filed: on or before %s", end)
+	default:
+		return ""
+	}
+}
+
+// PatentSearch performs a patent-focused search
+func (s *Searcher) PatentSearch(ctx context.Context, params *SearchParams) (string, error) {
+	// Use sonar-pro model for patent search if not specified
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	if err := NormalizePatentParams(params); err != nil {
+		return "", err
+	}
+	if len(params.SearchDomainFilter) == 0 {
+		params.SearchDomainFilter = patentDomains
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the patent-specific parameters
+	contextAdditions := []string{"Patent search"}
+	if params.Inventor != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Inventor: %s", params.Inventor))
+	}
+	if params.Assignee != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Assignee: %s", params.Assignee))
+	}
+	if params.CPCClass != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("CPC class: %s", params.CPCClass))
+	}
+	if params.Jurisdiction != "" {
+		office := params.Jurisdiction
+		if name, ok := patentOffices[office]; ok {
+			office = fmt.Sprintf("%s, %s", office, name)
+		}
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Jurisdiction: %s", office))
+	}
+	if filed := filingRange(params.FilingDateStart, params.FilingDateEnd); filed != "" {
+		contextAdditions = append(contextAdditions, filed)
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\nFor each patent mentioned, give its publication number (e.g. US 10,123,456 B2), "+
+		"title, assignee, and filing or priority date, and cite the patent record.", strings.Join(contextAdditions, ", "), params.Query)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
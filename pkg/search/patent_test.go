@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNormalizePatentParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  SearchParams
+		wantErr string
+	}{
+		{"empty", SearchParams{}, ""},
+		{"subclass", SearchParams{CPCClass: "g06f"}, ""},
+		{"group", SearchParams{CPCClass: "H04L 9/32"}, ""},
+		{"bad cpc", SearchParams{CPCClass: "software"}, "invalid cpc_class"},
+		{"jurisdiction", SearchParams{Jurisdiction: "ep"}, ""},
+		{"bad jurisdiction", SearchParams{Jurisdiction: "Europe"}, "invalid jurisdiction"},
+		{"bad date", SearchParams{FilingDateStart: "2020/01/01"}, "invalid filing_date_start"},
+		{"reversed dates", SearchParams{FilingDateStart: "2021-01-01", FilingDateEnd: "2020-01-01"}, "must not be before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NormalizePatentParams(&tt.params)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Error mismatch: got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatentSearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "US 10,000,000 B2 covers ...", "https://patents.google.com/patent/US10000000B2")
+	})
+
+	params := &SearchParams{
+		Query:           "solid-state battery electrolytes",
+		SearchType:      "patent",
+		Assignee:        "Toyota",
+		CPCClass:        "h01m",
+		Jurisdiction:    "jp",
+		FilingDateStart: "2018-01-01",
+	}
+	if _, err := s.PatentSearch(context.Background(), params); err != nil {
+		t.Fatalf("PatentSearch failed: %v", err)
+	}
+
+	if sent.Model != types.ModelSonarPro {
+		t.Errorf("Model mismatch: got %s, want %s", sent.Model, types.ModelSonarPro)
+	}
+	if strings.Join(sent.SearchDomainFilter, ",") != strings.Join(patentDomains, ",") {
+		t.Errorf("Domain filter mismatch: got %v, want %v", sent.SearchDomainFilter, patentDomains)
+	}
+	want := "[Patent search, Assignee: Toyota, CPC class: H01M, Jurisdiction: JP, Japan (JPO), Filed: on or after 2018-01-01] solid-state battery electrolytes"
+	if prompt := sent.Messages[0].Content; !strings.HasPrefix(prompt, want) {
+		t.Errorf("Prompt mismatch: got %q, want prefix %q", prompt, want)
+	}
+}
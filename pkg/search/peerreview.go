@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// peerReviewedDomains are journal publishers and indexes searched by peer_reviewed_only.
+// Perplexity accepts at most 20 domains per filter.
+var peerReviewedDomains = []string{
+	"pubmed.ncbi.nlm.nih.gov", "nature.com", "science.org", "cell.com", "thelancet.com",
+	"nejm.org", "jamanetwork.com", "bmj.com", "plos.org", "pnas.org",
+	"sciencedirect.com", "springer.com", "wiley.com", "tandfonline.com", "oup.com",
+	"ieee.org", "acm.org",
+}
+
+// preprintDomains host work that has not (yet) been peer reviewed
+var preprintDomains = []string{
+	"arxiv.org", "biorxiv.org", "medrxiv.org", "ssrn.com", "chemrxiv.org",
+	"osf.io", "preprints.org", "researchsquare.com", "zenodo.org", "researchgate.net",
+}
+
+// unreviewedDomains are sources that are clearly not peer reviewed, removed from results
+var unreviewedDomains = []string{
+	"wikipedia.org", "medium.com", "substack.com", "reddit.com", "quora.com",
+	"youtube.com", "twitter.com", "x.com", "facebook.com", "linkedin.com",
+	"blogspot.com", "wordpress.com", "stackexchange.com",
+}
+
+// applyPeerReview restricts an academic request to peer-reviewed sources. A caller's own
+// domain filter is kept; otherwise the search is limited to journal publishers and indexes.
+func applyPeerReview(req *types.PerplexityRequest, params *SearchParams) {
+	if len(params.SearchDomainFilter) == 0 {
+		req.SearchDomainFilter = append([]string{}, peerReviewedDomains...)
+		if params.IncludePreprints {
+			req.SearchDomainFilter = append(req.SearchDomainFilter, "arxiv.org", "biorxiv.org", "medrxiv.org")
+		}
+	} else if !params.IncludePreprints {
+		req.SearchExcludeDomains = append(append([]string{}, req.SearchExcludeDomains...), preprintDomains...)
+	}
+
+	constraint := "Use only peer-reviewed journal articles and conference proceedings as sources, and name the journal or venue for each study you cite. " +
+		"Do not cite blogs, news articles, encyclopedias, or forums"
+	if params.IncludePreprints {
+		constraint += ". Preprints are allowed but must be labelled as not peer reviewed"
+	} else {
+		constraint += ", and do not cite preprints"
+	}
+	last := len(req.Messages) - 1
+	req.Messages[last].Content = fmt.Sprintf("%s.\n\n%s", constraint, req.Messages[last].Content)
+}
+
+// peerReviewPolicy removes sources that are clearly not peer reviewed
+func peerReviewPolicy(includePreprints bool) sourcePolicy {
+	blocked := append([]string{}, unreviewedDomains...)
+	if !includePreprints {
+		blocked = append(blocked, preprintDomains...)
+	}
+	return sourcePolicy{blocked: blocked}
+}
+
+// filterPeerReviewed drops unreviewed sources from resp and notes how many were removed
+func filterPeerReviewed(resp *types.PerplexityResponse, params *SearchParams) *types.PerplexityResponse {
+	filtered, removed := peerReviewPolicy(params.IncludePreprints).apply(resp)
+	if removed > 0 {
+		params.addNote(fmt.Sprintf("Peer review filter: removed %d source(s) that are not peer reviewed", removed))
+	}
+	return filtered
+}
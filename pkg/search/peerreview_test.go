@@ -0,0 +1,73 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestAcademicSearchPeerReviewedOnly(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Sleep aids memory [1][2][3].",
+			"https://www.nature.com/articles/nrn2762", "https://en.wikipedia.org/wiki/Sleep", "https://arxiv.org/abs/2101.00001")
+	})
+
+	params := &SearchParams{Query: "sleep and memory consolidation", SearchType: "academic", PeerReviewedOnly: true}
+	result, err := s.AcademicSearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("AcademicSearch failed: %v", err)
+	}
+
+	if len(sent.SearchDomainFilter) != len(peerReviewedDomains) || sent.SearchDomainFilter[0] != "pubmed.ncbi.nlm.nih.gov" {
+		t.Errorf("Domain filter mismatch: got %v", sent.SearchDomainFilter)
+	}
+	if prompt := sent.Messages[len(sent.Messages)-1].Content; !strings.Contains(prompt, "do not cite preprints") || !strings.HasSuffix(prompt, params.Query) {
+		t.Errorf("Prompt should carry the peer review constraint: %q", prompt)
+	}
+	if strings.Contains(result, "wikipedia.org") || strings.Contains(result, "arxiv.org") {
+		t.Errorf("Unreviewed sources should be removed:\n%s", result)
+	}
+	if !strings.Contains(result, "Sleep aids memory [1].") || !strings.Contains(result, "Peer review filter: removed 2 source(s)") {
+		t.Errorf("Expected remapped markers and filter note:\n%s", result)
+	}
+}
+
+func TestAcademicSearchPeerReviewedKeepsDomainFilter(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Answer [1].", "https://arxiv.org/abs/2101.00001")
+	})
+
+	params := &SearchParams{
+		Query:              "graph neural networks",
+		SearchType:         "academic",
+		SearchDomainFilter: []string{"acm.org"},
+		PeerReviewedOnly:   true,
+	}
+	if _, err := s.AcademicSearch(context.Background(), params); err != nil {
+		t.Fatalf("AcademicSearch failed: %v", err)
+	}
+	if len(sent.SearchDomainFilter) != 1 || sent.SearchDomainFilter[0] != "acm.org" {
+		t.Errorf("Caller's domain filter should be kept: got %v", sent.SearchDomainFilter)
+	}
+	if !strings.Contains(strings.Join(sent.SearchExcludeDomains, " "), "arxiv.org") {
+		t.Errorf("Preprint servers should be excluded: got %v", sent.SearchExcludeDomains)
+	}
+
+	params = &SearchParams{Query: "graph neural networks", SearchType: "academic", PeerReviewedOnly: true, IncludePreprints: true}
+	result, err := s.AcademicSearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("AcademicSearch failed: %v", err)
+	}
+	if !strings.Contains(result, "https://arxiv.org/abs/2101.00001") {
+		t.Errorf("Preprints should be kept with include_preprints:\n%s", result)
+	}
+	if !strings.Contains(strings.Join(sent.SearchDomainFilter, " "), "arxiv.org") {
+		t.Errorf("Domain filter should include preprint servers: got %v", sent.SearchDomainFilter)
+	}
+}
@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// peopleDomains are searched by people search when the caller gives no domain filter
+var peopleDomains = []string{"linkedin.com", "crunchbase.com", "theorg.com", "github.com", "x.com"}
+
+// peoplePrivacyInstructions keep people research to professional, public information
+const peoplePrivacyInstructions = "Use only publicly available professional information such as roles, employers, " +
+	"education, publications, and public talks. Do not include personal contact details, home addresses, " +
+	"family members, or other private information."
+
+// Company research focuses accepted by the focus parameter
+const (
+	FocusOverview   = "overview"
+	FocusFunding    = "funding"
+	FocusJobs       = "jobs"
+	FocusLeadership = "leadership"
+	FocusNews       = "news"
+)
+
+// companyFocus tailors company search to what the caller wants to know
+type companyFocus struct {
+	// instructions are appended to the query
+	instructions string
+	// domains are searched when the caller gives no domain filter, alongside the company's own site
+	domains []string
+}
+
+var companyFocuses = map[string]companyFocus{
+	FocusOverview: {
+		instructions: "Summarize what the company does, its products, size, headquarters, founding date, ownership, and main competitors.",
+		domains:      []string{"crunchbase.com", "linkedin.com", "wikipedia.org", "bloomberg.com"},
+	},
+	FocusFunding: {
+		instructions: "List funding rounds with date, amount, round type, and lead investors, plus any acquisitions or public listing.",
+		domains:      []string{"crunchbase.com", "pitchbook.com", "techcrunch.com", "sec.gov", "prnewswire.com", "businesswire.com"},
+	},
+	FocusJobs: {
+		instructions: "List current open positions with title, location or remote policy, team, and a link to each posting, then summarize hiring trends.",
+		domains:      []string{"linkedin.com", "indeed.com", "glassdoor.com", "greenhouse.io", "lever.co", "wellfound.com"},
+	},
+	FocusLeadership: {
+		instructions: "List the leadership team and board with names, titles, and prior roles, and note recent leadership changes.",
+		domains:      []string{"linkedin.com", "crunchbase.com", "theorg.com", "bloomberg.com"},
+	},
+	FocusNews: {
+		instructions: "Summarize recent news about the company with dates, such as launches, partnerships, layoffs, and legal or regulatory events.",
+		domains:      []string{"reuters.com", "techcrunch.com", "bloomberg.com", "prnewswire.com", "businesswire.com"},
+	},
+}
+
+// IsValidCompanyFocus reports whether focus is a supported company search focus
+func IsValidCompanyFocus(focus string) bool {
+	_, ok := companyFocuses[focus]
+	return ok
+}
+
+// NormalizeCompanyDomain reduces a company website to its host name, e.g. "https://www.acme.com/about" to "acme.com"
+func NormalizeCompanyDomain(domain string) (string, error) {
+	raw := strings.TrimSpace(domain)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	host := strings.TrimPrefix(hostOf(raw), "www.")
+	if host == "" || !strings.Contains(host, ".") || strings.ContainsAny(host, " /") {
+		return "", fmt.Errorf("invalid company_domain '%s'. Use the company's website, e.g. acme.com", domain)
+	}
+	return host, nil
+}
+
+// withCompanyDomain adds the company's own site to a domain list
+func withCompanyDomain(domains []string, companyDomain string) []string {
+	if companyDomain == "" {
+		return domains
+	}
+	return append([]string{companyDomain}, domains...)
+}
+
+// PeopleSearch researches professionals by name, role, company, and location
+func (s *Searcher) PeopleSearch(ctx context.Context, params *SearchParams) (string, error) {
+	if params.PersonName == "" && params.Role == "" {
+		return "", fmt.Errorf("people search requires a name or role")
+	}
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+	if len(params.SearchDomainFilter) == 0 {
+		params.SearchDomainFilter = withCompanyDomain(peopleDomains, params.CompanyDomain)
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the people-specific parameters
+	contextAdditions := []string{"People research"}
+	if params.PersonName != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Name: %s", params.PersonName))
+	}
+	if params.Role != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Role: %s", params.Role))
+	}
+	if params.CompanyName != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Company: %s", params.CompanyName))
+	}
+	if params.Location != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Location: %s", params.Location))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\nFor each person, give their current title and employer, location, and a link to a public profile. %s",
+		strings.Join(contextAdditions, ", "), params.Query, peoplePrivacyInstructions)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
+
+// CompanySearch researches a company: overview, funding, open jobs, leadership, or news
+func (s *Searcher) CompanySearch(ctx context.Context, params *SearchParams) (string, error) {
+	if params.CompanyName == "" && params.CompanyDomain == "" {
+		return "", fmt.Errorf("company search requires company or company_domain")
+	}
+	if params.Focus == "" {
+		params.Focus = FocusOverview
+	}
+	focus, ok := companyFocuses[params.Focus]
+	if !ok {
+		return "", fmt.Errorf("invalid focus '%s'. Use %s, %s, %s, %s, or %s", params.Focus, FocusOverview, FocusFunding, FocusJobs, FocusLeadership, FocusNews)
+	}
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+	if len(params.SearchDomainFilter) == 0 {
+		params.SearchDomainFilter = withCompanyDomain(focus.domains, params.CompanyDomain)
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the company-specific parameters
+	contextAdditions := []string{fmt.Sprintf("Company research: %s", params.Focus)}
+	if params.CompanyName != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Company: %s", params.CompanyName))
+	}
+	if params.CompanyDomain != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Website: %s", params.CompanyDomain))
+	}
+	if params.Role != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Role: %s", params.Role))
+	}
+	if params.Location != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Location: %s", params.Location))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\n%s", strings.Join(contextAdditions, ", "), params.Query, focus.instructions)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
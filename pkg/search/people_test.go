@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNormalizeCompanyDomain(t *testing.T) {
+	tests := map[string]string{
+		"acme.com":                   "acme.com",
+		"https://www.Acme.com/about": "acme.com",
+		"jobs.acme.co.uk":            "jobs.acme.co.uk",
+	}
+	for input, want := range tests {
+		got, err := NormalizeCompanyDomain(input)
+		if err != nil || got != want {
+			t.Errorf("NormalizeCompanyDomain(%q) mismatch: got %q (%v), want %q", input, got, err, want)
+		}
+	}
+	if _, err := NormalizeCompanyDomain("acme"); err == nil {
+		t.Errorf("Expected error for a domain without a dot")
+	}
+}
+
+func TestPeopleSearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Jane Doe is VP Engineering at Acme.")
+	})
+
+	params := &SearchParams{Query: "background", SearchType: "people", Role: "VP Engineering", CompanyName: "Acme", CompanyDomain: "acme.com", Location: "Berlin"}
+	if _, err := s.PeopleSearch(context.Background(), params); err != nil {
+		t.Fatalf("PeopleSearch failed: %v", err)
+	}
+	if sent.SearchDomainFilter[0] != "acme.com" || len(sent.SearchDomainFilter) != len(peopleDomains)+1 {
+		t.Errorf("Domain filter mismatch: got %v", sent.SearchDomainFilter)
+	}
+	prompt := sent.Messages[0].Content
+	if !strings.HasPrefix(prompt, "[People research, Role: VP Engineering, Company: Acme, Location: Berlin] background") || !strings.HasSuffix(prompt, peoplePrivacyInstructions) {
+		t.Errorf("Prompt mismatch: got %q", prompt)
+	}
+
+	if _, err := s.PeopleSearch(context.Background(), &SearchParams{Query: "background"}); err == nil {
+		t.Errorf("Expected error without name or role")
+	}
+}
+
+func TestCompanySearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "Acme is hiring.")
+	})
+
+	params := &SearchParams{Query: "open roles", SearchType: "company", CompanyName: "Acme", Focus: FocusJobs, Role: "backend engineer"}
+	if _, err := s.CompanySearch(context.Background(), params); err != nil {
+		t.Fatalf("CompanySearch failed: %v", err)
+	}
+	if strings.Join(sent.SearchDomainFilter, ",") != strings.Join(companyFocuses[FocusJobs].domains, ",") {
+		t.Errorf("Domain filter mismatch: got %v", sent.SearchDomainFilter)
+	}
+	want := "[Company research: jobs, Company: Acme, Role: backend engineer] open roles\n\n" + companyFocuses[FocusJobs].instructions
+	if sent.Messages[0].Content != want {
+		t.Errorf("Prompt mismatch: got %q, want %q", sent.Messages[0].Content, want)
+	}
+
+	params = &SearchParams{Query: "what do they do", CompanyDomain: "acme.com"}
+	if _, err := s.CompanySearch(context.Background(), params); err != nil {
+		t.Fatalf("CompanySearch failed: %v", err)
+	}
+	if params.Focus != FocusOverview || sent.SearchDomainFilter[0] != "acme.com" {
+		t.Errorf("Expected overview focus with the company site: focus %q, domains %v", params.Focus, sent.SearchDomainFilter)
+	}
+}
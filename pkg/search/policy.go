@@ -0,0 +1,155 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// sourcePolicy decides which cited domains may appear in formatted output
+type sourcePolicy struct {
+	blocked []string
+	allowed []string
+}
+
+// policy returns the configured source policy
+func (s *Searcher) policy() sourcePolicy {
+	return sourcePolicy{
+		blocked: s.config().BlockedDomains,
+		allowed: s.config().AllowedDomains,
+	}
+}
+
+// enabled reports whether any allow or deny rules are configured
+func (p sourcePolicy) enabled() bool {
+	return len(p.blocked) > 0 || len(p.allowed) > 0
+}
+
+// permits reports whether a source URL passes the policy
+func (p sourcePolicy) permits(rawURL string) bool {
+	host := hostOf(rawURL)
+	for _, domain := range p.blocked {
+		if domainMatches(host, domain) {
+			return false
+		}
+	}
+	if len(p.allowed) == 0 {
+		return true
+	}
+	for _, domain := range p.allowed {
+		if domainMatches(host, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedShare returns the fraction of a response's sources that fail the policy
+func (p sourcePolicy) blockedShare(resp *types.PerplexityResponse) float64 {
+	urls := sourceURLs(resp)
+	if len(urls) == 0 {
+		return 0
+	}
+
+	blocked := 0
+	for _, u := range urls {
+		if !p.permits(u) {
+			blocked++
+		}
+	}
+	return float64(blocked) / float64(len(urls))
+}
+
+// apply returns a copy of resp with disallowed citations and search results removed,
+// along with the number of sources removed
+func (p sourcePolicy) apply(resp *types.PerplexityResponse) (*types.PerplexityResponse, int) {
+	filtered := *resp
+	removed := 0
+
+	// Drop disallowed citations and renumber the answer's markers to match
+	filtered.Citations = nil
+	mapping := make(map[int]int, len(resp.Citations))
+	for i, citation := range resp.Citations {
+		if p.permits(citation) {
+			filtered.Citations = append(filtered.Citations, citation)
+			mapping[i+1] = len(filtered.Citations)
+		} else {
+			mapping[i+1] = 0
+			removed++
+		}
+	}
+	if removed > 0 && len(resp.Choices) > 0 {
+		filtered.Choices = make([]types.Choice, len(resp.Choices))
+		copy(filtered.Choices, resp.Choices)
+		filtered.Choices[0].Message.Content = remapMarkers(resp.Choices[0].Message.Content, mapping)
+	}
+
+	filtered.SearchResults = nil
+	for _, result := range resp.SearchResults {
+		if p.permits(result.URL) {
+			filtered.SearchResults = append(filtered.SearchResults, result)
+		} else if len(resp.Citations) == 0 {
+			removed++
+		}
+	}
+
+	return &filtered, removed
+}
+
+// enforcePolicy re-queries with exclude filters when too many sources are blocked,
+// then strips the remaining disallowed sources
+func (s *Searcher) enforcePolicy(ctx context.Context, req *types.PerplexityRequest, params *SearchParams, resp *types.PerplexityResponse) *types.PerplexityResponse {
+	policy := s.policy()
+	if !policy.enabled() {
+		return resp
+	}
+
+	ratio := s.config().BlockedRequeryRatio
+	if ratio > 0 && len(policy.blocked) > 0 && policy.blockedShare(resp) >= ratio {
+		retryReq := *req
+		retryReq.SearchExcludeDomains = append(append([]string{}, req.SearchExcludeDomains...), policy.blocked...)
+
+		if retried, err := s.client.callAPI(ctx, &retryReq); err == nil && !isEmptyAnswer(retried) {
+			params.addNote(fmt.Sprintf("Source policy: %.0f%% of sources came from blocked domains; re-queried with those domains excluded",
+				policy.blockedShare(resp)*100))
+			resp = retried
+		}
+	}
+
+	filtered, removed := policy.apply(resp)
+	if removed > 0 {
+		params.addNote(fmt.Sprintf("Source policy: removed %d source(s) from disallowed domains", removed))
+	}
+	return filtered
+}
+
+// sourceURLs returns the citation URLs of a response, falling back to search result URLs
+func sourceURLs(resp *types.PerplexityResponse) []string {
+	if len(resp.Citations) > 0 {
+		return resp.Citations
+	}
+
+	urls := make([]string, 0, len(resp.SearchResults))
+	for _, result := range resp.SearchResults {
+		urls = append(urls, result.URL)
+	}
+	return urls
+}
+
+// hostOf extracts the lowercase host name from a URL
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(rawURL)
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// domainMatches reports whether host is domain or one of its subdomains
+func domainMatches(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
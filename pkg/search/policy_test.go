@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestSourcePolicyPermits(t *testing.T) {
+	p := sourcePolicy{blocked: []string{"contentfarm.com"}}
+
+	tests := map[string]bool{
+		"https://contentfarm.com/article":     false,
+		"https://www.contentfarm.com/article": false,
+		"https://notcontentfarm.com/article":  true,
+		"https://nature.com/paper":            true,
+	}
+	for u, want := range tests {
+		if got := p.permits(u); got != want {
+			t.Errorf("permits(%s) mismatch: got %v, want %v", u, got, want)
+		}
+	}
+
+	allow := sourcePolicy{allowed: []string{"nature.com"}}
+	if !allow.permits("https://www.nature.com/x") {
+		t.Error("Allowed domain should be permitted")
+	}
+	if allow.permits("https://example.com/x") {
+		t.Error("Domain outside allow list should be rejected")
+	}
+}
+
+func TestSourcePolicyApply(t *testing.T) {
+	p := sourcePolicy{blocked: []string{"spam.com"}}
+	resp := textResponse(types.ModelSonar, "answer", "https://good.com/a", "https://spam.com/b")
+	resp.SearchResults = []types.SearchResult{
+		{URL: "https://good.com/a", Title: "Good"},
+		{URL: "https://spam.com/b", Title: "Spam"},
+	}
+
+	filtered, removed := p.apply(resp)
+	if removed != 1 {
+		t.Errorf("Removed count mismatch: got %d, want 1", removed)
+	}
+	if len(filtered.Citations) != 1 || filtered.Citations[0] != "https://good.com/a" {
+		t.Errorf("Citations mismatch: got %v", filtered.Citations)
+	}
+	if len(filtered.SearchResults) != 1 {
+		t.Errorf("SearchResults count mismatch: got %d, want 1", len(filtered.SearchResults))
+	}
+	if len(resp.Citations) != 2 {
+		t.Error("apply must not modify the original response")
+	}
+}
+
+func TestEnforcePolicyRequeries(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		if len(req.SearchExcludeDomains) > 0 {
+			return textResponse(req.Model, "clean answer", "https://good.com/a")
+		}
+		return textResponse(req.Model, "farmed answer", "https://spam.com/a", "https://spam.com/b")
+	})
+	s.config().BlockedDomains = []string{"spam.com"}
+	s.config().BlockedRequeryRatio = 0.5
+
+	result, err := s.Search(context.Background(), &SearchParams{Query: "test", SearchType: "general"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if !strings.Contains(result, "clean answer") || !strings.Contains(result, "re-queried") {
+		t.Errorf("Expected re-queried answer, got:\n%s", result)
+	}
+	if strings.Contains(result, "spam.com") {
+		t.Errorf("Blocked domain leaked into output:\n%s", result)
+	}
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// preflightPrompt is the smallest request that proves the API key and endpoint work
+const preflightPrompt = "Reply with OK."
+
+// PreflightCheck is the outcome of one startup check
+type PreflightCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// PreflightReport collects the startup checks
+type PreflightReport struct {
+	Checks []PreflightCheck
+}
+
+// Ready reports whether every check passed
+func (r *PreflightReport) Ready() bool {
+	for _, check := range r.Checks {
+		if !check.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report as a readiness summary
+func (r *PreflightReport) String() string {
+	var b strings.Builder
+	b.WriteString("Preflight:\n")
+	for _, check := range r.Checks {
+		status := "ok"
+		if !check.OK {
+			status = "FAILED"
+		}
+		fmt.Fprintf(&b, "  %-6s %s: %s\n", status, check.Name, check.Detail)
+	}
+	if r.Ready() {
+		b.WriteString("Ready\n")
+	} else {
+		b.WriteString("Not ready\n")
+	}
+	return b.String()
+}
+
+func (r *PreflightReport) add(name string, err error, detail string) {
+	if err != nil {
+		r.Checks = append(r.Checks, PreflightCheck{Name: name, Detail: err.Error()})
+		return
+	}
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, OK: true, Detail: detail})
+}
+
+// Preflight verifies the configuration at startup so that problems surface before the first
+// query: a one-token request checks the API key and endpoint, and when caching is enabled the
+// results folder is checked for writability and its cached results are loaded, as are those
+// of the shared folder when one is configured. A configured Redis server must answer a PING.
+func (s *Searcher) Preflight(ctx context.Context) *PreflightReport {
+	report := &PreflightReport{}
+
+	start := time.Now()
+	req := &types.PerplexityRequest{
+		Model:     types.ModelSonar,
+		Messages:  []types.Message{{Role: "user", Content: preflightPrompt}},
+		MaxTokens: 1,
+	}
+	_, err := s.client.callAPI(ctx, req)
+	report.add("API", err, fmt.Sprintf("%s reachable in %s", s.client.baseURL, time.Since(start).Round(time.Millisecond)))
+
+	if s.redis != nil {
+		start := time.Now()
+		err := s.redis.Ping(ctx)
+		report.add("Redis", err, fmt.Sprintf("reachable in %s", time.Since(start).Round(time.Millisecond)))
+	}
+
+	root := s.config().ResultsRootFolder
+	if !cache.IsCachingEnabled(root) {
+		report.add("Cache", nil, "disabled")
+		return report
+	}
+	if err := cache.CheckWritable(root); err != nil {
+		report.add("Cache", err, "")
+		return report
+	}
+	report.add("Cache", nil, root+" is writable")
+
+	items, err := cache.ListPreviousQueries(root)
+	report.add("Index", err, fmt.Sprintf("%d cached result(s) loaded", len(items)))
+
+	if shared := s.config().SharedResultsFolder; shared != "" {
+		if err := cache.CheckReadable(shared); err != nil {
+			report.add("Shared", err, "")
+			return report
+		}
+		items, err := cache.ListPreviousQueries(shared)
+		report.add("Shared", err, fmt.Sprintf("%d shared result(s) in %s", len(items), shared))
+	}
+	return report
+}
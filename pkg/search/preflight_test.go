@@ -0,0 +1,77 @@
+package search
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestPreflight(t *testing.T) {
+	var got *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		got = req
+		return textResponse(types.ModelSonar, "OK")
+	})
+	s.config().ResultsRootFolder = filepath.Join(t.TempDir(), "results")
+
+	report := s.Preflight(context.Background())
+	if !report.Ready() || len(report.Checks) != 3 {
+		t.Fatalf("Expected three passing checks, got %+v", report.Checks)
+	}
+	if got == nil || got.MaxTokens != 1 || got.Model != types.ModelSonar {
+		t.Errorf("Expected a one-token sonar request, got %+v", got)
+	}
+	if !strings.Contains(report.String(), "0 cached result(s) loaded") || !strings.HasSuffix(report.String(), "Ready\n") {
+		t.Errorf("Unexpected summary:\n%s", report)
+	}
+}
+
+func TestPreflightSharedFolder(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	s.config().ResultsRootFolder = t.TempDir()
+	s.config().SharedResultsFolder = filepath.Join(t.TempDir(), "unmounted")
+
+	report := s.Preflight(context.Background())
+	if report.Ready() || report.Checks[len(report.Checks)-1].Name != "Shared" {
+		t.Errorf("Expected a missing shared folder to fail preflight, got %+v", report.Checks)
+	}
+
+	s.config().SharedResultsFolder = t.TempDir()
+	if report := s.Preflight(context.Background()); !report.Ready() || len(report.Checks) != 4 {
+		t.Errorf("Expected four passing checks, got %+v", report.Checks)
+	}
+}
+
+func TestPreflightFailures(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"invalid api key","type":"invalid_request_error"}}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	s.client.baseURL = srv.URL
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.config().ResultsRootFolder = filepath.Join(file, "results")
+
+	report := s.Preflight(context.Background())
+	if report.Ready() {
+		t.Fatal("Expected preflight to fail")
+	}
+	for _, check := range report.Checks {
+		if check.OK {
+			t.Errorf("Expected %s to fail: %s", check.Name, check.Detail)
+		}
+	}
+	if !strings.Contains(report.String(), "FAILED API") || !strings.HasSuffix(report.String(), "Not ready\n") {
+		t.Errorf("Unexpected summary:\n%s", report)
+	}
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// productTableInstructions ask for a comparison table rather than prose
+const productTableInstructions = "Answer with a markdown comparison table with one row per product and the columns " +
+	"Product, Price, Key features, Must-haves met, and Where to buy (a link to the retailer or review page). " +
+	"Quote prices in the local currency with the retailer they were seen at. After the table, recommend the best " +
+	"option for the budget in two or three sentences and explain the main trade-offs."
+
+// ProductSearch performs a shopping search that compares products in a table
+func (s *Searcher) ProductSearch(ctx context.Context, params *SearchParams) (string, error) {
+	// Use sonar-pro model for product search if not specified
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	// Product pictures help tell similar models apart
+	if params.ReturnImages == nil {
+		returnImages := true
+		params.ReturnImages = &returnImages
+	}
+	if params.Location == "" {
+		params.Location = params.Region
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the product-specific parameters
+	contextAdditions := []string{"Product comparison"}
+	if params.Category != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Category: %s", params.Category))
+	}
+	if params.Budget != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Budget: %s", params.Budget))
+	}
+	if params.Region != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Region: %s", params.Region))
+	}
+	if len(params.MustHaveFeatures) > 0 {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Must-have features: %s", strings.Join(params.MustHaveFeatures, "; ")))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\n%s", strings.Join(contextAdditions, ", "), params.Query, productTableInstructions)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
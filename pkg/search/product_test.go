@@ -0,0 +1,51 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestProductSearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		resp := textResponse(req.Model, "| Product | Price |\n|---|---|\n| Sony WH-1000XM5 | $399 |", "https://www.rtings.com/headphones")
+		resp.Images = []types.Image{{ImageURL: "https://img.example.com/xm5.jpg", OriginURL: "https://www.rtings.com/headphones"}}
+		return resp
+	})
+
+	params := &SearchParams{
+		Query:            "noise-cancelling headphones for travel",
+		SearchType:       "product",
+		Budget:           "under $400",
+		Region:           "US",
+		MustHaveFeatures: []string{"USB-C charging", "multipoint"},
+	}
+	result, err := s.ProductSearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("ProductSearch failed: %v", err)
+	}
+
+	if !sent.ReturnImages || sent.Location != "US" {
+		t.Errorf("Request mismatch: return_images %v, location %q", sent.ReturnImages, sent.Location)
+	}
+	want := "[Product comparison, Budget: under $400, Region: US, Must-have features: USB-C charging; multipoint] noise-cancelling headphones for travel\n\n"
+	if prompt := sent.Messages[0].Content; !strings.HasPrefix(prompt, want) || !strings.HasSuffix(prompt, productTableInstructions) {
+		t.Errorf("Prompt mismatch: got %q", prompt)
+	}
+	if !strings.Contains(result, "## Images\n1. ![image 1](https://img.example.com/xm5.jpg) from https://www.rtings.com/headphones\n") {
+		t.Errorf("Result should list product images:\n%s", result)
+	}
+
+	noImages := false
+	params = &SearchParams{Query: "espresso machines", SearchType: "product", ReturnImages: &noImages}
+	if _, err := s.ProductSearch(context.Background(), params); err != nil {
+		t.Fatalf("ProductSearch failed: %v", err)
+	}
+	if sent.ReturnImages {
+		t.Errorf("Explicit return_images=false should be respected")
+	}
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+)
+
+// projectsFolder holds the results folders of projects without a configured root
+const projectsFolder = "projects"
+
+// resultsRoot returns the folder a project's results are cached in: its configured root,
+// or a subfolder of the results root. An empty project uses the results root itself.
+func (s *Searcher) resultsRoot(project string) (string, error) {
+	if project == "" {
+		return s.config().ResultsRootFolder, nil
+	}
+	if err := config.ValidateProjectName(project); err != nil {
+		return "", err
+	}
+	if root, ok := s.config().ProjectRoots[project]; ok {
+		return root, nil
+	}
+	return filepath.Join(s.config().ResultsRootFolder, projectsFolder, project), nil
+}
+
+// rootOf returns the results folder holding a cached result, searching the results root,
+// then the configured project roots, then the project subfolders, and finally the shared
+// folder. Unknown results map to the results root so that lookups fail with the usual
+// not-found error.
+func (s *Searcher) rootOf(uniqueID string) string {
+	if !cache.IsValidID(uniqueID) || cache.HasResult(s.config().ResultsRootFolder, uniqueID) {
+		return s.config().ResultsRootFolder
+	}
+	for _, root := range s.config().ProjectRoots {
+		if cache.HasResult(root, uniqueID) {
+			return root
+		}
+	}
+	projects, _ := cache.ListFolders(filepath.Join(s.config().ResultsRootFolder, projectsFolder))
+	for _, project := range projects {
+		root := filepath.Join(s.config().ResultsRootFolder, projectsFolder, project)
+		if cache.HasResult(root, uniqueID) {
+			return root
+		}
+	}
+	if cache.HasResult(s.config().SharedResultsFolder, uniqueID) {
+		return s.config().SharedResultsFolder
+	}
+	return s.config().ResultsRootFolder
+}
+
+// writableRootOf returns the folder a result derived from uniqueID is saved in: the source's
+// folder, or the results root when the source lives in the read-only shared folder
+func (s *Searcher) writableRootOf(uniqueID string) string {
+	root := s.rootOf(uniqueID)
+	if s.config().SharedResultsFolder != "" && root == s.config().SharedResultsFolder {
+		return s.config().ResultsRootFolder
+	}
+	return root
+}
+
+// withShared adds the shared folder's queries to a listing of the results root, most recent
+// first. Results copied into the results root are listed once, from there.
+func (s *Searcher) withShared(ctx context.Context, queries []cache.QueryListItem) []cache.QueryListItem {
+	if s.config().SharedResultsFolder == "" {
+		return queries
+	}
+	shared, err := cache.ListPreviousQueries(s.config().SharedResultsFolder)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to list shared results", "folder", s.config().SharedResultsFolder, "error", err)
+		return queries
+	}
+
+	local := make(map[string]bool, len(queries))
+	for _, item := range queries {
+		local[item.UniqueID] = true
+	}
+	for _, item := range shared {
+		if !local[item.UniqueID] {
+			item.Shared = true
+			queries = append(queries, item)
+		}
+	}
+	sort.SliceStable(queries, func(i, j int) bool {
+		return queries[i].DateTime.After(queries[j].DateTime)
+	})
+	return queries
+}
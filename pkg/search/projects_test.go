@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+func TestProjectResultsFolders(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	s.config().ResultsRootFolder = t.TempDir()
+	s.config().ProjectRoots = map[string]string{"thesis": t.TempDir()}
+
+	search := func(query, project string) string {
+		t.Helper()
+		result, err := s.Search(context.Background(), &SearchParams{Query: query, SearchType: "general", CacheProject: project})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		var artifact struct {
+			UniqueID string `json:"unique_id"`
+			Paths    struct {
+				ResultFile string `json:"result_file"`
+			} `json:"paths"`
+		}
+		if err := json.Unmarshal([]byte(result), &artifact); err != nil {
+			t.Fatalf("Expected artifact JSON: %v", err)
+		}
+		return artifact.Paths.ResultFile
+	}
+
+	tests := []struct {
+		query   string
+		project string
+		root    string
+	}{
+		{"default query", "", s.config().ResultsRootFolder},
+		{"thesis query", "thesis", s.config().ProjectRoots["thesis"]},
+		{"startup query", "startup", filepath.Join(s.config().ResultsRootFolder, "projects", "startup")},
+	}
+	for _, tt := range tests {
+		file := search(tt.query, tt.project)
+		if filepath.Dir(filepath.Dir(file)) != tt.root {
+			t.Errorf("%s: expected the result under %s, got %s", tt.query, tt.root, file)
+		}
+		id := filepath.Base(filepath.Dir(file))
+		if result, err := s.GetPreviousResult(context.Background(), id); err != nil || !strings.Contains(result, tt.query) {
+			t.Errorf("%s: expected get_previous_result to find it in any project, got %q, %v", tt.query, result, err)
+		}
+
+		listing, err := s.ListPrevious(context.Background(), tt.project, cache.QueryFilter{})
+		if err != nil {
+			t.Fatalf("ListPrevious(%q) failed: %v", tt.project, err)
+		}
+		var queries []cache.QueryListItem
+		if err := json.Unmarshal([]byte(listing), &queries); err != nil {
+			t.Fatalf("Expected a JSON listing: %v", err)
+		}
+		if len(queries) != 1 || queries[0].Query != tt.query {
+			t.Errorf("Expected project %q to list only %q, got %+v", tt.project, tt.query, queries)
+		}
+	}
+
+	if _, err := s.ListPrevious(context.Background(), "empty", cache.QueryFilter{}); err == nil {
+		t.Error("Expected an error listing a project with no results")
+	}
+}
+
+func TestSharedResultsFolder(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	s.config().ResultsRootFolder = t.TempDir()
+	s.config().SharedResultsFolder = t.TempDir()
+
+	sharedID, err := cache.SaveResult(s.config().SharedResultsFolder, "team query", "general", "sonar-pro", "Team findings", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	localID, err := cache.SaveResult(s.config().ResultsRootFolder, "my query", "general", "sonar", "My findings", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	listing, err := s.ListPrevious(context.Background(), "", cache.QueryFilter{})
+	if err != nil {
+		t.Fatalf("ListPrevious failed: %v", err)
+	}
+	var queries []cache.QueryListItem
+	if err := json.Unmarshal([]byte(listing), &queries); err != nil {
+		t.Fatalf("Expected a JSON listing: %v", err)
+	}
+	if len(queries) != 2 || queries[0].UniqueID != localID || queries[0].Shared ||
+		queries[1].UniqueID != sharedID || !queries[1].Shared {
+		t.Errorf("Expected the local result then the shared one, got %+v", queries)
+	}
+
+	if result, err := s.GetPreviousResult(context.Background(), sharedID); err != nil || result != "Team findings" {
+		t.Errorf("Expected the shared result, got %q, %v", result, err)
+	}
+
+	// Results derived from a shared result are written to the local folder
+	artifact := s.saveWithCache("Derived findings", &SearchParams{Query: "derived", SearchType: "translation", SourceResultID: sharedID})
+	if !strings.Contains(artifact, s.config().ResultsRootFolder) {
+		t.Errorf("Expected the derived result in the local folder, got %s", artifact)
+	}
+	shared, _ := cache.ListPreviousQueries(s.config().SharedResultsFolder)
+	if len(shared) != 1 {
+		t.Errorf("Expected the shared folder to be left alone, got %+v", shared)
+	}
+}
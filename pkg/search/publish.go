@@ -0,0 +1,119 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/publish"
+)
+
+// newPublishers creates a publisher for each configured target, sharing the API client's transport
+func newPublishers(cfg *config.Config, transport http.RoundTripper) map[string]publish.Publisher {
+	httpClient := &http.Client{Timeout: cfg.Timeout, Transport: transport}
+	publishers := make(map[string]publish.Publisher)
+	if cfg.Notion.Enabled() {
+		publishers[publish.TargetNotion] = publish.NewNotionPublisher(cfg.Notion.Token, cfg.Notion.DatabaseID,
+			cfg.Notion.TitleProperty, cfg.Notion.TagsProperty, httpClient)
+	}
+	if cfg.Confluence.Enabled() {
+		publishers[publish.TargetConfluence] = publish.NewConfluencePublisher(cfg.Confluence.URL, cfg.Confluence.User,
+			cfg.Confluence.Token, cfg.Confluence.Space, httpClient)
+	}
+	return publishers
+}
+
+// PublishResult pushes a cached result to a configured wiki. target may be empty when
+// exactly one publishing target is configured.
+func (s *Searcher) PublishResult(ctx context.Context, resultID, target string) (string, error) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		return "", fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
+	}
+
+	publisher, err := s.publisherFor(target)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := s.readResult(resultID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get previous result: %w", err)
+	}
+	metadata, err := cache.GetMetadata(s.rootOf(resultID), resultID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get result metadata: %w", err)
+	}
+
+	page := &publish.Page{
+		ResultID: resultID,
+		Title:    metadata.Query,
+		Body:     strings.TrimSpace(answerBody(content)),
+		Sources:  listedSources(content),
+		Tags:     append(append([]string{}, metadata.Entities...), metadata.Keywords...),
+	}
+
+	url, err := publisher.Publish(ctx, page)
+	if err != nil {
+		return "", fmt.Errorf("failed to publish result: %w", err)
+	}
+	return fmt.Sprintf("Published result %s to %s: %s", resultID, publisher.Name(), url), nil
+}
+
+// publisherFor selects the publisher for target, defaulting to the only configured one
+func (s *Searcher) publisherFor(target string) (publish.Publisher, error) {
+	if len(s.publishers) == 0 {
+		return nil, fmt.Errorf("no publishing target is configured. Set PERPLEXITY_NOTION_TOKEN or PERPLEXITY_CONFLUENCE_TOKEN")
+	}
+
+	if target == "" {
+		if len(s.publishers) > 1 {
+			return nil, fmt.Errorf("target is required when several publishing targets are configured (%s)", strings.Join(s.publisherNames(), ", "))
+		}
+		for _, publisher := range s.publishers {
+			return publisher, nil
+		}
+	}
+
+	publisher, ok := s.publishers[strings.ToLower(target)]
+	if !ok {
+		return nil, fmt.Errorf("publishing target '%s' is not configured. Available: %s", target, strings.Join(s.publisherNames(), ", "))
+	}
+	return publisher, nil
+}
+
+// publisherNames lists the configured publishing targets
+func (s *Searcher) publisherNames() []string {
+	names := make([]string, 0, len(s.publishers))
+	for name := range s.publishers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sourceListItemPattern matches an entry in the Source URLs section
+var sourceListItemPattern = regexp.MustCompile(`^\d+\.\s+(\S+)`)
+
+// listedSources returns the URLs listed in a result's Source URLs section
+func listedSources(content string) []string {
+	start := strings.Index(content, appendedSectionHeaders[0])
+	if start < 0 {
+		return nil
+	}
+
+	var urls []string
+	for _, line := range strings.Split(content[start+len(appendedSectionHeaders[0]):], "\n") {
+		if strings.HasPrefix(line, "## ") {
+			break
+		}
+		if m := sourceListItemPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			urls = append(urls, m[1])
+		}
+	}
+	return urls
+}
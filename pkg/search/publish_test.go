@@ -0,0 +1,74 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/publish"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// stubPublisher records the last page it was asked to publish
+type stubPublisher struct {
+	name string
+	page *publish.Page
+}
+
+func (p *stubPublisher) Name() string { return p.name }
+
+func (p *stubPublisher) Publish(ctx context.Context, page *publish.Page) (string, error) {
+	p.page = page
+	return "https://wiki.example.com/" + page.ResultID, nil
+}
+
+func TestPublishResult(t *testing.T) {
+	s := newTestSearcher(t, nil)
+	s.config().ResultsRootFolder = t.TempDir()
+
+	id, err := cache.SaveResult(s.config().ResultsRootFolder, "paris facts", "general", types.ModelSonar, verifyFixture, nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	if err := cache.TagResult(s.config().ResultsRootFolder, id, []string{"capital"}, []string{"Paris"}); err != nil {
+		t.Fatalf("TagResult failed: %v", err)
+	}
+
+	if _, err := s.PublishResult(context.Background(), id, ""); err == nil || !strings.Contains(err.Error(), "no publishing target") {
+		t.Errorf("Expected unconfigured target error, got %v", err)
+	}
+
+	notion := &stubPublisher{name: publish.TargetNotion}
+	s.publishers = map[string]publish.Publisher{publish.TargetNotion: notion}
+
+	result, err := s.PublishResult(context.Background(), id, "")
+	if err != nil {
+		t.Fatalf("PublishResult failed: %v", err)
+	}
+	if !strings.Contains(result, "https://wiki.example.com/"+id) {
+		t.Errorf("Result missing page URL: %s", result)
+	}
+
+	page := notion.page
+	if page.Title != "paris facts" {
+		t.Errorf("Title mismatch: got %q", page.Title)
+	}
+	if strings.Contains(page.Body, "## Source URLs") {
+		t.Errorf("Body should not include the source sections:\n%s", page.Body)
+	}
+	if len(page.Sources) != 2 || page.Sources[1] != "https://example.com/eiffel" {
+		t.Errorf("Sources mismatch: got %v", page.Sources)
+	}
+	if strings.Join(page.Tags, ",") != "Paris,capital" {
+		t.Errorf("Tags mismatch: got %v", page.Tags)
+	}
+
+	s.publishers[publish.TargetConfluence] = &stubPublisher{name: publish.TargetConfluence}
+	if _, err := s.PublishResult(context.Background(), id, ""); err == nil || !strings.Contains(err.Error(), "target is required") {
+		t.Errorf("Expected ambiguous target error, got %v", err)
+	}
+	if _, err := s.PublishResult(context.Background(), id, "wordpress"); err == nil || !strings.Contains(err.Error(), "is not configured") {
+		t.Errorf("Expected unknown target error, got %v", err)
+	}
+}
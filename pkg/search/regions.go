@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+)
+
+// MaxRegions limits how many regions one comparison searches
+const MaxRegions = 8
+
+// regionAnswer holds the outcome of the search for one region
+type regionAnswer struct {
+	region  string
+	summary string
+	details string
+	sources []string
+	err     error
+}
+
+// ValidateRegions checks the regions of a comparison: two to MaxRegions distinct names
+func ValidateRegions(regions []string) error {
+	if len(regions) < 2 || len(regions) > MaxRegions {
+		return fmt.Errorf("regions must list between 2 and %d regions, got %d", MaxRegions, len(regions))
+	}
+	seen := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		key := strings.ToLower(strings.TrimSpace(region))
+		if key == "" {
+			return fmt.Errorf("regions must not contain empty names")
+		}
+		if seen[key] {
+			return fmt.Errorf("region '%s' is listed twice", region)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// RegionSearch runs the same query once per region concurrently, each with that region as the
+// user location, and returns a comparison table followed by each region's answer as one result
+func (s *Searcher) RegionSearch(ctx context.Context, params *SearchParams, regions []string) (string, error) {
+	if err := ValidateRegions(regions); err != nil {
+		return "", err
+	}
+
+	batchCtx := ratelimit.WithPriority(ctx, ratelimit.PriorityBatch)
+	answers := make([]regionAnswer, len(regions))
+	var wg sync.WaitGroup
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			answers[i] = s.searchRegion(batchCtx, params, strings.TrimSpace(region))
+		}(i, region)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, answer := range answers {
+		if answer.err != nil {
+			failed++
+		}
+	}
+	if failed == len(answers) {
+		return "", fmt.Errorf("all %d region searches failed: %v", len(answers), answers[0].err)
+	}
+	if failed > 0 {
+		params.addNote(fmt.Sprintf("Regions: %d of %d region searches failed", failed, len(answers)))
+	}
+
+	params.Regions = regions
+	return s.saveWithCache(formatRegionComparison(params.Query, answers)+formatNotes(params.notes), params), nil
+}
+
+// searchRegion runs the query as seen from one region
+func (s *Searcher) searchRegion(ctx context.Context, params *SearchParams, region string) regionAnswer {
+	// Each region gets its own copy so request building never shares state
+	runParams := *params
+	runParams.notes = nil
+	runParams.Location = region
+
+	req := s.buildRequest(&runParams, s.config().DefaultModel)
+	req.Messages[0].Content = fmt.Sprintf("[Region: %s] %s\n\nAnswer for %s specifically: local rules, prices, availability and sources. "+
+		"Start with one line \"Summary: ...\" of at most 20 words for a comparison table, then give the details.", region, params.Query, region)
+
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil {
+		return regionAnswer{region: region, err: err}
+	}
+	resp = applyTransforms(resp, runParams.Transforms, s.now(&runParams))
+	if len(resp.Choices) == 0 {
+		return regionAnswer{region: region, err: fmt.Errorf("no response from Perplexity API")}
+	}
+
+	content, sources, _ := normalizeCitations(resp.Choices[0].Message.Content, resp.Citations)
+	summary, details, ok := leadingField(content, "summary")
+	if !ok {
+		summary = firstLine(details)
+	}
+	return regionAnswer{region: region, summary: summary, details: details, sources: sources}
+}
+
+// formatRegionComparison renders the per-region table followed by each region's answer
+func formatRegionComparison(query string, answers []regionAnswer) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Regional Comparison: %s\n\n## Comparison\n| Region | Summary | Sources |\n|---|---|---|\n", query)
+	for _, answer := range answers {
+		if answer.err != nil {
+			fmt.Fprintf(&b, "| **%s** | n/a | n/a |\n", matrixCell(answer.region))
+			continue
+		}
+		fmt.Fprintf(&b, "| **%s** | %s | %d |\n", matrixCell(answer.region), matrixCell(answer.summary), len(answer.sources))
+	}
+
+	for _, answer := range answers {
+		fmt.Fprintf(&b, "\n## %s\n\n", answer.region)
+		if answer.err != nil {
+			fmt.Fprintf(&b, "**Error:** %v\n", answer.err)
+			continue
+		}
+		b.WriteString(answer.details + "\n")
+		for n, source := range answer.sources {
+			fmt.Fprintf(&b, "%d. %s\n", n+1, source)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestValidateRegions(t *testing.T) {
+	for _, regions := range [][]string{{"DE"}, {"DE", "de"}, {"DE", " "}, make([]string, MaxRegions+1)} {
+		if err := ValidateRegions(regions); err == nil {
+			t.Errorf("Expected an error for %q", regions)
+		}
+	}
+	if err := ValidateRegions([]string{"DE", "FR", "US"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRegionSearch(t *testing.T) {
+	var mu sync.Mutex
+	locations := make(map[string]bool)
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		locations[req.Location] = true
+		if req.Location == "FR" {
+			return &types.PerplexityResponse{}
+		}
+		return textResponse(req.Model, fmt.Sprintf("Summary: %s grant up to 4,000[1]\n- Detail", req.Location), "https://example.com/"+req.Location)
+	})
+
+	params := &SearchParams{Query: "EV incentives", SearchType: "general"}
+	result, err := s.RegionSearch(context.Background(), params, []string{"DE", "FR", "US"})
+	if err != nil {
+		t.Fatalf("RegionSearch failed: %v", err)
+	}
+	if len(locations) != 3 {
+		t.Errorf("Expected one search per region location, got %v", locations)
+	}
+	for _, want := range []string{
+		"| Region | Summary | Sources |",
+		"| **DE** | DE grant up to 4,000 | 1 |",
+		"| **FR** | n/a | n/a |",
+		"## US\n\n- Detail",
+		"1. https://example.com/US",
+		"Regions: 1 of 3 region searches failed",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+}
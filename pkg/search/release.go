@@ -0,0 +1,117 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// ownerRepoPattern matches GitHub "owner/name" shorthand
+var ownerRepoPattern = regexp.MustCompile(`^[\w.-]+/[\w.-]+$`)
+
+// releaseInstructions ask for the same sections on every run so repeated watches can be compared
+const releaseInstructions = "Answer in markdown with exactly these sections:\n" +
+	"## Latest Release: the version number and release date (YYYY-MM-DD)\n" +
+	"## Notable Changes: a bullet list of new features, improvements, and important fixes\n" +
+	"## Breaking Changes: a bullet list of removals, incompatible changes, and required migration steps, or \"None reported\"\n" +
+	"## Security: CVE or advisory IDs fixed in or affecting recent versions, with severity and affected versions, or \"None reported\"\n" +
+	"Cite the release notes, changelog, or security advisory for each item, and say if the newest release found is a pre-release."
+
+// NormalizeRepo turns "owner/name" shorthand or a repository URL into an https URL,
+// e.g. "golang/go" to "https://github.com/golang/go"
+func NormalizeRepo(repo string) (string, error) {
+	raw := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(repo), "/"), ".git")
+	if ownerRepoPattern.MatchString(raw) && !strings.Contains(strings.SplitN(raw, "/", 2)[0], ".") {
+		return "https://github.com/" + raw, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || !strings.Contains(parsed.Host, ".") || len(strings.Split(strings.Trim(parsed.Path, "/"), "/")) < 2 {
+		return "", fmt.Errorf("invalid repo '%s'. Use owner/name for GitHub or the repository URL", repo)
+	}
+	return "https://" + strings.ToLower(parsed.Host) + "/" + strings.Trim(parsed.Path, "/"), nil
+}
+
+// ValidateReleaseParams normalizes the repository and names the project after it when no project is given
+func ValidateReleaseParams(params *SearchParams) error {
+	if strings.TrimSpace(params.Project) == "" && strings.TrimSpace(params.Repo) == "" {
+		return fmt.Errorf("release watch requires project or repo")
+	}
+	if params.Repo != "" {
+		repo, err := NormalizeRepo(params.Repo)
+		if err != nil {
+			return err
+		}
+		params.Repo = repo
+	}
+	if strings.TrimSpace(params.Project) == "" {
+		params.Project = params.Repo[strings.LastIndex(params.Repo, "/")+1:]
+	}
+	return nil
+}
+
+// ReleaseWatchQuery is the query used when the caller gives none, so repeated watches of a
+// project are cached under the same query and compared with each other in digests
+func ReleaseWatchQuery(project, repo string) string {
+	if strings.TrimSpace(project) == "" {
+		project = repo
+	}
+	return fmt.Sprintf("Latest release of %s", strings.TrimSpace(project))
+}
+
+// ReleaseWatch reports a project's latest release, notable and breaking changes, and security advisories.
+// It searches the last day unless a recency or date range is given, widening to the last month when
+// the day has no sources.
+func (s *Searcher) ReleaseWatch(ctx context.Context, params *SearchParams) (string, error) {
+	if err := ValidateReleaseParams(params); err != nil {
+		return "", err
+	}
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+	defaultRecency := params.SearchRecencyFilter == "" && params.DateRangeStart == "" && params.DateRangeEnd == ""
+	if defaultRecency {
+		params.SearchRecencyFilter = "day"
+	}
+
+	resp, err := s.releaseRequest(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	if defaultRecency && citationCount(resp) == 0 {
+		params.SearchRecencyFilter = "month"
+		if widened, err := s.releaseRequest(ctx, params); err == nil && citationCount(widened) > 0 {
+			params.addNote("Recency widened: no release news from the last day; answer uses sources from the last month")
+			resp = widened
+		} else {
+			params.SearchRecencyFilter = "day"
+		}
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
+
+// releaseRequest builds and runs one release watch request at the params' current recency
+func (s *Searcher) releaseRequest(ctx context.Context, params *SearchParams) (*types.PerplexityResponse, error) {
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the project and where its releases are published
+	contextAdditions := []string{"Release watch", fmt.Sprintf("Project: %s", params.Project)}
+	if params.Repo != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Repository: %s", params.Repo))
+		if strings.HasPrefix(params.Repo, "https://github.com/") {
+			contextAdditions = append(contextAdditions, fmt.Sprintf("Releases: %s/releases", params.Repo))
+		}
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\n%s", strings.Join(contextAdditions, ", "), params.Query, releaseInstructions)
+
+	// Make API call
+	return s.execute(ctx, req, params)
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNormalizeRepo(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"golang/go", "https://github.com/golang/go", false},
+		{"https://github.com/kubernetes/kubernetes.git", "https://github.com/kubernetes/kubernetes", false},
+		{"gitlab.com/gitlab-org/gitlab/", "https://gitlab.com/gitlab-org/gitlab", false},
+		{"kubernetes", "", true},
+		{"https://github.com/golang", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeRepo(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Error mismatch: got %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeRepo mismatch: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseWatch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "## Latest Release\ngo1.23.2 (2026-10-01)", "https://github.com/golang/go/releases")
+	})
+
+	params := &SearchParams{Query: ReleaseWatchQuery("", "golang/go"), SearchType: "release", Repo: "golang/go"}
+	if _, err := s.ReleaseWatch(context.Background(), params); err != nil {
+		t.Fatalf("ReleaseWatch failed: %v", err)
+	}
+
+	if params.Project != "go" {
+		t.Errorf("Project mismatch: got %q, want %q", params.Project, "go")
+	}
+	if sent.SearchRecencyFilter != "day" {
+		t.Errorf("Recency mismatch: got %q, want day", sent.SearchRecencyFilter)
+	}
+	prompt := sent.Messages[0].Content
+	if !strings.Contains(prompt, "Releases: https://github.com/golang/go/releases") || !strings.Contains(prompt, "## Breaking Changes") {
+		t.Errorf("Prompt missing release context: %s", prompt)
+	}
+}
+
+func TestReleaseWatchWidensToMonth(t *testing.T) {
+	var recencies []string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		recencies = append(recencies, req.SearchRecencyFilter)
+		if req.SearchRecencyFilter == "day" {
+			return textResponse(req.Model, "No release in the last day.")
+		}
+		return textResponse(req.Model, "## Latest Release\nv1.31.0", "https://kubernetes.io/releases/")
+	})
+
+	params := &SearchParams{Query: ReleaseWatchQuery("Kubernetes", ""), SearchType: "release", Project: "Kubernetes"}
+	result, err := s.ReleaseWatch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("ReleaseWatch failed: %v", err)
+	}
+	if recencies[len(recencies)-1] != "month" || !strings.Contains(result, "Recency widened") {
+		t.Errorf("Expected widened month search with note: recencies %v\n%s", recencies, result)
+	}
+
+	if err := ValidateReleaseParams(&SearchParams{}); err == nil {
+		t.Error("Expected error without project or repo")
+	}
+}
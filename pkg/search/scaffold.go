@@ -0,0 +1,28 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// SetPromptScaffold replaces how search prompts are framed, for trying prompt changes without
+// editing the tools. In the template, {{prompt}} is the prompt a tool built (such as the
+// academic or financial prefix and the query) and {{query}} is the query alone. An empty
+// template restores the built prompts. Call it before the Searcher is used.
+func (s *Searcher) SetPromptScaffold(template string) {
+	s.scaffold = template
+}
+
+// withScaffold frames the built prompt with the configured scaffold
+func (s *Searcher) withScaffold(req *types.PerplexityRequest, params *SearchParams) *types.PerplexityRequest {
+	if s.scaffold == "" || len(req.Messages) == 0 {
+		return req
+	}
+	scaffolded := *req
+	scaffolded.Messages = make([]types.Message, len(req.Messages))
+	copy(scaffolded.Messages, req.Messages)
+	last := len(scaffolded.Messages) - 1
+	scaffolded.Messages[last].Content = strings.NewReplacer("{{prompt}}", scaffolded.Messages[last].Content, "{{query}}", params.Query).Replace(s.scaffold)
+	return &scaffolded
+}
@@ -0,0 +1,24 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestPromptScaffold(t *testing.T) {
+	var prompt string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt = req.Messages[len(req.Messages)-1].Content
+		return textResponse(req.Model, "An answer.")
+	})
+	s.SetPromptScaffold("Prefer review articles. {{prompt}} (topic: {{query}})")
+
+	if _, err := s.AcademicSearch(context.Background(), &SearchParams{Query: "CRISPR", SearchType: "academic", SubjectArea: "Biology"}); err != nil {
+		t.Fatalf("AcademicSearch failed: %v", err)
+	}
+	if want := "Prefer review articles. [Subject: Biology] CRISPR (topic: CRISPR)"; prompt != want {
+		t.Errorf("prompt = %q, want %q", prompt, want)
+	}
+}
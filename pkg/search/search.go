@@ -4,44 +4,160 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prasanthmj/perplexity/pkg/cache"
 	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/logging"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/notify"
+	"github.com/prasanthmj/perplexity/pkg/publish"
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+	"github.com/prasanthmj/perplexity/pkg/redis"
 	"github.com/prasanthmj/perplexity/pkg/types"
 )
 
-// Searcher handles search operations with caching
+// Searcher handles search operations with caching. A Searcher is safe for concurrent use:
+// per-call state lives in SearchParams and the request built from it, while the shared
+// clients, config, and lookup tables are read-only or guarded by their own locks.
 type Searcher struct {
-	client *Client
-	config *config.Config
+	client     *Client
+	source     config.Source
+	anonymizer *anonymizer
+	publishers map[string]publish.Publisher
+	notifier   *notify.Webhook
+	calendar   *calendarClient
+	tickers    *tickerResolver
+	papers     *paperClient
+	images     *imageDownloader
+	wayback    *waybackClient
+	pages      *pageFetcher
+	filter     *contentFilter
+	results    *cache.LRU
+	redis      *redis.Client
+	fx         FXSource
+	scaffold   string
+	// clock replaces time.Now in tests that need fixed dates
+	clock func() time.Time
+	// Work that outlives the call it belongs to, such as citation archiving; shared with the
+	// searchers derived by With
+	background *sync.WaitGroup
 }
 
-// NewSearcher creates a new searcher instance
-func NewSearcher(cfg *config.Config) (*Searcher, error) {
+// NewSearcher creates a new searcher instance. The clients it builds use the configuration
+// in effect now; other settings are read from src on each call, so a config.Reloader can
+// change them while the searcher runs.
+func NewSearcher(src config.Source) (*Searcher, error) {
+	cfg := src.Snapshot()
 	client := NewClient(cfg.APIKey, cfg.Timeout)
+	client.limiter = ratelimit.NewLimiter(cfg.RateLimit)
+	client.userAgent = cfg.UserAgent
+	client.headers = cfg.ExtraHeaders
+	client.stream = cfg.Stream
+
+	var shared *redis.Client
+	if cfg.RedisURL != "" {
+		rc, err := redis.New(cfg.RedisURL, cfg.RedisPrefix)
+		if err != nil {
+			return nil, err
+		}
+		shared = rc
+		client.limiter = ratelimit.NewSharedLimiter(cfg.RateLimit, rc)
+	}
+
+	if err := ValidateTransforms(cfg.Transforms.Default); err != nil {
+		return nil, fmt.Errorf("invalid transforms: %w", err)
+	}
+	for tool, names := range cfg.Transforms.Tools {
+		if err := ValidateTransforms(names); err != nil {
+			return nil, fmt.Errorf("invalid transforms for %s: %w", tool, err)
+		}
+	}
+
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure HTTP transport: %w", err)
+	}
+	client.httpClient.Transport = transport
 	
-	return &Searcher{
-		client: client,
-		config: cfg,
-	}, nil
+	searcher := &Searcher{
+		client:     client,
+		source:     src,
+		anonymizer: newAnonymizer(cfg.AnonymizeRules),
+		publishers: newPublishers(cfg, client.httpClient.Transport),
+		tickers:    newTickerResolver(cfg.Tickers, cfg.TickerLookup),
+		filter:     newContentFilter(cfg.ContentFilter, cfg.ContentFilterAction, cfg.ContentFilterTerms),
+		results:    cache.NewLRU(cfg.ResultCacheSize),
+		redis:      shared,
+		background: &sync.WaitGroup{},
+		wayback: &waybackClient{
+			httpClient:   &http.Client{Timeout: cfg.Timeout, Transport: client.httpClient.Transport},
+			userAgent:    cfg.UserAgent,
+			availableURL: waybackAvailableURL,
+			saveURL:      waybackSaveURL,
+		},
+		pages: &pageFetcher{
+			httpClient: &http.Client{Timeout: cfg.Timeout, Transport: client.httpClient.Transport},
+			userAgent:  cfg.UserAgent,
+		},
+	}
+	if cfg.CalendarURL != "" {
+		searcher.calendar = &calendarClient{
+			urlTemplate: cfg.CalendarURL,
+			token:       cfg.CalendarToken,
+			httpClient:  &http.Client{Timeout: cfg.Timeout, Transport: client.httpClient.Transport},
+		}
+	}
+	if cfg.PaperMetadata {
+		searcher.papers = &paperClient{
+			httpClient:  &http.Client{Timeout: cfg.Timeout, Transport: client.httpClient.Transport},
+			userAgent:   cfg.UserAgent,
+			crossrefURL: crossrefURL,
+			arxivURL:    arxivURL,
+		}
+	}
+	if cfg.DownloadImages {
+		searcher.images = &imageDownloader{
+			httpClient: &http.Client{Timeout: cfg.Timeout, Transport: client.httpClient.Transport},
+			userAgent:  cfg.UserAgent,
+			maxBytes:   cfg.ImageMaxBytes,
+		}
+	}
+	if cfg.FXRates != "" {
+		searcher.fx = &fxFeed{
+			location:   cfg.FXRates,
+			httpClient: &http.Client{Timeout: cfg.Timeout, Transport: client.httpClient.Transport},
+		}
+	}
+	if cfg.NotifyWebhook != "" {
+		searcher.notifier = notify.NewWebhook(cfg.NotifyWebhook, &http.Client{Timeout: notifyTimeout, Transport: client.httpClient.Transport})
+	}
+	return searcher, nil
+}
+
+// config returns the configuration in effect
+func (s *Searcher) config() *config.Config {
+	return s.source.Snapshot()
 }
 
 // Search performs a general web search
 func (s *Searcher) Search(ctx context.Context, params *SearchParams) (string, error) {
 	// Build request with default model for general search
-	req := s.buildRequest(params, s.config.DefaultModel)
+	req := s.buildRequest(params, s.config().DefaultModel)
 
 	// Apply config defaults if not specified in params
 	if params.ReturnImages == nil {
-		req.ReturnImages = s.config.ReturnImages
+		req.ReturnImages = s.config().ReturnImages
 	}
 	if params.ReturnRelatedQuestions == nil {
-		req.ReturnRelatedQuestions = s.config.ReturnRelated
+		req.ReturnRelatedQuestions = s.config().ReturnRelated
 	}
 
 	// Make API call
-	resp, err := s.client.callAPI(ctx, req)
+	resp, err := s.execute(ctx, req, params)
 	if err != nil {
 		return "", err
 	}
@@ -57,7 +173,7 @@ func (s *Searcher) AcademicSearch(ctx context.Context, params *SearchParams) (st
 	}
 
 	// Build request
-	req := s.buildRequest(params, s.config.DefaultModel)
+	req := s.buildRequest(params, s.config().DefaultModel)
 
 	// Set academic search mode
 	req.SearchMode = "academic"
@@ -68,13 +184,22 @@ func (s *Searcher) AcademicSearch(ctx context.Context, params *SearchParams) (st
 		req.Messages[0].Content = fmt.Sprintf("[Subject: %s] %s", params.SubjectArea, params.Query)
 	}
 
+	if params.PeerReviewedOnly {
+		applyPeerReview(req, params)
+	}
+
 	// Make API call
-	resp, err := s.client.callAPI(ctx, req)
+	resp, err := s.execute(ctx, req, params)
 	if err != nil {
 		return "", err
 	}
+	if params.PeerReviewedOnly {
+		resp = filterPeerReviewed(resp, params)
+	}
 
-	return s.formatResponseWithCache(resp, params), nil
+	// List cited papers with metadata from Crossref and arXiv
+	content := s.formatResponse(resp) + s.paperSection(ctx, resp)
+	return s.saveWithCache(content+formatNotes(params.notes), params), nil
 }
 
 // FinancialSearch performs a financial/SEC filing focused search
@@ -84,13 +209,52 @@ func (s *Searcher) FinancialSearch(ctx context.Context, params *SearchParams) (s
 		params.Model = types.ModelSonarPro
 	}
 
+	// An explicit asset class brings its own source domains unless the caller chose some
+	var profile assetProfile
+	if params.AssetClass != "" {
+		var ok bool
+		if profile, ok = assetProfiles[params.AssetClass]; !ok {
+			return "", fmt.Errorf("invalid asset_class '%s'. Use %s, %s, %s, or %s", params.AssetClass, AssetEquity, AssetCrypto, AssetFX, AssetCommodity)
+		}
+		if len(params.SearchDomainFilter) == 0 {
+			params.SearchDomainFilter = profile.domains
+		}
+	}
+
+	// Fill in whichever of ticker and company name is missing
+	s.resolveTicker(ctx, params)
+
+	// Resolve the event date first so the date filters can be scoped around it
+	if params.Event != "" {
+		if params.AssetClass != "" && params.AssetClass != AssetEquity {
+			return "", fmt.Errorf("event is only supported for the %s asset class", AssetEquity)
+		}
+		if !IsValidEvent(params.Event) {
+			return "", fmt.Errorf("invalid event '%s'. Use %s, %s, or %s", params.Event, EventEarnings, EventDividend, EventSplit)
+		}
+		if params.Ticker == "" && params.CompanyName == "" {
+			return "", fmt.Errorf("event requires a ticker or company_name")
+		}
+		s.scopeToEvent(ctx, params, s.now(params))
+	}
+
+	// Questions about today's prices while the market is closed get the last session's
+	marketHours := checkMarketHours(params, s.now(params))
+
 	// Build request
-	req := s.buildRequest(params, s.config.DefaultModel)
+	req := s.buildRequest(params, s.config().DefaultModel)
 
 	// Handle financial-specific parameters
 	var contextAdditions []string
+	if profile.guidance != "" {
+		contextAdditions = append(contextAdditions, profile.guidance)
+	}
 	if params.Ticker != "" {
-		contextAdditions = append(contextAdditions, fmt.Sprintf("Ticker: %s", params.Ticker))
+		label := "Ticker"
+		if profile.symbolLabel != "" {
+			label = profile.symbolLabel
+		}
+		contextAdditions = append(contextAdditions, fmt.Sprintf("%s: %s", label, params.Ticker))
 	}
 	if params.CompanyName != "" {
 		contextAdditions = append(contextAdditions, fmt.Sprintf("Company: %s", params.CompanyName))
@@ -98,26 +262,26 @@ func (s *Searcher) FinancialSearch(ctx context.Context, params *SearchParams) (s
 	if params.ReportType != "" {
 		contextAdditions = append(contextAdditions, fmt.Sprintf("Report Type: %s", params.ReportType))
 	}
+	if params.EventDate != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Event: %s on %s", params.Event, params.EventDate))
+	}
+	if marketHours != "" {
+		contextAdditions = append(contextAdditions, marketHours)
+	}
 
 	// Add financial context to query
 	if len(contextAdditions) > 0 {
-		contextStr := ""
-		for i, addition := range contextAdditions {
-			if i > 0 {
-				contextStr += ", "
-			}
-			contextStr += addition
-		}
-		req.Messages[0].Content = fmt.Sprintf("[%s] %s", contextStr, params.Query)
+		req.Messages[0].Content = fmt.Sprintf("[%s] %s", strings.Join(contextAdditions, ", "), params.Query)
 	}
 
 	// Make API call
-	resp, err := s.client.callAPI(ctx, req)
+	resp, err := s.execute(ctx, req, params)
 	if err != nil {
 		return "", err
 	}
 
-	return s.formatResponseWithCache(resp, params), nil
+	content := s.normalizeCurrencies(ctx, s.formatResponse(resp), params)
+	return s.saveWithCache(content+formatNotes(params.notes), params), nil
 }
 
 // FilteredSearch performs an advanced search with comprehensive filtering options
@@ -128,7 +292,7 @@ func (s *Searcher) FilteredSearch(ctx context.Context, params *SearchParams) (st
 	}
 
 	// Build request
-	req := s.buildRequest(params, s.config.DefaultModel)
+	req := s.buildRequest(params, s.config().DefaultModel)
 
 	// Handle advanced filtering parameters
 	var filterContext []string
@@ -151,32 +315,20 @@ func (s *Searcher) FilteredSearch(ctx context.Context, params *SearchParams) (st
 
 	// Add filter context to query if any filters are specified
 	if len(filterContext) > 0 {
-		contextStr := ""
-		for i, filter := range filterContext {
-			if i > 0 {
-				contextStr += ", "
-			}
-			contextStr += filter
-		}
-		req.Messages[0].Content = fmt.Sprintf("[Filters: %s] %s", contextStr, params.Query)
+		req.Messages[0].Content = fmt.Sprintf("[Filters: %s] %s", strings.Join(filterContext, ", "), params.Query)
 	}
 
 	// Handle custom filters
-	if params.CustomFilters != nil && len(params.CustomFilters) > 0 {
-		customContext := ""
+	if len(params.CustomFilters) > 0 {
+		customContext := make([]string, 0, len(params.CustomFilters))
 		for key, value := range params.CustomFilters {
-			if customContext != "" {
-				customContext += ", "
-			}
-			customContext += fmt.Sprintf("%s: %v", key, value)
-		}
-		if customContext != "" {
-			req.Messages[0].Content = fmt.Sprintf("[Custom Filters: %s] %s", customContext, req.Messages[0].Content)
+			customContext = append(customContext, fmt.Sprintf("%s: %v", key, value))
 		}
+		req.Messages[0].Content = fmt.Sprintf("[Custom Filters: %s] %s", strings.Join(customContext, ", "), req.Messages[0].Content)
 	}
 
 	// Make API call
-	resp, err := s.client.callAPI(ctx, req)
+	resp, err := s.execute(ctx, req, params)
 	if err != nil {
 		return "", err
 	}
@@ -184,20 +336,37 @@ func (s *Searcher) FilteredSearch(ctx context.Context, params *SearchParams) (st
 	return s.formatResponseWithCache(resp, params), nil
 }
 
-// ListPrevious lists previous cached queries
-func (s *Searcher) ListPrevious(ctx context.Context) (string, error) {
-	if !cache.IsCachingEnabled(s.config.ResultsRootFolder) {
+// ListPrevious lists previous cached queries in a project's results folder, or the default
+// folder when project is empty, optionally narrowed to an entity or keyword
+func (s *Searcher) ListPrevious(ctx context.Context, project string, filter cache.QueryFilter) (string, error) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
 		return "[]", fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
 	}
+
+	root, err := s.resultsRoot(project)
+	if err != nil {
+		return "[]", err
+	}
+	if !cache.IsCachingEnabled(root) {
+		return "[]", fmt.Errorf("no previous queries found for project '%s'", project)
+	}
 	
-	queries, err := cache.ListPreviousQueries(s.config.ResultsRootFolder)
+	queries, err := cache.ListPreviousQueries(root)
 	if err != nil {
 		return "", fmt.Errorf("failed to list previous queries: %w", err)
 	}
+	if project == "" {
+		queries = s.withShared(ctx, queries)
+	}
 	
 	if len(queries) == 0 {
 		return "[]", fmt.Errorf("no previous queries found. The results folder may be empty or not configured properly")
 	}
+
+	queries = filter.Apply(queries)
+	if len(queries) == 0 {
+		return "[]", fmt.Errorf("no previous queries match the given entity or keyword")
+	}
 	
 	// Convert to JSON
 	jsonBytes, err := json.MarshalIndent(queries, "", "  ")
@@ -210,18 +379,39 @@ func (s *Searcher) ListPrevious(ctx context.Context) (string, error) {
 
 // GetPreviousResult retrieves a cached result by unique ID
 func (s *Searcher) GetPreviousResult(ctx context.Context, uniqueID string) (string, error) {
-	if !cache.IsCachingEnabled(s.config.ResultsRootFolder) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
 		return "", fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
 	}
 	
-	result, err := cache.GetPreviousResult(s.config.ResultsRootFolder, uniqueID)
+	result, err := s.readResult(uniqueID)
 	if err != nil {
+		metrics.CacheLookups.Inc("miss")
 		return "", fmt.Errorf("failed to get previous result: %w", err)
 	}
+	metrics.CacheLookups.Inc("hit")
 	
 	return result, nil
 }
 
+// readResult returns a cached result, from memory when it was read or saved recently
+func (s *Searcher) readResult(uniqueID string) (string, error) {
+	if s.results == nil {
+		return cache.GetPreviousResult(s.rootOf(uniqueID), uniqueID)
+	}
+	if result, ok := s.results.Get(uniqueID); ok {
+		metrics.MemoryLookups.Inc("hit")
+		return result, nil
+	}
+	metrics.MemoryLookups.Inc("miss")
+
+	result, err := cache.GetPreviousResult(s.rootOf(uniqueID), uniqueID)
+	if err != nil {
+		return "", err
+	}
+	s.results.Add(uniqueID, result)
+	return result, nil
+}
+
 // buildRequest creates a PerplexityRequest from search parameters
 func (s *Searcher) buildRequest(params *SearchParams, defaultModel string) *types.PerplexityRequest {
 	req := &types.PerplexityRequest{
@@ -232,8 +422,8 @@ func (s *Searcher) buildRequest(params *SearchParams, defaultModel string) *type
 				Content: params.Query,
 			},
 		},
-		MaxTokens:       s.config.MaxTokens,
-		Temperature:     s.config.Temperature,
+		MaxTokens:       s.config().MaxTokens,
+		Temperature:     s.config().Temperature,
 		ReturnCitations: true, // Always return citations for LLM to potentially fetch more info
 	}
 
@@ -242,12 +432,22 @@ func (s *Searcher) buildRequest(params *SearchParams, defaultModel string) *type
 		req.Model = params.Model
 	}
 
+	// Resolve the auto pseudo-model to a concrete model
+	if req.Model == types.ModelAuto {
+		model, reason := selectModel(params)
+		req.Model = model
+		params.Model = model
+		params.addNote(fmt.Sprintf("Model auto-selected: %s (%s)", model, reason))
+	}
+
+	// Copy the domain lists so requests never alias the package-level defaults that
+	// concurrent calls share
 	if len(params.SearchDomainFilter) > 0 {
-		req.SearchDomainFilter = params.SearchDomainFilter
+		req.SearchDomainFilter = append([]string(nil), params.SearchDomainFilter...)
 	}
 
 	if len(params.SearchExcludeDomains) > 0 {
-		req.SearchExcludeDomains = params.SearchExcludeDomains
+		req.SearchExcludeDomains = append([]string(nil), params.SearchExcludeDomains...)
 	}
 
 	if params.SearchRecencyFilter != "" {
@@ -282,6 +482,10 @@ func (s *Searcher) buildRequest(params *SearchParams, defaultModel string) *type
 		req.Location = params.Location
 	}
 
+	if params.SearchMode != "" {
+		req.SearchMode = params.SearchMode
+	}
+
 	return req
 }
 
@@ -291,46 +495,142 @@ func (s *Searcher) formatResponse(resp *types.PerplexityResponse) string {
 		return "No response from Perplexity API"
 	}
 
-	content := resp.Choices[0].Message.Content
+	// Reasoning models think aloud in <think> blocks before answering
+	answer, traces := resp.Choices[0].Message.Content, []string(nil)
+	if s.config().ReasoningTraces != config.ReasoningTracesKeep {
+		answer, traces = splitReasoning(answer)
+	}
+
+	// Dedupe sources and keep inline [n] markers aligned with them
+	content, citations, citationMap := normalizeCitations(answer, resp.Citations)
+
+	var b strings.Builder
+	b.Grow(formattedSize(resp, content, citations, citationMap))
+	b.WriteString(content)
 
 	// Always append source URLs if available (for LLM to fetch if needed)
-	if len(resp.Citations) > 0 {
-		content += "\n\n## Source URLs\n"
-		for i, url := range resp.Citations {
-			content += fmt.Sprintf("%d. %s\n", i+1, url)
+	if len(citations) > 0 {
+		b.WriteString("\n\n## Source URLs\n")
+		for i, url := range citations {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, url)
 		}
 	}
+	b.WriteString(citationMap)
 
 	// Include detailed search results if available
 	if len(resp.SearchResults) > 0 {
-		content += "\n\n## Detailed Sources\n"
+		b.WriteString("\n\n## Detailed Sources\n")
 		for i, result := range resp.SearchResults {
-			content += fmt.Sprintf("\n%d. **%s**\n", i+1, result.Title)
-			content += fmt.Sprintf("   URL: %s\n", result.URL)
+			fmt.Fprintf(&b, "\n%d. **%s**\n", i+1, result.Title)
+			fmt.Fprintf(&b, "   URL: %s\n", result.URL)
 			if result.Snippet != "" {
-				content += fmt.Sprintf("   Snippet: %s\n", result.Snippet)
+				fmt.Fprintf(&b, "   Snippet: %s\n", result.Snippet)
 			}
 		}
 	}
 
+	// Include images if requested and returned
+	if len(resp.Images) > 0 {
+		b.WriteString("\n\n## Images\n")
+		for i, image := range resp.Images {
+			fmt.Fprintf(&b, "%d. ![image %d](%s)", i+1, i+1, image.ImageURL)
+			if image.OriginURL != "" {
+				fmt.Fprintf(&b, " from %s", image.OriginURL)
+			}
+			b.WriteString("\n")
+		}
+	}
+
 	// Append related questions if available
 	if len(resp.RelatedQuestions) > 0 {
-		content += "\n\n## Related Questions\n"
+		b.WriteString("\n\n## Related Questions\n")
 		for _, question := range resp.RelatedQuestions {
-			content += fmt.Sprintf("- %s\n", question)
+			fmt.Fprintf(&b, "- %s\n", question)
 		}
 	}
 
-	return content
+	if len(traces) > 0 && s.config().ReasoningTraces == config.ReasoningTracesAppendix {
+		b.WriteString("\n\n## Reasoning\n")
+		for _, trace := range traces {
+			fmt.Fprintf(&b, "\n%s\n", trace)
+		}
+	}
+
+	return b.String()
+}
+
+// formattedSize estimates the length of a formatted response so its buffer is allocated once
+func formattedSize(resp *types.PerplexityResponse, content string, citations []string, citationMap string) int {
+	// Headings plus the numbering and labels added to each list item
+	const sectionOverhead, itemOverhead = 32, 32
+
+	size := len(content) + len(citationMap) + 4*sectionOverhead
+	for _, citation := range citations {
+		size += len(citation) + itemOverhead
+	}
+	for _, result := range resp.SearchResults {
+		size += len(result.Title) + len(result.URL) + len(result.Snippet) + itemOverhead
+	}
+	for _, image := range resp.Images {
+		size += len(image.ImageURL) + len(image.OriginURL) + itemOverhead
+	}
+	for _, question := range resp.RelatedQuestions {
+		size += len(question) + itemOverhead
+	}
+	return size
+}
+
+// formatNotes renders per-call annotations as a metadata footer
+func formatNotes(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n## Search Metadata\n")
+	for _, note := range notes {
+		fmt.Fprintf(&b, "- %s\n", note)
+	}
+	return b.String()
+}
+
+// beforeMetadata inserts a section ahead of the metadata footer, or at the end without one
+func beforeMetadata(content, section string) string {
+	if i := strings.LastIndex(content, "\n\n## Search Metadata\n"); i >= 0 {
+		return content[:i] + section + content[i:]
+	}
+	return content + section
 }
 
 // formatResponseWithCache formats the API response and handles caching
 func (s *Searcher) formatResponseWithCache(resp *types.PerplexityResponse, params *SearchParams) string {
-	content := s.formatResponse(resp)
-	
+	return s.saveWithCache(s.formatResponse(resp)+formatNotes(params.notes), params)
+}
+
+// saveWithCache caches already formatted content and returns the response for the caller
+func (s *Searcher) saveWithCache(content string, params *SearchParams) string {
+	ctx := logging.WithLogger(context.Background(), params.log())
+	if params.DeepSources {
+		content = withEvidence(content, s.extendedEvidence(ctx, params.Query, content))
+	}
+	if s.separateOpinions(params) {
+		content = s.withFactsSeparated(ctx, content)
+	}
+	content = s.withConfidence(ctx, s.confidenceMode(params), content)
+	content = withOutline(content, s.config().OutlineThreshold)
+
 	// Save to cache if caching is enabled
-	if cache.IsCachingEnabled(s.config.ResultsRootFolder) {
-		model := s.config.DefaultModel
+	if cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		root, err := s.resultsRoot(params.CacheProject)
+		if err != nil {
+			params.log().Warn("failed to resolve project results folder", "project", params.CacheProject, "error", err)
+			return content
+		}
+		// Derived results stay next to the result they came from
+		if params.CacheProject == "" && params.SourceResultID != "" {
+			root = s.writableRootOf(params.SourceResultID)
+		}
+		model := s.config().DefaultModel
 		if params.Model != "" {
 			model = params.Model
 		}
@@ -338,25 +638,79 @@ func (s *Searcher) formatResponseWithCache(resp *types.PerplexityResponse, param
 		// Convert params to map for cache storage
 		paramsMap := s.convertParamsToMap(params)
 		
-		uniqueID, err := cache.SaveResult(s.config.ResultsRootFolder, params.Query, params.SearchType, model, content, paramsMap)
+		uniqueID, err := cache.SaveResult(root, params.Query, params.SearchType, model, content, paramsMap)
 		if err == nil && uniqueID != "" {
+			if params.requestID != "" {
+				if err := cache.RecordRequestID(root, uniqueID, params.requestID); err != nil {
+					params.log().Warn("failed to record request ID", "result_id", uniqueID, "error", err)
+				}
+			}
+
+			// Keep local copies of images before the result is tagged and chained
+			if s.images != nil {
+				if localized, changed := s.localizeImages(ctx, root, uniqueID, content); changed {
+					// Local image links change the length of the content, so the outline's
+					// offsets are computed again
+					localized = withOutline(withoutOutline(localized), s.config().OutlineThreshold)
+					if err := cache.UpdateResult(root, uniqueID, localized); err != nil {
+						params.log().Warn("failed to save local image links", "result_id", uniqueID, "error", err)
+					} else {
+						content = localized
+					}
+				}
+			}
+
+			if params.ExtractTables {
+				if tables := extractTables(answerBody(content)); len(tables) > 0 {
+					files, err := saveTables(root, uniqueID, tables)
+					if err != nil {
+						params.log().Warn("failed to save extracted tables", "result_id", uniqueID, "error", err)
+					}
+					params.tableFiles = files
+				}
+			}
+
+			keywords, entities := extractTags(content)
+			if err := cache.TagResult(root, uniqueID, keywords, entities); err != nil {
+				params.log().Warn("failed to tag result", "result_id", uniqueID, "error", err)
+			}
+
+			if s.config().AuditChain {
+				if err := cache.AppendAuditChain(root, uniqueID); err != nil {
+					params.log().Warn("failed to append result to audit chain", "result_id", uniqueID, "error", err)
+				}
+			}
+
+			s.results.Add(uniqueID, content)
+			s.notifyCompleted(uniqueID, content, params)
+
+			// Archiving starts after the metadata writes above, so its own write follows them
+			if s.config().ArchiveCitations {
+				s.archiveInBackground(ctx, root, uniqueID, content)
+			}
+
 			// Return artifact-compatible JSON when caching is enabled
-			return s.formatAsArtifactData(uniqueID, content, params, model)
+			return s.formatAsArtifactData(root, uniqueID, content, params, model)
 		}
 		// Silently ignore cache errors - don't break the search functionality
 	}
-	
+
+	if params.ExtractTables {
+		if tables := extractTables(answerBody(content)); len(tables) > 0 {
+			content = withTablesSection(content, tables)
+		}
+	}
 	return content
 }
 
 // formatAsArtifactData formats the response as artifact-compatible JSON
-func (s *Searcher) formatAsArtifactData(uniqueID, content string, params *SearchParams, model string) string {
+func (s *Searcher) formatAsArtifactData(root, uniqueID, content string, params *SearchParams, model string) string {
 	// Get current timestamp
-	timestamp := time.Now().Format(time.RFC3339)
+	timestamp := s.now(params).Format(time.RFC3339)
 	
 	// Build file paths
-	resultFile := fmt.Sprintf("%s/%s/result.md", s.config.ResultsRootFolder, uniqueID)
-	metadataFile := fmt.Sprintf("%s/%s/metadata.yaml", s.config.ResultsRootFolder, uniqueID)
+	resultFile := cache.Location(root, uniqueID, "result.md")
+	metadataFile := cache.Location(root, uniqueID, "metadata.yaml")
 	
 	// Create artifact-compatible data structure
 	artifactData := map[string]interface{}{
@@ -372,6 +726,12 @@ func (s *Searcher) formatAsArtifactData(uniqueID, content string, params *Search
 		},
 		"parameters": s.convertParamsToMap(params),
 	}
+	if len(params.tableFiles) > 0 {
+		artifactData["paths"].(map[string]interface{})["table_files"] = params.tableFiles
+	}
+	if params.requestID != "" {
+		artifactData["request_id"] = params.requestID
+	}
 	
 	// Marshal to JSON
 	jsonBytes, err := json.MarshalIndent(artifactData, "", "  ")
@@ -423,11 +783,48 @@ func (s *Searcher) convertParamsToMap(params *SearchParams) map[string]interface
 	if params.Location != "" {
 		result["location"] = params.Location
 	}
+	if len(params.Regions) > 0 {
+		result["regions"] = params.Regions
+	}
+	if params.SearchMode != "" {
+		result["search_mode"] = params.SearchMode
+	}
+	if params.RetryOnEmpty != nil {
+		result["retry_on_empty"] = *params.RetryOnEmpty
+	}
+	if params.MinCitations > 0 {
+		result["min_citations"] = params.MinCitations
+	}
+	if params.DeepSources {
+		result["deep_sources"] = true
+	}
+	if params.Confidence != "" {
+		result["confidence"] = params.Confidence
+	}
+	if params.SeparateOpinions != nil {
+		result["separate_opinions"] = *params.SeparateOpinions
+	}
+	if params.ExtractTables {
+		result["extract_tables"] = true
+	}
+	if params.Timezone != "" {
+		result["timezone"] = params.Timezone
+	}
+	if params.AnswerLanguage != "" {
+		result["answer_language"] = params.AnswerLanguage
+	}
+	if params.CacheProject != "" {
+		result["project"] = params.CacheProject
+	}
 	
 	// Add type-specific parameters
 	if params.SubjectArea != "" {
 		result["subject_area"] = params.SubjectArea
 	}
+	if params.PeerReviewedOnly {
+		result["peer_reviewed_only"] = true
+		result["include_preprints"] = params.IncludePreprints
+	}
 	if params.Ticker != "" {
 		result["ticker"] = params.Ticker
 	}
@@ -437,6 +834,105 @@ func (s *Searcher) convertParamsToMap(params *SearchParams) map[string]interface
 	if params.ReportType != "" {
 		result["report_type"] = params.ReportType
 	}
+	if params.AssetClass != "" {
+		result["asset_class"] = params.AssetClass
+	}
+	if params.Event != "" {
+		result["event"] = params.Event
+	}
+	if params.EventDate != "" {
+		result["event_date"] = params.EventDate
+	}
+	if params.Inventor != "" {
+		result["inventor"] = params.Inventor
+	}
+	if params.Assignee != "" {
+		result["assignee"] = params.Assignee
+	}
+	if params.CPCClass != "" {
+		result["cpc_class"] = params.CPCClass
+	}
+	if params.Jurisdiction != "" {
+		result["jurisdiction"] = params.Jurisdiction
+	}
+	if params.Court != "" {
+		result["court"] = params.Court
+	}
+	if params.StudyType != "" {
+		result["study_type"] = params.StudyType
+	}
+	if params.Population != "" {
+		result["population"] = params.Population
+	}
+	if params.Category != "" {
+		result["category"] = params.Category
+	}
+	if params.Budget != "" {
+		result["budget"] = params.Budget
+	}
+	if params.Region != "" {
+		result["region"] = params.Region
+	}
+	if len(params.MustHaveFeatures) > 0 {
+		result["must_have_features"] = params.MustHaveFeatures
+	}
+	if params.PersonName != "" {
+		result["person_name"] = params.PersonName
+	}
+	if params.Role != "" {
+		result["role"] = params.Role
+	}
+	if params.CompanyDomain != "" {
+		result["company_domain"] = params.CompanyDomain
+	}
+	if params.Focus != "" {
+		result["focus"] = params.Focus
+	}
+	if params.Origin != "" {
+		result["origin"] = params.Origin
+	}
+	if params.Destination != "" {
+		result["destination"] = params.Destination
+	}
+	if params.TravelDateStart != "" {
+		result["travel_date_start"] = params.TravelDateStart
+	}
+	if params.TravelDateEnd != "" {
+		result["travel_date_end"] = params.TravelDateEnd
+	}
+	if len(params.Constraints) > 0 {
+		result["constraints"] = params.Constraints
+	}
+	if params.ProgrammingLanguage != "" {
+		result["programming_language"] = params.ProgrammingLanguage
+	}
+	if params.Framework != "" {
+		result["framework"] = params.Framework
+	}
+	if params.Version != "" {
+		result["version"] = params.Version
+	}
+	if params.CVEID != "" {
+		result["cve_id"] = params.CVEID
+	}
+	if params.Product != "" {
+		result["product"] = params.Product
+	}
+	if params.Severity != "" {
+		result["severity"] = params.Severity
+	}
+	if params.Project != "" {
+		result["project"] = params.Project
+	}
+	if params.Repo != "" {
+		result["repo"] = params.Repo
+	}
+	if params.FilingDateStart != "" {
+		result["filing_date_start"] = params.FilingDateStart
+	}
+	if params.FilingDateEnd != "" {
+		result["filing_date_end"] = params.FilingDateEnd
+	}
 	if params.ContentType != "" {
 		result["content_type"] = params.ContentType
 	}
@@ -452,6 +948,19 @@ func (s *Searcher) convertParamsToMap(params *SearchParams) map[string]interface
 	if params.CustomFilters != nil {
 		result["custom_filters"] = params.CustomFilters
 	}
+	if len(params.Documents) > 0 {
+		names := make([]string, len(params.Documents))
+		for i, doc := range params.Documents {
+			names[i] = doc.Name
+		}
+		result["documents"] = names
+	}
+	if len(params.Transforms) > 0 {
+		result["transforms"] = params.Transforms
+	}
+	if params.SourceResultID != "" {
+		result["source_result_id"] = params.SourceResultID
+	}
 	
 	return result
 }
\ No newline at end of file
@@ -0,0 +1,188 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/metrics"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// testConfig returns a config with defaults suitable for unit tests
+func testConfig() *config.Config {
+	return &config.Config{
+		APIKey:       "test-api-key",
+		DefaultModel: types.DefaultModel,
+		MaxTokens:    types.DefaultMaxTokens,
+		Temperature:  types.DefaultTemperature,
+		Timeout:      30 * time.Second,
+		RetryOnEmpty: true,
+	}
+}
+
+// newTestSearcher returns a searcher whose client talks to a fake API driven by respond
+func newTestSearcher(t testing.TB, respond func(req *types.PerplexityRequest) *types.PerplexityResponse) *Searcher {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req types.PerplexityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(respond(&req))
+	}))
+	t.Cleanup(srv.Close)
+
+	searcher, err := NewSearcher(testConfig())
+	if err != nil {
+		t.Fatalf("NewSearcher failed: %v", err)
+	}
+	searcher.client.baseURL = srv.URL
+	return searcher
+}
+
+// textResponse builds a minimal successful API response
+func textResponse(model, content string, citations ...string) *types.PerplexityResponse {
+	return &types.PerplexityResponse{
+		Model: model,
+		Choices: []types.Choice{
+			{Message: types.Message{Role: "assistant", Content: content}},
+		},
+		Citations: citations,
+	}
+}
+
+func TestFormatNotes(t *testing.T) {
+	if got := formatNotes(nil); got != "" {
+		t.Errorf("Expected empty footer for no notes, got %q", got)
+	}
+
+	got := formatNotes([]string{"Model auto-selected: sonar (short factual query)"})
+	want := "\n\n## Search Metadata\n- Model auto-selected: sonar (short factual query)\n"
+	if got != want {
+		t.Errorf("Footer mismatch: got %q, want %q", got, want)
+	}
+}
+
+func TestFormatResponseLarge(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	const n = 500
+	resp := largeResponse(n)
+
+	got := s.formatResponse(resp)
+
+	for _, section := range []string{"## Source URLs", "## Citation Map", "## Detailed Sources", "## Images", "## Related Questions"} {
+		if strings.Count(got, section) != 1 {
+			t.Errorf("Expected one %q section", section)
+		}
+	}
+	for _, want := range []string{
+		fmt.Sprintf("%d. https://source%d.example.com/articles/%d\n", n, n-1, n-1),
+		fmt.Sprintf("| [%d] | https://source%d.example.com/articles/%d |\n", n, n-1, n-1),
+		fmt.Sprintf("\n%d. **Source %d: a study of the topic**\n", n, n),
+		fmt.Sprintf("- Follow-up question %d?\n", n/10-1),
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Formatted response is missing %q", want)
+		}
+	}
+	if lines := strings.Count(got, "\n   URL: "); lines != n {
+		t.Errorf("Expected %d detailed sources, got %d", n, lines)
+	}
+
+	// The buffer is sized up front, so formatting never regrows it
+	content, citations, citationMap := normalizeCitations(resp.Choices[0].Message.Content, resp.Citations)
+	if size := formattedSize(resp, content, citations, citationMap); size < len(got) {
+		t.Errorf("formattedSize underestimates: %d < %d", size, len(got))
+	}
+}
+
+func TestFormatResponseUnchangedForSmallAnswers(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	resp := textResponse(types.ModelSonar, "Paris is the capital [1].", "https://a.example.com", "https://b.example.com")
+	resp.SearchResults = []types.SearchResult{{Title: "Paris", URL: "https://a.example.com"}}
+	resp.Images = []types.Image{{ImageURL: "https://img.example.com/1.png", OriginURL: "https://a.example.com"}}
+	resp.RelatedQuestions = []string{"What is the population of Paris?"}
+
+	want := "Paris is the capital [1]." +
+		"\n\n## Source URLs\n1. https://a.example.com\n2. https://b.example.com\n" +
+		"\n\n## Citation Map\n| Marker | Source |\n|---|---|\n| [1] | https://a.example.com |\n| (unreferenced 2) | https://b.example.com |\n" +
+		"\n\n## Detailed Sources\n\n1. **Paris**\n   URL: https://a.example.com\n" +
+		"\n\n## Images\n1. ![image 1](https://img.example.com/1.png) from https://a.example.com\n" +
+		"\n\n## Related Questions\n- What is the population of Paris?\n"
+	if got := s.formatResponse(resp); got != want {
+		t.Errorf("Formatted response mismatch:\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatResponseReasoningTraces(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	resp := textResponse(types.ModelSonarReasoning, "<think>\nThe user asks about Paris.\n</think>\n\nParis is the capital [1].", "https://a.example.com")
+	const appendix = "\n\n## Reasoning\n\nThe user asks about Paris.\n"
+
+	s.config().ReasoningTraces = config.ReasoningTracesKeep
+	if got := s.formatResponse(resp); !strings.HasPrefix(got, "<think>\nThe user asks about Paris.\n</think>") || strings.Contains(got, appendix) {
+		t.Errorf("keep: expected the trace left in place, got:\n%s", got)
+	}
+
+	for _, mode := range []string{config.ReasoningTracesStrip, config.ReasoningTracesAppendix} {
+		s.config().ReasoningTraces = mode
+		got := s.formatResponse(resp)
+		if strings.Contains(got, "<think>") || strings.TrimSpace(answerBody(got)) != "Paris is the capital [1]." {
+			t.Errorf("%s: expected the trace removed from the answer, got:\n%s", mode, got)
+		}
+		if strings.HasSuffix(got, appendix) != (mode == config.ReasoningTracesAppendix) {
+			t.Errorf("%s: expected the trace appended only in appendix mode, got:\n%s", mode, got)
+		}
+	}
+}
+
+func TestGetPreviousResultFromMemory(t *testing.T) {
+	s := newTestSearcher(t, echoAnswer)
+	s.config().ResultsRootFolder = t.TempDir()
+	s.results = cache.NewLRU(8)
+
+	saved, err := cache.SaveResult(s.config().ResultsRootFolder, "q", "general", types.ModelSonar, "From disk", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	hits, misses := metrics.MemoryLookups.Value("hit"), metrics.MemoryLookups.Value("miss")
+
+	for i := 0; i < 2; i++ {
+		if result, err := s.GetPreviousResult(context.Background(), saved); err != nil || result != "From disk" {
+			t.Fatalf("Read %d: got %q, %v", i+1, result, err)
+		}
+	}
+	if metrics.MemoryLookups.Value("hit")-hits != 1 || metrics.MemoryLookups.Value("miss")-misses != 1 {
+		t.Errorf("Expected one miss then one hit")
+	}
+
+	// A result saved by a search is served from memory without reading it back
+	result, err := s.Search(context.Background(), &SearchParams{Query: "test", SearchType: "general"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	var artifact struct {
+		UniqueID string `json:"unique_id"`
+	}
+	if err := json.Unmarshal([]byte(result), &artifact); err != nil {
+		t.Fatalf("Expected artifact JSON: %v", err)
+	}
+	if err := os.Remove(filepath.Join(s.config().ResultsRootFolder, artifact.UniqueID, "result.md")); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := s.GetPreviousResult(context.Background(), artifact.UniqueID); err != nil || !strings.Contains(content, "test") {
+		t.Errorf("Expected the saved result from memory, got %q, %v", content, err)
+	}
+}
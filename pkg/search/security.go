@@ -0,0 +1,185 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// securityDomains are searched when the caller gives no domain filter: vulnerability databases,
+// CERT advisories, and the security advisory pages of major vendors
+var securityDomains = []string{
+	"nvd.nist.gov", "cve.org", "cisa.gov", "github.com", "osv.dev",
+	"msrc.microsoft.com", "access.redhat.com", "ubuntu.com", "security.snyk.io", "cert.org",
+}
+
+// Severities accepted by the severity parameter, in increasing order
+var severities = []string{"low", "medium", "high", "critical"}
+
+var (
+	cveIDPattern      = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	cvssVectorPattern = regexp.MustCompile(`CVSS:[34]\.\d(/[A-Z]{1,3}:[A-Z])+`)
+	cvssScorePattern  = regexp.MustCompile(`(?i)(?:CVSS(?:\s*v?[234](?:\.\d)?)?|score)[^0-9\n]{0,40}?\b(10(?:\.0)?|\d\.\d)\b`)
+)
+
+// securityInstructions ask for the per-advisory details the Advisories section is built from
+const securityInstructions = "For each vulnerability, give its CVE ID, affected products and versions, " +
+	"CVSS base score and vector string on the same line as the CVE ID, whether it is known to be exploited, " +
+	"and the fixed versions. End with a \"## Mitigation\" section listing patches, workarounds, and detection steps in order of priority. " +
+	"Cite NVD or the vendor advisory for each CVE."
+
+// NormalizeSecurityParams upper-cases the CVE ID and validates the security-specific parameters
+func NormalizeSecurityParams(params *SearchParams) error {
+	params.CVEID = strings.ToUpper(strings.TrimSpace(params.CVEID))
+	if params.CVEID != "" && !cveIDPattern.MatchString(params.CVEID) {
+		return fmt.Errorf("invalid cve_id '%s'. Use the form CVE-2024-3094", params.CVEID)
+	}
+
+	params.Severity = strings.ToLower(strings.TrimSpace(params.Severity))
+	if params.Severity != "" && severityRank(params.Severity) < 0 {
+		return fmt.Errorf("invalid severity '%s'. Use %s", params.Severity, strings.Join(severities, ", "))
+	}
+
+	_, _, err := parseDateRange("date_range_start", params.DateRangeStart, "date_range_end", params.DateRangeEnd)
+	return err
+}
+
+// severityRank returns the position of severity in severities, or -1 if it is unknown
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// cvssSeverity maps a CVSS v3/v4 base score to its qualitative rating
+func cvssSeverity(score float64) string {
+	switch {
+	case score >= 9.0:
+		return "Critical"
+	case score >= 7.0:
+		return "High"
+	case score >= 4.0:
+		return "Medium"
+	case score > 0:
+		return "Low"
+	default:
+		return "None"
+	}
+}
+
+// advisory is one CVE mentioned in an answer, with the CVSS details found next to it
+type advisory struct {
+	id     string
+	score  string
+	vector string
+}
+
+// findAdvisories returns the CVEs mentioned in content in order of first mention, taking the CVSS
+// score and vector from the line that first mentions each CVE with them
+func findAdvisories(content string) []advisory {
+	var found []advisory
+	index := make(map[string]int)
+
+	for _, line := range strings.Split(content, "\n") {
+		for _, id := range cveIDPattern.FindAllString(line, -1) {
+			id = strings.ToUpper(id)
+			i, ok := index[id]
+			if !ok {
+				i = len(found)
+				index[id] = i
+				found = append(found, advisory{id: id})
+			}
+			if found[i].score == "" {
+				// Drop vectors first so their CVSS:3.1 prefix is not read as a score
+				if match := cvssScorePattern.FindStringSubmatch(cvssVectorPattern.ReplaceAllString(line, "")); match != nil {
+					found[i].score = match[1]
+				}
+			}
+			if found[i].vector == "" {
+				found[i].vector = cvssVectorPattern.FindString(line)
+			}
+		}
+	}
+	return found
+}
+
+// formatAdvisories renders the CVEs in an answer as a table with CVSS details and NVD links
+func formatAdvisories(content string) string {
+	advisories := findAdvisories(content)
+	if len(advisories) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n## Advisories\n| CVE | CVSS | Severity | Vector | NVD |\n|---|---|---|---|---|\n")
+	for _, a := range advisories {
+		score, severity, vector := "-", "-", "-"
+		if a.score != "" {
+			if value, err := strconv.ParseFloat(a.score, 64); err == nil {
+				score, severity = a.score, cvssSeverity(value)
+			}
+		}
+		if a.vector != "" {
+			vector = "`" + a.vector + "`"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | https://nvd.nist.gov/vuln/detail/%s |\n", a.id, score, severity, vector, a.id)
+	}
+	b.WriteString("\nScores and vectors are taken from the answer text; confirm them on NVD before prioritizing.\n")
+	return b.String()
+}
+
+// SecuritySearch performs a vulnerability and security advisory search
+func (s *Searcher) SecuritySearch(ctx context.Context, params *SearchParams) (string, error) {
+	// Use sonar-pro model for security search if not specified
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	if err := NormalizeSecurityParams(params); err != nil {
+		return "", err
+	}
+	if len(params.SearchDomainFilter) == 0 {
+		params.SearchDomainFilter = securityDomains
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the security-specific parameters
+	contextAdditions := []string{"Security advisory search"}
+	if params.CVEID != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("CVE: %s", params.CVEID))
+	}
+	if params.Product != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Product: %s", params.Product))
+	}
+	if params.Severity != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Severity: %s or higher", params.Severity))
+	}
+	switch {
+	case params.DateRangeStart != "" && params.DateRangeEnd != "":
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Published: %s to %s", params.DateRangeStart, params.DateRangeEnd))
+	case params.DateRangeStart != "":
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Published: on or after %s", params.DateRangeStart))
+	case params.DateRangeEnd != "":
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Published: on or before %s", params.DateRangeEnd))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\n%s", strings.Join(contextAdditions, ", "), params.Query, securityInstructions)
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	content := s.formatResponse(resp)
+	content += formatAdvisories(answerBody(content))
+	return s.saveWithCache(content+formatNotes(params.notes), params), nil
+}
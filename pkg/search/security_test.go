@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNormalizeSecurityParams(t *testing.T) {
+	params := &SearchParams{CVEID: " cve-2024-3094 ", Severity: "High"}
+	if err := NormalizeSecurityParams(params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if params.CVEID != "CVE-2024-3094" || params.Severity != "high" {
+		t.Errorf("Normalization mismatch: got %q, %q", params.CVEID, params.Severity)
+	}
+
+	invalid := []*SearchParams{
+		{CVEID: "2024-3094"},
+		{Severity: "severe"},
+		{DateRangeStart: "2024-05-01", DateRangeEnd: "2024-04-01"},
+	}
+	for _, p := range invalid {
+		if err := NormalizeSecurityParams(p); err == nil {
+			t.Errorf("Expected error for %+v", p)
+		}
+	}
+}
+
+func TestFormatAdvisories(t *testing.T) {
+	content := "CVE-2024-3094 (CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H, CVSS 10.0) is a backdoor in xz[1].\n" +
+		"It is unrelated to cve-2023-4863, whose CVSS v3.1 base score is 8.8.\n" +
+		"See also CVE-2024-3094 on NVD."
+
+	got := formatAdvisories(content)
+	expected := []string{
+		"## Advisories",
+		"| CVE-2024-3094 | 10.0 | Critical | `CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H` | https://nvd.nist.gov/vuln/detail/CVE-2024-3094 |",
+		"| CVE-2023-4863 | 8.8 | High | - |",
+	}
+	for _, want := range expected {
+		if !strings.Contains(got, want) {
+			t.Errorf("Advisories missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "| CVE-2024-3094 |") != 1 {
+		t.Errorf("Expected one row per CVE:\n%s", got)
+	}
+
+	if formatAdvisories("No CVEs here.") != "" {
+		t.Error("Expected no section without CVEs")
+	}
+}
+
+func TestSecuritySearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "CVE-2024-3094 has CVSS 10.0.\n\n## Mitigation\n- Downgrade to 5.4.6", "https://nvd.nist.gov/vuln/detail/CVE-2024-3094")
+	})
+
+	params := &SearchParams{Query: "xz backdoor", SearchType: "security", CVEID: "CVE-2024-3094", Product: "xz-utils"}
+	result, err := s.SecuritySearch(context.Background(), params)
+	if err != nil {
+		t.Fatalf("SecuritySearch failed: %v", err)
+	}
+
+	if sent.SearchDomainFilter[0] != "nvd.nist.gov" {
+		t.Errorf("Domain filter mismatch: got %v", sent.SearchDomainFilter)
+	}
+	if prompt := sent.Messages[0].Content; !strings.Contains(prompt, "CVE: CVE-2024-3094") || !strings.Contains(prompt, "Product: xz-utils") {
+		t.Errorf("Prompt missing security context: %s", prompt)
+	}
+	if !strings.Contains(result, "| CVE-2024-3094 | 10.0 | Critical |") {
+		t.Errorf("Result missing advisories table: %s", result)
+	}
+	if strings.Contains(answerBody(result), "## Advisories") {
+		t.Error("answerBody should strip the Advisories section")
+	}
+}
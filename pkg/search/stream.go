@@ -0,0 +1,110 @@
+package search
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const (
+	// truncatedMarker ends an answer whose stream was cut off by the timeout
+	truncatedMarker = "**TRUNCATED BY TIMEOUT**"
+	// streamMaxLineBytes bounds one server-sent event; chunks carry the full source lists
+	streamMaxLineBytes = 4 << 20
+)
+
+// readStream assembles a response from the server-sent chunks of a streamed answer. Each
+// chunk's delta carries the next piece of the answer, while sources, images, and usage are
+// repeated in full, so the latest copy is kept. When the timeout hits after part of the
+// answer has arrived, that part is returned ending in truncatedMarker instead of an error.
+func readStream(body io.Reader, start time.Time) (*types.PerplexityResponse, error) {
+	var resp types.PerplexityResponse
+	var content strings.Builder
+	var finishReason string
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), streamMaxLineBytes)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk types.PerplexityResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		mergeChunk(&resp, &chunk)
+		if len(chunk.Choices) > 0 {
+			choice := chunk.Choices[0]
+			if choice.Delta != nil {
+				content.WriteString(choice.Delta.Content)
+			} else if choice.Message.Content != "" {
+				// Chunks without a delta carry the whole answer so far
+				content.Reset()
+				content.WriteString(choice.Message.Content)
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		ctxErr := contextError(err, time.Since(start))
+		if ctxErr == nil {
+			return nil, fmt.Errorf("failed to read response stream: %w", err)
+		}
+		if ctxErr.ErrorType != ErrorTypeTimeout || strings.TrimSpace(content.String()) == "" {
+			return nil, ctxErr
+		}
+		fmt.Fprintf(&content, "\n\n---\n%s after %gs. Raise timeout_seconds for the full answer.", truncatedMarker,
+			time.Since(start).Round(100*time.Millisecond).Seconds())
+		finishReason = finishReasonTimeout
+	}
+
+	resp.Choices = []types.Choice{{
+		FinishReason: finishReason,
+		Message:      types.Message{Role: "assistant", Content: content.String()},
+	}}
+	return &resp, nil
+}
+
+// mergeChunk copies a chunk's identifiers, sources, images, and usage onto the response
+func mergeChunk(resp, chunk *types.PerplexityResponse) {
+	if chunk.ID != "" {
+		resp.ID, resp.Model, resp.Object, resp.Created = chunk.ID, chunk.Model, chunk.Object, chunk.Created
+	}
+	if len(chunk.Citations) > 0 {
+		resp.Citations = chunk.Citations
+	}
+	if len(chunk.SearchResults) > 0 {
+		resp.SearchResults = chunk.SearchResults
+	}
+	if len(chunk.RelatedQuestions) > 0 {
+		resp.RelatedQuestions = chunk.RelatedQuestions
+	}
+	if len(chunk.Images) > 0 {
+		resp.Images = chunk.Images
+	}
+	if chunk.Usage.TotalTokens > 0 {
+		resp.Usage = chunk.Usage
+	}
+}
+
+// finishReasonTimeout marks a streamed answer that readStream cut off at the timeout
+const finishReasonTimeout = "timeout"
+
+// isTruncated reports whether a streamed answer was cut off by the timeout
+func isTruncated(resp *types.PerplexityResponse) bool {
+	return len(resp.Choices) > 0 && resp.Choices[0].FinishReason == finishReasonTimeout
+}
@@ -0,0 +1,140 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// streamChunk renders one server-sent event carrying a piece of the answer
+func streamChunk(piece string, citations ...string) string {
+	chunk := types.PerplexityResponse{
+		ID:        "chunk",
+		Model:     types.ModelSonarPro,
+		Choices:   []types.Choice{{Delta: &types.Message{Role: "assistant", Content: piece}}},
+		Citations: citations,
+	}
+	data, _ := json.Marshal(chunk)
+	return fmt.Sprintf("data: %s\n\n", data)
+}
+
+// streamServer sends the chunks, then either ends the stream or stalls until the test ends
+func streamServer(t *testing.T, chunks []string, stall bool) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req types.PerplexityRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.Stream {
+			http.Error(w, "expected a streamed request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprint(w, chunk)
+		}
+		w.(http.Flusher).Flush()
+		if stall {
+			<-done
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+	return srv
+}
+
+func TestReadStream(t *testing.T) {
+	body := streamChunk("Paris is ", "https://a.example.com") +
+		": keep-alive comment\n\n" +
+		streamChunk("the capital [1].", "https://a.example.com", "https://b.example.com") +
+		"data: [DONE]\n\n"
+
+	resp, err := readStream(strings.NewReader(body), time.Now())
+	if err != nil {
+		t.Fatalf("readStream failed: %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "Paris is the capital [1]." {
+		t.Errorf("Content mismatch: got %q", got)
+	}
+	if len(resp.Citations) != 2 || resp.Model != types.ModelSonarPro {
+		t.Errorf("Expected the latest citations and model, got %v and %s", resp.Citations, resp.Model)
+	}
+	if isTruncated(resp) {
+		t.Error("Complete stream reported as truncated")
+	}
+}
+
+func TestCallAPIStreamTimeoutKeepsPartialAnswer(t *testing.T) {
+	srv := streamServer(t, []string{streamChunk("The first half of the answer", "https://a.example.com")}, true)
+	client := NewClient("test-api-key", 100*time.Millisecond)
+	client.baseURL = srv.URL
+	client.stream = true
+
+	req := &types.PerplexityRequest{Model: types.ModelSonarPro}
+	resp, err := client.callAPI(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected the partial answer, got error %v", err)
+	}
+	content := resp.Choices[0].Message.Content
+	if !strings.HasPrefix(content, "The first half of the answer") || !strings.Contains(content, truncatedMarker) {
+		t.Errorf("Expected partial content ending in the marker, got %q", content)
+	}
+	if !isTruncated(resp) || len(resp.Citations) != 1 {
+		t.Errorf("Expected a truncated response with its citation, got %+v", resp)
+	}
+	if req.Stream {
+		t.Error("callAPI modified the caller's request")
+	}
+}
+
+func TestCallAPIStreamTimeoutWithoutContent(t *testing.T) {
+	srv := streamServer(t, nil, true)
+	client := NewClient("test-api-key", 100*time.Millisecond)
+	client.baseURL = srv.URL
+	client.stream = true
+
+	_, err := client.callAPI(context.Background(), &types.PerplexityRequest{Model: types.ModelSonarPro})
+	if err == nil || !strings.HasPrefix(err.Error(), "search timed out after") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestSearchCachesTruncatedAnswer(t *testing.T) {
+	srv := streamServer(t, []string{streamChunk("Partial findings", "https://a.example.com")}, true)
+	cfg := testConfig()
+	cfg.Stream = true
+	cfg.Timeout = 100 * time.Millisecond
+	cfg.ResultsRootFolder = t.TempDir()
+	s, err := NewSearcher(cfg)
+	if err != nil {
+		t.Fatalf("NewSearcher failed: %v", err)
+	}
+	s.client.baseURL = srv.URL
+
+	if _, err := s.Search(context.Background(), &SearchParams{Query: "q"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	queries, _ := cache.ListPreviousQueries(cfg.ResultsRootFolder)
+	if len(queries) != 1 {
+		t.Fatalf("Expected 1 cached result, got %d", len(queries))
+	}
+	cached, err := cache.GetPreviousResult(cfg.ResultsRootFolder, queries[0].UniqueID)
+	if err != nil {
+		t.Fatalf("GetPreviousResult failed: %v", err)
+	}
+	for _, want := range []string{"Partial findings", truncatedMarker, "Timeout: the streamed answer was cut off"} {
+		if !strings.Contains(cached, want) {
+			t.Errorf("Cached result is missing %q:\n%s", want, cached)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package search
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+// tablesSectionHeader starts the extracted tables shown inline when results are not cached
+const tablesSectionHeader = "\n\n## Extracted Tables\n"
+
+var (
+	// numericItemPattern matches a list item giving a value for a label, such as "- 2024: $1.2 billion"
+	numericItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\*\*)?([^:*]{1,80}?)(?:\*\*)?\s*[:–—]\s+(.*\d.*)$`)
+	// plainNumberPattern matches a cell that is just a number, allowing thousands separators
+	plainNumberPattern = regexp.MustCompile(`^-?(?:\d{1,3}(?:,\d{3})+|\d+)(?:\.\d+)?$`)
+	headingPattern     = regexp.MustCompile(`^#{1,6}\s+(.+)$`)
+)
+
+// extractedTable is a table of data found in an answer
+type extractedTable struct {
+	Title   string     `json:"title"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"-"`
+}
+
+// MarshalJSON writes rows as objects keyed by column, with plain numbers as JSON numbers
+func (t extractedTable) MarshalJSON() ([]byte, error) {
+	rows := make([]map[string]interface{}, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		object := make(map[string]interface{}, len(t.Columns))
+		for i, column := range t.Columns {
+			if i < len(row) {
+				object[column] = cellValue(row[i])
+			}
+		}
+		rows = append(rows, object)
+	}
+	return json.Marshal(struct {
+		Title   string                   `json:"title"`
+		Columns []string                 `json:"columns"`
+		Rows    []map[string]interface{} `json:"rows"`
+	}{t.Title, t.Columns, rows})
+}
+
+// cellValue returns a cell as a number when it is just one, and as text otherwise
+func cellValue(cell string) interface{} {
+	if plainNumberPattern.MatchString(cell) {
+		if n, err := strconv.ParseFloat(strings.ReplaceAll(cell, ",", ""), 64); err == nil {
+			return n
+		}
+	}
+	return cell
+}
+
+// extractTables finds the markdown tables of an answer and its lists of labelled values
+// with at least two items containing numbers. Tables are titled after the heading above
+// them; citation markers and bold markup are dropped from cells.
+func extractTables(body string) []extractedTable {
+	lines := strings.Split(body, "\n")
+	var tables []extractedTable
+	heading := ""
+	title := func() string {
+		if heading != "" {
+			return heading
+		}
+		return fmt.Sprintf("Table %d", len(tables)+1)
+	}
+
+	for i := 0; i < len(lines); {
+		if match := headingPattern.FindStringSubmatch(strings.TrimSpace(lines[i])); match != nil {
+			heading = cleanCell(match[1])
+			i++
+			continue
+		}
+
+		if isTableLine(lines[i]) {
+			var rows [][]string
+			for ; i < len(lines) && isTableLine(lines[i]); i++ {
+				cells := tableCells(lines[i])
+				if isSeparatorRow(cells) {
+					continue
+				}
+				for j := range cells {
+					cells[j] = cleanCell(cells[j])
+				}
+				rows = append(rows, cells)
+			}
+			if len(rows) >= 2 {
+				tables = append(tables, extractedTable{Title: title(), Columns: uniqueColumns(rows[0]), Rows: rows[1:]})
+			}
+			continue
+		}
+
+		var rows [][]string
+		for ; i < len(lines); i++ {
+			match := numericItemPattern.FindStringSubmatch(lines[i])
+			if match == nil {
+				break
+			}
+			rows = append(rows, []string{cleanCell(match[1]), cleanCell(match[2])})
+		}
+		if len(rows) >= 2 {
+			tables = append(tables, extractedTable{Title: title(), Columns: []string{"label", "value"}, Rows: rows})
+		}
+		if len(rows) == 0 {
+			i++
+		}
+	}
+	return tables
+}
+
+// cleanCell drops citation markers and bold or italic markup from a cell
+func cleanCell(cell string) string {
+	cell = citationMarkerPattern.ReplaceAllString(cell, "")
+	cell = strings.NewReplacer("**", "", "__", "", `\|`, "|").Replace(cell)
+	return strings.TrimSpace(cell)
+}
+
+// uniqueColumns names empty header cells and numbers repeated ones, so every column has a key
+func uniqueColumns(header []string) []string {
+	columns := make([]string, len(header))
+	seen := make(map[string]int)
+	for i, name := range header {
+		if name == "" {
+			name = fmt.Sprintf("column %d", i+1)
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s %d", name, seen[name])
+		}
+		columns[i] = name
+	}
+	return columns
+}
+
+// tableCSV renders a table as CSV with a header row
+func tableCSV(table extractedTable) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(table.Columns); err != nil {
+		return nil, err
+	}
+	for _, row := range table.Rows {
+		padded := make([]string, len(table.Columns))
+		copy(padded, row)
+		if err := w.Write(padded); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// saveTables writes the tables of a cached result as tables/table-N.csv and one
+// tables/tables.json, returning where the files can be found
+func saveTables(root, uniqueID string, tables []extractedTable) ([]string, error) {
+	var files []string
+	for i, table := range tables {
+		data, err := tableCSV(table)
+		if err != nil {
+			return files, err
+		}
+		location, err := cache.SaveTable(root, uniqueID, fmt.Sprintf("table-%d.csv", i+1), data)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, location)
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"tables": tables}, "", "  ")
+	if err != nil {
+		return files, err
+	}
+	location, err := cache.SaveTable(root, uniqueID, "tables.json", data)
+	if err != nil {
+		return files, err
+	}
+	return append(files, location), nil
+}
+
+// withTablesSection adds the extracted tables as JSON before the metadata footer, for results
+// that are not cached and so have no folder to save them in
+func withTablesSection(content string, tables []extractedTable) string {
+	data, err := json.MarshalIndent(map[string]interface{}{"tables": tables}, "", "  ")
+	if err != nil {
+		return content
+	}
+	return beforeMetadata(content, tablesSectionHeader+"```json\n"+string(data)+"\n```\n")
+}
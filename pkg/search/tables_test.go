@@ -0,0 +1,89 @@
+package search
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tablesFixture = `Cloud spending keeps growing.
+
+## Market Size
+
+| Year | Revenue (USD bn) | Growth |
+|------|-----------------:|--------|
+| 2023 | **1,250** [1] | 12% |
+| 2024 | 1,410.5 [2] | 13% |
+
+## Benchmarks
+
+- **Model A**: 87.5 on MMLU [3]
+- Model B: 82.1 on MMLU
+- Model C has no score
+
+A single item: 42 is not a table.`
+
+func TestExtractTables(t *testing.T) {
+	tables := extractTables(tablesFixture)
+	if len(tables) != 2 {
+		t.Fatalf("Expected 2 tables, got %d: %+v", len(tables), tables)
+	}
+
+	market := tables[0]
+	if market.Title != "Market Size" || !reflect.DeepEqual(market.Columns, []string{"Year", "Revenue (USD bn)", "Growth"}) {
+		t.Errorf("Unexpected market table: %+v", market)
+	}
+	if !reflect.DeepEqual(market.Rows, [][]string{{"2023", "1,250", "12%"}, {"2024", "1,410.5", "13%"}}) {
+		t.Errorf("Unexpected market rows: %v", market.Rows)
+	}
+
+	benchmarks := tables[1]
+	if benchmarks.Title != "Benchmarks" || !reflect.DeepEqual(benchmarks.Rows, [][]string{{"Model A", "87.5 on MMLU"}, {"Model B", "82.1 on MMLU"}}) {
+		t.Errorf("Unexpected benchmark table: %+v", benchmarks)
+	}
+
+	data, err := json.Marshal(market)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"Revenue (USD bn)":1250`) || !strings.Contains(string(data), `"Growth":"12%"`) {
+		t.Errorf("Expected plain numbers as JSON numbers and other cells as text, got %s", data)
+	}
+
+	csv, err := tableCSV(market)
+	if err != nil {
+		t.Fatalf("tableCSV failed: %v", err)
+	}
+	if want := "Year,Revenue (USD bn),Growth\n2023,\"1,250\",12%\n2024,\"1,410.5\",13%\n"; string(csv) != want {
+		t.Errorf("tableCSV() = %q, want %q", csv, want)
+	}
+}
+
+func TestSaveWithCacheExtractTables(t *testing.T) {
+	s := newTestSearcher(t, nil)
+
+	got := s.saveWithCache(tablesFixture, &SearchParams{Query: "cloud", SearchType: "general", ExtractTables: true})
+	if !strings.Contains(got, tablesSectionHeader) || !strings.Contains(got, `"title": "Benchmarks"`) {
+		t.Errorf("Expected tables inline without caching, got:\n%s", got)
+	}
+
+	s.config().ResultsRootFolder = t.TempDir()
+	got = s.saveWithCache(tablesFixture, &SearchParams{Query: "cloud", SearchType: "general", ExtractTables: true})
+	var artifact struct {
+		Paths struct {
+			TableFiles []string `json:"table_files"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal([]byte(got), &artifact); err != nil {
+		t.Fatalf("Expected artifact JSON, got %v:\n%s", err, got)
+	}
+	if len(artifact.Paths.TableFiles) != 3 || !strings.HasSuffix(artifact.Paths.TableFiles[2], "tables.json") {
+		t.Fatalf("Expected two CSV files and tables.json, got %v", artifact.Paths.TableFiles)
+	}
+	data, err := os.ReadFile(artifact.Paths.TableFiles[0])
+	if err != nil || !strings.HasPrefix(string(data), "Year,Revenue (USD bn),Growth\n") {
+		t.Errorf("Expected the first table saved as CSV, got %q, %v", data, err)
+	}
+}
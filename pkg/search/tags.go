@@ -0,0 +1,139 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+const (
+	maxKeywords      = 10
+	maxEntities      = 10
+	minKeywordLength = 4
+)
+
+var (
+	urlPattern     = regexp.MustCompile(`https?://\S+`)
+	entityPattern  = regexp.MustCompile(`\b[A-Z][A-Za-z0-9&.'-]*[A-Za-z0-9](?:\s+[A-Z][A-Za-z0-9&.'-]*[A-Za-z0-9])*`)
+	keywordPattern = regexp.MustCompile(`[a-z][a-z0-9-]*[a-z0-9]`)
+)
+
+// stopwords are common words never reported as keywords or single-word entities
+var stopwords = wordSet(`a about above after again against all also although am an and any are as at be
+	because been before being below between both but by can could did do does doing down during each either
+	few for from further had has have having he her here hers him his how however i if in into is it its itself
+	just least less many may me might more most much must my no nor not now of off on once only or other our
+	ours out over own per same she should since so some such than that the their them then there these they
+	this those through thus to too under until up upon very via was we were what when where whether which while
+	who whom whose why will with within without would yet you your according based including like new one two
+	first second third several various well used using another among around therefore overall
+	source sources answer result results information report reports data year years`)
+
+// wordSet builds a lookup set from whitespace-separated words
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// tagCount tracks how often a tag appears and where it first appeared
+type tagCount struct {
+	text      string
+	count     int
+	first     int
+	confirmed bool
+}
+
+// MigrateCache upgrades cached results to the current metadata schema, tagging results that
+// were saved before keywords and entities were extracted
+func MigrateCache(rootFolder string) (*cache.MigrationReport, error) {
+	return cache.Migrate(rootFolder, extractTags)
+}
+
+// extractTags returns the most frequent keywords and capitalized entity names in an answer
+func extractTags(content string) ([]string, []string) {
+	text := urlPattern.ReplaceAllString(answerBody(content), " ")
+	text = citationMarkerPattern.ReplaceAllString(text, " ")
+
+	entities := make(map[string]*tagCount)
+	for i, loc := range entityPattern.FindAllStringIndex(text, -1) {
+		words := strings.Fields(strings.TrimRight(text[loc[0]:loc[1]], ".'-"))
+		// Drop leading capitalized stopwords such as "The" in "The Eiffel Tower"
+		for len(words) > 0 && stopwords[strings.ToLower(words[0])] {
+			words = words[1:]
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		match := strings.Join(words, " ")
+		if len(match) < 2 {
+			continue
+		}
+
+		key := strings.ToLower(match)
+		if entities[key] == nil {
+			entities[key] = &tagCount{text: match, first: i}
+		}
+		entities[key].count++
+		if len(words) > 1 || match == strings.ToUpper(match) || !atSentenceStart(text, loc[0]) {
+			entities[key].confirmed = true
+		}
+	}
+
+	// A lone capitalized word that only ever starts sentences is probably not a name
+	for key, c := range entities {
+		if !c.confirmed {
+			delete(entities, key)
+		}
+	}
+
+	keywords := make(map[string]*tagCount)
+	for i, word := range keywordPattern.FindAllString(strings.ToLower(text), -1) {
+		if len(word) < minKeywordLength || stopwords[word] || entities[word] != nil {
+			continue
+		}
+		if keywords[word] == nil {
+			keywords[word] = &tagCount{text: word, first: i}
+		}
+		keywords[word].count++
+	}
+
+	return topTags(keywords, maxKeywords), topTags(entities, maxEntities)
+}
+
+// atSentenceStart reports whether the text before offset ends a sentence, line, or markdown marker
+func atSentenceStart(text string, offset int) bool {
+	before := strings.TrimRight(text[:offset], " \t")
+	if before == "" {
+		return true
+	}
+	return strings.ContainsAny(before[len(before)-1:], ".!?:\n#*-|>")
+}
+
+// topTags returns up to limit tags ordered by frequency, then first appearance
+func topTags(counts map[string]*tagCount, limit int) []string {
+	ranked := make([]*tagCount, 0, len(counts))
+	for _, c := range counts {
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].count != ranked[j].count {
+			return ranked[i].count > ranked[j].count
+		}
+		return ranked[i].first < ranked[j].first
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	tags := make([]string, len(ranked))
+	for i, c := range ranked {
+		tags[i] = c.text
+	}
+	return tags
+}
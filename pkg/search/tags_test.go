@@ -0,0 +1,66 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const tagsFixture = `Nvidia reported record revenue as demand for datacenter GPUs grew[1]. Chief executive Jensen Huang said
+datacenter demand would continue, while AMD and Intel expanded their own datacenter accelerators[2].
+Revenue from gaming GPUs was flat. Analysts at Morgan Stanley expect Nvidia to keep its lead.
+
+## Source URLs
+1. https://example.com/nvidia-earnings
+2. https://example.com/accelerators
+`
+
+func TestExtractTags(t *testing.T) {
+	keywords, entities := extractTags(tagsFixture)
+
+	if len(keywords) == 0 || keywords[0] != "datacenter" {
+		t.Errorf("Top keyword mismatch: got %v, want datacenter first", keywords)
+	}
+	for _, k := range keywords {
+		if strings.Contains(k, "example") || k == "urls" {
+			t.Errorf("Keywords should exclude source sections: got %v", keywords)
+		}
+	}
+
+	want := []string{"Nvidia", "GPUs", "Jensen Huang", "AMD", "Intel", "Morgan Stanley"}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("Entities mismatch: got %v, want %v", entities, want)
+	}
+}
+
+func TestListPreviousFiltersByEntity(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		if strings.Contains(req.Messages[0].Content, "chips") {
+			return textResponse(req.Model, tagsFixture)
+		}
+		return textResponse(req.Model, "Paris is the capital of France and home to the Louvre.")
+	})
+	s.config().ResultsRootFolder = t.TempDir()
+
+	for _, query := range []string{"chips market", "french capital"} {
+		if _, err := s.Search(context.Background(), &SearchParams{Query: query, SearchType: "general"}); err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+	}
+
+	listing, err := s.ListPrevious(context.Background(), "", cache.QueryFilter{Entity: "jensen huang"})
+	if err != nil {
+		t.Fatalf("ListPrevious failed: %v", err)
+	}
+	if !strings.Contains(listing, "chips market") || strings.Contains(listing, "french capital") {
+		t.Errorf("Entity filter mismatch:\n%s", listing)
+	}
+
+	if _, err := s.ListPrevious(context.Background(), "", cache.QueryFilter{Keyword: "quantum"}); err == nil {
+		t.Error("Expected error when nothing matches the filter, got nil")
+	}
+}
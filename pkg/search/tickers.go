@@ -0,0 +1,210 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// builtinTickers maps widely followed US listings to their company names and common aliases
+var builtinTickers = map[string][]string{
+	"AAPL":  {"Apple"},
+	"MSFT":  {"Microsoft"},
+	"GOOGL": {"Alphabet", "Google"},
+	"AMZN":  {"Amazon", "Amazon.com"},
+	"META":  {"Meta Platforms", "Meta", "Facebook"},
+	"NVDA":  {"Nvidia"},
+	"TSLA":  {"Tesla"},
+	"BRK.B": {"Berkshire Hathaway"},
+	"JPM":   {"JPMorgan Chase", "JPMorgan", "JP Morgan"},
+	"V":     {"Visa"},
+	"MA":    {"Mastercard"},
+	"JNJ":   {"Johnson & Johnson"},
+	"WMT":   {"Walmart"},
+	"PG":    {"Procter & Gamble"},
+	"XOM":   {"Exxon Mobil", "ExxonMobil", "Exxon"},
+	"CVX":   {"Chevron"},
+	"UNH":   {"UnitedHealth Group", "UnitedHealth"},
+	"HD":    {"Home Depot"},
+	"KO":    {"Coca-Cola"},
+	"PEP":   {"PepsiCo"},
+	"COST":  {"Costco Wholesale", "Costco"},
+	"DIS":   {"Walt Disney", "Disney"},
+	"NFLX":  {"Netflix"},
+	"ADBE":  {"Adobe"},
+	"CRM":   {"Salesforce"},
+	"ORCL":  {"Oracle"},
+	"INTC":  {"Intel"},
+	"AMD":   {"Advanced Micro Devices", "AMD"},
+	"AVGO":  {"Broadcom"},
+	"QCOM":  {"Qualcomm"},
+	"CSCO":  {"Cisco Systems", "Cisco"},
+	"IBM":   {"International Business Machines", "IBM"},
+	"TSM":   {"Taiwan Semiconductor Manufacturing", "TSMC"},
+	"ASML":  {"ASML Holding", "ASML"},
+	"BAC":   {"Bank of America"},
+	"WFC":   {"Wells Fargo"},
+	"GS":    {"Goldman Sachs"},
+	"MS":    {"Morgan Stanley"},
+	"PFE":   {"Pfizer"},
+	"MRK":   {"Merck"},
+	"LLY":   {"Eli Lilly", "Lilly"},
+	"ABBV":  {"AbbVie"},
+	"NKE":   {"Nike"},
+	"MCD":   {"McDonald's"},
+	"SBUX":  {"Starbucks"},
+	"BA":    {"Boeing"},
+	"F":     {"Ford Motor", "Ford"},
+	"GM":    {"General Motors"},
+	"UBER":  {"Uber Technologies", "Uber"},
+	"PYPL":  {"PayPal"},
+}
+
+// companySuffixes are dropped when matching company names
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "corp": true, "corporation": true, "co": true, "company": true,
+	"ltd": true, "limited": true, "plc": true, "holdings": true, "group": true, "sa": true, "nv": true,
+	"ag": true, "the": true, "class": true, "a": true, "b": true,
+}
+
+var (
+	nameTokenPattern    = regexp.MustCompile(`[a-z0-9&]+`)
+	tickerAnswerPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]{0,5}(?:\.[A-Z])?\b`)
+)
+
+// tickerResolver maps company names to tickers and back, from the dataset first and a
+// lookup search second. Lookup results are remembered for the life of the process.
+type tickerResolver struct {
+	byName   map[string]string
+	byTicker map[string]string
+	// lookup enables the search fallback for names missing from the dataset
+	lookup bool
+
+	mu      sync.Mutex
+	lookups map[string]string
+}
+
+// newTickerResolver builds a resolver from the built-in dataset plus extra entries, which
+// take precedence. Each entry maps a ticker to its company name followed by any aliases.
+func newTickerResolver(extra map[string][]string, lookup bool) *tickerResolver {
+	r := &tickerResolver{
+		byName:   make(map[string]string),
+		byTicker: make(map[string]string),
+		lookup:   lookup,
+		lookups:  make(map[string]string),
+	}
+	for _, dataset := range []map[string][]string{builtinTickers, extra} {
+		for ticker, names := range dataset {
+			ticker = strings.ToUpper(ticker)
+			if len(names) == 0 {
+				continue
+			}
+			r.byTicker[ticker] = names[0]
+			for _, name := range names {
+				r.byName[normalizeCompany(name)] = ticker
+			}
+		}
+	}
+	return r
+}
+
+// normalizeCompany lowercases a company name and drops punctuation and legal suffixes
+func normalizeCompany(name string) string {
+	var kept []string
+	for _, token := range nameTokenPattern.FindAllString(strings.ToLower(name), -1) {
+		if !companySuffixes[token] {
+			kept = append(kept, token)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// resolveTicker fills in the ticker for a company name or the company name for a ticker.
+// It only applies to equities and records a note describing what it did.
+func (s *Searcher) resolveTicker(ctx context.Context, params *SearchParams) {
+	if s.tickers == nil || (params.AssetClass != "" && params.AssetClass != AssetEquity) {
+		return
+	}
+
+	switch {
+	case params.Ticker == "" && params.CompanyName != "":
+		ticker, source := s.tickers.byName[normalizeCompany(params.CompanyName)], "dataset"
+		if ticker == "" {
+			ticker, source = s.lookupTicker(ctx, params.CompanyName), "lookup search"
+		}
+		if ticker == "" {
+			params.addNote(fmt.Sprintf("Ticker resolution: no ticker found for %s", params.CompanyName))
+			return
+		}
+		params.Ticker = ticker
+		params.addNote(fmt.Sprintf("Ticker resolution: %s → %s (%s)", params.CompanyName, ticker, source))
+
+	case params.Ticker != "" && params.CompanyName == "":
+		ticker := strings.ToUpper(strings.TrimSpace(params.Ticker))
+		name, source := s.tickers.byTicker[ticker], "dataset"
+		if name == "" {
+			name, source = s.lookupCompany(ctx, ticker), "lookup search"
+		}
+		if name == "" {
+			return
+		}
+		params.CompanyName = name
+		params.addNote(fmt.Sprintf("Ticker resolution: %s → %s (%s)", ticker, name, source))
+	}
+}
+
+// lookupTicker asks Perplexity for a company's primary ticker
+func (s *Searcher) lookupTicker(ctx context.Context, company string) string {
+	answer := s.tickerLookup(ctx, "name:"+normalizeCompany(company),
+		fmt.Sprintf("What is the primary stock ticker symbol of %s? Reply with the ticker only, or NONE if it is not publicly traded.", company))
+	if match := tickerAnswerPattern.FindString(answer); match != "" && match != "NONE" {
+		return match
+	}
+	return ""
+}
+
+// lookupCompany asks Perplexity which company trades under a ticker
+func (s *Searcher) lookupCompany(ctx context.Context, ticker string) string {
+	answer := strings.Trim(firstLine(s.tickerLookup(ctx, "ticker:"+ticker,
+		fmt.Sprintf("Which company's stock trades under the ticker symbol %s? Reply with the company name only, or NONE if unknown.", ticker))), " .\"'*")
+	if answer == "" || strings.EqualFold(answer, "NONE") || len(answer) > 80 {
+		return ""
+	}
+	return answer
+}
+
+// tickerLookup runs a short lookup search, remembering the answer under key
+func (s *Searcher) tickerLookup(ctx context.Context, key, prompt string) string {
+	if !s.tickers.lookup {
+		return ""
+	}
+
+	s.tickers.mu.Lock()
+	answer, ok := s.tickers.lookups[key]
+	s.tickers.mu.Unlock()
+	if ok {
+		return answer
+	}
+
+	req := &types.PerplexityRequest{
+		Model:       types.ModelSonar,
+		Messages:    []types.Message{{Role: "user", Content: prompt}},
+		MaxTokens:   16,
+		Temperature: 0,
+	}
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil || len(resp.Choices) == 0 {
+		// Don't remember failures; the next call may succeed
+		return ""
+	}
+
+	answer = strings.TrimSpace(resp.Choices[0].Message.Content)
+	s.tickers.mu.Lock()
+	s.tickers.lookups[key] = answer
+	s.tickers.mu.Unlock()
+	return answer
+}
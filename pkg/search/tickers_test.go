@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNormalizeCompany(t *testing.T) {
+	tests := map[string]string{
+		"Alphabet Inc.":              "alphabet",
+		"The Coca-Cola Company":      "coca cola",
+		"Berkshire Hathaway Class B": "berkshire hathaway",
+		"Johnson & Johnson":          "johnson & johnson",
+	}
+	for name, want := range tests {
+		if got := normalizeCompany(name); got != want {
+			t.Errorf("normalizeCompany(%q) mismatch: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestResolveTickerFromDataset(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		t.Errorf("Unexpected lookup search: %s", req.Messages[0].Content)
+		return textResponse(req.Model, "")
+	})
+	s.tickers = newTickerResolver(map[string][]string{"ACME": {"Acme Rockets", "Acme"}}, true)
+
+	params := &SearchParams{CompanyName: "Alphabet Inc."}
+	s.resolveTicker(context.Background(), params)
+	if params.Ticker != "GOOGL" || !strings.Contains(params.notes[0], "Alphabet Inc. → GOOGL (dataset)") {
+		t.Errorf("Name resolution mismatch: ticker %q, notes %v", params.Ticker, params.notes)
+	}
+
+	params = &SearchParams{Ticker: "acme"}
+	s.resolveTicker(context.Background(), params)
+	if params.CompanyName != "Acme Rockets" {
+		t.Errorf("Ticker resolution mismatch: got %q, want Acme Rockets", params.CompanyName)
+	}
+
+	params = &SearchParams{CompanyName: "Bitcoin", AssetClass: AssetCrypto}
+	s.resolveTicker(context.Background(), params)
+	if params.Ticker != "" {
+		t.Errorf("Non-equity asset should not be resolved: got %q", params.Ticker)
+	}
+}
+
+func TestResolveTickerLookup(t *testing.T) {
+	calls := 0
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		calls++
+		return textResponse(req.Model, "RIVN")
+	})
+	s.tickers = newTickerResolver(nil, true)
+
+	for i := 0; i < 2; i++ {
+		params := &SearchParams{CompanyName: "Rivian Automotive"}
+		s.resolveTicker(context.Background(), params)
+		if params.Ticker != "RIVN" || !strings.Contains(params.notes[0], "(lookup search)") {
+			t.Errorf("Lookup resolution mismatch: ticker %q, notes %v", params.Ticker, params.notes)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Lookup call count mismatch: got %d, want 1 (second lookup should be remembered)", calls)
+	}
+
+	s.tickers = newTickerResolver(nil, false)
+	params := &SearchParams{CompanyName: "Rivian Automotive"}
+	s.resolveTicker(context.Background(), params)
+	if params.Ticker != "" || calls != 1 {
+		t.Errorf("Lookup should be disabled: ticker %q, calls %d", params.Ticker, calls)
+	}
+}
@@ -0,0 +1,48 @@
+package search
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// location returns the time zone of a call: its own, else the configured one, or nil for
+// the server's zone
+func (s *Searcher) location(params *SearchParams) *time.Location {
+	if params.Timezone != "" {
+		if loc, err := time.LoadLocation(params.Timezone); err == nil {
+			return loc
+		}
+	}
+	return s.config().Location()
+}
+
+// now returns the current time in the call's time zone, so dates computed from it fall on
+// the user's calendar day rather than the server's
+func (s *Searcher) now(params *SearchParams) time.Time {
+	if loc := s.location(params); loc != nil {
+		return s.currentTime().In(loc)
+	}
+	return s.currentTime()
+}
+
+// currentTime returns the time from the searcher's clock, or time.Now when it has none
+func (s *Searcher) currentTime() time.Time {
+	if s.clock != nil {
+		return s.clock()
+	}
+	return time.Now()
+}
+
+// withTimezone asks for dates and times in the user's zone and tells the model the local
+// time there, so "today" and "yesterday" mean the user's days
+func withTimezone(req *types.PerplexityRequest, now time.Time) *types.PerplexityRequest {
+	withZone := *req
+	withZone.Messages = make([]types.Message, len(req.Messages))
+	copy(withZone.Messages, req.Messages)
+	last := len(withZone.Messages) - 1
+	withZone.Messages[last].Content += fmt.Sprintf("\n\nThe user's time zone is %s, where it is now %s. "+
+		"Give dates and times in that time zone.", now.Location(), now.Format("2006-01-02 15:04 MST"))
+	return &withZone
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestSearcherNow(t *testing.T) {
+	s := newTestSearcher(t, nil)
+	if loc := s.location(&SearchParams{}); loc != nil {
+		t.Errorf("Expected no time zone without configuration, got %v", loc)
+	}
+
+	s.config().Timezone = "Asia/Tokyo"
+	if got := s.now(&SearchParams{}).Location().String(); got != "Asia/Tokyo" {
+		t.Errorf("Expected the configured zone, got %s", got)
+	}
+	if got := s.now(&SearchParams{Timezone: "America/New_York"}).Location().String(); got != "America/New_York" {
+		t.Errorf("Expected the call's zone to win, got %s", got)
+	}
+}
+
+func TestSearchTimezone(t *testing.T) {
+	var prompt string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt = req.Messages[len(req.Messages)-1].Content
+		return textResponse("sonar", "It happened yesterday.")
+	})
+
+	result, err := s.Search(context.Background(), &SearchParams{Query: "What happened?", Timezone: "Europe/Berlin"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if !strings.Contains(prompt, "The user's time zone is Europe/Berlin") {
+		t.Errorf("Expected the time zone in the prompt, got %q", prompt)
+	}
+	if !strings.Contains(result, "Time zone: Europe/Berlin (dates computed as of ") {
+		t.Errorf("Expected a time zone note in the footer, got:\n%s", result)
+	}
+
+	if _, err := s.Search(context.Background(), &SearchParams{Query: "What happened?"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if strings.Contains(prompt, "time zone") {
+		t.Errorf("Expected no time zone instruction without one, got %q", prompt)
+	}
+}
+
+func TestTrendWindowsInTimezone(t *testing.T) {
+	// Late on October 31st in New York is already November 1st in UTC
+	now := time.Date(2026, 11, 1, 3, 0, 0, 0, time.UTC)
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows := trendWindows(now.In(ny), "month", 1)
+	if got := windows[0].end.Format(dateLayout); got != "2026-10-31" {
+		t.Errorf("Expected the window to end on the user's day, got %s", got)
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/prasanthmj/perplexity/pkg/models"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+const (
+	// defaultContextWindow is assumed for models without a known context window
+	defaultContextWindow = 127072
+	// searchContextReserve keeps room for the search results Perplexity adds to the prompt
+	searchContextReserve = 4000
+	// minCompletionTokens is the smallest answer budget worth sending a request for
+	minCompletionTokens = 256
+	// messageOverheadTokens covers the role and separators of each chat message
+	messageOverheadTokens = 4
+)
+
+// contextWindow returns the context length of model from the model registry
+func contextWindow(model string) int {
+	if m, ok := models.Default.Lookup(model); ok && m.ContextWindow > 0 {
+		return m.ContextWindow
+	}
+	return defaultContextWindow
+}
+
+// estimateTokens approximates how many tokens a BPE tokenizer such as tiktoken's produces
+// for text: about four characters per token for Latin-script words, one token per
+// character for Chinese, Japanese, and Korean, two characters per token for other
+// scripts, and one token per punctuation mark. Whitespace is folded into the next token.
+func estimateTokens(text string) int {
+	tokens := 0
+	ascii, other := 0, 0
+	flush := func() {
+		tokens += (ascii+3)/4 + (other+1)/2
+		ascii, other = 0, 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			ascii++
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			other++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// estimateRequestTokens approximates the prompt size of a request
+func estimateRequestTokens(req *types.PerplexityRequest) int {
+	tokens := 0
+	for _, message := range req.Messages {
+		tokens += messageOverheadTokens + estimateTokens(message.Content)
+	}
+	return tokens
+}
+
+// fitContext checks that the request's prompt, the search results Perplexity adds, and the
+// answer fit in the model's context window, lowering max_tokens when only the answer budget
+// is too large. It fails when even a minimal answer would not fit.
+func fitContext(req *types.PerplexityRequest, params *SearchParams) error {
+	window := contextWindow(req.Model)
+	prompt := estimateRequestTokens(req)
+	available := window - searchContextReserve - prompt
+
+	if available < minCompletionTokens {
+		return fmt.Errorf("request does not fit in the %s context window: the prompt is about %d tokens, "+
+			"%d are reserved for search results, and at least %d are needed for the answer, but the model accepts %d. "+
+			"Shorten the query or the context documents",
+			req.Model, prompt, searchContextReserve, minCompletionTokens, window)
+	}
+	if req.MaxTokens > available {
+		params.addNote(fmt.Sprintf("Token budget: prompt is about %d tokens, so max_tokens was lowered from %d to %d to fit the %d-token %s context window",
+			prompt, req.MaxTokens, available, window, req.Model))
+		req.MaxTokens = available
+	}
+	return nil
+}
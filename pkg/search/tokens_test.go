@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"hello", 1, 2},
+		{"The quick brown fox jumps over the lazy dog.", 9, 13},
+		{"東京の天気", 5, 5},
+		{"Привет, как дела?", 5, 10},
+		{strings.Repeat("word ", 1000), 1000, 1000},
+	}
+	for _, tt := range tests {
+		if got := estimateTokens(tt.text); got < tt.min || got > tt.max {
+			t.Errorf("estimateTokens(%q) = %d, want %d-%d", tt.text, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestFitContext(t *testing.T) {
+	t.Run("fits", func(t *testing.T) {
+		req := &types.PerplexityRequest{Model: types.ModelSonar, MaxTokens: 1024, Messages: []types.Message{{Role: "user", Content: "short question"}}}
+		params := &SearchParams{}
+		if err := fitContext(req, params); err != nil {
+			t.Fatalf("fitContext failed: %v", err)
+		}
+		if req.MaxTokens != 1024 || len(params.notes) != 0 {
+			t.Errorf("Expected request unchanged, got max_tokens %d and notes %v", req.MaxTokens, params.notes)
+		}
+	})
+
+	t.Run("lowers max_tokens", func(t *testing.T) {
+		prompt := strings.Repeat("word ", 120000)
+		req := &types.PerplexityRequest{Model: types.ModelSonar, MaxTokens: 8000, Messages: []types.Message{{Role: "user", Content: prompt}}}
+		params := &SearchParams{}
+		if err := fitContext(req, params); err != nil {
+			t.Fatalf("fitContext failed: %v", err)
+		}
+		if want := 127072 - searchContextReserve - 120000 - messageOverheadTokens; req.MaxTokens != want {
+			t.Errorf("Expected max_tokens %d, got %d", want, req.MaxTokens)
+		}
+		if len(params.notes) != 1 || !strings.Contains(params.notes[0], "max_tokens was lowered from 8000") {
+			t.Errorf("Expected a token budget note, got %v", params.notes)
+		}
+	})
+
+	t.Run("larger window", func(t *testing.T) {
+		prompt := strings.Repeat("word ", 150000)
+		req := &types.PerplexityRequest{Model: types.ModelSonarPro, MaxTokens: 1024, Messages: []types.Message{{Role: "user", Content: prompt}}}
+		if err := fitContext(req, &SearchParams{}); err != nil {
+			t.Errorf("Expected sonar-pro's window to fit the prompt: %v", err)
+		}
+	})
+}
+
+func TestSearchRejectsOversizedPrompt(t *testing.T) {
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		t.Fatal("Unexpected API call for an oversized prompt")
+		return nil
+	})
+	s.config().MaxDocumentBytes = 0
+
+	params := &SearchParams{
+		Query:     "summarize",
+		Documents: []Document{{Name: "big.txt", Content: strings.Repeat("word ", 130000)}},
+	}
+	_, err := s.SearchWithContext(context.Background(), params)
+	if err == nil || !strings.Contains(err.Error(), "does not fit in the sonar context window: the prompt is about 130") {
+		t.Errorf("Expected context window error with measured sizes, got %v", err)
+	}
+}
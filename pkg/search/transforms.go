@@ -0,0 +1,284 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// transform rewrites answer text; now anchors any date arithmetic
+type transform func(content string, now time.Time) string
+
+// Built-in transform names
+const (
+	TransformNormalizeTables    = "normalize_tables"
+	TransformStripReasoning     = "strip_reasoning"
+	TransformCollapseWhitespace = "collapse_whitespace"
+	TransformAbsoluteDates      = "absolute_dates"
+)
+
+// transforms is the registry of built-in answer transforms
+var transforms = map[string]transform{
+	TransformNormalizeTables:    normalizeTables,
+	TransformStripReasoning:     stripReasoning,
+	TransformCollapseWhitespace: collapseWhitespace,
+	TransformAbsoluteDates:      absoluteDates,
+}
+
+// ValidateTransforms checks that every name refers to a built-in transform
+func ValidateTransforms(names []string) error {
+	for _, name := range names {
+		if _, ok := transforms[name]; !ok {
+			known := make([]string, 0, len(transforms))
+			for k := range transforms {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown transform '%s'. Available: %s", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// applyTransforms returns a copy of resp with the named transforms applied to its answer, in order
+func applyTransforms(resp *types.PerplexityResponse, names []string, now time.Time) *types.PerplexityResponse {
+	if len(names) == 0 || len(resp.Choices) == 0 {
+		return resp
+	}
+
+	transformed := *resp
+	transformed.Choices = make([]types.Choice, len(resp.Choices))
+	copy(transformed.Choices, resp.Choices)
+
+	content := transformed.Choices[0].Message.Content
+	for _, name := range names {
+		if fn, ok := transforms[name]; ok {
+			content = fn(content, now)
+		}
+	}
+	transformed.Choices[0].Message.Content = content
+	return &transformed
+}
+
+var reasoningPattern = regexp.MustCompile(`(?s)<think>(.*?)</think>\s*`)
+
+// stripReasoning removes <think> reasoning traces emitted by reasoning models
+func stripReasoning(content string, _ time.Time) string {
+	return reasoningPattern.ReplaceAllString(content, "")
+}
+
+// splitReasoning separates the <think> traces of content from the answer, returning the
+// answer and the traces in order
+func splitReasoning(content string) (string, []string) {
+	var traces []string
+	for _, match := range reasoningPattern.FindAllStringSubmatch(content, -1) {
+		if trace := strings.TrimSpace(match[1]); trace != "" {
+			traces = append(traces, trace)
+		}
+	}
+	return reasoningPattern.ReplaceAllString(content, ""), traces
+}
+
+var innerSpacePattern = regexp.MustCompile(`(\S)[ \t]{2,}`)
+
+// collapseWhitespace trims trailing spaces, collapses runs of inner spaces and blank lines,
+// and leaves fenced code blocks untouched
+func collapseWhitespace(content string, _ time.Time) string {
+	var out []string
+	inFence := false
+	blank := 0
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if inFence {
+			out = append(out, line)
+			blank = 0
+			continue
+		}
+
+		line = innerSpacePattern.ReplaceAllString(strings.TrimRight(line, " \t"), "$1 ")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+var separatorCellPattern = regexp.MustCompile(`^:?-+:?$`)
+
+// normalizeTables rewrites markdown tables with consistent spacing and column counts,
+// inserting a header separator row where one is missing
+func normalizeTables(content string, _ time.Time) string {
+	lines := strings.Split(content, "\n")
+	var out []string
+
+	for i := 0; i < len(lines); {
+		if !isTableLine(lines[i]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+
+		var rows [][]string
+		for ; i < len(lines) && isTableLine(lines[i]); i++ {
+			rows = append(rows, tableCells(lines[i]))
+		}
+		if len(rows) < 2 {
+			out = append(out, "| "+strings.Join(rows[0], " | ")+" |")
+			continue
+		}
+		out = append(out, formatTable(rows)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// isTableLine reports whether a line looks like a markdown table row
+func isTableLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "|") && strings.Count(trimmed, "|") >= 2
+}
+
+// tableCells splits a table row into trimmed cells, respecting escaped pipes
+func tableCells(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	trimmed = strings.TrimPrefix(trimmed, "|")
+	if strings.HasSuffix(trimmed, "|") && !strings.HasSuffix(trimmed, `\|`) {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case trimmed[i] == '\\' && i+1 < len(trimmed) && trimmed[i+1] == '|':
+			cell.WriteString(`\|`)
+			i++
+		case trimmed[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(trimmed[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isSeparatorRow reports whether every cell is a ---, :--, --: or :-: marker
+func isSeparatorRow(cells []string) bool {
+	for _, cell := range cells {
+		if !separatorCellPattern.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTable renders parsed rows with a separator after the header and every row padded to the widest
+func formatTable(rows [][]string) []string {
+	header := rows[0]
+	var separator []string
+	body := rows[1:]
+	if isSeparatorRow(rows[1]) {
+		separator = rows[1]
+		body = rows[2:]
+	}
+
+	columns := len(header)
+	for _, row := range body {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+
+	pad := func(cells []string, filler string) []string {
+		padded := append([]string{}, cells...)
+		for len(padded) < columns {
+			padded = append(padded, filler)
+		}
+		return padded
+	}
+
+	out := []string{"| " + strings.Join(pad(header, ""), " | ") + " |"}
+	out = append(out, "|"+strings.Join(pad(separator, "---"), "|")+"|")
+	for _, row := range body {
+		out = append(out, "| "+strings.Join(pad(row, ""), " | ")+" |")
+	}
+	return out
+}
+
+var (
+	dayWordPattern      = regexp.MustCompile(`(?i)\b(today|yesterday|tomorrow)\b`)
+	relativeYearPattern = regexp.MustCompile(`(?i)\b(this|last|next) year\b`)
+	agoPattern          = regexp.MustCompile(`(?i)\b(\d+) (day|week|month|year)s? ago\b`)
+)
+
+// absoluteDates annotates relative date phrases with the absolute date they refer to
+func absoluteDates(content string, now time.Time) string {
+	content = annotateMatches(content, dayWordPattern, func(m []string) string {
+		switch strings.ToLower(m[1]) {
+		case "yesterday":
+			return now.AddDate(0, 0, -1).Format("2006-01-02")
+		case "tomorrow":
+			return now.AddDate(0, 0, 1).Format("2006-01-02")
+		default:
+			return now.Format("2006-01-02")
+		}
+	})
+
+	content = annotateMatches(content, relativeYearPattern, func(m []string) string {
+		offset := map[string]int{"this": 0, "last": -1, "next": 1}[strings.ToLower(m[1])]
+		return strconv.Itoa(now.Year() + offset)
+	})
+
+	return annotateMatches(content, agoPattern, func(m []string) string {
+		n, _ := strconv.Atoi(m[1])
+		switch strings.ToLower(m[2]) {
+		case "day":
+			return now.AddDate(0, 0, -n).Format("2006-01-02")
+		case "week":
+			return now.AddDate(0, 0, -7*n).Format("2006-01-02")
+		case "month":
+			return now.AddDate(0, -n, 0).Format("January 2006")
+		default:
+			return strconv.Itoa(now.Year() - n)
+		}
+	})
+}
+
+// annotateMatches appends " (value)" after each match, skipping matches already followed by a parenthesis
+func annotateMatches(content string, pattern *regexp.Regexp, value func(match []string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		end := loc[1]
+		if strings.HasPrefix(content[end:], " (") {
+			continue
+		}
+
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = content[loc[2*i]:loc[2*i+1]]
+			}
+		}
+
+		b.WriteString(content[last:end])
+		fmt.Fprintf(&b, " (%s)", value(match))
+		last = end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestTransforms(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		fn      transform
+		content string
+		want    string
+	}{
+		{
+			name:    "strip reasoning",
+			fn:      stripReasoning,
+			content: "<think>\nLet me work this out.\n</think>\n\nThe answer is 42.",
+			want:    "The answer is 42.",
+		},
+		{
+			name:    "collapse whitespace",
+			fn:      collapseWhitespace,
+			content: "First  line   here.  \n\n\n\nSecond line.\n```\nkeep    this\n\n\n```\n",
+			want:    "First line here.\n\nSecond line.\n```\nkeep    this\n\n\n```",
+		},
+		{
+			name:    "normalize tables",
+			fn:      normalizeTables,
+			content: "Intro\n|Name|Value|\n| a |1|\n|b|2|extra|\nOutro",
+			want:    "Intro\n| Name | Value |  |\n|---|---|---|\n| a | 1 |  |\n| b | 2 | extra |\nOutro",
+		},
+		{
+			name:    "normalize tables keeps alignment",
+			fn:      normalizeTables,
+			content: "|A|B|\n|:--|--:|\n|x \\| y|z|",
+			want:    "| A | B |\n|:--|--:|\n| x \\| y | z |",
+		},
+		{
+			name:    "absolute dates",
+			fn:      absoluteDates,
+			content: "Released yesterday, patched 3 days ago, up 5% this year and announced 2 months ago. Today (2026-10-18) is noted.",
+			want:    "Released yesterday (2026-10-17), patched 3 days ago (2026-10-15), up 5% this year (2026) and announced 2 months ago (August 2026). Today (2026-10-18) is noted.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.content, now); got != tt.want {
+				t.Errorf("Transform mismatch:\ngot  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTransformsInOrder(t *testing.T) {
+	resp := textResponse(types.ModelSonarReasoning, "<think>draft</think>\n\nShipped   today.")
+	got := applyTransforms(resp, []string{TransformStripReasoning, TransformCollapseWhitespace, TransformAbsoluteDates},
+		time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC))
+
+	if want := "Shipped today (2026-10-18)."; got.Choices[0].Message.Content != want {
+		t.Errorf("Content mismatch: got %q, want %q", got.Choices[0].Message.Content, want)
+	}
+	if resp.Choices[0].Message.Content == got.Choices[0].Message.Content {
+		t.Error("applyTransforms should not modify the original response")
+	}
+}
+
+func TestNewSearcherRejectsUnknownTransform(t *testing.T) {
+	cfg := testConfig()
+	cfg.Transforms = config.TransformsConfig{Tools: map[string][]string{"perplexity_search": {"shout"}}}
+	if _, err := NewSearcher(cfg); err == nil {
+		t.Error("Expected error for unknown transform, got nil")
+	}
+}
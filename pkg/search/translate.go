@@ -0,0 +1,84 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// TranslateResult translates the answer of a cached result into language and caches the
+// translation as a child result linked to its source
+func (s *Searcher) TranslateResult(ctx context.Context, resultID, language string) (string, error) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		return "", fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
+	}
+
+	content, err := s.readResult(resultID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get previous result: %w", err)
+	}
+
+	body := strings.TrimSpace(answerBody(content))
+	if body == "" {
+		return "", fmt.Errorf("result '%s' has no answer text to translate", resultID)
+	}
+
+	// Translations run longer than the source in many languages; allow roughly one token per three bytes
+	maxTokens := s.config().MaxTokens
+	if estimate := len(body)/3 + 256; estimate > maxTokens {
+		maxTokens = estimate
+	}
+
+	model := s.config().TranslationModel
+	if model == "" {
+		model = types.ModelSonar
+	}
+
+	req := &types.PerplexityRequest{
+		Model: model,
+		Messages: []types.Message{
+			{
+				Role: "user",
+				Content: fmt.Sprintf("Translate the following markdown into %s. Preserve the markdown formatting, [n] citation markers, "+
+					"URLs, numbers, and proper nouns. Reply with the translation only, without commentary.\n\n%s", language, body),
+			},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: 0,
+	}
+
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("translation returned no content")
+	}
+
+	params := &SearchParams{
+		Query:          fmt.Sprintf("Translation of result %s into %s", resultID, language),
+		SearchType:     "translation",
+		Model:          req.Model,
+		Language:       language,
+		SourceResultID: resultID,
+	}
+	return s.saveWithCache(formatTranslation(resultID, language, content, resp.Choices[0].Message.Content), params), nil
+}
+
+// formatTranslation renders the translated answer followed by the source's original source sections
+func formatTranslation(resultID, language, original, translated string) string {
+	content := fmt.Sprintf("# Translation of Result %s (%s)\n\n", resultID, language)
+	content += strings.TrimSpace(translated)
+
+	// Keep the Source URLs and other appended sections from the original untranslated
+	for _, header := range appendedSectionHeaders {
+		if i := strings.Index(original, header); i >= 0 {
+			content += original[i:]
+			break
+		}
+	}
+	return content
+}
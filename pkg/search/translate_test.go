@@ -0,0 +1,63 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestTranslateResult(t *testing.T) {
+	var prompt string
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		prompt = req.Messages[0].Content
+		return textResponse(req.Model, "Paris ist die Hauptstadt Frankreichs[1].")
+	})
+	s.config().ResultsRootFolder = t.TempDir()
+
+	id, err := cache.SaveResult(s.config().ResultsRootFolder, "paris facts", "general", types.ModelSonar, verifyFixture, nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+
+	if _, err := s.TranslateResult(context.Background(), id, "German"); err != nil {
+		t.Fatalf("TranslateResult failed: %v", err)
+	}
+	if !strings.Contains(prompt, "into German") || strings.Contains(prompt, "## Source URLs") {
+		t.Errorf("Prompt should ask for German and exclude source sections:\n%s", prompt)
+	}
+
+	var childID string
+	queries, _ := cache.ListPreviousQueries(s.config().ResultsRootFolder)
+	for _, q := range queries {
+		if q.SearchType == "translation" {
+			childID = q.UniqueID
+		}
+	}
+	if childID == "" {
+		t.Fatal("Expected translation to be cached")
+	}
+
+	translation, err := cache.GetPreviousResult(s.config().ResultsRootFolder, childID)
+	if err != nil {
+		t.Fatalf("GetPreviousResult failed: %v", err)
+	}
+	expected := []string{"Paris ist die Hauptstadt Frankreichs[1].", "## Source URLs\n1. https://example.com/paris"}
+	for _, want := range expected {
+		if !strings.Contains(translation, want) {
+			t.Errorf("Translation missing %q:\n%s", want, translation)
+		}
+	}
+
+	metadata, err := os.ReadFile(filepath.Join(s.config().ResultsRootFolder, childID, "metadata.yaml"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if !strings.Contains(string(metadata), "source_result_id: "+id) {
+		t.Errorf("Translation metadata should link to %s:\n%s", id, metadata)
+	}
+}
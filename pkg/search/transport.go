@@ -0,0 +1,65 @@
+package search
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/prasanthmj/perplexity/pkg/config"
+)
+
+// maxIdleConnsPerHost keeps enough idle connections for concurrent tool calls to reuse
+// connections to the API host instead of opening new ones
+const maxIdleConnsPerHost = 16
+
+// newTransport builds the HTTP transport shared by every client of a Searcher, honouring the
+// configured proxy and TLS settings. An http.Transport is safe for concurrent use, so one
+// connection pool serves all concurrent tool calls.
+func newTransport(cfg *config.Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		// An explicit proxy replaces HTTP_PROXY/HTTPS_PROXY from the environment
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CABundle == "" && cfg.TLSMinVersion == 0 {
+		return transport, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: cfg.TLSMinVersion}
+	if cfg.CABundle != "" {
+		pool, err := loadCABundle(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// loadCABundle adds the PEM certificates in path to the system root pool
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle '%s' contains no PEM certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestNewTransportDefaults(t *testing.T) {
+	transport, err := newTransport(testConfig())
+	if err != nil {
+		t.Fatalf("newTransport failed: %v", err)
+	}
+	if transport == nil {
+		t.Fatal("Expected a shared transport when nothing is configured")
+	}
+	if transport.MaxIdleConnsPerHost != maxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost mismatch: got %d, want %d", transport.MaxIdleConnsPerHost, maxIdleConnsPerHost)
+	}
+}
+
+func TestNewTransportProxyAndTLS(t *testing.T) {
+	cfg := testConfig()
+	cfg.ProxyURL = "socks5://proxy.internal:1080"
+	cfg.TLSMinVersion = tls.VersionTLS13
+
+	transport, err := newTransport(cfg)
+	if err != nil {
+		t.Fatalf("newTransport failed: %v", err)
+	}
+
+	req, _ := http.NewRequest("POST", baseURL, nil)
+	proxy, err := transport.Proxy(req)
+	if err != nil || proxy == nil || proxy.String() != cfg.ProxyURL {
+		t.Errorf("Proxy mismatch: got %v (%v), want %s", proxy, err, cfg.ProxyURL)
+	}
+	if transport.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion mismatch: got %x, want %x", transport.TLSClientConfig.MinVersion, tls.VersionTLS13)
+	}
+}
+
+func TestCABundleTrustsPrivateServer(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(textResponse(types.ModelSonar, "ok"))
+	}))
+	defer srv.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	cfg := testConfig()
+	cfg.CABundle = bundle
+	s, err := NewSearcher(cfg)
+	if err != nil {
+		t.Fatalf("NewSearcher failed: %v", err)
+	}
+	s.client.baseURL = srv.URL
+
+	if _, err := s.client.callAPI(context.Background(), &types.PerplexityRequest{Model: types.ModelSonar}); err != nil {
+		t.Errorf("callAPI with CA bundle failed: %v", err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	cfg.CABundle = empty
+	if _, err := NewSearcher(cfg); err == nil {
+		t.Error("Expected error for CA bundle without certificates, got nil")
+	}
+}
@@ -0,0 +1,124 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// maxTripDays bounds the itinerary length; longer trips are planned in parts
+const maxTripDays = 30
+
+// parseDateRange parses optional YYYY-MM-DD start and end dates, naming the parameters in errors.
+// Either date may be empty; when both are given end must not be before start.
+func parseDateRange(startName, start, endName, end string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if start != "" {
+		if from, err = time.Parse(dateLayout, start); err != nil {
+			return from, to, fmt.Errorf("invalid %s '%s'. Use YYYY-MM-DD", startName, start)
+		}
+	}
+	if end != "" {
+		if to, err = time.Parse(dateLayout, end); err != nil {
+			return from, to, fmt.Errorf("invalid %s '%s'. Use YYYY-MM-DD", endName, end)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, fmt.Errorf("%s must not be before %s", endName, startName)
+	}
+	return from, to, nil
+}
+
+// tripDays returns the number of days in a trip, counting both the first and last day
+func tripDays(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+// ValidateTravelParams checks the travel-specific parameters
+func ValidateTravelParams(params *SearchParams) error {
+	if strings.TrimSpace(params.Destination) == "" {
+		return fmt.Errorf("destination is required")
+	}
+	from, to, err := parseDateRange("travel_date_start", params.TravelDateStart, "travel_date_end", params.TravelDateEnd)
+	if err != nil {
+		return err
+	}
+	if !from.IsZero() && !to.IsZero() && tripDays(from, to) > maxTripDays {
+		return fmt.Errorf("trips longer than %d days are not supported; plan them in parts", maxTripDays)
+	}
+	return nil
+}
+
+// itineraryInstructions describes the answer layout, with one day heading per trip day when the dates are known
+func itineraryInstructions(params *SearchParams) string {
+	var b strings.Builder
+	b.WriteString("Answer as a travel itinerary in markdown with these sections:\n")
+	if params.Origin != "" {
+		b.WriteString("## Getting There: routes and typical journey times and prices from the origin\n")
+	}
+
+	from, to, _ := parseDateRange("travel_date_start", params.TravelDateStart, "travel_date_end", params.TravelDateEnd)
+	switch {
+	case !from.IsZero() && !to.IsZero():
+		fmt.Fprintf(&b, "## Itinerary: one \"### Day N (Weekday, YYYY-MM-DD)\" heading per day for all %d days, from %s to %s, each with morning, afternoon, and evening plans\n",
+			tripDays(from, to), from.Format(dateLayout), to.Format(dateLayout))
+	default:
+		b.WriteString("## Itinerary: a suggested trip length, then one \"### Day N\" heading per day with morning, afternoon, and evening plans\n")
+	}
+
+	b.WriteString("## Where to Stay: areas and example accommodation with price ranges\n")
+	b.WriteString("## Practical Notes: entry requirements, weather, local transport, and events, closures, or holidays during the trip\n")
+	b.WriteString("Give prices in the local currency with approximate conversions, and cite sources for opening hours, prices, and requirements.")
+	return b.String()
+}
+
+// TravelSearch plans a trip, returning an itinerary-style answer
+func (s *Searcher) TravelSearch(ctx context.Context, params *SearchParams) (string, error) {
+	if err := ValidateTravelParams(params); err != nil {
+		return "", err
+	}
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	// Search from the destination's point of view, and keep prices and opening hours current
+	if params.Location == "" {
+		params.Location = params.Destination
+	}
+	if params.SearchRecencyFilter == "" && params.DateRangeStart == "" && params.DateRangeEnd == "" {
+		params.SearchRecencyFilter = "year"
+	}
+
+	// Build request
+	req := s.buildRequest(params, s.config().DefaultModel)
+
+	// Scaffold the query with the travel-specific parameters
+	contextAdditions := []string{"Travel planning", fmt.Sprintf("Destination: %s", params.Destination)}
+	if params.Origin != "" {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Origin: %s", params.Origin))
+	}
+	switch {
+	case params.TravelDateStart != "" && params.TravelDateEnd != "":
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Dates: %s to %s", params.TravelDateStart, params.TravelDateEnd))
+	case params.TravelDateStart != "":
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Arriving: %s", params.TravelDateStart))
+	case params.TravelDateEnd != "":
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Leaving by: %s", params.TravelDateEnd))
+	}
+	if len(params.Constraints) > 0 {
+		contextAdditions = append(contextAdditions, fmt.Sprintf("Constraints: %s", strings.Join(params.Constraints, "; ")))
+	}
+	req.Messages[0].Content = fmt.Sprintf("[%s] %s\n\n%s", strings.Join(contextAdditions, ", "), params.Query, itineraryInstructions(params))
+
+	// Make API call
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	return s.formatResponseWithCache(resp, params), nil
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestValidateTravelParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  SearchParams
+		wantErr string
+	}{
+		{"destination only", SearchParams{Destination: "Lisbon"}, ""},
+		{"missing destination", SearchParams{TravelDateStart: "2026-05-01"}, "destination is required"},
+		{"bad date", SearchParams{Destination: "Lisbon", TravelDateEnd: "May 5"}, "invalid travel_date_end"},
+		{"reversed", SearchParams{Destination: "Lisbon", TravelDateStart: "2026-05-05", TravelDateEnd: "2026-05-01"}, "must not be before"},
+		{"too long", SearchParams{Destination: "Lisbon", TravelDateStart: "2026-05-01", TravelDateEnd: "2026-06-30"}, "longer than 30 days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTravelParams(&tt.params)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Error mismatch: got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTravelSearch(t *testing.T) {
+	var sent *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		sent = req
+		return textResponse(req.Model, "## Itinerary\n### Day 1 (Friday, 2026-05-01)")
+	})
+
+	params := &SearchParams{
+		Query:           "food-focused long weekend",
+		SearchType:      "travel",
+		Origin:          "Madrid",
+		Destination:     "Lisbon",
+		TravelDateStart: "2026-05-01",
+		TravelDateEnd:   "2026-05-03",
+		Constraints:     []string{"vegetarian", "no car"},
+	}
+	if _, err := s.TravelSearch(context.Background(), params); err != nil {
+		t.Fatalf("TravelSearch failed: %v", err)
+	}
+
+	if sent.Location != "Lisbon" || sent.SearchRecencyFilter != "year" {
+		t.Errorf("Request mismatch: location %q, recency %q", sent.Location, sent.SearchRecencyFilter)
+	}
+	prompt := sent.Messages[0].Content
+	for _, want := range []string{
+		"[Travel planning, Destination: Lisbon, Origin: Madrid, Dates: 2026-05-01 to 2026-05-03, Constraints: vegetarian; no car] food-focused long weekend",
+		"## Getting There",
+		"for all 3 days, from 2026-05-01 to 2026-05-03",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	params = &SearchParams{Query: "a week of hiking", Destination: "Patagonia", SearchRecencyFilter: "month"}
+	if _, err := s.TravelSearch(context.Background(), params); err != nil {
+		t.Fatalf("TravelSearch failed: %v", err)
+	}
+	if sent.SearchRecencyFilter != "month" || strings.Contains(sent.Messages[0].Content, "## Getting There") {
+		t.Errorf("Explicit recency should be kept and Getting There skipped without an origin")
+	}
+}
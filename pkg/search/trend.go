@@ -0,0 +1,252 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// Trend window lengths accepted by TrendSearch
+const (
+	TrendWeek    = "week"
+	TrendMonth   = "month"
+	TrendQuarter = "quarter"
+	TrendYear    = "year"
+)
+
+// Limits on the number of windows in a trend; each window is one API call
+const (
+	DefaultTrendWindows = 6
+	MaxTrendWindows     = 12
+)
+
+// trendSliceMaxTokens keeps each window's summary short; the synthesis does the detailed writing
+const trendSliceMaxTokens = 300
+
+// maxTrendSources limits the sources listed under each window in the timeline
+const maxTrendSources = 3
+
+// Sentiments a window summary can report
+var trendSentiments = []string{"positive", "negative", "mixed", "neutral"}
+
+// trendWindow is one date window of a trend
+type trendWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// label names the window by its date range
+func (w trendWindow) label() string {
+	return fmt.Sprintf("%s to %s", w.start.Format(dateLayout), w.end.Format(dateLayout))
+}
+
+// trendSlice holds the outcome of the search for one window
+type trendSlice struct {
+	window    trendWindow
+	sentiment string
+	summary   string
+	sources   []string
+	err       error
+}
+
+// ValidateTrendParams checks the window length and count
+func ValidateTrendParams(period string, windows int) error {
+	switch period {
+	case TrendWeek, TrendMonth, TrendQuarter, TrendYear:
+	default:
+		return fmt.Errorf("invalid period '%s'. Use %s, %s, %s, or %s", period, TrendWeek, TrendMonth, TrendQuarter, TrendYear)
+	}
+	if windows < 2 || windows > MaxTrendWindows {
+		return fmt.Errorf("windows must be between 2 and %d, got %d", MaxTrendWindows, windows)
+	}
+	return nil
+}
+
+// trendWindows returns consecutive windows of the given length ending today, oldest first
+func trendWindows(now time.Time, period string, count int) []trendWindow {
+	back := func(k int) time.Time {
+		switch period {
+		case TrendWeek:
+			return now.AddDate(0, 0, -7*k)
+		case TrendQuarter:
+			return now.AddDate(0, -3*k, 0)
+		case TrendYear:
+			return now.AddDate(-k, 0, 0)
+		default:
+			return now.AddDate(0, -k, 0)
+		}
+	}
+
+	windows := make([]trendWindow, count)
+	for i := range windows {
+		windows[i] = trendWindow{
+			start: back(count-i).AddDate(0, 0, 1),
+			end:   back(count - 1 - i),
+		}
+	}
+	return windows
+}
+
+// leadingField reads an answer that starts with a "Label: value" line, returning the value and
+// the rest of the answer. ok is false when the first line is not the labeled field.
+func leadingField(answer, label string) (value, rest string, ok bool) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(answer), "\n")
+	name, value, found := strings.Cut(first, ":")
+	if !found || !strings.EqualFold(strings.Trim(strings.TrimSpace(name), "*"), label) {
+		return "", strings.TrimSpace(answer), false
+	}
+	return strings.Trim(strings.TrimSpace(value), "* "), strings.TrimSpace(rest), true
+}
+
+// parseSentiment reads the "Sentiment: X" line a window summary starts with, returning the
+// sentiment and the rest of the summary
+func parseSentiment(answer string) (string, string) {
+	value, rest, ok := leadingField(answer, "sentiment")
+	if !ok {
+		return "unclear", rest
+	}
+
+	value = strings.ToLower(strings.TrimRight(value, ". "))
+	for _, sentiment := range trendSentiments {
+		if strings.HasPrefix(value, sentiment) {
+			return sentiment, rest
+		}
+	}
+	return "unclear", rest
+}
+
+// TrendSearch runs the query once per date window and synthesizes how coverage and sentiment
+// changed across them, returning a timeline followed by the analysis
+func (s *Searcher) TrendSearch(ctx context.Context, params *SearchParams, period string, windows int) (string, error) {
+	if err := ValidateTrendParams(period, windows); err != nil {
+		return "", err
+	}
+	if params.Model == "" {
+		params.Model = types.ModelSonarPro
+	}
+
+	results := s.searchTrendWindows(ctx, params, trendWindows(s.now(params), period, windows))
+	failed := 0
+	for _, slice := range results {
+		if slice.err != nil {
+			failed++
+		}
+	}
+	if failed == len(results) {
+		return "", fmt.Errorf("all %d trend windows failed: %v", len(results), results[0].err)
+	}
+	if failed > 0 {
+		params.addNote(fmt.Sprintf("Trend: %d of %d window searches failed; the analysis covers the others", failed, len(results)))
+	}
+
+	// The synthesis searches the whole range and works from the window summaries
+	params.SearchRecencyFilter = ""
+	params.DateRangeStart = results[0].window.start.Format(dateLayout)
+	params.DateRangeEnd = results[len(results)-1].window.end.Format(dateLayout)
+	req := s.buildRequest(params, s.config().DefaultModel)
+	req.Messages[0].Content = fmt.Sprintf("[Trend analysis, %d %s windows from %s to %s] %s\n\nFindings per window, oldest first:\n%s\n"+
+		"Describe how coverage and sentiment changed over time: when attention rose or fell, turning points and what caused them, "+
+		"and where things stand now. Refer to windows by their dates and cite sources.",
+		len(results), period, params.DateRangeStart, params.DateRangeEnd, params.Query, trendFindings(results))
+
+	resp, err := s.execute(ctx, req, params)
+	if err != nil {
+		return "", err
+	}
+
+	content := formatTimeline(params.Query, results) + "\n\n## Trend Analysis\n\n" + s.formatResponse(resp)
+	return s.saveWithCache(content+formatNotes(params.notes), params), nil
+}
+
+// searchTrendWindows runs the window searches concurrently as batch work
+func (s *Searcher) searchTrendWindows(ctx context.Context, params *SearchParams, windows []trendWindow) []trendSlice {
+	batchCtx := ratelimit.WithPriority(ctx, ratelimit.PriorityBatch)
+
+	results := make([]trendSlice, len(windows))
+	var wg sync.WaitGroup
+	for i, window := range windows {
+		wg.Add(1)
+		go func(i int, window trendWindow) {
+			defer wg.Done()
+
+			// Each window gets its own copy so request building never shares state
+			runParams := *params
+			runParams.notes = nil
+			runParams.Model = types.ModelSonar
+			runParams.SearchRecencyFilter = ""
+			runParams.DateRangeStart = window.start.Format(dateLayout)
+			runParams.DateRangeEnd = window.end.Format(dateLayout)
+			maxTokens := trendSliceMaxTokens
+			runParams.MaxTokens = &maxTokens
+
+			req := s.buildRequest(&runParams, types.ModelSonar)
+			req.Messages[0].Content = fmt.Sprintf("[Coverage from %s only] %s\n\nStart with one line \"Sentiment: positive, negative, mixed, or neutral\" "+
+				"describing the coverage in this period, then summarize what was reported in this period only in three to five bullet points.",
+				window.label(), params.Query)
+
+			resp, err := s.callAnonymized(batchCtx, req)
+			if err != nil {
+				results[i] = trendSlice{window: window, err: err}
+				return
+			}
+			resp = applyTransforms(resp, runParams.Transforms, s.now(&runParams))
+			if len(resp.Choices) == 0 {
+				results[i] = trendSlice{window: window, err: fmt.Errorf("no response from Perplexity API")}
+				return
+			}
+
+			_, sources, _ := normalizeCitations("", resp.Citations)
+			sentiment, summary := parseSentiment(resp.Choices[0].Message.Content)
+			results[i] = trendSlice{window: window, sentiment: sentiment, summary: summary, sources: sources}
+		}(i, window)
+	}
+	wg.Wait()
+	return results
+}
+
+// trendFindings lists the window summaries for the synthesis prompt
+func trendFindings(results []trendSlice) string {
+	var b strings.Builder
+	for _, slice := range results {
+		if slice.err != nil {
+			fmt.Fprintf(&b, "\n%s: search failed\n", slice.window.label())
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s (sentiment %s, %d source(s)):\n%s\n", slice.window.label(), slice.sentiment, len(slice.sources), slice.summary)
+	}
+	return b.String()
+}
+
+// formatTimeline renders the per-window table and summaries
+func formatTimeline(query string, results []trendSlice) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Trend: %s\n\n## Timeline\n| Period | Sentiment | Sources found |\n|---|---|---|\n", query)
+	for _, slice := range results {
+		if slice.err != nil {
+			fmt.Fprintf(&b, "| %s | n/a | n/a |\n", slice.window.label())
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d |\n", slice.window.label(), slice.sentiment, len(slice.sources))
+	}
+
+	for _, slice := range results {
+		fmt.Fprintf(&b, "\n### %s\n\n", slice.window.label())
+		if slice.err != nil {
+			fmt.Fprintf(&b, "**Error:** %v\n", slice.err)
+			continue
+		}
+		b.WriteString(slice.summary + "\n")
+		for i, source := range slice.sources {
+			if i == maxTrendSources {
+				break
+			}
+			fmt.Fprintf(&b, "- Source: %s\n", source)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+func TestTrendWindows(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	windows := trendWindows(now, TrendMonth, 3)
+	want := []string{"2026-07-19 to 2026-08-18", "2026-08-19 to 2026-09-18", "2026-09-19 to 2026-10-18"}
+	for i, w := range windows {
+		if w.label() != want[i] {
+			t.Errorf("Window %d mismatch: got %s, want %s", i, w.label(), want[i])
+		}
+	}
+
+	if got := trendWindows(now, TrendWeek, 2)[0].label(); got != "2026-10-05 to 2026-10-11" {
+		t.Errorf("Week window mismatch: got %s", got)
+	}
+}
+
+func TestValidateTrendParams(t *testing.T) {
+	if err := ValidateTrendParams(TrendQuarter, 4); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := ValidateTrendParams("decade", 4); err == nil {
+		t.Error("Expected error for invalid period")
+	}
+	if err := ValidateTrendParams(TrendMonth, MaxTrendWindows+1); err == nil {
+		t.Error("Expected error for too many windows")
+	}
+}
+
+func TestParseSentiment(t *testing.T) {
+	tests := []struct {
+		answer, sentiment, summary string
+	}{
+		{"Sentiment: Mixed.\n- Prices rose", "mixed", "- Prices rose"},
+		{"**Sentiment:** negative\n- Layoffs", "negative", "- Layoffs"},
+		{"- No sentiment line", "unclear", "- No sentiment line"},
+	}
+	for _, tt := range tests {
+		sentiment, summary := parseSentiment(tt.answer)
+		if sentiment != tt.sentiment || summary != tt.summary {
+			t.Errorf("parseSentiment(%q) mismatch: got %q, %q", tt.answer, sentiment, summary)
+		}
+	}
+}
+
+func TestTrendSearch(t *testing.T) {
+	var mu sync.Mutex
+	var windowRequests int
+	var synthesis *types.PerplexityRequest
+	s := newTestSearcher(t, func(req *types.PerplexityRequest) *types.PerplexityResponse {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasPrefix(req.Messages[len(req.Messages)-1].Content, "[Coverage from") {
+			windowRequests++
+			return textResponse(req.Model, "Sentiment: positive\n- Adoption grew", "https://news.example.com/a")
+		}
+		synthesis = req
+		return textResponse(req.Model, "Coverage grew steadily.", "https://news.example.com/b")
+	})
+
+	params := &SearchParams{Query: "remote work", SearchType: "trend"}
+	result, err := s.TrendSearch(context.Background(), params, TrendMonth, 3)
+	if err != nil {
+		t.Fatalf("TrendSearch failed: %v", err)
+	}
+
+	if windowRequests != 3 {
+		t.Errorf("Window request count mismatch: got %d, want 3", windowRequests)
+	}
+	if synthesis == nil || !strings.Contains(synthesis.Messages[0].Content, "(sentiment positive, 1 source(s))") {
+		t.Fatalf("Synthesis prompt missing window findings: %+v", synthesis)
+	}
+	if synthesis.DateRangeStart == "" || synthesis.DateRangeEnd != time.Now().Format(dateLayout) {
+		t.Errorf("Synthesis date range mismatch: %s to %s", synthesis.DateRangeStart, synthesis.DateRangeEnd)
+	}
+	for _, want := range []string{"# Trend: remote work", "| Period | Sentiment | Sources found |", "| positive | 1 |", "## Trend Analysis", "Coverage grew steadily."} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result missing %q:\n%s", want, result)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package search
 
+import (
+	"log/slog"
+
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
 // SearchParams represents strongly-typed search parameters
 type SearchParams struct {
 	// Common parameters
@@ -16,14 +22,78 @@ type SearchParams struct {
 	DateRangeStart           string             `json:"date_range_start,omitempty"`
 	DateRangeEnd             string             `json:"date_range_end,omitempty"`
 	Location                 string             `json:"location,omitempty"`
+	Regions                  []string           `json:"regions,omitempty"` // User locations compared by RegionSearch
+	SearchMode               string             `json:"search_mode,omitempty"`
+	RetryOnEmpty             *bool              `json:"retry_on_empty,omitempty"`
+	MinCitations             int                `json:"min_citations,omitempty"`
+	DeepSources              bool               `json:"deep_sources,omitempty"`
+	Confidence               string             `json:"confidence,omitempty"` // off, citations, or model; empty uses PERPLEXITY_CONFIDENCE
+	SeparateOpinions         *bool              `json:"separate_opinions,omitempty"`
+	ExtractTables            bool               `json:"extract_tables,omitempty"` // Save tables and numeric lists of the answer as CSV and JSON
+	Timezone                 string             `json:"timezone,omitempty"` // IANA zone for computed dates and answer times; empty uses PERPLEXITY_TIMEZONE
+	AnswerLanguage           string             `json:"answer_language,omitempty"`
+	TimeoutSeconds           int                `json:"timeout_seconds,omitempty"` // Replaces PERPLEXITY_TIMEOUT for this search's API calls
 
 	// Academic-specific parameters
 	SubjectArea              string             `json:"subject_area,omitempty"`
+	PeerReviewedOnly         bool               `json:"peer_reviewed_only,omitempty"`
+	IncludePreprints         bool               `json:"include_preprints,omitempty"` // Keep preprint servers when PeerReviewedOnly is set
 
 	// Financial-specific parameters
 	Ticker                   string             `json:"ticker,omitempty"`
 	CompanyName              string             `json:"company_name,omitempty"`
 	ReportType               string             `json:"report_type,omitempty"`
+	AssetClass               string             `json:"asset_class,omitempty"`
+	Event                    string             `json:"event,omitempty"`
+	EventDate                string             `json:"event_date,omitempty"` // Resolved date of Event, YYYY-MM-DD
+
+	// Patent-specific parameters
+	Inventor                 string             `json:"inventor,omitempty"`
+	Assignee                 string             `json:"assignee,omitempty"`
+	CPCClass                 string             `json:"cpc_class,omitempty"`
+	Jurisdiction             string             `json:"jurisdiction,omitempty"`
+	FilingDateStart          string             `json:"filing_date_start,omitempty"`
+	FilingDateEnd            string             `json:"filing_date_end,omitempty"`
+
+	// Legal-specific parameters (Jurisdiction is shared with patent search)
+	Court                    string             `json:"court,omitempty"`
+
+	// Medical-specific parameters
+	StudyType                string             `json:"study_type,omitempty"`
+	Population               string             `json:"population,omitempty"`
+
+	// Product-specific parameters
+	Category                 string             `json:"category,omitempty"`
+	Budget                   string             `json:"budget,omitempty"`
+	Region                   string             `json:"region,omitempty"`
+	MustHaveFeatures         []string           `json:"must_have_features,omitempty"`
+
+	// People and company research parameters (CompanyName is shared with financial search)
+	PersonName               string             `json:"person_name,omitempty"`
+	Role                     string             `json:"role,omitempty"`
+	CompanyDomain            string             `json:"company_domain,omitempty"`
+	Focus                    string             `json:"focus,omitempty"`
+
+	// Travel-specific parameters
+	Origin                   string             `json:"origin,omitempty"`
+	Destination              string             `json:"destination,omitempty"`
+	TravelDateStart          string             `json:"travel_date_start,omitempty"`
+	TravelDateEnd            string             `json:"travel_date_end,omitempty"`
+	Constraints              []string           `json:"constraints,omitempty"`
+
+	// Dev-specific parameters (the tool's language argument; Language below is a filtered-search filter)
+	ProgrammingLanguage      string             `json:"programming_language,omitempty"`
+	Framework                string             `json:"framework,omitempty"`
+	Version                  string             `json:"version,omitempty"`
+
+	// Security-specific parameters
+	CVEID                    string             `json:"cve_id,omitempty"`
+	Product                  string             `json:"product,omitempty"`
+	Severity                 string             `json:"severity,omitempty"` // Minimum severity: low, medium, high, or critical
+
+	// Release watch parameters
+	Project                  string             `json:"project,omitempty"`
+	Repo                     string             `json:"repo,omitempty"` // Normalized repository URL
 
 	// Filtered search parameters
 	ContentType              string             `json:"content_type,omitempty"`
@@ -31,6 +101,52 @@ type SearchParams struct {
 	Language                 string             `json:"language,omitempty"`
 	Country                  string             `json:"country,omitempty"`
 	CustomFilters            map[string]interface{} `json:"custom_filters,omitempty"`
+
+	// Grounding documents for context search
+	Documents                []Document         `json:"-"`
+
+	// Few-shot examples sent ahead of the query
+	Examples                 []types.Example    `json:"-"`
+
+	// Answer transforms applied before formatting, in order
+	Transforms               []string           `json:"-"`
+
+	// Cached result this one was derived from (translations, verifications)
+	SourceResultID           string             `json:"source_result_id,omitempty"`
+
+	// One-line summary used in completion notifications; derived from the answer when empty
+	Summary                  string             `json:"-"`
+
+	// Project whose results folder the result is cached in; empty for the default folder
+	CacheProject             string             `json:"cache_project,omitempty"`
+
+	// notes collects per-call annotations rendered in the metadata footer
+	notes []string
+
+	// logger is the calling request's logger, set by execute
+	logger *slog.Logger
+
+	// requestID identifies the tool call in logs, the footer, and the cached metadata
+	requestID string
+
+	// tableFiles are the CSV and JSON files of the tables extracted from a cached result
+	tableFiles []string
+
+	// timezoneNoted records that the call's time zone is already in the metadata footer
+	timezoneNoted bool
+}
+
+// addNote records an annotation shown in the result's metadata footer
+func (p *SearchParams) addNote(note string) {
+	p.notes = append(p.notes, note)
+}
+
+// log returns the request's logger, or the default logger for calls that never reached execute
+func (p *SearchParams) log() *slog.Logger {
+	if p.logger != nil {
+		return p.logger
+	}
+	return slog.Default()
 }
 
 // SearchResult represents a search operation result
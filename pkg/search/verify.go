@@ -0,0 +1,295 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"github.com/prasanthmj/perplexity/pkg/ratelimit"
+	"github.com/prasanthmj/perplexity/pkg/types"
+)
+
+// Verification verdicts
+const (
+	VerdictSupported    = "supported"
+	VerdictContradicted = "contradicted"
+	VerdictUnclear      = "unclear"
+)
+
+const (
+	// DefaultMaxClaims is the number of claims checked when the caller doesn't specify
+	DefaultMaxClaims = 5
+	// MaxClaims bounds the claims of one verification, each of which is its own API request
+	MaxClaims = 10
+	// maxVerifySources is the number of checking sources listed per claim
+	maxVerifySources = 3
+	minClaimLength   = 40
+	maxClaimLength   = 300
+)
+
+var (
+	sentenceSplitPattern = regexp.MustCompile(`(?m)([.!?])\s+|\n+`)
+	digitPattern         = regexp.MustCompile(`\d`)
+	properNounPattern    = regexp.MustCompile(`\s[A-Z][a-z]+`)
+)
+
+// claimCheck is the verification outcome for a single claim
+type claimCheck struct {
+	claim       string
+	verdict     string
+	explanation string
+	sources     []string
+	err         error
+}
+
+// VerifyResult extracts key claims from a cached result and checks each with a targeted search
+func (s *Searcher) VerifyResult(ctx context.Context, resultID string, maxClaims int) (string, error) {
+	if !cache.IsCachingEnabled(s.config().ResultsRootFolder) {
+		return "", fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable to enable caching")
+	}
+	if maxClaims <= 0 {
+		maxClaims = DefaultMaxClaims
+	}
+
+	content, err := s.readResult(resultID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get previous result: %w", err)
+	}
+
+	claims := extractClaims(answerBody(content), maxClaims)
+	if len(claims) == 0 {
+		return "", fmt.Errorf("no verifiable claims found in result '%s'", resultID)
+	}
+
+	// Verification searches are background work relative to interactive tool calls
+	batchCtx := ratelimit.WithPriority(ctx, ratelimit.PriorityBatch)
+
+	checks := make([]claimCheck, len(claims))
+	var wg sync.WaitGroup
+	for i, claim := range claims {
+		wg.Add(1)
+		go func(i int, claim string) {
+			defer wg.Done()
+			checks[i] = s.checkClaim(batchCtx, claim)
+		}(i, claim)
+	}
+	wg.Wait()
+
+	params := &SearchParams{
+		Query:          fmt.Sprintf("Verification of result %s", resultID),
+		SearchType:     "verification",
+		Model:          types.ModelSonar,
+		SourceResultID: resultID,
+		Summary:        verificationSummary(checks),
+	}
+	return s.saveWithCache(formatVerification(resultID, checks), params), nil
+}
+
+// checkClaim runs a single verification search and parses the verdict
+func (s *Searcher) checkClaim(ctx context.Context, claim string) claimCheck {
+	req := &types.PerplexityRequest{
+		Model: types.ModelSonar,
+		Messages: []types.Message{
+			{
+				Role: "user",
+				Content: "Verify the following claim against current sources. Start your reply with exactly one of " +
+					"SUPPORTED, CONTRADICTED, or UNCLEAR, then give a one-sentence explanation.\n\nClaim: " + claim,
+			},
+		},
+		MaxTokens:       256,
+		Temperature:     0,
+		ReturnCitations: true,
+	}
+
+	resp, err := s.callAnonymized(ctx, req)
+	if err != nil {
+		return claimCheck{claim: claim, verdict: VerdictUnclear, err: err}
+	}
+	if len(resp.Choices) == 0 {
+		return claimCheck{claim: claim, verdict: VerdictUnclear, explanation: "No response from verification search"}
+	}
+
+	answer := strings.TrimSpace(resp.Choices[0].Message.Content)
+	sources := resp.Citations
+	if len(sources) > maxVerifySources {
+		sources = sources[:maxVerifySources]
+	}
+
+	return claimCheck{
+		claim:       claim,
+		verdict:     parseVerdict(answer),
+		explanation: firstLine(stripVerdict(answer)),
+		sources:     sources,
+	}
+}
+
+// appendedSectionHeaders are the sections formatResponse adds after the answer
+var appendedSectionHeaders = []string{
+	"\n\n## Source URLs\n",
+	"\n\n## Citation Map\n",
+	"\n\n## Detailed Sources\n",
+	"\n\n## Images\n",
+	"\n\n## Related Questions\n",
+	"\n\n## Reasoning\n",
+	"\n\n## Papers\n",
+	"\n\n## Evidence Levels\n",
+	"\n\n## Advisories\n",
+	"\n\n## Extended Evidence\n",
+	"\n\n## Confidence\n",
+	"\n\n## Extracted Tables\n",
+	"\n\n## Search Metadata\n",
+}
+
+// answerBody returns the answer text between any outline and the appended source sections
+func answerBody(content string) string {
+	if strings.HasPrefix(content, outlineHeader) {
+		if i := strings.Index(content, outlineSeparator); i >= 0 {
+			content = content[i+len(outlineSeparator):]
+		}
+	}
+	content = strings.TrimPrefix(content, legalDisclaimer)
+
+	end := len(content)
+	for _, header := range appendedSectionHeaders {
+		if i := strings.Index(content, header); i >= 0 && i < end {
+			end = i
+		}
+	}
+	return content[:end]
+}
+
+// extractClaims picks the most fact-dense sentences from an answer
+func extractClaims(body string, maxClaims int) []string {
+	type scored struct {
+		text  string
+		score int
+		order int
+	}
+
+	var candidates []scored
+	for i, sentence := range splitSentences(body) {
+		sentence = strings.TrimSpace(citationMarkerPattern.ReplaceAllString(sentence, ""))
+		sentence = strings.TrimLeft(sentence, "-*# ")
+		if len(sentence) < minClaimLength || len(sentence) > maxClaimLength || strings.HasSuffix(sentence, "?") ||
+			strings.HasPrefix(sentence, confidenceNotePrefix) {
+			continue
+		}
+
+		score := 0
+		if digitPattern.MatchString(sentence) {
+			score += 2
+		}
+		score += len(properNounPattern.FindAllString(sentence, -1))
+		if score == 0 {
+			continue
+		}
+		candidates = append(candidates, scored{text: sentence, score: score, order: i})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > maxClaims {
+		candidates = candidates[:maxClaims]
+	}
+
+	// Present claims in their original order
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].order < candidates[j].order
+	})
+
+	claims := make([]string, len(candidates))
+	for i, c := range candidates {
+		claims[i] = c.text
+	}
+	return claims
+}
+
+// splitSentences splits text on sentence-ending punctuation and line breaks
+func splitSentences(text string) []string {
+	marked := sentenceSplitPattern.ReplaceAllString(text, "$1\x00")
+	return strings.Split(marked, "\x00")
+}
+
+// parseVerdict maps the leading verdict word of a verification answer
+func parseVerdict(answer string) string {
+	upper := strings.ToUpper(answer)
+	switch {
+	case strings.HasPrefix(upper, "SUPPORTED"):
+		return VerdictSupported
+	case strings.HasPrefix(upper, "CONTRADICTED"):
+		return VerdictContradicted
+	case strings.HasPrefix(upper, "UNCLEAR"):
+		return VerdictUnclear
+	case strings.Contains(upper, "CONTRADICTED"):
+		return VerdictContradicted
+	case strings.Contains(upper, "SUPPORTED") && !strings.Contains(upper, "UNSUPPORTED"):
+		return VerdictSupported
+	default:
+		return VerdictUnclear
+	}
+}
+
+// stripVerdict removes the leading verdict word and punctuation
+func stripVerdict(answer string) string {
+	for _, word := range []string{"SUPPORTED", "CONTRADICTED", "UNCLEAR"} {
+		if strings.HasPrefix(strings.ToUpper(answer), word) {
+			return strings.TrimLeft(answer[len(word):], ":.- \n")
+		}
+	}
+	return answer
+}
+
+// firstLine returns the first non-empty line of text
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// verificationSummary counts the verdicts, e.g. "3 claims checked: 2 supported, 1 contradicted, 0 unclear"
+func verificationSummary(checks []claimCheck) string {
+	counts := make(map[string]int)
+	for _, check := range checks {
+		counts[check.verdict]++
+	}
+	return fmt.Sprintf("%d claims checked: %d supported, %d contradicted, %d unclear",
+		len(checks), counts[VerdictSupported], counts[VerdictContradicted], counts[VerdictUnclear])
+}
+
+// formatVerification renders the claim table and per-claim explanations
+func formatVerification(resultID string, checks []claimCheck) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Verification of Result %s\n\n", resultID)
+	b.WriteString("| # | Claim | Status | Checking Sources |\n|---|---|---|---|\n")
+
+	for i, check := range checks {
+		sources := "—"
+		if len(check.sources) > 0 {
+			sources = strings.Join(check.sources, "<br>")
+		}
+		claim := strings.ReplaceAll(check.claim, "|", "\\|")
+		fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", i+1, claim, check.verdict, sources)
+	}
+
+	b.WriteString("\n## Notes\n")
+	for i, check := range checks {
+		switch {
+		case check.err != nil:
+			fmt.Fprintf(&b, "%d. Verification search failed: %v\n", i+1, check.err)
+		case check.explanation != "":
+			fmt.Fprintf(&b, "%d. %s\n", i+1, check.explanation)
+		default:
+			fmt.Fprintf(&b, "%d. No explanation returned\n", i+1)
+		}
+	}
+
+	return b.String()
+}
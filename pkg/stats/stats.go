@@ -0,0 +1,355 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// dateLayout keys the days of a stats file
+const dateLayout = "2006-01-02"
+
+// retentionDays is how long daily counts are kept before a flush drops them
+const retentionDays = 90
+
+// flushInterval is how often a Recorder writes its counts to the file
+const flushInterval = time.Minute
+
+// lockTimeout is how long a flush waits for another process's lock on the file; the counts
+// are kept for the next flush when it runs out
+const lockTimeout = 5 * time.Second
+
+// staleLockAge is when a lock file is taken as left behind by a process that exited while
+// holding it, since a flush holds the lock for milliseconds
+const staleLockAge = 30 * time.Second
+
+// Default is the recorder the server reports to; nil, the default, records nothing
+var Default *Recorder
+
+// File is the format of the stats file and of exports
+type File struct {
+	Version int             `json:"version"`
+	Days    map[string]*Day `json:"days"` // Keyed by date, YYYY-MM-DD
+}
+
+// Day holds the counts of one day
+type Day struct {
+	Tools  map[string]int `json:"tools,omitempty"`  // Tool calls by tool name
+	Models map[string]int `json:"models,omitempty"` // API requests by model
+	Errors map[string]int `json:"errors,omitempty"` // Failed tool calls by error type
+}
+
+// add adds the counts of other to d
+func (d *Day) add(other *Day) {
+	d.Tools = addCounts(d.Tools, other.Tools)
+	d.Models = addCounts(d.Models, other.Models)
+	d.Errors = addCounts(d.Errors, other.Errors)
+}
+
+func addCounts(dst, src map[string]int) map[string]int {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]int{}
+	}
+	for key, n := range src {
+		dst[key] += n
+	}
+	return dst
+}
+
+// Recorder counts tool calls, API requests by model, and failed calls by error type per day,
+// and adds them to a JSON file every minute and when it is closed. The counts never leave the
+// machine unless the user exports them. Recorders in several processes may share a file,
+// since each flush adds to the counts already in it while holding a lock file next to it.
+type Recorder struct {
+	path string
+	loc  *time.Location
+	now  func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*Day
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open starts recording to the file at path, counting days in loc (the local zone when nil).
+// An existing file must be a stats file.
+func Open(path string, loc *time.Location) (*Recorder, error) {
+	if _, err := Load(path); err != nil {
+		return nil, err
+	}
+	r := newRecorder(path, loc)
+	go r.flushLoop()
+	return r, nil
+}
+
+func newRecorder(path string, loc *time.Location) *Recorder {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Recorder{
+		path:    path,
+		loc:     loc,
+		now:     time.Now,
+		pending: map[string]*Day{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Call counts a tool call, and a failure of errorType when it is not empty. It does nothing
+// on a nil Recorder.
+func (r *Recorder) Call(tool, errorType string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	day := r.today()
+	day.Tools = addCounts(day.Tools, map[string]int{tool: 1})
+	if errorType != "" {
+		day.Errors = addCounts(day.Errors, map[string]int{errorType: 1})
+	}
+}
+
+// Model counts an API request made with model. It does nothing on a nil Recorder.
+func (r *Recorder) Model(model string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	day := r.today()
+	day.Models = addCounts(day.Models, map[string]int{model: 1})
+}
+
+// today returns the pending counts of the current day; r.mu must be held
+func (r *Recorder) today() *Day {
+	date := r.now().In(r.loc).Format(dateLayout)
+	day, ok := r.pending[date]
+	if !ok {
+		day = &Day{}
+		r.pending[date] = day
+	}
+	return day
+}
+
+// Flush adds the counts recorded since the last flush to the file, dropping days older than
+// the retention period. Counts that cannot be written are kept for the next flush.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = map[string]*Day{}
+	r.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := r.write(pending)
+	if err != nil {
+		r.mu.Lock()
+		for date, day := range r.pending {
+			if kept, ok := pending[date]; ok {
+				kept.add(day)
+			} else {
+				pending[date] = day
+			}
+		}
+		r.pending = pending
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// write merges pending into the file and replaces it atomically, holding the file's lock so
+// that a flush in another process cannot overwrite the merge
+func (r *Recorder) write(pending map[string]*Day) error {
+	unlock, err := lockFile(r.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	for date, day := range pending {
+		if existing, ok := file.Days[date]; ok {
+			existing.add(day)
+		} else {
+			file.Days[date] = day
+		}
+	}
+	cutoff := r.now().In(r.loc).AddDate(0, 0, -retentionDays).Format(dateLayout)
+	for date := range file.Days {
+		if date < cutoff {
+			delete(file.Days, date)
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".stats-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write usage stats: %w", err)
+	}
+	return nil
+}
+
+// lockFile takes the advisory lock of the file at path by creating path.lock, waiting up to
+// lockTimeout while another process holds it. A stale lock is removed. The returned function
+// releases the lock.
+func lockFile(path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock usage stats: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock usage stats: %s is held by another process", lockPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// flushLoop flushes every flushInterval until Close
+func (r *Recorder) flushLoop() {
+	defer close(r.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Flush()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Close stops the periodic flush and writes the remaining counts. It does nothing on a nil
+// Recorder.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	close(r.stop)
+	<-r.done
+	return r.Flush()
+}
+
+// Load reads a stats file; a file that does not exist yet has no days
+func Load(path string) (*File, error) {
+	file := &File{Version: 1, Days: map[string]*Day{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage stats: %w", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("invalid usage stats file %s: %w", path, err)
+	}
+	if file.Days == nil {
+		file.Days = map[string]*Day{}
+	}
+	return file, nil
+}
+
+// Total sums the counts of the days from start to end, both dates inclusive
+func (f *File) Total(start, end time.Time) *Day {
+	first, last := start.Format(dateLayout), end.Format(dateLayout)
+	total := &Day{}
+	for date, day := range f.Days {
+		if date >= first && date <= last {
+			total.add(day)
+		}
+	}
+	return total
+}
+
+// WriteSummary writes a plain-text summary of the days days up to and including now's date,
+// with the change in calls from the days before them
+func WriteSummary(w io.Writer, f *File, now time.Time, days int) {
+	start := now.AddDate(0, 0, -(days - 1))
+	total := f.Total(start, now)
+	previous := f.Total(start.AddDate(0, 0, -days), start.AddDate(0, 0, -1))
+
+	calls, failed := sumCounts(total.Tools), sumCounts(total.Errors)
+	fmt.Fprintf(w, "Usage from %s to %s\n\n", start.Format(dateLayout), now.Format(dateLayout))
+	fmt.Fprintf(w, "Tool calls: %d", calls)
+	if calls > 0 {
+		fmt.Fprintf(w, " (%d failed, %.1f%%)", failed, 100*float64(failed)/float64(calls))
+	}
+	fmt.Fprintf(w, "\nPrevious %d days: %d\n", days, sumCounts(previous.Tools))
+
+	writeCounts(w, "Tools", total.Tools)
+	writeCounts(w, "API requests by model", total.Models)
+	writeCounts(w, "Errors", total.Errors)
+}
+
+// writeCounts writes a titled list of counts, largest first, or nothing when there are none
+func writeCounts(w io.Writer, title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(counts))
+	width := 0
+	for key := range counts {
+		keys = append(keys, key)
+		width = max(width, len(key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %-*s  %d\n", width, key, counts[key])
+	}
+}
+
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
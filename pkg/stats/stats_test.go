@@ -0,0 +1,157 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorderFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	now := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC)
+	r := newRecorder(path, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Call("perplexity_search", "")
+	r.Call("perplexity_search", "rate_limit")
+	r.Model("sonar")
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// A second recorder sharing the file adds to its counts
+	other := newRecorder(path, time.UTC)
+	other.now = func() time.Time { return now.Add(time.Hour) }
+	other.Call("perplexity_search", "")
+	other.Model("sonar-pro")
+	if err := other.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	day := file.Days["2026-03-10"]
+	if day == nil || day.Tools["perplexity_search"] != 2 || day.Errors["rate_limit"] != 1 || day.Models["sonar"] != 1 {
+		t.Errorf("Unexpected counts for the first day: %+v", day)
+	}
+	if next := file.Days["2026-03-11"]; next == nil || next.Tools["perplexity_search"] != 1 || next.Models["sonar-pro"] != 1 {
+		t.Errorf("Expected calls after midnight counted on the next day, got %+v", next)
+	}
+
+	// Days past the retention period are dropped
+	later := newRecorder(path, time.UTC)
+	later.now = func() time.Time { return now.AddDate(0, 0, retentionDays+1) }
+	later.Call("perplexity_search", "")
+	later.Flush()
+	if file, _ := Load(path); file.Days["2026-03-10"] != nil || file.Days["2026-03-11"] == nil {
+		t.Errorf("Expected only days within the retention period kept, got %v", file.Days)
+	}
+}
+
+func TestConcurrentFlushes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// Recorders flushing at once, as in several processes, each add their counts
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		r := newRecorder(path, time.UTC)
+		r.now = func() time.Time { return now }
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				r.Call("perplexity_search", "")
+				if err := r.Flush(); err != nil {
+					t.Errorf("Flush failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := file.Days["2026-03-10"].Tools["perplexity_search"]; got != 40 {
+		t.Errorf("Expected 40 calls counted, got %d", got)
+	}
+
+	// A lock left behind by a process that exited is taken over
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	stale := time.Now().Add(-2 * staleLockAge)
+	os.Chtimes(lockPath, stale, stale)
+	r := newRecorder(path, time.UTC)
+	r.Call("perplexity_search", "")
+	if err := r.Flush(); err != nil {
+		t.Errorf("Expected a stale lock removed, got %v", err)
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock released after the flush, got %v", err)
+	}
+}
+
+func TestRecorderKeepsCountsOnFailedFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := newRecorder(path, time.UTC)
+	r.Call("perplexity_search", "")
+	if err := r.Flush(); err == nil {
+		t.Fatal("Expected an error flushing to a corrupt file")
+	}
+
+	os.Remove(path)
+	r.Call("perplexity_search", "")
+	if err := r.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	file, _ := Load(path)
+	if total := file.Total(time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 1)); total.Tools["perplexity_search"] != 2 {
+		t.Errorf("Expected the unwritten call kept for the next flush, got %+v", total)
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Call("perplexity_search", "")
+	r.Model("sonar")
+	if err := r.Close(); err != nil {
+		t.Errorf("Expected Close on a nil recorder to do nothing, got %v", err)
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	file := &File{Days: map[string]*Day{
+		"2026-03-02": {Tools: map[string]int{"perplexity_search": 4}},
+		"2026-03-09": {Tools: map[string]int{"perplexity_search": 6, "perplexity_academic_search": 2}, Models: map[string]int{"sonar": 7, "sonar-pro": 1}, Errors: map[string]int{"timeout": 2}},
+		"2026-03-10": {Tools: map[string]int{"perplexity_academic_search": 2}},
+		"2026-03-11": {Tools: map[string]int{"perplexity_search": 50}},
+	}}
+
+	var b strings.Builder
+	WriteSummary(&b, file, time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), 7)
+	summary := b.String()
+
+	for _, want := range []string{
+		"Usage from 2026-03-04 to 2026-03-10",
+		"Tool calls: 10 (2 failed, 20.0%)",
+		"Previous 7 days: 4",
+		"  perplexity_search           6\n  perplexity_academic_search  4\n",
+		"  sonar      7\n  sonar-pro  1\n",
+		"Errors:\n  timeout  2\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected %q in the summary:\n%s", want, summary)
+		}
+	}
+}
@@ -2,8 +2,14 @@ package types
 
 // Model constants
 const (
-	ModelSonar    = "sonar"
-	ModelSonarPro = "sonar-pro"
+	ModelSonar             = "sonar"
+	ModelSonarPro          = "sonar-pro"
+	ModelSonarReasoning    = "sonar-reasoning"
+	ModelSonarReasoningPro = "sonar-reasoning-pro"
+	ModelSonarDeepResearch = "sonar-deep-research"
+
+	// ModelAuto is a pseudo-model resolved locally from the query
+	ModelAuto = "auto"
 )
 
 // Recency filter constants
@@ -15,6 +21,13 @@ const (
 	RecencyYear  = "year"
 )
 
+// Search mode constants
+const (
+	SearchModeWeb      = "web"
+	SearchModeAcademic = "academic"
+	SearchModeSEC      = "sec"
+)
+
 // Default values
 const (
 	DefaultModel           = ModelSonar
@@ -24,7 +37,7 @@ const (
 	DefaultTopK            = 0
 	DefaultReturnImages    = false
 	DefaultReturnRelated   = false
-	DefaultSearchMode      = "web"
+	DefaultSearchMode      = SearchModeWeb
 	DefaultContextSize     = 5
 )
 
@@ -34,6 +47,12 @@ type Message struct {
 	Content string `json:"content"`
 }
 
+// Example is a few-shot question/answer pair sent ahead of the user's query
+type Example struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
 // PerplexityRequest represents the request to Perplexity API
 type PerplexityRequest struct {
 	Model                    string   `json:"model"`
@@ -70,6 +89,15 @@ type PerplexityResponse struct {
 	Citations         []string   `json:"citations,omitempty"`
 	SearchResults     []SearchResult `json:"search_results,omitempty"`
 	RelatedQuestions  []string   `json:"related_questions,omitempty"`
+	Images            []Image    `json:"images,omitempty"`
+}
+
+// Image is a picture returned when return_images is set
+type Image struct {
+	ImageURL  string `json:"image_url"`
+	OriginURL string `json:"origin_url,omitempty"`
+	Height    int    `json:"height,omitempty"`
+	Width     int    `json:"width,omitempty"`
 }
 
 // Choice represents a response choice
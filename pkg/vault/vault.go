@@ -0,0 +1,289 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// EntitiesFolder and KeywordsFolder hold the hub notes that link results sharing a tag
+	EntitiesFolder = "Entities"
+	KeywordsFolder = "Keywords"
+	// maxRelated caps the related-results list on each note
+	maxRelated = 10
+	// maxTitleLength caps the query portion of a note file name
+	maxTitleLength = 80
+)
+
+// Report summarizes an export run
+type Report struct {
+	Results  int
+	Entities int
+	Keywords int
+}
+
+// note is a cached result prepared for export
+type note struct {
+	id       string
+	name     string
+	metadata *cache.QueryMetadata
+	content  string
+}
+
+// frontmatter is the YAML header written at the top of each result note
+type frontmatter struct {
+	ID         string   `yaml:"id"`
+	Query      string   `yaml:"query"`
+	SearchType string   `yaml:"search_type"`
+	Model      string   `yaml:"model"`
+	Created    string   `yaml:"created"`
+	Tags       []string `yaml:"tags,omitempty"`
+	Entities   []string `yaml:"entities,omitempty"`
+	Source     string   `yaml:"source,omitempty"`
+	Aliases    []string `yaml:"aliases,omitempty"`
+}
+
+// Export converts every cached result under rootFolder into interlinked markdown
+// notes in outDir, in a layout readable by both Obsidian and Logseq
+func Export(rootFolder, outDir string) (*Report, error) {
+	if !cache.IsCachingEnabled(rootFolder) {
+		return nil, fmt.Errorf("results caching is not enabled. Set PERPLEXITY_RESULTS_ROOT_FOLDER environment variable")
+	}
+	if outDir == "" {
+		return nil, fmt.Errorf("vault directory is required")
+	}
+
+	items, err := cache.ListPreviousQueries(rootFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached results: %w", err)
+	}
+
+	notes := make([]*note, 0, len(items))
+	byID := make(map[string]*note, len(items))
+	for _, item := range items {
+		metadata, err := cache.GetMetadata(rootFolder, item.UniqueID)
+		if err != nil {
+			continue
+		}
+		content, err := cache.GetPreviousResult(rootFolder, item.UniqueID)
+		if err != nil {
+			continue
+		}
+		n := &note{
+			id:       item.UniqueID,
+			name:     noteName(metadata.Query, item.UniqueID),
+			metadata: metadata,
+			content:  content,
+		}
+		notes = append(notes, n)
+		byID[n.id] = n
+	}
+
+	entities := groupBy(notes, func(n *note) []string { return n.metadata.Entities })
+	keywords := groupBy(notes, func(n *note) []string { return n.metadata.Keywords })
+
+	for _, folder := range []string{outDir, filepath.Join(outDir, EntitiesFolder), filepath.Join(outDir, KeywordsFolder)} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create vault folder: %w", err)
+		}
+	}
+
+	for _, n := range notes {
+		body, err := renderNote(n, byID, entities, keywords)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(outDir, n.name+".md"), []byte(body), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write note %s: %w", n.id, err)
+		}
+	}
+
+	if err := writeHubNotes(filepath.Join(outDir, EntitiesFolder), "Entity", entities); err != nil {
+		return nil, err
+	}
+	if err := writeHubNotes(filepath.Join(outDir, KeywordsFolder), "Keyword", keywords); err != nil {
+		return nil, err
+	}
+
+	return &Report{Results: len(notes), Entities: len(entities), Keywords: len(keywords)}, nil
+}
+
+// renderNote builds the frontmatter and body of a result note
+func renderNote(n *note, byID map[string]*note, entities, keywords map[string][]*note) (string, error) {
+	meta := n.metadata
+	fm := frontmatter{
+		ID:         n.id,
+		Query:      meta.Query,
+		SearchType: meta.SearchType,
+		Model:      meta.Model,
+		Created:    meta.Timestamp.Format(time.RFC3339),
+		Tags:       tagNames(meta.Keywords),
+		Entities:   meta.Entities,
+		Aliases:    []string{meta.Query},
+	}
+	source := sourceID(meta)
+	if parent, ok := byID[source]; ok {
+		fm.Source = "[[" + parent.name + "]]"
+	}
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal frontmatter for %s: %w", n.id, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.Write(header)
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", meta.Query)
+	b.WriteString(strings.TrimRight(n.content, "\n"))
+	b.WriteString("\n\n## Links\n\n")
+
+	if fm.Source != "" {
+		fmt.Fprintf(&b, "- **Derived from:** %s\n", fm.Source)
+	}
+	if len(meta.Entities) > 0 {
+		fmt.Fprintf(&b, "- **Entities:** %s\n", joinLinks(EntitiesFolder, meta.Entities))
+	}
+	if len(meta.Keywords) > 0 {
+		fmt.Fprintf(&b, "- **Keywords:** %s\n", joinLinks(KeywordsFolder, meta.Keywords))
+	}
+
+	if related := relatedNotes(n, entities, keywords); len(related) > 0 {
+		b.WriteString("\n## Related Results\n\n")
+		for _, r := range related {
+			fmt.Fprintf(&b, "- [[%s]]\n", r.name)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// writeHubNotes writes one note per tag listing every result that carries it,
+// so the tag shows up as a shared node in the vault graph
+func writeHubNotes(folder, kind string, groups map[string][]*note) error {
+	for tag, members := range groups {
+		var b strings.Builder
+		fmt.Fprintf(&b, "---\ntype: %s\n---\n\n", strings.ToLower(kind))
+		fmt.Fprintf(&b, "# %s\n\n", tag)
+		fmt.Fprintf(&b, "%s mentioned in %d cached result(s):\n\n", kind, len(members))
+		for _, n := range members {
+			fmt.Fprintf(&b, "- [[%s]] (%s)\n", n.name, n.metadata.Timestamp.Format("2006-01-02"))
+		}
+		path := filepath.Join(folder, sanitizeName(tag)+".md")
+		if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write %s note %q: %w", strings.ToLower(kind), tag, err)
+		}
+	}
+	return nil
+}
+
+// groupBy indexes notes by each tag returned from tags, keeping notes in export order
+func groupBy(notes []*note, tags func(*note) []string) map[string][]*note {
+	groups := make(map[string][]*note)
+	for _, n := range notes {
+		for _, tag := range tags(n) {
+			if sanitizeName(tag) == "" {
+				continue
+			}
+			groups[tag] = append(groups[tag], n)
+		}
+	}
+	return groups
+}
+
+// relatedNotes ranks other notes by the tags they share with n, weighting shared
+// entities above shared keywords
+func relatedNotes(n *note, entities, keywords map[string][]*note) []*note {
+	shared := make(map[*note]int)
+	for _, tag := range n.metadata.Entities {
+		for _, other := range entities[tag] {
+			shared[other] += 2
+		}
+	}
+	for _, tag := range n.metadata.Keywords {
+		for _, other := range keywords[tag] {
+			shared[other]++
+		}
+	}
+	delete(shared, n)
+
+	related := make([]*note, 0, len(shared))
+	for other := range shared {
+		related = append(related, other)
+	}
+	sort.Slice(related, func(i, j int) bool {
+		if shared[related[i]] != shared[related[j]] {
+			return shared[related[i]] > shared[related[j]]
+		}
+		return related[i].metadata.Timestamp.After(related[j].metadata.Timestamp)
+	})
+	if len(related) > maxRelated {
+		related = related[:maxRelated]
+	}
+	return related
+}
+
+// sourceID returns the ID of the result this one was derived from, if any
+func sourceID(meta *cache.QueryMetadata) string {
+	if id, ok := meta.Parameters["source_result_id"].(string); ok {
+		return id
+	}
+	return ""
+}
+
+// noteName builds a readable, unique note name from the query and result ID
+func noteName(query, id string) string {
+	title := sanitizeName(query)
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength]))
+	}
+	if title == "" {
+		return id
+	}
+	return fmt.Sprintf("%s (%s)", title, id)
+}
+
+// sanitizeName removes characters that are invalid in file names or break wiki-links
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '#', '^', '[', ']':
+			return -1
+		case '\n', '\r', '\t':
+			return ' '
+		}
+		return r
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// tagNames converts keywords to tag form, which may not contain spaces
+func tagNames(keywords []string) []string {
+	var tags []string
+	for _, keyword := range keywords {
+		if tag := strings.ReplaceAll(sanitizeName(keyword), " ", "-"); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// joinLinks formats names as a comma-separated list of wiki-links to hub notes in
+// folder; the folder prefix keeps an entity and a keyword with the same name apart
+func joinLinks(folder string, names []string) string {
+	links := make([]string, 0, len(names))
+	for _, name := range names {
+		if link := sanitizeName(name); link != "" {
+			links = append(links, "[["+folder+"/"+link+"|"+link+"]]")
+		}
+	}
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prasanthmj/perplexity/pkg/cache"
+)
+
+func TestNoteName(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"What is Go?", "What is Go (ABCDE12345)"},
+		{"a/b: c|d [x]", "ab cd x (ABCDE12345)"},
+		{"  \n ", "ABCDE12345"},
+		{strings.Repeat("x", 100), strings.Repeat("x", maxTitleLength) + " (ABCDE12345)"},
+	}
+	for _, tt := range tests {
+		if got := noteName(tt.query, "ABCDE12345"); got != tt.want {
+			t.Errorf("noteName(%q) mismatch: got %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(t.TempDir(), "vault")
+
+	first, err := cache.SaveResult(root, "nvidia earnings", "financial", "sonar", "Nvidia reported record revenue.", nil)
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	if err := cache.TagResult(root, first, []string{"revenue"}, []string{"Nvidia"}); err != nil {
+		t.Fatalf("TagResult failed: %v", err)
+	}
+	second, err := cache.SaveResult(root, "nvidia earnings in French", "translation", "sonar", "Nvidia a annoncé...", map[string]interface{}{"source_result_id": first})
+	if err != nil {
+		t.Fatalf("SaveResult failed: %v", err)
+	}
+	if err := cache.TagResult(root, second, nil, []string{"Nvidia"}); err != nil {
+		t.Fatalf("TagResult failed: %v", err)
+	}
+
+	report, err := Export(root, out)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if report.Results != 2 || report.Entities != 1 || report.Keywords != 1 {
+		t.Errorf("Report mismatch: got %+v, want 2 results, 1 entity, 1 keyword", *report)
+	}
+
+	firstName := noteName("nvidia earnings", first)
+	secondName := noteName("nvidia earnings in French", second)
+
+	data, err := os.ReadFile(filepath.Join(out, secondName+".md"))
+	if err != nil {
+		t.Fatalf("Failed to read note: %v", err)
+	}
+	note := string(data)
+	expected := []string{
+		"---\nid: " + second + "\n",
+		"source: '[[" + firstName + "]]'",
+		"# nvidia earnings in French",
+		"- **Entities:** [[Entities/Nvidia|Nvidia]]",
+		"## Related Results\n\n- [[" + firstName + "]]",
+	}
+	for _, want := range expected {
+		if !strings.Contains(note, want) {
+			t.Errorf("Note missing %q:\n%s", want, note)
+		}
+	}
+
+	hub, err := os.ReadFile(filepath.Join(out, EntitiesFolder, "Nvidia.md"))
+	if err != nil {
+		t.Fatalf("Failed to read entity note: %v", err)
+	}
+	for _, name := range []string{firstName, secondName} {
+		if !strings.Contains(string(hub), "[["+name+"]]") {
+			t.Errorf("Entity note missing link to %q:\n%s", name, hub)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, KeywordsFolder, "revenue.md")); err != nil {
+		t.Errorf("Keyword note not written: %v", err)
+	}
+}
+
+func TestExportRequiresCache(t *testing.T) {
+	if _, err := Export("", t.TempDir()); err == nil {
+		t.Error("Expected error when caching is disabled")
+	}
+}
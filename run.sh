@@ -23,10 +23,26 @@ function show_usage() {
     echo "  filtered <query> [model]      Test filtered search"
     echo "  list                          List previous cached queries"
     echo "  get <result_id>               Get cached result by unique ID"
+    echo "  verify-audit                  Verify the audit hash chain of cached results"
+    echo "  cache migrate                 Upgrade cached results to the current metadata schema"
+    echo "  cache export <file> [flags]   Bundle cached results into a tar.gz (-ids A,B / -tags x,y to select)"
+    echo "  cache import <file>           Unpack a bundle made by cache export into the cache"
+    echo "  export-vault <dir>            Export cached results as an Obsidian/Logseq vault"
+    echo "  send-digest <daily|weekly>    Email a digest of watched queries (prints it if no recipients)"
+    echo "  release-watch <projects>      Check comma-separated projects or owner/name repos for new releases"
+    echo "  eval <spec.json>              Compare two prompt or argument variants on fixture queries"
+    echo "  credentials <set|delete>      Store the API key in the OS credential store, or remove it"
+    echo "  stats                         Print a summary of the past week's local usage stats"
+    echo "  stats-export <file.json>      Write the recorded usage stats to a JSON file"
     echo ""
     echo "Integration Testing:"
     echo "  integration-test              Run integration tests against real API"
     echo ""
+    echo "Benchmarks:"
+    echo "  bench                         Run the benchmark suite"
+    echo "  bench-baseline                Run the benchmarks and store them as the baseline"
+    echo "  bench-compare                 Run the benchmarks and fail on regressions against the baseline"
+    echo ""
     echo "Examples:"
     echo "  ./run.sh search 'latest AI news' sonar-pro"
     echo "  ./run.sh academic 'quantum computing' sonar-pro"
@@ -37,6 +53,15 @@ function show_usage() {
     exit 1
 }
 
+# Benchmark settings; BENCH_THRESHOLD is the slowdown, in percent, that fails bench-compare
+BENCH_BASELINE="bench/baseline.txt"
+BENCH_COUNT="${BENCH_COUNT:-5}"
+BENCH_THRESHOLD="${BENCH_THRESHOLD:-20}"
+
+function run_benchmarks() {
+    go test -run '^$' -bench . -benchmem -count "$BENCH_COUNT" ./pkg/...
+}
+
 # Handle different commands
 case "$1" in
     build)
@@ -116,11 +141,119 @@ case "$1" in
         go run ./cmd -get "$2"
         ;;
     
+    verify-audit)
+        echo "Verifying audit hash chain..."
+        go run ./cmd -verify-audit
+        ;;
+    
+    cache)
+        case "$2" in
+            migrate)
+                echo "Migrating cached results..."
+                go run ./cmd -cache-migrate
+                ;;
+            export)
+                if [ -z "$3" ]; then
+                    echo "Usage: ./run.sh cache export <file.tar.gz> [-ids A1B2C3D4E5,...] [-tags tag,...]"
+                    exit 1
+                fi
+                go run ./cmd "${@:4}" -cache-export "$3"
+                ;;
+            import)
+                if [ -z "$3" ]; then
+                    echo "Usage: ./run.sh cache import <file.tar.gz>"
+                    exit 1
+                fi
+                go run ./cmd -cache-import "$3"
+                ;;
+            *)
+                echo "Usage: ./run.sh cache <migrate|export|import>"
+                exit 1
+                ;;
+        esac
+        ;;
+    
+    send-digest)
+        if [ -z "$2" ]; then
+            echo "Usage: ./run.sh send-digest <daily|weekly>"
+            exit 1
+        fi
+        go run ./cmd -send-digest "$2"
+        ;;
+    
+    release-watch)
+        if [ -z "$2" ]; then
+            echo "Usage: ./run.sh release-watch <project|owner/name>[,...]"
+            exit 1
+        fi
+        go run ./cmd -release-watch "$2"
+        ;;
+    
+    eval)
+        if [ -z "$2" ]; then
+            echo "Usage: ./run.sh eval <spec.json>"
+            exit 1
+        fi
+        go run ./cmd -eval "$2"
+        ;;
+    
+    credentials)
+        if [ "$2" != "set" ] && [ "$2" != "delete" ]; then
+            echo "Usage: ./run.sh credentials <set|delete>"
+            exit 1
+        fi
+        go run ./cmd credentials "$2"
+        ;;
+    
+    stats)
+        go run ./cmd -stats
+        ;;
+    
+    stats-export)
+        if [ -z "$2" ]; then
+            echo "Usage: ./run.sh stats-export <file.json>"
+            exit 1
+        fi
+        go run ./cmd -stats-export "$2"
+        ;;
+    
+    export-vault)
+        if [ -z "$2" ]; then
+            echo "Usage: ./run.sh export-vault <dir>"
+            exit 1
+        fi
+        echo "Exporting cached results to vault: '$2'"
+        go run ./cmd -export-vault "$2"
+        ;;
+    
     integration-test)
         echo "Running integration tests against real API..."
         go run ./cmd -test
         ;;
     
+    bench)
+        echo "Running benchmarks..."
+        run_benchmarks
+        ;;
+    
+    bench-baseline)
+        echo "Recording benchmark baseline in $BENCH_BASELINE..."
+        run_benchmarks > "$BENCH_BASELINE" || { cat "$BENCH_BASELINE"; exit 1; }
+        cat "$BENCH_BASELINE"
+        ;;
+    
+    bench-compare)
+        if [ ! -f "$BENCH_BASELINE" ]; then
+            echo "No baseline at $BENCH_BASELINE; record one with ./run.sh bench-baseline"
+            exit 1
+        fi
+        echo "Comparing benchmarks against $BENCH_BASELINE (threshold ${BENCH_THRESHOLD}%)..."
+        current=$(mktemp)
+        trap 'rm -f "$current"' EXIT
+        run_benchmarks > "$current" || { cat "$current"; exit 1; }
+        awk -v threshold="$BENCH_THRESHOLD" -f bench/compare.awk "$BENCH_BASELINE" "$current"
+        ;;
+    
     run)
         echo "Running perplexity MCP server..."
         go run ./cmd